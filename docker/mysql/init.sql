@@ -9,13 +9,33 @@ CREATE TABLE IF NOT EXISTS raw_texts (
     content TEXT NOT NULL,
     source VARCHAR(100) NOT NULL,
     timestamp BIGINT NOT NULL,
-    metadata JSON,
+    url VARCHAR(768),
+    author VARCHAR(255),
+    platform VARCHAR(64),
+    type VARCHAR(64),
+    metadata JSON COMMENT '未提升为独立列的其余 Metadata 键',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     INDEX idx_source (source),
     INDEX idx_timestamp (timestamp),
-    INDEX idx_created_at (created_at)
+    INDEX idx_created_at (created_at),
+    INDEX idx_raw_texts_created_at_id (created_at, id),
+    INDEX idx_raw_texts_url (url),
+    INDEX idx_raw_texts_author (author),
+    INDEX idx_raw_texts_platform (platform),
+    INDEX idx_raw_texts_type (type)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- Metadata 中的 url、author、platform、type 保存在上面的独立索引列中。
+-- 已有数据库升级时 data-collector 启动时自动添加这些列，并将旧记录 metadata 中的对应键迁移到列中，
+-- 按主键范围每批 1000 条，对每个键执行：
+-- UPDATE raw_texts SET platform = metadata->>'$.platform', metadata = JSON_REMOVE(metadata, '$.platform')
+--     WHERE id > ? AND id <= ? AND metadata->>'$.platform' IS NOT NULL AND LENGTH(metadata->>'$.platform') <= 64;
+-- 迁移只执行一次，完成后写入 system_configs 的 migration.raw_text_metadata 标记；导出命令不执行迁移。
+-- 表较大时建议在低峰期先手动执行上述语句，再写入该标记。按其余 meta.<key> 筛选的常用键仍可建立生成列索引，例如：
+-- ALTER TABLE raw_texts
+--     ADD COLUMN meta_tag VARCHAR(64) GENERATED ALWAYS AS (metadata->>'$.tag') VIRTUAL,
+--     ADD INDEX idx_raw_texts_meta_tag (meta_tag);
+
 -- 预处理文本表
 CREATE TABLE IF NOT EXISTS processed_texts (
     id VARCHAR(36) PRIMARY KEY,
@@ -111,6 +131,26 @@ CREATE TABLE IF NOT EXISTS collection_tasks (
     INDEX idx_created_at (created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- 定时采集计划表
+CREATE TABLE IF NOT EXISTS collection_schedules (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(100),
+    cron_expr VARCHAR(100) NOT NULL COMMENT '标准5段cron表达式',
+    source_type VARCHAR(20) NOT NULL COMMENT 'api, web_crawler, local_file',
+    source_url VARCHAR(1000),
+    source_file_path VARCHAR(500),
+    parameters JSON,
+    config JSON NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    last_run_at TIMESTAMP NULL,
+    next_run_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_source_type (source_type),
+    INDEX idx_enabled (enabled),
+    INDEX idx_next_run_at (next_run_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- 停用词表
 CREATE TABLE IF NOT EXISTS stop_words (
     id INT AUTO_INCREMENT PRIMARY KEY,
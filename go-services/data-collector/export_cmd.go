@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/export"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+)
+
+// 导出子命令名
+const (
+	exportHFCommand       = "export-hf"
+	exportFeaturesCommand = "export-features"
+)
+
+// bindFilterFlags 注册按来源与标签筛选的参数，返回的函数在解析参数后填充 filter
+func bindFilterFlags(flags *flag.FlagSet, filter *repository.ProcessedTextFilter) func() error {
+	flags.StringVar(&filter.Source, "source", "", "only export texts from this source")
+	label := flags.String("label", "", "only export texts with this label")
+	return func() error {
+		if *label == "" {
+			return nil
+		}
+		value, err := strconv.Atoi(*label)
+		if err != nil {
+			return fmt.Errorf("invalid -label %q: %w", *label, err)
+		}
+		filter.Label = &value
+		return nil
+	}
+}
+
+// parseExportFlags 解析 export-hf 子命令参数，返回输出目录与导出参数
+func parseExportFlags(args []string) (string, export.HuggingFaceOptions, error) {
+	var opts export.HuggingFaceOptions
+	flags := flag.NewFlagSet(exportHFCommand, flag.ContinueOnError)
+	out := flags.String("out", "", "output directory for <split>.jsonl and dataset_infos.json")
+	parseFilter := bindFilterFlags(flags, &opts.Filter)
+	split := flags.String("split", "0.8,0.1,0.1", "train,validation,test ratios")
+	flags.IntVar(&opts.BatchSize, "batch", 500, "rows read from the database per query")
+	if err := flags.Parse(args); err != nil {
+		return "", opts, err
+	}
+
+	if *out == "" {
+		return "", opts, fmt.Errorf("-out is required")
+	}
+	if err := parseFilter(); err != nil {
+		return "", opts, err
+	}
+	splits, err := export.ParseSplits(*split)
+	if err != nil {
+		return "", opts, err
+	}
+	opts.Splits = splits
+	return *out, opts, nil
+}
+
+// parseFeatureExportFlags 解析 export-features 子命令参数，返回输出文件与导出参数
+func parseFeatureExportFlags(args []string) (string, export.FeatureOptions, error) {
+	var opts export.FeatureOptions
+	flags := flag.NewFlagSet(exportFeaturesCommand, flag.ContinueOnError)
+	out := flags.String("out", "", "output file")
+	parseFilter := bindFilterFlags(flags, &opts.Filter)
+	format := flags.String("format", string(export.FormatLibSVM), "libsvm or tfrecord")
+	flags.IntVar(&opts.BatchSize, "batch", 500, "rows read from the database per query")
+	if err := flags.Parse(args); err != nil {
+		return "", opts, err
+	}
+
+	if *out == "" {
+		return "", opts, fmt.Errorf("-out is required")
+	}
+	if err := parseFilter(); err != nil {
+		return "", opts, err
+	}
+	parsed, err := export.ParseFeatureFormat(*format)
+	if err != nil {
+		return "", opts, err
+	}
+	opts.Format = parsed
+	return *out, opts, nil
+}
+
+// runExportCommand 执行导出子命令后返回
+func runExportCommand(ctx context.Context, cfg *config.Config, command string, args []string, logger *logrus.Entry) error {
+	var out string
+	var hfOpts export.HuggingFaceOptions
+	var featureOpts export.FeatureOptions
+	var err error
+	if command == exportFeaturesCommand {
+		out, featureOpts, err = parseFeatureExportFlags(args)
+	} else {
+		out, hfOpts, err = parseExportFlags(args)
+	}
+	if err != nil {
+		return err
+	}
+	repo, err := repository.NewMySQLRepository(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	if command == exportHFCommand {
+		result, err := export.ExportHuggingFace(ctx, repo, out, hfOpts)
+		if err != nil {
+			return err
+		}
+		logger.WithFields(logrus.Fields{
+			"dir":    out,
+			"counts": result.Counts,
+		}).Info("HuggingFace dataset exported")
+		return nil
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	defer file.Close()
+	result, err := export.ExportFeatures(ctx, repo, file, featureOpts)
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	for _, mismatch := range result.Mismatches {
+		logger.WithFields(logrus.Fields{
+			"id":        mismatch.ID,
+			"length":    mismatch.Length,
+			"dimension": result.Dimension,
+		}).Warn("Skipped row with inconsistent feature length")
+	}
+	logger.WithFields(logrus.Fields{
+		"file":       out,
+		"format":     featureOpts.Format,
+		"rows":       result.Rows,
+		"dimension":  result.Dimension,
+		"unlabeled":  result.Unlabeled,
+		"mismatches": result.MismatchCount,
+	}).Info("Features exported")
+	return nil
+}
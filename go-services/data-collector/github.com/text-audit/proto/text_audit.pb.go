@@ -143,6 +143,12 @@ const (
 	SourceType_API         SourceType = 0 // API接口
 	SourceType_WEB_CRAWLER SourceType = 1 // 网页爬虫
 	SourceType_LOCAL_FILE  SourceType = 2 // 本地文件
+	SourceType_BILIBILI    SourceType = 3 // 哔哩哔哩评论/弹幕
+	SourceType_DATABASE    SourceType = 4 // 外部数据库表
+	SourceType_BROWSER     SourceType = 5 // 无头浏览器渲染的网页
+	SourceType_GRAPHQL     SourceType = 6 // GraphQL 接口
+	SourceType_TWITTER     SourceType = 7 // Twitter/X 最近推文搜索
+	SourceType_REDDIT      SourceType = 8 // Reddit 版块帖子与评论
 )
 
 // Enum value maps for SourceType.
@@ -151,11 +157,23 @@ var (
 		0: "API",
 		1: "WEB_CRAWLER",
 		2: "LOCAL_FILE",
+		3: "BILIBILI",
+		4: "DATABASE",
+		5: "BROWSER",
+		6: "GRAPHQL",
+		7: "TWITTER",
+		8: "REDDIT",
 	}
 	SourceType_value = map[string]int32{
 		"API":         0,
 		"WEB_CRAWLER": 1,
 		"LOCAL_FILE":  2,
+		"BILIBILI":    3,
+		"DATABASE":    4,
+		"BROWSER":     5,
+		"GRAPHQL":     6,
+		"TWITTER":     7,
+		"REDDIT":      8,
 	}
 )
 
@@ -194,6 +212,8 @@ const (
 	CollectionStatus_COLLECTION_RUNNING   CollectionStatus = 1 // 采集中
 	CollectionStatus_COLLECTION_COMPLETED CollectionStatus = 2 // 已完成
 	CollectionStatus_COLLECTION_FAILED    CollectionStatus = 3 // 失败
+	CollectionStatus_COLLECTION_CANCELLED CollectionStatus = 4 // 已取消
+	CollectionStatus_COLLECTION_PAUSED    CollectionStatus = 5 // 已暂停
 )
 
 // Enum value maps for CollectionStatus.
@@ -203,12 +223,16 @@ var (
 		1: "COLLECTION_RUNNING",
 		2: "COLLECTION_COMPLETED",
 		3: "COLLECTION_FAILED",
+		4: "COLLECTION_CANCELLED",
+		5: "COLLECTION_PAUSED",
 	}
 	CollectionStatus_value = map[string]int32{
 		"COLLECTION_PENDING":   0,
 		"COLLECTION_RUNNING":   1,
 		"COLLECTION_COMPLETED": 2,
 		"COLLECTION_FAILED":    3,
+		"COLLECTION_CANCELLED": 4,
+		"COLLECTION_PAUSED":    5,
 	}
 )
 
@@ -1337,6 +1361,11 @@ type CollectionConfig struct {
 	ConcurrentLimit int32                  `protobuf:"varint,2,opt,name=concurrent_limit,json=concurrentLimit,proto3" json:"concurrent_limit,omitempty"` // 并发限制
 	RateLimit       int32                  `protobuf:"varint,3,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`                   // 速率限制（每秒）
 	Filters         []string               `protobuf:"bytes,4,rep,name=filters,proto3" json:"filters,omitempty"`                                         // 过滤规则
+	Timeout         int32                  `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`                                        // 任务超时时间（秒），0 表示不限制
+	Priority        int32                  `protobuf:"varint,6,opt,name=priority,proto3" json:"priority,omitempty"`                                      // 任务优先级，数值越大越先执行，相同优先级按提交顺序
+	MaxAttempts     int32                  `protobuf:"varint,7,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`             // 最大执行次数（含首次），大于 1 时失败后自动重试
+	RetryBackoff    int32                  `protobuf:"varint,8,opt,name=retry_backoff,json=retryBackoff,proto3" json:"retry_backoff,omitempty"`          // 首次自动重试前的等待时间（秒），之后每次翻倍，0 使用默认值
+	MinQuality      int32                  `protobuf:"varint,9,opt,name=min_quality,json=minQuality,proto3" json:"min_quality,omitempty"`                // 最低文本质量分（0-100），低于该分数的文本不保存，0 表示不过滤
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -1399,6 +1428,41 @@ func (x *CollectionConfig) GetFilters() []string {
 	return nil
 }
 
+func (x *CollectionConfig) GetTimeout() int32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetRetryBackoff() int32 {
+	if x != nil {
+		return x.RetryBackoff
+	}
+	return 0
+}
+
+func (x *CollectionConfig) GetMinQuality() int32 {
+	if x != nil {
+		return x.MinQuality
+	}
+	return 0
+}
+
 // 采集响应
 type CollectResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -1522,6 +1586,7 @@ type StatusResponse struct {
 	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`                                 // 状态消息
 	StartTime     int64                  `protobuf:"varint,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`           // 开始时间
 	EndTime       int64                  `protobuf:"varint,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`                 // 结束时间
+	Attempts      int32                  `protobuf:"varint,7,opt,name=attempts,proto3" json:"attempts,omitempty"`                              // 已执行次数，含自动重试
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1598,6 +1663,13 @@ func (x *StatusResponse) GetEndTime() int64 {
 	return 0
 }
 
+func (x *StatusResponse) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
 var File_proto_text_audit_proto protoreflect.FileDescriptor
 
 const file_proto_text_audit_proto_rawDesc = "" +
@@ -1704,20 +1776,26 @@ const file_proto_text_audit_proto_rawDesc = "" +
 	"parameters\x1a=\n" +
 	"\x0fParametersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x93\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x02\n" +
 	"\x10CollectionConfig\x12\x1b\n" +
 	"\tmax_count\x18\x01 \x01(\x05R\bmaxCount\x12)\n" +
 	"\x10concurrent_limit\x18\x02 \x01(\x05R\x0fconcurrentLimit\x12\x1d\n" +
 	"\n" +
 	"rate_limit\x18\x03 \x01(\x05R\trateLimit\x12\x18\n" +
-	"\afilters\x18\x04 \x03(\tR\afilters\"\xa3\x01\n" +
+	"\afilters\x18\x04 \x03(\tR\afilters\x12\x18\n" +
+	"\atimeout\x18\x05 \x01(\x05R\atimeout\x12\x1a\n" +
+	"\bpriority\x18\x06 \x01(\x05R\bpriority\x12!\n" +
+	"\fmax_attempts\x18\a \x01(\x05R\vmaxAttempts\x12#\n" +
+	"\rretry_backoff\x18\b \x01(\x05R\fretryBackoff\x12\x1f\n" +
+	"\vmin_quality\x18\t \x01(\x05R\n" +
+	"minQuality\"\xa3\x01\n" +
 	"\x0fCollectResponse\x12\x17\n" +
 	"\atask_id\x18\x01 \x01(\tR\x06taskId\x124\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x1c.text_audit.CollectionStatusR\x06status\x12'\n" +
 	"\x0fcollected_count\x18\x03 \x01(\x05R\x0ecollectedCount\x12\x18\n" +
 	"\amessage\x18\x04 \x01(\tR\amessage\"(\n" +
 	"\rStatusRequest\x12\x17\n" +
-	"\atask_id\x18\x01 \x01(\tR\x06taskId\"\xcf\x01\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\"\xeb\x01\n" +
 	"\x0eStatusResponse\x12\x17\n" +
 	"\atask_id\x18\x01 \x01(\tR\x06taskId\x124\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x1c.text_audit.CollectionStatusR\x06status\x12\x1a\n" +
@@ -1725,7 +1803,8 @@ const file_proto_text_audit_proto_rawDesc = "" +
 	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1d\n" +
 	"\n" +
 	"start_time\x18\x05 \x01(\x03R\tstartTime\x12\x19\n" +
-	"\bend_time\x18\x06 \x01(\x03R\aendTime*s\n" +
+	"\bend_time\x18\x06 \x01(\x03R\aendTime\x12\x1a\n" +
+	"\battempts\x18\a \x01(\x05R\battempts*s\n" +
 	"\rViolationType\x12\n" +
 	"\n" +
 	"\x06NORMAL\x10\x00\x12\x0f\n" +
@@ -1739,18 +1818,27 @@ const file_proto_text_audit_proto_rawDesc = "" +
 	"\x10TRAINING_PENDING\x10\x00\x12\x14\n" +
 	"\x10TRAINING_RUNNING\x10\x01\x12\x16\n" +
 	"\x12TRAINING_COMPLETED\x10\x02\x12\x13\n" +
-	"\x0fTRAINING_FAILED\x10\x03*6\n" +
+	"\x0fTRAINING_FAILED\x10\x03*\x85\x01\n" +
 	"\n" +
 	"SourceType\x12\a\n" +
 	"\x03API\x10\x00\x12\x0f\n" +
 	"\vWEB_CRAWLER\x10\x01\x12\x0e\n" +
 	"\n" +
-	"LOCAL_FILE\x10\x02*s\n" +
+	"LOCAL_FILE\x10\x02\x12\f\n" +
+	"\bBILIBILI\x10\x03\x12\f\n" +
+	"\bDATABASE\x10\x04\x12\v\n" +
+	"\aBROWSER\x10\x05\x12\v\n" +
+	"\aGRAPHQL\x10\x06\x12\v\n" +
+	"\aTWITTER\x10\a\x12\n" +
+	"\n" +
+	"\x06REDDIT\x10\b*\xa4\x01\n" +
 	"\x10CollectionStatus\x12\x16\n" +
 	"\x12COLLECTION_PENDING\x10\x00\x12\x16\n" +
 	"\x12COLLECTION_RUNNING\x10\x01\x12\x18\n" +
 	"\x14COLLECTION_COMPLETED\x10\x02\x12\x15\n" +
-	"\x11COLLECTION_FAILED\x10\x032\xaf\x02\n" +
+	"\x11COLLECTION_FAILED\x10\x03\x12\x18\n" +
+	"\x14COLLECTION_CANCELLED\x10\x04\x12\x15\n" +
+	"\x11COLLECTION_PAUSED\x10\x052\xaf\x02\n" +
 	"\x10TextAuditService\x12@\n" +
 	"\tAuditText\x12\x18.text_audit.AuditRequest\x1a\x19.text_audit.AuditResponse\x12O\n" +
 	"\x0eBatchAuditText\x12\x1d.text_audit.BatchAuditRequest\x1a\x1e.text_audit.BatchAuditResponse\x12A\n" +
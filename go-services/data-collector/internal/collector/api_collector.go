@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,9 +18,10 @@ import (
 )
 
 type APICollector struct {
-	config  *config.Config
-	client  *http.Client
-	limiter *rate.Limiter
+	config        *config.Config
+	client        *http.Client
+	limiter       *rate.Limiter
+	domainLimiter DomainLimiter
 }
 
 type APIResponse struct {
@@ -41,9 +41,20 @@ func NewAPICollector(cfg *config.Config) (*APICollector, error) {
 	client := &http.Client{
 		Timeout: cfg.Collector.Timeout,
 	}
+	transport, err := newProxyTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		client.Transport = transport
+	}
 
-	// 创建速率限制器
-	limiter := rate.NewLimiter(rate.Limit(cfg.Collector.RateLimit), 1)
+	// 创建速率限制器，未设置速率时不限速
+	limit := rate.Inf
+	if cfg.Collector.RateLimit > 0 {
+		limit = rate.Limit(cfg.Collector.RateLimit)
+	}
+	limiter := rate.NewLimiter(limit, 1)
 
 	return &APICollector{
 		config:  cfg,
@@ -52,6 +63,11 @@ func NewAPICollector(cfg *config.Config) (*APICollector, error) {
 	}, nil
 }
 
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (c *APICollector) SetDomainLimiter(limiter DomainLimiter) {
+	c.domainLimiter = limiter
+}
+
 func (c *APICollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
 	logrus.WithField("url", source.Url).Info("Starting API collection")
 
@@ -89,7 +105,7 @@ func (c *APICollector) Collect(ctx context.Context, source *pb.CollectionSource,
 			}
 
 			// 应用过滤器
-			if !c.applyFilters(text.Content, config.Filters) {
+			if !ApplyFilters(text.Content, config.Filters) {
 				continue
 			}
 
@@ -129,7 +145,7 @@ func (c *APICollector) fetchTextsFromAPI(ctx context.Context, apiURL string, par
 	// 构建请求URL
 	u, err := url.Parse(apiURL)
 	if err != nil {
-		return nil, "", fmt.Errorf("invalid URL: %w", err)
+		return nil, "", Permanent(fmt.Errorf("invalid URL: %w", err))
 	}
 
 	// 添加参数
@@ -139,25 +155,21 @@ func (c *APICollector) fetchTextsFromAPI(ctx context.Context, apiURL string, par
 	}
 	u.RawQuery = query.Encode()
 
-	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// 设置请求头
-	c.setRequestHeaders(req)
-
 	// 发送请求
-	resp, err := c.client.Do(req)
+	resp, err := c.doRequest(ctx, u.String())
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to send request: %w", err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("API returned status %d", resp.StatusCode)
+		err := fmt.Errorf("API returned status %d", resp.StatusCode)
+		// 除超时与限流外的 4xx 说明请求本身有误，重试无意义
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && !isThrottled(resp.StatusCode) {
+			err = Permanent(err)
+		}
+		return nil, "", err
 	}
 
 	// 读取响应体
@@ -176,6 +188,43 @@ func (c *APICollector) fetchTextsFromAPI(ctx context.Context, apiURL string, par
 	return apiResp.Data, apiResp.NextURL, nil
 }
 
+// doRequest 发送 GET 请求，遇到 429/503 时按 Retry-After 等待后重试
+func (c *APICollector) doRequest(ctx context.Context, target string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		// 创建请求
+		req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+		if err != nil {
+			return nil, Permanent(fmt.Errorf("failed to create request: %w", err))
+		}
+
+		// 设置请求头
+		c.setRequestHeaders(req)
+
+		if err := waitDomain(ctx, c.domainLimiter, req.URL.Hostname()); err != nil {
+			return nil, fmt.Errorf("rate limiter error: %w", err)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		if !isThrottled(resp.StatusCode) || attempt >= maxThrottleRetries {
+			return resp, nil
+		}
+
+		wait := retryAfterDelay(resp.Header, time.Second)
+		resp.Body.Close()
+		logrus.WithFields(logrus.Fields{
+			"url":     target,
+			"status":  resp.StatusCode,
+			"attempt": attempt + 1,
+			"wait":    wait,
+		}).Warn("Throttled by API, retrying after delay")
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
 func (c *APICollector) parseSimpleResponse(body []byte) ([]APITextItem, string, error) {
 	// 尝试解析为字符串数组
 	var texts []string
@@ -212,45 +261,4 @@ func (c *APICollector) setRequestHeaders(req *http.Request) {
 	req.Header.Set("Accept", "application/json, text/plain, */*")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Cache-Control", "no-cache")
-}
-
-func (c *APICollector) applyFilters(content string, filters []string) bool {
-	if len(filters) == 0 {
-		return true
-	}
-
-	content = strings.ToLower(strings.TrimSpace(content))
-	
-	// 长度过滤
-	if len(content) < 5 || len(content) > 500 {
-		return false
-	}
-
-	// 应用自定义过滤器
-	for _, filter := range filters {
-		switch filter {
-		case "no_empty":
-			if content == "" {
-				return false
-			}
-		case "no_short":
-			if len(content) < 10 {
-				return false
-			}
-		case "no_long":
-			if len(content) > 200 {
-				return false
-			}
-		case "no_url":
-			if strings.Contains(content, "http://") || strings.Contains(content, "https://") {
-				return false
-			}
-		case "no_email":
-			if strings.Contains(content, "@") && strings.Contains(content, ".") {
-				return false
-			}
-		}
-	}
-
-	return true
 }
\ No newline at end of file
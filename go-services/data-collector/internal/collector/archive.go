@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// ArchiveEntryMetadataKey 压缩包中采集的文本在 Metadata 中记录条目名的键
+const ArchiveEntryMetadataKey = "archive_entry"
+
+// collectFromZip 依次读取压缩包中的条目，按条目扩展名交给对应的解析方法，条目内容直接流式解压，不落盘。
+// MaxCount 对整个压缩包生效；嵌套的压缩包不展开，压缩包不支持断点续采
+func (c *FileCollector) collectFromZip(ctx context.Context, filePath string, params map[string]string, config *pb.CollectionConfig, dedup *fileDedup, textChan chan<- *pb.RawText) error {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to open zip archive: %w", err))
+	}
+	defer archive.Close()
+
+	remaining := config.MaxCount
+	if remaining <= 0 {
+		remaining = 10000 // 默认最大采集数量
+	}
+	// 每个条目以剩余数量作为上限，避免修改调用方的配置
+	entryConfig := proto.Clone(config).(*pb.CollectionConfig)
+
+	collected := int32(0)
+	for _, entry := range archive.File {
+		if remaining <= 0 {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ext := strings.ToLower(path.Ext(entry.Name))
+		if entry.FileInfo().IsDir() || ext == ".zip" {
+			logrus.WithField("entry", entry.Name).Debug("Skipping zip entry")
+			continue
+		}
+
+		entryConfig.MaxCount = remaining
+		n, err := c.collectZipEntry(ctx, entry, filePath, ext, params, entryConfig, dedup, textChan)
+		collected += n
+		remaining -= n
+		if err != nil {
+			return fmt.Errorf("zip entry %s: %w", entry.Name, err)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"entries":         len(archive.File),
+		"total_collected": collected,
+	}).Info("ZIP archive processing completed")
+	return nil
+}
+
+// collectZipEntry 解析压缩包中的单个条目，返回采集的文本数量
+func (c *FileCollector) collectZipEntry(ctx context.Context, entry *zip.File, archivePath, ext string, params map[string]string, config *pb.CollectionConfig, dedup *fileDedup, textChan chan<- *pb.RawText) (int32, error) {
+	reader, err := entry.Open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open entry: %w", err)
+	}
+	defer reader.Close()
+
+	in := &fileInput{reader: reader, path: archivePath, name: path.Base(entry.Name), entry: entry.Name, dedup: dedup}
+	return c.collectInput(ctx, ext, in, params, config, textChan)
+}
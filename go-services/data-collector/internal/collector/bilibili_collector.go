@@ -0,0 +1,397 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+const (
+	bilibiliAPIBase     = "https://api.bilibili.com"
+	bilibiliCommentBase = "https://comment.bilibili.com"
+	// bilibiliVideoURL 视频页地址前缀，拼接 BV 号后作为评论与弹幕的 url
+	bilibiliVideoURL = "https://www.bilibili.com/video/"
+
+	// bilibiliDefaultRate 未配置速率限制时每秒最多请求数，B站风控较严格
+	bilibiliDefaultRate = 2
+	bilibiliPageSize    = 20
+	// bilibiliRiskControlCode 触发风控时接口返回的业务码
+	bilibiliRiskControlCode = -412
+)
+
+// errRiskControl 请求被B站风控拦截（HTTP 412 或业务码 -412），退避后可重试
+var errRiskControl = errors.New("bilibili risk control triggered")
+
+var bvidPattern = regexp.MustCompile(`BV[0-9A-Za-z]{10}`)
+
+// BilibiliCollector 哔哩哔哩视频评论与弹幕采集器
+type BilibiliCollector struct {
+	config      *config.Config
+	client      *http.Client
+	apiBase     string
+	commentBase string
+	backoff     time.Duration // 首次风控退避时间，之后每次翻倍
+	maxRetries  int
+
+	domainLimiter DomainLimiter
+}
+
+// bilibiliResponse B站接口通用响应结构
+type bilibiliResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// bilibiliVideoInfo 视频基本信息
+type bilibiliVideoInfo struct {
+	Aid   int64  `json:"aid"`
+	Cid   int64  `json:"cid"`
+	Bvid  string `json:"bvid"`
+	Title string `json:"title"`
+}
+
+// bilibiliReplyPage 评论分页结果
+type bilibiliReplyPage struct {
+	Page struct {
+		Num   int `json:"num"`
+		Size  int `json:"size"`
+		Count int `json:"count"`
+	} `json:"page"`
+	Replies []bilibiliReply `json:"replies"`
+}
+
+// bilibiliReply 评论，Replies 为楼中楼回复
+type bilibiliReply struct {
+	Rpid   int64 `json:"rpid"`
+	Like   int   `json:"like"`
+	Rcount int   `json:"rcount"`
+	Ctime  int64 `json:"ctime"`
+	Member struct {
+		Uname string `json:"uname"`
+	} `json:"member"`
+	Content struct {
+		Message string `json:"message"`
+	} `json:"content"`
+	Replies []bilibiliReply `json:"replies"`
+}
+
+// bilibiliDanmakuList 弹幕 XML，p 属性依次为出现时间、模式、字号、颜色、发送时间等
+type bilibiliDanmakuList struct {
+	Items []struct {
+		Attr string `xml:"p,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"d"`
+}
+
+// NewBilibiliCollector 创建B站采集器
+func NewBilibiliCollector(cfg *config.Config) (*BilibiliCollector, error) {
+	return &BilibiliCollector{
+		config:      cfg,
+		client:      &http.Client{Timeout: cfg.Collector.Timeout, Transport: newDecodingTransport(nil)},
+		apiBase:     bilibiliAPIBase,
+		commentBase: bilibiliCommentBase,
+		backoff:     2 * time.Second,
+		maxRetries:  3,
+	}, nil
+}
+
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (b *BilibiliCollector) SetDomainLimiter(limiter DomainLimiter) {
+	b.domainLimiter = limiter
+}
+
+// Collect 采集视频评论；parameters.mode 为 danmaku 时采集弹幕。
+// BV 号取自 parameters.bvid，未设置时从 URL 中解析
+func (b *BilibiliCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	bvid := source.Parameters["bvid"]
+	if bvid == "" {
+		bvid = bvidPattern.FindString(source.Url)
+	}
+	if bvid == "" {
+		return Permanent(fmt.Errorf("bilibili source requires a BV id in parameters.bvid or url"))
+	}
+
+	maxCount := config.MaxCount
+	if maxCount <= 0 {
+		maxCount = 1000 // 默认最大采集数量
+	}
+	limit := rate.Limit(bilibiliDefaultRate)
+	if config.RateLimit > 0 {
+		limit = rate.Limit(config.RateLimit)
+	}
+	run := &bilibiliRun{
+		collector: b,
+		limiter:   rate.NewLimiter(limit, 1),
+		cookie:    source.Parameters["cookie"],
+		textChan:  textChan,
+		maxCount:  maxCount,
+	}
+
+	info, err := run.fetchVideoInfo(ctx, bvid)
+	if err != nil {
+		return err
+	}
+
+	mode := source.Parameters["mode"]
+	logrus.WithFields(logrus.Fields{
+		"bvid": bvid,
+		"mode": mode,
+	}).Info("Starting Bilibili collection")
+
+	if mode == "danmaku" {
+		err = run.collectDanmaku(ctx, info)
+	} else {
+		err = run.collectComments(ctx, info)
+	}
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"bvid":            bvid,
+		"total_collected": run.collected,
+	}).Info("Bilibili collection completed")
+	return nil
+}
+
+// bilibiliRun 单次采集的状态
+type bilibiliRun struct {
+	collector *BilibiliCollector
+	limiter   *rate.Limiter
+	cookie    string
+	textChan  chan<- *pb.RawText
+	maxCount  int32
+	collected int32
+}
+
+func (r *bilibiliRun) full() bool {
+	return r.collected >= r.maxCount
+}
+
+func (r *bilibiliRun) emit(ctx context.Context, content, source string, metadata map[string]string) error {
+	content = cleanText(content)
+	if content == "" {
+		return nil
+	}
+	metadata[PlatformMetadataKey] = "bilibili"
+	metadata[TypeMetadataKey] = strings.TrimPrefix(source, "bilibili:")
+	metadata[URLMetadataKey] = bilibiliVideoURL + metadata["bvid"]
+	rawText := &pb.RawText{
+		Id:        uuid.New().String(),
+		Content:   content,
+		Source:    source,
+		Timestamp: time.Now().UnixMilli(),
+		Metadata:  metadata,
+	}
+	select {
+	case r.textChan <- rawText:
+		r.collected++
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *bilibiliRun) fetchVideoInfo(ctx context.Context, bvid string) (*bilibiliVideoInfo, error) {
+	var info bilibiliVideoInfo
+	endpoint := fmt.Sprintf("%s/x/web-interface/view?bvid=%s", r.collector.apiBase, url.QueryEscape(bvid))
+	if err := r.getJSON(ctx, endpoint, &info); err != nil {
+		return nil, fmt.Errorf("failed to fetch bilibili video info: %w", err)
+	}
+	if info.Bvid == "" {
+		info.Bvid = bvid
+	}
+	return &info, nil
+}
+
+// collectComments 按页拉取评论，每条评论后紧跟其楼中楼回复
+func (r *bilibiliRun) collectComments(ctx context.Context, info *bilibiliVideoInfo) error {
+	for pn := 1; !r.full(); pn++ {
+		var page bilibiliReplyPage
+		endpoint := fmt.Sprintf("%s/x/v2/reply?type=1&oid=%d&pn=%d&ps=%d&sort=0", r.collector.apiBase, info.Aid, pn, bilibiliPageSize)
+		if err := r.getJSON(ctx, endpoint, &page); err != nil {
+			return fmt.Errorf("failed to fetch bilibili comments page %d: %w", pn, err)
+		}
+		if len(page.Replies) == 0 {
+			return nil
+		}
+
+		for _, reply := range page.Replies {
+			if err := r.emitReply(ctx, info, reply, 1); err != nil {
+				return err
+			}
+			for _, sub := range reply.Replies {
+				if err := r.emitReply(ctx, info, sub, 2); err != nil {
+					return err
+				}
+			}
+		}
+
+		if page.Page.Count > 0 && pn*bilibiliPageSize >= page.Page.Count {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *bilibiliRun) emitReply(ctx context.Context, info *bilibiliVideoInfo, reply bilibiliReply, level int) error {
+	if r.full() {
+		return nil
+	}
+	return r.emit(ctx, reply.Content.Message, "bilibili:comment", map[string]string{
+		"bvid":        info.Bvid,
+		"title":       info.Title,
+		"rpid":        strconv.FormatInt(reply.Rpid, 10),
+		"author":      reply.Member.Uname,
+		"like_count":  strconv.Itoa(reply.Like),
+		"reply_count": strconv.Itoa(reply.Rcount),
+		"reply_level": strconv.Itoa(level),
+		"created_at":  strconv.FormatInt(reply.Ctime, 10),
+	})
+}
+
+// collectDanmaku 拉取视频的弹幕列表
+func (r *bilibiliRun) collectDanmaku(ctx context.Context, info *bilibiliVideoInfo) error {
+	endpoint := fmt.Sprintf("%s/%d.xml", r.collector.commentBase, info.Cid)
+	var body []byte
+	err := r.collector.retry(ctx, func() error {
+		var err error
+		body, err = r.get(ctx, endpoint)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch bilibili danmaku: %w", err)
+	}
+
+	var list bilibiliDanmakuList
+	if err := xml.Unmarshal(body, &list); err != nil {
+		return fmt.Errorf("failed to parse bilibili danmaku: %w", err)
+	}
+
+	for _, item := range list.Items {
+		if r.full() {
+			break
+		}
+		metadata := map[string]string{
+			"bvid":  info.Bvid,
+			"title": info.Title,
+			"cid":   strconv.FormatInt(info.Cid, 10),
+		}
+		attrs := strings.Split(item.Attr, ",")
+		if len(attrs) > 0 {
+			metadata["video_time"] = attrs[0]
+		}
+		if len(attrs) > 4 {
+			metadata["created_at"] = attrs[4]
+		}
+		if err := r.emit(ctx, item.Text, "bilibili:danmaku", metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getJSON 请求B站接口并解析 data 字段，风控时退避重试
+func (r *bilibiliRun) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	return r.collector.retry(ctx, func() error {
+		body, err := r.get(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+		var resp bilibiliResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		if resp.Code == bilibiliRiskControlCode {
+			return errRiskControl
+		}
+		if resp.Code != 0 {
+			return fmt.Errorf("bilibili API error %d: %s", resp.Code, resp.Message)
+		}
+		return json.Unmarshal(resp.Data, out)
+	})
+}
+
+// get 发送限速后的 GET 请求；弹幕接口返回 deflate 压缩的内容，由 decodingTransport 解压
+func (r *bilibiliRun) get(ctx context.Context, endpoint string) ([]byte, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter error: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	if len(r.collector.config.Collector.UserAgents) > 0 {
+		userAgent = r.collector.config.Collector.UserAgents[0]
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Referer", "https://www.bilibili.com")
+	if err := waitDomain(ctx, r.collector.domainLimiter, req.URL.Hostname()); err != nil {
+		return nil, fmt.Errorf("rate limiter error: %w", err)
+	}
+	if r.cookie != "" {
+		req.Header.Set("Cookie", r.cookie)
+	}
+
+	resp, err := r.collector.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, errRiskControl
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bilibili returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// retry 在触发风控时按指数退避重试 fn，其他错误直接返回
+func (b *BilibiliCollector) retry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if !errors.Is(err, errRiskControl) {
+			return err
+		}
+		if attempt >= b.maxRetries {
+			return fmt.Errorf("%w: gave up after %d retries", err, attempt)
+		}
+
+		wait := b.backoff << attempt
+		logrus.WithFields(logrus.Fields{
+			"attempt": attempt + 1,
+			"wait":    wait,
+		}).Warn("Bilibili risk control triggered, backing off")
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"compress/flate"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestBilibili 启动模拟B站接口的测试服务器；riskControlled 次请求评论接口会先被风控拦截
+func newTestBilibili(t *testing.T, riskControlled int32) (*BilibiliCollector, *int32) {
+	var replyCalls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/x/web-interface/view", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "BV1xx411c7mD", r.URL.Query().Get("bvid"))
+		fmt.Fprint(w, `{"code":0,"data":{"aid":170001,"cid":279786,"bvid":"BV1xx411c7mD","title":"测试视频"}}`)
+	})
+	mux.HandleFunc("/x/v2/reply", func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&replyCalls, 1)
+		switch {
+		case call == 1 && riskControlled > 0:
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		case call == 2 && riskControlled > 1:
+			fmt.Fprint(w, `{"code":-412,"message":"请求被拦截"}`)
+			return
+		}
+		switch r.URL.Query().Get("pn") {
+		case "1":
+			fmt.Fprint(w, `{"code":0,"data":{"page":{"num":1,"size":20,"count":40},"replies":[
+				{"rpid":1,"like":12,"rcount":1,"member":{"uname":"a"},"content":{"message":"第一条评论"},
+				 "replies":[{"rpid":2,"like":3,"member":{"uname":"b"},"content":{"message":"楼中楼回复"}}]}]}}`)
+		case "2":
+			fmt.Fprint(w, `{"code":0,"data":{"page":{"num":2,"size":20,"count":40},"replies":[
+				{"rpid":3,"like":0,"member":{"uname":"c"},"content":{"message":"第二页评论"}}]}}`)
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("pn"))
+		}
+	})
+	mux.HandleFunc("/279786.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "deflate")
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		fmt.Fprint(fw, `<?xml version="1.0" encoding="UTF-8"?><i><chatid>279786</chatid>`+
+			`<d p="12.5,1,25,16777215,1700000000,0,abc,1">前方高能</d>`+
+			`<d p="30.0,1,25,16777215,1700000001,0,def,2">哈哈哈</d></i>`)
+		fw.Close()
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	c, err := NewBilibiliCollector(&config.Config{})
+	require.NoError(t, err)
+	c.apiBase = server.URL
+	c.commentBase = server.URL
+	c.backoff = time.Millisecond
+	return c, &replyCalls
+}
+
+func TestBilibiliCollectorComments(t *testing.T) {
+	c, replyCalls := newTestBilibili(t, 2)
+
+	source := &pb.CollectionSource{Type: pb.SourceType_BILIBILI, Url: "https://www.bilibili.com/video/BV1xx411c7mD/"}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, RateLimit: 1000})
+
+	assert.Equal(t, []string{"第一条评论", "楼中楼回复", "第二页评论"}, contents(texts))
+	// 风控两次后退避重试成功
+	assert.Equal(t, int32(4), atomic.LoadInt32(replyCalls))
+
+	assert.Equal(t, "bilibili:comment", texts[0].Source)
+	assert.Equal(t, "12", texts[0].Metadata["like_count"])
+	assert.Equal(t, "1", texts[0].Metadata["reply_level"])
+	assert.Equal(t, "2", texts[1].Metadata["reply_level"])
+	assert.Equal(t, "BV1xx411c7mD", texts[2].Metadata["bvid"])
+	assert.Equal(t, "bilibili", texts[0].Metadata[PlatformMetadataKey])
+	assert.Equal(t, "comment", texts[0].Metadata[TypeMetadataKey])
+	assert.Equal(t, "https://www.bilibili.com/video/BV1xx411c7mD", texts[0].Metadata[URLMetadataKey])
+}
+
+func TestBilibiliCollectorRespectsMaxCount(t *testing.T) {
+	c, replyCalls := newTestBilibili(t, 0)
+
+	source := &pb.CollectionSource{Type: pb.SourceType_BILIBILI, Parameters: map[string]string{"bvid": "BV1xx411c7mD"}}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 1, RateLimit: 1000})
+
+	assert.Equal(t, []string{"第一条评论"}, contents(texts))
+	assert.Equal(t, int32(1), atomic.LoadInt32(replyCalls))
+}
+
+func TestBilibiliCollectorGivesUpAfterRetries(t *testing.T) {
+	c, _ := newTestBilibili(t, 2)
+	c.maxRetries = 1
+
+	source := &pb.CollectionSource{Type: pb.SourceType_BILIBILI, Parameters: map[string]string{"bvid": "BV1xx411c7mD"}}
+	err := c.Collect(context.Background(), source, &pb.CollectionConfig{RateLimit: 1000}, make(chan *pb.RawText, 10))
+	assert.ErrorIs(t, err, errRiskControl)
+}
+
+func TestBilibiliCollectorDanmaku(t *testing.T) {
+	c, _ := newTestBilibili(t, 0)
+
+	source := &pb.CollectionSource{Type: pb.SourceType_BILIBILI, Parameters: map[string]string{"bvid": "BV1xx411c7mD", "mode": "danmaku"}}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, RateLimit: 1000})
+
+	require.Len(t, texts, 2)
+	assert.Equal(t, []string{"前方高能", "哈哈哈"}, contents(texts))
+	assert.Equal(t, "bilibili:danmaku", texts[0].Source)
+	assert.Equal(t, "12.5", texts[0].Metadata["video_time"])
+	assert.Equal(t, "1700000000", texts[0].Metadata["created_at"])
+	assert.Equal(t, "danmaku", texts[0].Metadata[TypeMetadataKey])
+}
+
+func TestBilibiliCollectorRequiresBvid(t *testing.T) {
+	c, err := NewBilibiliCollector(&config.Config{})
+	require.NoError(t, err)
+
+	err = c.Collect(context.Background(), &pb.CollectionSource{Url: "https://www.bilibili.com/"}, &pb.CollectionConfig{}, make(chan *pb.RawText, 1))
+	assert.Error(t, err)
+}
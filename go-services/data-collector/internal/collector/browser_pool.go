@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// pooledBrowser 池中的一个浏览器进程，ctx 取消时进程退出
+type pooledBrowser struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// browserPool 复用的浏览器实例池，实例按需启动，最多 size 个，每个实例同一时刻只渲染一个页面
+type browserPool struct {
+	size int
+	opts []chromedp.ExecAllocatorOption
+
+	mu       sync.Mutex
+	started  int
+	closed   bool
+	idle     chan *pooledBrowser
+	all      map[*pooledBrowser]struct{}
+	allocCtx context.Context
+	cancel   context.CancelFunc
+}
+
+func newBrowserPool(size int, opts []chromedp.ExecAllocatorOption) *browserPool {
+	return &browserPool{
+		size: size,
+		opts: opts,
+		idle: make(chan *pooledBrowser, size),
+		all:  make(map[*pooledBrowser]struct{}),
+	}
+}
+
+// acquire 取出一个空闲实例，没有空闲实例且未达上限时启动新实例，否则等待其他页面释放
+func (p *browserPool) acquire(ctx context.Context) (*pooledBrowser, error) {
+	select {
+	case b := <-p.idle:
+		return b, nil
+	default:
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("browser pool is closed")
+	}
+	if p.started < p.size {
+		p.started++
+		p.mu.Unlock()
+		b, err := p.start()
+		if err != nil {
+			p.mu.Lock()
+			p.started--
+			p.mu.Unlock()
+			return nil, err
+		}
+		return b, nil
+	}
+	p.mu.Unlock()
+
+	select {
+	case b := <-p.idle:
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release 归还实例；浏览器进程已退出时从池中移除，腾出的名额由后续 acquire 重新启动
+func (p *browserPool) release(b *pooledBrowser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || b.ctx.Err() != nil {
+		b.cancel()
+		delete(p.all, b)
+		p.started--
+		return
+	}
+	p.idle <- b
+}
+
+// start 启动一个浏览器进程，所有实例共享同一个 ExecAllocator
+func (p *browserPool) start() (*pooledBrowser, error) {
+	p.mu.Lock()
+	if p.allocCtx == nil {
+		p.allocCtx, p.cancel = chromedp.NewExecAllocator(context.Background(), p.opts...)
+	}
+	allocCtx := p.allocCtx
+	p.mu.Unlock()
+
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	b := &pooledBrowser{ctx: ctx, cancel: cancel}
+	p.mu.Lock()
+	p.all[b] = struct{}{}
+	p.mu.Unlock()
+	return b, nil
+}
+
+// close 关闭所有实例，正在渲染的页面随之失败
+func (p *browserPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for b := range p.all {
+		b.cancel()
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// 对象池只用于单次调用内部的临时缓冲：取出的对象不得逃逸出调用方，
+// 结果必须先拷贝（如 string(buf.Bytes())、base64 编码）再归还。
+// 发送到 textChan 的 *pb.RawText 会被写库协程长期持有，不做池化。
+var (
+	// bufferPool 复用 bytes.Buffer，用于原始 HTML 压缩与文本清洗
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	// gzipWriterPool 复用 gzip.Writer，避免每条文本重新分配压缩状态
+	gzipWriterPool = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+)
+
+// maxPooledBufferSize 超过该容量的缓冲不归还，避免个别大页面长期占用内存
+const maxPooledBufferSize = 1 << 20
+
+// getBuffer 从池中取出一个已清空的缓冲
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer 归还缓冲，调用后不得再引用 buf 及其 Bytes()
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// getGzipWriter 取出一个写入 w 的 gzip.Writer
+func getGzipWriter(w io.Writer) *gzip.Writer {
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	zw.Reset(w)
+	return zw
+}
+
+// putGzipWriter 归还 gzip.Writer，下次取出时会重新 Reset
+func putGzipWriter(zw *gzip.Writer) {
+	gzipWriterPool.Put(zw)
+}
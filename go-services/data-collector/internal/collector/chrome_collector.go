@@ -0,0 +1,285 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// ChromeCollector 使用无头 Chrome 渲染页面后提取文本，用于内容由前端脚本生成的页面。
+// 浏览器实例较重，仅 BROWSER 类型的采集源使用，实例在首次采集时按需启动并在任务间复用
+type ChromeCollector struct {
+	web           *WebCollector
+	pool          *browserPool
+	pageTimeout   time.Duration
+	domainLimiter DomainLimiter
+}
+
+// NewChromeCollector 创建浏览器采集器
+func NewChromeCollector(cfg *config.Config) (*ChromeCollector, error) {
+	web, err := NewWebCollector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("blink-settings", "imagesEnabled=false"),
+	)
+	if cfg.Collector.BrowserPath != "" {
+		opts = append(opts, chromedp.ExecPath(cfg.Collector.BrowserPath))
+	}
+
+	size := cfg.Collector.BrowserPoolSize
+	if size <= 0 {
+		size = 1
+	}
+	pageTimeout := cfg.Collector.BrowserPageTimeout
+	if pageTimeout <= 0 {
+		pageTimeout = 30 * time.Second
+	}
+
+	return &ChromeCollector{
+		web:         web,
+		pool:        newBrowserPool(size, opts),
+		pageTimeout: pageTimeout,
+	}, nil
+}
+
+// SetDomainLimiter 设置按域名的全局限速
+func (c *ChromeCollector) SetDomainLimiter(limiter DomainLimiter) {
+	c.domainLimiter = limiter
+}
+
+// Close 关闭所有浏览器实例
+func (c *ChromeCollector) Close() error {
+	c.pool.close()
+	return nil
+}
+
+// Collect 依次渲染采集源的页面并按选择器提取文本。
+// 参数 urls 为逗号分隔的页面列表，为空时使用 source.Url；wait_selector 指定渲染完成的标志元素，
+// 未指定时等待网络空闲；page_timeout 为单页超时秒数，覆盖全局配置
+func (c *ChromeCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	params := source.Parameters
+	urls := browserURLs(source)
+	if len(urls) == 0 {
+		return Permanent(fmt.Errorf("browser source requires url or urls parameter"))
+	}
+
+	pageTimeout := c.pageTimeout
+	if raw := params["page_timeout"]; raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			return Permanent(fmt.Errorf("invalid page_timeout: %q", raw))
+		}
+		pageTimeout = time.Duration(seconds) * time.Second
+	}
+
+	maxCount := config.MaxCount
+	if maxCount <= 0 {
+		maxCount = 100 // 默认最大采集数量
+	}
+	selectors := c.web.getSelectors(params)
+
+	logrus.WithFields(logrus.Fields{
+		"urls":         len(urls),
+		"page_timeout": pageTimeout,
+	}).Info("Starting browser collection")
+
+	collected := int32(0)
+	for _, pageURL := range urls {
+		if collected >= maxCount {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		html, err := c.renderPage(ctx, pageURL, params["wait_selector"], pageTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// 单页失败不影响后续页面
+			logrus.WithFields(logrus.Fields{
+				"url":   pageURL,
+				"error": err.Error(),
+			}).Error("Browser rendering error")
+			continue
+		}
+
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if err != nil {
+			logrus.WithError(err).WithField("url", pageURL).Error("Failed to parse rendered page")
+			continue
+		}
+		meta := extractPageMeta(doc.Selection, params)
+
+		for _, selector := range selectors {
+			var stop bool
+			doc.Find(selector).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+				if collected >= maxCount {
+					return false
+				}
+				text := strings.TrimSpace(s.Text())
+				if text == "" || !ApplyFilters(text, config.Filters) {
+					return true
+				}
+
+				rawText := &pb.RawText{
+					Id:        uuid.New().String(),
+					Content:   text,
+					Source:    fmt.Sprintf("browser:%s", extractDomain(pageURL)),
+					Timestamp: time.Now().UnixMilli(),
+					Metadata: map[string]string{
+						"url":      pageURL,
+						"selector": selector,
+						"tag":      goquery.NodeName(s),
+						"type":     "page",
+						"platform": "web",
+					},
+				}
+				meta.apply(rawText.Metadata)
+
+				select {
+				case textChan <- rawText:
+					collected++
+					return true
+				case <-ctx.Done():
+					stop = true
+					return false
+				}
+			})
+			if stop {
+				return ctx.Err()
+			}
+		}
+	}
+
+	logrus.WithField("total_collected", collected).Info("Browser collection completed")
+	return nil
+}
+
+// renderPage 在池中的浏览器新开标签页加载页面，等待渲染完成后返回 DOM 的 HTML
+func (c *ChromeCollector) renderPage(ctx context.Context, pageURL, waitSelector string, timeout time.Duration) (string, error) {
+	if err := waitDomain(ctx, c.domainLimiter, extractDomain(pageURL)); err != nil {
+		return "", err
+	}
+
+	b, err := c.pool.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.pool.release(b)
+
+	tabCtx, cancelTab := chromedp.NewContext(b.ctx)
+	defer cancelTab()
+	pageCtx, cancelPage := context.WithTimeout(tabCtx, timeout)
+	defer cancelPage()
+	// 任务取消时立即关闭标签页
+	stopAfter := context.AfterFunc(ctx, cancelPage)
+	defer stopAfter()
+
+	idle := newNetworkIdleWatcher()
+	chromedp.ListenTarget(pageCtx, idle.handle)
+
+	var html string
+	err = chromedp.Run(pageCtx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := emulation.SetUserAgentOverride(c.web.getRandomUserAgent()).Do(ctx); err != nil {
+				return err
+			}
+			_, loaderID, errorText, _, err := page.Navigate(pageURL).Do(ctx)
+			if err != nil {
+				return err
+			}
+			if errorText != "" {
+				return fmt.Errorf("navigation failed: %s", errorText)
+			}
+			if waitSelector != "" {
+				return chromedp.WaitVisible(waitSelector, chromedp.ByQuery).Do(ctx)
+			}
+			return idle.wait(ctx, loaderID)
+		}),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if err != nil {
+		return "", err
+	}
+	return html, nil
+}
+
+// browserURLs 返回采集源要渲染的页面列表
+func browserURLs(source *pb.CollectionSource) []string {
+	raw := source.Parameters["urls"]
+	if raw == "" {
+		raw = source.Url
+	}
+	var urls []string
+	for _, u := range strings.Split(raw, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// networkIdleWatcher 记录收到 networkIdle 生命周期事件的页面加载。
+// 事件可能先于 Page.navigate 的返回到达，因此按 loaderId 缓存
+type networkIdleWatcher struct {
+	mu     sync.Mutex
+	idle   map[cdp.LoaderID]bool
+	notify chan struct{}
+}
+
+func newNetworkIdleWatcher() *networkIdleWatcher {
+	return &networkIdleWatcher{
+		idle:   make(map[cdp.LoaderID]bool),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (w *networkIdleWatcher) handle(ev interface{}) {
+	e, ok := ev.(*page.EventLifecycleEvent)
+	if !ok || e.Name != "networkIdle" {
+		return
+	}
+	w.mu.Lock()
+	w.idle[e.LoaderID] = true
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// wait 阻塞直到 loaderID 对应的页面网络空闲
+func (w *networkIdleWatcher) wait(ctx context.Context, loaderID cdp.LoaderID) error {
+	for {
+		w.mu.Lock()
+		done := w.idle[loaderID]
+		w.mu.Unlock()
+		if done {
+			return nil
+		}
+		select {
+		case <-w.notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
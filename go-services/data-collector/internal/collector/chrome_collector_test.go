@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// testBrowserPath 查找本机的 Chrome，可通过 CHROME_PATH 指定；找不到时跳过依赖浏览器的测试
+func testBrowserPath(t *testing.T) string {
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		return path
+	}
+	for _, name := range []string{"headless-shell", "chromium", "chromium-browser", "google-chrome", "google-chrome-stable"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	t.Skip("chrome not found, set CHROME_PATH to run browser tests")
+	return ""
+}
+
+// jsRenderedPage 静态 HTML 中没有文本，评论由脚本在加载数据后插入
+const jsRenderedPage = `<html><head>
+<meta name="author" content="渲染作者">
+</head><body><div id="list"></div>
+<script>
+setTimeout(function () {
+  fetch('/data').then(function (r) { return r.json(); }).then(function (items) {
+    var list = document.getElementById('list');
+    items.forEach(function (text) {
+      var p = document.createElement('p');
+      p.className = 'comment';
+      p.textContent = text;
+      list.appendChild(p);
+    });
+  });
+}, 100);
+</script></body></html>`
+
+func TestChromeCollectorRendersJavaScript(t *testing.T) {
+	browserPath := testBrowserPath(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/data" {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `["脚本渲染的评论一", "脚本渲染的评论二"]`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, jsRenderedPage)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.Collector.BrowserPath = browserPath
+	cfg.Collector.BrowserPoolSize = 1
+	cfg.Collector.BrowserPageTimeout = 20 * time.Second
+	c, err := NewChromeCollector(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	tests := map[string]map[string]string{
+		"network idle":  {"selectors": "p.comment"},
+		"wait selector": {"selectors": "p.comment", "wait_selector": "p.comment"},
+	}
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			source := &pb.CollectionSource{Type: pb.SourceType_BROWSER, Url: server.URL + "/", Parameters: params}
+			texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10})
+			require.NotEmpty(t, texts)
+			assert.Contains(t, contents(texts), "脚本渲染的评论一")
+			assert.Equal(t, "渲染作者", texts[0].Metadata["author"])
+		})
+	}
+}
+
+func TestNetworkIdleWatcherKeepsEarlyEvents(t *testing.T) {
+	w := newNetworkIdleWatcher()
+	// networkIdle 先于 Page.navigate 的返回到达
+	w.handle(&page.EventLifecycleEvent{LoaderID: "loader-1", Name: "networkIdle"})
+	w.handle(&page.EventLifecycleEvent{LoaderID: "loader-2", Name: "load"})
+
+	require.NoError(t, w.wait(context.Background(), "loader-1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.wait(ctx, "loader-2"), context.DeadlineExceeded)
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// contentDedupFalsePositiveRate 布隆过滤器的目标误判率，误判时一条新文本被当作重复丢弃
+const contentDedupFalsePositiveRate = 0.01
+
+// contentDedup 单次采集内按内容哈希去重的布隆过滤器，内存只取决于预期文本数，
+// 超出预期数量后误判率逐渐升高但内存不再增长
+type contentDedup struct {
+	mu     sync.Mutex
+	bits   []uint64
+	size   uint64
+	hashes int
+}
+
+// newContentDedup 按预期文本数创建过滤器，capacity 不大于 0 时返回 nil，表示不去重
+func newContentDedup(capacity int) *contentDedup {
+	if capacity <= 0 {
+		return nil
+	}
+	// m = -n·ln(p)/ln²2，k = m/n·ln2
+	size := uint64(math.Ceil(-float64(capacity) * math.Log(contentDedupFalsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := int(math.Round(float64(size) / float64(capacity) * math.Ln2))
+	return &contentDedup{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: max(hashes, 1),
+	}
+}
+
+// seen 判断 content 是否已出现过，未出现时记录下来；d 为 nil 时始终返回 false
+func (d *contentDedup) seen(content string) bool {
+	if d == nil {
+		return false
+	}
+	h := fnv.New128a()
+	h.Write([]byte(content))
+	sum := h.Sum(nil)
+	h1, h2 := binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	found := true
+	for i := 0; i < d.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % d.size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if d.bits[word]&mask == 0 {
+			found = false
+			d.bits[word] |= mask
+		}
+	}
+	return found
+}
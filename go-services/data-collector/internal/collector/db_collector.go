@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// dbDefaultBatchSize 每页读取的行数
+const dbDefaultBatchSize = 500
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// forbiddenSQLPattern 只读查询中不应出现的关键字
+	forbiddenSQLPattern = regexp.MustCompile(`(?i)\b(insert|update|delete|drop|alter|truncate|create|replace|merge|grant|revoke|call|exec|execute|into|lock|sleep|benchmark|pg_sleep)\b`)
+)
+
+// DBCollector 从外部 MySQL/Postgres 表中读取文本，按主键分页流式输出
+//
+// 参数（source.Parameters）：
+//   - driver: mysql 或 postgres，默认 mysql
+//   - dsn: 数据库连接串，建议使用只读账号；不随任务记录持久化
+//   - query: SELECT 查询，作为子查询执行；未设置时使用 table
+//   - table: 表名，等价于 query 为 SELECT * FROM table
+//   - text_column: 文本列，默认 content
+//   - key_column: 分页使用的唯一递增列，默认 id
+//   - metadata_columns: 逗号分隔的元数据列
+//   - batch_size: 每页行数，默认 500
+type DBCollector struct {
+	config *config.Config
+	// dialectors 支持的驱动，测试中可注入 sqlite
+	dialectors map[string]func(dsn string) gorm.Dialector
+}
+
+// dbQuery 解析并校验后的查询参数
+type dbQuery struct {
+	source          string
+	text            string
+	key             string
+	metadataColumns []string
+	batchSize       int
+}
+
+// NewDBCollector 创建数据库采集器
+func NewDBCollector(cfg *config.Config) (*DBCollector, error) {
+	return &DBCollector{
+		config: cfg,
+		dialectors: map[string]func(dsn string) gorm.Dialector{
+			"mysql":    mysql.Open,
+			"postgres": postgres.Open,
+		},
+	}, nil
+}
+
+// Collect 执行数据库采集
+func (d *DBCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	params := source.Parameters
+	query, err := parseDBQuery(params)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	driver := params["driver"]
+	if driver == "" {
+		driver = "mysql"
+	}
+	open, ok := d.dialectors[driver]
+	if !ok {
+		return Permanent(fmt.Errorf("unsupported database driver: %s", driver))
+	}
+	if params["dsn"] == "" {
+		return Permanent(fmt.Errorf("database source requires parameters.dsn"))
+	}
+
+	db, err := gorm.Open(open(params["dsn"]), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return fmt.Errorf("failed to connect to source database: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get source database: %w", err)
+	}
+	defer sqlDB.Close()
+
+	maxCount := config.MaxCount
+	if maxCount <= 0 {
+		maxCount = 1000 // 默认最大采集数量
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"driver": driver,
+		"source": query.source,
+	}).Info("Starting database collection")
+
+	collected := int32(0)
+	var lastKey interface{}
+	for collected < maxCount {
+		limit := query.batchSize
+		if remaining := int(maxCount - collected); remaining < limit {
+			limit = remaining
+		}
+
+		rows, err := query.page(ctx, db, lastKey, limit)
+		if err != nil {
+			return fmt.Errorf("failed to query source database: %w", err)
+		}
+		count, key, err := query.emitRows(ctx, rows, textChan, &collected)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if count < limit {
+			break
+		}
+		lastKey = key
+	}
+
+	logrus.WithField("total_collected", collected).Info("Database collection completed")
+	return nil
+}
+
+// parseDBQuery 校验查询参数；列名会拼接进 SQL，只允许普通标识符
+func parseDBQuery(params map[string]string) (*dbQuery, error) {
+	source := strings.TrimSpace(params["query"])
+	if source == "" {
+		table := params["table"]
+		if !identifierPattern.MatchString(table) {
+			return nil, fmt.Errorf("database source requires a valid query or table")
+		}
+		source = "SELECT * FROM " + table
+	}
+	if err := validateSelect(source); err != nil {
+		return nil, err
+	}
+
+	q := &dbQuery{
+		source:    source,
+		text:      params["text_column"],
+		key:       params["key_column"],
+		batchSize: dbDefaultBatchSize,
+	}
+	if q.text == "" {
+		q.text = "content"
+	}
+	if q.key == "" {
+		q.key = "id"
+	}
+	columns := []string{q.text, q.key}
+	for _, column := range strings.Split(params["metadata_columns"], ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			q.metadataColumns = append(q.metadataColumns, column)
+			columns = append(columns, column)
+		}
+	}
+	for _, column := range columns {
+		if !identifierPattern.MatchString(column) {
+			return nil, fmt.Errorf("invalid column name: %q", column)
+		}
+	}
+
+	if raw := params["batch_size"]; raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid batch_size: %q", raw)
+		}
+		q.batchSize = size
+	}
+	return q, nil
+}
+
+// validateSelect 拒绝明显危险的语句：只允许单条 SELECT，且不含写操作关键字。
+// 这只是兜底检查，源库仍应使用只读账号
+func validateSelect(query string) error {
+	if !strings.HasPrefix(strings.ToLower(query), "select") {
+		return fmt.Errorf("database query must be a SELECT statement")
+	}
+	if strings.Contains(query, ";") || strings.Contains(query, "--") || strings.Contains(query, "/*") {
+		return fmt.Errorf("database query must be a single statement without comments")
+	}
+	if keyword := forbiddenSQLPattern.FindString(query); keyword != "" {
+		return fmt.Errorf("database query contains forbidden keyword %q", keyword)
+	}
+	return nil
+}
+
+// page 按 key 列做键集分页，避免 OFFSET 在大表上越翻越慢
+func (q *dbQuery) page(ctx context.Context, db *gorm.DB, lastKey interface{}, limit int) (*sql.Rows, error) {
+	columns := append([]string{q.key, q.text}, q.metadataColumns...)
+	stmt := fmt.Sprintf("SELECT %s FROM (%s) src", strings.Join(columns, ", "), q.source)
+	var args []interface{}
+	if lastKey != nil {
+		stmt += fmt.Sprintf(" WHERE %s > ?", q.key)
+		args = append(args, lastKey)
+	}
+	stmt += fmt.Sprintf(" ORDER BY %s LIMIT %d", q.key, limit)
+	return db.WithContext(ctx).Raw(stmt, args...).Rows()
+}
+
+// emitRows 逐行读取并输出文本，返回读取的行数和最后一行的 key
+func (q *dbQuery) emitRows(ctx context.Context, rows *sql.Rows, textChan chan<- *pb.RawText, collected *int32) (int, interface{}, error) {
+	count := 0
+	var lastKey interface{}
+	var text sql.NullString
+	metadata := make([]sql.NullString, len(q.metadataColumns))
+	dest := make([]interface{}, 0, len(metadata)+2)
+	dest = append(dest, &lastKey, &text)
+	for i := range metadata {
+		dest = append(dest, &metadata[i])
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, nil, fmt.Errorf("failed to scan source row: %w", err)
+		}
+		// 部分驱动复用 []byte 缓冲，需要复制后才能作为下一页的查询参数
+		if raw, ok := lastKey.([]byte); ok {
+			lastKey = string(raw)
+		}
+		count++
+
+		content := cleanText(text.String)
+		if !text.Valid || content == "" {
+			continue
+		}
+		meta := map[string]string{"key": fmt.Sprint(lastKey)}
+		for i, column := range q.metadataColumns {
+			if metadata[i].Valid {
+				meta[column] = metadata[i].String
+			}
+		}
+
+		rawText := &pb.RawText{
+			Id:        uuid.New().String(),
+			Content:   content,
+			Source:    "database",
+			Timestamp: time.Now().UnixMilli(),
+			Metadata:  meta,
+		}
+		select {
+		case textChan <- rawText:
+			*collected++
+		case <-ctx.Done():
+			return count, nil, ctx.Err()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, nil, fmt.Errorf("failed to read source rows: %w", err)
+	}
+	return count, lastKey, nil
+}
@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestSourceDB 创建包含 rows 条评论的 sqlite 源库，返回 DSN
+func newTestSourceDB(t *testing.T, rows int) string {
+	dsn := filepath.Join(t.TempDir(), "source.db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`CREATE TABLE comments (id INTEGER PRIMARY KEY, body TEXT, author TEXT, status TEXT)`).Error)
+	for i := 1; i <= rows; i++ {
+		status := "published"
+		if i%5 == 0 {
+			status = "hidden"
+		}
+		require.NoError(t, db.Exec(`INSERT INTO comments (id, body, author, status) VALUES (?, ?, ?, ?)`,
+			i, fmt.Sprintf("comment %d", i), fmt.Sprintf("user%d", i%3), status).Error)
+	}
+	// 空文本行被跳过但仍参与分页
+	require.NoError(t, db.Exec(`INSERT INTO comments (id, body, author, status) VALUES (?, NULL, 'nobody', 'published')`, rows+1).Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	return dsn
+}
+
+func newTestDBCollector(t *testing.T) *DBCollector {
+	c, err := NewDBCollector(&config.Config{})
+	require.NoError(t, err)
+	c.dialectors["sqlite"] = sqlite.Open
+	return c
+}
+
+func TestDBCollectorPagesThroughTable(t *testing.T) {
+	dsn := newTestSourceDB(t, 23)
+	source := &pb.CollectionSource{Type: pb.SourceType_DATABASE, Parameters: map[string]string{
+		"driver":           "sqlite",
+		"dsn":              dsn,
+		"table":            "comments",
+		"text_column":      "body",
+		"metadata_columns": "author, status",
+		"batch_size":       "4",
+	}}
+
+	texts := collectAll(t, newTestDBCollector(t), source, &pb.CollectionConfig{MaxCount: 100})
+
+	require.Len(t, texts, 23)
+	for i, text := range texts {
+		assert.Equal(t, fmt.Sprintf("comment %d", i+1), text.Content)
+		assert.Equal(t, fmt.Sprint(i+1), text.Metadata["key"])
+	}
+	assert.Equal(t, "user1", texts[0].Metadata["author"])
+	assert.Equal(t, "published", texts[0].Metadata["status"])
+}
+
+func TestDBCollectorCustomQueryAndMaxCount(t *testing.T) {
+	dsn := newTestSourceDB(t, 23)
+	source := &pb.CollectionSource{Type: pb.SourceType_DATABASE, Parameters: map[string]string{
+		"driver":      "sqlite",
+		"dsn":         dsn,
+		"query":       "SELECT id, body FROM comments WHERE status = 'published'",
+		"text_column": "body",
+		"batch_size":  "3",
+	}}
+
+	texts := collectAll(t, newTestDBCollector(t), source, &pb.CollectionConfig{MaxCount: 7})
+
+	assert.Equal(t, []string{
+		"comment 1", "comment 2", "comment 3", "comment 4", "comment 6", "comment 7", "comment 8",
+	}, contents(texts))
+}
+
+func TestParseDBQueryRejectsDangerousInput(t *testing.T) {
+	cases := map[string]map[string]string{
+		"not a select":        {"query": "DELETE FROM comments"},
+		"stacked statement":   {"query": "SELECT * FROM comments; DROP TABLE comments"},
+		"comment":             {"query": "SELECT * FROM comments -- x"},
+		"write in subquery":   {"query": "SELECT * FROM comments WHERE id IN (SELECT id FROM x) FOR UPDATE"},
+		"select into":         {"query": "SELECT * INTO backup FROM comments"},
+		"table injection":     {"table": "comments WHERE 1=1"},
+		"column injection":    {"table": "comments", "text_column": "body) FROM comments --"},
+		"metadata injection":  {"table": "comments", "metadata_columns": "author,1=1"},
+		"invalid batch size":  {"table": "comments", "batch_size": "0"},
+		"missing table/query": {},
+	}
+	for name, params := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseDBQuery(params)
+			assert.Error(t, err)
+		})
+	}
+
+	q, err := parseDBQuery(map[string]string{"query": "select id, content from posts where created_at > '2024-01-01'"})
+	require.NoError(t, err)
+	assert.Equal(t, "content", q.text)
+	assert.Equal(t, "id", q.key)
+	assert.Equal(t, dbDefaultBatchSize, q.batchSize)
+}
+
+func TestDBCollectorRejectsSQLiteDriver(t *testing.T) {
+	// 生产环境不支持 sqlite，避免通过采集请求在服务器任意路径创建或读取文件
+	c, err := NewDBCollector(&config.Config{})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "created.db")
+	err = c.Collect(context.Background(), &pb.CollectionSource{Parameters: map[string]string{
+		"driver": "sqlite",
+		"dsn":    path,
+		"table":  "comments",
+	}}, &pb.CollectionConfig{}, make(chan *pb.RawText, 1))
+	assert.True(t, IsPermanent(err))
+	assert.NoFileExists(t, path)
+}
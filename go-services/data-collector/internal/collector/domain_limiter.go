@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// DomainLimiter 按目标域名限速，采集器在每次发出请求前调用
+type DomainLimiter interface {
+	Wait(ctx context.Context, domain string) error
+}
+
+// tokenBucketScript 令牌桶取令牌：成功返回 0，否则返回需要等待的毫秒数。
+// 使用 Redis 服务端时间，避免各实例时钟不一致
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+// RedisDomainLimiter 基于 Redis 令牌桶的域名限速器，所有实例共享同一域名的速率；
+// Redis 不可用时退化为进程内限速
+type RedisDomainLimiter struct {
+	client      *redis.Client
+	defaultRate int
+	rates       map[string]int
+
+	mu    sync.Mutex
+	local map[string]*rate.Limiter
+}
+
+// NewRedisDomainLimiter 创建域名限速器；rates 为域名到每秒请求数的映射，
+// 同时匹配其子域名，未配置的域名使用 defaultRate
+func NewRedisDomainLimiter(client *redis.Client, defaultRate int, rates map[string]int) *RedisDomainLimiter {
+	return &RedisDomainLimiter{
+		client:      client,
+		defaultRate: defaultRate,
+		rates:       rates,
+		local:       make(map[string]*rate.Limiter),
+	}
+}
+
+// Wait 阻塞直到该域名有可用令牌或 ctx 结束
+func (l *RedisDomainLimiter) Wait(ctx context.Context, domain string) error {
+	domain = strings.ToLower(domain)
+	perSecond := l.rateFor(domain)
+	if perSecond <= 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf("collector:ratelimit:%s", domain)
+	for {
+		wait, err := tokenBucketScript.Run(ctx, l.client, []string{key}, perSecond, 1).Int64()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logrus.WithError(err).WithField("domain", domain).Warn("Redis rate limiter unavailable, falling back to local limiter")
+			return l.localLimiter(domain, perSecond).Wait(ctx)
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, time.Duration(wait)*time.Millisecond); err != nil {
+			return err
+		}
+	}
+}
+
+// rateFor 返回域名的限速，优先使用最长匹配的配置项
+func (l *RedisDomainLimiter) rateFor(domain string) int {
+	best, bestLen := l.defaultRate, -1
+	for configured, perSecond := range l.rates {
+		configured = strings.ToLower(configured)
+		if (domain == configured || strings.HasSuffix(domain, "."+configured)) && len(configured) > bestLen {
+			best, bestLen = perSecond, len(configured)
+		}
+	}
+	return best
+}
+
+func (l *RedisDomainLimiter) localLimiter(domain string, perSecond int) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.local[domain]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+		l.local[domain] = limiter
+	}
+	return limiter
+}
+
+// waitDomain 未设置限速器时直接返回
+func waitDomain(ctx context.Context, limiter DomainLimiter, domain string) error {
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx, domain)
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func newTestRedisClient(t *testing.T, mr *miniredis.Miniredis) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestDomainLimiterCapsCombinedRate(t *testing.T) {
+	// 无限翻页的接口，统计两个采集器的请求总数
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		fmt.Fprintf(w, `{"data":[{"id":"%d","content":"text %d"}],"has_more":true,"next_url":"http://%s/page/%d"}`, n, n, r.Host, n)
+	}))
+	t.Cleanup(server.Close)
+
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// 两个实例各自的进程内限速远高于域名全局限速
+	cfg := &config.Config{Collector: config.CollectorConfig{RateLimit: 1000, Timeout: 5 * time.Second}}
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		c, err := NewAPICollector(cfg)
+		require.NoError(t, err)
+		c.SetDomainLimiter(NewRedisDomainLimiter(newTestRedisClient(t, mr), 1000, map[string]int{"127.0.0.1": 10}))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source := &pb.CollectionSource{Type: pb.SourceType_API, Url: server.URL}
+			c.Collect(ctx, source, &pb.CollectionConfig{MaxCount: 100000}, make(chan *pb.RawText, 100000))
+		}()
+	}
+	wg.Wait()
+
+	// 1 秒内每秒 10 个令牌，加上初始的 1 个
+	got := atomic.LoadInt32(&requests)
+	assert.LessOrEqual(t, got, int32(12))
+	assert.GreaterOrEqual(t, got, int32(5))
+}
+
+func TestDomainLimiterFallsBackToLocalWhenRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	limiter := NewRedisDomainLimiter(newTestRedisClient(t, mr), 20, nil)
+	mr.Close()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Wait(context.Background(), "example.com"))
+	}
+	// 首个令牌立即可用，之后每 50ms 一个
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestDomainLimiterRateFor(t *testing.T) {
+	limiter := NewRedisDomainLimiter(nil, 10, map[string]int{
+		"zhihu.com":     5,
+		"api.zhihu.com": 2,
+	})
+
+	assert.Equal(t, 5, limiter.rateFor("zhihu.com"))
+	assert.Equal(t, 5, limiter.rateFor("www.zhihu.com"))
+	assert.Equal(t, 2, limiter.rateFor("api.zhihu.com"))
+	assert.Equal(t, 10, limiter.rateFor("notzhihu.com"))
+	assert.Equal(t, 10, limiter.rateFor("example.com"))
+}
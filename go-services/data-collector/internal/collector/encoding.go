@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decodingTransport 按 Content-Encoding 解压响应体。
+// 手动设置 Accept-Encoding 后标准库不再自动解压，colly 也只处理 gzip，
+// br/deflate 响应会被当作 HTML 解析成乱码
+type decodingTransport struct {
+	base http.RoundTripper
+}
+
+// newDecodingTransport 包装 base，base 为空时使用 http.DefaultTransport
+func newDecodingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &decodingTransport{base: base}
+}
+
+func (t *decodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+
+	body, err := decodeBody(resp.Header.Get("Content-Encoding"), resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if body != resp.Body {
+		resp.Body = body
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
+
+// decodeBody 返回解压后的响应体；不支持的编码原样返回
+func decodeBody(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	var reader io.Reader
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "br":
+		reader = brotli.NewReader(body)
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = gz
+	case "deflate":
+		// 规范要求 zlib 封装，但不少站点直接返回裸 deflate 数据
+		buffered := bufio.NewReader(body)
+		header, _ := buffered.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, err
+			}
+			reader = zr
+		} else {
+			reader = flate.NewReader(buffered)
+		}
+	default:
+		return body, nil
+	}
+	return &decodedBody{Reader: reader, body: body}, nil
+}
+
+// decodedBody 读取解压后的数据，关闭时同时关闭原始响应体
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (d *decodedBody) Close() error {
+	if closer, ok := d.Reader.(io.Closer); ok {
+		closer.Close()
+	}
+	return d.body.Close()
+}
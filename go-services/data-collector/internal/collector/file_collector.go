@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +21,24 @@ import (
 	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
 )
 
+// ResumeOffsetParam 采集源参数：TXT、JSONL 文件从该字节偏移开始读取，用于中断后续采
+const ResumeOffsetParam = "resume_offset"
+
+// FileOffsetMetadataKey TXT、JSONL 文本 Metadata 中记录该行结束处字节偏移的键，
+// 以此偏移续采时从下一行开始
+const FileOffsetMetadataKey = "file_offset"
+
+// DedupParam 采集源参数：为 true 时同一文件（压缩包视为一个文件）内内容相同的文本只采集一次，
+// 从 resume_offset 续采时只对偏移之后的内容去重
+const DedupParam = "dedup"
+
+// DedupCapacityParam 采集源参数：文件内去重的预期文本数，决定布隆过滤器的内存占用，默认 fileDedupCapacity
+const DedupCapacityParam = "dedup_capacity"
+
+// fileDedupCapacity 文件内去重默认的预期文本数，布隆过滤器约占 1.2MB 内存；
+// 超出后内存不再增长，但误判为重复而被跳过的文本逐渐增多
+const fileDedupCapacity = 1000000
+
 type FileCollector struct {
 	config *config.Config
 }
@@ -41,43 +61,136 @@ func (c *FileCollector) Collect(ctx context.Context, source *pb.CollectionSource
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist: %s", filePath)
+		return Permanent(fmt.Errorf("file does not exist: %s", filePath))
 	}
 
 	// 根据文件扩展名选择处理方法
 	ext := strings.ToLower(filepath.Ext(filePath))
 	
-	var err error
-	switch ext {
-	case ".txt":
-		err = c.collectFromTXT(ctx, filePath, config, textChan)
-	case ".csv":
-		err = c.collectFromCSV(ctx, filePath, source.Parameters, config, textChan)
-	case ".json":
-		err = c.collectFromJSON(ctx, filePath, config, textChan)
-	case ".jsonl":
-		err = c.collectFromJSONL(ctx, filePath, config, textChan)
-	default:
-		// 默认按文本文件处理
-		err = c.collectFromTXT(ctx, filePath, config, textChan)
+	dedup, err := newFileDedup(source.Parameters)
+	if err != nil {
+		return Permanent(err)
+	}
+	if ext == ".zip" {
+		err = c.collectFromZip(ctx, filePath, source.Parameters, config, dedup, textChan)
+	} else {
+		err = c.collectFromLocalFile(ctx, filePath, ext, source.Parameters, config, dedup, textChan)
 	}
 
 	if err != nil {
 		return fmt.Errorf("failed to collect from file: %w", err)
 	}
 
-	logrus.WithField("file_path", filePath).Info("File collection completed")
+	fields := logrus.Fields{"file_path": filePath}
+	if dedup != nil {
+		fields["duplicates_skipped"] = dedup.skipped
+	}
+	logrus.WithFields(fields).Info("File collection completed")
 	return nil
 }
 
-func (c *FileCollector) collectFromTXT(ctx context.Context, filePath string, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	file, err := os.Open(filePath)
+// fileDedup 单次文件采集内按内容去重，skipped 为跳过的重复文本数
+type fileDedup struct {
+	filter  *contentDedup
+	skipped int64
+}
+
+// newFileDedup 按 dedup、dedup_capacity 参数创建去重器，未开启时返回 nil
+func newFileDedup(params map[string]string) (*fileDedup, error) {
+	if enabled, _ := strconv.ParseBool(params[DedupParam]); !enabled {
+		return nil, nil
+	}
+	capacity := fileDedupCapacity
+	if raw := params[DedupCapacityParam]; raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", DedupCapacityParam, raw)
+		}
+		capacity = parsed
+	}
+	return &fileDedup{filter: newContentDedup(capacity)}, nil
+}
+
+// duplicate 判断 content 在本次采集中是否已出现过，出现过时计入 skipped；d 为 nil 时始终返回 false
+func (d *fileDedup) duplicate(content string) bool {
+	if d == nil || !d.filter.seen(content) {
+		return false
+	}
+	d.skipped++
+	return true
+}
+
+// fileInput 待解析的文件内容，来自本地文件或压缩包中的条目
+type fileInput struct {
+	reader io.Reader
+	// path 写入 Metadata 的 file_path，压缩包条目为压缩包路径
+	path string
+	// name 用于生成 Source 的文件名
+	name string
+	// entry 压缩包中的条目名，本地文件为空
+	entry string
+	// offset reader 起始处在本地文件中的字节偏移
+	offset int64
+	// dedup 为空时不去重，压缩包的各条目共用同一个去重器
+	dedup *fileDedup
+}
+
+// metadata 返回文本的基础元数据，压缩包条目额外记录条目名
+func (in *fileInput) metadata() map[string]string {
+	metadata := map[string]string{"file_path": in.path}
+	if in.entry != "" {
+		metadata[ArchiveEntryMetadataKey] = in.entry
+	}
+	return metadata
+}
+
+// setLineOffset 记录行结束处的字节偏移；压缩包条目的偏移无法用于续采，不记录
+func (in *fileInput) setLineOffset(metadata map[string]string, scanner *lineScanner) {
+	if in.entry == "" {
+		metadata[FileOffsetMetadataKey] = strconv.FormatInt(scanner.offset, 10)
+	}
+}
+
+// collectFromLocalFile 打开本地文件并按扩展名解析，TXT、JSONL 从 resume_offset 处开始读取
+func (c *FileCollector) collectFromLocalFile(ctx context.Context, filePath, ext string, params map[string]string, config *pb.CollectionConfig, dedup *fileDedup, textChan chan<- *pb.RawText) error {
+	var file *os.File
+	var offset int64
+	var err error
+	if ext == ".csv" || ext == ".json" {
+		file, err = os.Open(filePath)
+		if err != nil {
+			err = fmt.Errorf("failed to open file: %w", err)
+		}
+	} else {
+		file, offset, err = openAtResumeOffset(filePath, params)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	in := &fileInput{reader: file, path: filePath, name: filepath.Base(filePath), offset: offset, dedup: dedup}
+	_, err = c.collectInput(ctx, ext, in, params, config, textChan)
+	return err
+}
+
+// collectInput 按扩展名选择解析方法，返回采集的文本数量
+func (c *FileCollector) collectInput(ctx context.Context, ext string, in *fileInput, params map[string]string, config *pb.CollectionConfig, textChan chan<- *pb.RawText) (int32, error) {
+	switch ext {
+	case ".csv":
+		return c.collectFromCSV(ctx, in, params, config, textChan)
+	case ".json":
+		return c.collectFromJSON(ctx, in, config, textChan)
+	case ".jsonl":
+		return c.collectFromJSONL(ctx, in, config, textChan)
+	default:
+		// 默认按文本文件处理
+		return c.collectFromTXT(ctx, in, config, textChan)
+	}
+}
+
+func (c *FileCollector) collectFromTXT(ctx context.Context, in *fileInput, config *pb.CollectionConfig, textChan chan<- *pb.RawText) (int32, error) {
+	scanner := c.newLineScanner(in.reader, in.offset)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -87,24 +200,25 @@ func (c *FileCollector) collectFromTXT(ctx context.Context, filePath string, con
 	for scanner.Scan() && collected < maxCount {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		default:
 		}
 
 		line := strings.TrimSpace(scanner.Text())
-		if !c.applyFilters(line, config.Filters) {
+		if !ApplyFilters(line, config.Filters) || in.dedup.duplicate(line) {
 			continue
 		}
 
+		metadata := in.metadata()
+		metadata["line_num"] = fmt.Sprintf("%d", collected+1)
+		in.setLineOffset(metadata, scanner)
+
 		rawText := &pb.RawText{
 			Id:        uuid.New().String(),
 			Content:   line,
-			Source:    fmt.Sprintf("file:%s", filepath.Base(filePath)),
+			Source:    fmt.Sprintf("file:%s", in.name),
 			Timestamp: time.Now().UnixMilli(),
-			Metadata: map[string]string{
-				"file_path": filePath,
-				"line_num":  fmt.Sprintf("%d", collected+1),
-			},
+			Metadata:  metadata,
 		}
 
 		select {
@@ -114,42 +228,70 @@ func (c *FileCollector) collectFromTXT(ctx context.Context, filePath string, con
 				logrus.WithField("collected", collected).Debug("Progress update")
 			}
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+	if err := c.scanError(scanner); err != nil {
+		return collected, err
 	}
 
 	logrus.WithField("total_collected", collected).Info("TXT file processing completed")
-	return nil
+	return collected, nil
 }
 
-func (c *FileCollector) collectFromCSV(ctx context.Context, filePath string, params map[string]string, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+func (c *FileCollector) collectFromCSV(ctx context.Context, in *fileInput, params map[string]string, config *pb.CollectionConfig, textChan chan<- *pb.RawText) (int32, error) {
+	// 去掉 UTF-8 BOM，否则首个表头带 BOM 导致文本列匹配不上
+	buffered := bufio.NewReaderSize(in.reader, csvSniffSize)
+	if bom, _ := buffered.Peek(len(utf8BOM)); string(bom) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(buffered)
 	
-	// 设置CSV参数
+	// 设置CSV参数，未指定分隔符时根据首行推断
 	if delimiter, exists := params["delimiter"]; exists && len(delimiter) > 0 {
 		reader.Comma = rune(delimiter[0])
+	} else {
+		head, _ := buffered.Peek(csvSniffSize)
+		reader.Comma = sniffDelimiter(head)
 	}
 	
 	// 读取表头
 	headers, err := reader.Read()
 	if err != nil {
-		return fmt.Errorf("failed to read CSV headers: %w", err)
+		return 0, fmt.Errorf("failed to read CSV headers: %w", err)
+	}
+
+	// 确定文本列索引，text_columns 指定多列时按顺序以 text_separator 拼接
+	textColumns, err := c.findTextColumns(headers, params)
+	if err != nil {
+		return 0, Permanent(err)
+	}
+	separator := "\n"
+	if sep, exists := params["text_separator"]; exists {
+		separator = sep
+	}
+	isTextColumn := make(map[int]bool, len(textColumns))
+	for _, i := range textColumns {
+		isTextColumn[i] = true
 	}
 
-	// 确定文本列索引
-	textColumnIndex := c.findTextColumn(headers, params)
-	if textColumnIndex == -1 {
-		return fmt.Errorf("no text column found in CSV")
+	// 标注列：label_column 指定的列解析为整数标签，写入 Metadata 供保存时生成 ProcessedText
+	labelColumnIndex := -1
+	var labelMap map[string]int
+	if labelColumn := params["label_column"]; labelColumn != "" {
+		for i, header := range headers {
+			if header == labelColumn {
+				labelColumnIndex = i
+				break
+			}
+		}
+		if labelColumnIndex == -1 {
+			return 0, Permanent(fmt.Errorf("label column %q not found in CSV", labelColumn))
+		}
+		if labelMap, err = parseLabelMap(params["label_map"]); err != nil {
+			return 0, Permanent(err)
+		}
 	}
 
 	collected := int32(0)
@@ -161,7 +303,7 @@ func (c *FileCollector) collectFromCSV(ctx context.Context, filePath string, par
 	for collected < maxCount {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		default:
 		}
 
@@ -174,32 +316,53 @@ func (c *FileCollector) collectFromCSV(ctx context.Context, filePath string, par
 			continue
 		}
 
-		if textColumnIndex >= len(record) {
+		if textColumns[0] >= len(record) {
 			continue
 		}
 
-		content := strings.TrimSpace(record[textColumnIndex])
-		if !c.applyFilters(content, config.Filters) {
+		parts := make([]string, 0, len(textColumns))
+		for _, i := range textColumns {
+			if i < len(record) {
+				if part := strings.TrimSpace(record[i]); part != "" {
+					parts = append(parts, part)
+				}
+			}
+		}
+		content := strings.Join(parts, separator)
+		if !ApplyFilters(content, config.Filters) || in.dedup.duplicate(content) {
 			continue
 		}
 
 		// 构建元数据
-		metadata := map[string]string{
-			"file_path": filePath,
-			"row_num":   fmt.Sprintf("%d", collected+2), // +2 因为有表头且从1开始计数
-		}
+		metadata := in.metadata()
+		metadata["row_num"] = fmt.Sprintf("%d", collected+2) // +2 因为有表头且从1开始计数
 
 		// 添加其他列作为元数据
 		for i, header := range headers {
-			if i != textColumnIndex && i < len(record) {
+			if !isTextColumn[i] && i != labelColumnIndex && i < len(record) {
 				metadata[header] = record[i]
 			}
 		}
 
+		if labelColumnIndex >= 0 {
+			raw := ""
+			if labelColumnIndex < len(record) {
+				raw = record[labelColumnIndex]
+			}
+			if label, ok := parseLabel(raw, labelMap); ok {
+				metadata[LabelMetadataKey] = strconv.Itoa(label)
+			} else {
+				logrus.WithFields(logrus.Fields{
+					"row_num": metadata["row_num"],
+					"label":   raw,
+				}).Warn("Missing or unparseable CSV label, leaving text unlabeled")
+			}
+		}
+
 		rawText := &pb.RawText{
 			Id:        uuid.New().String(),
 			Content:   content,
-			Source:    fmt.Sprintf("csv:%s", filepath.Base(filePath)),
+			Source:    fmt.Sprintf("csv:%s", in.name),
 			Timestamp: time.Now().UnixMilli(),
 			Metadata:  metadata,
 		}
@@ -211,25 +374,19 @@ func (c *FileCollector) collectFromCSV(ctx context.Context, filePath string, par
 				logrus.WithField("collected", collected).Debug("Progress update")
 			}
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		}
 	}
 
 	logrus.WithField("total_collected", collected).Info("CSV file processing completed")
-	return nil
+	return collected, nil
 }
 
-func (c *FileCollector) collectFromJSON(ctx context.Context, filePath string, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
+func (c *FileCollector) collectFromJSON(ctx context.Context, in *fileInput, config *pb.CollectionConfig, textChan chan<- *pb.RawText) (int32, error) {
 	var data []JSONTextItem
-	decoder := json.NewDecoder(file)
+	decoder := json.NewDecoder(in.reader)
 	if err := decoder.Decode(&data); err != nil {
-		return fmt.Errorf("failed to decode JSON: %w", err)
+		return 0, fmt.Errorf("failed to decode JSON: %w", err)
 	}
 
 	collected := int32(0)
@@ -245,18 +402,16 @@ func (c *FileCollector) collectFromJSON(ctx context.Context, filePath string, co
 
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		default:
 		}
 
-		if !c.applyFilters(item.Content, config.Filters) {
+		if !ApplyFilters(item.Content, config.Filters) || in.dedup.duplicate(item.Content) {
 			continue
 		}
 
-		metadata := map[string]string{
-			"file_path": filePath,
-			"index":     fmt.Sprintf("%d", i),
-		}
+		metadata := in.metadata()
+		metadata["index"] = fmt.Sprintf("%d", i)
 
 		// 添加item中的元数据
 		for k, v := range item.Meta {
@@ -265,7 +420,7 @@ func (c *FileCollector) collectFromJSON(ctx context.Context, filePath string, co
 
 		source := item.Source
 		if source == "" {
-			source = fmt.Sprintf("json:%s", filepath.Base(filePath))
+			source = fmt.Sprintf("json:%s", in.name)
 		}
 
 		rawText := &pb.RawText{
@@ -280,22 +435,16 @@ func (c *FileCollector) collectFromJSON(ctx context.Context, filePath string, co
 		case textChan <- rawText:
 			collected++
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		}
 	}
 
 	logrus.WithField("total_collected", collected).Info("JSON file processing completed")
-	return nil
+	return collected, nil
 }
 
-func (c *FileCollector) collectFromJSONL(ctx context.Context, filePath string, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
+func (c *FileCollector) collectFromJSONL(ctx context.Context, in *fileInput, config *pb.CollectionConfig, textChan chan<- *pb.RawText) (int32, error) {
+	scanner := c.newLineScanner(in.reader, in.offset)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -308,7 +457,7 @@ func (c *FileCollector) collectFromJSONL(ctx context.Context, filePath string, c
 		
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		default:
 		}
 
@@ -323,14 +472,13 @@ func (c *FileCollector) collectFromJSONL(ctx context.Context, filePath string, c
 			continue
 		}
 
-		if !c.applyFilters(item.Content, config.Filters) {
+		if !ApplyFilters(item.Content, config.Filters) || in.dedup.duplicate(item.Content) {
 			continue
 		}
 
-		metadata := map[string]string{
-			"file_path": filePath,
-			"line_num":  fmt.Sprintf("%d", lineNum),
-		}
+		metadata := in.metadata()
+		metadata["line_num"] = fmt.Sprintf("%d", lineNum)
+		in.setLineOffset(metadata, scanner)
 
 		// 添加item中的元数据
 		for k, v := range item.Meta {
@@ -339,7 +487,7 @@ func (c *FileCollector) collectFromJSONL(ctx context.Context, filePath string, c
 
 		source := item.Source
 		if source == "" {
-			source = fmt.Sprintf("jsonl:%s", filepath.Base(filePath))
+			source = fmt.Sprintf("jsonl:%s", in.name)
 		}
 
 		rawText := &pb.RawText{
@@ -357,16 +505,158 @@ func (c *FileCollector) collectFromJSONL(ctx context.Context, filePath string, c
 				logrus.WithField("collected", collected).Debug("Progress update")
 			}
 		case <-ctx.Done():
-			return ctx.Err()
+			return collected, ctx.Err()
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+	if err := c.scanError(scanner); err != nil {
+		return collected, err
 	}
 
 	logrus.WithField("total_collected", collected).Info("JSONL file processing completed")
-	return nil
+	return collected, nil
+}
+
+// defaultMaxLineBytes 未配置 collector.max_line_bytes 时单行的最大字节数
+const defaultMaxLineBytes = 4 << 20
+
+// maxLineBytes 返回 TXT、JSONL 文件单行的最大字节数
+func (c *FileCollector) maxLineBytes() int {
+	if c.config != nil && c.config.Collector.MaxLineBytes > 0 {
+		return c.config.Collector.MaxLineBytes
+	}
+	return defaultMaxLineBytes
+}
+
+// lineScanner 按行读取并记录当前行结束处（含换行符）在文件中的字节偏移
+type lineScanner struct {
+	*bufio.Scanner
+	offset int64
+}
+
+// newLineScanner 创建从文件 offset 处开始按行读取的 Scanner，单行上限为 maxLineBytes 而非默认的 64KB
+func (c *FileCollector) newLineScanner(r io.Reader, offset int64) *lineScanner {
+	// 初始缓冲区不能超过上限，否则上限以缓冲区容量为准
+	maxBytes := c.maxLineBytes()
+	scanner := &lineScanner{Scanner: bufio.NewScanner(r), offset: offset}
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, maxBytes)), maxBytes)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		scanner.offset += int64(advance)
+		return advance, token, err
+	})
+	return scanner
+}
+
+// openAtResumeOffset 打开文件并定位到 source 参数 resume_offset 指定的字节偏移，未指定时从头读取
+func openAtResumeOffset(filePath string, params map[string]string) (*os.File, int64, error) {
+	var offset int64
+	if raw := params[ResumeOffsetParam]; raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			return nil, 0, Permanent(fmt.Errorf("invalid %s %q", ResumeOffsetParam, raw))
+		}
+		offset = parsed
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, 0, fmt.Errorf("failed to seek to offset %d: %w", offset, err)
+		}
+		logrus.WithFields(logrus.Fields{
+			"file_path": filePath,
+			"offset":    offset,
+		}).Info("Resuming file collection from checkpoint")
+	}
+	return file, offset, nil
+}
+
+// scanError 包装 Scanner 的读取错误，单行超出上限时提示调整 max_line_bytes
+func (c *FileCollector) scanError(scanner *lineScanner) error {
+	err := scanner.Err()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, bufio.ErrTooLong) {
+		return Permanent(fmt.Errorf("line exceeds collector.max_line_bytes (%d bytes): %w", c.maxLineBytes(), err))
+	}
+	return fmt.Errorf("error reading file: %w", err)
+}
+
+// utf8BOM UTF-8 字节序标记
+const utf8BOM = "\xef\xbb\xbf"
+
+// csvSniffSize 推断 CSV 分隔符时最多读取的字节数
+const csvSniffSize = 64 * 1024
+
+// csvDelimiters 可自动识别的 CSV 分隔符
+var csvDelimiters = []rune{',', '\t', ';'}
+
+// sniffDelimiter 统计首行中引号外各候选分隔符的出现次数，取最多者；都未出现时使用逗号
+func sniffDelimiter(head []byte) rune {
+	line := string(head)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	counts := make(map[rune]int, len(csvDelimiters))
+	quoted := false
+	for _, r := range line {
+		if r == '"' {
+			quoted = !quoted
+			continue
+		}
+		if !quoted {
+			counts[r]++
+		}
+	}
+
+	best := ','
+	for _, d := range csvDelimiters {
+		if counts[d] > counts[best] {
+			best = d
+		}
+	}
+	return best
+}
+
+// findTextColumns 解析 text_columns 参数指定的文本列（逗号分隔，按顺序拼接），
+// 未指定时退回 findTextColumn 确定的单个文本列
+func (c *FileCollector) findTextColumns(headers []string, params map[string]string) ([]int, error) {
+	var names []string
+	for _, name := range strings.Split(params["text_columns"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		index := c.findTextColumn(headers, params)
+		if index == -1 {
+			return nil, fmt.Errorf("no text column found in CSV")
+		}
+		return []int{index}, nil
+	}
+
+	columns := make([]int, 0, len(names))
+	for _, name := range names {
+		index := -1
+		for i, header := range headers {
+			if header == name {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return nil, fmt.Errorf("text column %q not found in CSV", name)
+		}
+		columns = append(columns, index)
+	}
+	return columns, nil
 }
 
 func (c *FileCollector) findTextColumn(headers []string, params map[string]string) int {
@@ -395,49 +685,4 @@ func (c *FileCollector) findTextColumn(headers []string, params map[string]strin
 	}
 
 	return -1
-}
-
-func (c *FileCollector) applyFilters(content string, filters []string) bool {
-	if len(filters) == 0 {
-		return true
-	}
-
-	content = strings.TrimSpace(content)
-	
-	// 基本长度过滤
-	if len(content) < 5 || len(content) > 2000 {
-		return false
-	}
-
-	// 应用自定义过滤器
-	for _, filter := range filters {
-		switch filter {
-		case "no_empty":
-			if content == "" {
-				return false
-			}
-		case "no_short":
-			if len(content) < 10 {
-				return false
-			}
-		case "no_long":
-			if len(content) > 500 {
-				return false
-			}
-		case "no_url":
-			if strings.Contains(content, "http://") || strings.Contains(content, "https://") {
-				return false
-			}
-		case "no_email":
-			if strings.Contains(content, "@") && strings.Contains(content, ".") {
-				return false
-			}
-		case "chinese_only":
-			if !containsChinese(content) {
-				return false
-			}
-		}
-	}
-
-	return true
 }
\ No newline at end of file
@@ -0,0 +1,265 @@
+package collector
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// collectTestFile 将 content 写入临时目录下的 name 文件并采集，返回全部采集结果
+func collectTestFile(t *testing.T, name, content string, params map[string]string) []*pb.RawText {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	c, err := NewFileCollector(&config.Config{})
+	require.NoError(t, err)
+	textChan := make(chan *pb.RawText, 100)
+	source := &pb.CollectionSource{Type: pb.SourceType_LOCAL_FILE, FilePath: path, Parameters: params}
+	require.NoError(t, c.Collect(context.Background(), source, &pb.CollectionConfig{}, textChan))
+	close(textChan)
+
+	var texts []*pb.RawText
+	for text := range textChan {
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+func TestCollectCSVParsesLabelColumn(t *testing.T) {
+	content := "text,category,label\n" +
+		"今天天气不错,chat,正常\n" +
+		"加微信领取优惠券,ads,spam\n" +
+		"这条评论没有标注,chat,\n" +
+		"标注写错了,chat,unknown\n" +
+		"数值标签,chat,1\n"
+	texts := collectTestFile(t, "labeled.csv", content, map[string]string{"label_column": "label"})
+	require.Len(t, texts, 5)
+
+	labels := make(map[string]string)
+	for _, text := range texts {
+		if label, ok := text.Metadata[LabelMetadataKey]; ok {
+			labels[text.Content] = label
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"今天天气不错":   "0",
+		"加微信领取优惠券": "1",
+		"数值标签":     "1",
+	}, labels)
+	// 其他列仍作为元数据保留
+	assert.Equal(t, "ads", texts[1].Metadata["category"])
+}
+
+func TestCollectCSVLabelMap(t *testing.T) {
+	content := "content,tag\n好评,good\n差评,bad\n"
+	texts := collectTestFile(t, "mapped.csv", content, map[string]string{"label_column": "tag", "label_map": "good:0,bad:1"})
+	require.Len(t, texts, 2)
+	assert.Equal(t, "0", texts[0].Metadata[LabelMetadataKey])
+	assert.Equal(t, "1", texts[1].Metadata[LabelMetadataKey])
+}
+
+func TestCollectCSVConcatenatesTextColumns(t *testing.T) {
+	content := "id,title,body,author\n" +
+		"1,标题一,正文一,alice\n" +
+		"2,标题二,,bob\n"
+	texts := collectTestFile(t, "articles.csv", content, map[string]string{"text_columns": "title, body", "text_separator": " | "})
+	require.Len(t, texts, 2)
+	assert.Equal(t, "标题一 | 正文一", texts[0].Content)
+	// 空列不参与拼接
+	assert.Equal(t, "标题二", texts[1].Content)
+	assert.Equal(t, "alice", texts[0].Metadata["author"])
+	assert.Equal(t, "1", texts[0].Metadata["id"])
+	assert.NotContains(t, texts[0].Metadata, "title")
+	assert.NotContains(t, texts[0].Metadata, "body")
+
+	// 只指定一列时与 text_column 行为一致
+	texts = collectTestFile(t, "articles.csv", content, map[string]string{"text_columns": "body"})
+	require.Len(t, texts, 2)
+	assert.Equal(t, "正文一", texts[0].Content)
+	assert.Equal(t, "标题一", texts[0].Metadata["title"])
+}
+
+func TestCollectCSVSniffsDelimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"tab", "id\ttext\tauthor\n1\t你好，世界\talice\n"},
+		{"semicolon", "id;text;author\n1;你好，世界;alice\n"},
+		// 引号内的逗号不参与推断
+		{"semicolon with quoted comma", "id;text;\"name,nick\"\n1;你好，世界;alice\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			texts := collectTestFile(t, "data.csv", tt.content, nil)
+			require.Len(t, texts, 1)
+			assert.Equal(t, "你好，世界", texts[0].Content)
+			assert.Equal(t, "1", texts[0].Metadata["id"])
+		})
+	}
+}
+
+func TestCollectCSVStripsBOM(t *testing.T) {
+	// 带 BOM 时首个表头不等于 "text"，自动检测会误选后面的 description 列
+	content := "\ufefftext,description\n正文内容,补充说明\n"
+	texts := collectTestFile(t, "bom.csv", content, nil)
+	require.Len(t, texts, 1)
+	assert.Equal(t, "正文内容", texts[0].Content)
+	assert.Equal(t, "补充说明", texts[0].Metadata["description"])
+
+	texts = collectTestFile(t, "bom.csv", "\ufeffid,body\n7,带 BOM 的文本\n", map[string]string{"text_column": "body"})
+	require.Len(t, texts, 1)
+	assert.Equal(t, "7", texts[0].Metadata["id"])
+}
+
+func TestCollectReadsLinesLongerThan64KB(t *testing.T) {
+	long := strings.Repeat("长", 40*1024) // 120KB，超过 bufio.Scanner 默认的 64KB 上限
+
+	texts := collectTestFile(t, "long.jsonl", `{"content":"`+long+`"}`+"\n"+`{"content":"short"}`+"\n", nil)
+	require.Len(t, texts, 2)
+	assert.Equal(t, long, texts[0].Content)
+	assert.Equal(t, "short", texts[1].Content)
+
+	texts = collectTestFile(t, "long.txt", long+"\nshort\n", nil)
+	require.Len(t, texts, 2)
+	assert.Equal(t, long, texts[0].Content)
+}
+
+func TestCollectLineOverConfiguredLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "long.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("a", 2048)+"\n"), 0o644))
+
+	c, err := NewFileCollector(&config.Config{Collector: config.CollectorConfig{MaxLineBytes: 1024}})
+	require.NoError(t, err)
+	source := &pb.CollectionSource{Type: pb.SourceType_LOCAL_FILE, FilePath: path}
+	err = c.Collect(context.Background(), source, &pb.CollectionConfig{}, make(chan *pb.RawText, 1))
+	require.Error(t, err)
+	assert.True(t, IsPermanent(err))
+	assert.Contains(t, err.Error(), "max_line_bytes")
+}
+
+func TestCollectResumesFromFileOffset(t *testing.T) {
+	for _, name := range []string{"lines.txt", "lines.jsonl"} {
+		t.Run(name, func(t *testing.T) {
+			content := "第一行\r\n第二行\n第三行"
+			if strings.HasSuffix(name, ".jsonl") {
+				content = `{"content":"第一行"}` + "\r\n" + `{"content":"第二行"}` + "\n" + `{"content":"第三行"}`
+			}
+			texts := collectTestFile(t, name, content, nil)
+			require.Len(t, texts, 3)
+			// 偏移指向行尾换行符之后，最后一行没有换行符时为文件长度
+			assert.Equal(t, strconv.Itoa(len(content)), texts[2].Metadata[FileOffsetMetadataKey])
+
+			resumed := collectTestFile(t, name, content, map[string]string{ResumeOffsetParam: texts[0].Metadata[FileOffsetMetadataKey]})
+			require.Len(t, resumed, 2)
+			assert.Equal(t, "第二行", resumed[0].Content)
+			assert.Equal(t, texts[1].Metadata[FileOffsetMetadataKey], resumed[0].Metadata[FileOffsetMetadataKey])
+			assert.Equal(t, "第三行", resumed[1].Content)
+		})
+	}
+}
+
+// zipContent 按顺序将 entries 写入 zip 压缩包，返回压缩包内容
+func zipContent(t *testing.T, entries [][2]string) string {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := writer.Create(entry[0])
+		require.NoError(t, err)
+		_, err = io.WriteString(w, entry[1])
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buf.String()
+}
+
+func TestCollectZipDispatchesEntriesByExtension(t *testing.T) {
+	content := zipContent(t, [][2]string{
+		{"docs/comments.txt", "第一条评论\n第二条评论\n"},
+		{"docs/", ""},
+		{"posts.jsonl", `{"content":"帖子正文","meta":{"author":"alice"}}` + "\n"},
+	})
+	texts := collectTestFile(t, "dataset.zip", content, nil)
+	require.Len(t, texts, 3)
+
+	assert.Equal(t, "第一条评论", texts[0].Content)
+	assert.Equal(t, "file:comments.txt", texts[0].Source)
+	assert.Equal(t, "docs/comments.txt", texts[0].Metadata[ArchiveEntryMetadataKey])
+	assert.Equal(t, "第二条评论", texts[1].Content)
+	assert.Equal(t, "帖子正文", texts[2].Content)
+	assert.Equal(t, "posts.jsonl", texts[2].Metadata[ArchiveEntryMetadataKey])
+	assert.Equal(t, "alice", texts[2].Metadata["author"])
+	for _, text := range texts {
+		assert.True(t, strings.HasSuffix(text.Metadata["file_path"], "dataset.zip"))
+		// 条目内的偏移无法用于续采
+		assert.NotContains(t, text.Metadata, FileOffsetMetadataKey)
+	}
+}
+
+func TestCollectZipMaxCountSpansEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dataset.zip")
+	require.NoError(t, os.WriteFile(path, []byte(zipContent(t, [][2]string{
+		{"a.txt", "1\n2\n3\n"},
+		{"b.jsonl", `{"content":"4"}` + "\n" + `{"content":"5"}` + "\n"},
+		{"c.txt", "6\n"},
+	})), 0o644))
+
+	c, err := NewFileCollector(&config.Config{})
+	require.NoError(t, err)
+	textChan := make(chan *pb.RawText, 10)
+	source := &pb.CollectionSource{Type: pb.SourceType_LOCAL_FILE, FilePath: path}
+	require.NoError(t, c.Collect(context.Background(), source, &pb.CollectionConfig{MaxCount: 4}, textChan))
+	close(textChan)
+
+	var contents []string
+	for text := range textChan {
+		contents = append(contents, text.Content)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, contents)
+}
+
+func TestCollectDedupSkipsRepeatedLines(t *testing.T) {
+	content := "重复的评论\n第一条\n重复的评论\n  重复的评论  \n第二条\n第一条\n重复的评论\n"
+	texts := collectTestFile(t, "dump.txt", content, map[string]string{DedupParam: "true"})
+	assert.Equal(t, []string{"重复的评论", "第一条", "第二条"}, contents(texts))
+
+	// 未开启时每行都采集
+	assert.Len(t, collectTestFile(t, "dump.txt", content, nil), 7)
+
+	// 压缩包内跨条目去重
+	archive := zipContent(t, [][2]string{
+		{"a.txt", "甲\n乙\n"},
+		{"b.jsonl", `{"content":"乙"}` + "\n" + `{"content":"丙"}` + "\n"},
+	})
+	texts = collectTestFile(t, "dump.zip", archive, map[string]string{DedupParam: "true"})
+	assert.Equal(t, []string{"甲", "乙", "丙"}, contents(texts))
+}
+
+func TestFileDedupCountsSkipped(t *testing.T) {
+	dedup, err := newFileDedup(map[string]string{DedupParam: "true", DedupCapacityParam: "100"})
+	require.NoError(t, err)
+	for _, line := range []string{"a", "b", "a", "a", "c"} {
+		dedup.duplicate(line)
+	}
+	assert.Equal(t, int64(2), dedup.skipped)
+
+	dedup, err = newFileDedup(map[string]string{DedupParam: "false"})
+	require.NoError(t, err)
+	assert.Nil(t, dedup)
+	assert.False(t, dedup.duplicate("a"))
+
+	_, err = newFileDedup(map[string]string{DedupParam: "true", DedupCapacityParam: "0"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CollectionConfig.Filters 支持的过滤规则，所有采集器共用同一套规则：
+//   - no_empty、no_short、no_long：拒绝空文本、少于 shortContentLength 或多于 longContentLength 个字符的文本
+//   - no_url、no_email：拒绝包含链接或邮箱地址的文本
+//   - chinese_only：拒绝不含中文的文本
+//   - min_length:N、max_length:N：覆盖基本长度范围，默认 defaultMinContentLength-defaultMaxContentLength 个字符
+//   - regex:PATTERN：文本须匹配该正则，配置多条时须全部匹配；无效的正则拒绝所有文本
+//   - keyword:WORD：文本须包含至少一条 keyword 规则中的关键词，不区分大小写
+//
+// 配置了任意规则时，纯数字或符号的文本与超出基本长度范围的文本总是被拒绝；未知规则被忽略
+const (
+	FilterNoEmpty     = "no_empty"
+	FilterNoShort     = "no_short"
+	FilterNoLong      = "no_long"
+	FilterNoURL       = "no_url"
+	FilterNoEmail     = "no_email"
+	FilterChineseOnly = "chinese_only"
+
+	minLengthFilterPrefix = "min_length:"
+	maxLengthFilterPrefix = "max_length:"
+	regexFilterPrefix     = "regex:"
+	keywordFilterPrefix   = "keyword:"
+)
+
+// 内容长度过滤的默认范围与 no_short、no_long 的阈值（字符数）
+const (
+	defaultMinContentLength = 5
+	defaultMaxContentLength = 2000
+	shortContentLength      = 10
+	longContentLength       = 500
+)
+
+// filterPatterns 编译后的 regex 规则，按正则文本缓存；编译失败时缓存 nil
+var filterPatterns sync.Map
+
+// ApplyFilters 按 CollectionConfig.Filters 判断文本是否保留，filters 为空时保留所有文本
+func ApplyFilters(content string, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	content = strings.TrimSpace(content)
+
+	// 基本长度过滤，范围来自 min_length/max_length 规则
+	if minLength, maxLength := contentLengthBounds(filters); !isValidTextLength(content, minLength, maxLength) {
+		return false
+	}
+
+	// 过滤纯数字或特殊字符
+	if isOnlyNumbersOrSymbols(content) {
+		return false
+	}
+
+	length := utf8.RuneCountInString(content)
+	lowered := strings.ToLower(content)
+	var keywords []string
+	for _, filter := range filters {
+		switch {
+		case filter == FilterNoEmpty:
+			if content == "" {
+				return false
+			}
+		case filter == FilterNoShort:
+			if length < shortContentLength {
+				return false
+			}
+		case filter == FilterNoLong:
+			if length > longContentLength {
+				return false
+			}
+		case filter == FilterNoURL:
+			if strings.Contains(lowered, "http://") || strings.Contains(lowered, "https://") {
+				return false
+			}
+		case filter == FilterNoEmail:
+			if strings.Contains(content, "@") && strings.Contains(content, ".") {
+				return false
+			}
+		case filter == FilterChineseOnly:
+			if !containsChinese(content) {
+				return false
+			}
+		case strings.HasPrefix(filter, regexFilterPrefix):
+			pattern := filterPattern(strings.TrimPrefix(filter, regexFilterPrefix))
+			if pattern == nil || !pattern.MatchString(content) {
+				return false
+			}
+		case strings.HasPrefix(filter, keywordFilterPrefix):
+			if keyword := strings.TrimPrefix(filter, keywordFilterPrefix); keyword != "" {
+				keywords = append(keywords, strings.ToLower(keyword))
+			}
+		}
+	}
+
+	if len(keywords) > 0 {
+		for _, keyword := range keywords {
+			if strings.Contains(lowered, keyword) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// contentLengthBounds 从 Filters 的 min_length:N 与 max_length:N 解析长度范围，
+// 未配置、不是非负整数或最小值大于最大值时使用默认范围
+func contentLengthBounds(filters []string) (minLength, maxLength int) {
+	minLength, maxLength = defaultMinContentLength, defaultMaxContentLength
+	for _, filter := range filters {
+		if value, ok := strings.CutPrefix(filter, minLengthFilterPrefix); ok {
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				minLength = n
+			}
+		}
+		if value, ok := strings.CutPrefix(filter, maxLengthFilterPrefix); ok {
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				maxLength = n
+			}
+		}
+	}
+	if minLength > maxLength {
+		return defaultMinContentLength, defaultMaxContentLength
+	}
+	return minLength, maxLength
+}
+
+// filterPattern 返回编译后的 regex 规则，无效的正则只记录一次日志并返回 nil
+func filterPattern(expr string) *regexp.Regexp {
+	if cached, ok := filterPatterns.Load(expr); ok {
+		return cached.(*regexp.Regexp)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		logrus.WithError(err).WithField("pattern", expr).Warn("Invalid regex filter, rejecting all content")
+	}
+	filterPatterns.Store(expr, pattern)
+	return pattern
+}
+
+// isOnlyNumbersOrSymbols 判断文本是否不含字母与中文
+func isOnlyNumbersOrSymbols(text string) bool {
+	for _, r := range text {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= 0x4e00 && r <= 0x9fff) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func TestContentLengthBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []string
+		min, max int
+	}{
+		{"defaults", []string{"no_url"}, defaultMinContentLength, defaultMaxContentLength},
+		{"both bounds", []string{"min_length:2", "max_length:8"}, 2, 8},
+		{"only max", []string{"max_length:100"}, defaultMinContentLength, 100},
+		{"invalid value", []string{"min_length:abc", "max_length:-1"}, defaultMinContentLength, defaultMaxContentLength},
+		{"min above max", []string{"min_length:10", "max_length:3"}, defaultMinContentLength, defaultMaxContentLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLength, maxLength := contentLengthBounds(tt.filters)
+			assert.Equal(t, tt.min, minLength)
+			assert.Equal(t, tt.max, maxLength)
+		})
+	}
+}
+
+// filterCases 所有采集器共用的过滤规则用例
+var filterCases = []struct {
+	name    string
+	filters []string
+	inputs  []string
+	want    []string
+}{
+	{
+		name:    "default length bounds count characters",
+		filters: []string{FilterNoEmpty},
+		inputs:  []string{"短文", "中文内容五个字", strings.Repeat("字", 700), strings.Repeat("字", 2001)},
+		want:    []string{"中文内容五个字", strings.Repeat("字", 700)},
+	},
+	{
+		name:    "custom length bounds",
+		filters: []string{"min_length:3", "max_length:8"},
+		inputs:  []string{"短文", "这是一段八个汉字", "这是一段超过八个汉字的内容"},
+		want:    []string{"这是一段八个汉字"},
+	},
+	{
+		name:    "numbers and symbols only",
+		filters: []string{FilterNoEmpty},
+		inputs:  []string{"1234567", "!!!???", "第 12345 条"},
+		want:    []string{"第 12345 条"},
+	},
+	{
+		name:    "no_short and no_long",
+		filters: []string{FilterNoShort, FilterNoLong},
+		inputs:  []string{"九个字的一段文本啊", "正好是十个字的一段文本", strings.Repeat("长", 501)},
+		want:    []string{"正好是十个字的一段文本"},
+	},
+	{
+		name:    "no_url",
+		filters: []string{FilterNoURL},
+		inputs:  []string{"详情见 https://example.com", "详情见 HTTP://EXAMPLE.COM", "没有链接的评论"},
+		want:    []string{"没有链接的评论"},
+	},
+	{
+		name:    "no_email",
+		filters: []string{FilterNoEmail},
+		inputs:  []string{"联系 a@example.com", "没有邮箱的评论"},
+		want:    []string{"没有邮箱的评论"},
+	},
+	{
+		name:    "chinese_only",
+		filters: []string{FilterChineseOnly},
+		inputs:  []string{"english only text", "中英混合 mixed"},
+		want:    []string{"中英混合 mixed"},
+	},
+	{
+		name:    "regex",
+		filters: []string{`regex:^\p{Han}+$`},
+		inputs:  []string{"全部是中文字符", "带有 English 的句子"},
+		want:    []string{"全部是中文字符"},
+	},
+	{
+		name:    "invalid regex rejects everything",
+		filters: []string{"regex:("},
+		inputs:  []string{"全部是中文字符"},
+		want:    nil,
+	},
+	{
+		name:    "keyword",
+		filters: []string{"keyword:优惠", "keyword:WeChat"},
+		inputs:  []string{"限时优惠快来领取", "add me on wechat now", "普通的聊天内容"},
+		want:    []string{"限时优惠快来领取", "add me on wechat now"},
+	},
+}
+
+// filterRunner 用给定过滤规则采集 inputs，返回保留下来的文本
+type filterRunner func(t *testing.T, inputs, filters []string) []string
+
+func filterRunners() map[string]filterRunner {
+	return map[string]filterRunner{
+		"file": collectFilteredFile,
+		"web":  collectFilteredWeb,
+		"api":  collectFilteredAPI,
+	}
+}
+
+func collectFilteredFile(t *testing.T, inputs, filters []string) []string {
+	path := filepath.Join(t.TempDir(), "texts.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(inputs, "\n")), 0o644))
+
+	c, err := NewFileCollector(&config.Config{})
+	require.NoError(t, err)
+	source := &pb.CollectionSource{Type: pb.SourceType_LOCAL_FILE, FilePath: path}
+	return contents(collectAll(t, c, source, &pb.CollectionConfig{Filters: filters}))
+}
+
+func collectFilteredWeb(t *testing.T, inputs, filters []string) []string {
+	var page strings.Builder
+	page.WriteString("<html><body>")
+	for _, input := range inputs {
+		fmt.Fprintf(&page, "<p>%s</p>", html.EscapeString(input))
+	}
+	page.WriteString("</body></html>")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page.String()))
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewWebCollector(&config.Config{})
+	require.NoError(t, err)
+	source := &pb.CollectionSource{Type: pb.SourceType_WEB_CRAWLER, Url: server.URL + "/", Parameters: map[string]string{"selectors": "p"}}
+	return contents(collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100, Filters: filters}))
+}
+
+func collectFilteredAPI(t *testing.T, inputs, filters []string) []string {
+	items := make([]map[string]string, len(inputs))
+	for i, input := range inputs {
+		items[i] = map[string]string{"id": fmt.Sprint(i), "content": input, "source": "mock"}
+	}
+	body, err := json.Marshal(map[string]interface{}{"data": items})
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewAPICollector(&config.Config{Collector: config.CollectorConfig{RateLimit: 100}})
+	require.NoError(t, err)
+	source := &pb.CollectionSource{Type: pb.SourceType_API, Url: server.URL}
+	return contents(collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 100, Filters: filters}))
+}
+
+func TestApplyFilters(t *testing.T) {
+	for _, tt := range filterCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var kept []string
+			for _, input := range tt.inputs {
+				if ApplyFilters(input, tt.filters) {
+					kept = append(kept, input)
+				}
+			}
+			assert.Equal(t, tt.want, kept)
+		})
+	}
+	assert.True(t, ApplyFilters("1", nil), "未配置规则时保留所有文本")
+}
+
+func TestCollectorsApplyFiltersIdentically(t *testing.T) {
+	for name, run := range filterRunners() {
+		for _, tt := range filterCases {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, run(t, tt.inputs, tt.filters))
+			})
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// GraphQLCollector 向 source.Url 发送 GraphQL 查询，按 Relay 风格的游标分页读取节点文本
+//
+// 参数（source.Parameters）：
+//   - query: GraphQL 查询，需声明游标变量，如 query($after: String) { ... }
+//   - variables: 查询变量的 JSON 对象
+//   - nodes_path: 响应中节点数组的路径，以点分隔，如 data.search.nodes
+//   - page_info_path: pageInfo 的路径，默认为节点数组同级的 pageInfo
+//   - cursor_variable: 传入 endCursor 的变量名，默认 after
+//   - text_field: 节点中文本字段的路径，默认 content
+//   - metadata_fields: 逗号分隔的节点字段路径，写入 Metadata
+//   - headers: 请求头的 JSON 对象，用于鉴权，如 {"Authorization": "Bearer <token>"}
+type GraphQLCollector struct {
+	config *config.Config
+	client *http.Client
+
+	domainLimiter DomainLimiter
+}
+
+// graphQLQuery 解析并校验后的查询参数
+type graphQLQuery struct {
+	query          string
+	variables      map[string]interface{}
+	nodesPath      []string
+	pageInfoPath   []string
+	cursorVariable string
+	textField      []string
+	metadataFields []string
+	headers        map[string]string
+}
+
+// graphQLResponse GraphQL 响应，Data 按路径解析
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// NewGraphQLCollector 创建 GraphQL 采集器
+func NewGraphQLCollector(cfg *config.Config) (*GraphQLCollector, error) {
+	return &GraphQLCollector{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Collector.Timeout, Transport: newDecodingTransport(nil)},
+	}, nil
+}
+
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (g *GraphQLCollector) SetDomainLimiter(limiter DomainLimiter) {
+	g.domainLimiter = limiter
+}
+
+// Collect 逐页查询，直到 hasNextPage 为 false 或达到最大采集数量
+func (g *GraphQLCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	if source.Url == "" {
+		return Permanent(fmt.Errorf("graphql source requires url"))
+	}
+	query, err := parseGraphQLQuery(source.Parameters)
+	if err != nil {
+		return Permanent(err)
+	}
+	logrus.WithField("url", source.Url).Info("Starting GraphQL collection")
+
+	maxCount := config.MaxCount
+	if maxCount <= 0 {
+		maxCount = 1000 // 默认最大采集数量
+	}
+	var limiter *rate.Limiter
+	if config.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.RateLimit), 1)
+	}
+
+	collected := int32(0)
+	cursor := ""
+	for page := 1; collected < maxCount; page++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("rate limiter error: %w", err)
+			}
+		}
+
+		data, err := g.fetchPage(ctx, source.Url, query, cursor)
+		if err != nil {
+			return err
+		}
+		nodes, ok := lookupJSONPath(data, query.nodesPath).([]interface{})
+		if !ok {
+			return Permanent(fmt.Errorf("nodes_path %q is not an array in response", strings.Join(query.nodesPath, ".")))
+		}
+
+		for _, node := range nodes {
+			if collected >= maxCount {
+				break
+			}
+			content, _ := lookupJSONPath(node, query.textField).(string)
+			content = strings.TrimSpace(content)
+			if content == "" {
+				continue
+			}
+
+			metadata := map[string]string{"page": fmt.Sprintf("%d", page)}
+			for _, field := range query.metadataFields {
+				if value := lookupJSONPath(node, strings.Split(field, ".")); value != nil {
+					metadata[field] = jsonScalarString(value)
+				}
+			}
+
+			rawText := &pb.RawText{
+				Id:        uuid.New().String(),
+				Content:   content,
+				Source:    fmt.Sprintf("graphql:%s", source.Url),
+				Timestamp: time.Now().UnixMilli(),
+				Metadata:  metadata,
+			}
+			select {
+			case textChan <- rawText:
+				collected++
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		pageInfo, _ := lookupJSONPath(data, query.pageInfoPath).(map[string]interface{})
+		hasNext, _ := pageInfo["hasNextPage"].(bool)
+		next, _ := pageInfo["endCursor"].(string)
+		if !hasNext || next == "" || next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	logrus.WithField("total_collected", collected).Info("GraphQL collection completed")
+	return nil
+}
+
+// fetchPage 发送一页查询，cursor 为空时不设置游标变量，返回响应的 data 对象
+func (g *GraphQLCollector) fetchPage(ctx context.Context, endpoint string, query *graphQLQuery, cursor string) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(query.variables)+1)
+	for k, v := range query.variables {
+		variables[k] = v
+	}
+	if cursor != "" {
+		variables[query.cursorVariable] = cursor
+	}
+	body, err := json.Marshal(map[string]interface{}{"query": query.query, "variables": variables})
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to encode graphql request: %w", err))
+	}
+
+	resp, err := g.doRequest(ctx, endpoint, body, query.headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("GraphQL endpoint returned status %d", resp.StatusCode)
+		// 除超时与限流外的 4xx 说明查询或鉴权有误，重试无意义
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && !isThrottled(resp.StatusCode) {
+			err = Permanent(err)
+		}
+		return nil, err
+	}
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	var result graphQLResponse
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse graphql response: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			messages[i] = e.Message
+		}
+		if result.Data == nil {
+			return nil, Permanent(fmt.Errorf("graphql errors: %s", strings.Join(messages, "; ")))
+		}
+		// 部分字段出错时 data 仍可用，记录后继续
+		logrus.WithField("errors", messages).Warn("GraphQL response contains errors")
+	}
+	return result.Data, nil
+}
+
+// doRequest 发送 POST 请求，遇到 429/503 时按 Retry-After 等待后重试
+func (g *GraphQLCollector) doRequest(ctx context.Context, endpoint string, body []byte, headers map[string]string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, Permanent(fmt.Errorf("failed to create request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if len(g.config.Collector.UserAgents) > 0 {
+			req.Header.Set("User-Agent", g.config.Collector.UserAgents[0])
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		if err := waitDomain(ctx, g.domainLimiter, req.URL.Hostname()); err != nil {
+			return nil, fmt.Errorf("rate limiter error: %w", err)
+		}
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		if !isThrottled(resp.StatusCode) || attempt >= maxThrottleRetries {
+			return resp, nil
+		}
+
+		wait := retryAfterDelay(resp.Header, time.Second)
+		resp.Body.Close()
+		logrus.WithFields(logrus.Fields{
+			"url":     endpoint,
+			"status":  resp.StatusCode,
+			"attempt": attempt + 1,
+			"wait":    wait,
+		}).Warn("Throttled by GraphQL endpoint, retrying after delay")
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseGraphQLQuery 解析采集源参数
+func parseGraphQLQuery(params map[string]string) (*graphQLQuery, error) {
+	query := &graphQLQuery{
+		query:          strings.TrimSpace(params["query"]),
+		cursorVariable: params["cursor_variable"],
+		textField:      []string{"content"},
+	}
+	if query.query == "" {
+		return nil, fmt.Errorf("graphql source requires parameters.query")
+	}
+	if query.cursorVariable == "" {
+		query.cursorVariable = "after"
+	}
+
+	nodesPath := strings.TrimSpace(params["nodes_path"])
+	if nodesPath == "" {
+		return nil, fmt.Errorf("graphql source requires parameters.nodes_path")
+	}
+	query.nodesPath = strings.Split(nodesPath, ".")
+	if pageInfoPath := strings.TrimSpace(params["page_info_path"]); pageInfoPath != "" {
+		query.pageInfoPath = strings.Split(pageInfoPath, ".")
+	} else {
+		query.pageInfoPath = append(append([]string{}, query.nodesPath[:len(query.nodesPath)-1]...), "pageInfo")
+	}
+	if textField := strings.TrimSpace(params["text_field"]); textField != "" {
+		query.textField = strings.Split(textField, ".")
+	}
+	for _, field := range strings.Split(params["metadata_fields"], ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			query.metadataFields = append(query.metadataFields, field)
+		}
+	}
+
+	if raw := params["variables"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &query.variables); err != nil {
+			return nil, fmt.Errorf("invalid graphql variables: %w", err)
+		}
+	}
+	if raw := params["headers"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &query.headers); err != nil {
+			return nil, fmt.Errorf("invalid graphql headers: %w", err)
+		}
+	}
+	return query, nil
+}
+
+// lookupJSONPath 沿 path 逐级取出 JSON 对象中的字段，路径不存在时返回 nil
+func lookupJSONPath(value interface{}, path []string) interface{} {
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// jsonScalarString 将 JSON 值转换为字符串，对象与数组保留 JSON 编码
+func jsonScalarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestGraphQLServer 模拟按游标分页的 GraphQL 接口：共 total 条评论，每页 pageSize 条，游标为下一条的序号
+func newTestGraphQLServer(t *testing.T, total, pageSize int, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "golang", req.Variables["topic"])
+
+		start := 0
+		if after, ok := req.Variables["after"].(string); ok {
+			start, _ = strconv.Atoi(after)
+		}
+		end := min(start+pageSize, total)
+		nodes := make([]map[string]interface{}, 0, end-start)
+		for i := start; i < end; i++ {
+			nodes = append(nodes, map[string]interface{}{
+				"id":     i,
+				"body":   fmt.Sprintf("comment %d", i),
+				"author": map[string]interface{}{"login": fmt.Sprintf("user%d", i%2)},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"comments": map[string]interface{}{
+					"nodes":    nodes,
+					"pageInfo": map[string]interface{}{"endCursor": strconv.Itoa(end), "hasNextPage": end < total},
+				},
+			},
+		})
+	}))
+}
+
+func graphQLTestSource(url string) *pb.CollectionSource {
+	return &pb.CollectionSource{Type: pb.SourceType_GRAPHQL, Url: url, Parameters: map[string]string{
+		"query":           `query($topic: String!, $after: String) { comments(topic: $topic, after: $after) { nodes { id body author { login } } pageInfo { endCursor hasNextPage } } }`,
+		"variables":       `{"topic": "golang"}`,
+		"nodes_path":      "comments.nodes",
+		"text_field":      "body",
+		"metadata_fields": "id, author.login",
+		"headers":         `{"Authorization": "Bearer secret"}`,
+	}}
+}
+
+func newTestGraphQLCollector(t *testing.T) *GraphQLCollector {
+	c, err := NewGraphQLCollector(&config.Config{})
+	require.NoError(t, err)
+	return c
+}
+
+func TestGraphQLCollectorFollowsCursorPagination(t *testing.T) {
+	var requests int32
+	server := newTestGraphQLServer(t, 7, 3, &requests)
+	defer server.Close()
+
+	texts := collectAll(t, newTestGraphQLCollector(t), graphQLTestSource(server.URL), &pb.CollectionConfig{MaxCount: 100})
+
+	require.Len(t, texts, 7)
+	for i, text := range texts {
+		assert.Equal(t, fmt.Sprintf("comment %d", i), text.Content)
+		assert.Equal(t, strconv.Itoa(i), text.Metadata["id"])
+	}
+	assert.Equal(t, "user1", texts[1].Metadata["author.login"])
+	assert.Equal(t, "3", texts[6].Metadata["page"])
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestGraphQLCollectorStopsAtMaxCount(t *testing.T) {
+	var requests int32
+	server := newTestGraphQLServer(t, 100, 3, &requests)
+	defer server.Close()
+
+	texts := collectAll(t, newTestGraphQLCollector(t), graphQLTestSource(server.URL), &pb.CollectionConfig{MaxCount: 5})
+
+	assert.Equal(t, []string{"comment 0", "comment 1", "comment 2", "comment 3", "comment 4"}, contents(texts))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestGraphQLCollectorErrors(t *testing.T) {
+	var requests int32
+	server := newTestGraphQLServer(t, 3, 3, &requests)
+	defer server.Close()
+	c := newTestGraphQLCollector(t)
+
+	t.Run("unauthorized", func(t *testing.T) {
+		source := graphQLTestSource(server.URL)
+		delete(source.Parameters, "headers")
+		err := c.Collect(context.Background(), source, &pb.CollectionConfig{}, make(chan *pb.RawText, 10))
+		require.Error(t, err)
+		assert.True(t, IsPermanent(err))
+	})
+
+	t.Run("graphql errors", func(t *testing.T) {
+		errorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "Cannot query field \"bodyy\""}]}`))
+		}))
+		defer errorServer.Close()
+		err := c.Collect(context.Background(), graphQLTestSource(errorServer.URL), &pb.CollectionConfig{}, make(chan *pb.RawText, 10))
+		require.Error(t, err)
+		assert.True(t, IsPermanent(err))
+		assert.Contains(t, err.Error(), "bodyy")
+	})
+
+	t.Run("missing nodes path", func(t *testing.T) {
+		source := graphQLTestSource(server.URL)
+		delete(source.Parameters, "nodes_path")
+		err := c.Collect(context.Background(), source, &pb.CollectionConfig{}, make(chan *pb.RawText, 10))
+		require.Error(t, err)
+		assert.True(t, IsPermanent(err))
+	})
+}
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"errors"
 
 	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
 )
@@ -14,4 +15,26 @@ type Collector interface {
 	// config: 采集配置
 	// textChan: 文本输出通道
 	Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error
-}
\ No newline at end of file
+}
+// PermanentError 重试也无法恢复的错误，如采集源配置错误，任务失败后不会自动重试
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent 将 err 标记为不可重试
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent err 是否被标记为不可重试
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
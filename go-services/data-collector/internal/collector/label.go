@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LabelMetadataKey 采集结果 Metadata 中标注标签的键，值为 ProcessedText.Label 的整数形式
+const LabelMetadataKey = "label"
+
+// defaultLabelValues 常见文字标签到 ProcessedText.Label 的映射（0=正常，1=违规）
+var defaultLabelValues = map[string]int{
+	"正常":        0,
+	"normal":    0,
+	"ham":       0,
+	"negative":  0,
+	"false":     0,
+	"违规":        1,
+	"violation": 1,
+	"spam":      1,
+	"positive":  1,
+	"true":      1,
+}
+
+// parseLabelMap 解析 label_map 参数，格式为 "ham:0,spam:1"
+func parseLabelMap(raw string) (map[string]int, error) {
+	labels := make(map[string]int)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid label_map entry %q, expected name:value", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid label_map value in %q: %w", pair, err)
+		}
+		labels[strings.ToLower(strings.TrimSpace(name))] = n
+	}
+	return labels, nil
+}
+
+// parseLabel 将标注列的取值映射为整数标签：优先使用 labelMap，其次为整数取值与常见文字标签。
+// 空值或无法识别时返回 false
+func parseLabel(raw string, labelMap map[string]int) (int, bool) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if value == "" {
+		return 0, false
+	}
+	if n, ok := labelMap[value]; ok {
+		return n, true
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n, true
+	}
+	// 兼容导出为浮点数的整数标签，如 "1.0"
+	if f, err := strconv.ParseFloat(value, 64); err == nil && f == float64(int(f)) {
+		return int(f), true
+	}
+	n, ok := defaultLabelValues[value]
+	return n, ok
+}
@@ -0,0 +1,9 @@
+package collector
+
+// 采集结果 Metadata 中各采集器通用的键，入库时保存为 raw_texts 的独立索引列（见 model.RawText）
+const (
+	URLMetadataKey      = "url"
+	AuthorMetadataKey   = "author"
+	PlatformMetadataKey = "platform"
+	TypeMetadataKey     = "type"
+)
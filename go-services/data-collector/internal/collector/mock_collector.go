@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// MockCollector 依次产出 Texts 的采集器，用于在不访问网络的情况下测试服务层。
+// 每条文本发送前复制一份，写入协程对文本的修改不会影响 Texts，同一实例可重复使用
+type MockCollector struct {
+	Texts []*pb.RawText
+	// Err 产出全部文本后返回的错误，为空时正常结束
+	Err error
+	// Interval 每条文本发送前的等待时间，期间可被取消
+	Interval time.Duration
+	// Block 为 true 时产出全部文本后阻塞直到 ctx 结束，用于测试取消
+	Block bool
+
+	calls atomic.Int32
+}
+
+// MockTexts 生成 count 条内容各不相同的文本
+func MockTexts(count int, source string) []*pb.RawText {
+	texts := make([]*pb.RawText, count)
+	for i := range texts {
+		texts[i] = &pb.RawText{
+			Id:        fmt.Sprintf("mock-%d", i),
+			Content:   fmt.Sprintf("mock content %d", i),
+			Source:    source,
+			Timestamp: int64(i),
+		}
+	}
+	return texts
+}
+
+// Collect 实现 Collector 接口
+func (c *MockCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	c.calls.Add(1)
+	for _, text := range c.Texts {
+		if err := sleepContext(ctx, c.Interval); err != nil {
+			return err
+		}
+		select {
+		case textChan <- proto.Clone(text).(*pb.RawText):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.Block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.Err
+}
+
+// Calls 返回 Collect 被调用的次数
+func (c *MockCollector) Calls() int {
+	return int(c.calls.Load())
+}
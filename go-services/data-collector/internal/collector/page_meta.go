@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pageMeta 页面级的结构化信息，写入该页面每条文本的 Metadata
+type pageMeta struct {
+	Author      string
+	PublishedAt string
+	// RawHTML 开启 store_html 时压缩编码后的页面原文
+	RawHTML          string
+	RawHTMLTruncated bool
+}
+
+// apply 将非空字段写入 metadata
+func (m pageMeta) apply(metadata map[string]string) {
+	if m.Author != "" {
+		metadata[AuthorMetadataKey] = m.Author
+	}
+	if m.PublishedAt != "" {
+		metadata["published_at"] = m.PublishedAt
+	}
+	if m.RawHTML != "" {
+		metadata["raw_html"] = m.RawHTML
+		metadata["raw_html_encoding"] = rawHTMLEncoding
+		if m.RawHTMLTruncated {
+			metadata["raw_html_truncated"] = "true"
+		}
+	}
+}
+
+// publishedDateLayouts 常见的发布时间格式，解析成功时统一转换为 RFC3339
+var publishedDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// extractPageMeta 从页面提取作者与发布时间。
+// 优先使用参数 author_selector / date_selector 指定的元素，其次依次尝试 meta 标签、JSON-LD 与 microdata
+func extractPageMeta(doc *goquery.Selection, params map[string]string) pageMeta {
+	var meta pageMeta
+	ld := parseJSONLD(doc)
+
+	meta.Author = firstNonEmpty(
+		selectorValue(doc, params["author_selector"]),
+		attrValue(doc, `meta[name="author"]`, "content"),
+		attrValue(doc, `meta[property="article:author"]`, "content"),
+		ld.author,
+		microdataValue(doc, "author"),
+	)
+
+	meta.PublishedAt = normalizePublishedDate(firstNonEmpty(
+		selectorValue(doc, params["date_selector"]),
+		attrValue(doc, `meta[property="article:published_time"]`, "content"),
+		attrValue(doc, `meta[itemprop="datePublished"]`, "content"),
+		attrValue(doc, "time[datetime]", "datetime"),
+		ld.datePublished,
+		microdataValue(doc, "datePublished"),
+	))
+
+	return meta
+}
+
+// selectorValue 按选择器取第一个元素的值：优先 content、datetime 属性，否则取文本
+func selectorValue(doc *goquery.Selection, selector string) string {
+	if strings.TrimSpace(selector) == "" {
+		return ""
+	}
+	return elementValue(doc.Find(selector).First())
+}
+
+func elementValue(s *goquery.Selection) string {
+	if s.Length() == 0 {
+		return ""
+	}
+	for _, attr := range []string{"content", "datetime"} {
+		if v, ok := s.Attr(attr); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return strings.TrimSpace(s.Text())
+}
+
+func attrValue(doc *goquery.Selection, selector, attr string) string {
+	v, _ := doc.Find(selector).First().Attr(attr)
+	return strings.TrimSpace(v)
+}
+
+// microdataValue 读取 itemprop 属性，作者通常嵌套在 itemprop=name 中
+func microdataValue(doc *goquery.Selection, prop string) string {
+	s := doc.Find(`[itemprop="` + prop + `"]`).First()
+	if name := s.Find(`[itemprop="name"]`).First(); name.Length() > 0 {
+		return elementValue(name)
+	}
+	return elementValue(s)
+}
+
+// jsonLDMeta JSON-LD 中与文章相关的字段
+type jsonLDMeta struct {
+	author        string
+	datePublished string
+}
+
+// parseJSONLD 解析页面中的 application/ld+json 脚本，支持单个对象、数组与 @graph
+func parseJSONLD(doc *goquery.Selection) jsonLDMeta {
+	var result jsonLDMeta
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var raw interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &raw); err != nil {
+			return true
+		}
+		for _, node := range jsonLDNodes(raw) {
+			if result.author == "" {
+				result.author = jsonLDAuthor(node["author"])
+			}
+			if result.datePublished == "" {
+				result.datePublished, _ = node["datePublished"].(string)
+			}
+		}
+		return result.author == "" || result.datePublished == ""
+	})
+	return result
+}
+
+func jsonLDNodes(raw interface{}) []map[string]interface{} {
+	var nodes []map[string]interface{}
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes = append(nodes, jsonLDNodes(item)...)
+		}
+	case map[string]interface{}:
+		nodes = append(nodes, v)
+		if graph, ok := v["@graph"]; ok {
+			nodes = append(nodes, jsonLDNodes(graph)...)
+		}
+	}
+	return nodes
+}
+
+// jsonLDAuthor author 可以是字符串、带 name 的对象或它们的数组，多个作者以逗号连接
+func jsonLDAuthor(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		name, _ := v["name"].(string)
+		return strings.TrimSpace(name)
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if name := jsonLDAuthor(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// normalizePublishedDate 能识别的时间统一为 RFC3339，否则原样保留
+func normalizePublishedDate(value string) string {
+	for _, layout := range publishedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+)
+
+// deadProxyCooldown 代理连接失败后被跳过的时间
+const deadProxyCooldown = time.Minute
+
+type proxyContextKey struct{}
+
+// proxyTransport 按配置的代理列表转发请求：rotate 为 true 时每个请求轮换代理，
+// 否则持续使用当前代理；连接代理失败时将其标记为失效并换下一个代理重试，
+// 冷却期内跳过失效代理，全部失效时仍按顺序尝试
+type proxyTransport struct {
+	base    *http.Transport
+	proxies []*url.URL
+	rotate  bool
+
+	mu        sync.Mutex
+	next      int
+	deadUntil map[int]time.Time
+}
+
+// newProxyTransport 根据 cfg.Collector.ProxyURLs 创建代理传输层，未配置代理时返回 nil，由调用方使用默认传输层。
+// 认证信息（user:pass@host）由 http.Transport 以 Proxy-Authorization 发送
+func newProxyTransport(cfg *config.Config) (http.RoundTripper, error) {
+	if len(cfg.Collector.ProxyURLs) == 0 {
+		return nil, nil
+	}
+	proxies := make([]*url.URL, 0, len(cfg.Collector.ProxyURLs))
+	for i, raw := range cfg.Collector.ProxyURLs {
+		proxyURL, err := url.Parse(raw)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url at index %d", i)
+		}
+		proxies = append(proxies, proxyURL)
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = func(req *http.Request) (*url.URL, error) {
+		proxyURL, _ := req.Context().Value(proxyContextKey{}).(*url.URL)
+		return proxyURL, nil
+	}
+	return &proxyTransport{
+		base:      base,
+		proxies:   proxies,
+		rotate:    cfg.Collector.ProxyRotate,
+		deadUntil: make(map[int]time.Time),
+	}, nil
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		index, proxyURL := t.pick()
+		outReq := req.WithContext(context.WithValue(req.Context(), proxyContextKey{}, proxyURL))
+		if attempt > 0 {
+			body, err := replayBody(req)
+			if err != nil {
+				return nil, err
+			}
+			outReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(outReq)
+		if err == nil || req.Context().Err() != nil {
+			return resp, err
+		}
+		t.markDead(index)
+		logrus.WithError(err).WithField("proxy", proxyURL.Redacted()).Warn("Proxy request failed, skipping proxy")
+		// 请求体无法重放时不再换代理重试
+		if attempt+1 >= len(t.proxies) || !canReplayBody(req) {
+			return nil, err
+		}
+	}
+}
+
+// pick 选出本次请求使用的代理，跳过冷却期内的失效代理
+func (t *proxyTransport) pick() (int, *url.URL) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	start := t.next
+	index := start
+	for i := 0; i < len(t.proxies); i++ {
+		candidate := (start + i) % len(t.proxies)
+		if now.After(t.deadUntil[candidate]) {
+			index = candidate
+			break
+		}
+	}
+	if t.rotate {
+		t.next = (index + 1) % len(t.proxies)
+	} else {
+		t.next = index
+	}
+	return index, t.proxies[index]
+}
+
+// markDead 标记代理失效，未轮换时下一个请求改用后一个代理
+func (t *proxyTransport) markDead(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.deadUntil[index] = time.Now().Add(deadProxyCooldown)
+	if t.next == index {
+		t.next = (index + 1) % len(t.proxies)
+	}
+}
+
+// canReplayBody 请求无请求体或可通过 GetBody 重新获取时返回 true
+func canReplayBody(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// replayBody 为重试重新获取请求体
+func replayBody(req *http.Request) (io.ReadCloser, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Body, nil
+	}
+	return req.GetBody()
+}
@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestProxy 启动转发 HTTP 请求的代理，要求 user:pass 认证，返回代理地址与转发的请求数
+func newTestProxy(t *testing.T) (string, *int32) {
+	var forwarded int32
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != wantAuth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		atomic.AddInt32(&forwarded, 1)
+
+		r.RequestURI = ""
+		r.Header.Del("Proxy-Authorization")
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for key, values := range resp.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(server.Close)
+	return "http://user:pass@" + strings.TrimPrefix(server.URL, "http://"), &forwarded
+}
+
+// deadProxyURL 返回一个已关闭端口的代理地址
+func deadProxyURL(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+	return "http://" + addr
+}
+
+func TestWebCollectorSendsRequestsThroughProxy(t *testing.T) {
+	var mu sync.Mutex
+	pages := []string{"a", "b"}
+	site := newTestSite(t, &pages, &mu)
+	proxyURL, forwarded := newTestProxy(t)
+
+	c, err := NewWebCollector(&config.Config{Collector: config.CollectorConfig{ProxyURLs: []string{proxyURL}}})
+	require.NoError(t, err)
+	source := &pb.CollectionSource{
+		Type:       pb.SourceType_WEB_CRAWLER,
+		Url:        site.URL + "/",
+		Parameters: map[string]string{"selectors": "p", "follow_links": "true"},
+	}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, ConcurrentLimit: 1, RateLimit: 100})
+
+	assert.Len(t, texts, 3)
+	assert.Equal(t, int32(3), atomic.LoadInt32(forwarded))
+}
+
+func TestAPICollectorSkipsDeadProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"data":[{"id":"1","content":"first page"}],"has_more":true,"next_url":"http://%s/?page=2"}`, r.Host)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"2","content":"second page"}]}`)
+	}))
+	t.Cleanup(server.Close)
+	proxyURL, forwarded := newTestProxy(t)
+
+	c, err := NewAPICollector(&config.Config{Collector: config.CollectorConfig{
+		Timeout:     5 * time.Second,
+		ProxyURLs:   []string{deadProxyURL(t), proxyURL},
+		ProxyRotate: true,
+	}})
+	require.NoError(t, err)
+	texts := collectAll(t, c, &pb.CollectionSource{Type: pb.SourceType_API, Url: server.URL}, &pb.CollectionConfig{MaxCount: 10})
+
+	assert.Equal(t, []string{"first page", "second page"}, contents(texts))
+	assert.Equal(t, int32(2), atomic.LoadInt32(forwarded))
+}
+
+func TestProxyTransportRotation(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(target.Close)
+	first, firstCount := newTestProxy(t)
+	second, secondCount := newTestProxy(t)
+
+	send := func(rotate bool, requests int) {
+		transport, err := newProxyTransport(&config.Config{Collector: config.CollectorConfig{
+			ProxyURLs:   []string{first, second},
+			ProxyRotate: rotate,
+		}})
+		require.NoError(t, err)
+		client := &http.Client{Transport: transport}
+		for i := 0; i < requests; i++ {
+			resp, err := client.Get(target.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+	}
+
+	// 不轮换时始终使用第一个可用代理
+	send(false, 3)
+	assert.Equal(t, int32(3), atomic.LoadInt32(firstCount))
+	assert.Equal(t, int32(0), atomic.LoadInt32(secondCount))
+
+	send(true, 4)
+	assert.Equal(t, int32(5), atomic.LoadInt32(firstCount))
+	assert.Equal(t, int32(2), atomic.LoadInt32(secondCount))
+}
+
+func TestNewProxyTransportWithoutProxies(t *testing.T) {
+	transport, err := newProxyTransport(&config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, transport)
+}
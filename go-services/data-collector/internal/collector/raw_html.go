@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+)
+
+// defaultRawHTMLMaxBytes 保存原始 HTML 的默认大小上限（压缩前）
+const defaultRawHTMLMaxBytes = 512 * 1024
+
+// rawHTMLEncoding 原始 HTML 在 Metadata 中的编码方式
+const rawHTMLEncoding = "gzip+base64"
+
+// rawHTMLOptions 参数 store_html 与 store_html_max_bytes 的解析结果
+type rawHTMLOptions struct {
+	enabled  bool
+	maxBytes int
+}
+
+// parseRawHTMLOptions 解析是否保存原始 HTML 以及大小上限
+func parseRawHTMLOptions(params map[string]string) (rawHTMLOptions, error) {
+	opts := rawHTMLOptions{maxBytes: defaultRawHTMLMaxBytes}
+	if store := params["store_html"]; store == "true" || store == "1" {
+		opts.enabled = true
+	}
+	if raw := params["store_html_max_bytes"]; raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size <= 0 {
+			return opts, Permanent(fmt.Errorf("invalid store_html_max_bytes: %q", raw))
+		}
+		opts.maxBytes = size
+	}
+	return opts, nil
+}
+
+// encodeRawHTML 截断到 maxBytes 后 gzip 压缩并 base64 编码，便于放入字符串类型的 Metadata
+func encodeRawHTML(body []byte, maxBytes int) (encoded string, truncated bool, err error) {
+	if len(body) > maxBytes {
+		body = body[:maxBytes]
+		truncated = true
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	zw := getGzipWriter(buf)
+	defer putGzipWriter(zw)
+	if _, err := zw.Write(body); err != nil {
+		return "", false, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", false, err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), truncated, nil
+}
@@ -0,0 +1,413 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+const (
+	redditAPIBase = "https://www.reddit.com"
+	// redditUserAgent Reddit 要求 User-Agent 能标识客户端，通用浏览器标识会被限流或拒绝
+	redditUserAgent = "linux:ai-demo-data-collector:v1.0"
+
+	// redditDefaultRate 未配置速率限制时每秒最多请求数，未登录的公开接口每分钟只允许少量请求
+	redditDefaultRate       = 1
+	redditPageSize          = 100
+	redditDefaultCommentCap = 20
+	// redditMaxRateLimitWait 等待限流窗口重置的上限，接口的限流窗口为 10 分钟
+	redditMaxRateLimitWait = 10 * time.Minute
+)
+
+var subredditPattern = regexp.MustCompile(`/r/([A-Za-z0-9_]+)`)
+
+// redditSorts 支持的帖子排序方式
+var redditSorts = map[string]bool{"new": true, "hot": true, "top": true, "rising": true}
+
+// RedditCollector 通过 Reddit 公开的 .json 接口采集版块帖子及其一级评论，按 after 游标翻页
+//
+// 参数（source.Parameters）：
+//   - subreddit: 版块名，未设置时从 URL 中的 /r/<name> 解析
+//   - sort: 帖子排序方式，new、hot、top 或 rising，默认 new
+//   - comments: 为 false 时只采集帖子
+//   - comment_limit: 每个帖子最多采集的一级评论数，默认 20
+//   - user_agent: 请求使用的 User-Agent，默认 redditUserAgent
+type RedditCollector struct {
+	config  *config.Config
+	client  *http.Client
+	apiBase string
+	maxWait time.Duration // 单次等待限流窗口重置的上限
+
+	domainLimiter DomainLimiter
+}
+
+// redditThing 接口返回的对象，Kind 为 Listing、t3（帖子）、t1（评论）或 more（折叠的评论）
+type redditThing struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// redditListing 分页列表，After 为下一页的游标
+type redditListing struct {
+	After    string        `json:"after"`
+	Children []redditThing `json:"children"`
+}
+
+// redditPost 帖子
+type redditPost struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Title       string  `json:"title"`
+	Selftext    string  `json:"selftext"`
+	Author      string  `json:"author"`
+	Subreddit   string  `json:"subreddit"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	Permalink   string  `json:"permalink"`
+	CreatedUTC  float64 `json:"created_utc"`
+}
+
+// redditComment 评论
+type redditComment struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Body       string  `json:"body"`
+	Author     string  `json:"author"`
+	Subreddit  string  `json:"subreddit"`
+	Score      int     `json:"score"`
+	Permalink  string  `json:"permalink"`
+	CreatedUTC float64 `json:"created_utc"`
+}
+
+// NewRedditCollector 创建 Reddit 采集器
+func NewRedditCollector(cfg *config.Config) (*RedditCollector, error) {
+	return &RedditCollector{
+		config:  cfg,
+		client:  &http.Client{Timeout: cfg.Collector.Timeout, Transport: newDecodingTransport(nil)},
+		apiBase: redditAPIBase,
+		maxWait: redditMaxRateLimitWait,
+	}, nil
+}
+
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (r *RedditCollector) SetDomainLimiter(limiter DomainLimiter) {
+	r.domainLimiter = limiter
+}
+
+// Collect 逐页读取版块帖子，每个帖子后紧跟其一级评论，直到没有下一页或达到最大采集数量
+func (r *RedditCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	subreddit := strings.TrimSpace(source.Parameters["subreddit"])
+	if subreddit == "" {
+		if match := subredditPattern.FindStringSubmatch(source.Url); match != nil {
+			subreddit = match[1]
+		}
+	}
+	if subreddit == "" {
+		return Permanent(fmt.Errorf("reddit source requires parameters.subreddit or a /r/<name> url"))
+	}
+	sort := source.Parameters["sort"]
+	if sort == "" {
+		sort = "new"
+	}
+	if !redditSorts[sort] {
+		return Permanent(fmt.Errorf("unsupported reddit sort %q", sort))
+	}
+	commentLimit := redditDefaultCommentCap
+	if raw := source.Parameters["comment_limit"]; raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			return Permanent(fmt.Errorf("invalid reddit comment_limit %q", raw))
+		}
+		commentLimit = limit
+	}
+	if source.Parameters["comments"] == "false" {
+		commentLimit = 0
+	}
+
+	maxCount := config.MaxCount
+	if maxCount <= 0 {
+		maxCount = 1000 // 默认最大采集数量
+	}
+	limit := rate.Limit(redditDefaultRate)
+	if config.RateLimit > 0 {
+		limit = rate.Limit(config.RateLimit)
+	}
+	userAgent := source.Parameters["user_agent"]
+	if userAgent == "" {
+		userAgent = redditUserAgent
+	}
+	run := &redditRun{
+		collector: r,
+		limiter:   rate.NewLimiter(limit, 1),
+		userAgent: userAgent,
+		textChan:  textChan,
+		maxCount:  maxCount,
+	}
+	logrus.WithFields(logrus.Fields{
+		"subreddit": subreddit,
+		"sort":      sort,
+	}).Info("Starting Reddit collection")
+
+	after := ""
+	for page := 1; !run.full(); page++ {
+		params := url.Values{}
+		params.Set("limit", strconv.Itoa(redditPageSize))
+		params.Set("raw_json", "1")
+		if after != "" {
+			params.Set("after", after)
+		}
+		var listing redditThing
+		endpoint := fmt.Sprintf("%s/r/%s/%s.json?%s", r.apiBase, url.PathEscape(subreddit), sort, params.Encode())
+		if err := run.getJSON(ctx, endpoint, &listing); err != nil {
+			return fmt.Errorf("failed to fetch reddit listing page %d: %w", page, err)
+		}
+		var posts redditListing
+		if err := json.Unmarshal(listing.Data, &posts); err != nil {
+			return fmt.Errorf("failed to parse reddit listing: %w", err)
+		}
+
+		for _, child := range posts.Children {
+			if run.full() {
+				break
+			}
+			if child.Kind != "t3" {
+				continue
+			}
+			var post redditPost
+			if err := json.Unmarshal(child.Data, &post); err != nil {
+				return fmt.Errorf("failed to parse reddit post: %w", err)
+			}
+			if err := run.emitPost(ctx, post); err != nil {
+				return err
+			}
+			if commentLimit > 0 && post.NumComments > 0 {
+				if err := run.collectComments(ctx, post, commentLimit); err != nil {
+					return err
+				}
+			}
+		}
+
+		if posts.After == "" || posts.After == after {
+			break
+		}
+		after = posts.After
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"subreddit":       subreddit,
+		"total_collected": run.collected,
+	}).Info("Reddit collection completed")
+	return nil
+}
+
+// redditRun 单次采集的状态，pause 为配额用尽后下一次请求前需要等待的时间
+type redditRun struct {
+	collector *RedditCollector
+	limiter   *rate.Limiter
+	userAgent string
+	textChan  chan<- *pb.RawText
+	maxCount  int32
+	collected int32
+	pause     time.Duration
+}
+
+func (r *redditRun) full() bool {
+	return r.collected >= r.maxCount
+}
+
+func (r *redditRun) emitPost(ctx context.Context, post redditPost) error {
+	content := post.Title
+	if body := redditText(post.Selftext); body != "" {
+		content += " " + body
+	}
+	return r.emit(ctx, content, "reddit:post", map[string]string{
+		TypeMetadataKey:   "post",
+		AuthorMetadataKey: post.Author,
+		URLMetadataKey:    redditAPIBase + post.Permalink,
+		"post_id":         post.Name,
+		"subreddit":       post.Subreddit,
+		"score":           strconv.Itoa(post.Score),
+		"num_comments":    strconv.Itoa(post.NumComments),
+		"created_at":      redditTimestamp(post.CreatedUTC),
+	})
+}
+
+// collectComments 拉取帖子的一级评论，忽略楼中楼回复与折叠的评论
+func (r *redditRun) collectComments(ctx context.Context, post redditPost, limit int) error {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("depth", "1")
+	params.Set("raw_json", "1")
+	endpoint := fmt.Sprintf("%s/comments/%s.json?%s", r.collector.apiBase, url.PathEscape(post.ID), params.Encode())
+	// 返回两个列表，第一个为帖子本身，第二个为评论
+	var listings []redditThing
+	if err := r.getJSON(ctx, endpoint, &listings); err != nil {
+		return fmt.Errorf("failed to fetch reddit comments for %s: %w", post.Name, err)
+	}
+	if len(listings) < 2 {
+		return nil
+	}
+	var comments redditListing
+	if err := json.Unmarshal(listings[1].Data, &comments); err != nil {
+		return fmt.Errorf("failed to parse reddit comments: %w", err)
+	}
+
+	emitted := 0
+	for _, child := range comments.Children {
+		if r.full() || emitted >= limit {
+			break
+		}
+		if child.Kind != "t1" {
+			continue
+		}
+		var comment redditComment
+		if err := json.Unmarshal(child.Data, &comment); err != nil {
+			return fmt.Errorf("failed to parse reddit comment: %w", err)
+		}
+		body := redditText(comment.Body)
+		if body == "" {
+			continue
+		}
+		err := r.emit(ctx, body, "reddit:comment", map[string]string{
+			TypeMetadataKey:   "comment",
+			AuthorMetadataKey: comment.Author,
+			URLMetadataKey:    redditAPIBase + comment.Permalink,
+			"comment_id":      comment.Name,
+			"post_id":         post.Name,
+			"subreddit":       comment.Subreddit,
+			"score":           strconv.Itoa(comment.Score),
+			"created_at":      redditTimestamp(comment.CreatedUTC),
+		})
+		if err != nil {
+			return err
+		}
+		emitted++
+	}
+	return nil
+}
+
+func (r *redditRun) emit(ctx context.Context, content, source string, metadata map[string]string) error {
+	content = cleanText(content)
+	if content == "" {
+		return nil
+	}
+	metadata[PlatformMetadataKey] = "reddit"
+	rawText := &pb.RawText{
+		Id:        uuid.New().String(),
+		Content:   content,
+		Source:    source,
+		Timestamp: time.Now().UnixMilli(),
+		Metadata:  metadata,
+	}
+	select {
+	case r.textChan <- rawText:
+		r.collected++
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getJSON 发送限速后的 GET 请求并解析响应，被限流（429）时等待限流窗口重置后重试
+func (r *redditRun) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		if r.pause > 0 {
+			logrus.WithField("wait", r.pause).Warn("Reddit rate limit exhausted, waiting for reset")
+			if err := sleepContext(ctx, r.pause); err != nil {
+				return err
+			}
+			r.pause = 0
+		}
+		if err := r.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("rate limiter error: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return Permanent(fmt.Errorf("failed to create request: %w", err))
+		}
+		req.Header.Set("User-Agent", r.userAgent)
+		req.Header.Set("Accept", "application/json")
+		if err := waitDomain(ctx, r.collector.domainLimiter, req.URL.Hostname()); err != nil {
+			return fmt.Errorf("rate limiter error: %w", err)
+		}
+		resp, err := r.collector.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if remaining, err := strconv.ParseFloat(resp.Header.Get("x-ratelimit-remaining"), 64); err == nil && remaining < 1 {
+			r.pause = r.collector.rateLimitWait(resp.Header)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxThrottleRetries {
+			r.pause = r.collector.rateLimitWait(resp.Header)
+			logrus.WithFields(logrus.Fields{
+				"url":     endpoint,
+				"attempt": attempt + 1,
+				"wait":    r.pause,
+			}).Warn("Throttled by Reddit, retrying after rate limit reset")
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("reddit returned status %d", resp.StatusCode)
+			// 版块不存在、私有或被封禁，重试无意义
+			if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+				err = Permanent(err)
+			}
+			return err
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to parse reddit response: %w", err)
+		}
+		return nil
+	}
+}
+
+// rateLimitWait 根据 x-ratelimit-reset（距限流窗口重置的秒数）计算等待时间，
+// 未给出时按 Retry-After 或 1 秒，结果不超过 maxWait
+func (r *RedditCollector) rateLimitWait(header http.Header) time.Duration {
+	wait := time.Second
+	if reset, err := strconv.ParseFloat(header.Get("x-ratelimit-reset"), 64); err == nil && reset >= 0 {
+		wait = time.Duration(reset * float64(time.Second))
+	} else if parsed, ok := parseRetryAfter(header.Get("Retry-After"), time.Now()); ok {
+		wait = parsed
+	}
+	if wait > r.maxWait {
+		wait = r.maxWait
+	}
+	return wait
+}
+
+// redditText 去除首尾空白，被删除或移除的内容返回空字符串
+func redditText(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "[deleted]" || text == "[removed]" {
+		return ""
+	}
+	return text
+}
+
+// redditTimestamp 将 created_utc（Unix 秒，浮点数）转换为整数秒字符串
+func redditTimestamp(created float64) string {
+	return strconv.FormatInt(int64(math.Floor(created)), 10)
+}
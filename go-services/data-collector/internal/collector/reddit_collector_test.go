@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestReddit 用 testdata/reddit 中抓取的响应模拟 r/golang：共两页帖子，只有第一个帖子有评论。
+// 第一次请求被限流，评论接口返回后配额用尽
+func newTestReddit(t *testing.T) (*RedditCollector, *[]string) {
+	var requests []string
+	var calls int32
+	fixture := func(w http.ResponseWriter, name string) {
+		body, err := os.ReadFile(filepath.Join("testdata", "reddit", name))
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("x-ratelimit-remaining", "0.0")
+			w.Header().Set("x-ratelimit-reset", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.Header.Get("User-Agent") != redditUserAgent {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		requests = append(requests, r.URL.Path+"?after="+r.URL.Query().Get("after"))
+		w.Header().Set("x-ratelimit-reset", "60")
+		switch r.URL.Path {
+		case "/r/golang/new.json":
+			w.Header().Set("x-ratelimit-remaining", "98.0")
+			if r.URL.Query().Get("after") == "t3_1g2abcd" {
+				fixture(w, "listing_page2.json")
+			} else {
+				fixture(w, "listing_page1.json")
+			}
+		case "/comments/1g2aaaa.json":
+			assert.Equal(t, "1", r.URL.Query().Get("depth"))
+			w.Header().Set("x-ratelimit-remaining", "0.0")
+			fixture(w, "comments_1g2aaaa.json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewRedditCollector(&config.Config{})
+	require.NoError(t, err)
+	c.apiBase = server.URL
+	c.maxWait = 20 * time.Millisecond
+	return c, &requests
+}
+
+func TestRedditCollectorPostsAndTopLevelComments(t *testing.T) {
+	c, requests := newTestReddit(t)
+
+	source := &pb.CollectionSource{Type: pb.SourceType_REDDIT, Url: "https://www.reddit.com/r/golang/"}
+	start := time.Now()
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, RateLimit: 1000})
+	elapsed := time.Since(start)
+
+	assert.Equal(t, []string{
+		"Go 1.23 in production We just shipped 1.23 in production and GC pauses dropped by half.",
+		"Nice! Did you tune GOGC or just upgrade?",
+		"Benchmarks or it didn't happen.",
+		"Looking for a generics tutorial",
+		`Weekly "Who's hiring" thread`,
+	}, contents(texts))
+	// 没有评论的帖子不请求评论接口
+	assert.Equal(t, []string{"/r/golang/new.json?after=", "/comments/1g2aaaa.json?after=", "/r/golang/new.json?after=t3_1g2abcd"}, *requests)
+	// 429 与配额用尽各等待一次，每次被 maxWait 截断
+	assert.GreaterOrEqual(t, elapsed, 40*time.Millisecond)
+
+	post := texts[0]
+	assert.Equal(t, "reddit:post", post.Source)
+	assert.Equal(t, "reddit", post.Metadata[PlatformMetadataKey])
+	assert.Equal(t, "post", post.Metadata[TypeMetadataKey])
+	assert.Equal(t, "gopher_anna", post.Metadata[AuthorMetadataKey])
+	assert.Equal(t, "https://www.reddit.com/r/golang/comments/1g2aaaa/go_123_in_production/", post.Metadata[URLMetadataKey])
+	assert.Equal(t, "golang", post.Metadata["subreddit"])
+	assert.Equal(t, "142", post.Metadata["score"])
+	assert.Equal(t, "1727740800", post.Metadata["created_at"])
+
+	comment := texts[2]
+	assert.Equal(t, "reddit:comment", comment.Source)
+	assert.Equal(t, "comment", comment.Metadata[TypeMetadataKey])
+	assert.Equal(t, "pprof_guy", comment.Metadata[AuthorMetadataKey])
+	assert.Equal(t, "-2", comment.Metadata["score"])
+	assert.Equal(t, "t3_1g2aaaa", comment.Metadata["post_id"])
+	assert.Equal(t, "t1_lr0003c", comment.Metadata["comment_id"])
+}
+
+func TestRedditCollectorRespectsLimits(t *testing.T) {
+	c, requests := newTestReddit(t)
+
+	source := &pb.CollectionSource{Parameters: map[string]string{"subreddit": "golang", "comment_limit": "1"}}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 3, RateLimit: 1000})
+	assert.Equal(t, []string{
+		"Go 1.23 in production We just shipped 1.23 in production and GC pauses dropped by half.",
+		"Nice! Did you tune GOGC or just upgrade?",
+		"Looking for a generics tutorial",
+	}, contents(texts))
+	assert.Len(t, *requests, 2)
+
+	c, requests = newTestReddit(t)
+	source.Parameters["comments"] = "false"
+	texts = collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, RateLimit: 1000})
+	assert.Len(t, texts, 3)
+	assert.Equal(t, []string{"/r/golang/new.json?after=", "/r/golang/new.json?after=t3_1g2abcd"}, *requests)
+}
+
+func TestRedditCollectorRejectsInvalidSource(t *testing.T) {
+	c, requests := newTestReddit(t)
+	c.maxWait = 0
+
+	for name, source := range map[string]*pb.CollectionSource{
+		"missing subreddit": {Url: "https://www.reddit.com/"},
+		"sort":              {Parameters: map[string]string{"subreddit": "golang", "sort": "best"}},
+		"comment limit":     {Parameters: map[string]string{"subreddit": "golang", "comment_limit": "-1"}},
+		// 测试服务器对未知版块返回 404
+		"unknown subreddit": {Parameters: map[string]string{"subreddit": "nosuchsub"}},
+	} {
+		err := c.Collect(context.Background(), source, &pb.CollectionConfig{RateLimit: 1000}, make(chan *pb.RawText, 10))
+		assert.True(t, IsPermanent(err), name)
+	}
+	assert.Equal(t, []string{"/r/nosuchsub/new.json?after="}, *requests)
+}
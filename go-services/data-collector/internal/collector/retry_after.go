@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxRetryAfter Retry-After 的等待上限，避免异常值让采集长时间挂起
+	maxRetryAfter = 2 * time.Minute
+	// maxThrottleRetries 同一请求因限流重试的最大次数
+	maxThrottleRetries = 3
+
+	throttleRetriesKey   = "throttle_retries"
+	throttleRecoveredKey = "throttle_recovered"
+)
+
+// isThrottled 响应是否表示被限流或服务暂时不可用
+func isThrottled(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期），未设置或无法解析时 ok 为 false
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := at.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// retryAfterDelay 根据响应头计算限流后的等待时间，未给出 Retry-After 时使用 fallback，结果不超过 maxRetryAfter
+func retryAfterDelay(header http.Header, fallback time.Duration) time.Duration {
+	wait := fallback
+	if header != nil {
+		if parsed, ok := parseRetryAfter(header.Get("Retry-After"), time.Now()); ok {
+			wait = parsed
+		}
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait
+}
+
+// sleepContext 等待 d，ctx 先结束时返回 ctx.Err()
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryThrottled 供 colly 的 OnError 回调使用：429/503 时按 Retry-After 等待后重试请求，
+// 返回 true 表示已重试
+func retryThrottled(ctx context.Context, r *colly.Response, fallback time.Duration) bool {
+	if r == nil || r.Request == nil || !isThrottled(r.StatusCode) {
+		return false
+	}
+
+	retries, _ := r.Request.Ctx.GetAny(throttleRetriesKey).(int)
+	if retries >= maxThrottleRetries {
+		return false
+	}
+	r.Request.Ctx.Put(throttleRetriesKey, retries+1)
+
+	var header http.Header
+	if r.Headers != nil {
+		header = *r.Headers
+	}
+	wait := retryAfterDelay(header, fallback)
+	logrus.WithFields(logrus.Fields{
+		"url":     r.Request.URL.String(),
+		"status":  r.StatusCode,
+		"attempt": retries + 1,
+		"wait":    wait,
+	}).Warn("Throttled by target, retrying after delay")
+
+	if err := sleepContext(ctx, wait); err != nil {
+		return false
+	}
+	if err := r.Request.Retry(); err != nil {
+		logrus.WithError(err).WithField("url", r.Request.URL.String()).Warn("Failed to retry throttled request")
+		return false
+	}
+	r.Request.Ctx.Put(throttleRecoveredKey, true)
+	return true
+}
+
+// visitSeed 访问起始页；同步模式下首次请求被限流但重试成功时，colly 仍返回首次的错误，此时视为成功
+func visitSeed(c *colly.Collector, url string) error {
+	ctx := colly.NewContext()
+	err := c.Request(http.MethodGet, url, nil, ctx, nil)
+	if err != nil {
+		if recovered, _ := ctx.GetAny(throttleRecoveredKey).(bool); recovered {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.value, now)
+		assert.Equal(t, tc.ok, ok, tc.value)
+		assert.Equal(t, tc.want, got, tc.value)
+	}
+}
+
+func TestRetryAfterDelayIsCapped(t *testing.T) {
+	header := http.Header{}
+	assert.Equal(t, 3*time.Second, retryAfterDelay(header, 3*time.Second))
+
+	header.Set("Retry-After", "86400")
+	assert.Equal(t, maxRetryAfter, retryAfterDelay(header, time.Second))
+}
+
+// newThrottlingServer 前 throttled 次请求返回 status 和 Retry-After: 1，之后返回 body
+func newThrottlingServer(t *testing.T, status int, throttled int32, contentType, body string) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= throttled {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestAPICollectorWaitsForRetryAfter(t *testing.T) {
+	server, calls := newThrottlingServer(t, http.StatusTooManyRequests, 1, "application/json",
+		`{"data":[{"id":"1","content":"限流之后的内容","source":"mock"}]}`)
+
+	c, err := NewAPICollector(&config.Config{Collector: config.CollectorConfig{RateLimit: 100, Timeout: 5 * time.Second}})
+	require.NoError(t, err)
+
+	start := time.Now()
+	texts := collectAll(t, c, &pb.CollectionSource{Type: pb.SourceType_API, Url: server.URL}, &pb.CollectionConfig{MaxCount: 10})
+
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.Equal(t, []string{"限流之后的内容"}, contents(texts))
+}
+
+func TestWebCollectorRetriesAfterServiceUnavailable(t *testing.T) {
+	server, calls := newThrottlingServer(t, http.StatusServiceUnavailable, 1, "text/html; charset=utf-8",
+		`<html><body><p>recovered page</p></body></html>`)
+
+	c, err := NewWebCollector(&config.Config{})
+	require.NoError(t, err)
+
+	start := time.Now()
+	source := &pb.CollectionSource{Type: pb.SourceType_WEB_CRAWLER, Url: server.URL + "/", Parameters: map[string]string{"selectors": "p"}}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, ConcurrentLimit: 1, RateLimit: 100})
+
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.Equal(t, []string{"recovered page"}, contents(texts))
+}
@@ -0,0 +1,239 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// SeenStore 记录每个采集源已采集过的URL，用于增量采集
+type SeenStore interface {
+	IsSeen(ctx context.Context, sourceKey, url string) (bool, error)
+	// MarkSeen 将URL加入已采集集合，返回该URL此前是否未被记录
+	MarkSeen(ctx context.Context, sourceKey, url string) (bool, error)
+	// Forget 移除URL，允许下次重新采集
+	Forget(ctx context.Context, sourceKey, url string) error
+	Count(ctx context.Context, sourceKey string) (int64, error)
+	Reset(ctx context.Context, sourceKey string) error
+}
+
+// RedisSeenStore 基于 Redis Set 的实现，集合成员为URL的哈希；
+// 每次写入时刷新过期时间，长期未采集的源自动清理
+type RedisSeenStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisSeenStore 创建Redis已采集集合，ttl 不大于 0 时集合不过期
+func NewRedisSeenStore(client *redis.Client, ttl time.Duration) *RedisSeenStore {
+	return &RedisSeenStore{client: client, ttl: ttl}
+}
+
+func (s *RedisSeenStore) key(sourceKey string) string {
+	return fmt.Sprintf("collector:seen:%s", sourceKey)
+}
+
+// IsSeen 检查URL是否已采集
+func (s *RedisSeenStore) IsSeen(ctx context.Context, sourceKey, url string) (bool, error) {
+	return s.client.SIsMember(ctx, s.key(sourceKey), hashURL(url)).Result()
+}
+
+// MarkSeen 将URL加入已采集集合，SADD 与刷新过期时间在同一次往返中完成
+func (s *RedisSeenStore) MarkSeen(ctx context.Context, sourceKey, url string) (bool, error) {
+	key := s.key(sourceKey)
+	var added *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(ctx, key, hashURL(url))
+		if s.ttl > 0 {
+			pipe.Expire(ctx, key, s.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val() == 1, nil
+}
+
+// Forget 将URL移出已采集集合
+func (s *RedisSeenStore) Forget(ctx context.Context, sourceKey, url string) error {
+	return s.client.SRem(ctx, s.key(sourceKey), hashURL(url)).Err()
+}
+
+// Count 返回采集源已记录的URL数量
+func (s *RedisSeenStore) Count(ctx context.Context, sourceKey string) (int64, error) {
+	return s.client.SCard(ctx, s.key(sourceKey)).Result()
+}
+
+// Reset 清空采集源的已采集集合
+func (s *RedisSeenStore) Reset(ctx context.Context, sourceKey string) error {
+	return s.client.Del(ctx, s.key(sourceKey)).Err()
+}
+
+// MemorySeenStore 进程内实现，用于测试或未配置Redis的场景
+type MemorySeenStore struct {
+	mu   sync.Mutex
+	sets map[string]map[string]struct{}
+}
+
+// NewMemorySeenStore 创建内存已采集集合
+func NewMemorySeenStore() *MemorySeenStore {
+	return &MemorySeenStore{sets: make(map[string]map[string]struct{})}
+}
+
+// IsSeen 检查URL是否已采集
+func (s *MemorySeenStore) IsSeen(ctx context.Context, sourceKey, url string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sets[sourceKey][hashURL(url)]
+	return ok, nil
+}
+
+// MarkSeen 将URL加入已采集集合
+func (s *MemorySeenStore) MarkSeen(ctx context.Context, sourceKey, url string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sets[sourceKey] == nil {
+		s.sets[sourceKey] = make(map[string]struct{})
+	}
+	hash := hashURL(url)
+	if _, ok := s.sets[sourceKey][hash]; ok {
+		return false, nil
+	}
+	s.sets[sourceKey][hash] = struct{}{}
+	return true, nil
+}
+
+// Forget 将URL移出已采集集合
+func (s *MemorySeenStore) Forget(ctx context.Context, sourceKey, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sets[sourceKey], hashURL(url))
+	return nil
+}
+
+// Count 返回采集源已记录的URL数量
+func (s *MemorySeenStore) Count(ctx context.Context, sourceKey string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.sets[sourceKey])), nil
+}
+
+// Reset 清空采集源的已采集集合
+func (s *MemorySeenStore) Reset(ctx context.Context, sourceKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sets, sourceKey)
+	return nil
+}
+
+func hashURL(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// isIncremental 是否开启增量采集（incremental=true）
+func isIncremental(params map[string]string) bool {
+	v := params["incremental"]
+	return v == "true" || v == "1"
+}
+
+// shouldResetSeen 是否在采集前清空已采集集合（reset_seen=true）
+func shouldResetSeen(params map[string]string) bool {
+	v := params["reset_seen"]
+	return v == "true" || v == "1"
+}
+
+// incrementalTracker 单次增量采集的状态，nil 表示未开启增量模式
+type incrementalTracker struct {
+	ctx       context.Context
+	store     SeenStore
+	sourceKey string
+	seedURL   string
+}
+
+// newIncrementalTracker 根据采集参数创建增量跟踪器，未开启增量或未配置存储时返回 nil
+func newIncrementalTracker(ctx context.Context, store SeenStore, sourceKey, seedURL string, params map[string]string) (*incrementalTracker, error) {
+	if store == nil || !isIncremental(params) {
+		return nil, nil
+	}
+
+	if shouldResetSeen(params) {
+		if err := store.Reset(ctx, sourceKey); err != nil {
+			return nil, fmt.Errorf("failed to reset seen set: %w", err)
+		}
+		logrus.WithField("source_key", sourceKey).Info("Seen set reset")
+	}
+
+	return &incrementalTracker{
+		ctx:       ctx,
+		store:     store,
+		sourceKey: sourceKey,
+		seedURL:   seedURL,
+	}, nil
+}
+
+// seenCtxKey 请求上下文中记录URL是否在之前的采集中出现过的键。
+// colly 的子请求与重试共用同一个上下文，因此按URL区分
+func seenCtxKey(url string) string {
+	return "seen_before:" + url
+}
+
+// attach 在爬虫上注册增量采集回调：请求前跳过已采集的页面，页面处理完成后才写入已采集集合。
+// 被中止（任务取消、超时、限速等待失败）或请求失败的页面不会写入，下次采集时重新访问
+func (t *incrementalTracker) attach(c *colly.Collector) {
+	if t == nil {
+		return
+	}
+
+	c.OnRequest(func(r *colly.Request) {
+		url := r.URL.String()
+		key := seenCtxKey(url)
+		if r.Ctx.GetAny(key) != nil {
+			// 限流后的重试，已检查过
+			return
+		}
+
+		seen, err := t.store.IsSeen(t.ctx, t.sourceKey, url)
+		if err != nil {
+			// 存储不可用时退化为全量采集
+			logrus.WithError(err).WithField("url", url).Warn("Failed to check seen set")
+			seen = false
+		}
+		r.Ctx.Put(key, seen)
+
+		// 起始页始终访问以发现新链接，其内容是否输出由 skip 决定
+		if seen && url != t.seedURL {
+			logrus.WithField("url", url).Debug("Skipping previously collected URL")
+			r.Abort()
+		}
+	})
+
+	// OnScraped 在页面的所有 OnHTML 回调之后执行，此时页面文本已交给 textChan；
+	// 采集已取消时文本可能未送出或未写入，不记录
+	c.OnScraped(func(r *colly.Response) {
+		if t.skip(r.Request) || t.ctx.Err() != nil {
+			return
+		}
+		url := r.Request.URL.String()
+		if _, err := t.store.MarkSeen(t.ctx, t.sourceKey, url); err != nil {
+			logrus.WithError(err).WithField("url", url).Warn("Failed to add URL to seen set")
+		}
+	})
+}
+
+// skip 当前页面是否已在之前的采集中处理过
+func (t *incrementalTracker) skip(r *colly.Request) bool {
+	if t == nil {
+		return false
+	}
+	seenBefore, _ := r.Ctx.GetAny(seenCtxKey(r.URL.String())).(bool)
+	return seenBefore
+}
@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func TestRedisSeenStoreSurvivesRestart(t *testing.T) {
+	var mu sync.Mutex
+	pages := []string{"a", "b"}
+	server := newTestSite(t, &pages, &mu)
+	mr := miniredis.RunT(t)
+
+	source := &pb.CollectionSource{
+		Type: pb.SourceType_WEB_CRAWLER,
+		Url:  server.URL + "/",
+		Parameters: map[string]string{
+			"selectors":    "p",
+			"follow_links": "true",
+			"incremental":  "true",
+		},
+	}
+	cfg := &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100}
+
+	// newReplica 模拟一次进程启动：新的 Redis 连接与新的采集器
+	newReplica := func() *WebCollector {
+		c, err := NewWebCollector(&config.Config{})
+		require.NoError(t, err)
+		c.SetSeenStore(NewRedisSeenStore(newTestRedisClient(t, mr), time.Hour))
+		return c
+	}
+
+	first := contents(collectAll(t, newReplica(), source, cfg))
+	assert.ElementsMatch(t, []string{"index page content", "content of page a", "content of page b"}, first)
+
+	mu.Lock()
+	pages = append(pages, "c")
+	mu.Unlock()
+
+	second := contents(collectAll(t, newReplica(), source, cfg))
+	assert.Equal(t, []string{"content of page c"}, second)
+}
+
+func TestRedisSeenStoreMarkSeen(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	store := NewRedisSeenStore(newTestRedisClient(t, mr), time.Hour)
+
+	added, err := store.MarkSeen(ctx, "zhihu", "https://www.zhihu.com/question/1")
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.MarkSeen(ctx, "zhihu", "https://www.zhihu.com/question/1")
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	count, err := store.Count(ctx, "zhihu")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, store.Forget(ctx, "zhihu", "https://www.zhihu.com/question/1"))
+	seen, err := store.IsSeen(ctx, "zhihu", "https://www.zhihu.com/question/1")
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	// 超过 TTL 未写入的集合过期
+	_, err = store.MarkSeen(ctx, "zhihu", "https://www.zhihu.com/question/2")
+	require.NoError(t, err)
+	mr.FastForward(2 * time.Hour)
+	count, err = store.Count(ctx, "zhihu")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestIncrementalForgetsFailedPages(t *testing.T) {
+	var mu sync.Mutex
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<html><body><a href="/flaky">flaky</a></body></html>`))
+			return
+		}
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`<html><body><p>recovered</p></body></html>`))
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewWebCollector(&config.Config{})
+	require.NoError(t, err)
+	store := NewMemorySeenStore()
+	c.SetSeenStore(store)
+	source := &pb.CollectionSource{
+		Type:       pb.SourceType_WEB_CRAWLER,
+		Url:        server.URL + "/",
+		Parameters: map[string]string{"selectors": "p", "follow_links": "true", "incremental": "true"},
+	}
+	cfg := &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100}
+
+	assert.Empty(t, collectAll(t, c, source, cfg))
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	assert.Equal(t, []string{"recovered"}, contents(collectAll(t, c, source, cfg)))
+}
+
+// ctxDomainLimiter 不限速，ctx 结束后返回错误，模拟任务取消时限速等待失败而中止请求
+type ctxDomainLimiter struct{}
+
+func (ctxDomainLimiter) Wait(ctx context.Context, domain string) error {
+	return ctx.Err()
+}
+
+func TestIncrementalRevisitsPagesAbortedByCancellation(t *testing.T) {
+	var mu sync.Mutex
+	pages := []string{"a", "b", "c", "d"}
+	ctx, cancel := context.WithCancel(context.Background())
+	var site *httptest.Server
+	site = newTestSite(t, &pages, &mu)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 访问到 b 时任务被取消，之后排队的页面在限速等待时中止
+		if r.URL.Path == "/b" {
+			cancel()
+		}
+		site.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewWebCollector(&config.Config{})
+	require.NoError(t, err)
+	c.SetSeenStore(NewMemorySeenStore())
+	c.SetDomainLimiter(ctxDomainLimiter{})
+	source := &pb.CollectionSource{
+		Type:       pb.SourceType_WEB_CRAWLER,
+		Url:        server.URL + "/",
+		Parameters: map[string]string{"selectors": "p", "follow_links": "true", "incremental": "true"},
+	}
+	cfg := &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100}
+
+	textChan := make(chan *pb.RawText, 100)
+	c.Collect(ctx, source, cfg, textChan)
+	close(textChan)
+	var first []string
+	for text := range textChan {
+		first = append(first, text.Content)
+	}
+	assert.NotContains(t, first, "content of page c")
+	assert.NotContains(t, first, "content of page d")
+
+	// 取消前已处理完的页面不再输出，未处理完的页面在下次采集时补齐
+	second := contents(collectAll(t, c, source, cfg))
+	assert.NotContains(t, second, "content of page a")
+	assert.ElementsMatch(t, []string{"index page content", "content of page a", "content of page b", "content of page c", "content of page d"},
+		dedupStrings(append(first, second...)))
+}
+
+func dedupStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
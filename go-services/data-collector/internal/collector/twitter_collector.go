@@ -0,0 +1,304 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+const (
+	twitterAPIBase = "https://api.twitter.com"
+	// twitterStatusURL 推文页地址前缀，拼接 用户名/status/推文ID 后作为 url
+	twitterStatusURL = "https://x.com/"
+
+	// twitterMinPageSize、twitterMaxPageSize 最近推文搜索接口 max_results 的取值范围
+	twitterMinPageSize = 10
+	twitterMaxPageSize = 100
+	// twitterMaxRateLimitWait 等待限流窗口重置的上限，接口的限流窗口为 15 分钟
+	twitterMaxRateLimitWait = 15 * time.Minute
+)
+
+// TwitterCollector 通过 Twitter/X API v2 的最近推文搜索接口采集推文，按 next_token 翻页
+//
+// 参数（source.Parameters）：
+//   - query: 搜索条件，语法同接口的 query 参数，如 "golang lang:en -is:retweet"
+//   - bearer_token: 应用的 Bearer Token，不随任务记录持久化
+//   - page_size: 每页推文数，取值 10-100，默认 100
+type TwitterCollector struct {
+	config  *config.Config
+	client  *http.Client
+	apiBase string
+	maxWait time.Duration // 单次等待限流窗口重置的上限
+
+	domainLimiter DomainLimiter
+}
+
+// twitterSearchResponse 最近推文搜索结果，作者信息通过 expansions=author_id 放在 includes.users 中
+type twitterSearchResponse struct {
+	Data     []twitterTweet `json:"data"`
+	Includes struct {
+		Users []twitterUser `json:"users"`
+	} `json:"includes"`
+	Meta struct {
+		ResultCount int    `json:"result_count"`
+		NextToken   string `json:"next_token"`
+	} `json:"meta"`
+	Errors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// twitterTweet 推文
+type twitterTweet struct {
+	ID            string `json:"id"`
+	Text          string `json:"text"`
+	AuthorID      string `json:"author_id"`
+	CreatedAt     string `json:"created_at"`
+	Lang          string `json:"lang"`
+	PublicMetrics struct {
+		LikeCount    int `json:"like_count"`
+		RetweetCount int `json:"retweet_count"`
+		ReplyCount   int `json:"reply_count"`
+		QuoteCount   int `json:"quote_count"`
+	} `json:"public_metrics"`
+}
+
+// twitterUser 推文作者
+type twitterUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// NewTwitterCollector 创建 Twitter/X 采集器
+func NewTwitterCollector(cfg *config.Config) (*TwitterCollector, error) {
+	return &TwitterCollector{
+		config:  cfg,
+		client:  &http.Client{Timeout: cfg.Collector.Timeout, Transport: newDecodingTransport(nil)},
+		apiBase: twitterAPIBase,
+		maxWait: twitterMaxRateLimitWait,
+	}, nil
+}
+
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (t *TwitterCollector) SetDomainLimiter(limiter DomainLimiter) {
+	t.domainLimiter = limiter
+}
+
+// Collect 逐页搜索推文，直到没有 next_token 或达到最大采集数量。
+// 配额用尽（x-rate-limit-remaining 为 0）时等待到 x-rate-limit-reset 再请求下一页
+func (t *TwitterCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	query := strings.TrimSpace(source.Parameters["query"])
+	if query == "" {
+		return Permanent(fmt.Errorf("twitter source requires parameters.query"))
+	}
+	token := strings.TrimSpace(source.Parameters["bearer_token"])
+	if token == "" {
+		return Permanent(fmt.Errorf("twitter source requires parameters.bearer_token"))
+	}
+	pageSize := twitterMaxPageSize
+	if raw := source.Parameters["page_size"]; raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < twitterMinPageSize || size > twitterMaxPageSize {
+			return Permanent(fmt.Errorf("twitter page_size must be between %d and %d", twitterMinPageSize, twitterMaxPageSize))
+		}
+		pageSize = size
+	}
+
+	maxCount := config.MaxCount
+	if maxCount <= 0 {
+		maxCount = 1000 // 默认最大采集数量
+	}
+	var limiter *rate.Limiter
+	if config.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.RateLimit), 1)
+	}
+	logrus.WithField("query", query).Info("Starting Twitter collection")
+
+	collected := int32(0)
+	nextToken := ""
+	var pause time.Duration
+	for page := 1; collected < maxCount; page++ {
+		if pause > 0 {
+			logrus.WithField("wait", pause).Warn("Twitter rate limit exhausted, waiting for reset")
+			if err := sleepContext(ctx, pause); err != nil {
+				return err
+			}
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return fmt.Errorf("rate limiter error: %w", err)
+			}
+		}
+
+		params := url.Values{}
+		params.Set("query", query)
+		params.Set("max_results", strconv.Itoa(pageSize))
+		params.Set("tweet.fields", "author_id,created_at,lang,public_metrics")
+		params.Set("expansions", "author_id")
+		params.Set("user.fields", "username")
+		if nextToken != "" {
+			params.Set("next_token", nextToken)
+		}
+		var result twitterSearchResponse
+		var err error
+		pause, err = t.search(ctx, params, token, &result)
+		if err != nil {
+			return fmt.Errorf("failed to fetch twitter search page %d: %w", page, err)
+		}
+
+		usernames := make(map[string]string, len(result.Includes.Users))
+		for _, user := range result.Includes.Users {
+			usernames[user.ID] = user.Username
+		}
+		for _, tweet := range result.Data {
+			if collected >= maxCount {
+				break
+			}
+			content := cleanText(tweet.Text)
+			if content == "" {
+				continue
+			}
+
+			rawText := &pb.RawText{
+				Id:        uuid.New().String(),
+				Content:   content,
+				Source:    "twitter:search",
+				Timestamp: time.Now().UnixMilli(),
+				Metadata:  tweetMetadata(tweet, usernames[tweet.AuthorID]),
+			}
+			select {
+			case textChan <- rawText:
+				collected++
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if result.Meta.NextToken == "" || result.Meta.NextToken == nextToken {
+			break
+		}
+		nextToken = result.Meta.NextToken
+	}
+
+	logrus.WithField("total_collected", collected).Info("Twitter collection completed")
+	return nil
+}
+
+// tweetMetadata 推文的作者、互动数与语言等元数据
+func tweetMetadata(tweet twitterTweet, username string) map[string]string {
+	metadata := map[string]string{
+		PlatformMetadataKey: "twitter",
+		TypeMetadataKey:     "tweet",
+		"tweet_id":          tweet.ID,
+		"author_id":         tweet.AuthorID,
+		"lang":              tweet.Lang,
+		"created_at":        tweet.CreatedAt,
+		"like_count":        strconv.Itoa(tweet.PublicMetrics.LikeCount),
+		"retweet_count":     strconv.Itoa(tweet.PublicMetrics.RetweetCount),
+		"reply_count":       strconv.Itoa(tweet.PublicMetrics.ReplyCount),
+		"quote_count":       strconv.Itoa(tweet.PublicMetrics.QuoteCount),
+	}
+	if username != "" {
+		metadata[AuthorMetadataKey] = username
+		metadata[URLMetadataKey] = twitterStatusURL + username + "/status/" + tweet.ID
+	}
+	return metadata
+}
+
+// search 请求一页搜索结果，被限流（429）时等待限流窗口重置后重试。
+// 返回请求下一页前需要等待的时间，配额未用尽时为 0
+func (t *TwitterCollector) search(ctx context.Context, params url.Values, token string, out *twitterSearchResponse) (time.Duration, error) {
+	endpoint := t.apiBase + "/2/tweets/search/recent?" + params.Encode()
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return 0, Permanent(fmt.Errorf("failed to create request: %w", err))
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+		if len(t.config.Collector.UserAgents) > 0 {
+			req.Header.Set("User-Agent", t.config.Collector.UserAgents[0])
+		}
+
+		if err := waitDomain(ctx, t.domainLimiter, req.URL.Hostname()); err != nil {
+			return 0, fmt.Errorf("rate limiter error: %w", err)
+		}
+		resp, err := t.client.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("failed to send request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxThrottleRetries {
+			wait := t.rateLimitWait(resp.Header, time.Now())
+			resp.Body.Close()
+			logrus.WithFields(logrus.Fields{
+				"attempt": attempt + 1,
+				"wait":    wait,
+			}).Warn("Throttled by Twitter API, retrying after rate limit reset")
+			if err := sleepContext(ctx, wait); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("twitter API returned status %d", resp.StatusCode)
+			// 鉴权失败或查询语法错误，重试无意义
+			if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+				err = Permanent(err)
+			}
+			return 0, err
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return 0, fmt.Errorf("failed to parse twitter response: %w", err)
+		}
+		if len(out.Data) == 0 && len(out.Errors) > 0 {
+			return 0, Permanent(fmt.Errorf("twitter API error: %s: %s", out.Errors[0].Title, out.Errors[0].Detail))
+		}
+
+		var pause time.Duration
+		if resp.Header.Get("x-rate-limit-remaining") == "0" {
+			pause = t.rateLimitWait(resp.Header, time.Now())
+		}
+		return pause, nil
+	}
+}
+
+// rateLimitWait 根据 x-rate-limit-reset（限流窗口重置的 Unix 秒数）计算等待时间，
+// 未给出时按 Retry-After 或 1 秒，结果不超过 maxWait
+func (t *TwitterCollector) rateLimitWait(header http.Header, now time.Time) time.Duration {
+	var wait time.Duration
+	if reset, err := strconv.ParseInt(header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+		wait = time.Unix(reset, 0).Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+	} else if parsed, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+		wait = parsed
+	} else {
+		wait = time.Second
+	}
+	if wait > t.maxWait {
+		wait = t.maxWait
+	}
+	return wait
+}
@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestTwitter 模拟最近推文搜索接口：共 total 条推文，每页 3 条，next_token 为下一条的序号。
+// 第一次请求被限流，第二页返回后配额用尽，两次都要求等待限流窗口重置
+func newTestTwitter(t *testing.T, total int, requests *int32) *TwitterCollector {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(requests, 1)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/2/tweets/search/recent", r.URL.Path)
+		assert.Equal(t, "golang lang:en", r.URL.Query().Get("query"))
+		assert.Equal(t, "author_id", r.URL.Query().Get("expansions"))
+
+		reset := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+		if call == 1 {
+			w.Header().Set("x-rate-limit-remaining", "0")
+			w.Header().Set("x-rate-limit-reset", reset)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("next_token"))
+		end := min(start+3, total)
+		tweets := make([]map[string]interface{}, 0, end-start)
+		for i := start; i < end; i++ {
+			tweets = append(tweets, map[string]interface{}{
+				"id":             strconv.Itoa(100 + i),
+				"text":           fmt.Sprintf("tweet %d", i),
+				"author_id":      strconv.Itoa(i % 2),
+				"lang":           "en",
+				"created_at":     "2026-10-01T00:00:00.000Z",
+				"public_metrics": map[string]int{"like_count": i, "retweet_count": i * 2},
+			})
+		}
+		meta := map[string]interface{}{"result_count": len(tweets)}
+		if end < total {
+			meta["next_token"] = strconv.Itoa(end)
+		}
+		if end == 6 {
+			w.Header().Set("x-rate-limit-remaining", "0")
+		} else {
+			w.Header().Set("x-rate-limit-remaining", "10")
+		}
+		w.Header().Set("x-rate-limit-reset", reset)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":     tweets,
+			"includes": map[string]interface{}{"users": []map[string]string{{"id": "0", "username": "alice"}, {"id": "1", "username": "bob"}}},
+			"meta":     meta,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewTwitterCollector(&config.Config{})
+	require.NoError(t, err)
+	c.apiBase = server.URL
+	c.maxWait = 20 * time.Millisecond
+	return c
+}
+
+func twitterTestSource() *pb.CollectionSource {
+	return &pb.CollectionSource{Type: pb.SourceType_TWITTER, Parameters: map[string]string{
+		"query":        "golang lang:en",
+		"bearer_token": "secret",
+		"page_size":    "10",
+	}}
+}
+
+func TestTwitterCollectorPaginatesWithRateLimitBackoff(t *testing.T) {
+	var requests int32
+	c := newTestTwitter(t, 8, &requests)
+
+	start := time.Now()
+	texts := collectAll(t, c, twitterTestSource(), &pb.CollectionConfig{MaxCount: 20})
+	elapsed := time.Since(start)
+
+	require.Len(t, texts, 8)
+	for i, text := range texts {
+		assert.Equal(t, fmt.Sprintf("tweet %d", i), text.Content)
+	}
+	// 一次 429 重试加三页
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+	// 429 与配额用尽各等待一次，每次被 maxWait 截断
+	assert.GreaterOrEqual(t, elapsed, 40*time.Millisecond)
+
+	assert.Equal(t, "twitter:search", texts[1].Source)
+	metadata := texts[1].Metadata
+	assert.Equal(t, "bob", metadata[AuthorMetadataKey])
+	assert.Equal(t, "https://x.com/bob/status/101", metadata[URLMetadataKey])
+	assert.Equal(t, "twitter", metadata[PlatformMetadataKey])
+	assert.Equal(t, "tweet", metadata[TypeMetadataKey])
+	assert.Equal(t, "101", metadata["tweet_id"])
+	assert.Equal(t, "1", metadata["like_count"])
+	assert.Equal(t, "2", metadata["retweet_count"])
+	assert.Equal(t, "en", metadata["lang"])
+}
+
+func TestTwitterCollectorRespectsMaxCount(t *testing.T) {
+	var requests int32
+	c := newTestTwitter(t, 8, &requests)
+
+	texts := collectAll(t, c, twitterTestSource(), &pb.CollectionConfig{MaxCount: 2})
+	assert.Equal(t, []string{"tweet 0", "tweet 1"}, contents(texts))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestTwitterCollectorRejectsInvalidSource(t *testing.T) {
+	var requests int32
+	c := newTestTwitter(t, 8, &requests)
+
+	for name, params := range map[string]map[string]string{
+		"missing query": {"bearer_token": "secret"},
+		"missing token": {"query": "golang"},
+		"page size":     {"query": "golang", "bearer_token": "secret", "page_size": "500"},
+	} {
+		err := c.Collect(context.Background(), &pb.CollectionSource{Parameters: params}, &pb.CollectionConfig{}, make(chan *pb.RawText, 1))
+		assert.True(t, IsPermanent(err), name)
+	}
+
+	// 令牌错误时接口返回 401，不再重试
+	source := twitterTestSource()
+	source.Parameters["bearer_token"] = "wrong"
+	err := c.Collect(context.Background(), source, &pb.CollectionConfig{}, make(chan *pb.RawText, 1))
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestTwitterRateLimitWait(t *testing.T) {
+	c := &TwitterCollector{maxWait: time.Minute}
+	now := time.Unix(1700000000, 0)
+
+	header := http.Header{}
+	header.Set("x-rate-limit-reset", "1700000030")
+	assert.Equal(t, 30*time.Second, c.rateLimitWait(header, now))
+	header.Set("x-rate-limit-reset", "1700000900")
+	assert.Equal(t, time.Minute, c.rateLimitWait(header, now))
+	header.Set("x-rate-limit-reset", "1699999990")
+	assert.Zero(t, c.rateLimitWait(header, now))
+
+	header = http.Header{}
+	header.Set("Retry-After", "5")
+	assert.Equal(t, 5*time.Second, c.rateLimitWait(header, now))
+	assert.Equal(t, time.Second, c.rateLimitWait(http.Header{}, now))
+}
@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // containsChinese 检查文本是否包含中文字符
@@ -32,11 +33,85 @@ func cleanText(text string) string {
 	// 去除多余的空白字符
 	text = strings.TrimSpace(text)
 	
-	// 去除连续的空格
-	spaceRegex := regexp.MustCompile(`\s+`)
-	text = spaceRegex.ReplaceAllString(text, " ")
-	
-	return text
+	// 将连续的空白字符（与正则 \s 一致：空格、\t、\n、\f、\r）合并为一个空格，
+	// 无需改写时直接返回原字符串
+	if !needsSpaceCollapse(text) {
+		return text
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	inSpace := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if isASCIISpace(c) {
+			if !inSpace {
+				buf.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// isASCIISpace 判断字节是否属于正则 \s 匹配的空白字符
+func isASCIISpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// needsSpaceCollapse 判断文本中是否存在需要替换的空白（非单个空格或连续空白）
+func needsSpaceCollapse(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if !isASCIISpace(text[i]) {
+			continue
+		}
+		if text[i] != ' ' || (i+1 < len(text) && isASCIISpace(text[i+1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeText 删除除 \t、\n、\r 以外的控制字符（C0、DEL 与 C1），非法 UTF-8 字节替换为 U+FFFD，
+// 避免 NUL 等字符破坏数据库存储与下游分词；中文、emoji 及零宽连接符等格式字符原样保留。
+// model-inference 推理前使用相同规则（sanitize.Text），修改时需同步
+func SanitizeText(text string) string {
+	if isSanitized(text) {
+		return text
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf.WriteRune(utf8.RuneError)
+		case isStrippedControl(r):
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// isStrippedControl 判断字符是否为需要删除的控制字符
+func isStrippedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
+
+// isSanitized 判断文本是否为合法 UTF-8 且不含需要删除的控制字符
+func isSanitized(text string) bool {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if (r == utf8.RuneError && size == 1) || isStrippedControl(r) {
+			return false
+		}
+		i += size
+	}
+	return true
 }
 
 // isValidTextLength 检查文本长度是否有效
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gocolly/colly/v2"
@@ -17,15 +19,34 @@ import (
 )
 
 type WebCollector struct {
-	config *config.Config
+	config        *config.Config
+	seenStore     SeenStore
+	domainLimiter DomainLimiter
+	// transport 配置了代理时为代理传输层，否则为 nil
+	transport http.RoundTripper
 }
 
 func NewWebCollector(cfg *config.Config) (*WebCollector, error) {
+	transport, err := newProxyTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &WebCollector{
-		config: cfg,
+		config:    cfg,
+		transport: transport,
 	}, nil
 }
 
+// SetSeenStore 设置增量采集使用的已采集URL存储
+func (c *WebCollector) SetSeenStore(store SeenStore) {
+	c.seenStore = store
+}
+
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (c *WebCollector) SetDomainLimiter(limiter DomainLimiter) {
+	c.domainLimiter = limiter
+}
+
 func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
 	logrus.WithField("url", source.Url).Info("Starting web crawling")
 
@@ -34,12 +55,21 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 		colly.Debugger(&debug.LogDebugger{}),
 		colly.UserAgent(c.getRandomUserAgent()),
 	)
-
-	// 设置限制
+	// 解压 br/deflate 响应，colly 只处理 gzip
+	collector.WithTransport(newDecodingTransport(c.transport))
+
+	// 设置限制，每次请求后等待 delay 加上 [0, jitter) 的随机时间
+	// 任务未设置速率时使用全局配置
+	rateLimit := config.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = int32(c.config.Collector.RateLimit)
+	}
+	delay, jitter := c.crawlDelay(rateLimit)
 	collector.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
 		Parallelism: int(config.ConcurrentLimit),
-		Delay:       time.Second / time.Duration(config.RateLimit),
+		Delay:       delay,
+		RandomDelay: jitter,
 	})
 
 	collected := int32(0)
@@ -48,8 +78,24 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 		maxCount = 100 // 默认最大采集数量
 	}
 
+	htmlOpts, err := parseRawHTMLOptions(source.Parameters)
+	if err != nil {
+		return err
+	}
+
+	// 增量采集 - 跳过之前已采集过的URL
+	tracker, err := newIncrementalTracker(ctx, c.seenStore, fmt.Sprintf("web:%s", extractDomain(source.Url)), source.Url, source.Parameters)
+	if err != nil {
+		return err
+	}
+	tracker.attach(collector)
+
 	// 设置请求回调
 	collector.OnRequest(func(r *colly.Request) {
+		if err := waitDomain(ctx, c.domainLimiter, r.URL.Hostname()); err != nil {
+			r.Abort()
+			return
+		}
 		logrus.WithField("url", r.URL.String()).Debug("Visiting URL")
 		
 		// 随机设置User-Agent
@@ -71,16 +117,41 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 		}).Debug("Received response")
 	})
 
+	// 页面级的作者、发布时间与原始 HTML，先于选择器回调解析；同步模式下跟随链接会嵌套抓取，按请求区分
+	var metaMutex sync.Mutex
+	pageMetas := make(map[uint32]pageMeta)
+	collector.OnHTML("html", func(e *colly.HTMLElement) {
+		meta := extractPageMeta(e.DOM, source.Parameters)
+		if htmlOpts.enabled {
+			encoded, truncated, err := encodeRawHTML(e.Response.Body, htmlOpts.maxBytes)
+			if err != nil {
+				logrus.WithError(err).WithField("url", e.Request.URL.String()).Warn("Failed to encode raw HTML")
+			} else {
+				meta.RawHTML, meta.RawHTMLTruncated = encoded, truncated
+			}
+		}
+		metaMutex.Lock()
+		pageMetas[e.Request.ID] = meta
+		metaMutex.Unlock()
+	})
+
+	// 同一内容可能被多个选择器命中或出现在多个页面，本次爬取内只输出一次
+	dedup := newContentDedup(c.config.Collector.CrawlDedupCapacity)
+
 	// 设置HTML回调 - 根据参数配置选择器
 	selectors := c.getSelectors(source.Parameters)
 	for _, selector := range selectors {
 		collector.OnHTML(selector, func(e *colly.HTMLElement) {
-			if collected >= maxCount {
+			if collected >= maxCount || tracker.skip(e.Request) {
 				return
 			}
 
 			text := strings.TrimSpace(e.Text)
-			if !c.applyFilters(text, config.Filters) {
+			if !ApplyFilters(text, config.Filters) {
+				return
+			}
+			if dedup.seen(text) {
+				logrus.WithField("url", e.Request.URL.String()).Debug("Skipping duplicate text in crawl")
 				return
 			}
 
@@ -93,8 +164,13 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 					"url":      e.Request.URL.String(),
 					"selector": selector,
 					"tag":      e.Name,
+					"type":     "page",
+					"platform": "web",
 				},
 			}
+			metaMutex.Lock()
+			pageMetas[e.Request.ID].apply(rawText.Metadata)
+			metaMutex.Unlock()
 
 			select {
 			case textChan <- rawText:
@@ -126,6 +202,10 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 
 	// 错误处理
 	collector.OnError(func(r *colly.Response, err error) {
+		// 被限流时按 Retry-After 等待后重试
+		if retryThrottled(ctx, r, 5*time.Second) {
+			return
+		}
 		logrus.WithFields(logrus.Fields{
 			"url":   r.Request.URL.String(),
 			"error": err.Error(),
@@ -134,11 +214,14 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 
 	// 完成回调
 	collector.OnScraped(func(r *colly.Response) {
+		metaMutex.Lock()
+		delete(pageMetas, r.Request.ID)
+		metaMutex.Unlock()
 		logrus.WithField("url", r.Request.URL.String()).Debug("Finished scraping")
 	})
 
 	// 开始爬取
-	if err := collector.Visit(source.Url); err != nil {
+	if err := visitSeed(collector, source.Url); err != nil {
 		return fmt.Errorf("failed to start crawling: %w", err)
 	}
 
@@ -149,6 +232,22 @@ func (c *WebCollector) Collect(ctx context.Context, source *pb.CollectionSource,
 	return nil
 }
 
+// crawlDelay 返回每次请求后的固定等待时间与随机抖动上限：固定部分取 rateLimit 换算的间隔与 crawl_delay_min 的较大者，
+// 抖动使总等待不超过 crawl_delay_max；rateLimit 未设置时不按速率等待
+func (c *WebCollector) crawlDelay(rateLimit int32) (time.Duration, time.Duration) {
+	var delay time.Duration
+	if rateLimit > 0 {
+		delay = time.Second / time.Duration(rateLimit)
+	}
+	delay = max(delay, c.config.Collector.CrawlDelayMin)
+
+	var jitter time.Duration
+	if maxDelay := c.config.Collector.CrawlDelayMax; maxDelay > delay {
+		jitter = maxDelay - delay
+	}
+	return delay, jitter
+}
+
 func (c *WebCollector) getSelectors(params map[string]string) []string {
 	// 从参数中获取选择器，如果没有则使用默认选择器
 	if selectors, exists := params["selectors"]; exists {
@@ -216,56 +315,6 @@ func (c *WebCollector) getRandomUserAgent() string {
 	return c.config.Collector.UserAgents[index]
 }
 
-func (c *WebCollector) applyFilters(content string, filters []string) bool {
-	if len(filters) == 0 {
-		return true
-	}
-
-	content = strings.TrimSpace(content)
-	
-	// 基本长度过滤
-	if len(content) < 5 || len(content) > 1000 {
-		return false
-	}
-
-	// 过滤纯数字或特殊字符
-	if isOnlyNumbersOrSymbols(content) {
-		return false
-	}
-
-	// 应用自定义过滤器
-	for _, filter := range filters {
-		switch filter {
-		case "no_empty":
-			if content == "" {
-				return false
-			}
-		case "no_short":
-			if len(content) < 20 {
-				return false
-			}
-		case "no_long":
-			if len(content) > 500 {
-				return false
-			}
-		case "no_url":
-			if strings.Contains(content, "http://") || strings.Contains(content, "https://") {
-				return false
-			}
-		case "no_email":
-			if strings.Contains(content, "@") && strings.Contains(content, ".") {
-				return false
-			}
-		case "chinese_only":
-			if !containsChinese(content) {
-				return false
-			}
-		}
-	}
-
-	return true
-}
-
 func extractDomain(url string) string {
 	if strings.HasPrefix(url, "http://") {
 		url = url[7:]
@@ -278,13 +327,4 @@ func extractDomain(url string) string {
 	}
 	
 	return url
-}
-
-func isOnlyNumbersOrSymbols(text string) bool {
-	for _, r := range text {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= 0x4e00 && r <= 0x9fff) {
-			return false
-		}
-	}
-	return true
 }
\ No newline at end of file
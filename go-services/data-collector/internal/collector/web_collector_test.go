@@ -0,0 +1,452 @@
+package collector
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// newTestSite 启动一个测试站点，首页链接到 pages 中的每个页面
+func newTestSite(t *testing.T, pages *[]string, mu *sync.Mutex) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/" {
+			var links strings.Builder
+			for _, page := range *pages {
+				fmt.Fprintf(&links, `<a href="/%s">%s</a>`, page, page)
+			}
+			fmt.Fprintf(w, `<html><body><p>index page content</p>%s</body></html>`, links.String())
+			return
+		}
+		fmt.Fprintf(w, `<html><body><p>content of page %s</p></body></html>`, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func collectAll(t *testing.T, c Collector, source *pb.CollectionSource, cfg *pb.CollectionConfig) []*pb.RawText {
+	textChan := make(chan *pb.RawText, 100)
+	err := c.Collect(context.Background(), source, cfg, textChan)
+	require.NoError(t, err)
+	close(textChan)
+
+	var texts []*pb.RawText
+	for text := range textChan {
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+func contents(texts []*pb.RawText) []string {
+	var result []string
+	for _, text := range texts {
+		result = append(result, text.Content)
+	}
+	return result
+}
+
+func TestWebCollectorIncrementalSkipsSeenURLs(t *testing.T) {
+	var mu sync.Mutex
+	pages := []string{"a", "b"}
+	server := newTestSite(t, &pages, &mu)
+
+	c, err := NewWebCollector(&config.Config{})
+	require.NoError(t, err)
+	c.SetSeenStore(NewMemorySeenStore())
+
+	source := &pb.CollectionSource{
+		Type: pb.SourceType_WEB_CRAWLER,
+		Url:  server.URL + "/",
+		Parameters: map[string]string{
+			"selectors":    "p",
+			"follow_links": "true",
+			"incremental":  "true",
+		},
+	}
+	cfg := &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100}
+
+	first := contents(collectAll(t, c, source, cfg))
+	assert.ElementsMatch(t, []string{"index page content", "content of page a", "content of page b"}, first)
+
+	// 站点新增页面后再次采集，只输出新页面
+	mu.Lock()
+	pages = append(pages, "c")
+	mu.Unlock()
+
+	second := contents(collectAll(t, c, source, cfg))
+	assert.Equal(t, []string{"content of page c"}, second)
+
+	// reset_seen 清空后重新全量采集
+	source.Parameters["reset_seen"] = "true"
+	third := contents(collectAll(t, c, source, cfg))
+	assert.Len(t, third, 4)
+}
+
+func TestWebCollectorDecodesCompressedResponses(t *testing.T) {
+	const page = `<html><body><p class="comment">压缩内容一</p><p class="comment">压缩内容二</p></body></html>`
+	encoders := map[string]func(w io.Writer) io.WriteCloser{
+		"br":   func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		// zlib 封装的标准 deflate
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+
+	for encoding, newEncoder := range encoders {
+		t.Run(encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Header().Set("Content-Encoding", encoding)
+				enc := newEncoder(w)
+				io.WriteString(enc, page)
+				enc.Close()
+			}))
+			t.Cleanup(server.Close)
+
+			c, err := NewWebCollector(&config.Config{})
+			require.NoError(t, err)
+			source := &pb.CollectionSource{
+				Type:       pb.SourceType_WEB_CRAWLER,
+				Url:        server.URL + "/",
+				Parameters: map[string]string{"selectors": "p.comment"},
+			}
+			texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, ConcurrentLimit: 1, RateLimit: 100})
+			assert.Equal(t, []string{"压缩内容一", "压缩内容二"}, contents(texts))
+		})
+	}
+}
+
+func TestDecodeBodyRawDeflate(t *testing.T) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	require.NoError(t, err)
+	io.WriteString(fw, "raw deflate body")
+	require.NoError(t, fw.Close())
+
+	body, err := decodeBody("deflate", io.NopCloser(&buf))
+	require.NoError(t, err)
+	defer body.Close()
+	decoded, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "raw deflate body", string(decoded))
+}
+
+func TestWebCollectorExtractsAuthorAndDate(t *testing.T) {
+	pages := map[string]string{
+		"/meta": `<html><head>
+			<meta name="author" content="张三">
+			<meta property="article:published_time" content="2024-03-01T08:30:00+08:00">
+			</head><body><p>meta 标签</p></body></html>`,
+		"/jsonld": `<html><head><script type="application/ld+json">
+			{"@context":"https://schema.org","@graph":[{"@type":"WebSite"},
+			{"@type":"Article","author":[{"@type":"Person","name":"李四"},{"@type":"Person","name":"王五"}],"datePublished":"2024-03-02"}]}
+			</script></head><body><p>JSON-LD</p></body></html>`,
+		"/selector": `<html><head><meta name="author" content="网站编辑"></head><body>
+			<span class="byline">赵六</span><span class="date">2024/03/03 12:00:00</span>
+			<p>自定义选择器</p></body></html>`,
+		"/none": `<html><body><p>没有元信息</p></body></html>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, pages[r.URL.Path])
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		path   string
+		params map[string]string
+		author string
+		date   string
+	}{
+		{path: "/meta", author: "张三", date: "2024-03-01T08:30:00+08:00"},
+		{path: "/jsonld", author: "李四, 王五", date: "2024-03-02T00:00:00Z"},
+		{
+			path:   "/selector",
+			params: map[string]string{"author_selector": ".byline", "date_selector": ".date"},
+			author: "赵六",
+			date:   "2024-03-03T12:00:00Z",
+		},
+		{path: "/none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			c, err := NewWebCollector(&config.Config{})
+			require.NoError(t, err)
+			params := map[string]string{"selectors": "p"}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			source := &pb.CollectionSource{Type: pb.SourceType_WEB_CRAWLER, Url: server.URL + tt.path, Parameters: params}
+			texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, ConcurrentLimit: 1, RateLimit: 100})
+			require.Len(t, texts, 1)
+
+			author, ok := texts[0].Metadata["author"]
+			assert.Equal(t, tt.author != "", ok)
+			assert.Equal(t, tt.author, author)
+			date, ok := texts[0].Metadata["published_at"]
+			assert.Equal(t, tt.date != "", ok)
+			assert.Equal(t, tt.date, date)
+		})
+	}
+}
+
+func TestWebCollectorStoresRawHTML(t *testing.T) {
+	page := `<html><body><p>第一段</p><p>第二段</p></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	t.Cleanup(server.Close)
+
+	collect := func(params map[string]string) []*pb.RawText {
+		c, err := NewWebCollector(&config.Config{})
+		require.NoError(t, err)
+		params["selectors"] = "p"
+		source := &pb.CollectionSource{Type: pb.SourceType_WEB_CRAWLER, Url: server.URL + "/", Parameters: params}
+		texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 10, ConcurrentLimit: 1, RateLimit: 100})
+		require.Len(t, texts, 2)
+		return texts
+	}
+	decode := func(text *pb.RawText) string {
+		assert.Equal(t, "gzip+base64", text.Metadata["raw_html_encoding"])
+		data, err := base64.StdEncoding.DecodeString(text.Metadata["raw_html"])
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		html, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		return string(html)
+	}
+
+	for _, text := range collect(map[string]string{}) {
+		assert.NotContains(t, text.Metadata, "raw_html")
+	}
+
+	for _, text := range collect(map[string]string{"store_html": "true"}) {
+		assert.Equal(t, page, decode(text))
+		assert.NotContains(t, text.Metadata, "raw_html_truncated")
+	}
+
+	texts := collect(map[string]string{"store_html": "true", "store_html_max_bytes": "20"})
+	assert.Equal(t, page[:20], decode(texts[0]))
+	assert.Equal(t, "true", texts[0].Metadata["raw_html_truncated"])
+}
+
+func TestWebCollectorCrawlDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		rateLimit  int32
+		min, max   time.Duration
+		wantDelay  time.Duration
+		wantJitter time.Duration
+	}{
+		{"rate only", 10, 0, 0, 100 * time.Millisecond, 0},
+		{"jitter range", 100, 200 * time.Millisecond, 500 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+		{"rate above min", 2, 100 * time.Millisecond, 800 * time.Millisecond, 500 * time.Millisecond, 300 * time.Millisecond},
+		{"rate unset", 0, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewWebCollector(&config.Config{Collector: config.CollectorConfig{CrawlDelayMin: tt.min, CrawlDelayMax: tt.max}})
+			require.NoError(t, err)
+			delay, jitter := c.crawlDelay(tt.rateLimit)
+			assert.Equal(t, tt.wantDelay, delay)
+			assert.Equal(t, tt.wantJitter, jitter)
+		})
+	}
+}
+
+func TestWebCollectorWaitsWithinJitterRange(t *testing.T) {
+	const minDelay, maxDelay = 40 * time.Millisecond, 80 * time.Millisecond
+	var mu sync.Mutex
+	var requestTimes []time.Time
+	pages := []string{"a", "b", "c", "d"}
+	site := newTestSite(t, &pages, &mu)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestTimes = append(requestTimes, time.Now())
+		mu.Unlock()
+		site.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewWebCollector(&config.Config{Collector: config.CollectorConfig{CrawlDelayMin: minDelay, CrawlDelayMax: maxDelay}})
+	require.NoError(t, err)
+	source := &pb.CollectionSource{
+		Type:       pb.SourceType_WEB_CRAWLER,
+		Url:        server.URL + "/",
+		Parameters: map[string]string{"selectors": "p", "follow_links": "true"},
+	}
+	texts := collectAll(t, c, source, &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100})
+	require.Len(t, texts, 5)
+
+	require.Len(t, requestTimes, 5)
+	for i := 1; i < len(requestTimes); i++ {
+		interval := requestTimes[i].Sub(requestTimes[i-1])
+		assert.GreaterOrEqual(t, interval, minDelay)
+		// 上限留出请求与解析本身的耗时
+		assert.Less(t, interval, maxDelay+50*time.Millisecond)
+	}
+}
+
+func TestCollectorsRunWithZeroRateLimit(t *testing.T) {
+	// 任务与全局配置都未设置速率（proto 默认值为 0）
+	cfg := &config.Config{Collector: config.CollectorConfig{Timeout: 5 * time.Second}}
+	collectCfg := &pb.CollectionConfig{MaxCount: 10}
+
+	t.Run("web", func(t *testing.T) {
+		var mu sync.Mutex
+		pages := []string{"a"}
+		server := newTestSite(t, &pages, &mu)
+		c, err := NewWebCollector(cfg)
+		require.NoError(t, err)
+
+		source := &pb.CollectionSource{
+			Type:       pb.SourceType_WEB_CRAWLER,
+			Url:        server.URL + "/",
+			Parameters: map[string]string{"selectors": "p", "follow_links": "true"},
+		}
+		assert.Len(t, collectAll(t, c, source, collectCfg), 2)
+	})
+
+	t.Run("api", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "" {
+				fmt.Fprintf(w, `{"data":[{"id":"1","content":"first page"}],"has_more":true,"next_url":"%s/?page=2"}`, "http://"+r.Host)
+				return
+			}
+			fmt.Fprint(w, `{"data":[{"id":"2","content":"second page"}]}`)
+		}))
+		t.Cleanup(server.Close)
+		c, err := NewAPICollector(cfg)
+		require.NoError(t, err)
+
+		texts := collectAll(t, c, &pb.CollectionSource{Type: pb.SourceType_API, Url: server.URL}, collectCfg)
+		assert.Equal(t, []string{"first page", "second page"}, contents(texts))
+	})
+}
+
+func TestWebCollectorSkipsDuplicateTextsWithinCrawl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body><div class="content"><p>shared paragraph</p></div><a href="/other">other</a></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><p>shared paragraph</p><p>only on other page</p></body></html>`)
+	}))
+	t.Cleanup(server.Close)
+	source := &pb.CollectionSource{
+		Type:       pb.SourceType_WEB_CRAWLER,
+		Url:        server.URL + "/",
+		Parameters: map[string]string{"selectors": "p,.content", "follow_links": "true"},
+	}
+	cfg := &pb.CollectionConfig{MaxCount: 100, ConcurrentLimit: 1, RateLimit: 100}
+
+	c, err := NewWebCollector(&config.Config{Collector: config.CollectorConfig{CrawlDedupCapacity: 1000}})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"shared paragraph", "only on other page"}, contents(collectAll(t, c, source, cfg)))
+
+	// 每次爬取使用新的过滤器
+	assert.Len(t, collectAll(t, c, source, cfg), 2)
+
+	// 未开启时按选择器与页面重复输出
+	c, err = NewWebCollector(&config.Config{})
+	require.NoError(t, err)
+	assert.Len(t, collectAll(t, c, source, cfg), 4)
+}
+
+func TestContentDedupFalsePositiveRate(t *testing.T) {
+	const capacity = 10000
+	dedup := newContentDedup(capacity)
+	for i := 0; i < capacity; i++ {
+		dedup.seen(fmt.Sprintf("text %d", i))
+	}
+	assert.True(t, dedup.seen("text 42"))
+
+	// 每次检查都会记录新文本，只抽查少量以保持在预期容量附近
+	falsePositives := 0
+	for i := 0; i < capacity/10; i++ {
+		if dedup.seen(fmt.Sprintf("unseen %d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, capacity/10*3/100)
+	assert.Nil(t, newContentDedup(0))
+}
+
+func BenchmarkEncodeRawHTML(b *testing.B) {
+	body := []byte(strings.Repeat(`<div class="comment"><p>这是一条用于基准测试的评论内容</p></div>`, 200))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := encodeRawHTML(body, defaultRawHTMLMaxBytes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCleanTextCollapsesWhitespace(t *testing.T) {
+	spaceRegex := regexp.MustCompile(`\s+`)
+	for _, text := range []string{
+		"",
+		"单行文本",
+		"  前后空白  ",
+		"a b c",
+		"多个  空格\t制表\n\n换行\r\n回车\f换页",
+		"全角空格　保留",
+		"\u00a0不换行空格\u00a0",
+	} {
+		assert.Equal(t, spaceRegex.ReplaceAllString(strings.TrimSpace(text), " "), cleanText(text), "%q", text)
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"clean", "正常的评论 👍", "正常的评论 👍"},
+		{"nul and bell", "加\x00微信\x07领券", "加微信领券"},
+		{"keeps whitespace", "第一行\n第二行\t\r\n", "第一行\n第二行\t\r\n"},
+		{"del and c1", "a\x7fb\u0085c\u009fd", "abcd"},
+		{"invalid utf8", "坏\xff字节", "坏\ufffd字节"},
+		{"emoji with zwj", "家庭👨\u200d👩\u200d👧\x00", "家庭👨\u200d👩\u200d👧"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeText(tt.text))
+		})
+	}
+}
+
+func BenchmarkCleanText(b *testing.B) {
+	text := strings.Repeat("  这是一条  用于基准测试的\n\t评论内容 ", 20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cleanText(text)
+	}
+}
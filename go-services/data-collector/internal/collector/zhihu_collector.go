@@ -28,6 +28,9 @@ type ZhihuCollector struct {
 	userAgent []string
 	cookies   map[string]string
 	proxies   []string
+	seenStore SeenStore
+	// domainLimiter 跨实例共享的域名限速，与进程内 limiter 同时生效
+	domainLimiter DomainLimiter
 }
 
 // ZhihuQuestion 知乎问题结构
@@ -88,26 +91,32 @@ func NewZhihuCollector(cfg *config.Config) (*ZhihuCollector, error) {
 func (z *ZhihuCollector) Collect(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
 	logrus.WithField("url", source.Url).Info("Starting Zhihu crawling")
 
+	// 增量采集 - 跳过之前已采集过的URL
+	tracker, err := newIncrementalTracker(ctx, z.seenStore, "zhihu", source.Url, source.Parameters)
+	if err != nil {
+		return err
+	}
+
 	// 解析采集类型
 	collectType := z.getCollectType(source.Parameters)
 	
 	switch collectType {
 	case "questions":
-		return z.collectQuestions(ctx, source, config, textChan)
+		return z.collectQuestions(ctx, source, config, textChan, tracker)
 	case "answers":
-		return z.collectAnswers(ctx, source, config, textChan)
+		return z.collectAnswers(ctx, source, config, textChan, tracker)
 	case "search":
-		return z.collectSearchResults(ctx, source, config, textChan)
+		return z.collectSearchResults(ctx, source, config, textChan, tracker)
 	case "topic":
-		return z.collectTopicContent(ctx, source, config, textChan)
+		return z.collectTopicContent(ctx, source, config, textChan, tracker)
 	default:
-		return z.collectGeneral(ctx, source, config, textChan)
+		return z.collectGeneral(ctx, source, config, textChan, tracker)
 	}
 }
 
 // collectQuestions 采集知乎问题
-func (z *ZhihuCollector) collectQuestions(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	collector := z.createCollector()
+func (z *ZhihuCollector) collectQuestions(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText, tracker *incrementalTracker) error {
+	collector := z.createCollector(ctx, tracker)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -116,7 +125,7 @@ func (z *ZhihuCollector) collectQuestions(ctx context.Context, source *pb.Collec
 
 	// 设置问题页面处理
 	collector.OnHTML(".QuestionHeader-title", func(e *colly.HTMLElement) {
-		if collected >= maxCount {
+		if collected >= maxCount || tracker.skip(e.Request) {
 			return
 		}
 
@@ -160,7 +169,7 @@ func (z *ZhihuCollector) collectQuestions(ctx context.Context, source *pb.Collec
 
 	// 设置答案处理
 	collector.OnHTML(".RichContent-inner", func(e *colly.HTMLElement) {
-		if collected >= maxCount {
+		if collected >= maxCount || tracker.skip(e.Request) {
 			return
 		}
 
@@ -217,8 +226,8 @@ func (z *ZhihuCollector) collectQuestions(ctx context.Context, source *pb.Collec
 }
 
 // collectAnswers 采集知乎回答
-func (z *ZhihuCollector) collectAnswers(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	collector := z.createCollector()
+func (z *ZhihuCollector) collectAnswers(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText, tracker *incrementalTracker) error {
+	collector := z.createCollector(ctx, tracker)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -227,7 +236,7 @@ func (z *ZhihuCollector) collectAnswers(ctx context.Context, source *pb.Collecti
 
 	// 处理回答内容
 	collector.OnHTML(".RichContent-inner", func(e *colly.HTMLElement) {
-		if collected >= maxCount {
+		if collected >= maxCount || tracker.skip(e.Request) {
 			return
 		}
 
@@ -274,16 +283,19 @@ func (z *ZhihuCollector) collectAnswers(ctx context.Context, source *pb.Collecti
 }
 
 // collectSearchResults 采集搜索结果
-func (z *ZhihuCollector) collectSearchResults(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+func (z *ZhihuCollector) collectSearchResults(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText, tracker *incrementalTracker) error {
 	keyword := z.getSearchKeyword(source.Parameters)
 	if keyword == "" {
-		return fmt.Errorf("search keyword is required")
+		return Permanent(fmt.Errorf("search keyword is required"))
 	}
 
 	// 构建搜索URL
 	searchURL := fmt.Sprintf("https://www.zhihu.com/search?type=content&q=%s", url.QueryEscape(keyword))
+	if tracker != nil {
+		tracker.seedURL = searchURL
+	}
 	
-	collector := z.createCollector()
+	collector := z.createCollector(ctx, tracker)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -292,7 +304,7 @@ func (z *ZhihuCollector) collectSearchResults(ctx context.Context, source *pb.Co
 
 	// 处理搜索结果
 	collector.OnHTML(".SearchResult-Card", func(e *colly.HTMLElement) {
-		if collected >= maxCount {
+		if collected >= maxCount || tracker.skip(e.Request) {
 			return
 		}
 
@@ -336,8 +348,8 @@ func (z *ZhihuCollector) collectSearchResults(ctx context.Context, source *pb.Co
 }
 
 // collectTopicContent 采集话题内容
-func (z *ZhihuCollector) collectTopicContent(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	collector := z.createCollector()
+func (z *ZhihuCollector) collectTopicContent(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText, tracker *incrementalTracker) error {
+	collector := z.createCollector(ctx, tracker)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -346,7 +358,7 @@ func (z *ZhihuCollector) collectTopicContent(ctx context.Context, source *pb.Col
 
 	// 处理话题下的问题和回答
 	collector.OnHTML(".ContentItem", func(e *colly.HTMLElement) {
-		if collected >= maxCount {
+		if collected >= maxCount || tracker.skip(e.Request) {
 			return
 		}
 
@@ -386,8 +398,8 @@ func (z *ZhihuCollector) collectTopicContent(ctx context.Context, source *pb.Col
 }
 
 // collectGeneral 通用采集方法
-func (z *ZhihuCollector) collectGeneral(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
-	collector := z.createCollector()
+func (z *ZhihuCollector) collectGeneral(ctx context.Context, source *pb.CollectionSource, config *pb.CollectionConfig, textChan chan<- *pb.RawText, tracker *incrementalTracker) error {
+	collector := z.createCollector(ctx, tracker)
 	collected := int32(0)
 	maxCount := config.MaxCount
 	if maxCount <= 0 {
@@ -404,7 +416,7 @@ func (z *ZhihuCollector) collectGeneral(ctx context.Context, source *pb.Collecti
 
 	for _, selector := range selectors {
 		collector.OnHTML(selector, func(e *colly.HTMLElement) {
-			if collected >= maxCount {
+			if collected >= maxCount || tracker.skip(e.Request) {
 				return
 			}
 
@@ -443,11 +455,13 @@ func (z *ZhihuCollector) collectGeneral(ctx context.Context, source *pb.Collecti
 }
 
 // createCollector 创建配置好的爬虫实例
-func (z *ZhihuCollector) createCollector() *colly.Collector {
+func (z *ZhihuCollector) createCollector(ctx context.Context, tracker *incrementalTracker) *colly.Collector {
 	c := colly.NewCollector(
 		colly.Debugger(&debug.LogDebugger{}),
 		colly.UserAgent(z.getRandomUserAgent()),
 	)
+	// 请求头声明支持 br，需要自行解压，colly 只处理 gzip
+	c.WithTransport(newDecodingTransport(nil))
 
 	// 设置限制
 	c.Limit(&colly.LimitRule{
@@ -456,10 +470,16 @@ func (z *ZhihuCollector) createCollector() *colly.Collector {
 		Delay:       3 * time.Second, // 增加延迟
 	})
 
+	tracker.attach(c)
+
 	// 设置请求回调 - 反爬虫处理
 	c.OnRequest(func(r *colly.Request) {
 		// 速率限制
 		z.limiter.Wait(context.Background())
+		if err := waitDomain(ctx, z.domainLimiter, r.URL.Hostname()); err != nil {
+			r.Abort()
+			return
+		}
 
 		// 设置随机User-Agent
 		r.Headers.Set("User-Agent", z.getRandomUserAgent())
@@ -495,25 +515,22 @@ func (z *ZhihuCollector) createCollector() *colly.Collector {
 			"status": r.StatusCode,
 			"size":   len(r.Body),
 		}).Debug("Received Zhihu response")
-
-		// 检查是否被反爬虫拦截
-		if r.StatusCode == 429 || r.StatusCode == 403 {
-			logrus.Warn("Rate limited or blocked by Zhihu, increasing delay")
-			time.Sleep(10 * time.Second)
-		}
 	})
 
 	// 错误处理
 	c.OnError(func(r *colly.Response, err error) {
+		// 被限流时按 Retry-After 等待后重试，未给出时默认等待 30 秒
+		if retryThrottled(ctx, r, 30*time.Second) {
+			return
+		}
 		logrus.WithFields(logrus.Fields{
 			"url":   r.Request.URL.String(),
 			"error": err.Error(),
 			"status": r.StatusCode,
 		}).Error("Zhihu crawling error")
 
-		// 如果是429错误，增加延迟
-		if r.StatusCode == 429 {
-			time.Sleep(30 * time.Second)
+		if r.StatusCode == 403 {
+			logrus.Warn("Blocked by Zhihu anti-crawler")
 		}
 	})
 
@@ -527,7 +544,7 @@ func (z *ZhihuCollector) startCrawling(ctx context.Context, collector *colly.Col
 	
 	go func() {
 		defer close(errChan)
-		if err := collector.Visit(startURL); err != nil {
+		if err := visitSeed(collector, startURL); err != nil {
 			errChan <- fmt.Errorf("failed to start crawling: %w", err)
 			return
 		}
@@ -591,6 +608,16 @@ func (z *ZhihuCollector) SetCookies(cookies map[string]string) {
 	z.cookies = cookies
 }
 
+// SetSeenStore 设置增量采集使用的已采集URL存储
+func (z *ZhihuCollector) SetSeenStore(store SeenStore) {
+	z.seenStore = store
+}
+
+// SetDomainLimiter 设置跨实例共享的域名限速器
+func (z *ZhihuCollector) SetDomainLimiter(limiter DomainLimiter) {
+	z.domainLimiter = limiter
+}
+
 // SetProxies 设置代理列表
 func (z *ZhihuCollector) SetProxies(proxies []string) {
 	z.proxies = proxies
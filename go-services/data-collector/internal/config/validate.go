@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ValidationError 汇总配置校验发现的所有问题
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate 校验必填字段与取值范围，返回汇总后的错误
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if err := validateListenAddress(c.HTTP.Address); err != nil {
+		addf("http.address %q: %v", c.HTTP.Address, err)
+	}
+	if c.HTTP.MaxBodyBytes <= 0 {
+		addf("http.max_body_bytes %d must be positive", c.HTTP.MaxBodyBytes)
+	}
+	if err := validateListenAddress(c.GRPC.Address); err != nil {
+		addf("grpc.address %q: %v", c.GRPC.Address, err)
+	}
+
+	if c.Database.Host == "" {
+		addf("database.host is required")
+	}
+	if !validPort(c.Database.Port) {
+		addf("database.port %d is out of range 1-65535", c.Database.Port)
+	}
+	if c.Database.Username == "" {
+		addf("database.username is required")
+	}
+	if c.Database.Database == "" {
+		addf("database.database is required")
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		addf("database.max_open_conns %d must be positive", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		addf("database.max_idle_conns %d must be between 0 and max_open_conns", c.Database.MaxIdleConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		addf("database.conn_max_lifetime %s must not be negative", c.Database.ConnMaxLifetime)
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		addf("database.slow_query_threshold %s must not be negative", c.Database.SlowQueryThreshold)
+	}
+
+	if _, _, err := net.SplitHostPort(c.Redis.Address); err != nil {
+		addf("redis.address %q: expected host:port", c.Redis.Address)
+	}
+	if c.Redis.DB < 0 {
+		addf("redis.db %d must not be negative", c.Redis.DB)
+	}
+
+	if c.Kafka.Enabled {
+		if len(c.Kafka.Brokers) == 0 {
+			addf("kafka.brokers is required")
+		}
+		for _, broker := range c.Kafka.Brokers {
+			if _, _, err := net.SplitHostPort(broker); err != nil {
+				addf("kafka broker %q: expected host:port", broker)
+			}
+		}
+		if c.Kafka.RawTopic == "" {
+			addf("kafka.raw_topic is required")
+		}
+	}
+
+	if c.Collector.RateLimit <= 0 {
+		addf("collector.rate_limit %d must be positive", c.Collector.RateLimit)
+	}
+	if c.Collector.ConcurrentLimit <= 0 {
+		addf("collector.concurrent_limit %d must be positive", c.Collector.ConcurrentLimit)
+	}
+	if c.Collector.Timeout <= 0 {
+		addf("collector.timeout %s must be positive", c.Collector.Timeout)
+	}
+	if c.Collector.TextBufferSize <= 0 {
+		addf("collector.text_buffer_size %d must be positive", c.Collector.TextBufferSize)
+	}
+	if c.Collector.WriterCount <= 0 {
+		addf("collector.writer_count %d must be positive", c.Collector.WriterCount)
+	}
+	if c.Collector.WriteBatchSize <= 0 {
+		addf("collector.write_batch_size %d must be positive", c.Collector.WriteBatchSize)
+	}
+	if c.Collector.TaskLease <= 0 {
+		addf("collector.task_lease %s must be positive", c.Collector.TaskLease)
+	}
+	if c.Collector.SeenTTL < 0 {
+		addf("collector.seen_ttl %s must not be negative", c.Collector.SeenTTL)
+	}
+	if c.Collector.BrowserPoolSize <= 0 {
+		addf("collector.browser_pool_size %d must be positive", c.Collector.BrowserPoolSize)
+	}
+	if c.Collector.BrowserPageTimeout <= 0 {
+		addf("collector.browser_page_timeout %s must be positive", c.Collector.BrowserPageTimeout)
+	}
+	if c.Collector.NearDuplicateThreshold < 0 || c.Collector.NearDuplicateThreshold > 32 {
+		addf("collector.near_duplicate_threshold %d must be between 0 and 32", c.Collector.NearDuplicateThreshold)
+	}
+	if c.Collector.NearDuplicateWindow < 0 {
+		addf("collector.near_duplicate_window %s must not be negative", c.Collector.NearDuplicateWindow)
+	}
+	if c.Collector.CrawlDelayMin < 0 || c.Collector.CrawlDelayMax < 0 {
+		addf("collector.crawl_delay_min %s and crawl_delay_max %s must not be negative", c.Collector.CrawlDelayMin, c.Collector.CrawlDelayMax)
+	} else if c.Collector.CrawlDelayMax > 0 && c.Collector.CrawlDelayMax < c.Collector.CrawlDelayMin {
+		addf("collector.crawl_delay_max %s must not be less than crawl_delay_min %s", c.Collector.CrawlDelayMax, c.Collector.CrawlDelayMin)
+	}
+	if c.Collector.CrawlDedupCapacity < 0 {
+		addf("collector.crawl_dedup_capacity %d must not be negative", c.Collector.CrawlDedupCapacity)
+	}
+	// 代理地址可能带有密码，报错时只给出序号
+	for i, raw := range c.Collector.ProxyURLs {
+		proxyURL, err := url.Parse(raw)
+		if err != nil || proxyURL.Host == "" {
+			addf("collector.proxy_urls[%d]: expected scheme://[user:pass@]host:port", i)
+		} else if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+			addf("collector.proxy_urls[%d]: unsupported scheme %q", i, proxyURL.Scheme)
+		}
+	}
+	if c.Collector.MaxRunningTasks <= 0 {
+		addf("collector.max_running_tasks %d must be positive", c.Collector.MaxRunningTasks)
+	}
+	for domain, limit := range c.Collector.DomainRateLimits {
+		if domain == "" || limit <= 0 {
+			addf("collector.domain_rate_limits %q=%d: domain is required and rate must be positive", domain, limit)
+		}
+	}
+	for source, quota := range c.Collector.SourceDailyQuotas {
+		if source == "" || quota <= 0 {
+			addf("collector.source_daily_quotas %q=%d: source is required and quota must be positive", source, quota)
+		}
+	}
+	for source, quota := range c.Collector.SourceTotalQuotas {
+		if source == "" || quota <= 0 {
+			addf("collector.source_total_quotas %q=%d: source is required and quota must be positive", source, quota)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateListenAddress 校验监听地址为 host:port 或 :port 格式
+func validateListenAddress(address string) error {
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("expected [host]:port")
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || !validPort(port) {
+		return fmt.Errorf("port %q is out of range 1-65535", portStr)
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
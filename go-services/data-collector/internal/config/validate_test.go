@@ -0,0 +1,98 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig(t *testing.T) *Config {
+	cfg, err := Load()
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	assert.NoError(t, validConfig(t).Validate())
+}
+
+func TestValidateRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   string
+	}{
+		{"http port zero", func(c *Config) { c.HTTP.Address = ":0" }, "http.address"},
+		{"http missing port", func(c *Config) { c.HTTP.Address = "8080" }, "http.address"},
+		{"zero max body bytes", func(c *Config) { c.HTTP.MaxBodyBytes = 0 }, "http.max_body_bytes"},
+		{"grpc non-numeric port", func(c *Config) { c.GRPC.Address = "localhost:grpc" }, "grpc.address"},
+		{"grpc empty", func(c *Config) { c.GRPC.Address = "" }, "grpc.address"},
+		{"empty db host", func(c *Config) { c.Database.Host = "" }, "database.host"},
+		{"db port out of range", func(c *Config) { c.Database.Port = 70000 }, "database.port"},
+		{"empty db username", func(c *Config) { c.Database.Username = "" }, "database.username"},
+		{"empty db name", func(c *Config) { c.Database.Database = "" }, "database.database"},
+		{"negative slow query threshold", func(c *Config) { c.Database.SlowQueryThreshold = -time.Second }, "database.slow_query_threshold"},
+		{"zero max open conns", func(c *Config) { c.Database.MaxOpenConns = 0 }, "database.max_open_conns"},
+		{"idle conns above open conns", func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, "database.max_idle_conns"},
+		{"negative conn lifetime", func(c *Config) { c.Database.ConnMaxLifetime = -time.Second }, "database.conn_max_lifetime"},
+		{"bad redis address", func(c *Config) { c.Redis.Address = "localhost" }, "redis.address"},
+		{"negative redis db", func(c *Config) { c.Redis.DB = -1 }, "redis.db"},
+		{"no kafka brokers", func(c *Config) { c.Kafka.Brokers = nil }, "kafka.brokers"},
+		{"bad kafka broker", func(c *Config) { c.Kafka.Brokers = []string{"kafka"} }, "kafka broker"},
+		{"empty raw topic", func(c *Config) { c.Kafka.RawTopic = "" }, "kafka.raw_topic"},
+		{"zero rate limit", func(c *Config) { c.Collector.RateLimit = 0 }, "collector.rate_limit"},
+		{"negative concurrency", func(c *Config) { c.Collector.ConcurrentLimit = -1 }, "collector.concurrent_limit"},
+		{"zero timeout", func(c *Config) { c.Collector.Timeout = 0 }, "collector.timeout"},
+		{"zero text buffer", func(c *Config) { c.Collector.TextBufferSize = 0 }, "collector.text_buffer_size"},
+		{"zero writers", func(c *Config) { c.Collector.WriterCount = 0 }, "collector.writer_count"},
+		{"zero write batch", func(c *Config) { c.Collector.WriteBatchSize = 0 }, "collector.write_batch_size"},
+		{"zero task lease", func(c *Config) { c.Collector.TaskLease = 0 }, "collector.task_lease"},
+		{"negative seen ttl", func(c *Config) { c.Collector.SeenTTL = -time.Hour }, "collector.seen_ttl"},
+		{"zero browser pool", func(c *Config) { c.Collector.BrowserPoolSize = 0 }, "collector.browser_pool_size"},
+		{"zero browser page timeout", func(c *Config) { c.Collector.BrowserPageTimeout = 0 }, "collector.browser_page_timeout"},
+		{"near duplicate threshold too large", func(c *Config) { c.Collector.NearDuplicateThreshold = 40 }, "collector.near_duplicate_threshold"},
+		{"negative near duplicate window", func(c *Config) { c.Collector.NearDuplicateWindow = -time.Hour }, "collector.near_duplicate_window"},
+		{"zero running tasks", func(c *Config) { c.Collector.MaxRunningTasks = 0 }, "collector.max_running_tasks"},
+		{"negative crawl delay", func(c *Config) { c.Collector.CrawlDelayMin = -time.Second }, "collector.crawl_delay_min"},
+		{"crawl delay max below min", func(c *Config) {
+			c.Collector.CrawlDelayMin, c.Collector.CrawlDelayMax = 2*time.Second, time.Second
+		}, "collector.crawl_delay_max"},
+		{"negative crawl dedup capacity", func(c *Config) { c.Collector.CrawlDedupCapacity = -1 }, "collector.crawl_dedup_capacity"},
+		{"proxy without host", func(c *Config) { c.Collector.ProxyURLs = []string{"user:pass@proxy:8080"} }, "collector.proxy_urls[0]"},
+		{"unsupported proxy scheme", func(c *Config) { c.Collector.ProxyURLs = []string{"ftp://proxy:21"} }, "collector.proxy_urls[0]"},
+		{"zero domain rate", func(c *Config) { c.Collector.DomainRateLimits = map[string]int{"zhihu.com": 0} }, "collector.domain_rate_limits"},
+		{"zero daily quota", func(c *Config) { c.Collector.SourceDailyQuotas = map[string]int{"zhihu:answer": 0} }, "collector.source_daily_quotas"},
+		{"empty total quota source", func(c *Config) { c.Collector.SourceTotalQuotas = map[string]int{"": 10} }, "collector.source_total_quotas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestValidateAggregatesProblems(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.HTTP.Address = ":0"
+	cfg.Database.Host = ""
+
+	err := cfg.Validate()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 2)
+}
+
+func TestValidateSkipsDisabledKafka(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Kafka.Enabled = false
+	cfg.Kafka.Brokers = nil
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,215 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+)
+
+// FeatureFormat 特征导出格式
+type FeatureFormat string
+
+const (
+	// FormatLibSVM 每行 "label index:value ..."，索引从 1 开始，省略取值为 0 的特征
+	FormatLibSVM FeatureFormat = "libsvm"
+	// FormatTFRecord 每条记录为 tf.train.Example，包含 id、features（float 列表）与 label（int64）
+	FormatTFRecord FeatureFormat = "tfrecord"
+)
+
+// maxReportedMismatches 结果中保留的维度不一致行数上限，超出部分只计数
+const maxReportedMismatches = 100
+
+// ParseFeatureFormat 解析导出格式
+func ParseFeatureFormat(raw string) (FeatureFormat, error) {
+	switch format := FeatureFormat(raw); format {
+	case FormatLibSVM, FormatTFRecord:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported feature format %q, expected libsvm or tfrecord", raw)
+	}
+}
+
+// FeatureOptions 特征导出参数
+type FeatureOptions struct {
+	Filter    repository.ProcessedTextFilter
+	Format    FeatureFormat
+	BatchSize int
+}
+
+// FeatureMismatch 特征维度与首行不一致的文本
+type FeatureMismatch struct {
+	ID     string
+	Length int
+}
+
+// FeatureResult 特征导出结果。未标注或维度不一致的行不导出
+type FeatureResult struct {
+	Rows      int
+	Dimension int
+	Unlabeled int
+	// MismatchCount 维度不一致的总行数，Mismatches 只保留前 maxReportedMismatches 条
+	MismatchCount int
+	Mismatches    []FeatureMismatch
+}
+
+// ExportFeatures 将预处理文本的特征向量与标签逐行写入 w，以首个非空特征向量的长度为维度，
+// 维度不一致（包括尚未提取特征）的行跳过并在结果中报告
+func ExportFeatures(ctx context.Context, lister ProcessedTextLister, w io.Writer, opts FeatureOptions) (*FeatureResult, error) {
+	var write func(io.Writer, *model.ProcessedText, []float64) error
+	switch opts.Format {
+	case FormatLibSVM:
+		write = writeLibSVMRow
+	case FormatTFRecord:
+		write = writeTFRecordRow
+	default:
+		return nil, fmt.Errorf("unsupported feature format %q", opts.Format)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	buf := bufio.NewWriter(w)
+	result := &FeatureResult{}
+	afterID := ""
+	for {
+		texts, err := lister.ListProcessedTextsAfter(ctx, opts.Filter, afterID, batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list processed texts: %w", err)
+		}
+		for _, text := range texts {
+			if text.Label == nil {
+				result.Unlabeled++
+				continue
+			}
+			features, err := decodeFeatures(text.Features)
+			if err != nil {
+				return nil, fmt.Errorf("processed text %s: invalid features: %w", text.ID, err)
+			}
+			if result.Dimension == 0 && len(features) > 0 {
+				result.Dimension = len(features)
+			}
+			if len(features) == 0 || len(features) != result.Dimension {
+				result.addMismatch(text.ID, len(features))
+				continue
+			}
+			if err := write(buf, text, features); err != nil {
+				return nil, fmt.Errorf("failed to write row %s: %w", text.ID, err)
+			}
+			result.Rows++
+		}
+		if len(texts) < batchSize {
+			break
+		}
+		afterID = texts[len(texts)-1].ID
+	}
+
+	if err := buf.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write features: %w", err)
+	}
+	return result, nil
+}
+
+func (r *FeatureResult) addMismatch(id string, length int) {
+	r.MismatchCount++
+	if len(r.Mismatches) < maxReportedMismatches {
+		r.Mismatches = append(r.Mismatches, FeatureMismatch{ID: id, Length: length})
+	}
+}
+
+func writeLibSVMRow(w io.Writer, text *model.ProcessedText, features []float64) error {
+	line := strconv.AppendInt(nil, int64(*text.Label), 10)
+	for i, value := range features {
+		if value == 0 {
+			continue
+		}
+		line = append(line, ' ')
+		line = strconv.AppendInt(line, int64(i+1), 10)
+		line = append(line, ':')
+		line = strconv.AppendFloat(line, value, 'g', -1, 64)
+	}
+	line = append(line, '\n')
+	_, err := w.Write(line)
+	return err
+}
+
+// crc32c TFRecord 使用 Castagnoli 多项式的 CRC32 校验
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// maskedCRC TFRecord 存储的掩码校验值
+func maskedCRC(data []byte) uint32 {
+	crc := crc32.Checksum(data, crc32c)
+	return (crc>>15 | crc<<17) + 0xa282ead8
+}
+
+// writeTFRecordRow 写出一条 TFRecord：长度、长度校验、tf.train.Example 与其校验
+func writeTFRecordRow(w io.Writer, text *model.ProcessedText, features []float64) error {
+	example := encodeExample(text.ID, features, int64(*text.Label))
+
+	header := binary.LittleEndian.AppendUint64(nil, uint64(len(example)))
+	header = binary.LittleEndian.AppendUint32(header, maskedCRC(header))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(example); err != nil {
+		return err
+	}
+	_, err := w.Write(binary.LittleEndian.AppendUint32(nil, maskedCRC(example)))
+	return err
+}
+
+// encodeExample 按 tensorflow/core/example/example.proto 编码 tf.train.Example：
+// Example.features(1) -> Features.feature(1) map<string, Feature>，
+// Feature 的 bytes_list(1)、float_list(2)、int64_list(3) 均以字段 1 保存取值
+func encodeExample(id string, features []float64, label int64) []byte {
+	var bytesList []byte
+	bytesList = protowire.AppendTag(bytesList, 1, protowire.BytesType)
+	bytesList = protowire.AppendString(bytesList, id)
+
+	var floats []byte
+	for _, value := range features {
+		floats = protowire.AppendFixed32(floats, math.Float32bits(float32(value)))
+	}
+	var floatList []byte
+	floatList = protowire.AppendTag(floatList, 1, protowire.BytesType)
+	floatList = protowire.AppendBytes(floatList, floats)
+
+	var int64List []byte
+	int64List = protowire.AppendTag(int64List, 1, protowire.BytesType)
+	int64List = protowire.AppendBytes(int64List, protowire.AppendVarint(nil, uint64(label)))
+
+	var featureMap []byte
+	featureMap = appendFeatureEntry(featureMap, "id", 1, bytesList)
+	featureMap = appendFeatureEntry(featureMap, "features", 2, floatList)
+	featureMap = appendFeatureEntry(featureMap, "label", 3, int64List)
+
+	var example []byte
+	example = protowire.AppendTag(example, 1, protowire.BytesType)
+	return protowire.AppendBytes(example, featureMap)
+}
+
+// appendFeatureEntry 追加 Features.feature 的一个条目，kind 为 Feature 中取值列表的字段号
+func appendFeatureEntry(b []byte, key string, kind protowire.Number, list []byte) []byte {
+	var feature []byte
+	feature = protowire.AppendTag(feature, kind, protowire.BytesType)
+	feature = protowire.AppendBytes(feature, list)
+
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, key)
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, feature)
+
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, entry)
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+)
+
+func newFeatureLister() *memoryLister {
+	normal, violation := 0, 1
+	return &memoryLister{texts: []*model.ProcessedText{
+		{ID: "a", Features: "[0.5, 0, 2]", Label: &violation},
+		{ID: "b", Features: "[0, 1.25, 0]", Label: &normal},
+		{ID: "c", Features: "[1, 2]", Label: &normal},
+		{ID: "d", Features: "{}", Label: &normal},
+		{ID: "e", Features: "[1, 1, 1]"},
+		{ID: "f", Features: "[3, 0, -1e-07]", Label: &violation},
+	}}
+}
+
+// parseLibSVM 解析 libsvm 文本，按维度还原稠密向量
+func parseLibSVM(t *testing.T, r io.Reader, dimension int) ([]int, [][]float64) {
+	var labels []int
+	var rows [][]float64
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		label, err := strconv.Atoi(fields[0])
+		require.NoError(t, err)
+		row := make([]float64, dimension)
+		for _, field := range fields[1:] {
+			index, value, ok := strings.Cut(field, ":")
+			require.True(t, ok, field)
+			i, err := strconv.Atoi(index)
+			require.NoError(t, err)
+			row[i-1], err = strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+		}
+		labels = append(labels, label)
+		rows = append(rows, row)
+	}
+	require.NoError(t, scanner.Err())
+	return labels, rows
+}
+
+func TestExportFeaturesLibSVMRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+	result, err := ExportFeatures(context.Background(), newFeatureLister(), &out, FeatureOptions{Format: FormatLibSVM, BatchSize: 4})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Rows)
+	assert.Equal(t, 3, result.Dimension)
+	assert.Equal(t, 1, result.Unlabeled)
+	assert.Equal(t, 2, result.MismatchCount)
+	assert.Equal(t, []FeatureMismatch{{ID: "c", Length: 2}, {ID: "d", Length: 0}}, result.Mismatches)
+
+	assert.Equal(t, "1 1:0.5 3:2\n", strings.SplitAfter(out.String(), "\n")[0])
+	labels, rows := parseLibSVM(t, &out, result.Dimension)
+	assert.Equal(t, []int{1, 0, 1}, labels)
+	assert.Equal(t, [][]float64{{0.5, 0, 2}, {0, 1.25, 0}, {3, 0, -1e-07}}, rows)
+}
+
+// decodeExample 解析 encodeExample 写出的 tf.train.Example
+func decodeExample(t *testing.T, example []byte) (string, []float32, int64) {
+	var id string
+	var features []float32
+	var label int64
+
+	featureMap, n := protowire.ConsumeBytes(example[protowire.SizeTag(1):])
+	require.Greater(t, n, 0)
+	for b := featureMap; len(b) > 0; {
+		_, _, tagLen := protowire.ConsumeTag(b)
+		entry, n := protowire.ConsumeBytes(b[tagLen:])
+		require.Greater(t, n, 0)
+		b = b[tagLen+n:]
+
+		key, n := protowire.ConsumeString(entry[protowire.SizeTag(1):])
+		entry = entry[protowire.SizeTag(1)+n:]
+		feature, _ := protowire.ConsumeBytes(entry[protowire.SizeTag(2):])
+		_, _, tagLen = protowire.ConsumeTag(feature)
+		list, _ := protowire.ConsumeBytes(feature[tagLen:])
+		values, _ := protowire.ConsumeBytes(list[protowire.SizeTag(1):])
+
+		switch key {
+		case "id":
+			id = string(values)
+		case "features":
+			for len(values) > 0 {
+				bits, n := protowire.ConsumeFixed32(values)
+				features = append(features, math.Float32frombits(bits))
+				values = values[n:]
+			}
+		case "label":
+			v, _ := protowire.ConsumeVarint(values)
+			label = int64(v)
+		}
+	}
+	return id, features, label
+}
+
+func TestExportFeaturesTFRecord(t *testing.T) {
+	var out bytes.Buffer
+	result, err := ExportFeatures(context.Background(), newFeatureLister(), &out, FeatureOptions{Format: FormatTFRecord})
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Rows)
+
+	var ids []string
+	data := out.Bytes()
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 12)
+		length := binary.LittleEndian.Uint64(data)
+		assert.Equal(t, maskedCRC(data[:8]), binary.LittleEndian.Uint32(data[8:]))
+		example := data[12 : 12+length]
+		assert.Equal(t, maskedCRC(example), binary.LittleEndian.Uint32(data[12+length:]))
+		data = data[16+length:]
+
+		id, features, label := decodeExample(t, example)
+		ids = append(ids, id)
+		if id == "a" {
+			assert.Equal(t, []float32{0.5, 0, 2}, features)
+			assert.Equal(t, int64(1), label)
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "f"}, ids)
+}
+
+func TestParseFeatureFormat(t *testing.T) {
+	format, err := ParseFeatureFormat("tfrecord")
+	require.NoError(t, err)
+	assert.Equal(t, FormatTFRecord, format)
+
+	_, err = ParseFeatureFormat("csv")
+	assert.Error(t, err)
+}
@@ -0,0 +1,302 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+)
+
+// defaultBatchSize 每次从数据库读取的行数
+const defaultBatchSize = 500
+
+// DatasetInfosFile HuggingFace datasets 读取数据集结构的描述文件名
+const DatasetInfosFile = "dataset_infos.json"
+
+// Split 数据集划分及其占比
+type Split struct {
+	Name  string
+	Ratio float64
+}
+
+// DefaultSplits 未指定划分时的 train/validation/test 占比
+var DefaultSplits = []Split{{"train", 0.8}, {"validation", 0.1}, {"test", 0.1}}
+
+// ProcessedTextLister 按 ID 逐页读取预处理文本
+type ProcessedTextLister interface {
+	ListProcessedTextsAfter(ctx context.Context, filter repository.ProcessedTextFilter, afterID string, limit int) ([]*model.ProcessedText, error)
+}
+
+// HuggingFaceOptions 导出参数
+type HuggingFaceOptions struct {
+	// Filter 按来源与标签筛选
+	Filter repository.ProcessedTextFilter
+	// Splits 为空时使用 DefaultSplits
+	Splits    []Split
+	BatchSize int
+}
+
+// HuggingFaceResult 各划分导出的行数
+type HuggingFaceResult struct {
+	Counts map[string]int
+}
+
+// hfRow 导出文件中的一行，与 dataset_infos.json 中的 features 对应
+type hfRow struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Tokens    []string  `json:"tokens"`
+	Features  []float64 `json:"features"`
+	Label     *int      `json:"label"`
+	Source    string    `json:"source"`
+	Timestamp int64     `json:"timestamp"`
+}
+
+// ParseSplits 解析 "0.8,0.1,0.1" 形式的 train/validation/test 占比，占比之和须为 1，占比为 0 的划分不输出
+func ParseSplits(spec string) ([]Split, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != len(DefaultSplits) {
+		return nil, fmt.Errorf("split %q: expected train,validation,test ratios", spec)
+	}
+	var splits []Split
+	total := 0.0
+	for i, part := range parts {
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || ratio < 0 {
+			return nil, fmt.Errorf("split %q: invalid ratio %q", spec, part)
+		}
+		total += ratio
+		if ratio > 0 {
+			splits = append(splits, Split{Name: DefaultSplits[i].Name, Ratio: ratio})
+		}
+	}
+	if math.Abs(total-1) > 1e-6 {
+		return nil, fmt.Errorf("split %q: ratios sum to %g, expected 1", spec, total)
+	}
+	return splits, nil
+}
+
+// ExportHuggingFace 将预处理文本按划分写入 dir 下的 <split>.jsonl，并生成 dataset_infos.json，
+// 可直接用 datasets.load_dataset("json", data_files=...) 加载。数据逐页读取、逐行写出，不在内存中缓存；
+// 每行按各划分的占比差额分配，划分行数与占比的偏差不超过一行，重复导出同一批数据结果一致
+func ExportHuggingFace(ctx context.Context, lister ProcessedTextLister, dir string, opts HuggingFaceOptions) (*HuggingFaceResult, error) {
+	splits := opts.Splits
+	if len(splits) == 0 {
+		splits = DefaultSplits
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	writers := make([]*splitWriter, len(splits))
+	for i, split := range splits {
+		w, err := newSplitWriter(filepath.Join(dir, split.Name+".jsonl"))
+		if err != nil {
+			closeSplitWriters(writers)
+			return nil, err
+		}
+		writers[i] = w
+	}
+	defer closeSplitWriters(writers)
+
+	total := 0
+	afterID := ""
+	for {
+		texts, err := lister.ListProcessedTextsAfter(ctx, opts.Filter, afterID, batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list processed texts: %w", err)
+		}
+		for _, text := range texts {
+			row, err := newHFRow(text)
+			if err != nil {
+				return nil, err
+			}
+			total++
+			if err := writers[assignSplit(splits, writers, total)].write(row); err != nil {
+				return nil, err
+			}
+		}
+		if len(texts) < batchSize {
+			break
+		}
+		afterID = texts[len(texts)-1].ID
+	}
+
+	result := &HuggingFaceResult{Counts: make(map[string]int, len(splits))}
+	for i, w := range writers {
+		if err := w.close(); err != nil {
+			return nil, err
+		}
+		result.Counts[splits[i].Name] = w.rows
+	}
+	if err := writeDatasetInfos(filepath.Join(dir, DatasetInfosFile), splits, writers); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// assignSplit 为第 n 行选择当前行数与占比差额最大的划分
+func assignSplit(splits []Split, writers []*splitWriter, n int) int {
+	best, bestDeficit := 0, math.Inf(-1)
+	for i, split := range splits {
+		if deficit := split.Ratio*float64(n) - float64(writers[i].rows); deficit > bestDeficit {
+			best, bestDeficit = i, deficit
+		}
+	}
+	return best
+}
+
+func newHFRow(text *model.ProcessedText) (*hfRow, error) {
+	row := &hfRow{
+		ID:        text.ID,
+		Content:   text.Content,
+		Tokens:    []string{},
+		Label:     text.Label,
+		Source:    text.Source,
+		Timestamp: text.Timestamp,
+	}
+	if err := decodeJSONList(text.Tokens, &row.Tokens); err != nil {
+		return nil, fmt.Errorf("processed text %s: invalid tokens: %w", text.ID, err)
+	}
+	features, err := decodeFeatures(text.Features)
+	if err != nil {
+		return nil, fmt.Errorf("processed text %s: invalid features: %w", text.ID, err)
+	}
+	row.Features = features
+	return row, nil
+}
+
+// decodeFeatures 解析特征向量，未提取特征时为空列表（入库时的占位值为 {}）
+func decodeFeatures(raw string) ([]float64, error) {
+	features := []float64{}
+	if strings.TrimSpace(raw) == "{}" {
+		return features, nil
+	}
+	if err := decodeJSONList(raw, &features); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// decodeJSONList 解析 JSON 数组，空字符串与 null 保持 v 不变
+func decodeJSONList(raw string, v interface{}) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), v)
+}
+
+// splitWriter 单个划分的输出文件
+type splitWriter struct {
+	file  *os.File
+	buf   *bufio.Writer
+	rows  int
+	bytes int64
+}
+
+func newSplitWriter(path string) (*splitWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	return &splitWriter{file: file, buf: bufio.NewWriter(file)}, nil
+}
+
+func (w *splitWriter) write(row *hfRow) error {
+	line, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("failed to encode row %s: %w", row.ID, err)
+	}
+	line = append(line, '\n')
+	if _, err := w.buf.Write(line); err != nil {
+		return fmt.Errorf("failed to write %s: %w", w.file.Name(), err)
+	}
+	w.rows++
+	w.bytes += int64(len(line))
+	return nil
+}
+
+// close 写出缓冲并关闭文件，可重复调用
+func (w *splitWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	flushErr := w.buf.Flush()
+	closeErr := w.file.Close()
+	name := w.file.Name()
+	w.file = nil
+	if flushErr != nil {
+		return fmt.Errorf("failed to write %s: %w", name, flushErr)
+	}
+	return closeErr
+}
+
+func closeSplitWriters(writers []*splitWriter) {
+	for _, w := range writers {
+		if w != nil {
+			w.close()
+		}
+	}
+}
+
+// hfValue 与 hfSequence 对应 datasets.Value 与 datasets.Sequence 的序列化形式
+func hfValue(dtype string) map[string]interface{} {
+	return map[string]interface{}{"dtype": dtype, "_type": "Value"}
+}
+
+func hfSequence(dtype string) map[string]interface{} {
+	return map[string]interface{}{"feature": hfValue(dtype), "_type": "Sequence"}
+}
+
+// writeDatasetInfos 写出 datasets 的 dataset_infos.json，记录列类型与各划分的行数
+func writeDatasetInfos(path string, splits []Split, writers []*splitWriter) error {
+	splitInfos := make(map[string]interface{}, len(splits))
+	var datasetSize int64
+	for i, split := range splits {
+		splitInfos[split.Name] = map[string]interface{}{
+			"name":         split.Name,
+			"num_bytes":    writers[i].bytes,
+			"num_examples": writers[i].rows,
+		}
+		datasetSize += writers[i].bytes
+	}
+	infos := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "Processed texts exported from data-collector",
+			"config_name": "default",
+			"features": map[string]interface{}{
+				"id":        hfValue("string"),
+				"content":   hfValue("string"),
+				"tokens":    hfSequence("string"),
+				"features":  hfSequence("float64"),
+				"label":     hfValue("int64"),
+				"source":    hfValue("string"),
+				"timestamp": hfValue("int64"),
+			},
+			"splits":       splitInfos,
+			"dataset_size": datasetSize,
+		},
+	}
+
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dataset infos: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
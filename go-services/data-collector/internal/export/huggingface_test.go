@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+)
+
+// memoryLister 按 ID 排序的内存数据，筛选与分页语义同 MySQLRepository
+type memoryLister struct {
+	texts []*model.ProcessedText
+}
+
+func (l *memoryLister) ListProcessedTextsAfter(ctx context.Context, filter repository.ProcessedTextFilter, afterID string, limit int) ([]*model.ProcessedText, error) {
+	var page []*model.ProcessedText
+	for _, text := range l.texts {
+		if text.ID <= afterID || (filter.Source != "" && text.Source != filter.Source) {
+			continue
+		}
+		if filter.Label != nil && (text.Label == nil || *text.Label != *filter.Label) {
+			continue
+		}
+		if page = append(page, text); len(page) == limit {
+			break
+		}
+	}
+	return page, nil
+}
+
+func newMemoryLister() *memoryLister {
+	lister := &memoryLister{}
+	for i := 0; i < 25; i++ {
+		label := i % 2
+		source := "zhihu"
+		if i >= 20 {
+			source = "web"
+		}
+		lister.texts = append(lister.texts, &model.ProcessedText{
+			ID:        fmt.Sprintf("text-%02d", i),
+			Content:   fmt.Sprintf("content %d", i),
+			Tokens:    fmt.Sprintf(`["content", "%d"]`, i),
+			Features:  "[0.5, 1.5]",
+			Label:     &label,
+			Source:    source,
+			Timestamp: int64(1000 + i),
+		})
+	}
+	// 采集时写入的占位值
+	lister.texts[3].Tokens, lister.texts[3].Features = "[]", "{}"
+	return lister
+}
+
+func readJSONLines(t *testing.T, path string) []map[string]interface{} {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var rows []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var row map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		rows = append(rows, row)
+	}
+	require.NoError(t, scanner.Err())
+	return rows
+}
+
+func TestExportHuggingFaceSplitsAndSchema(t *testing.T) {
+	dir := t.TempDir()
+	splits, err := ParseSplits("0.6,0.2,0.2")
+	require.NoError(t, err)
+
+	result, err := ExportHuggingFace(context.Background(), newMemoryLister(), dir, HuggingFaceOptions{
+		Filter:    repository.ProcessedTextFilter{Source: "zhihu"},
+		Splits:    splits,
+		BatchSize: 7,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"train": 12, "validation": 4, "test": 4}, result.Counts)
+
+	rows := make(map[string]map[string]interface{})
+	for name, count := range result.Counts {
+		splitRows := readJSONLines(t, filepath.Join(dir, name+".jsonl"))
+		require.Len(t, splitRows, count)
+		for _, row := range splitRows {
+			assert.Equal(t, "zhihu", row["source"])
+			assert.ElementsMatch(t, []string{"id", "content", "tokens", "features", "label", "source", "timestamp"}, keys(row))
+			rows[row["id"].(string)] = row
+		}
+	}
+	assert.Len(t, rows, 20)
+	assert.Equal(t, []interface{}{"content", "0"}, rows["text-00"]["tokens"])
+	assert.Equal(t, []interface{}{0.5, 1.5}, rows["text-00"]["features"])
+	assert.Equal(t, []interface{}{}, rows["text-03"]["features"], "placeholder features export as an empty list")
+
+	var infos map[string]struct {
+		Features map[string]map[string]interface{} `json:"features"`
+		Splits   map[string]struct {
+			NumExamples int `json:"num_examples"`
+		} `json:"splits"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, DatasetInfosFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &infos))
+	info := infos["default"]
+	assert.Equal(t, "Sequence", info.Features["tokens"]["_type"])
+	assert.Equal(t, "int64", info.Features["label"]["dtype"])
+	for name, count := range result.Counts {
+		assert.Equal(t, count, info.Splits[name].NumExamples, name)
+	}
+}
+
+func TestExportHuggingFaceFiltersByLabel(t *testing.T) {
+	dir := t.TempDir()
+	violation := 1
+	result, err := ExportHuggingFace(context.Background(), newMemoryLister(), dir, HuggingFaceOptions{
+		Filter: repository.ProcessedTextFilter{Label: &violation},
+		Splits: []Split{{"train", 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"train": 12}, result.Counts)
+	for _, row := range readJSONLines(t, filepath.Join(dir, "train.jsonl")) {
+		assert.Equal(t, float64(1), row["label"])
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "test.jsonl"))
+}
+
+func TestParseSplits(t *testing.T) {
+	splits, err := ParseSplits("0.9, 0.1, 0")
+	require.NoError(t, err)
+	assert.Equal(t, []Split{{"train", 0.9}, {"validation", 0.1}}, splits)
+
+	for _, spec := range []string{"0.8,0.1", "0.8,0.1,0.2", "0.8,-0.1,0.3", "a,b,c"} {
+		_, err := ParseSplits(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func keys(row map[string]interface{}) []string {
+	var result []string
+	for key := range row {
+		result = append(result, key)
+	}
+	return result
+}
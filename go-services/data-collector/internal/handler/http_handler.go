@@ -1,87 +1,139 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/scheduler"
 	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/service"
 	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
 )
 
-// HTTPHandler HTTP处理器
-type HTTPHandler struct {
-	collectorService *service.CollectorService
-	logger           *logrus.Logger
+// taskEventHeartbeat SSE 连接的心跳间隔，避免代理因长时间无数据断开连接
+const taskEventHeartbeat = 15 * time.Second
+
+// readinessTimeout 就绪检查中单个依赖的超时时间
+const readinessTimeout = 3 * time.Second
+
+// healthChecker 可被探测健康状态的依赖
+type healthChecker interface {
+	HealthCheck(ctx context.Context) error
 }
 
-// NewHTTPHandler 创建HTTP处理器
-func NewHTTPHandler(collectorService *service.CollectorService) *HTTPHandler {
-	logger := logrus.New()
-	logger.SetLevel(logrus.InfoLevel)
+// readinessCheck /ready 检查的一项依赖
+type readinessCheck struct {
+	name    string
+	checker healthChecker
+}
 
-	return &HTTPHandler{
-		collectorService: collectorService,
-		logger:           logger,
-	}
+// taskSubscriber 任务进度事件订阅
+type taskSubscriber interface {
+	SubscribeTask(ctx context.Context, taskID string) (<-chan service.TaskEvent, error)
 }
 
-// CollectRequest 采集请求结构
-type CollectRequest struct {
-	Source *CollectionSource `json:"source" binding:"required"`
-	Config *CollectionConfig `json:"config"`
+// taskRetrier 重新执行失败的任务
+type taskRetrier interface {
+	RetryTask(ctx context.Context, taskID string) (*pb.CollectResponse, error)
 }
 
-// CollectionSource 采集源配置
-type CollectionSource struct {
-	Type     string `json:"type" binding:"required,oneof=web api file"`
-	URL      string `json:"url"`
-	FilePath string `json:"file_path"`
+// seenInspector 增量采集已采集URL集合的查看与清空
+type seenInspector interface {
+	SeenCount(ctx context.Context, sourceKey, url string) (int64, bool, error)
+	ResetSeen(ctx context.Context, sourceKey string) error
 }
 
-// CollectionConfig 采集配置
-type CollectionConfig struct {
-	MaxTexts    int32             `json:"max_texts"`
-	Timeout     int32             `json:"timeout"`
-	Concurrent  int32             `json:"concurrent"`
-	Filters     map[string]string `json:"filters"`
-	Selectors   map[string]string `json:"selectors"`
-	Headers     map[string]string `json:"headers"`
-	Pagination  *PaginationConfig `json:"pagination"`
-	RateLimit   *RateLimitConfig  `json:"rate_limit"`
-	FileOptions *FileOptions      `json:"file_options"`
+// quotaReporter 来源配额用量查询
+type quotaReporter interface {
+	QuotaUsage(ctx context.Context) ([]service.QuotaUsage, error)
 }
 
-// PaginationConfig 分页配置
-type PaginationConfig struct {
-	Enabled   bool   `json:"enabled"`
-	PageParam string `json:"page_param"`
-	SizeParam string `json:"size_param"`
-	MaxPages  int32  `json:"max_pages"`
+// rawTextLister 原始文本分页查询，支持偏移分页与键集分页
+type rawTextLister interface {
+	ListRawTexts(ctx context.Context, filter repository.RawTextFilter, limit, offset int) ([]*model.RawText, error)
+	ListRawTextsBefore(ctx context.Context, filter repository.RawTextFilter, cursor *repository.RawTextCursor, limit int) ([]*model.RawText, *repository.RawTextCursor, error)
+	CountRawTexts(ctx context.Context, filter repository.RawTextFilter) (int64, error)
 }
 
-// RateLimitConfig 速率限制配置
-type RateLimitConfig struct {
-	RequestsPerSecond float64 `json:"requests_per_second"`
-	BurstSize         int     `json:"burst_size"`
+// HTTPHandler HTTP处理器
+type HTTPHandler struct {
+	collectorService *service.CollectorService
+	taskEvents       taskSubscriber
+	texts            rawTextLister
+	quotas           quotaReporter
+	retrier          taskRetrier
+	seen             seenInspector
+	scheduler        *scheduler.Scheduler
+	readiness        []readinessCheck
+	gateway          *runtime.ServeMux
+	maxBodyBytes     int64
+	adminToken       string
+	logger           *logrus.Logger
 }
 
-// FileOptions 文件选项
-type FileOptions struct {
-	Encoding    string `json:"encoding"`
-	Delimiter   string `json:"delimiter"`
-	TextColumn  string `json:"text_column"`
-	LabelColumn string `json:"label_column"`
+// NewHTTPHandler 创建HTTP处理器
+func NewHTTPHandler(collectorService *service.CollectorService, scheduler *scheduler.Scheduler, cfg config.HTTPConfig) (*HTTPHandler, error) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+
+	gateway, err := newGatewayMux(collectorService)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPHandler{
+		collectorService: collectorService,
+		taskEvents:       collectorService,
+		texts:            collectorService.GetRepository(),
+		quotas:           collectorService,
+		retrier:          collectorService,
+		seen:             collectorService,
+		scheduler:        scheduler,
+		readiness:        []readinessCheck{{name: "database", checker: collectorService.GetRepository()}},
+		gateway:          gateway,
+		maxBodyBytes:     cfg.MaxBodyBytes,
+		adminToken:       cfg.AdminToken,
+		logger:           logger,
+	}, nil
 }
 
-// CollectResponse 采集响应结构
-type CollectResponse struct {
-	TaskID  string `json:"task_id"`
-	Message string `json:"message"`
+// newGatewayMux 创建进程内的 grpc-gateway，直接调用 DataCollectionService 实现
+func newGatewayMux(server pb.DataCollectionServiceServer) (*runtime.ServeMux, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+		}),
+	)
+
+	if err := pb.RegisterDataCollectionServiceHandlerServer(context.Background(), mux, server); err != nil {
+		return nil, fmt.Errorf("failed to register gateway handlers: %w", err)
+	}
+
+	return mux, nil
 }
 
 // TaskStatusResponse 任务状态响应结构
@@ -91,6 +143,8 @@ type TaskStatusResponse struct {
 	Progress       int    `json:"progress"`
 	CollectedCount int    `json:"collected_count"`
 	TotalCount     int    `json:"total_count"`
+	Priority       int    `json:"priority"`
+	Attempts       int    `json:"attempts"`
 	StartTime      string `json:"start_time,omitempty"`
 	EndTime        string `json:"end_time,omitempty"`
 	ErrorMessage   string `json:"error_message,omitempty"`
@@ -105,6 +159,25 @@ type TaskListResponse struct {
 	TotalPages int                   `json:"total_pages"`
 }
 
+// RawTextListResponse 原始文本列表响应结构。
+// 偏移分页返回 Total 与 Page；键集分页返回 NextCursor，为空表示没有更多数据
+type RawTextListResponse struct {
+	Texts      []*model.RawText `json:"texts"`
+	Total      int64            `json:"total,omitempty"`
+	Page       int              `json:"page,omitempty"`
+	PageSize   int              `json:"page_size"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// CreateScheduleRequest 创建定时采集计划请求结构
+// Source 与 Config 使用与 /api/v1/collect 相同的 protobuf JSON 格式
+type CreateScheduleRequest struct {
+	Name     string          `json:"name"`
+	CronExpr string          `json:"cron_expr" binding:"required"`
+	Source   json.RawMessage `json:"source" binding:"required"`
+	Config   json.RawMessage `json:"config"`
+}
+
 // ErrorResponse 错误响应结构
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -112,133 +185,55 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
-// CollectText 文本采集接口
-func (h *HTTPHandler) CollectText(c *gin.Context) {
-	var req CollectRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid request body")
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Code:    400,
-			Message: err.Error(),
-		})
-		return
-	}
-
-	// 转换源类型
-	var sourceType pb.SourceType
-	switch req.Source.Type {
-	case "api":
-		sourceType = pb.SourceType_API
-	case "web":
-		sourceType = pb.SourceType_WEB_CRAWLER
-	case "file":
-		sourceType = pb.SourceType_LOCAL_FILE
-	default:
-		sourceType = pb.SourceType_API
-	}
-
-	// 转换为protobuf格式
-	pbSource := &pb.CollectionSource{
-		Type:     sourceType,
-		Url:      req.Source.URL,
-		FilePath: req.Source.FilePath,
-	}
-
-	pbConfig := &pb.CollectionConfig{}
-	if req.Config != nil {
-		pbConfig.MaxCount = req.Config.MaxTexts
-		pbConfig.ConcurrentLimit = req.Config.Concurrent
-		// 修复：这里应该是一个固定值或者从其他地方获取，而不是Timeout
-		pbConfig.RateLimit = 100 // 默认速率限制
-		if req.Config.Filters != nil {
-			for filterName, enabled := range req.Config.Filters {
-				if enabled == "true" {
-					pbConfig.Filters = append(pbConfig.Filters, filterName)
-				}
-			}
+// parseTimeRange 解析 RFC3339 格式的 start_time 与 end_time 查询参数，缺省时为零值表示不限。
+// 筛选范围为 [start_time, end_time]，两端均包含
+func parseTimeRange(c *gin.Context) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if raw := c.Query("start_time"); raw != "" {
+		if start, err = time.Parse(time.RFC3339, raw); err != nil {
+			return start, end, fmt.Errorf("invalid start_time: %w", err)
 		}
 	}
-	
-	// 添加调试日志
-	h.logger.WithFields(logrus.Fields{
-		"req_config": req.Config,
-		"pb_config": pbConfig,
-	}).Info("HTTP handler config conversion debug")
-
-	// 调用服务
-	pbReq := &pb.CollectRequest{
-		Source: pbSource,
-		Config: pbConfig,
+	if raw := c.Query("end_time"); raw != "" {
+		if end, err = time.Parse(time.RFC3339, raw); err != nil {
+			return start, end, fmt.Errorf("invalid end_time: %w", err)
+		}
 	}
-
-	resp, err := h.collectorService.CollectText(c.Request.Context(), pbReq)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to collect text")
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "collection_failed",
-			Code:    500,
-			Message: err.Error(),
-		})
-		return
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return start, end, fmt.Errorf("start_time must not be after end_time")
 	}
-
-	c.JSON(http.StatusOK, CollectResponse{
-		TaskID:  resp.TaskId,
-		Message: resp.Message,
-	})
+	return start, end, nil
 }
 
-// GetTaskStatus 获取任务状态
-func (h *HTTPHandler) GetTaskStatus(c *gin.Context) {
-	taskID := c.Param("id")
-	if taskID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_task_id",
-			Code:    400,
-			Message: "Task ID is required",
-		})
-		return
-	}
-
-	req := &pb.StatusRequest{
-		TaskId: taskID,
+// ListTasks 获取任务列表，可按 status（枚举名或 cancelled 等短名）与创建时间范围 start_time、end_time 筛选
+func (h *HTTPHandler) ListTasks(c *gin.Context) {
+	// 获取查询参数
+	pageStr := c.DefaultQuery("page", "1")
+	pageSizeStr := c.DefaultQuery("page_size", "10")
+	status := c.Query("status")
+	if status != "" {
+		normalized, ok := service.NormalizeTaskStatus(status)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("unknown task status: %s", status),
+			})
+			return
+		}
+		status = normalized
 	}
-
-	resp, err := h.collectorService.GetCollectionStatus(c.Request.Context(), req)
+	startTime, endTime, err := parseTimeRange(c)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get task status")
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "status_query_failed",
-			Code:    500,
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Code:    http.StatusBadRequest,
 			Message: err.Error(),
 		})
 		return
 	}
 
-	response := &TaskStatusResponse{
-		TaskID:   resp.TaskId,
-		Status:   resp.Status.String(),
-		Progress: int(resp.Progress),
-	}
-
-	if resp.StartTime != 0 {
-		response.StartTime = time.Unix(resp.StartTime, 0).Format(time.RFC3339)
-	}
-	if resp.EndTime != 0 {
-		response.EndTime = time.Unix(resp.EndTime, 0).Format(time.RFC3339)
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// ListTasks 获取任务列表
-func (h *HTTPHandler) ListTasks(c *gin.Context) {
-	// 获取查询参数
-	pageStr := c.DefaultQuery("page", "1")
-	pageSizeStr := c.DefaultQuery("page_size", "10")
-	status := c.Query("status")
-
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
 		page = 1
@@ -253,7 +248,7 @@ func (h *HTTPHandler) ListTasks(c *gin.Context) {
 
 	// 从数据库获取任务列表
 	ctx := c.Request.Context()
-	tasks, err := h.collectorService.GetRepository().ListCollectionTasks(ctx, status, pageSize, offset)
+	tasks, err := h.collectorService.GetRepository().ListCollectionTasks(ctx, status, startTime, endTime, pageSize, offset)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to list collection tasks")
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -265,7 +260,7 @@ func (h *HTTPHandler) ListTasks(c *gin.Context) {
 	}
 
 	// 获取总数
-	total, err := h.collectorService.GetRepository().CountCollectionTasks(ctx, status)
+	total, err := h.collectorService.GetRepository().CountCollectionTasks(ctx, status, startTime, endTime)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to count collection tasks")
 		total = 0
@@ -280,6 +275,8 @@ func (h *HTTPHandler) ListTasks(c *gin.Context) {
 			Progress:       task.Progress,
 			CollectedCount: task.CollectedCount,
 			TotalCount:     task.TotalCount,
+			Priority:       task.Priority,
+			Attempts:       task.Attempts,
 			StartTime:      func() string { if task.StartTime != nil { return task.StartTime.Format(time.RFC3339) } else { return "" } }(),
 			EndTime:        func() string { if task.EndTime != nil { return task.EndTime.Format(time.RFC3339) } else { return "" } }(),
 			ErrorMessage:   task.ErrorMessage,
@@ -297,6 +294,415 @@ func (h *HTTPHandler) ListTasks(c *gin.Context) {
 	})
 }
 
+// metadataQueryPrefix 按 Metadata 筛选的查询参数前缀，如 meta.platform=zhihu
+const metadataQueryPrefix = "meta."
+
+// rawTextFilterFromQuery 从 source、meta.<key> 与 start_time、end_time 查询参数构造筛选条件
+func rawTextFilterFromQuery(c *gin.Context) (repository.RawTextFilter, error) {
+	filter := repository.RawTextFilter{Source: c.Query("source")}
+	var err error
+	if filter.StartTime, filter.EndTime, err = parseTimeRange(c); err != nil {
+		return filter, err
+	}
+	for param, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, metadataQueryPrefix)
+		if !ok {
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = values[0]
+	}
+	return filter, filter.Validate()
+}
+
+// ListRawTexts 按创建时间倒序获取原始文本列表，可按 source、meta.<key> 与创建时间范围筛选。
+// 携带 cursor 参数（首页可为空）时使用键集分页，翻页期间的新写入不会造成重复或遗漏；否则按 page 偏移分页
+func (h *HTTPHandler) ListRawTexts(c *gin.Context) {
+	filter, err := rawTextFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	ctx := c.Request.Context()
+
+	if rawCursor, ok := c.GetQuery("cursor"); ok {
+		var cursor *repository.RawTextCursor
+		if rawCursor != "" {
+			cursor, err = repository.ParseRawTextCursor(rawCursor)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Code:    http.StatusBadRequest,
+					Message: err.Error(),
+				})
+				return
+			}
+		}
+
+		texts, next, err := h.texts.ListRawTextsBefore(ctx, filter, cursor, pageSize)
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to list raw texts")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to retrieve texts",
+			})
+			return
+		}
+		resp := RawTextListResponse{Texts: texts, PageSize: pageSize}
+		if next != nil {
+			resp.NextCursor = next.Encode()
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	texts, err := h.texts.ListRawTexts(ctx, filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list raw texts")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to retrieve texts",
+		})
+		return
+	}
+	total, err := h.texts.CountRawTexts(ctx, filter)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to count raw texts")
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, RawTextListResponse{
+		Texts:    texts,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// CreateSchedule 创建定时采集计划
+func (h *HTTPHandler) CreateSchedule(c *gin.Context) {
+	var req CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	pbReq, err := req.toPBCollectRequest()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.scheduler.CreateSchedule(c.Request.Context(), req.Name, req.CronExpr, pbReq)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to create schedule")
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_schedule",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// toPBCollectRequest 按 protobuf JSON 规则解析采集源与采集配置
+func (r *CreateScheduleRequest) toPBCollectRequest() (*pb.CollectRequest, error) {
+	source := &pb.CollectionSource{}
+	if err := protojson.Unmarshal(r.Source, source); err != nil {
+		return nil, fmt.Errorf("invalid source: %w", err)
+	}
+
+	config := &pb.CollectionConfig{}
+	if len(r.Config) > 0 {
+		if err := protojson.Unmarshal(r.Config, config); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	return &pb.CollectRequest{Source: source, Config: config}, nil
+}
+
+// ListSchedules 获取定时采集计划列表
+func (h *HTTPHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.scheduler.ListSchedules(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list schedules")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to retrieve schedules",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+		"total":     len(schedules),
+	})
+}
+
+// ListQuotas 获取各来源的配额用量
+func (h *HTTPHandler) ListQuotas(c *gin.Context) {
+	usages, err := h.quotas.QuotaUsage(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get quota usage")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to retrieve quota usage",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quotas": usages,
+	})
+}
+
+// DeleteSchedule 删除定时采集计划
+func (h *HTTPHandler) DeleteSchedule(c *gin.Context) {
+	scheduleID := c.Param("id")
+
+	if err := h.scheduler.DeleteSchedule(c.Request.Context(), scheduleID); err != nil {
+		if errors.Is(err, scheduler.ErrScheduleNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Code:    http.StatusNotFound,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to delete schedule")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to delete schedule",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      scheduleID,
+		"message": "Schedule deleted",
+	})
+}
+
+// RetryTask 重新执行失败的任务，沿用任务原有的采集源与配置
+func (h *HTTPHandler) RetryTask(c *gin.Context) {
+	taskID := c.Param("taskId")
+
+	resp, err := h.retrier.RetryTask(c.Request.Context(), taskID)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Code:    http.StatusNotFound,
+				Message: fmt.Sprintf("task not found: %s", taskID),
+			})
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "invalid_state",
+				Code:    http.StatusConflict,
+				Message: status.Convert(err).Message(),
+			})
+		default:
+			h.logger.WithError(err).WithField("task_id", taskID).Error("Failed to retry task")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to retry task",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"task_id": resp.TaskId,
+		"status":  resp.Status.String(),
+		"message": resp.Message,
+	})
+}
+
+// StreamTask 通过 Server-Sent Events 推送任务进度，任务结束或客户端断开时关闭连接
+func (h *HTTPHandler) StreamTask(c *gin.Context) {
+	taskID := c.Param("taskId")
+	ctx := c.Request.Context()
+
+	events, err := h.taskEvents.SubscribeTask(ctx, taskID)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Code:    http.StatusNotFound,
+				Message: fmt.Sprintf("task not found: %s", taskID),
+			})
+			return
+		}
+		h.logger.WithError(err).WithField("task_id", taskID).Error("Failed to subscribe task events")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to subscribe task events",
+		})
+		return
+	}
+
+	// 长连接不受服务器写超时限制
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.WithError(err).Debug("Failed to clear write deadline for task stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(taskEventHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("progress", ev)
+			return !ev.Terminal()
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// LogLevelRequest 日志级别请求结构
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// GetLogLevel 获取当前日志级别
+func (h *HTTPHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logrus.GetLevel().String()})
+}
+
+// SetLogLevel 运行时修改日志级别，同时作用于全局 logger 与处理器 logger
+func (h *HTTPHandler) SetLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("invalid log level: %s", req.Level),
+		})
+		return
+	}
+
+	previous := logrus.GetLevel()
+	logrus.SetLevel(level)
+	if h.logger != nil {
+		h.logger.SetLevel(level)
+	}
+	logrus.WithFields(logrus.Fields{
+		"previous": previous.String(),
+		"level":    level.String(),
+	}).Warn("Log level changed")
+
+	c.JSON(http.StatusOK, gin.H{"level": level.String()})
+}
+
+// GetSeen 查看采集源的已采集URL数量，携带 url 查询参数时同时返回该URL是否已采集
+func (h *HTTPHandler) GetSeen(c *gin.Context) {
+	sourceKey := c.Param("sourceKey")
+	url := c.Query("url")
+
+	count, seen, err := h.seen.SeenCount(c.Request.Context(), sourceKey, url)
+	if err != nil {
+		h.seenError(c, sourceKey, err)
+		return
+	}
+
+	resp := gin.H{
+		"source_key": sourceKey,
+		"count":      count,
+	}
+	if url != "" {
+		resp["url"] = url
+		resp["seen"] = seen
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ResetSeen 清空采集源的已采集URL集合
+func (h *HTTPHandler) ResetSeen(c *gin.Context) {
+	sourceKey := c.Param("sourceKey")
+	if err := h.seen.ResetSeen(c.Request.Context(), sourceKey); err != nil {
+		h.seenError(c, sourceKey, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source_key": sourceKey,
+		"message":    "Seen set cleared",
+	})
+}
+
+func (h *HTTPHandler) seenError(c *gin.Context, sourceKey string, err error) {
+	if status.Code(err) == codes.FailedPrecondition {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "not_configured",
+			Code:    http.StatusConflict,
+			Message: status.Convert(err).Message(),
+		})
+		return
+	}
+	h.logger.WithError(err).WithField("source_key", sourceKey).Error("Failed to access seen set")
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "internal_error",
+		Code:    http.StatusInternalServerError,
+		Message: "Failed to access seen set",
+	})
+}
+
 // HealthCheck 健康检查
 func (h *HTTPHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -307,16 +713,55 @@ func (h *HTTPHandler) HealthCheck(c *gin.Context) {
 	})
 }
 
-// GetMetrics 获取指标
+// AddReadinessCheck 增加 /ready 需要检查的依赖
+func (h *HTTPHandler) AddReadinessCheck(name string, checker healthChecker) {
+	h.readiness = append(h.readiness, readinessCheck{name: name, checker: checker})
+}
+
+// ReadinessCheck 就绪检查，所有依赖可用时返回 200，否则返回 503，避免流量进入无法写入数据的实例
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	ready := true
+	checks := make(map[string]string, len(h.readiness))
+	for _, check := range h.readiness {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		err := check.checker.HealthCheck(ctx)
+		cancel()
+		if err != nil {
+			ready = false
+			checks[check.name] = err.Error()
+			h.logger.WithError(err).WithField("dependency", check.name).Warn("Readiness check failed")
+			continue
+		}
+		checks[check.name] = "ok"
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
+
+// GetMetrics 以 Prometheus 文本格式输出已注册的指标
 func (h *HTTPHandler) GetMetrics(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"total_tasks":     0,
-		"running_tasks":   0,
-		"completed_tasks": 0,
-		"failed_tasks":    0,
-		"total_texts":     0,
-		"uptime":          time.Now().Unix(),
-	})
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
+
+// metricsHandler 输出默认注册表中的指标，包含 Go 运行时与进程指标
+var metricsHandler = newMetricsHandler()
+
+// newMetricsHandler 确保 Go 运行时与进程指标已注册到默认注册表，默认注册表通常已包含时不重复注册
+func newMetricsHandler() http.Handler {
+	for _, collector := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	} {
+		var already prometheus.AlreadyRegisteredError
+		if err := prometheus.Register(collector); err != nil && !errors.As(err, &already) {
+			panic(err)
+		}
+	}
+	return promhttp.Handler()
 }
 
 // SetupRoutes 设置路由
@@ -325,17 +770,38 @@ func (h *HTTPHandler) SetupRoutes(r *gin.Engine) {
 	r.Use(h.requestIDMiddleware())
 	r.Use(h.loggingMiddleware())
 	r.Use(h.corsMiddleware())
+	r.Use(bodyLimitMiddleware(h.maxBodyBytes))
 
 	// 健康检查和指标
 	r.GET("/health", h.HealthCheck)
+	r.GET("/ready", h.ReadinessCheck)
 	r.GET("/metrics", h.GetMetrics)
 
 	// API路由组
 	api := r.Group("/api/v1")
 	{
-		api.POST("/collect", h.CollectText)
-		api.GET("/status/:taskId", h.GetTaskStatus)
+		// 采集与状态查询由 grpc-gateway 按 proto 定义生成，与 gRPC 接口保持一致
+		api.POST("/collect", gin.WrapH(h.gateway))
+		api.GET("/status/:taskId", gin.WrapH(h.gateway))
 		api.GET("/tasks", h.ListTasks)
+		api.GET("/tasks/:taskId/stream", h.StreamTask)
+		api.POST("/tasks/:taskId/retry", h.RetryTask)
+		api.GET("/texts", h.ListRawTexts)
+
+		api.POST("/schedules", h.CreateSchedule)
+		api.GET("/schedules", h.ListSchedules)
+		api.DELETE("/schedules/:id", h.DeleteSchedule)
+
+		api.GET("/quotas", h.ListQuotas)
+	}
+
+	// 管理接口需携带 Authorization: Bearer <admin_token>
+	admin := r.Group("/admin", adminAuthMiddleware(h.adminToken))
+	{
+		admin.GET("/loglevel", h.GetLogLevel)
+		admin.PUT("/loglevel", h.SetLogLevel)
+		admin.GET("/seen/:sourceKey", h.GetSeen)
+		admin.DELETE("/seen/:sourceKey", h.ResetSeen)
 	}
 }
 
@@ -369,6 +835,72 @@ func (h *HTTPHandler) loggingMiddleware() gin.HandlerFunc {
 	})
 }
 
+// bodyLimitMiddleware 限制请求体大小，超出 limit 时返回 413。
+// 请求体会被完整读入内存后交给后续处理器，路由上可叠加更小的限制
+func bodyLimitMiddleware(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		tooLarge := func() {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:   "request_too_large",
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("request body exceeds %d bytes", limit),
+			})
+		}
+		if c.Request.ContentLength > limit {
+			tooLarge()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				tooLarge()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// adminAuthMiddleware 校验管理接口令牌，未配置令牌时拒绝所有管理请求
+func adminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error:   "forbidden",
+				Code:    http.StatusForbidden,
+				Message: "admin API is disabled",
+			})
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Code:    http.StatusUnauthorized,
+				Message: "invalid admin token",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // corsMiddleware CORS中间件
 func (h *HTTPHandler) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
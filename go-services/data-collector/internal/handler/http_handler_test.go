@@ -0,0 +1,568 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/scheduler"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/service"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// fakeCollectionServer 记录网关转发的请求
+type fakeCollectionServer struct {
+	pb.UnimplementedDataCollectionServiceServer
+	lastCollect *pb.CollectRequest
+}
+
+func (f *fakeCollectionServer) CollectText(ctx context.Context, req *pb.CollectRequest) (*pb.CollectResponse, error) {
+	f.lastCollect = req
+	return &pb.CollectResponse{
+		TaskId:  "task-1",
+		Status:  pb.CollectionStatus_COLLECTION_PENDING,
+		Message: "Collection task started",
+	}, nil
+}
+
+func (f *fakeCollectionServer) GetCollectionStatus(ctx context.Context, req *pb.StatusRequest) (*pb.StatusResponse, error) {
+	if req.TaskId != "task-1" {
+		return nil, status.Errorf(codes.NotFound, "task not found: %s", req.TaskId)
+	}
+	return &pb.StatusResponse{
+		TaskId:   req.TaskId,
+		Status:   pb.CollectionStatus_COLLECTION_RUNNING,
+		Progress: 40,
+	}, nil
+}
+
+func newGatewayRouter(t *testing.T, server pb.DataCollectionServiceServer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	gateway, err := newGatewayMux(server)
+	require.NoError(t, err)
+
+	h := &HTTPHandler{gateway: gateway}
+	router := gin.New()
+	router.POST("/api/v1/collect", gin.WrapH(h.gateway))
+	router.GET("/api/v1/status/:taskId", gin.WrapH(h.gateway))
+	return router
+}
+
+func TestGatewayCollectUsesProtoContract(t *testing.T) {
+	server := &fakeCollectionServer{}
+	router := newGatewayRouter(t, server)
+
+	body := `{
+		"source": {"type": "WEB_CRAWLER", "url": "https://example.com", "parameters": {"follow_links": "true"}},
+		"config": {"max_count": 50, "rate_limit": 2, "filters": ["no_url"]}
+	}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/collect", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotNil(t, server.lastCollect)
+	assert.Equal(t, pb.SourceType_WEB_CRAWLER, server.lastCollect.Source.Type)
+	assert.Equal(t, "https://example.com", server.lastCollect.Source.Url)
+	assert.Equal(t, "true", server.lastCollect.Source.Parameters["follow_links"])
+	assert.Equal(t, int32(50), server.lastCollect.Config.MaxCount)
+	assert.Equal(t, []string{"no_url"}, server.lastCollect.Config.Filters)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "task-1", resp["task_id"])
+	assert.Equal(t, "COLLECTION_PENDING", resp["status"])
+}
+
+func TestGatewayCollectRejectsLegacyPayload(t *testing.T) {
+	server := &fakeCollectionServer{}
+	router := newGatewayRouter(t, server)
+
+	body := `{"source": {"type": "web", "url": "https://example.com"}, "config": {"max_texts": 50}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/collect", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, server.lastCollect)
+}
+
+func TestGatewayStatus(t *testing.T) {
+	router := newGatewayRouter(t, &fakeCollectionServer{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/status/task-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "COLLECTION_RUNNING", resp["status"])
+	assert.Equal(t, float64(40), resp["progress"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/status/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateScheduleRequestParsesProtoJSON(t *testing.T) {
+	req := &CreateScheduleRequest{
+		CronExpr: "0 2 * * *",
+		Source:   json.RawMessage(`{"type": "LOCAL_FILE", "file_path": "/data/a.txt"}`),
+		Config:   json.RawMessage(`{"maxCount": 10}`),
+	}
+
+	pbReq, err := req.toPBCollectRequest()
+	require.NoError(t, err)
+	assert.Equal(t, pb.SourceType_LOCAL_FILE, pbReq.Source.Type)
+	assert.Equal(t, "/data/a.txt", pbReq.Source.FilePath)
+	assert.Equal(t, int32(10), pbReq.Config.MaxCount)
+
+	req.Source = json.RawMessage(`{"type": "web"}`)
+	_, err = req.toPBCollectRequest()
+	assert.Error(t, err)
+}
+
+// fakeScheduleStore 内存中的调度计划存储
+type fakeScheduleStore struct {
+	schedules map[string]*model.CollectionSchedule
+}
+
+func (f *fakeScheduleStore) CreateCollectionSchedule(ctx context.Context, schedule *model.CollectionSchedule) error {
+	f.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (f *fakeScheduleStore) ListCollectionSchedules(ctx context.Context) ([]*model.CollectionSchedule, error) {
+	var result []*model.CollectionSchedule
+	for _, schedule := range f.schedules {
+		result = append(result, schedule)
+	}
+	return result, nil
+}
+
+func (f *fakeScheduleStore) ListDueCollectionSchedules(ctx context.Context, now time.Time) ([]*model.CollectionSchedule, error) {
+	return nil, nil
+}
+
+func (f *fakeScheduleStore) UpdateCollectionScheduleRun(ctx context.Context, id string, lastRunAt, nextRunAt time.Time) error {
+	return nil
+}
+
+func (f *fakeScheduleStore) DeleteCollectionSchedule(ctx context.Context, id string) (bool, error) {
+	if _, ok := f.schedules[id]; !ok {
+		return false, nil
+	}
+	delete(f.schedules, id)
+	return true, nil
+}
+
+func TestScheduleEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeScheduleStore{schedules: make(map[string]*model.CollectionSchedule)}
+	h := &HTTPHandler{scheduler: scheduler.NewScheduler(store, nil, nil), logger: logrus.New()}
+	router := gin.New()
+	router.POST("/api/v1/schedules", h.CreateSchedule)
+	router.GET("/api/v1/schedules", h.ListSchedules)
+	router.DELETE("/api/v1/schedules/:id", h.DeleteSchedule)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/v1/schedules", `{"name": "nightly", "cron_expr": "0 2 * * *", "source": {"type": "API", "url": "https://example.com"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var created model.CollectionSchedule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "nightly", created.Name)
+	assert.Equal(t, "API", created.SourceType)
+	require.NotNil(t, created.NextRunAt)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/api/v1/schedules", `{"cron_expr": "not a cron", "source": {"type": "API"}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/api/v1/schedules", `{"source": {"type": "API"}}`).Code)
+
+	w = serve(http.MethodGet, "/api/v1/schedules", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Schedules []model.CollectionSchedule `json:"schedules"`
+		Total     int                        `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Total)
+	require.Len(t, list.Schedules, 1)
+	assert.Equal(t, created.ID, list.Schedules[0].ID)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/api/v1/schedules/"+created.ID, "").Code)
+	assert.Empty(t, store.schedules)
+
+	// 不存在或已删除的计划返回 404
+	w = serve(http.MethodDelete, "/api/v1/schedules/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var errResp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, "not_found", errResp.Error)
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(bodyLimitMiddleware(16))
+	router.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"a":1}`)))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"a":1}`, w.Body.String())
+	})
+
+	t.Run("content length over limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("x", 17))))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("chunked body over limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/echo", io.MultiReader(strings.NewReader(strings.Repeat("x", 64))))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "request_too_large")
+	})
+}
+
+// fakeTaskSubscriber 返回由测试控制的事件流
+type fakeTaskSubscriber struct {
+	events chan service.TaskEvent
+}
+
+func (f *fakeTaskSubscriber) SubscribeTask(ctx context.Context, taskID string) (<-chan service.TaskEvent, error) {
+	if taskID != "task-1" {
+		return nil, status.Errorf(codes.NotFound, "task not found: %s", taskID)
+	}
+	return f.events, nil
+}
+
+func TestStreamTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	events := make(chan service.TaskEvent, 3)
+	h := &HTTPHandler{taskEvents: &fakeTaskSubscriber{events: events}, logger: logrus.New()}
+	router := gin.New()
+	router.GET("/api/v1/tasks/:taskId/stream", h.StreamTask)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	running := pb.CollectionStatus_COLLECTION_RUNNING.String()
+	events <- service.TaskEvent{TaskID: "task-1", Status: running, Progress: 10, CollectedCount: 1}
+	events <- service.TaskEvent{TaskID: "task-1", Status: running, Progress: 50, CollectedCount: 5}
+	events <- service.TaskEvent{TaskID: "task-1", Status: pb.CollectionStatus_COLLECTION_COMPLETED.String(), Progress: 100, CollectedCount: 10}
+
+	resp, err := http.Get(server.URL + "/api/v1/tasks/task-1/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	// 任务结束后服务端关闭连接，读到 EOF 即可拿到全部帧
+	var frames []service.TaskEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var ev service.TaskEvent
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &ev))
+		frames = append(frames, ev)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, frames, 3)
+	assert.Equal(t, int32(10), frames[0].Progress)
+	assert.Equal(t, int32(5), frames[1].CollectedCount)
+	assert.True(t, frames[2].Terminal())
+}
+
+func TestStreamTaskNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &HTTPHandler{taskEvents: &fakeTaskSubscriber{}, logger: logrus.New()}
+	router := gin.New()
+	router.GET("/api/v1/tasks/:taskId/stream", h.StreamTask)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/missing/stream", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func newAdminRouter(token string) (*gin.Engine, *HTTPHandler) {
+	gin.SetMode(gin.TestMode)
+	h := &HTTPHandler{adminToken: token, logger: logrus.New()}
+	router := gin.New()
+	admin := router.Group("/admin", adminAuthMiddleware(h.adminToken))
+	admin.GET("/loglevel", h.GetLogLevel)
+	admin.PUT("/loglevel", h.SetLogLevel)
+	return router, h
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	original := logrus.GetLevel()
+	defer logrus.SetLevel(original)
+	logrus.SetLevel(logrus.InfoLevel)
+
+	router, h := newAdminRouter("secret")
+	h.logger.SetLevel(logrus.InfoLevel)
+
+	putLevel := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/admin/loglevel", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requires token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, putLevel("", `{"level":"debug"}`).Code)
+		assert.Equal(t, http.StatusUnauthorized, putLevel("wrong", `{"level":"debug"}`).Code)
+		assert.False(t, logrus.IsLevelEnabled(logrus.DebugLevel))
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		w := putLevel("secret", `{"level":"verbose"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	})
+
+	t.Run("valid level", func(t *testing.T) {
+		w := putLevel("secret", `{"level":"debug"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, logrus.IsLevelEnabled(logrus.DebugLevel))
+		assert.True(t, h.logger.IsLevelEnabled(logrus.DebugLevel))
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		get := httptest.NewRecorder()
+		router.ServeHTTP(get, req)
+		assert.JSONEq(t, `{"level":"debug"}`, get.Body.String())
+	})
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	router, _ := newAdminRouter("")
+	req := httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// fakeTaskRetrier 按任务ID返回预设的重试结果
+type fakeTaskRetrier struct{}
+
+func (f *fakeTaskRetrier) RetryTask(ctx context.Context, taskID string) (*pb.CollectResponse, error) {
+	switch taskID {
+	case "failed":
+		return &pb.CollectResponse{TaskId: taskID, Status: pb.CollectionStatus_COLLECTION_PENDING, Message: "Collection task retried"}, nil
+	case "completed":
+		return nil, status.Errorf(codes.FailedPrecondition, "task %s is COLLECTION_COMPLETED, only failed tasks can be retried", taskID)
+	default:
+		return nil, status.Errorf(codes.NotFound, "task not found: %s", taskID)
+	}
+}
+
+func TestRetryTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &HTTPHandler{retrier: &fakeTaskRetrier{}, logger: logrus.New()}
+	router := gin.New()
+	router.POST("/api/v1/tasks/:taskId/retry", h.RetryTask)
+
+	cases := []struct {
+		taskID string
+		code   int
+	}{
+		{"failed", http.StatusAccepted},
+		{"completed", http.StatusConflict},
+		{"missing", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/tasks/"+tc.taskID+"/retry", nil))
+		assert.Equal(t, tc.code, w.Code, tc.taskID)
+	}
+}
+
+// fakeRawTextLister 记录收到的筛选与分页参数，键集分页时返回指向 ID "b" 的游标
+type fakeRawTextLister struct {
+	filter repository.RawTextFilter
+	cursor *repository.RawTextCursor
+	offset int
+}
+
+func (f *fakeRawTextLister) ListRawTexts(ctx context.Context, filter repository.RawTextFilter, limit, offset int) ([]*model.RawText, error) {
+	f.filter = filter
+	f.offset = offset
+	return []*model.RawText{{ID: "a"}}, nil
+}
+
+func (f *fakeRawTextLister) ListRawTextsBefore(ctx context.Context, filter repository.RawTextFilter, cursor *repository.RawTextCursor, limit int) ([]*model.RawText, *repository.RawTextCursor, error) {
+	f.cursor = cursor
+	return []*model.RawText{{ID: "a"}, {ID: "b"}}, &repository.RawTextCursor{ID: "b"}, nil
+}
+
+func (f *fakeRawTextLister) CountRawTexts(ctx context.Context, filter repository.RawTextFilter) (int64, error) {
+	return 42, nil
+}
+
+func TestListRawTexts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lister := &fakeRawTextLister{}
+	h := &HTTPHandler{texts: lister, logger: logrus.New()}
+	router := gin.New()
+	router.GET("/api/v1/texts", h.ListRawTexts)
+
+	get := func(query string) (*httptest.ResponseRecorder, RawTextListResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/texts?"+query, nil))
+		var resp RawTextListResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w, resp
+	}
+
+	w, resp := get("page=3&page_size=10")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 20, lister.offset)
+	assert.Equal(t, int64(42), resp.Total)
+	assert.Empty(t, resp.NextCursor)
+
+	w, resp = get("cursor=&page_size=2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, lister.cursor)
+	assert.Len(t, resp.Texts, 2)
+	assert.Zero(t, resp.Total)
+	require.NotEmpty(t, resp.NextCursor)
+
+	w, _ = get("cursor=" + resp.NextCursor)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, lister.cursor)
+	assert.Equal(t, "b", lister.cursor.ID)
+
+	w, _ = get("cursor=not-a-cursor")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = get("source=zhihu:answer&meta.platform=zhihu&meta.type=answer")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, repository.RawTextFilter{
+		Source:   "zhihu:answer",
+		Metadata: map[string]string{"platform": "zhihu", "type": "answer"},
+	}, lister.filter)
+
+	w, _ = get("meta.platform')%20OR%201=1%20--=x")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListEndpointsValidateTimeRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lister := &fakeRawTextLister{}
+	h := &HTTPHandler{texts: lister, logger: logrus.New()}
+	router := gin.New()
+	router.GET("/api/v1/texts", h.ListRawTexts)
+	router.GET("/api/v1/tasks", h.ListTasks)
+
+	get := func(target string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Code
+	}
+
+	for _, path := range []string{"/api/v1/texts", "/api/v1/tasks"} {
+		assert.Equal(t, http.StatusBadRequest, get(path+"?start_time=2024-03-02T00:00:00Z&end_time=2024-03-01T00:00:00Z"), path)
+		assert.Equal(t, http.StatusBadRequest, get(path+"?start_time=yesterday"), path)
+		assert.Equal(t, http.StatusBadRequest, get(path+"?end_time=2024-03-01"), path)
+	}
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/tasks?status=archived"))
+
+	require.Equal(t, http.StatusOK, get("/api/v1/texts?start_time=2024-03-01T00:00:00Z&end_time=2024-03-01T00:00:00Z"))
+	assert.True(t, lister.filter.StartTime.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, lister.filter.EndTime.Equal(lister.filter.StartTime))
+}
+
+// fakeHealthChecker 返回固定的检查结果
+type fakeHealthChecker struct {
+	err error
+}
+
+func (f *fakeHealthChecker) HealthCheck(ctx context.Context) error {
+	return f.err
+}
+
+func TestReadinessCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kafka := &fakeHealthChecker{err: errors.New("kafka unavailable: connection refused")}
+	h := &HTTPHandler{logger: logrus.New()}
+	h.AddReadinessCheck("database", &fakeHealthChecker{})
+	h.AddReadinessCheck("kafka", kafka)
+	r := gin.New()
+	r.GET("/health", h.HealthCheck)
+	r.GET("/ready", h.ReadinessCheck)
+
+	get := func(path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", body["status"])
+	assert.Equal(t, map[string]interface{}{"database": "ok", "kafka": "kafka unavailable: connection refused"}, body["checks"])
+
+	// 存活探针不受依赖影响
+	code, _ = get("/health")
+	assert.Equal(t, http.StatusOK, code)
+
+	kafka.err = nil
+	code, body = get("/ready")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body["status"])
+}
+
+func TestMetricsIncludeRuntimeCollectors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &HTTPHandler{logger: logrus.New()}
+	r := gin.New()
+	r.GET("/metrics", h.GetMetrics)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "go_goroutines ")
+	assert.Contains(t, w.Body.String(), "go_gc_duration_seconds")
+	assert.Contains(t, w.Body.String(), "process_resident_memory_bytes ")
+
+	// 重复创建不会因重复注册而 panic
+	assert.NotPanics(t, func() { newMetricsHandler() })
+}
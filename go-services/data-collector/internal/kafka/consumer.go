@@ -0,0 +1,186 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+// ReceivedEnvelope 消费端解析的消息包装器，Data 保留原始 JSON，由处理函数按消息类型解码
+type ReceivedEnvelope struct {
+	MessageID     string                 `json:"message_id"`
+	MessageType   string                 `json:"message_type"`
+	SchemaVersion int                    `json:"schema_version"`
+	Source        string                 `json:"source"`
+	Timestamp     int64                  `json:"timestamp"`
+	Data          json.RawMessage        `json:"data"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// DeadLetter 写入死信主题的消息，保留原始消息内容与拒收原因
+type DeadLetter struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Key       string `json:"key,omitempty"`
+	Reason    string `json:"reason"`
+	Payload   string `json:"payload"`
+	FailedAt  int64  `json:"failed_at"`
+}
+
+// EnvelopeHandler 处理通过 Schema 校验的消息
+type EnvelopeHandler func(ctx context.Context, envelope *ReceivedEnvelope) error
+
+// dedupWindowSize 消费者记录的最近已处理幂等键数量
+const dedupWindowSize = 10000
+
+// recentKeys 固定容量的最近键集合，超出容量时淘汰最早加入的键
+type recentKeys struct {
+	mu    sync.Mutex
+	index map[string]struct{}
+	ring  []string
+	next  int
+}
+
+func newRecentKeys(size int) *recentKeys {
+	return &recentKeys{index: make(map[string]struct{}, size), ring: make([]string, size)}
+}
+
+func (r *recentKeys) contains(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.index[key]
+	return ok
+}
+
+func (r *recentKeys) add(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.index[key]; ok {
+		return
+	}
+	if old := r.ring[r.next]; old != "" {
+		delete(r.index, old)
+	}
+	r.ring[r.next] = key
+	r.index[key] = struct{}{}
+	r.next = (r.next + 1) % len(r.ring)
+}
+
+// EnvelopeConsumer 消费者组处理器：按消息声明的类型与 SchemaVersion 校验，
+// 无法解析、版本未注册或校验失败的消息转入死信主题，其余交给 handler 处理。
+// 携带 IdempotencyKeyHeader 的消息按幂等键去重，生产者重试产生的重复消息只处理一次；
+// 同一消息键总是写入同一分区，因此在分区所属的消费者内去重即可
+type EnvelopeConsumer struct {
+	registry *SchemaRegistry
+	handler  EnvelopeHandler
+	dlq      Producer
+	dlqTopic string
+	handled  *recentKeys
+	logger   *logrus.Logger
+}
+
+// NewEnvelopeConsumer 创建消费者组处理器，dlqTopic 为空时使用 TopicDeadLetter
+func NewEnvelopeConsumer(registry *SchemaRegistry, handler EnvelopeHandler, dlq Producer, dlqTopic string) *EnvelopeConsumer {
+	if dlqTopic == "" {
+		dlqTopic = TopicDeadLetter
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+
+	return &EnvelopeConsumer{
+		registry: registry,
+		handler:  handler,
+		dlq:      dlq,
+		dlqTopic: dlqTopic,
+		handled:  newRecentKeys(dedupWindowSize),
+		logger:   logger,
+	}
+}
+
+// Setup 实现 sarama.ConsumerGroupHandler
+func (c *EnvelopeConsumer) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup 实现 sarama.ConsumerGroupHandler
+func (c *EnvelopeConsumer) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim 逐条处理分区消息。消息只在处理成功或写入死信主题后标记，
+// handler 或死信写入失败时返回错误结束会话，未标记的消息在重新分配分区后再次投递
+func (c *EnvelopeConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if err := c.consume(ctx, msg); err != nil {
+				return err
+			}
+			session.MarkMessage(msg, "")
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// consume 校验并处理单条消息
+func (c *EnvelopeConsumer) consume(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	key := IdempotencyKey(msg.Headers)
+	if key != "" && c.handled.contains(key) {
+		c.logger.WithFields(logrus.Fields{
+			"topic":           msg.Topic,
+			"offset":          msg.Offset,
+			"idempotency_key": key,
+		}).Debug("Skipping duplicate message")
+		return nil
+	}
+
+	var envelope ReceivedEnvelope
+	if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		return c.deadLetter(ctx, msg, fmt.Sprintf("invalid envelope: %v", err))
+	}
+	if err := c.registry.Validate(&envelope); err != nil {
+		return c.deadLetter(ctx, msg, err.Error())
+	}
+	if err := c.handler(ctx, &envelope); err != nil {
+		return fmt.Errorf("failed to handle message %s: %w", envelope.MessageID, err)
+	}
+	if key != "" {
+		c.handled.add(key)
+	}
+	return nil
+}
+
+// deadLetter 将消息写入死信主题
+func (c *EnvelopeConsumer) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, reason string) error {
+	c.logger.WithFields(logrus.Fields{
+		"topic":     msg.Topic,
+		"partition": msg.Partition,
+		"offset":    msg.Offset,
+		"reason":    reason,
+	}).Warn("Routing message to dead letter topic")
+
+	letter := DeadLetter{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Reason:    reason,
+		Payload:   string(msg.Value),
+		FailedAt:  time.Now().Unix(),
+	}
+	if err := c.dlq.SendMessage(ctx, c.dlqTopic, string(msg.Key), letter); err != nil {
+		return fmt.Errorf("failed to send message to dead letter topic: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProducer 记录发送的消息
+type fakeProducer struct {
+	Producer
+	topics   []string
+	messages []interface{}
+}
+
+func (p *fakeProducer) SendMessage(ctx context.Context, topic string, key string, value interface{}) error {
+	p.topics = append(p.topics, topic)
+	p.messages = append(p.messages, value)
+	return nil
+}
+
+// fakeSession 记录被标记的消息
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+// fakeClaim 依次投递给定的消息后关闭
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func newFakeClaim(values ...[]byte) *fakeClaim {
+	messages := make([]*sarama.ConsumerMessage, len(values))
+	for i, value := range values {
+		messages[i] = &sarama.ConsumerMessage{Value: value}
+	}
+	return newFakeClaimFromMessages(messages...)
+}
+
+// newFakeClaimFromMessages 按顺序为消息补全主题、键与偏移量
+func newFakeClaimFromMessages(messages ...*sarama.ConsumerMessage) *fakeClaim {
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	for i, msg := range messages {
+		msg.Topic, msg.Partition, msg.Offset, msg.Key = TopicRawText, 0, int64(i), []byte("key")
+		claim.messages <- msg
+	}
+	close(claim.messages)
+	return claim
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+func encodeEnvelope(t *testing.T, envelope *MessageEnvelope) []byte {
+	value, err := json.Marshal(envelope)
+	require.NoError(t, err)
+	return value
+}
+
+func TestEnvelopeConsumerRoutesInvalidSchemaToDeadLetter(t *testing.T) {
+	data := map[string]string{"id": "text-1", "content": "hello", "source": "web"}
+	valid := NewMessageEnvelope(MessageTypeRawText, "data-collector", data)
+	require.Equal(t, 1, valid.SchemaVersion)
+
+	mismatched := NewMessageEnvelope(MessageTypeRawText, "data-collector", data)
+	mismatched.SchemaVersion = 2
+	missingField := NewMessageEnvelope(MessageTypeRawText, "data-collector", map[string]string{"id": "text-3", "source": "web"})
+	unknownType := NewMessageEnvelope("unknown_type", "data-collector", data)
+	assert.Zero(t, unknownType.SchemaVersion)
+
+	var handled []string
+	dlq := &fakeProducer{}
+	consumer := NewEnvelopeConsumer(DefaultSchemaRegistry, func(ctx context.Context, envelope *ReceivedEnvelope) error {
+		handled = append(handled, envelope.MessageID)
+		return nil
+	}, dlq, "")
+
+	session := &fakeSession{}
+	claim := newFakeClaim(
+		encodeEnvelope(t, valid),
+		encodeEnvelope(t, mismatched),
+		encodeEnvelope(t, missingField),
+		encodeEnvelope(t, unknownType),
+		[]byte("not json"),
+	)
+	require.NoError(t, consumer.ConsumeClaim(session, claim))
+
+	assert.Equal(t, []string{valid.MessageID}, handled)
+	assert.Equal(t, []int64{0, 1, 2, 3, 4}, session.marked)
+	require.Len(t, dlq.messages, 4)
+	for _, topic := range dlq.topics {
+		assert.Equal(t, TopicDeadLetter, topic)
+	}
+
+	letter := dlq.messages[0].(DeadLetter)
+	assert.Equal(t, TopicRawText, letter.Topic)
+	assert.Equal(t, int64(1), letter.Offset)
+	assert.Contains(t, letter.Reason, "unknown schema raw_text v2")
+	assert.Equal(t, string(encodeEnvelope(t, mismatched)), letter.Payload)
+	assert.Contains(t, dlq.messages[1].(DeadLetter).Reason, `missing required field "content"`)
+	assert.Contains(t, dlq.messages[2].(DeadLetter).Reason, "unknown schema unknown_type v0")
+	assert.Contains(t, dlq.messages[3].(DeadLetter).Reason, "invalid envelope")
+}
+
+func TestSchemaRegistryLatest(t *testing.T) {
+	registry := NewSchemaRegistry(
+		EnvelopeSchema{MessageType: MessageTypeRawText, Version: 1},
+		EnvelopeSchema{MessageType: MessageTypeRawText, Version: 3},
+		EnvelopeSchema{MessageType: MessageTypeRawText, Version: 2},
+	)
+	assert.Equal(t, 3, registry.Latest(MessageTypeRawText))
+	assert.Zero(t, registry.Latest(MessageTypeProcessed))
+
+	schema, ok := registry.Lookup(MessageTypeRawText, 2)
+	require.True(t, ok)
+	assert.NoError(t, schema.Validate(json.RawMessage(`{}`)))
+	assert.Error(t, schema.Validate(json.RawMessage(`[1]`)))
+	assert.Error(t, schema.Validate(json.RawMessage(`null`)))
+}
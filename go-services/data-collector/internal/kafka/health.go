@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// brokerCheckTimeout 连接单个 broker 并获取元数据的超时时间
+const brokerCheckTimeout = 3 * time.Second
+
+// BrokerChecker 向 broker 请求集群元数据以检查 Kafka 是否可用，任一 broker 响应即视为可用
+type BrokerChecker struct {
+	brokers []string
+	config  *sarama.Config
+}
+
+// NewBrokerChecker 创建 Kafka 连通性检查
+func NewBrokerChecker(brokers []string) *BrokerChecker {
+	config := sarama.NewConfig()
+	config.Net.DialTimeout = brokerCheckTimeout
+	config.Net.ReadTimeout = brokerCheckTimeout
+	config.Net.WriteTimeout = brokerCheckTimeout
+	config.Version = sarama.V2_6_0_0
+	return &BrokerChecker{brokers: brokers, config: config}
+}
+
+// HealthCheck 依次尝试各 broker，全部失败时返回最后一个错误
+func (c *BrokerChecker) HealthCheck(ctx context.Context) error {
+	if len(c.brokers) == 0 {
+		return fmt.Errorf("no kafka brokers configured")
+	}
+	var lastErr error
+	for _, addr := range c.brokers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if lastErr = c.checkBroker(addr); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("kafka unavailable: %w", lastErr)
+}
+
+func (c *BrokerChecker) checkBroker(addr string) error {
+	broker := sarama.NewBroker(addr)
+	if err := broker.Open(c.config); err != nil {
+		return fmt.Errorf("broker %s: %w", addr, err)
+	}
+	defer broker.Close()
+
+	if _, err := broker.GetMetadata(&sarama.MetadataRequest{}); err != nil {
+		return fmt.Errorf("broker %s: %w", addr, err)
+	}
+	return nil
+}
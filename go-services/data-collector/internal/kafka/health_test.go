@@ -0,0 +1,28 @@
+package kafka
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerCheckerReachesAnyBroker(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest":    sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := listener.Addr().String()
+	listener.Close()
+
+	assert.NoError(t, NewBrokerChecker([]string{unreachable, broker.Addr()}).HealthCheck(context.Background()))
+	assert.Error(t, NewBrokerChecker([]string{unreachable}).HealthCheck(context.Background()))
+}
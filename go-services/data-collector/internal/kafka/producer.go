@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -14,9 +15,63 @@ import (
 type Producer interface {
 	SendMessage(ctx context.Context, topic string, key string, value interface{}) error
 	SendRawMessage(ctx context.Context, topic string, key string, value []byte) error
+	SendEnvelope(ctx context.Context, topic string, envelope *MessageEnvelope) error
 	Close() error
 }
 
+// IdempotencyKeyHeader 幂等键的消息头名称，调用方重试发送同一逻辑消息时保持不变，消费者据此去重
+const IdempotencyKeyHeader = "idempotency_key"
+
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey 返回携带幂等键的 context，SendMessage 与 SendRawMessage 会将其写入消息头
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+// IdempotencyKey 从消息头中读取幂等键，未设置时返回空字符串
+func IdempotencyKey(headers []*sarama.RecordHeader) string {
+	for _, header := range headers {
+		if header != nil && string(header.Key) == IdempotencyKeyHeader {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// unknownSourceKey 来源为空的消息使用的分区键，保证这些消息同样写入固定分区
+const unknownSourceKey = "unknown"
+
+// PartitionKey 由采集来源推导稳定的分区键：去除首尾空白并转为小写，来源为空时使用 unknownSourceKey。
+// 生产者按键哈希选择分区，同一来源的消息总是写入同一分区，从而保证同一来源内的消息按发送顺序消费；
+// 不同来源之间不保证顺序。主题分区数变化后键与分区的映射会改变，扩容期间同一来源可能短暂乱序
+func PartitionKey(source string) string {
+	key := strings.ToLower(strings.TrimSpace(source))
+	if key == "" {
+		return unknownSourceKey
+	}
+	return key
+}
+
+// defaultProducerConfig 默认生产者配置。开启幂等生产，broker 按生产者 ID 与序号丢弃 sarama 内部重试造成的重复写入，
+// 要求 acks=all、Retry.Max >= 1 且同一连接同时只有一个在途请求
+func defaultProducerConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	config.Producer.Return.Successes = true
+	config.Producer.Compression = sarama.CompressionSnappy
+	config.Producer.Flush.Frequency = 500 * time.Millisecond
+	config.Producer.Flush.Messages = 100
+	config.Producer.MaxMessageBytes = 1000000
+	config.Producer.Idempotent = true
+	config.Net.MaxOpenRequests = 1
+	// 按消息键哈希分区，配合 PartitionKey 保证同一来源的消息写入同一分区
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Version = sarama.V2_6_0_0
+	return config
+}
+
 // SaramaProducer Sarama Kafka生产者实现
 type SaramaProducer struct {
 	producer sarama.SyncProducer
@@ -26,15 +81,7 @@ type SaramaProducer struct {
 // NewSaramaProducer 创建Sarama Kafka生产者
 func NewSaramaProducer(brokers []string, config *sarama.Config) (*SaramaProducer, error) {
 	if config == nil {
-		config = sarama.NewConfig()
-		config.Producer.RequiredAcks = sarama.WaitForAll
-		config.Producer.Retry.Max = 5
-		config.Producer.Return.Successes = true
-		config.Producer.Compression = sarama.CompressionSnappy
-		config.Producer.Flush.Frequency = 500 * time.Millisecond
-		config.Producer.Flush.Messages = 100
-		config.Producer.MaxMessageBytes = 1000000
-		config.Version = sarama.V2_6_0_0
+		config = defaultProducerConfig()
 	}
 
 	producer, err := sarama.NewSyncProducer(brokers, config)
@@ -42,13 +89,18 @@ func NewSaramaProducer(brokers []string, config *sarama.Config) (*SaramaProducer
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
+	return newSaramaProducer(producer), nil
+}
+
+// newSaramaProducer 包装已创建的 sarama 同步生产者
+func newSaramaProducer(producer sarama.SyncProducer) *SaramaProducer {
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
 
 	return &SaramaProducer{
 		producer: producer,
 		logger:   logger,
-	}, nil
+	}
 }
 
 // SendMessage 发送消息（自动序列化为JSON）
@@ -62,6 +114,11 @@ func (p *SaramaProducer) SendMessage(ctx context.Context, topic string, key stri
 	return p.SendRawMessage(ctx, topic, key, valueBytes)
 }
 
+// SendEnvelope 发送消息包装器，以 PartitionKey(envelope.Source) 作为消息键，保证同一来源的消息有序
+func (p *SaramaProducer) SendEnvelope(ctx context.Context, topic string, envelope *MessageEnvelope) error {
+	return p.SendMessage(ctx, topic, PartitionKey(envelope.Source), envelope)
+}
+
 // SendRawMessage 发送原始字节消息
 func (p *SaramaProducer) SendRawMessage(ctx context.Context, topic string, key string, value []byte) error {
 	msg := &sarama.ProducerMessage{
@@ -73,12 +130,16 @@ func (p *SaramaProducer) SendRawMessage(ctx context.Context, topic string, key s
 
 	// 添加请求ID到消息头
 	if requestID := ctx.Value("request_id"); requestID != nil {
-		msg.Headers = []sarama.RecordHeader{
-			{
-				Key:   []byte("request_id"),
-				Value: []byte(fmt.Sprintf("%v", requestID)),
-			},
-		}
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{
+			Key:   []byte("request_id"),
+			Value: []byte(fmt.Sprintf("%v", requestID)),
+		})
+	}
+	if key, ok := ctx.Value(idempotencyKeyCtxKey{}).(string); ok && key != "" {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{
+			Key:   []byte(IdempotencyKeyHeader),
+			Value: []byte(key),
+		})
 	}
 
 	partition, offset, err := p.producer.SendMessage(msg)
@@ -109,25 +170,27 @@ func (p *SaramaProducer) Close() error {
 	return nil
 }
 
-// MessageEnvelope 消息包装器
+// MessageEnvelope 消息包装器，SchemaVersion 为 Data 结构的版本，版本策略见 schema.go
 type MessageEnvelope struct {
-	MessageID   string                 `json:"message_id"`
-	MessageType string                 `json:"message_type"`
-	Source      string                 `json:"source"`
-	Timestamp   int64                  `json:"timestamp"`
-	Data        interface{}            `json:"data"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	MessageID     string                 `json:"message_id"`
+	MessageType   string                 `json:"message_type"`
+	SchemaVersion int                    `json:"schema_version"`
+	Source        string                 `json:"source"`
+	Timestamp     int64                  `json:"timestamp"`
+	Data          interface{}            `json:"data"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // NewMessageEnvelope 创建消息包装器
 func NewMessageEnvelope(messageType, source string, data interface{}) *MessageEnvelope {
 	return &MessageEnvelope{
-		MessageID:   generateMessageID(),
-		MessageType: messageType,
-		Source:      source,
-		Timestamp:   time.Now().Unix(),
-		Data:        data,
-		Metadata:    make(map[string]interface{}),
+		MessageID:     generateMessageID(),
+		MessageType:   messageType,
+		SchemaVersion: DefaultSchemaRegistry.Latest(messageType),
+		Source:        source,
+		Timestamp:     time.Now().Unix(),
+		Data:          data,
+		Metadata:      make(map[string]interface{}),
 	}
 }
 
@@ -153,6 +216,7 @@ const (
 	TopicModelUpdate   = "text-audit.model-update"
 	TopicTrainingTask  = "text-audit.training-task"
 	TopicSystemEvent   = "text-audit.system-event"
+	TopicDeadLetter    = "text-audit.dead-letter"
 )
 
 // MessageTypes 定义消息类型常量
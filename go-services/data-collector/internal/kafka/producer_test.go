@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultProducerConfigIsIdempotent(t *testing.T) {
+	config := defaultProducerConfig()
+	assert.True(t, config.Producer.Idempotent)
+	assert.Equal(t, 1, config.Net.MaxOpenRequests)
+	assert.NoError(t, config.Validate())
+}
+
+func TestRetriedProduceIsDeduplicated(t *testing.T) {
+	// 第一次发送超时（broker 可能已写入），调用方以相同幂等键重试
+	var sent []*sarama.ProducerMessage
+	record := func(msg *sarama.ProducerMessage) error {
+		sent = append(sent, msg)
+		return nil
+	}
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndFail(record, sarama.ErrRequestTimedOut)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(record)
+	producer := newSaramaProducer(mock)
+	defer producer.Close()
+
+	envelope := NewMessageEnvelope(MessageTypeRawText, "data-collector", map[string]string{"id": "text-1", "content": "hello", "source": "web"})
+	ctx := WithIdempotencyKey(context.WithValue(context.Background(), "request_id", "req-1"), envelope.MessageID)
+	err := producer.SendMessage(ctx, TopicRawText, "text-1", envelope)
+	require.ErrorIs(t, err, sarama.ErrRequestTimedOut)
+	require.NoError(t, producer.SendMessage(ctx, TopicRawText, "text-1", envelope))
+
+	// 两次写入都到达主题，消费者按幂等键只处理一次
+	require.Len(t, sent, 2)
+	received := make([]*sarama.ConsumerMessage, len(sent))
+	for i, msg := range sent {
+		value, err := msg.Value.Encode()
+		require.NoError(t, err)
+		received[i] = &sarama.ConsumerMessage{Value: value}
+		for j := range msg.Headers {
+			received[i].Headers = append(received[i].Headers, &msg.Headers[j])
+		}
+		assert.Equal(t, envelope.MessageID, IdempotencyKey(received[i].Headers))
+	}
+
+	var handled []string
+	dlq := &fakeProducer{}
+	consumer := NewEnvelopeConsumer(DefaultSchemaRegistry, func(ctx context.Context, envelope *ReceivedEnvelope) error {
+		handled = append(handled, envelope.MessageID)
+		return nil
+	}, dlq, "")
+	session := &fakeSession{}
+	require.NoError(t, consumer.ConsumeClaim(session, newFakeClaimFromMessages(received...)))
+
+	assert.Equal(t, []string{envelope.MessageID}, handled)
+	assert.Equal(t, []int64{0, 1}, session.marked)
+	assert.Empty(t, dlq.messages)
+}
+
+func TestRecentKeysEvictsOldest(t *testing.T) {
+	keys := newRecentKeys(2)
+	keys.add("a")
+	keys.add("b")
+	keys.add("a")
+	keys.add("c")
+	assert.False(t, keys.contains("a"))
+	assert.True(t, keys.contains("b"))
+	assert.True(t, keys.contains("c"))
+}
+
+// recordingPartitioner 包装哈希分区器，记录每个消息键被分配到的分区
+type recordingPartitioner struct {
+	sarama.Partitioner
+	partitions map[string][]int32
+}
+
+func (p *recordingPartitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	partition, err := p.Partitioner.Partition(msg, numPartitions)
+	key, _ := msg.Key.Encode()
+	p.partitions[string(key)] = append(p.partitions[string(key)], partition)
+	return partition, err
+}
+
+func TestSendEnvelopeKeysBySource(t *testing.T) {
+	recorder := &recordingPartitioner{partitions: make(map[string][]int32)}
+	config := defaultProducerConfig()
+	config.Producer.Partitioner = func(topic string) sarama.Partitioner {
+		recorder.Partitioner = sarama.NewHashPartitioner(topic)
+		return recorder
+	}
+	mock := mocks.NewSyncProducer(t, config)
+	mock.TopicConfig.SetDefaultPartitions(12)
+
+	sources := []string{"zhihu:answer", "web:example.com", "zhihu:answer", " Zhihu:Answer ", "web:example.com", "", "bilibili:comment"}
+	for range sources {
+		mock.ExpectSendMessageAndSucceed()
+	}
+	producer := newSaramaProducer(mock)
+	defer producer.Close()
+
+	for _, source := range sources {
+		envelope := NewMessageEnvelope(MessageTypeRawText, source, map[string]string{"id": "text", "content": "hello", "source": source})
+		require.NoError(t, producer.SendEnvelope(context.Background(), TopicRawText, envelope))
+	}
+
+	assert.Len(t, recorder.partitions, 4)
+	assert.Len(t, recorder.partitions["zhihu:answer"], 3)
+	assert.Len(t, recorder.partitions["web:example.com"], 2)
+	assert.Len(t, recorder.partitions[unknownSourceKey], 1)
+	for key, partitions := range recorder.partitions {
+		for _, partition := range partitions {
+			assert.Equal(t, partitions[0], partition, key)
+		}
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	assert.Equal(t, "zhihu:answer", PartitionKey(" Zhihu:Answer"))
+	assert.Equal(t, unknownSourceKey, PartitionKey("  "))
+}
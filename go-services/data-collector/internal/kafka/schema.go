@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 消息 Schema 版本策略：
+//   - 每种 MessageType 的 Data 结构各自维护从 1 开始的整数版本，写入 MessageEnvelope.SchemaVersion
+//   - 只新增可选字段属于兼容修改，不升级版本；删除、重命名字段，修改字段类型或语义，新增必填字段时升级版本
+//   - 升级时先在所有消费者的注册表中注册新版本并完成部署，再让生产者切换到新版本；
+//     旧版本在确认主题中不再有积压消息后才可移除
+//   - 消费者收到未注册的类型或版本、或 Data 不符合对应 Schema 的消息时，转入死信主题而不处理
+
+// EnvelopeSchema 某一消息类型某一版本的 Data 结构约定
+type EnvelopeSchema struct {
+	MessageType string
+	Version     int
+	// Required Data 中必须存在且非 null 的字段
+	Required []string
+}
+
+// Validate 校验 Data 是否为 JSON 对象且包含所有必填字段
+func (s EnvelopeSchema) Validate(data json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return fmt.Errorf("%s v%d: data must be a JSON object", s.MessageType, s.Version)
+	}
+	for _, name := range s.Required {
+		if value, ok := fields[name]; !ok || string(value) == "null" {
+			return fmt.Errorf("%s v%d: missing required field %q", s.MessageType, s.Version, name)
+		}
+	}
+	return nil
+}
+
+// SchemaRegistry 已知的消息 Schema，按消息类型与版本查找
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]map[int]EnvelopeSchema
+}
+
+// NewSchemaRegistry 创建注册表并注册给定的 Schema
+func NewSchemaRegistry(schemas ...EnvelopeSchema) *SchemaRegistry {
+	r := &SchemaRegistry{schemas: make(map[string]map[int]EnvelopeSchema)}
+	for _, schema := range schemas {
+		r.Register(schema)
+	}
+	return r
+}
+
+// Register 注册 Schema，相同类型与版本的已有 Schema 会被替换
+func (r *SchemaRegistry) Register(schema EnvelopeSchema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.schemas[schema.MessageType] == nil {
+		r.schemas[schema.MessageType] = make(map[int]EnvelopeSchema)
+	}
+	r.schemas[schema.MessageType][schema.Version] = schema
+}
+
+// Lookup 查找消息类型与版本对应的 Schema
+func (r *SchemaRegistry) Lookup(messageType string, version int) (EnvelopeSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[messageType][version]
+	return schema, ok
+}
+
+// Latest 返回消息类型已注册的最高版本，未注册时返回 0
+func (r *SchemaRegistry) Latest(messageType string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]int, 0, len(r.schemas[messageType]))
+	for version := range r.schemas[messageType] {
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return 0
+	}
+	sort.Ints(versions)
+	return versions[len(versions)-1]
+}
+
+// Validate 按信封声明的类型与版本校验消息
+func (r *SchemaRegistry) Validate(envelope *ReceivedEnvelope) error {
+	schema, ok := r.Lookup(envelope.MessageType, envelope.SchemaVersion)
+	if !ok {
+		return fmt.Errorf("unknown schema %s v%d", envelope.MessageType, envelope.SchemaVersion)
+	}
+	return schema.Validate(envelope.Data)
+}
+
+// DefaultSchemaRegistry 当前生产者使用的消息 Schema，NewMessageEnvelope 以其中的最高版本作为 SchemaVersion
+var DefaultSchemaRegistry = NewSchemaRegistry(
+	EnvelopeSchema{MessageType: MessageTypeRawText, Version: 1, Required: []string{"id", "content", "source"}},
+	EnvelopeSchema{MessageType: MessageTypeProcessed, Version: 1, Required: []string{"id", "raw_text_id", "content"}},
+	EnvelopeSchema{MessageType: MessageTypeAuditRequest, Version: 1},
+	EnvelopeSchema{MessageType: MessageTypeAuditResult, Version: 1},
+	EnvelopeSchema{MessageType: MessageTypeModelUpdate, Version: 1},
+	EnvelopeSchema{MessageType: MessageTypeTrainingTask, Version: 1},
+	EnvelopeSchema{MessageType: MessageTypeSystemEvent, Version: 1},
+)
@@ -2,16 +2,66 @@ package model
 
 import (
 	"time"
+	"unicode/utf8"
 )
 
-// RawText 原始文本数据模型
+// RawText 原始文本数据模型。采集 Metadata 中的常用键（见 PromoteMetadata）保存在独立的索引列中，
+// 其余键以 JSON 保存在 Metadata
 type RawText struct {
-	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ID        string    `gorm:"primaryKey;type:varchar(36);index:idx_raw_texts_created_at_id,priority:2" json:"id"`
 	Content   string    `gorm:"type:text;not null" json:"content"`
 	Source    string    `gorm:"type:varchar(100);not null;index" json:"source"`
 	Timestamp int64     `gorm:"not null;index" json:"timestamp"`
+	URL       string    `gorm:"type:varchar(768);index" json:"url,omitempty"`
+	Author    string    `gorm:"type:varchar(255);index" json:"author,omitempty"`
+	Platform  string    `gorm:"type:varchar(64);index" json:"platform,omitempty"`
+	Type      string    `gorm:"type:varchar(64);index" json:"type,omitempty"`
 	Metadata  string    `gorm:"type:json" json:"metadata"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_raw_texts_created_at_id,priority:1" json:"created_at"`
+}
+
+// promotedMetadataField RawText 中保存某个 Metadata 键的列
+type promotedMetadataField struct {
+	// maxLen 列的最大字符数，与 gorm 标签中的 varchar 长度一致
+	maxLen int
+	field  func(t *RawText) *string
+}
+
+// promotedMetadataFields 提升为独立列的 Metadata 键，列名与键相同
+var promotedMetadataFields = map[string]promotedMetadataField{
+	"url":      {maxLen: 768, field: func(t *RawText) *string { return &t.URL }},
+	"author":   {maxLen: 255, field: func(t *RawText) *string { return &t.Author }},
+	"platform": {maxLen: 64, field: func(t *RawText) *string { return &t.Platform }},
+	"type":     {maxLen: 64, field: func(t *RawText) *string { return &t.Type }},
+}
+
+// PromotedMetadataKeys 返回提升为 RawText 独立列的 Metadata 键及对应列的最大字符数
+func PromotedMetadataKeys() map[string]int {
+	keys := make(map[string]int, len(promotedMetadataFields))
+	for key, f := range promotedMetadataFields {
+		keys[key] = f.maxLen
+	}
+	return keys
+}
+
+// IsPromotedMetadataKey Metadata 键是否保存在 RawText 的独立列中
+func IsPromotedMetadataKey(key string) bool {
+	_, ok := promotedMetadataFields[key]
+	return ok
+}
+
+// PromoteMetadata 将 metadata 中提升为独立列的键写入对应字段，返回其余需要以 JSON 保存的键。
+// 超过列宽的取值不提升，保留在返回结果中；metadata 本身不会被修改
+func (t *RawText) PromoteMetadata(metadata map[string]string) map[string]string {
+	rest := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		if f, ok := promotedMetadataFields[key]; ok && utf8.RuneCountInString(value) <= f.maxLen {
+			*f.field(t) = value
+			continue
+		}
+		rest[key] = value
+	}
+	return rest
 }
 
 func (RawText) TableName() string {
@@ -24,6 +74,8 @@ type CollectionTask struct {
 	SourceType     string    `gorm:"type:varchar(20);not null;index" json:"source_type"`
 	SourceURL      string    `gorm:"type:varchar(1000)" json:"source_url"`
 	SourceFilePath string    `gorm:"type:varchar(500)" json:"source_file_path"`
+	// SourceParameters 采集源参数的 JSON，任务被其他实例接管时用于还原请求
+	SourceParameters string  `gorm:"type:text" json:"source_parameters,omitempty"`
 	Config         string    `gorm:"type:json;not null" json:"config"`
 	Status         string    `gorm:"type:varchar(20);default:'pending';index" json:"status"`
 	CollectedCount int       `gorm:"default:0" json:"collected_count"`
@@ -32,14 +84,55 @@ type CollectionTask struct {
 	StartTime      *time.Time `gorm:"type:timestamp null;default:null" json:"start_time"`
 	EndTime        *time.Time `gorm:"type:timestamp null;default:null" json:"end_time"`
 	ErrorMessage   string    `gorm:"type:text" json:"error_message"`
+	// Priority 任务优先级，数值越大越先执行
+	Priority       int       `gorm:"default:0;index" json:"priority"`
+	// Attempts 已执行次数；RetryAt 为自动重试的最早时间，之前其他实例不会接管
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	RetryAt        *time.Time `gorm:"type:timestamp null;default:null" json:"retry_at,omitempty"`
+	// ClaimedBy 正在执行任务的实例ID，LeaseExpiresAt 之前其他实例不会接管
+	ClaimedBy      string     `gorm:"type:varchar(64);index" json:"claimed_by,omitempty"`
+	LeaseExpiresAt *time.Time `gorm:"type:timestamp null;default:null" json:"lease_expires_at,omitempty"`
+	// ResumeOffset 文件采集已连续保存到的字节偏移，任务恢复时从此处继续读取
+	ResumeOffset   int64      `gorm:"default:0" json:"resume_offset,omitempty"`
 	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
+// 采集任务状态，与 proto 中 CollectionStatus 的枚举名一致
+const (
+	TaskStatusPending   = "COLLECTION_PENDING"
+	TaskStatusRunning   = "COLLECTION_RUNNING"
+	TaskStatusCompleted = "COLLECTION_COMPLETED"
+	TaskStatusFailed    = "COLLECTION_FAILED"
+	TaskStatusCancelled = "COLLECTION_CANCELLED"
+	TaskStatusPaused    = "COLLECTION_PAUSED"
+)
+
 func (CollectionTask) TableName() string {
 	return "collection_tasks"
 }
 
+// CollectionSchedule 定时采集计划
+type CollectionSchedule struct {
+	ID             string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name           string     `gorm:"type:varchar(100)" json:"name"`
+	CronExpr       string     `gorm:"type:varchar(100);not null" json:"cron_expr"`
+	SourceType     string     `gorm:"type:varchar(20);not null;index" json:"source_type"`
+	SourceURL      string     `gorm:"type:varchar(1000)" json:"source_url"`
+	SourceFilePath string     `gorm:"type:varchar(500)" json:"source_file_path"`
+	Parameters     string     `gorm:"type:json" json:"parameters"`
+	Config         string     `gorm:"type:json;not null" json:"config"`
+	Enabled        bool       `gorm:"default:true;index" json:"enabled"`
+	LastRunAt      *time.Time `gorm:"type:timestamp null;default:null" json:"last_run_at"`
+	NextRunAt      *time.Time `gorm:"type:timestamp null;default:null;index" json:"next_run_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (CollectionSchedule) TableName() string {
+	return "collection_schedules"
+}
+
 // ProcessedText 预处理文本数据模型
 type ProcessedText struct {
 	ID                 string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
@@ -0,0 +1,135 @@
+// Package quality 基于规则的文本质量评分，用于采集时过滤低质量文本。
+// 规则与 model-inference 的 /api/v1/text/quality 保持一致，修改时需同步，并同步更新两侧的 testdata/cases.json
+package quality
+
+import (
+	"math"
+	"unicode"
+)
+
+const (
+	// minRunes 有效字符少于该数量的文本长度得分为 0
+	minRunes = 5
+	// fullLengthRunes 达到该长度后长度得分为满分
+	fullLengthRunes = 20
+	// maxRunes 超过该长度后长度得分逐渐降低
+	maxRunes = 1000
+	// maxPunctuationRatio 标点占比超过该值开始扣分
+	maxPunctuationRatio = 0.3
+	// minDistinctBigramRatio 不重复二元组占比低于该值时重复度得分为 0
+	minDistinctBigramRatio = 0.3
+	// fullDistinctBigramRatio 不重复二元组占比达到该值时重复度得分为满分
+	fullDistinctBigramRatio = 0.8
+)
+
+// Score 文本质量评分，Total 为 0-100 的综合得分，其余各项为 0-1 的分项得分
+type Score struct {
+	Total       int     `json:"score"`
+	Length      float64 `json:"length"`
+	Purity      float64 `json:"purity"`
+	Punctuation float64 `json:"punctuation"`
+	Repetition  float64 `json:"repetition"`
+}
+
+// Evaluate 计算文本质量得分。
+// 长度与重复度是硬性条件，以乘积计入；语言纯度与标点占比加权平均后再相乘
+func Evaluate(text string) Score {
+	var (
+		runes                []rune
+		han, latin, digits   int
+		punctuation, invalid int
+	)
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		runes = append(runes, r)
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			latin++
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsPunct(r):
+			punctuation++
+		default:
+			invalid++
+		}
+	}
+
+	s := Score{
+		Length:      lengthScore(len(runes)),
+		Purity:      purityScore(han, latin, invalid, len(runes)),
+		Punctuation: punctuationScore(punctuation, len(runes)),
+		Repetition:  repetitionScore(runes),
+	}
+	total := 100 * s.Length * s.Repetition * (0.6*s.Purity + 0.4*s.Punctuation)
+	s.Total = int(math.Round(total))
+	return s
+}
+
+// lengthScore 过短的文本为 0，达到 fullLengthRunes 为满分，超过 maxRunes 后按比例降低，最低 0.5
+func lengthScore(n int) float64 {
+	switch {
+	case n < minRunes:
+		return 0
+	case n < fullLengthRunes:
+		return float64(n-minRunes+1) / float64(fullLengthRunes-minRunes+1)
+	case n <= maxRunes:
+		return 1
+	default:
+		return math.Max(0.5, float64(maxRunes)/float64(n))
+	}
+}
+
+// purityScore 文字、数字与标点之外的字符（表情、符号、乱码）越多得分越低；
+// 中文与拉丁字母混杂时按主要文字的占比略微扣分
+func purityScore(han, latin, invalid, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	valid := 1 - float64(invalid)/float64(total)
+	letters := han + latin
+	if letters == 0 {
+		return 0
+	}
+	dominant := float64(max(han, latin)) / float64(letters)
+	return valid * (0.7 + 0.3*dominant)
+}
+
+// punctuationScore 标点占比超过 maxPunctuationRatio 后线性扣分，较长文本完全没有标点时扣分
+func punctuationScore(punctuation, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	ratio := float64(punctuation) / float64(total)
+	switch {
+	case ratio > maxPunctuationRatio:
+		return math.Max(0, 1-(ratio-maxPunctuationRatio)/maxPunctuationRatio)
+	case punctuation == 0 && total > 50:
+		return 0.7
+	default:
+		return 1
+	}
+}
+
+// repetitionScore 按相邻字符二元组的不重复占比衡量重复程度，整段复制或刷屏的文本得分接近 0
+func repetitionScore(runes []rune) float64 {
+	if len(runes) < 2 {
+		return 0
+	}
+	seen := make(map[[2]rune]struct{}, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		seen[[2]rune{runes[i], runes[i+1]}] = struct{}{}
+	}
+	ratio := float64(len(seen)) / float64(len(runes)-1)
+	switch {
+	case ratio <= minDistinctBigramRatio:
+		return 0
+	case ratio >= fullDistinctBigramRatio:
+		return 1
+	default:
+		return (ratio - minDistinctBigramRatio) / (fullDistinctBigramRatio - minDistinctBigramRatio)
+	}
+}
@@ -0,0 +1,42 @@
+package quality
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedCase testdata/cases.json 中的用例，data-collector 与 model-inference 各存一份相同的副本
+type sharedCase struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+	Want Score  `json:"want"`
+}
+
+// TestEvaluateSharedCases 两个服务的 quality 包是同一份规则的副本，用相同的用例表校验，任一侧单独修改规则都会失败
+func TestEvaluateSharedCases(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cases.json"))
+	require.NoError(t, err)
+	var cases []sharedCase
+	require.NoError(t, json.Unmarshal(data, &cases))
+	require.NotEmpty(t, cases)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Want, Evaluate(tc.Text))
+		})
+	}
+
+	// 仓库中另一服务的用例表必须与本服务一致（单独构建某个服务时不存在，跳过）
+	sibling := filepath.Join("..", "..", "..", "model-inference", "internal", "quality", "testdata", "cases.json")
+	other, err := os.ReadFile(sibling)
+	if os.IsNotExist(err) {
+		t.Skip("sibling service not present")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(other), "testdata/cases.json differs from %s", sibling)
+}
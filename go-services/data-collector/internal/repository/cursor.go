@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RawTextCursor 原始文本键集分页的位置，指向上一页最后一条记录。
+// 创建时间相同的记录再按 ID 排序，保证翻页顺序稳定
+type RawTextCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode 将游标编码为 URL 安全的字符串
+func (c RawTextCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseRawTextCursor 解析 Encode 生成的游标
+func ParseRawTextCursor(s string) (*RawTextCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding: %w", err)
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("invalid cursor %q", s)
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor timestamp: %w", err)
+	}
+	return &RawTextCursor{CreatedAt: time.Unix(0, unixNano).UTC(), ID: id}, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// QueryDuration 按仓库方法统计的数据库查询耗时，由 main 注册到 Prometheus
+var QueryDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "data_collector_db_query_duration_seconds",
+		Help:    "Database query duration in seconds by repository operation",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	},
+	[]string{"operation"},
+)
+
+const (
+	queryStartKey = "query_metrics:start"
+	// repositoryMethodPrefix 调用栈中 MySQLRepository 方法的函数名前缀
+	repositoryMethodPrefix = "repository.(*MySQLRepository)."
+)
+
+// queryMetrics gorm 插件：记录每条 SQL 的耗时，超过阈值时输出慢查询日志。
+// 操作名取自调用栈中最近的 MySQLRepository 方法，如 SaveRawText
+type queryMetrics struct {
+	threshold time.Duration
+	logger    logrus.FieldLogger
+	now       func() time.Time
+}
+
+// newQueryMetrics 创建查询耗时插件，threshold 不大于 0 时只统计耗时不输出慢查询日志
+func newQueryMetrics(threshold time.Duration) *queryMetrics {
+	return &queryMetrics{threshold: threshold, logger: logrus.StandardLogger(), now: time.Now}
+}
+
+func (m *queryMetrics) Name() string {
+	return "query_metrics"
+}
+
+func (m *queryMetrics) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("gorm:create").Register("query_metrics:before_create", m.before),
+		callbacks.Create().After("gorm:create").Register("query_metrics:after_create", m.after),
+		callbacks.Query().Before("gorm:query").Register("query_metrics:before_query", m.before),
+		callbacks.Query().After("gorm:query").Register("query_metrics:after_query", m.after),
+		callbacks.Update().Before("gorm:update").Register("query_metrics:before_update", m.before),
+		callbacks.Update().After("gorm:update").Register("query_metrics:after_update", m.after),
+		callbacks.Delete().Before("gorm:delete").Register("query_metrics:before_delete", m.before),
+		callbacks.Delete().After("gorm:delete").Register("query_metrics:after_delete", m.after),
+		callbacks.Row().Before("gorm:row").Register("query_metrics:before_row", m.before),
+		callbacks.Row().After("gorm:row").Register("query_metrics:after_row", m.after),
+		callbacks.Raw().Before("gorm:raw").Register("query_metrics:before_raw", m.before),
+		callbacks.Raw().After("gorm:raw").Register("query_metrics:after_raw", m.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *queryMetrics) before(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, m.now())
+}
+
+func (m *queryMetrics) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(queryStartKey)
+	if !ok {
+		return
+	}
+	elapsed := m.now().Sub(value.(time.Time))
+	operation := repositoryOperation()
+	QueryDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+
+	if m.threshold > 0 && elapsed >= m.threshold {
+		m.logger.WithFields(logrus.Fields{
+			"operation":     operation,
+			"duration_ms":   elapsed.Milliseconds(),
+			"threshold_ms":  m.threshold.Milliseconds(),
+			"table":         db.Statement.Table,
+			"rows_affected": db.RowsAffected,
+			"sql":           db.Statement.SQL.String(),
+		}).Warn("Slow database query")
+	}
+}
+
+// repositoryOperation 从调用栈中找到发起查询的 MySQLRepository 方法名，事务内的闭包归到外层方法
+func repositoryOperation() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if i := strings.Index(frame.Function, repositoryMethodPrefix); i >= 0 {
+			method := frame.Function[i+len(repositoryMethodPrefix):]
+			if dot := strings.IndexByte(method, '.'); dot >= 0 {
+				method = method[:dot]
+			}
+			return method
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
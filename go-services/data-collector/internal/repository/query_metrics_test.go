@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+)
+
+func TestQueryMetricsLogsSlowQueries(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	// 每次读取时钟前进 step，模拟查询耗时
+	clock := time.Unix(0, 0)
+	step := time.Millisecond
+	logger, hook := logtest.NewNullLogger()
+	metrics := &queryMetrics{threshold: 100 * time.Millisecond, logger: logger, now: func() time.Time {
+		clock = clock.Add(step)
+		return clock
+	}}
+	require.NoError(t, repo.db.Use(metrics))
+	observed := querySampleCount(t, "ListRawTexts")
+
+	require.NoError(t, repo.SaveRawText(ctx, &model.RawText{ID: "text-1", Content: "fast", Source: "web"}))
+	assert.Empty(t, hook.AllEntries())
+
+	step = 300 * time.Millisecond
+	_, err := repo.ListRawTexts(ctx, RawTextFilter{Source: "web"}, 10, 0)
+	require.NoError(t, err)
+
+	require.Len(t, hook.AllEntries(), 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.WarnLevel, entry.Level)
+	assert.Equal(t, "Slow database query", entry.Message)
+	assert.Equal(t, "ListRawTexts", entry.Data["operation"])
+	assert.Equal(t, int64(300), entry.Data["duration_ms"])
+	assert.Contains(t, entry.Data["sql"], "raw_texts")
+
+	assert.Equal(t, observed+1, querySampleCount(t, "ListRawTexts"))
+}
+
+func querySampleCount(t *testing.T, operation string) uint64 {
+	var metric dto.Metric
+	require.NoError(t, QueryDuration.WithLabelValues(operation).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestRepositoryOperationInsideTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	logger, hook := logtest.NewNullLogger()
+	require.NoError(t, repo.db.Use(&queryMetrics{threshold: time.Nanosecond, logger: logger, now: time.Now}))
+
+	require.NoError(t, repo.WithTransaction(ctx, func(tx Repository) error {
+		return tx.SaveRawText(ctx, &model.RawText{ID: "text-1", Content: "a", Source: "web"})
+	}))
+
+	require.NotEmpty(t, hook.AllEntries())
+	for _, entry := range hook.AllEntries() {
+		assert.Equal(t, "SaveRawText", entry.Data["operation"])
+	}
+}
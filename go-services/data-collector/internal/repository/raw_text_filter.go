@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+)
+
+// MetadataFilterKeys 允许按 Metadata 筛选的键。键会拼入列名或 JSON 路径，只接受列表中的取值以避免注入。
+// url、author、platform、type 保存在 raw_texts 的独立索引列中（见 model.RawText），其余键按 JSON 路径筛选
+var MetadataFilterKeys = map[string]bool{
+	"platform":      true,
+	"type":          true,
+	"author":        true,
+	"url":           true,
+	"tag":           true,
+	"bvid":          true,
+	"published_at":  true,
+	"archive_entry": true,
+	"label":         true,
+	"pii_types":     true,
+}
+
+// RawTextFilter 原始文本的筛选条件，零值字段不参与筛选
+type RawTextFilter struct {
+	Source string
+	// Metadata 要求 Metadata 中对应键的取值完全相等，键须在 MetadataFilterKeys 中
+	Metadata map[string]string
+	// StartTime、EndTime 按创建时间筛选 [StartTime, EndTime]，两端均包含
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// Validate 检查 Metadata 的键是否都在允许列表中以及时间范围是否有效
+func (f RawTextFilter) Validate() error {
+	if !f.StartTime.IsZero() && !f.EndTime.IsZero() && f.StartTime.After(f.EndTime) {
+		return fmt.Errorf("start time %s is after end time %s", f.StartTime.Format(time.RFC3339), f.EndTime.Format(time.RFC3339))
+	}
+	for key := range f.Metadata {
+		if !MetadataFilterKeys[key] {
+			return fmt.Errorf("metadata key %q is not filterable", key)
+		}
+	}
+	return nil
+}
+
+// apply 将筛选条件加入查询。提升为独立列的 Metadata 键直接比较该列，
+// 其余键转换为 metadata->>'$.key' = ?，即 MySQL 的 JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.key'))
+func (f RawTextFilter) apply(query *gorm.DB) (*gorm.DB, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if f.Source != "" {
+		query = query.Where("source = ?", f.Source)
+	}
+	if !f.StartTime.IsZero() {
+		query = query.Where("created_at >= ?", f.StartTime)
+	}
+	if !f.EndTime.IsZero() {
+		query = query.Where("created_at <= ?", f.EndTime)
+	}
+	keys := make([]string, 0, len(f.Metadata))
+	for key := range f.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if model.IsPromotedMetadataKey(key) {
+			query = query.Where(fmt.Sprintf("%s = ?", key), f.Metadata[key])
+			continue
+		}
+		query = query.Where(fmt.Sprintf("metadata->>'$.%s' = ?", key), f.Metadata[key])
+	}
+	return query, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+)
+
+const (
+	// rawTextMetadataMigrationKey system_configs 中标记旧记录 Metadata 已迁移的配置键
+	rawTextMetadataMigrationKey = "migration.raw_text_metadata"
+	// rawTextMigrationBatchSize 迁移时每批按主键范围处理的记录数
+	rawTextMigrationBatchSize = 1000
+)
+
+// MigrateRawTextMetadata 迁移提升为独立列之前写入的记录，完成后在 system_configs 写入标记，
+// 之后只检查标记，不再扫描 raw_texts。由采集服务启动时调用，导出命令不执行
+func (r *MySQLRepository) MigrateRawTextMetadata(ctx context.Context) error {
+	db := r.db.WithContext(ctx)
+	var done int64
+	if err := db.Model(&model.SystemConfig{}).Where("config_key = ?", rawTextMetadataMigrationKey).Count(&done).Error; err != nil {
+		return fmt.Errorf("check raw text metadata migration: %w", err)
+	}
+	if done > 0 {
+		return nil
+	}
+
+	if err := migrateRawTextMetadata(db, rawTextMigrationBatchSize); err != nil {
+		return err
+	}
+	// 多个实例同时启动时都可能执行迁移，迁移可重复执行，标记只写入一次
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.SystemConfig{
+		ConfigKey:   rawTextMetadataMigrationKey,
+		ConfigValue: "done",
+		Description: "raw_texts 旧记录 Metadata 已迁移到独立列",
+	}).Error
+}
+
+// migrateRawTextMetadata 将 url、author 等键提升为独立列之前写入的记录迁移到新列：
+// 取值写入同名列并从 Metadata 中移除。按主键范围每批处理 batchSize 条记录，避免长时间锁表；
+// 已迁移的记录不再包含这些键，重复执行不会产生变化。
+// 超过列宽的取值保持原样留在 Metadata 中；LENGTH 在 MySQL 中按字节计算，比列宽的字符数更保守
+func migrateRawTextMetadata(db *gorm.DB, batchSize int) error {
+	promoted := model.PromotedMetadataKeys()
+	keys := make([]string, 0, len(promoted))
+	for key := range promoted {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lower string
+	for {
+		var ids []string
+		if err := db.Model(&model.RawText{}).Where("id > ?", lower).Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("list raw texts to migrate: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		upper := ids[len(ids)-1]
+
+		for _, key := range keys {
+			path := "$." + key
+			// MySQL 按顺序执行赋值，先读取原 Metadata 中的取值再移除该键
+			err := db.Exec(fmt.Sprintf(
+				"UPDATE raw_texts SET %s = metadata->>'%s', metadata = JSON_REMOVE(metadata, '%s') "+
+					"WHERE id > ? AND id <= ? AND metadata->>'%s' IS NOT NULL AND LENGTH(metadata->>'%s') <= ?",
+				key, path, path, path, path,
+			), lower, upper, promoted[key]).Error
+			if err != nil {
+				return fmt.Errorf("migrate metadata key %s: %w", key, err)
+			}
+		}
+
+		if len(ids) < batchSize {
+			return nil
+		}
+		lower = upper
+	}
+}
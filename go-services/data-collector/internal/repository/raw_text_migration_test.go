@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+)
+
+func TestMigrateRawTextMetadata(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	// 提升为独立列之前写入的记录，常用键只存在于 Metadata 中
+	longURL := "https://example.com/" + strings.Repeat("a", 800)
+	for _, text := range []*model.RawText{
+		{ID: "answer", Source: "zhihu:answer", Metadata: `{"url":"https://zhihu.com/a/1","author":"张三","platform":"zhihu","type":"answer","vote_count":"3"}`},
+		{ID: "long", Source: "web", Metadata: `{"url":"` + longURL + `","platform":"web"}`},
+		{ID: "current", Source: "web", Platform: "web", Metadata: `{"tag":"p"}`},
+	} {
+		text.Content = text.ID
+		require.NoError(t, repo.SaveRawText(ctx, text))
+	}
+
+	// 每批一条，验证按主键范围分批处理
+	require.NoError(t, migrateRawTextMetadata(repo.db, 1))
+	// 重复执行不改变已迁移的记录
+	require.NoError(t, migrateRawTextMetadata(repo.db, 2))
+
+	answer, err := repo.GetRawTextByID(ctx, "answer")
+	require.NoError(t, err)
+	assert.Equal(t, "https://zhihu.com/a/1", answer.URL)
+	assert.Equal(t, "张三", answer.Author)
+	assert.Equal(t, "zhihu", answer.Platform)
+	assert.Equal(t, "answer", answer.Type)
+	assert.Equal(t, map[string]string{"vote_count": "3"}, decodeMetadata(t, answer.Metadata))
+
+	// 超过列宽的取值留在 Metadata 中
+	long, err := repo.GetRawTextByID(ctx, "long")
+	require.NoError(t, err)
+	assert.Empty(t, long.URL)
+	assert.Equal(t, "web", long.Platform)
+	assert.Equal(t, map[string]string{"url": longURL}, decodeMetadata(t, long.Metadata))
+
+	current, err := repo.GetRawTextByID(ctx, "current")
+	require.NoError(t, err)
+	assert.Equal(t, "web", current.Platform)
+	assert.Equal(t, map[string]string{"tag": "p"}, decodeMetadata(t, current.Metadata))
+
+	texts, err := repo.ListRawTexts(ctx, RawTextFilter{Metadata: map[string]string{"platform": "web"}}, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, texts, 2)
+}
+
+func TestMigrateRawTextMetadataRunsOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.db.AutoMigrate(&model.SystemConfig{}))
+
+	legacy := func(id string) {
+		require.NoError(t, repo.SaveRawText(ctx, &model.RawText{ID: id, Content: id, Source: "web", Metadata: `{"platform":"web"}`}))
+	}
+	legacy("before")
+	require.NoError(t, repo.MigrateRawTextMetadata(ctx))
+	migrated, err := repo.GetRawTextByID(ctx, "before")
+	require.NoError(t, err)
+	assert.Equal(t, "web", migrated.Platform)
+
+	marker, err := repo.GetConfig(ctx, rawTextMetadataMigrationKey)
+	require.NoError(t, err)
+	assert.Equal(t, "done", marker.ConfigValue)
+
+	// 已写入标记后不再扫描表，之后的启动不会处理任何记录
+	legacy("after")
+	require.NoError(t, repo.MigrateRawTextMetadata(ctx))
+	skipped, err := repo.GetRawTextByID(ctx, "after")
+	require.NoError(t, err)
+	assert.Empty(t, skipped.Platform)
+}
+
+func TestPromotedMetadataColumnsAreIndexed(t *testing.T) {
+	repo := newTestRepository(t)
+	for key := range model.PromotedMetadataKeys() {
+		index := "idx_raw_texts_" + key
+		assert.True(t, repo.db.Migrator().HasIndex(&model.RawText{}, index), index)
+
+		// 按提升的列筛选时使用对应索引而不是全表扫描
+		var plan []struct {
+			Detail string
+		}
+		require.NoError(t, repo.db.Raw("EXPLAIN QUERY PLAN SELECT id FROM raw_texts WHERE "+key+" = ?", "x").Scan(&plan).Error)
+		require.NotEmpty(t, plan)
+		assert.Contains(t, plan[0].Detail, "USING INDEX "+index, key)
+	}
+}
+
+func decodeMetadata(t *testing.T, raw string) map[string]string {
+	var metadata map[string]string
+	require.NoError(t, json.Unmarshal([]byte(raw), &metadata))
+	return metadata
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+)
+
+// NewRedisClient 创建Redis客户端，不检查连接；连接在首次使用时建立，断开后自动重连
+func NewRedisClient(cfg config.RedisConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+}
+
+// PingRedis 测试Redis连接
+func PingRedis(client *redis.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
 	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -15,23 +16,44 @@ import (
 type Repository interface {
 	// RawText 相关操作
 	SaveRawText(ctx context.Context, text *model.RawText) error
+	SaveRawTexts(ctx context.Context, texts []*model.RawText) error
 	GetRawTextByID(ctx context.Context, id string) (*model.RawText, error)
-	ListRawTexts(ctx context.Context, source string, limit, offset int) ([]*model.RawText, error)
-	CountRawTexts(ctx context.Context, source string) (int64, error)
+	ListRawTexts(ctx context.Context, filter RawTextFilter, limit, offset int) ([]*model.RawText, error)
+	// ListRawTextsBefore 按创建时间倒序返回 cursor 之前的一页，cursor 为 nil 时从最新一条开始；
+	// 返回的游标指向本页最后一条，不足一页时为 nil。翻页期间的新写入不会造成重复或遗漏
+	ListRawTextsBefore(ctx context.Context, filter RawTextFilter, cursor *RawTextCursor, limit int) ([]*model.RawText, *RawTextCursor, error)
+	CountRawTexts(ctx context.Context, filter RawTextFilter) (int64, error)
 
 	// CollectionTask 相关操作
 	CreateCollectionTask(ctx context.Context, task *model.CollectionTask) error
 	UpdateCollectionTask(ctx context.Context, task *model.CollectionTask) error
 	GetCollectionTaskByID(ctx context.Context, id string) (*model.CollectionTask, error)
-	ListCollectionTasks(ctx context.Context, status string, limit, offset int) ([]*model.CollectionTask, error)
-	CountCollectionTasks(ctx context.Context, status string) (int64, error)
+	// ListCollectionTasks 按创建时间筛选 [startTime, endTime]，两端均包含，零值表示不限
+	ListCollectionTasks(ctx context.Context, status string, startTime, endTime time.Time, limit, offset int) ([]*model.CollectionTask, error)
+	CountCollectionTasks(ctx context.Context, status string, startTime, endTime time.Time) (int64, error)
 	UpdateTaskProgress(ctx context.Context, taskID string, progress int, collectedCount int) error
+	IncrementTaskProgress(ctx context.Context, taskID string, delta int, maxCount int) error
+	UpdateTaskCheckpoint(ctx context.Context, taskID string, offset int64) error
 	UpdateTaskStatus(ctx context.Context, taskID string, status string, errorMessage string) error
+	ClaimCollectionTask(ctx context.Context, taskID, instanceID string, now, leaseUntil time.Time) (bool, error)
+	RenewTaskLease(ctx context.Context, taskID, instanceID string, leaseUntil time.Time) (bool, error)
+	ListClaimableTasks(ctx context.Context, now time.Time, limit int) ([]*model.CollectionTask, error)
+	ResetFailedTask(ctx context.Context, taskID string) (bool, error)
+
+	// CollectionSchedule 相关操作
+	CreateCollectionSchedule(ctx context.Context, schedule *model.CollectionSchedule) error
+	GetCollectionScheduleByID(ctx context.Context, id string) (*model.CollectionSchedule, error)
+	ListCollectionSchedules(ctx context.Context) ([]*model.CollectionSchedule, error)
+	ListDueCollectionSchedules(ctx context.Context, now time.Time) ([]*model.CollectionSchedule, error)
+	UpdateCollectionScheduleRun(ctx context.Context, id string, lastRunAt, nextRunAt time.Time) error
+	DeleteCollectionSchedule(ctx context.Context, id string) (bool, error)
 
 	// ProcessedText 相关操作
 	SaveProcessedText(ctx context.Context, text *model.ProcessedText) error
 	GetProcessedTextByID(ctx context.Context, id string) (*model.ProcessedText, error)
 	ListProcessedTexts(ctx context.Context, source string, limit, offset int) ([]*model.ProcessedText, error)
+	// ListProcessedTextsAfter 按 ID 升序返回 afterID 之后的一页，导出全量数据时逐页推进，不受并发写入造成的偏移影响
+	ListProcessedTextsAfter(ctx context.Context, filter ProcessedTextFilter, afterID string, limit int) ([]*model.ProcessedText, error)
 
 	// Model 相关操作
 	SaveModel(ctx context.Context, model *model.Model) error
@@ -64,6 +86,15 @@ type Repository interface {
 
 	// 健康检查
 	HealthCheck(ctx context.Context) error
+
+	// WithTransaction 在同一事务中执行 fn，fn 返回错误时整体回滚
+	WithTransaction(ctx context.Context, fn func(repo Repository) error) error
+}
+
+// ProcessedTextFilter 预处理文本的筛选条件，零值字段不参与筛选
+type ProcessedTextFilter struct {
+	Source string
+	Label  *int
 }
 
 // MySQLRepository MySQL数据库仓库实现
@@ -72,18 +103,33 @@ type MySQLRepository struct {
 }
 
 // NewMySQLRepository 创建MySQL仓库实例
-func NewMySQLRepository(dsn string) (*MySQLRepository, error) {
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+func NewMySQLRepository(cfg config.DatabaseConfig) (*MySQLRepository, error) {
+	db, err := gorm.Open(mysql.Open(cfg.DSN()), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// 配置连接池，避免默认不限连接数在高负载下耗尽 MySQL 连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	// 按仓库方法统计查询耗时并记录慢查询
+	if err := db.Use(newQueryMetrics(cfg.SlowQueryThreshold)); err != nil {
+		return nil, fmt.Errorf("failed to register query metrics: %w", err)
+	}
+
 	// 自动迁移数据库表
 	err = db.AutoMigrate(
 		&model.RawText{},
 		&model.CollectionTask{},
+		&model.CollectionSchedule{},
 		&model.ProcessedText{},
 		&model.Model{},
 		&model.AuditRecord{},
@@ -99,11 +145,26 @@ func NewMySQLRepository(dsn string) (*MySQLRepository, error) {
 	return &MySQLRepository{db: db}, nil
 }
 
+// WithTransaction 在 gorm 事务中执行 fn，传入的 Repository 绑定到该事务
+func (r *MySQLRepository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&MySQLRepository{db: tx})
+	})
+}
+
 // RawText 相关操作实现
 func (r *MySQLRepository) SaveRawText(ctx context.Context, text *model.RawText) error {
 	return r.db.WithContext(ctx).Create(text).Error
 }
 
+// SaveRawTexts 使用单条多值 INSERT 批量保存文本
+func (r *MySQLRepository) SaveRawTexts(ctx context.Context, texts []*model.RawText) error {
+	if len(texts) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(texts, len(texts)).Error
+}
+
 func (r *MySQLRepository) GetRawTextByID(ctx context.Context, id string) (*model.RawText, error) {
 	var text model.RawText
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(&text).Error
@@ -113,23 +174,42 @@ func (r *MySQLRepository) GetRawTextByID(ctx context.Context, id string) (*model
 	return &text, nil
 }
 
-func (r *MySQLRepository) ListRawTexts(ctx context.Context, source string, limit, offset int) ([]*model.RawText, error) {
+func (r *MySQLRepository) ListRawTexts(ctx context.Context, filter RawTextFilter, limit, offset int) ([]*model.RawText, error) {
 	var texts []*model.RawText
-	query := r.db.WithContext(ctx)
-	if source != "" {
-		query = query.Where("source = ?", source)
+	query, err := filter.apply(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
 	}
-	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&texts).Error
+	err = query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&texts).Error
 	return texts, err
 }
 
-func (r *MySQLRepository) CountRawTexts(ctx context.Context, source string) (int64, error) {
+func (r *MySQLRepository) ListRawTextsBefore(ctx context.Context, filter RawTextFilter, cursor *RawTextCursor, limit int) ([]*model.RawText, *RawTextCursor, error) {
+	var texts []*model.RawText
+	query, err := filter.apply(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	if cursor != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	if err := query.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&texts).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(texts) < limit {
+		return texts, nil, nil
+	}
+	last := texts[len(texts)-1]
+	return texts, &RawTextCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+func (r *MySQLRepository) CountRawTexts(ctx context.Context, filter RawTextFilter) (int64, error) {
 	var count int64
-	query := r.db.WithContext(ctx).Model(&model.RawText{})
-	if source != "" {
-		query = query.Where("source = ?", source)
+	query, err := filter.apply(r.db.WithContext(ctx).Model(&model.RawText{}))
+	if err != nil {
+		return 0, err
 	}
-	err := query.Count(&count).Error
+	err = query.Count(&count).Error
 	return count, err
 }
 
@@ -147,6 +227,8 @@ func (r *MySQLRepository) UpdateCollectionTask(ctx context.Context, task *model.
 		"error_message":   task.ErrorMessage,
 		"start_time":      task.StartTime,
 		"end_time":        task.EndTime,
+		"attempts":        task.Attempts,
+		"retry_at":        task.RetryAt,
 		"updated_at":      time.Now(),
 	}
 	
@@ -175,22 +257,34 @@ func (r *MySQLRepository) GetCollectionTaskByID(ctx context.Context, id string)
 	return &task, nil
 }
 
-func (r *MySQLRepository) ListCollectionTasks(ctx context.Context, status string, limit, offset int) ([]*model.CollectionTask, error) {
+func (r *MySQLRepository) ListCollectionTasks(ctx context.Context, status string, startTime, endTime time.Time, limit, offset int) ([]*model.CollectionTask, error) {
 	var tasks []*model.CollectionTask
 	query := r.db.WithContext(ctx)
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if !startTime.IsZero() {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("created_at <= ?", endTime)
+	}
 	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&tasks).Error
 	return tasks, err
 }
 
-func (r *MySQLRepository) CountCollectionTasks(ctx context.Context, status string) (int64, error) {
+func (r *MySQLRepository) CountCollectionTasks(ctx context.Context, status string, startTime, endTime time.Time) (int64, error) {
 	var count int64
 	query := r.db.WithContext(ctx).Model(&model.CollectionTask{})
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if !startTime.IsZero() {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("created_at <= ?", endTime)
+	}
 	err := query.Count(&count).Error
 	return count, err
 }
@@ -204,6 +298,96 @@ func (r *MySQLRepository) UpdateTaskProgress(ctx context.Context, taskID string,
 		}).Error
 }
 
+// IncrementTaskProgress 在数据库中累加已采集数量并按 maxCount 重新计算进度，多个写入方并发调用时计数不会丢失；
+// maxCount 为 0 时不更新进度
+func (r *MySQLRepository) IncrementTaskProgress(ctx context.Context, taskID string, delta int, maxCount int) error {
+	updates := map[string]interface{}{
+		"collected_count": gorm.Expr("collected_count + ?", delta),
+	}
+	if maxCount > 0 {
+		updates["progress"] = gorm.Expr("(collected_count + ?) * 100 / ?", delta, maxCount)
+	}
+	return r.db.WithContext(ctx).Model(&model.CollectionTask{}).
+		Where("id = ?", taskID).
+		Updates(updates).Error
+}
+
+// UpdateTaskCheckpoint 记录文件采集的断点偏移，只增不减，多个写入方乱序提交时不会回退
+func (r *MySQLRepository) UpdateTaskCheckpoint(ctx context.Context, taskID string, offset int64) error {
+	return r.db.WithContext(ctx).Model(&model.CollectionTask{}).
+		Where("id = ? AND resume_offset < ?", taskID, offset).
+		Update("resume_offset", offset).Error
+}
+
+// ClaimCollectionTask 以条件更新认领任务：仅当任务仍待执行，或运行中但租约已过期时成功，
+// 多个实例并发认领同一任务时只有一个返回 true
+func (r *MySQLRepository) ClaimCollectionTask(ctx context.Context, taskID, instanceID string, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.CollectionTask{}).
+		Where("id = ?", taskID).
+		Where("status = ? OR (status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?))",
+			model.TaskStatusPending, model.TaskStatusRunning, now).
+		Updates(map[string]interface{}{
+			"status":           model.TaskStatusRunning,
+			"claimed_by":       instanceID,
+			"lease_expires_at": leaseUntil,
+			"updated_at":       now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RenewTaskLease 为 instanceID 仍持有的运行中任务续约，返回 false 表示任务已结束或已被其他实例接管
+func (r *MySQLRepository) RenewTaskLease(ctx context.Context, taskID, instanceID string, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.CollectionTask{}).
+		Where("id = ? AND claimed_by = ? AND status = ?", taskID, instanceID, model.TaskStatusRunning).
+		Update("lease_expires_at", leaseUntil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListClaimableTasks 列出待执行或租约已过期的运行中任务，按优先级从高到低、创建时间先后排序；
+// 等待自动重试的任务到达 RetryAt 后才会列出
+func (r *MySQLRepository) ListClaimableTasks(ctx context.Context, now time.Time, limit int) ([]*model.CollectionTask, error) {
+	var tasks []*model.CollectionTask
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?))",
+			model.TaskStatusPending, model.TaskStatusRunning, now).
+		Where("retry_at IS NULL OR retry_at <= ?", now).
+		Order("priority DESC").
+		Order("created_at").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ResetFailedTask 将失败的任务重置为待执行并清空上次运行的进度与认领信息，
+// 任务不存在或不是失败状态时返回 false，并发重试同一任务时只有一个成功
+func (r *MySQLRepository) ResetFailedTask(ctx context.Context, taskID string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.CollectionTask{}).
+		Where("id = ? AND status = ?", taskID, model.TaskStatusFailed).
+		Updates(map[string]interface{}{
+			"status":           model.TaskStatusPending,
+			"collected_count":  0,
+			"progress":         0,
+			"resume_offset":    0,
+			"error_message":    "",
+			"start_time":       nil,
+			"end_time":         nil,
+			"claimed_by":       "",
+			"lease_expires_at": nil,
+			"attempts":         0,
+			"retry_at":         nil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (r *MySQLRepository) UpdateTaskStatus(ctx context.Context, taskID string, status string, errorMessage string) error {
 	updates := map[string]interface{}{
 		"status": status,
@@ -222,6 +406,53 @@ func (r *MySQLRepository) UpdateTaskStatus(ctx context.Context, taskID string, s
 		Updates(updates).Error
 }
 
+// CollectionSchedule 相关操作实现
+func (r *MySQLRepository) CreateCollectionSchedule(ctx context.Context, schedule *model.CollectionSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *MySQLRepository) GetCollectionScheduleByID(ctx context.Context, id string) (*model.CollectionSchedule, error) {
+	var schedule model.CollectionSchedule
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *MySQLRepository) ListCollectionSchedules(ctx context.Context) ([]*model.CollectionSchedule, error) {
+	var schedules []*model.CollectionSchedule
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *MySQLRepository) ListDueCollectionSchedules(ctx context.Context, now time.Time) ([]*model.CollectionSchedule, error) {
+	var schedules []*model.CollectionSchedule
+	err := r.db.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *MySQLRepository) UpdateCollectionScheduleRun(ctx context.Context, id string, lastRunAt, nextRunAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.CollectionSchedule{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_run_at": lastRunAt,
+			"next_run_at": nextRunAt,
+		}).Error
+}
+
+// DeleteCollectionSchedule 删除计划，返回 false 表示计划不存在
+func (r *MySQLRepository) DeleteCollectionSchedule(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.CollectionSchedule{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // ProcessedText 相关操作实现
 func (r *MySQLRepository) SaveProcessedText(ctx context.Context, text *model.ProcessedText) error {
 	return r.db.WithContext(ctx).Create(text).Error
@@ -246,6 +477,19 @@ func (r *MySQLRepository) ListProcessedTexts(ctx context.Context, source string,
 	return texts, err
 }
 
+func (r *MySQLRepository) ListProcessedTextsAfter(ctx context.Context, filter ProcessedTextFilter, afterID string, limit int) ([]*model.ProcessedText, error) {
+	var texts []*model.ProcessedText
+	query := r.db.WithContext(ctx).Where("id > ?", afterID)
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.Label != nil {
+		query = query.Where("label = ?", *filter.Label)
+	}
+	err := query.Order("id ASC").Limit(limit).Find(&texts).Error
+	return texts, err
+}
+
 // Model 相关操作实现
 func (r *MySQLRepository) SaveModel(ctx context.Context, model *model.Model) error {
 	return r.db.WithContext(ctx).Create(model).Error
@@ -0,0 +1,492 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+)
+
+func newTestRepository(t *testing.T) *MySQLRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	// 内存数据库每个连接相互独立，限制为单连接保证事务内外看到同一份数据
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&model.RawText{}, &model.CollectionTask{}, &model.ProcessedText{}, &model.CollectionSchedule{}))
+	return &MySQLRepository{db: db}
+}
+
+func TestWithTransaction(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: "task-1", SourceType: "API", Config: "{}"}))
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := repo.WithTransaction(ctx, func(tx Repository) error {
+			require.NoError(t, tx.SaveRawText(ctx, &model.RawText{ID: "text-1", Content: "a", Source: "api", Timestamp: 1}))
+			require.NoError(t, tx.UpdateTaskProgress(ctx, "task-1", 50, 1))
+			return boom
+		})
+		assert.ErrorIs(t, err, boom)
+
+		count, err := repo.CountRawTexts(ctx, RawTextFilter{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		task, err := repo.GetCollectionTaskByID(ctx, "task-1")
+		require.NoError(t, err)
+		assert.Zero(t, task.CollectedCount)
+		assert.Zero(t, task.Progress)
+	})
+
+	t.Run("commits on success", func(t *testing.T) {
+		err := repo.WithTransaction(ctx, func(tx Repository) error {
+			if err := tx.SaveRawText(ctx, &model.RawText{ID: "text-2", Content: "b", Source: "api", Timestamp: 2}); err != nil {
+				return err
+			}
+			return tx.UpdateTaskProgress(ctx, "task-1", 10, 1)
+		})
+		require.NoError(t, err)
+
+		count, err := repo.CountRawTexts(ctx, RawTextFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		task, err := repo.GetCollectionTaskByID(ctx, "task-1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, task.CollectedCount)
+		assert.Equal(t, 10, task.Progress)
+	})
+}
+
+func TestSaveRawTextsIncrementsProgress(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: "task-1", SourceType: "API", Config: "{}"}))
+
+	texts := []*model.RawText{
+		{ID: "text-1", Content: "a", Source: "api", Timestamp: 1},
+		{ID: "text-2", Content: "b", Source: "api", Timestamp: 2},
+		{ID: "text-3", Content: "c", Source: "api", Timestamp: 3},
+	}
+	require.NoError(t, repo.SaveRawTexts(ctx, texts))
+	require.NoError(t, repo.SaveRawTexts(ctx, nil))
+	require.NoError(t, repo.IncrementTaskProgress(ctx, "task-1", 3, 4))
+	require.NoError(t, repo.IncrementTaskProgress(ctx, "task-1", 1, 4))
+
+	var count int64
+	require.NoError(t, repo.db.Model(&model.RawText{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+
+	task, err := repo.GetCollectionTaskByID(ctx, "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, task.CollectedCount)
+	assert.Equal(t, 100, task.Progress)
+}
+
+func TestUpdateTaskCheckpointNeverMovesBackwards(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: "task-1", SourceType: "LOCAL_FILE", Config: "{}"}))
+
+	require.NoError(t, repo.UpdateTaskCheckpoint(ctx, "task-1", 200))
+	require.NoError(t, repo.UpdateTaskCheckpoint(ctx, "task-1", 100))
+
+	task, err := repo.GetCollectionTaskByID(ctx, "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), task.ResumeOffset)
+}
+
+func TestListProcessedTextsAfter(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	normal, violation := 0, 1
+	for i, label := range []*int{&normal, &violation, &normal, nil, &normal} {
+		require.NoError(t, repo.SaveProcessedText(ctx, &model.ProcessedText{
+			ID:      fmt.Sprintf("text-%d", i),
+			Content: fmt.Sprintf("content %d", i),
+			Label:   label,
+			Source:  "zhihu",
+		}))
+	}
+	require.NoError(t, repo.SaveProcessedText(ctx, &model.ProcessedText{ID: "text-9", Content: "other", Label: &normal, Source: "web"}))
+
+	filter := ProcessedTextFilter{Source: "zhihu", Label: &normal}
+	page, err := repo.ListProcessedTextsAfter(ctx, filter, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, []string{"text-0", "text-2"}, []string{page[0].ID, page[1].ID})
+
+	page, err = repo.ListProcessedTextsAfter(ctx, filter, page[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "text-4", page[0].ID)
+
+	all, err := repo.ListProcessedTextsAfter(ctx, ProcessedTextFilter{}, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, all, 6)
+}
+
+func TestClaimCollectionTask(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: "task-1", SourceType: "API", Status: model.TaskStatusPending}))
+	now := time.Now()
+
+	// 并发认领只有一个实例成功
+	var wg sync.WaitGroup
+	var winners int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			claimed, err := repo.ClaimCollectionTask(ctx, "task-1", fmt.Sprintf("instance-%d", i), now, now.Add(time.Minute))
+			require.NoError(t, err)
+			if claimed {
+				atomic.AddInt32(&winners, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), winners)
+
+	task, err := repo.GetCollectionTaskByID(ctx, "task-1")
+	require.NoError(t, err)
+	owner := task.ClaimedBy
+	assert.Equal(t, model.TaskStatusRunning, task.Status)
+
+	// 租约有效期内无法被其他实例认领，也只有持有者能续约
+	claimed, err := repo.ClaimCollectionTask(ctx, "task-1", "other", now.Add(30*time.Second), now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	renewed, err := repo.RenewTaskLease(ctx, "task-1", "other", now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.False(t, renewed)
+	renewed, err = repo.RenewTaskLease(ctx, "task-1", owner, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, renewed)
+
+	// 租约过期后可被接管，原持有者续约失败
+	later := now.Add(3 * time.Minute)
+	claimable, err := repo.ListClaimableTasks(ctx, later, 10)
+	require.NoError(t, err)
+	assert.Len(t, claimable, 1)
+	claimed, err = repo.ClaimCollectionTask(ctx, "task-1", "other", later, later.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	renewed, err = repo.RenewTaskLease(ctx, "task-1", owner, later.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, renewed)
+}
+
+func TestResetFailedTask(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	endTime := time.Now()
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{
+		ID: "failed", SourceType: "API", Status: model.TaskStatusFailed,
+		CollectedCount: 3, Progress: 30, ErrorMessage: "boom", EndTime: &endTime, ClaimedBy: "instance-1", ResumeOffset: 42,
+	}))
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: "running", SourceType: "API", Status: model.TaskStatusRunning}))
+
+	reset, err := repo.ResetFailedTask(ctx, "failed")
+	require.NoError(t, err)
+	assert.True(t, reset)
+
+	task, err := repo.GetCollectionTaskByID(ctx, "failed")
+	require.NoError(t, err)
+	assert.Equal(t, model.TaskStatusPending, task.Status)
+	assert.Zero(t, task.CollectedCount)
+	assert.Zero(t, task.ResumeOffset)
+	assert.Empty(t, task.ErrorMessage)
+	assert.Nil(t, task.EndTime)
+	assert.Empty(t, task.ClaimedBy)
+
+	// 只有失败的任务可以重置，重复重置不会成功
+	reset, err = repo.ResetFailedTask(ctx, "failed")
+	require.NoError(t, err)
+	assert.False(t, reset)
+	reset, err = repo.ResetFailedTask(ctx, "running")
+	require.NoError(t, err)
+	assert.False(t, reset)
+}
+
+func TestDeleteCollectionSchedule(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.CreateCollectionSchedule(ctx, &model.CollectionSchedule{ID: "nightly", CronExpr: "0 2 * * *", SourceType: "API", Config: "{}"}))
+
+	deleted, err := repo.DeleteCollectionSchedule(ctx, "nightly")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	// 不存在或已删除的计划返回 false
+	deleted, err = repo.DeleteCollectionSchedule(ctx, "nightly")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestListClaimableTasksWaitsForRetryAt(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+	require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: "retrying", SourceType: "API", Status: model.TaskStatusPending, RetryAt: &retryAt}))
+
+	tasks, err := repo.ListClaimableTasks(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	tasks, err = repo.ListClaimableTasks(ctx, retryAt.Add(time.Second), 10)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 1)
+}
+
+// seedRawTexts 写入 count 条原始文本，每两条共用一个创建时间以覆盖时间相同的情况
+func seedRawTexts(t *testing.T, repo *MySQLRepository, base time.Time, count int) []string {
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("seed-%02d", i)
+		require.NoError(t, repo.SaveRawText(context.Background(), &model.RawText{
+			ID:        ids[i],
+			Content:   fmt.Sprintf("content %d", i),
+			Source:    "web",
+			CreatedAt: base.Add(time.Duration(i/2) * time.Second),
+		}))
+	}
+	return ids
+}
+
+func TestListRawTextsBeforeMatchesOffsetPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	seedRawTexts(t, repo, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 11)
+	require.NoError(t, repo.SaveRawText(ctx, &model.RawText{ID: "other", Content: "x", Source: "api", CreatedAt: time.Now().UTC()}))
+
+	var cursor *RawTextCursor
+	for offset := 0; ; offset += 4 {
+		page, next, err := repo.ListRawTextsBefore(ctx, RawTextFilter{Source: "web"}, cursor, 4)
+		require.NoError(t, err)
+		offsetPage, err := repo.ListRawTexts(ctx, RawTextFilter{Source: "web"}, 4, offset)
+		require.NoError(t, err)
+		require.Len(t, page, len(offsetPage))
+		for i := range page {
+			assert.Equal(t, offsetPage[i].CreatedAt.Unix(), page[i].CreatedAt.Unix())
+		}
+		if next == nil {
+			assert.Len(t, page, 3)
+			break
+		}
+		cursor = next
+	}
+}
+
+func TestListRawTextsBeforeIsStableUnderInserts(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seeded := seedRawTexts(t, repo, base, 20)
+
+	// 每读取一页后写入两条更新的文本，模拟翻页期间的并发采集
+	inserted := 0
+	insertNewer := func() {
+		for i := 0; i < 2; i++ {
+			require.NoError(t, repo.SaveRawText(ctx, &model.RawText{
+				ID:        fmt.Sprintf("new-%02d", inserted),
+				Content:   "new",
+				Source:    "web",
+				CreatedAt: base.Add(time.Hour + time.Duration(inserted)*time.Second),
+			}))
+			inserted++
+		}
+	}
+
+	var keyset []string
+	var cursor *RawTextCursor
+	for {
+		page, next, err := repo.ListRawTextsBefore(ctx, RawTextFilter{Source: "web"}, cursor, 6)
+		require.NoError(t, err)
+		for _, text := range page {
+			keyset = append(keyset, text.ID)
+		}
+		if next != nil {
+			encoded, err := ParseRawTextCursor(next.Encode())
+			require.NoError(t, err)
+			cursor = encoded
+		}
+		insertNewer()
+		if next == nil {
+			break
+		}
+	}
+
+	// 键集分页按创建时间与 ID 倒序恰好返回每条初始数据一次
+	expected := make([]string, len(seeded))
+	for i, id := range seeded {
+		expected[len(seeded)-1-i] = id
+	}
+	assert.Equal(t, expected, keyset)
+
+	// 同样的写入下偏移分页会重复返回已读过的文本
+	var offsetIDs []string
+	seen := make(map[string]int)
+	for offset := 0; offset < len(seeded); offset += 6 {
+		page, err := repo.ListRawTexts(ctx, RawTextFilter{Source: "web"}, 6, offset)
+		require.NoError(t, err)
+		for _, text := range page {
+			offsetIDs = append(offsetIDs, text.ID)
+			seen[text.ID]++
+		}
+		insertNewer()
+	}
+	duplicated := 0
+	for _, n := range seen {
+		if n > 1 {
+			duplicated++
+		}
+	}
+	assert.Positive(t, duplicated, "offset pagination should repeat rows shifted by inserts: %v", offsetIDs)
+}
+
+func TestParseRawTextCursor(t *testing.T) {
+	cursor := RawTextCursor{CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.UTC), ID: "text:1"}
+	parsed, err := ParseRawTextCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+	assert.Equal(t, "text:1", parsed.ID)
+
+	for _, invalid := range []string{"%%%", "bm8tc2VwYXJhdG9y", "YWJjOnRleHQ"} {
+		_, err := ParseRawTextCursor(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestListRawTextsFiltersByMetadata(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, text := range []*model.RawText{
+		{ID: "answer", Source: "zhihu:answer", Platform: "zhihu", Type: "answer", Metadata: `{"tag":"hot"}`},
+		{ID: "question", Source: "zhihu:question", Platform: "zhihu", Type: "question", Metadata: `{}`},
+		{ID: "comment", Source: "bilibili:comment", Platform: "bilibili", Type: "answer", Metadata: `{"tag":"new"}`},
+		{ID: "plain", Source: "web", URL: "https://example.com", Metadata: `{}`},
+		{ID: "empty", Source: "web", Metadata: `{}`},
+	} {
+		text.Content = text.ID
+		require.NoError(t, repo.SaveRawText(ctx, text))
+	}
+
+	ids := func(filter RawTextFilter) []string {
+		texts, err := repo.ListRawTexts(ctx, filter, 10, 0)
+		require.NoError(t, err)
+		var ids []string
+		for _, text := range texts {
+			ids = append(ids, text.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	assert.Equal(t, []string{"answer"}, ids(RawTextFilter{Metadata: map[string]string{"platform": "zhihu", "type": "answer"}}))
+	assert.Equal(t, []string{"answer", "question"}, ids(RawTextFilter{Metadata: map[string]string{"platform": "zhihu"}}))
+	assert.Equal(t, []string{"answer", "comment"}, ids(RawTextFilter{Metadata: map[string]string{"type": "answer"}}))
+	assert.Empty(t, ids(RawTextFilter{Source: "web", Metadata: map[string]string{"type": "answer"}}))
+	// 未提升为独立列的键按 JSON 路径筛选
+	assert.Equal(t, []string{"answer"}, ids(RawTextFilter{Metadata: map[string]string{"tag": "hot", "platform": "zhihu"}}))
+	assert.Equal(t, []string{"plain"}, ids(RawTextFilter{Metadata: map[string]string{"url": "https://example.com"}}))
+
+	count, err := repo.CountRawTexts(ctx, RawTextFilter{Metadata: map[string]string{"platform": "zhihu"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	page, _, err := repo.ListRawTextsBefore(ctx, RawTextFilter{Metadata: map[string]string{"platform": "bilibili"}}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "comment", page[0].ID)
+
+	_, err = repo.ListRawTexts(ctx, RawTextFilter{Metadata: map[string]string{"platform') = 'x' OR 1=1 --": "x"}}, 10, 0)
+	assert.Error(t, err)
+}
+
+func TestListByCreatedAtRangeIsInclusive(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		createdAt := base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, repo.SaveRawText(ctx, &model.RawText{ID: fmt.Sprintf("text-%d", i), Content: "a", Source: "web", Metadata: `{}`, CreatedAt: createdAt}))
+		require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: fmt.Sprintf("task-%d", i), SourceType: "WEB", Config: "{}", CreatedAt: createdAt}))
+	}
+
+	textIDs := func(filter RawTextFilter) []string {
+		texts, err := repo.ListRawTexts(ctx, filter, 10, 0)
+		require.NoError(t, err)
+		var ids []string
+		for _, text := range texts {
+			ids = append(ids, text.ID)
+		}
+		return ids
+	}
+	taskIDs := func(start, end time.Time) []string {
+		tasks, err := repo.ListCollectionTasks(ctx, "", start, end, 10, 0)
+		require.NoError(t, err)
+		var ids []string
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	// 起止时间恰好等于记录的创建时间时两端都包含
+	start, end := base.Add(time.Hour), base.Add(3*time.Hour)
+	assert.Equal(t, []string{"text-3", "text-2", "text-1"}, textIDs(RawTextFilter{StartTime: start, EndTime: end}))
+	assert.Equal(t, []string{"task-3", "task-2", "task-1"}, taskIDs(start, end))
+
+	// 偏离边界 1 纳秒即排除对应记录
+	start, end = base.Add(time.Hour+time.Nanosecond), base.Add(3*time.Hour-time.Nanosecond)
+	assert.Equal(t, []string{"text-2"}, textIDs(RawTextFilter{StartTime: start, EndTime: end}))
+	assert.Equal(t, []string{"task-2"}, taskIDs(start, end))
+
+	// 只指定一端时另一端不限
+	assert.Equal(t, []string{"text-4", "text-3"}, textIDs(RawTextFilter{StartTime: base.Add(3 * time.Hour)}))
+	assert.Equal(t, []string{"task-1", "task-0"}, taskIDs(time.Time{}, base.Add(time.Hour)))
+
+	count, err := repo.CountCollectionTasks(ctx, "", base, base)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = repo.ListRawTexts(ctx, RawTextFilter{StartTime: end, EndTime: start}, 10, 0)
+	assert.Error(t, err)
+}
+
+func TestListCollectionTasksFiltersByStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	statuses := []string{model.TaskStatusPending, model.TaskStatusRunning, model.TaskStatusCompleted, model.TaskStatusFailed, model.TaskStatusCancelled, model.TaskStatusPaused}
+	for i, status := range statuses {
+		require.NoError(t, repo.CreateCollectionTask(ctx, &model.CollectionTask{ID: fmt.Sprintf("task-%d", i), SourceType: "WEB", Config: "{}", Status: status}))
+	}
+
+	for i, status := range statuses {
+		tasks, err := repo.ListCollectionTasks(ctx, status, time.Time{}, time.Time{}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1, status)
+		assert.Equal(t, fmt.Sprintf("task-%d", i), tasks[0].ID)
+		assert.Equal(t, status, tasks[0].Status)
+
+		count, err := repo.CountCollectionTasks(ctx, status, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count, status)
+	}
+}
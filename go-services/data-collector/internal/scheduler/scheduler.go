@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+const (
+	// defaultInterval 检查到期计划的间隔
+	defaultInterval = 15 * time.Second
+	// lockTTL 单次触发锁的有效期，需覆盖多副本间的时钟偏差
+	lockTTL = 10 * time.Minute
+)
+
+// Clock 时间源，测试中可替换为假时钟
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Locker 分布式锁，保证同一计划在多副本部署中只被触发一次
+type Locker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// RedisLocker 基于 Redis SETNX 的锁实现
+type RedisLocker struct {
+	client *redis.Client
+}
+
+// NewRedisLocker 创建Redis锁
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+// TryLock 尝试获取锁，已被其他副本持有时返回 false
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, key, time.Now().Unix(), ttl).Result()
+}
+
+// Store 调度计划存储
+type Store interface {
+	CreateCollectionSchedule(ctx context.Context, schedule *model.CollectionSchedule) error
+	ListCollectionSchedules(ctx context.Context) ([]*model.CollectionSchedule, error)
+	ListDueCollectionSchedules(ctx context.Context, now time.Time) ([]*model.CollectionSchedule, error)
+	UpdateCollectionScheduleRun(ctx context.Context, id string, lastRunAt, nextRunAt time.Time) error
+	DeleteCollectionSchedule(ctx context.Context, id string) (bool, error)
+}
+
+// ErrScheduleNotFound 计划不存在
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// TriggerFunc 计划到期时执行的采集动作
+type TriggerFunc func(ctx context.Context, req *pb.CollectRequest) error
+
+// Scheduler 定时采集调度器
+type Scheduler struct {
+	store    Store
+	locker   Locker
+	trigger  TriggerFunc
+	clock    Clock
+	interval time.Duration
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(store Store, locker Locker, trigger TriggerFunc) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		locker:   locker,
+		trigger:  trigger,
+		clock:    realClock{},
+		interval: defaultInterval,
+	}
+}
+
+// SetClock 替换时间源
+func (s *Scheduler) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// CreateSchedule 校验 cron 表达式并保存计划
+func (s *Scheduler) CreateSchedule(ctx context.Context, name, cronExpr string, req *pb.CollectRequest) (*model.CollectionSchedule, error) {
+	sched, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", cronExpr, err)
+	}
+	if req.Source == nil {
+		return nil, fmt.Errorf("collection source is required")
+	}
+
+	paramBytes, err := json.Marshal(req.Source.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal parameters: %w", err)
+	}
+	configBytes, err := json.Marshal(req.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	nextRunAt := sched.Next(s.clock.Now())
+	schedule := &model.CollectionSchedule{
+		ID:             uuid.New().String(),
+		Name:           name,
+		CronExpr:       cronExpr,
+		SourceType:     req.Source.Type.String(),
+		SourceURL:      req.Source.Url,
+		SourceFilePath: req.Source.FilePath,
+		Parameters:     string(paramBytes),
+		Config:         string(configBytes),
+		Enabled:        true,
+		NextRunAt:      &nextRunAt,
+	}
+
+	if err := s.store.CreateCollectionSchedule(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to save schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// ListSchedules 列出所有计划
+func (s *Scheduler) ListSchedules(ctx context.Context) ([]*model.CollectionSchedule, error) {
+	return s.store.ListCollectionSchedules(ctx)
+}
+
+// DeleteSchedule 删除计划，计划不存在时返回 ErrScheduleNotFound
+func (s *Scheduler) DeleteSchedule(ctx context.Context, id string) error {
+	deleted, err := s.store.DeleteCollectionSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrScheduleNotFound, id)
+	}
+	return nil
+}
+
+// Run 周期性检查并触发到期计划，直到 ctx 被取消
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	logrus.WithField("interval", s.interval).Info("Collection scheduler started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Collection scheduler stopped")
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue 触发所有已到期的计划，返回本副本实际触发的数量
+func (s *Scheduler) RunDue(ctx context.Context) int {
+	now := s.clock.Now()
+
+	schedules, err := s.store.ListDueCollectionSchedules(ctx, now)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list due schedules")
+		return 0
+	}
+
+	fired := 0
+	for _, schedule := range schedules {
+		if s.fire(ctx, schedule, now) {
+			fired++
+		}
+	}
+	return fired
+}
+
+func (s *Scheduler) fire(ctx context.Context, schedule *model.CollectionSchedule, now time.Time) bool {
+	logger := logrus.WithFields(logrus.Fields{
+		"schedule_id": schedule.ID,
+		"cron_expr":   schedule.CronExpr,
+	})
+
+	sched, err := cron.ParseStandard(schedule.CronExpr)
+	if err != nil {
+		logger.WithError(err).Error("Invalid cron expression in stored schedule")
+		return false
+	}
+
+	// 锁键包含本次应触发的时间点，不同副本对同一次触发只会有一个拿到锁
+	lockKey := fmt.Sprintf("collection_schedule:%s:%d", schedule.ID, schedule.NextRunAt.Unix())
+	locked, err := s.locker.TryLock(ctx, lockKey, lockTTL)
+	if err != nil {
+		// 锁不可用（如Redis断开）时不推进下次触发时间，下次检查时重试
+		logger.WithError(err).Warn("Schedule lock unavailable, will retry on next check")
+		return false
+	}
+	if !locked {
+		logger.Debug("Schedule already fired by another replica")
+		return false
+	}
+
+	nextRunAt := sched.Next(now)
+	if err := s.store.UpdateCollectionScheduleRun(ctx, schedule.ID, now, nextRunAt); err != nil {
+		logger.WithError(err).Error("Failed to update schedule run time")
+		return false
+	}
+
+	req, err := buildCollectRequest(schedule)
+	if err != nil {
+		logger.WithError(err).Error("Failed to build collect request from schedule")
+		return false
+	}
+
+	if err := s.trigger(ctx, req); err != nil {
+		logger.WithError(err).Error("Scheduled collection failed to start")
+		return false
+	}
+
+	logger.WithField("next_run_at", nextRunAt).Info("Scheduled collection triggered")
+	return true
+}
+
+func buildCollectRequest(schedule *model.CollectionSchedule) (*pb.CollectRequest, error) {
+	sourceType, ok := pb.SourceType_value[schedule.SourceType]
+	if !ok {
+		return nil, fmt.Errorf("unknown source type: %s", schedule.SourceType)
+	}
+
+	source := &pb.CollectionSource{
+		Type:     pb.SourceType(sourceType),
+		Url:      schedule.SourceURL,
+		FilePath: schedule.SourceFilePath,
+	}
+	if schedule.Parameters != "" {
+		if err := json.Unmarshal([]byte(schedule.Parameters), &source.Parameters); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+		}
+	}
+
+	config := &pb.CollectionConfig{}
+	if schedule.Config != "" {
+		if err := json.Unmarshal([]byte(schedule.Config), config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	}
+
+	return &pb.CollectRequest{
+		Source: source,
+		Config: config,
+	}, nil
+}
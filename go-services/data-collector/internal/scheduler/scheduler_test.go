@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	schedules map[string]*model.CollectionSchedule
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{schedules: make(map[string]*model.CollectionSchedule)}
+}
+
+func (m *memoryStore) CreateCollectionSchedule(ctx context.Context, schedule *model.CollectionSchedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (m *memoryStore) ListCollectionSchedules(ctx context.Context) ([]*model.CollectionSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*model.CollectionSchedule
+	for _, s := range m.schedules {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) ListDueCollectionSchedules(ctx context.Context, now time.Time) ([]*model.CollectionSchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*model.CollectionSchedule
+	for _, s := range m.schedules {
+		if s.Enabled && s.NextRunAt != nil && !s.NextRunAt.After(now) {
+			copied := *s
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) UpdateCollectionScheduleRun(ctx context.Context, id string, lastRunAt, nextRunAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.schedules[id]
+	s.LastRunAt = &lastRunAt
+	s.NextRunAt = &nextRunAt
+	return nil
+}
+
+func (m *memoryStore) DeleteCollectionSchedule(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.schedules[id]; !ok {
+		return false, nil
+	}
+	delete(m.schedules, id)
+	return true, nil
+}
+
+type memoryLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] {
+		return false, nil
+	}
+	l.held[key] = true
+	return true, nil
+}
+
+// flakyLocker 在 down 为 true 时模拟 Redis 不可用
+type flakyLocker struct {
+	memoryLocker
+	down bool
+}
+
+func (l *flakyLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if l.down {
+		return false, errors.New("redis: connection refused")
+	}
+	return l.memoryLocker.TryLock(ctx, key, ttl)
+}
+
+func TestSchedulerRetriesWhenLockerUnavailable(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)}
+	store := newMemoryStore()
+	locker := &flakyLocker{memoryLocker: memoryLocker{held: make(map[string]bool)}, down: true}
+
+	triggered := 0
+	s := NewScheduler(store, locker, func(ctx context.Context, req *pb.CollectRequest) error {
+		triggered++
+		return nil
+	})
+	s.SetClock(clock)
+
+	schedule, err := s.CreateSchedule(ctx, "nightly", "0 2 * * *", &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API, Url: "https://example.com"},
+	})
+	require.NoError(t, err)
+	dueAt := *schedule.NextRunAt
+
+	// 锁不可用时不触发，也不跳过这次触发
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, 0, s.RunDue(ctx))
+	assert.Equal(t, 0, triggered)
+	assert.Equal(t, dueAt, *store.schedules[schedule.ID].NextRunAt)
+
+	// 恢复后下次检查补上触发
+	locker.down = false
+	clock.Advance(15 * time.Second)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, 1, triggered)
+	assert.True(t, store.schedules[schedule.ID].NextRunAt.After(dueAt))
+}
+
+func TestSchedulerFiresOnceAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)}
+	store := newMemoryStore()
+	locker := &memoryLocker{held: make(map[string]bool)}
+
+	var triggered []*pb.CollectRequest
+	trigger := func(ctx context.Context, req *pb.CollectRequest) error {
+		triggered = append(triggered, req)
+		return nil
+	}
+
+	replicaA := NewScheduler(store, locker, trigger)
+	replicaA.SetClock(clock)
+	replicaB := NewScheduler(store, locker, trigger)
+	replicaB.SetClock(clock)
+
+	schedule, err := replicaA.CreateSchedule(ctx, "nightly", "0 2 * * *", &pb.CollectRequest{
+		Source: &pb.CollectionSource{
+			Type:       pb.SourceType_WEB_CRAWLER,
+			Url:        "https://example.com",
+			Parameters: map[string]string{"follow_links": "true"},
+		},
+		Config: &pb.CollectionConfig{MaxCount: 50},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC), *schedule.NextRunAt)
+
+	// 未到触发时间
+	assert.Equal(t, 0, replicaA.RunDue(ctx))
+	assert.Empty(t, triggered)
+
+	// 到达触发时间，两个副本同时检查，只有一个触发
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, 1, replicaA.RunDue(ctx)+replicaB.RunDue(ctx))
+	require.Len(t, triggered, 1)
+	assert.Equal(t, pb.SourceType_WEB_CRAWLER, triggered[0].Source.Type)
+	assert.Equal(t, "https://example.com", triggered[0].Source.Url)
+	assert.Equal(t, "true", triggered[0].Source.Parameters["follow_links"])
+	assert.Equal(t, int32(50), triggered[0].Config.MaxCount)
+
+	stored := store.schedules[schedule.ID]
+	assert.Equal(t, clock.Now(), *stored.LastRunAt)
+	assert.Equal(t, time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC), *stored.NextRunAt)
+
+	// 同一天内再次检查不会重复触发
+	clock.Advance(time.Hour)
+	assert.Equal(t, 0, replicaA.RunDue(ctx)+replicaB.RunDue(ctx))
+	assert.Len(t, triggered, 1)
+
+	// 第二天再次触发
+	clock.Advance(23 * time.Hour)
+	assert.Equal(t, 1, replicaB.RunDue(ctx)+replicaA.RunDue(ctx))
+	assert.Len(t, triggered, 2)
+}
+
+func TestCreateScheduleRejectsInvalidCron(t *testing.T) {
+	s := NewScheduler(newMemoryStore(), &memoryLocker{held: make(map[string]bool)}, nil)
+
+	_, err := s.CreateSchedule(context.Background(), "bad", "not a cron", &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API},
+	})
+	assert.Error(t, err)
+}
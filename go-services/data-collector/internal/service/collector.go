@@ -3,12 +3,17 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 
 	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
@@ -26,6 +31,26 @@ type CollectorService struct {
 	collectors map[pb.SourceType]collector.Collector
 	tasks      map[string]*CollectionTask
 	tasksMutex sync.RWMutex
+	events     *taskEventHub
+	// instanceID 认领任务时写入数据库，多副本间以此区分任务归属
+	instanceID string
+
+	// 等待执行的任务队列及当前执行中的任务数
+	queueMutex sync.Mutex
+	pending    taskQueue
+	running    int
+	queueSeq   uint64
+
+	// quota 为空时不限制来源配额
+	quota SourceQuota
+	// seenStore 增量采集的已采集URL集合，为空时不支持增量采集
+	seenStore collector.SeenStore
+	// nearDuplicates 为空时不做近似去重
+	nearDuplicates NearDuplicateDetector
+
+	// ctx 为所有排队任务的根上下文，Shutdown 时取消
+	ctx      context.Context
+	shutdown context.CancelFunc
 }
 
 // GetRepository 获取repository实例
@@ -33,10 +58,16 @@ func (s *CollectorService) GetRepository() repository.Repository {
 	return s.repo
 }
 
+// CollectionTask 本实例中排队或执行中的任务。执行任务的协程与写入协程池并发修改状态字段，
+// 修改时持有 mu；其他协程通过 snapshot 读取
 type CollectionTask struct {
+	mu              sync.Mutex
 	ID              string
 	SourceType      pb.SourceType
 	Config          *pb.CollectionConfig
+	Priority        int32
+	Attempts        int32
+	RetryAt         *time.Time
 	Status          pb.CollectionStatus
 	CollectedCount  int32
 	TotalCount      int32
@@ -44,25 +75,92 @@ type CollectionTask struct {
 	StartTime       *time.Time
 	EndTime         *time.Time
 	ErrorMessage    string
+	// ResumeOffset 文件采集的断点偏移，重试或恢复时从此处继续读取
+	ResumeOffset    int64
 	cancelFunc      context.CancelFunc
+	request         *pb.CollectRequest // 自动重试时复用的原始请求
 }
 
-func NewCollectorService(cfg *config.Config) (*CollectorService, error) {
-	// 构建数据库DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Database.Username,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Database)
-	
+// snapshot 持锁复制任务的状态字段，用于状态查询、事件推送与写回数据库
+func (t *CollectionTask) snapshot() *CollectionTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &CollectionTask{
+		ID:             t.ID,
+		SourceType:     t.SourceType,
+		Config:         t.Config,
+		Priority:       t.Priority,
+		Attempts:       t.Attempts,
+		RetryAt:        t.RetryAt,
+		Status:         t.Status,
+		CollectedCount: t.CollectedCount,
+		TotalCount:     t.TotalCount,
+		Progress:       t.Progress,
+		StartTime:      t.StartTime,
+		EndTime:        t.EndTime,
+		ErrorMessage:   t.ErrorMessage,
+		ResumeOffset:   t.ResumeOffset,
+	}
+}
+
+// CollectorServiceOption 创建 CollectorService 时的可选配置
+type CollectorServiceOption func(*CollectorService)
+
+// WithRepository 使用指定的仓库，不再连接 MySQL
+func WithRepository(repo repository.Repository) CollectorServiceOption {
+	return func(s *CollectorService) {
+		s.repo = repo
+	}
+}
+
+// WithCollectors 使用指定的采集器替换默认采集器，未包含的来源类型视为不支持；
+// 测试中可注入 collector.MockCollector，无需访问网络
+func WithCollectors(collectors map[pb.SourceType]collector.Collector) CollectorServiceOption {
+	return func(s *CollectorService) {
+		s.collectors = collectors
+	}
+}
+
+func NewCollectorService(cfg *config.Config, opts ...CollectorServiceOption) (*CollectorService, error) {
+	instanceID := cfg.Collector.InstanceID
+	if instanceID == "" {
+		instanceID = defaultInstanceID()
+	}
+	s := &CollectorService{
+		config:     cfg,
+		tasks:      make(map[string]*CollectionTask),
+		events:     newTaskEventHub(),
+		instanceID: instanceID,
+	}
+	s.ctx, s.shutdown = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+
 	// 初始化数据库连接
-	repo, err := repository.NewMySQLRepository(dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create repository: %w", err)
+	if s.repo == nil {
+		repo, err := repository.NewMySQLRepository(cfg.Database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create repository: %w", err)
+		}
+		if err := repo.MigrateRawTextMetadata(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to migrate raw text metadata: %w", err)
+		}
+		s.repo = repo
 	}
 
-	// 初始化采集器
+	if s.collectors == nil {
+		collectors, err := newDefaultCollectors(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.collectors = collectors
+	}
+	return s, nil
+}
+
+// newDefaultCollectors 创建所有来源类型的采集器
+func newDefaultCollectors(cfg *config.Config) (map[pb.SourceType]collector.Collector, error) {
 	collectors := make(map[pb.SourceType]collector.Collector)
 	
 	// API 采集器
@@ -86,15 +184,148 @@ func NewCollectorService(cfg *config.Config) (*CollectorService, error) {
 	}
 	collectors[pb.SourceType_LOCAL_FILE] = fileCollector
 
-	return &CollectorService{
-		config:     cfg,
-		repo:       repo,
-		collectors: collectors,
-		tasks:      make(map[string]*CollectionTask),
-	}, nil
+	// B站评论/弹幕采集器
+	bilibiliCollector, err := collector.NewBilibiliCollector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bilibili collector: %w", err)
+	}
+	collectors[pb.SourceType_BILIBILI] = bilibiliCollector
+
+	// 外部数据库采集器
+	dbCollector, err := collector.NewDBCollector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database collector: %w", err)
+	}
+	collectors[pb.SourceType_DATABASE] = dbCollector
+
+	// 无头浏览器采集器，浏览器实例在首次使用时启动
+	chromeCollector, err := collector.NewChromeCollector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create browser collector: %w", err)
+	}
+	collectors[pb.SourceType_BROWSER] = chromeCollector
+
+	// GraphQL 接口采集器
+	graphQLCollector, err := collector.NewGraphQLCollector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create graphql collector: %w", err)
+	}
+	collectors[pb.SourceType_GRAPHQL] = graphQLCollector
+
+	// Twitter/X 推文采集器
+	twitterCollector, err := collector.NewTwitterCollector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create twitter collector: %w", err)
+	}
+	collectors[pb.SourceType_TWITTER] = twitterCollector
+
+	// Reddit 帖子/评论采集器
+	redditCollector, err := collector.NewRedditCollector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reddit collector: %w", err)
+	}
+	collectors[pb.SourceType_REDDIT] = redditCollector
+
+	return collectors, nil
+}
+
+// Shutdown 取消执行中的任务与进行中的数据库写入，不再启动排队的任务；
+// 被中断的任务不写回终态，租约过期后由其他实例恢复
+func (s *CollectorService) Shutdown() {
+	s.shutdown()
+}
+
+// shuttingDown 服务是否已调用 Shutdown
+func (s *CollectorService) shuttingDown() bool {
+	return s.ctx.Err() != nil
+}
+
+// Close 释放采集器持有的资源，如浏览器进程
+func (s *CollectorService) Close() {
+	for sourceType, c := range s.collectors {
+		if closer, ok := c.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil {
+				logrus.WithError(err).WithField("source_type", sourceType).Warn("Failed to close collector")
+			}
+		}
+	}
+}
+
+// SetSeenStore 为支持增量采集的采集器设置已采集URL存储
+func (s *CollectorService) SetSeenStore(store collector.SeenStore) {
+	s.seenStore = store
+	for _, c := range s.collectors {
+		if incremental, ok := c.(interface{ SetSeenStore(collector.SeenStore) }); ok {
+			incremental.SetSeenStore(store)
+		}
+	}
+}
+
+// SeenCount 返回采集源已记录的URL数量；url 不为空时同时返回该URL是否已记录
+func (s *CollectorService) SeenCount(ctx context.Context, sourceKey, url string) (int64, bool, error) {
+	if s.seenStore == nil {
+		return 0, false, status.Error(codes.FailedPrecondition, "incremental collection is not configured")
+	}
+	count, err := s.seenStore.Count(ctx, sourceKey)
+	if err != nil || url == "" {
+		return count, false, err
+	}
+	seen, err := s.seenStore.IsSeen(ctx, sourceKey, url)
+	return count, seen, err
+}
+
+// ResetSeen 清空采集源的已采集URL集合，下次增量采集将重新全量采集
+func (s *CollectorService) ResetSeen(ctx context.Context, sourceKey string) error {
+	if s.seenStore == nil {
+		return status.Error(codes.FailedPrecondition, "incremental collection is not configured")
+	}
+	return s.seenStore.Reset(ctx, sourceKey)
+}
+
+// SetSourceQuota 设置按来源的采集配额
+func (s *CollectorService) SetSourceQuota(quota SourceQuota) {
+	s.quota = quota
+}
+
+// SetNearDuplicateDetector 开启近似重复文本过滤
+func (s *CollectorService) SetNearDuplicateDetector(detector NearDuplicateDetector) {
+	s.nearDuplicates = detector
+}
+
+// QuotaUsage 返回各来源的配额用量，未设置配额时为空
+func (s *CollectorService) QuotaUsage(ctx context.Context) ([]QuotaUsage, error) {
+	if s.quota == nil {
+		return []QuotaUsage{}, nil
+	}
+	return s.quota.Usage(ctx)
+}
+
+// SetDomainLimiter 为发起 HTTP 请求的采集器设置跨实例共享的域名限速器
+func (s *CollectorService) SetDomainLimiter(limiter collector.DomainLimiter) {
+	for _, c := range s.collectors {
+		if limited, ok := c.(interface{ SetDomainLimiter(collector.DomainLimiter) }); ok {
+			limited.SetDomainLimiter(limiter)
+		}
+	}
 }
 
 func (s *CollectorService) CollectText(ctx context.Context, req *pb.CollectRequest) (*pb.CollectResponse, error) {
+	if req.Source == nil {
+		return nil, status.Error(codes.InvalidArgument, "source is required")
+	}
+	if req.Config == nil {
+		req.Config = &pb.CollectionConfig{}
+	}
+	if req.Config.RateLimit < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "rate_limit %d must not be negative", req.Config.RateLimit)
+	}
+	if req.Config.RateLimit == 0 {
+		req.Config.RateLimit = int32(s.config.Collector.RateLimit)
+	}
+	if req.Config.MinQuality < 0 || req.Config.MinQuality > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "min_quality %d must be between 0 and 100", req.Config.MinQuality)
+	}
+
 	taskID := uuid.New().String()
 	
 	logrus.Info("CollectText method called - DEBUG TEST")
@@ -111,12 +342,14 @@ func (s *CollectorService) CollectText(ctx context.Context, req *pb.CollectReque
 		ID:         taskID,
 		SourceType: req.Source.Type,
 		Config:     req.Config,
+		Priority:   req.Config.Priority,
 		Status:     pb.CollectionStatus_COLLECTION_PENDING,
 	}
 
 	s.tasksMutex.Lock()
 	s.tasks[taskID] = task
 	s.tasksMutex.Unlock()
+	s.events.publish(taskEventFrom(task))
 
 	// 保存任务到数据库
 	dbTask := &model.CollectionTask{
@@ -125,6 +358,7 @@ func (s *CollectorService) CollectText(ctx context.Context, req *pb.CollectReque
 		SourceURL:  req.Source.Url,
 		SourceFilePath: req.Source.FilePath,
 		Status:     pb.CollectionStatus_COLLECTION_PENDING.String(),
+		Priority:   int(req.Config.Priority),
 		StartTime:  nil, // 明确设置为nil，任务开始时会被设置
 		EndTime:    nil, // 明确设置为nil，任务结束时会被设置
 	}
@@ -135,6 +369,14 @@ func (s *CollectorService) CollectText(ctx context.Context, req *pb.CollectReque
 		logrus.WithError(err).Error("Failed to marshal config")
 	}
 	dbTask.Config = string(configBytes)
+	// 保存源参数，供其他实例恢复任务时还原采集请求；连接串、令牌等凭据不落库
+	if parameters := persistedSourceParameters(req.Source.Parameters); len(parameters) > 0 {
+		parameterBytes, err := json.Marshal(parameters)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to marshal source parameters")
+		}
+		dbTask.SourceParameters = string(parameterBytes)
+	}
 	
 	logrus.WithFields(logrus.Fields{
 		"task_id": taskID,
@@ -147,8 +389,8 @@ func (s *CollectorService) CollectText(ctx context.Context, req *pb.CollectReque
 		return nil, fmt.Errorf("failed to save collection task: %w", err)
 	}
 
-	// 异步执行采集任务 - 使用background context避免HTTP请求结束时任务被取消
-	go s.executeCollectionTask(context.Background(), task, req)
+	// 按优先级排队，执行中的任务数未达上限时立即开始
+	s.enqueueTask(task, req)
 
 	return &pb.CollectResponse{
 		TaskId:         taskID,
@@ -168,7 +410,7 @@ func (s *CollectorService) GetCollectionStatus(ctx context.Context, req *pb.Stat
 		dbTask, err := s.repo.GetCollectionTaskByID(ctx, req.TaskId)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
-				return nil, fmt.Errorf("task not found: %s", req.TaskId)
+				return nil, status.Errorf(codes.NotFound, "task not found: %s", req.TaskId)
 			}
 			return nil, fmt.Errorf("failed to get task: %w", err)
 		}
@@ -178,16 +420,19 @@ func (s *CollectorService) GetCollectionStatus(ctx context.Context, req *pb.Stat
 			Status:    parseCollectionStatus(dbTask.Status),
 			Progress:  int32(dbTask.Progress),
 			Message:   dbTask.ErrorMessage,
+			Attempts:  int32(dbTask.Attempts),
 			StartTime: func() int64 { if dbTask.StartTime != nil { return dbTask.StartTime.Unix() } else { return 0 } }(),
 			EndTime:   func() int64 { if dbTask.EndTime != nil { return dbTask.EndTime.Unix() } else { return 0 } }(),
 		}, nil
 	}
 
+	task = task.snapshot()
 	resp := &pb.StatusResponse{
 		TaskId:   task.ID,
 		Status:   task.Status,
 		Progress: task.Progress,
 		Message:  task.ErrorMessage,
+		Attempts: task.Attempts,
 	}
 
 	if task.StartTime != nil {
@@ -202,127 +447,193 @@ func (s *CollectorService) GetCollectionStatus(ctx context.Context, req *pb.Stat
 
 func (s *CollectorService) executeCollectionTask(ctx context.Context, task *CollectionTask, req *pb.CollectRequest) {
 	logrus.WithField("task_id", task.ID).Info("executeCollectionTask started")
+
+	// 多副本部署时先认领任务，只有认领成功的实例执行
+	claimed, err := s.claimTask(ctx, task.ID)
+	if err != nil {
+		s.handleTaskError(ctx, task, fmt.Errorf("failed to claim task: %w", err))
+		return
+	}
+	if !claimed {
+		logrus.WithFields(logrus.Fields{
+			"task_id":     task.ID,
+			"instance_id": s.instanceID,
+		}).Info("Task already claimed by another instance, skipping")
+		// 状态查询改为读取数据库中其他实例写入的进度
+		s.tasksMutex.Lock()
+		delete(s.tasks, task.ID)
+		s.tasksMutex.Unlock()
+		return
+	}
+	ActiveCollectionTasks.Inc()
+	defer ActiveCollectionTasks.Dec()
+	defer CollectionRate.DeleteLabelValues(task.ID)
+	task.mu.Lock()
+	task.Attempts++
+	task.RetryAt = nil
+	task.ErrorMessage = ""
+	task.mu.Unlock()
+	task.request = req
 	
-	// 创建可取消的上下文
+	// 创建可取消的上下文，配置了超时时间时到期自动取消，0 表示不限制
+	timeout := time.Duration(req.Config.GetTimeout()) * time.Second
 	taskCtx, cancel := context.WithCancel(ctx)
+	if timeout > 0 {
+		taskCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
 	task.cancelFunc = cancel
 	defer cancel()
 
+	// 定期续约；租约被其他实例接管后停止执行，且不再写回任务状态
+	var leaseLost atomic.Bool
+	go s.keepTaskLease(taskCtx, task.ID, func() {
+		leaseLost.Store(true)
+		cancel()
+	})
+
 	logrus.WithField("task_id", task.ID).Info("Context created")
 
 	// 更新任务状态为运行中
 	now := time.Now()
+	task.mu.Lock()
 	task.StartTime = &now
 	task.Status = pb.CollectionStatus_COLLECTION_RUNNING
+	task.mu.Unlock()
 	
 	logrus.WithFields(logrus.Fields{
 		"task_id": task.ID,
 		"config": task.Config,
 	}).Info("About to call updateTaskInDB")
 	
-	s.updateTaskInDB(task)
+	s.updateTaskInDB(taskCtx, task)
+	s.events.publish(taskEventFrom(task))
 
 	logrus.WithField("task_id", task.ID).Info("Collection task started")
 
 	// 获取对应的采集器
-	collector, exists := s.collectors[req.Source.Type]
+	sourceCollector, exists := s.collectors[req.Source.Type]
 	if !exists {
-		s.handleTaskError(task, fmt.Errorf("unsupported source type: %v", req.Source.Type))
+		s.handleTaskError(ctx, task, collector.Permanent(fmt.Errorf("unsupported source type: %v", req.Source.Type)))
 		return
 	}
 
-	// 执行采集
-	textChan := make(chan *pb.RawText, 100)
+	// 执行采集，采集结果由写入协程池批量写入数据库，慢速数据库不会直接阻塞采集器
+	textChan := make(chan *pb.RawText, s.config.Collector.TextBufferSize)
 	errorChan := make(chan error, 1)
+	collectReq := resumeRequest(task, req)
 
 	go func() {
 		defer close(textChan)
 		defer close(errorChan)
 		
-		err := collector.Collect(taskCtx, req.Source, req.Config, textChan)
+		err := sourceCollector.Collect(taskCtx, collectReq.Source, collectReq.Config, textChan)
 		if err != nil {
 			errorChan <- err
 		}
 	}()
 
-	// 处理采集结果
-	collectedCount := int32(0)
+	writers := startTextWriters(ctx, s.repo, task, req.Config.MaxCount,
+		s.config.Collector.WriterCount, s.config.Collector.WriteBatchSize, textChan, s.events.publish, s.quota, s.nearDuplicates, req.Config.GetMinQuality(), s.config.Collector.RedactPII, cancel)
+	writersDone := writers.Done()
+
+	// 超时后采集器返回的是 context 错误，统一记录为超时便于排查
+	fail := func(err error) {
+		if leaseLost.Load() {
+			logrus.WithField("task_id", task.ID).Warn("Task taken over by another instance, discarding result")
+			return
+		}
+		if s.shuttingDown() {
+			logrus.WithField("task_id", task.ID).Warn("Service shutting down, task left for recovery")
+			return
+		}
+		// 写入失败导致的提前结束按写入错误处理，可重试
+		if writeErr := writers.Err(); writeErr != nil {
+			s.handleTaskError(ctx, task, writeErr)
+			return
+		}
+		// 来源配额用尽导致的提前结束视为正常完成
+		if source := writers.QuotaReached(); source != "" {
+			task.mu.Lock()
+			task.ErrorMessage = fmt.Sprintf("quota reached: %s", source)
+			task.mu.Unlock()
+			s.completeTask(ctx, task, writers.Wait())
+			return
+		}
+		if errors.Is(taskCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timeout: collection exceeded %s", timeout)
+		}
+		s.handleTaskError(ctx, task, err)
+	}
+
+	// 等待采集结束；失败、超时或取消时先等写入协程写完已采集的文本，再记录最终计数
 	for {
 		select {
-		case text, ok := <-textChan:
-			if !ok {
-				// 采集完成
-				s.completeTask(task, collectedCount)
-				return
+		case <-writersDone:
+			// 采集器先关闭 errorChan 再关闭 textChan，此时读取不会阻塞
+			if errorChan != nil {
+				if err, ok := <-errorChan; ok {
+					fail(err)
+					return
+				}
 			}
-			
-			// 保存文本到数据库
-			if err := s.saveRawText(ctx, text); err != nil {
-				logrus.WithError(err).Error("Failed to save raw text")
-				continue
+			collected := writers.Wait()
+			if leaseLost.Load() {
+				fail(fmt.Errorf("lease lost"))
+				return
 			}
-			
-			collectedCount++
-			task.CollectedCount = collectedCount
-			
-			// 更新进度
-			if req.Config.MaxCount > 0 {
-				task.Progress = (collectedCount * 100) / req.Config.MaxCount
+			if err := writers.Err(); err != nil {
+				fail(err)
+				return
 			}
-			
-			// 定期更新数据库
-			if collectedCount%10 == 0 {
-				s.updateTaskInDB(task)
+			if source := writers.QuotaReached(); source != "" {
+				task.mu.Lock()
+				task.ErrorMessage = fmt.Sprintf("quota reached: %s", source)
+				task.mu.Unlock()
 			}
+			s.completeTask(ctx, task, collected)
+			return
 
-		case err := <-errorChan:
-			if err != nil {
-				s.handleTaskError(task, err)
-				return
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+				continue
 			}
+			cancel()
+			writers.Wait()
+			fail(err)
+			return
 
 		case <-taskCtx.Done():
-			s.handleTaskError(task, fmt.Errorf("task cancelled"))
+			writers.Wait()
+			fail(collector.Permanent(fmt.Errorf("task cancelled")))
 			return
 		}
 	}
 }
 
-func (s *CollectorService) saveRawText(ctx context.Context, text *pb.RawText) error {
-	// 保存到数据库
-	dbText := &model.RawText{
-		ID:        text.Id,
-		Content:   text.Content,
-		Source:    text.Source,
-		Timestamp: text.Timestamp,
-	}
-	
-	if len(text.Metadata) > 0 {
-		metadataBytes, _ := json.Marshal(text.Metadata)
-		dbText.Metadata = string(metadataBytes)
-	}
-	
-	if err := s.repo.SaveRawText(ctx, dbText); err != nil {
-		return fmt.Errorf("failed to save to database: %w", err)
-	}
-
-	// 发送到消息队列 (暂时注释掉，因为repository接口中没有PublishRawText方法)
-	// TODO: 实现消息队列发布功能
-	// if err := s.repo.PublishRawText(ctx, text); err != nil {
-	//     logrus.WithError(err).Error("Failed to publish to message queue")
-	// }
+// finalWriteTimeout 任务结束时写回最终状态的超时时间
+const finalWriteTimeout = 5 * time.Second
 
-	return nil
+// detachedContext 返回不随 ctx 取消但保留其中的值（请求ID、追踪信息）的上下文，最多等待 finalWriteTimeout。
+// 任务被取消或超时后仍须写回最终状态，不能使用已结束的任务上下文
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), finalWriteTimeout)
 }
 
-func (s *CollectorService) completeTask(task *CollectionTask, collectedCount int32) {
+func (s *CollectorService) completeTask(ctx context.Context, task *CollectionTask, collectedCount int32) {
+	ctx, cancel := detachedContext(ctx)
+	defer cancel()
+
 	now := time.Now()
+	task.mu.Lock()
 	task.EndTime = &now
 	task.Status = pb.CollectionStatus_COLLECTION_COMPLETED
 	task.CollectedCount = collectedCount
 	task.Progress = 100
+	task.mu.Unlock()
 
-	s.updateTaskInDB(task)
+	s.updateTaskInDB(ctx, task)
+	s.events.publish(taskEventFrom(task))
 	
 	logrus.WithFields(logrus.Fields{
 		"task_id":         task.ID,
@@ -331,23 +642,35 @@ func (s *CollectorService) completeTask(task *CollectionTask, collectedCount int
 	}).Info("Collection task completed")
 }
 
-func (s *CollectorService) handleTaskError(task *CollectionTask, err error) {
+func (s *CollectorService) handleTaskError(ctx context.Context, task *CollectionTask, err error) {
+	ctx, cancel := detachedContext(ctx)
+	defer cancel()
+
+	if s.scheduleRetry(ctx, task, err) {
+		return
+	}
+
 	now := time.Now()
+	task.mu.Lock()
 	task.EndTime = &now
 	task.Status = pb.CollectionStatus_COLLECTION_FAILED
 	task.ErrorMessage = err.Error()
+	task.mu.Unlock()
 
 	// 确保Config字段不为空，如果为空则从数据库获取原始配置
 	if task.Config == nil {
-		if dbTask, dbErr := s.repo.GetCollectionTaskByID(context.Background(), task.ID); dbErr == nil && dbTask.Config != "" {
+		if dbTask, dbErr := s.repo.GetCollectionTaskByID(ctx, task.ID); dbErr == nil && dbTask.Config != "" {
 			var config pb.CollectionConfig
 			if json.Unmarshal([]byte(dbTask.Config), &config) == nil {
+				task.mu.Lock()
 				task.Config = &config
+				task.mu.Unlock()
 			}
 		}
 	}
 
-	s.updateTaskInDB(task)
+	s.updateTaskInDB(ctx, task)
+	s.events.publish(taskEventFrom(task))
 	
 	logrus.WithFields(logrus.Fields{
 		"task_id": task.ID,
@@ -355,11 +678,13 @@ func (s *CollectorService) handleTaskError(task *CollectionTask, err error) {
 	}).Error("Collection task failed")
 }
 
-func (s *CollectorService) updateTaskInDB(task *CollectionTask) {
+// updateTaskInDB 将任务状态写回数据库，ctx 取消时写入随之取消
+func (s *CollectorService) updateTaskInDB(ctx context.Context, task *CollectionTask) {
 	logrus.WithField("task_id", task.ID).Info("updateTaskInDB called")
+	task = task.snapshot()
 	
 	// 先从数据库获取原始任务信息，避免覆盖其他字段
-	dbTask, err := s.repo.GetCollectionTaskByID(context.Background(), task.ID)
+	dbTask, err := s.repo.GetCollectionTaskByID(ctx, task.ID)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to get task from database for update")
 		return
@@ -377,6 +702,8 @@ func (s *CollectorService) updateTaskInDB(task *CollectionTask) {
 	dbTask.CollectedCount = int(task.CollectedCount)
 	dbTask.Progress = int(task.Progress)
 	dbTask.ErrorMessage = task.ErrorMessage
+	dbTask.Attempts = int(task.Attempts)
+	dbTask.RetryAt = task.RetryAt
 	
 	// 序列化配置 - 只有当task.Config不为nil时才更新config字段
 	if task.Config != nil {
@@ -409,22 +736,37 @@ func (s *CollectorService) updateTaskInDB(task *CollectionTask) {
 		"config": dbTask.Config,
 	}).Info("About to update task in DB")
 	
-	if err := s.repo.UpdateCollectionTask(context.Background(), dbTask); err != nil {
+	if err := s.repo.UpdateCollectionTask(ctx, dbTask); err != nil {
 		logrus.WithError(err).Error("Failed to update task in database")
 	}
 }
 
+// parseCollectionStatus 将数据库中的任务状态转换为枚举，无法识别时视为等待中
 func parseCollectionStatus(status string) pb.CollectionStatus {
-	switch status {
-	case "pending":
-		return pb.CollectionStatus_COLLECTION_PENDING
-	case "running":
-		return pb.CollectionStatus_COLLECTION_RUNNING
-	case "completed":
-		return pb.CollectionStatus_COLLECTION_COMPLETED
-	case "failed":
-		return pb.CollectionStatus_COLLECTION_FAILED
-	default:
-		return pb.CollectionStatus_COLLECTION_PENDING
+	if value, ok := lookupCollectionStatus(status); ok {
+		return value
+	}
+	return pb.CollectionStatus_COLLECTION_PENDING
+}
+
+// lookupCollectionStatus 按枚举名（COLLECTION_CANCELLED）或去掉前缀的短名（cancelled）查找状态，不区分大小写
+func lookupCollectionStatus(status string) (pb.CollectionStatus, bool) {
+	name := strings.ToUpper(strings.TrimSpace(status))
+	if name == "" {
+		return pb.CollectionStatus_COLLECTION_PENDING, false
+	}
+	if !strings.HasPrefix(name, "COLLECTION_") {
+		name = "COLLECTION_" + name
+	}
+	value, ok := pb.CollectionStatus_value[name]
+	return pb.CollectionStatus(value), ok
+}
+
+// NormalizeTaskStatus 将查询参数中的任务状态转换为数据库中存储的枚举名，无法识别时返回 false
+func NormalizeTaskStatus(status string) (string, bool) {
+	value, ok := lookupCollectionStatus(status)
+	if !ok {
+		return "", false
 	}
+	return value.String(), true
 }
\ No newline at end of file
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// slowCollector 每隔 interval 产出一条文本，直到 ctx 结束
+type slowCollector struct {
+	interval time.Duration
+}
+
+func (c *slowCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	for i := 0; ; i++ {
+		select {
+		case <-time.After(c.interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case textChan <- &pb.RawText{Id: fmt.Sprintf("text-%d", i), Content: "content", Source: "api"}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestExecuteCollectionTaskTimeout(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &slowCollector{interval: 20 * time.Millisecond}, 2, 5)
+
+	start := time.Now()
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 1000, Timeout: 1})
+
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+	assert.Contains(t, task.ErrorMessage, "timeout")
+
+	// 超时前采集到的文本已全部落库，部分计数写回数据库
+	assert.Positive(t, task.CollectedCount)
+	assert.Len(t, repo.texts, int(task.CollectedCount))
+	dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int(task.CollectedCount), dbTask.CollectedCount)
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED.String(), dbTask.Status)
+}
+
+func TestExecuteCollectionTaskWithoutTimeoutRunsToCompletion(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &sequenceCollector{count: 10}, 1, 5)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10, Timeout: 0})
+
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(10), task.CollectedCount)
+}
+
+func TestExecuteCollectionTaskWithMockCollector(t *testing.T) {
+	t.Run("progress and completion", func(t *testing.T) {
+		repo := newMemoryRepository("task-1")
+		mock := &collector.MockCollector{Texts: collector.MockTexts(30, "api")}
+		s := newTestCollectorService(repo, mock, 1, 10)
+
+		s.events.publish(TaskEvent{TaskID: "task-1", Status: pb.CollectionStatus_COLLECTION_PENDING.String()})
+		events, err := s.SubscribeTask(context.Background(), "task-1")
+		require.NoError(t, err)
+
+		task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 30})
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+		assert.Equal(t, int32(30), task.CollectedCount)
+		assert.Len(t, repo.texts, 30)
+		assert.Equal(t, 1, mock.Calls())
+
+		// 每写入一批推送一次进度，最后一个事件为完成
+		var progress []int32
+		var last TaskEvent
+		for ev := range events {
+			if ev.Status == pb.CollectionStatus_COLLECTION_RUNNING.String() && ev.CollectedCount > 0 {
+				progress = append(progress, ev.Progress)
+			}
+			last = ev
+		}
+		assert.Equal(t, []int32{33, 66, 100}, progress)
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED.String(), last.Status)
+		assert.Equal(t, int32(30), last.CollectedCount)
+	})
+
+	t.Run("error keeps collected texts", func(t *testing.T) {
+		repo := newMemoryRepository("task-1")
+		mock := &collector.MockCollector{Texts: collector.MockTexts(5, "api"), Err: fmt.Errorf("upstream closed")}
+		s := newTestCollectorService(repo, mock, 1, 10)
+
+		task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 100})
+
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+		assert.Equal(t, "upstream closed", task.ErrorMessage)
+		assert.Len(t, repo.texts, 5)
+		dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+		require.NoError(t, err)
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED.String(), dbTask.Status)
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		repo := newMemoryRepository("task-1")
+		mock := &collector.MockCollector{Texts: collector.MockTexts(3, "api"), Block: true}
+		s := newTestCollectorService(repo, mock, 1, 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		task := &CollectionTask{ID: "task-1", SourceType: pb.SourceType_API, Status: pb.CollectionStatus_COLLECTION_PENDING}
+		req := &pb.CollectRequest{Source: &pb.CollectionSource{Type: pb.SourceType_API}, Config: &pb.CollectionConfig{MaxCount: 100}}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.executeCollectionTask(ctx, task, req)
+		}()
+
+		// 文本全部落库后采集器阻塞，取消后任务以失败结束且不再重试
+		require.Eventually(t, func() bool {
+			dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+			return err == nil && dbTask.CollectedCount == 3
+		}, 2*time.Second, 5*time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("task did not stop after cancellation")
+		}
+
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+		assert.Equal(t, "task cancelled", task.ErrorMessage)
+		assert.Equal(t, int32(3), task.CollectedCount)
+		assert.Nil(t, task.RetryAt)
+	})
+
+	t.Run("unsupported source type", func(t *testing.T) {
+		repo := newMemoryRepository("task-1")
+		s := newTestCollectorService(repo, &collector.MockCollector{}, 1, 1)
+		task := &CollectionTask{ID: "task-1", SourceType: pb.SourceType_WEB_CRAWLER, Status: pb.CollectionStatus_COLLECTION_PENDING}
+		req := &pb.CollectRequest{Source: &pb.CollectionSource{Type: pb.SourceType_WEB_CRAWLER}, Config: &pb.CollectionConfig{MaxCount: 1}}
+
+		s.executeCollectionTask(context.Background(), task, req)
+
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+		assert.Contains(t, task.ErrorMessage, "unsupported source type")
+	})
+}
+
+// requestIDKey 测试用的请求级上下文键
+type requestIDKey struct{}
+
+// ctxRecordingRepository 记录每次写任务状态时的上下文及其写入时的状态；写入 RUNNING 状态时阻塞到 ctx 结束
+type ctxRecordingRepository struct {
+	*memoryRepository
+	blocked chan struct{}
+
+	mu     sync.Mutex
+	writes map[string]context.Context
+	errs   map[string]error
+}
+
+func (r *ctxRecordingRepository) UpdateCollectionTask(ctx context.Context, task *model.CollectionTask) error {
+	if task.Status == model.TaskStatusRunning {
+		close(r.blocked)
+		<-ctx.Done()
+	}
+	r.mu.Lock()
+	r.writes[task.Status] = ctx
+	r.errs[task.Status] = ctx.Err()
+	r.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.memoryRepository.UpdateCollectionTask(ctx, task)
+}
+
+func TestTaskStatusWritesFollowTaskContext(t *testing.T) {
+	repo := &ctxRecordingRepository{
+		memoryRepository: newMemoryRepository("task-1"),
+		blocked:          make(chan struct{}),
+		writes:           make(map[string]context.Context),
+		errs:             make(map[string]error),
+	}
+	s := newTestCollectorService(repo, &collector.MockCollector{Texts: collector.MockTexts(3, "api"), Block: true}, 1, 1)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), requestIDKey{}, "req-1"))
+	task := &CollectionTask{ID: "task-1", SourceType: pb.SourceType_API, Status: pb.CollectionStatus_COLLECTION_PENDING}
+	req := &pb.CollectRequest{Source: &pb.CollectionSource{Type: pb.SourceType_API}, Config: &pb.CollectionConfig{MaxCount: 100}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.executeCollectionTask(ctx, task, req)
+	}()
+
+	// 任务取消时进行中的写入随之取消，最终状态仍以脱离取消、带超时的上下文写回
+	select {
+	case <-repo.blocked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("running status was not written")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task did not stop after cancellation")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.ErrorIs(t, repo.errs[model.TaskStatusRunning], context.Canceled)
+	assert.Equal(t, "req-1", repo.writes[model.TaskStatusRunning].Value(requestIDKey{}))
+
+	final := repo.writes[model.TaskStatusFailed]
+	require.NotNil(t, final)
+	assert.NoError(t, repo.errs[model.TaskStatusFailed])
+	_, hasDeadline := final.Deadline()
+	assert.True(t, hasDeadline)
+	assert.Equal(t, "req-1", final.Value(requestIDKey{}))
+
+	dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.TaskStatusFailed, dbTask.Status)
+	assert.Equal(t, "task cancelled", dbTask.ErrorMessage)
+}
+
+func TestShutdownCancelsQueuedTasks(t *testing.T) {
+	repo := &ctxRecordingRepository{
+		memoryRepository: newMemoryRepository(),
+		blocked:          make(chan struct{}),
+		writes:           make(map[string]context.Context),
+		errs:             make(map[string]error),
+	}
+	c := &gateCollector{release: make(chan struct{})}
+	s := newTestCollectorService(repo, c, 1, 1)
+	s.config.Collector.MaxRunningTasks = 1
+
+	req := func() *pb.CollectRequest {
+		return &pb.CollectRequest{Source: &pb.CollectionSource{Type: pb.SourceType_API}, Config: &pb.CollectionConfig{MaxCount: 100}}
+	}
+	running, err := s.CollectText(context.Background(), req())
+	require.NoError(t, err)
+	waiting, err := s.CollectText(context.Background(), req())
+	require.NoError(t, err)
+
+	select {
+	case <-repo.blocked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("running status was not written")
+	}
+	s.Shutdown()
+
+	// 关闭时进行中的写入被取消，任务不写回终态，排队的任务不再启动
+	require.Eventually(t, func() bool {
+		s.queueMutex.Lock()
+		defer s.queueMutex.Unlock()
+		return s.running == 0
+	}, 2*time.Second, 5*time.Millisecond)
+
+	repo.mu.Lock()
+	assert.ErrorIs(t, repo.errs[model.TaskStatusRunning], context.Canceled)
+	assert.NotContains(t, repo.writes, model.TaskStatusFailed)
+	repo.mu.Unlock()
+
+	dbTask, err := repo.GetCollectionTaskByID(context.Background(), running.TaskId)
+	require.NoError(t, err)
+	assert.Equal(t, model.TaskStatusRunning, dbTask.Status)
+	dbTask, err = repo.GetCollectionTaskByID(context.Background(), waiting.TaskId)
+	require.NoError(t, err)
+	assert.Equal(t, model.TaskStatusPending, dbTask.Status)
+	assert.LessOrEqual(t, len(c.startedTasks()), 1)
+}
+
+func TestCollectionMetricsTrackConcurrentTasks(t *testing.T) {
+	repo := newMemoryRepository("task-1", "task-2")
+	s := newTestCollectorService(repo, &collector.MockCollector{Texts: collector.MockTexts(2, "api"), Block: true}, 1, 1)
+	activeBefore := testutil.ToFloat64(ActiveCollectionTasks)
+	collectedBefore := testutil.ToFloat64(TextsCollected.WithLabelValues(pb.SourceType_API.String()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, id := range []string{"task-1", "task-2"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			task := &CollectionTask{ID: id, SourceType: pb.SourceType_API, Status: pb.CollectionStatus_COLLECTION_PENDING}
+			s.executeCollectionTask(ctx, task, &pb.CollectRequest{
+				Source: &pb.CollectionSource{Type: pb.SourceType_API},
+				Config: &pb.CollectionConfig{MaxCount: 100},
+			})
+		}(id)
+	}
+
+	// 两个任务都已落库全部文本并阻塞在采集器中
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(TextsCollected.WithLabelValues(pb.SourceType_API.String())) == collectedBefore+4
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, activeBefore+2, testutil.ToFloat64(ActiveCollectionTasks))
+	assert.Equal(t, 2, testutil.CollectAndCount(CollectionRate))
+	assert.Positive(t, testutil.ToFloat64(CollectionRate.WithLabelValues("task-1")))
+
+	cancel()
+	wg.Wait()
+	assert.Equal(t, activeBefore, testutil.ToFloat64(ActiveCollectionTasks))
+	assert.Equal(t, 0, testutil.CollectAndCount(CollectionRate))
+}
+
+func TestCollectionStatusMapping(t *testing.T) {
+	tests := []struct {
+		stored string
+		status pb.CollectionStatus
+	}{
+		{model.TaskStatusPending, pb.CollectionStatus_COLLECTION_PENDING},
+		{model.TaskStatusRunning, pb.CollectionStatus_COLLECTION_RUNNING},
+		{model.TaskStatusCompleted, pb.CollectionStatus_COLLECTION_COMPLETED},
+		{model.TaskStatusFailed, pb.CollectionStatus_COLLECTION_FAILED},
+		{model.TaskStatusCancelled, pb.CollectionStatus_COLLECTION_CANCELLED},
+		{model.TaskStatusPaused, pb.CollectionStatus_COLLECTION_PAUSED},
+	}
+	require.Len(t, tests, len(pb.CollectionStatus_name), "every CollectionStatus needs a stored status")
+	for _, tt := range tests {
+		t.Run(tt.stored, func(t *testing.T) {
+			assert.Equal(t, tt.stored, tt.status.String())
+			assert.Equal(t, tt.status, parseCollectionStatus(tt.stored))
+
+			// 早期记录与查询参数使用的小写短名
+			short := strings.ToLower(strings.TrimPrefix(tt.stored, "COLLECTION_"))
+			assert.Equal(t, tt.status, parseCollectionStatus(short))
+			normalized, ok := NormalizeTaskStatus(short)
+			require.True(t, ok)
+			assert.Equal(t, tt.stored, normalized)
+		})
+	}
+
+	for _, unknown := range []string{"", "archived", "COLLECTION_"} {
+		assert.Equal(t, pb.CollectionStatus_COLLECTION_PENDING, parseCollectionStatus(unknown), unknown)
+		_, ok := NormalizeTaskStatus(unknown)
+		assert.False(t, ok, unknown)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// fileCheckpoint 跟踪文件采集已连续处理完的字节偏移。
+// 文本按读取顺序登记，写入协程处理完一批后标记完成；多个协程乱序完成时，
+// 断点只推进到最早一条尚未处理完的文本之前，续采时不会漏掉未保存的行
+type fileCheckpoint struct {
+	mu sync.Mutex
+	// pending 按读取顺序登记、尚未推进断点的偏移
+	pending []int64
+	done    map[int64]bool
+	offset  int64
+}
+
+func newFileCheckpoint(offset int64) *fileCheckpoint {
+	return &fileCheckpoint{done: make(map[int64]bool), offset: offset}
+}
+
+// track 按读取顺序登记 texts 中的文本后转发，texts 关闭后关闭返回的通道
+func (c *fileCheckpoint) track(texts <-chan *pb.RawText) <-chan *pb.RawText {
+	tracked := make(chan *pb.RawText)
+	go func() {
+		defer close(tracked)
+		for text := range texts {
+			if offset, ok := textFileOffset(text); ok {
+				c.mu.Lock()
+				c.pending = append(c.pending, offset)
+				c.mu.Unlock()
+			}
+			tracked <- text
+		}
+	}()
+	return tracked
+}
+
+// peek 返回 offsets 处理完后断点可推进到的偏移，不改变状态
+func (c *fileCheckpoint) peek(offsets []int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	batch := make(map[int64]bool, len(offsets))
+	for _, offset := range offsets {
+		batch[offset] = true
+	}
+	next := c.offset
+	for _, offset := range c.pending {
+		if !c.done[offset] && !batch[offset] {
+			break
+		}
+		next = offset
+	}
+	return next
+}
+
+// complete 将 offsets 标记为已处理，返回推进后的断点
+func (c *fileCheckpoint) complete(offsets []int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, offset := range offsets {
+		c.done[offset] = true
+	}
+	for len(c.pending) > 0 && c.done[c.pending[0]] {
+		c.offset = c.pending[0]
+		delete(c.done, c.pending[0])
+		c.pending = c.pending[1:]
+	}
+	return c.offset
+}
+
+// resumeRequest 文件采集存在断点时返回从断点续采的请求：源参数带上 resume_offset，
+// 最大采集数量扣除已保存的数量；其他情况原样返回 req
+func resumeRequest(task *CollectionTask, req *pb.CollectRequest) *pb.CollectRequest {
+	if task.ResumeOffset <= 0 || req.Source.GetType() != pb.SourceType_LOCAL_FILE {
+		return req
+	}
+
+	resumed := proto.Clone(req).(*pb.CollectRequest)
+	if resumed.Source.Parameters == nil {
+		resumed.Source.Parameters = make(map[string]string)
+	}
+	resumed.Source.Parameters[collector.ResumeOffsetParam] = strconv.FormatInt(task.ResumeOffset, 10)
+	if maxCount := resumed.Config.GetMaxCount(); maxCount > task.CollectedCount {
+		resumed.Config.MaxCount = maxCount - task.CollectedCount
+	}
+	return resumed
+}
+
+// textFileOffsets 取出一批文本中记录的文件偏移
+func textFileOffsets(batch []*pb.RawText) []int64 {
+	var offsets []int64
+	for _, text := range batch {
+		if offset, ok := textFileOffset(text); ok {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+func textFileOffset(text *pb.RawText) (int64, bool) {
+	raw, ok := text.Metadata[collector.FileOffsetMetadataKey]
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(raw, 10, 64)
+	return offset, err == nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// crashingCollector 转发 inner 的前 after 条文本后模拟进程崩溃，其余已读取的文本丢失
+type crashingCollector struct {
+	inner collector.Collector
+	after int
+}
+
+func (c *crashingCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	inner := make(chan *pb.RawText, 10)
+	go func() {
+		defer close(inner)
+		c.inner.Collect(ctx, source, cfg, inner)
+	}()
+
+	forwarded := 0
+	for text := range inner {
+		if forwarded == c.after {
+			cancel()
+			continue
+		}
+		textChan <- text
+		forwarded++
+	}
+	return collector.Permanent(errors.New("simulated crash"))
+}
+
+func TestFileCheckpointWaitsForEarlierTexts(t *testing.T) {
+	checkpoint := newFileCheckpoint(0)
+	checkpoint.pending = []int64{10, 20, 30}
+
+	assert.Equal(t, int64(0), checkpoint.peek([]int64{20, 30}))
+	assert.Equal(t, int64(0), checkpoint.complete([]int64{20, 30}))
+	assert.Equal(t, int64(30), checkpoint.peek([]int64{10}))
+	assert.Equal(t, int64(30), checkpoint.complete([]int64{10}))
+}
+
+func TestFileCollectionResumesFromCheckpoint(t *testing.T) {
+	const lines = 300
+	var content strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&content, "line-%03d\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "large.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content.String()), 0o644))
+
+	repo := newMemoryRepository("task-1")
+	repo.tasks["task-1"].SourceType = pb.SourceType_LOCAL_FILE.String()
+	repo.tasks["task-1"].SourceFilePath = path
+	repo.tasks["task-1"].Config = fmt.Sprintf(`{"max_count":%d}`, lines)
+
+	fileCollector, err := collector.NewFileCollector(&config.Config{})
+	require.NoError(t, err)
+	waitForStatus := func(status pb.CollectionStatus) *model.CollectionTask {
+		var task *model.CollectionTask
+		require.Eventually(t, func() bool {
+			task, err = repo.GetCollectionTaskByID(context.Background(), "task-1")
+			return err == nil && task.Status == status.String()
+		}, 5*time.Second, 10*time.Millisecond)
+		return task
+	}
+
+	// 多个写入协程乱序提交，断点仍只覆盖已连续保存的行
+	crashed := newTestCollectorService(repo, nil, 4, 7)
+	crashed.collectors[pb.SourceType_LOCAL_FILE] = &crashingCollector{inner: fileCollector, after: 123}
+	_, err = crashed.RecoverTasks(context.Background())
+	require.NoError(t, err)
+	task := waitForStatus(pb.CollectionStatus_COLLECTION_FAILED)
+	assert.Equal(t, 123, task.CollectedCount)
+	assert.Equal(t, int64(123*len("line-000\n")), task.ResumeOffset)
+
+	// 模拟进程崩溃后任务停留在运行中且租约过期
+	expired := time.Now().Add(-time.Minute)
+	repo.tasks["task-1"].Status = model.TaskStatusRunning
+	repo.tasks["task-1"].LeaseExpiresAt = &expired
+
+	resumed := newTestCollectorService(repo, nil, 4, 7)
+	resumed.collectors[pb.SourceType_LOCAL_FILE] = fileCollector
+	_, err = resumed.RecoverTasks(context.Background())
+	require.NoError(t, err)
+	task = waitForStatus(pb.CollectionStatus_COLLECTION_COMPLETED)
+	assert.Equal(t, lines, task.CollectedCount)
+	assert.Equal(t, 100, task.Progress)
+
+	seen := make(map[string]int)
+	for _, text := range repo.texts {
+		seen[text.Content]++
+	}
+	require.Len(t, seen, lines)
+	for i := 0; i < lines; i++ {
+		assert.Equal(t, 1, seen[fmt.Sprintf("line-%03d", i)], "line %d", i)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 采集任务的 Prometheus 指标，由 main 注册
+var (
+	// ActiveCollectionTasks 本实例正在执行的采集任务数
+	ActiveCollectionTasks = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "data_collector_active_tasks",
+			Help: "Number of active collection tasks",
+		},
+	)
+
+	// TextsCollected 已落库的文本数，按来源类型统计（文本自身的 Source 含域名、文件名等，基数过高不作为标签）
+	TextsCollected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "data_collector_texts_collected_total",
+			Help: "Total number of collected texts saved to the database by source type",
+		},
+		[]string{"source_type"},
+	)
+
+	// CollectionRate 执行中任务自开始以来的平均落库速率（条/秒），任务结束后删除
+	CollectionRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "data_collector_collection_rate",
+			Help: "Texts saved per second for each running collection task",
+		},
+		[]string{"task_id"},
+	)
+)
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// minSimHashRunes 有效字符少于该数量的文本不做近似去重，短文本的 SimHash 区分度太低
+const minSimHashRunes = 10
+
+// NearDuplicateDetector 判断文本是否与近期采集过的文本近似重复。
+// 检查与记录分两步，文本写入数据库后才记录其指纹，写入失败的文本不会挡住之后的重新采集
+type NearDuplicateDetector interface {
+	// Check 逐个判断 hashes 是否与近期记录或 hashes 中排在前面的非重复指纹汉明距离不超过阈值，不记录指纹
+	Check(ctx context.Context, hashes []uint64) ([]bool, error)
+	// Add 记录已保存文本的指纹
+	Add(ctx context.Context, hashes []uint64) error
+}
+
+// simHash 计算文本的 64 位 SimHash 指纹，特征为去除空白与标点后的相邻字符二元组。
+// 有效字符过少时返回 false
+func simHash(text string) (uint64, bool) {
+	runes := make([]rune, 0, len(text))
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			runes = append(runes, r)
+		}
+	}
+	if len(runes) < minSimHashRunes {
+		return 0, false
+	}
+
+	var weights [64]int
+	for i := 0; i+1 < len(runes); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(string(runes[i : i+2])))
+		feature := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if feature&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+	return fingerprint, true
+}
+
+// hammingDistance 两个指纹不同的位数
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// RedisSimHashStore 基于 Redis 的近期指纹存储，所有实例共享。
+// 指纹按位切分为 threshold+1 段，距离不超过阈值的两个指纹至少有一段完全相同，
+// 因此只需比较与新指纹某一段相同的候选
+type RedisSimHashStore struct {
+	client    *redis.Client
+	threshold int
+	window    time.Duration
+	bands     []simHashBand
+	now       func() time.Time
+}
+
+// simHashBand 指纹中的一段位
+type simHashBand struct {
+	shift uint
+	mask  uint64
+}
+
+// NewRedisSimHashStore 创建近似去重存储；threshold 为判定重复的最大汉明距离，window 为指纹保留时间，0 表示不过期
+func NewRedisSimHashStore(client *redis.Client, threshold int, window time.Duration) *RedisSimHashStore {
+	if threshold < 0 {
+		threshold = 0
+	}
+	return &RedisSimHashStore{
+		client:    client,
+		threshold: threshold,
+		window:    window,
+		bands:     splitBands(threshold + 1),
+		now:       time.Now,
+	}
+}
+
+// splitBands 将 64 位尽量均匀地切分为 n 段
+func splitBands(n int) []simHashBand {
+	if n > 64 {
+		n = 64
+	}
+	bands := make([]simHashBand, 0, n)
+	shift := uint(0)
+	for i := 0; i < n; i++ {
+		width := uint(64 / n)
+		if i < 64%n {
+			width++
+		}
+		mask := uint64(1)<<width - 1
+		if width == 64 {
+			mask = ^uint64(0)
+		}
+		bands = append(bands, simHashBand{shift: shift, mask: mask})
+		shift += width
+	}
+	return bands
+}
+
+// bandKeys 返回指纹各段对应的有序集合键
+func (s *RedisSimHashStore) bandKeys(hash uint64) []string {
+	keys := make([]string, len(s.bands))
+	for i, band := range s.bands {
+		keys[i] = fmt.Sprintf("collector:simhash:%d:%x", i, (hash>>band.shift)&band.mask)
+	}
+	return keys
+}
+
+// Check 与近期指纹及同批中排在前面的非重复指纹比较。
+// 每段使用以记录时间为分数的有序集合，比较前先清理超出保留时间的指纹
+func (s *RedisSimHashStore) Check(ctx context.Context, hashes []uint64) ([]bool, error) {
+	duplicates := make([]bool, len(hashes))
+	if len(hashes) == 0 {
+		return duplicates, nil
+	}
+	now := s.now()
+
+	pipe := s.client.Pipeline()
+	candidates := make([][]*redis.StringSliceCmd, len(hashes))
+	for i, hash := range hashes {
+		for _, key := range s.bandKeys(hash) {
+			if s.window > 0 {
+				pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", now.Add(-s.window).UnixMilli()))
+			}
+			candidates[i] = append(candidates[i], pipe.ZRange(ctx, key, 0, -1))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load simhash candidates: %w", err)
+	}
+
+	kept := make([]uint64, 0, len(hashes))
+	for i, hash := range hashes {
+		duplicates[i] = s.matchesStored(hash, candidates[i]) || s.matchesAny(hash, kept)
+		if !duplicates[i] {
+			kept = append(kept, hash)
+		}
+	}
+	return duplicates, nil
+}
+
+// matchesStored 候选指纹中是否有与 hash 近似重复的
+func (s *RedisSimHashStore) matchesStored(hash uint64, candidates []*redis.StringSliceCmd) bool {
+	for _, cmd := range candidates {
+		for _, member := range cmd.Val() {
+			candidate, err := strconv.ParseUint(member, 16, 64)
+			if err != nil {
+				continue
+			}
+			if hammingDistance(hash, candidate) <= s.threshold {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesAny hashes 中是否有与 hash 近似重复的
+func (s *RedisSimHashStore) matchesAny(hash uint64, hashes []uint64) bool {
+	for _, other := range hashes {
+		if hammingDistance(hash, other) <= s.threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// Add 以当前时间记录指纹
+func (s *RedisSimHashStore) Add(ctx context.Context, hashes []uint64) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	now := s.now()
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, hash := range hashes {
+			member := strconv.FormatUint(hash, 16)
+			for _, key := range s.bandKeys(hash) {
+				pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now.UnixMilli()), Member: member})
+				if s.window > 0 {
+					pipe.Expire(ctx, key, s.window)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record simhash: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+const (
+	originalAnswer = "这个问题其实很好回答，关键在于先把基础打牢。每天坚持练习一个小时，三个月之后你会发现自己的进步非常明显，千万不要急于求成。"
+	// 转载时改了标点和个别字
+	editedAnswer = "这个问题其实很好回答：关键在于先把基础打好。每天坚持练习一小时，三个月之后你会发现自己的进步非常明显！千万不要急于求成"
+	otherAnswer  = "我不同意楼上的观点，学习方法因人而异，有的人适合集中突击，有的人适合长期积累，找到适合自己的节奏最重要。"
+)
+
+// textsCollector 依次输出给定文本
+type textsCollector struct {
+	contents []string
+}
+
+func (c *textsCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	for i, content := range c.contents {
+		select {
+		case textChan <- &pb.RawText{Id: fmt.Sprintf("text-%d", i), Content: content, Source: "zhihu:answer"}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func newTestSimHashStore(t *testing.T, threshold int, window time.Duration) *RedisSimHashStore {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisSimHashStore(client, threshold, window)
+}
+
+func TestSimHashDistance(t *testing.T) {
+	original, ok := simHash(originalAnswer)
+	require.True(t, ok)
+	edited, _ := simHash(editedAnswer)
+	other, _ := simHash(otherAnswer)
+
+	assert.LessOrEqual(t, hammingDistance(original, edited), 6)
+	assert.Greater(t, hammingDistance(original, other), 10)
+
+	// 有效字符过少的文本不计算指纹
+	_, ok = simHash("好的，谢谢！")
+	assert.False(t, ok)
+}
+
+func TestRedisSimHashStoreDetectsNearDuplicates(t *testing.T) {
+	ctx := context.Background()
+	store := newTestSimHashStore(t, 6, time.Hour)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	check := func(text string) bool {
+		hash, ok := simHash(text)
+		require.True(t, ok)
+		duplicates, err := store.Check(ctx, []uint64{hash})
+		require.NoError(t, err)
+		if !duplicates[0] {
+			require.NoError(t, store.Add(ctx, []uint64{hash}))
+		}
+		return duplicates[0]
+	}
+
+	assert.False(t, check(originalAnswer))
+	assert.True(t, check(editedAnswer))
+	assert.False(t, check(otherAnswer))
+
+	// 超出保留时间的指纹不再参与比较
+	now = now.Add(2 * time.Hour)
+	assert.False(t, check(editedAnswer))
+
+	// 同一批中与排在前面的指纹比较，检查本身不记录指纹
+	fresh := newTestSimHashStore(t, 6, time.Hour)
+	original, _ := simHash(originalAnswer)
+	edited, _ := simHash(editedAnswer)
+	other, _ := simHash(otherAnswer)
+	duplicates, err := fresh.Check(ctx, []uint64{original, edited, other})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, false}, duplicates)
+	duplicates, err = fresh.Check(ctx, []uint64{edited})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, duplicates)
+}
+
+func TestTaskDropsNearDuplicateTexts(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	c := &textsCollector{contents: []string{originalAnswer, editedAnswer, otherAnswer, "短文本", "短文本"}}
+	s := newTestCollectorService(repo, c, 1, 10)
+	s.SetNearDuplicateDetector(newTestSimHashStore(t, 6, time.Hour))
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(4), task.CollectedCount)
+
+	contents := make([]string, 0, len(repo.texts))
+	for _, text := range repo.texts {
+		contents = append(contents, text.Content)
+	}
+	assert.ElementsMatch(t, []string{originalAnswer, otherAnswer, "短文本", "短文本"}, contents)
+}
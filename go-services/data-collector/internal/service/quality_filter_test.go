@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func TestTaskDropsLowQualityTexts(t *testing.T) {
+	good := "这是一个关于人工智能发展的深度讨论，涉及到机器学习、深度学习等多个领域。"
+	short := "简短回复"
+	repetitive := strings.Repeat("这是一个非常长的文本内容，用于测试长度过滤功能。", 50)
+
+	repo := newMemoryRepository("task-1", "task-2")
+	s := newTestCollectorService(repo, &textsCollector{contents: []string{good, short, repetitive}}, 1, 10)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10, MinQuality: 60})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(1), task.CollectedCount)
+	require.Len(t, repo.texts, 1)
+	for _, text := range repo.texts {
+		assert.Equal(t, good, text.Content)
+	}
+
+	// 未设置最低质量分时不过滤
+	task = runTestCollection(s, "task-2", &pb.CollectionConfig{MaxCount: 10})
+	assert.Equal(t, int32(3), task.CollectedCount)
+}
+
+func TestCollectTextRejectsInvalidMinQuality(t *testing.T) {
+	s := newTestCollectorService(newMemoryRepository(), &textsCollector{}, 1, 10)
+	_, err := s.CollectText(context.Background(), &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API},
+		Config: &pb.CollectionConfig{MinQuality: 101},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCollectTextRejectsNegativeRateLimit(t *testing.T) {
+	s := newTestCollectorService(newMemoryRepository(), &textsCollector{}, 1, 10)
+	_, err := s.CollectText(context.Background(), &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API},
+		Config: &pb.CollectionConfig{RateLimit: -1},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
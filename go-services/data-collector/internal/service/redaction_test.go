@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func TestTaskRedactsPIIBeforeSaving(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	c := &textsCollector{contents: []string{"有问题加我微信或者打电话13812345678，邮箱 li4@example.com", "没有敏感信息的评论"}}
+	s := newTestCollectorService(repo, c, 1, 10)
+	s.config.Collector.RedactPII = true
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10})
+	require.Equal(t, int32(2), task.CollectedCount)
+
+	saved := make(map[string]string)
+	for _, text := range repo.texts {
+		saved[text.Content] = text.Metadata
+	}
+	assert.Equal(t, map[string]string{
+		"有问题加我微信或者打电话138****5678，邮箱 l***@example.com": `{"pii_types":"email,phone"}`,
+		"没有敏感信息的评论": "",
+	}, saved)
+}
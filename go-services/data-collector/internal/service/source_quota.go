@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// dailyQuotaTTL 每日计数键的过期时间，略长于一天以覆盖跨时区的查询
+const dailyQuotaTTL = 48 * time.Hour
+
+// SourceQuota 按来源限制采集条数，写入文本前预占配额
+type SourceQuota interface {
+	// Reserve 为 source 预占 n 条配额，返回实际获得的条数，小于 n 表示配额已用尽
+	Reserve(ctx context.Context, source string, n int) (int, error)
+	// Release 归还预占后未能保存的 n 条配额
+	Release(ctx context.Context, source string, n int) error
+	// Usage 返回所有配置了配额的来源的当前用量
+	Usage(ctx context.Context) ([]QuotaUsage, error)
+}
+
+// QuotaUsage 来源的配额用量，Limit 为 0 表示未限制
+type QuotaUsage struct {
+	Source     string `json:"source"`
+	DailyUsed  int    `json:"daily_used"`
+	DailyLimit int    `json:"daily_limit"`
+	TotalUsed  int    `json:"total_used"`
+	TotalLimit int    `json:"total_limit"`
+}
+
+// reserveQuotaScript 同时检查每日与累计配额，按两者剩余量的较小值预占。
+// KEYS[1] 为当日计数，KEYS[2] 为累计计数；ARGV 依次为请求条数、每日上限、累计上限与当日计数的过期秒数
+var reserveQuotaScript = redis.NewScript(`
+local grant = tonumber(ARGV[1])
+local daily = tonumber(ARGV[2])
+local total = tonumber(ARGV[3])
+if daily > 0 then
+  local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+  grant = math.min(grant, math.max(0, daily - used))
+end
+if total > 0 then
+  local used = tonumber(redis.call('GET', KEYS[2]) or '0')
+  grant = math.min(grant, math.max(0, total - used))
+end
+if grant > 0 then
+  redis.call('INCRBY', KEYS[1], grant)
+  redis.call('EXPIRE', KEYS[1], tonumber(ARGV[4]))
+  redis.call('INCRBY', KEYS[2], grant)
+end
+return grant
+`)
+
+// releaseQuotaScript 归还预占的配额，计数不会减到 0 以下；KEYS 与 reserveQuotaScript 相同，ARGV[1] 为归还条数
+var releaseQuotaScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+for _, key in ipairs(KEYS) do
+  local used = tonumber(redis.call('GET', key) or '0')
+  if used > 0 then
+    redis.call('DECRBY', key, math.min(n, used))
+  end
+end
+return 0
+`)
+
+// RedisSourceQuota 基于 Redis 计数的来源配额，所有实例共享同一份用量
+type RedisSourceQuota struct {
+	client *redis.Client
+	daily  map[string]int
+	total  map[string]int
+	now    func() time.Time
+}
+
+// NewRedisSourceQuota 创建来源配额；daily 与 total 为来源到采集条数上限的映射，未配置的来源不限制
+func NewRedisSourceQuota(client *redis.Client, daily, total map[string]int) *RedisSourceQuota {
+	return &RedisSourceQuota{
+		client: client,
+		daily:  daily,
+		total:  total,
+		now:    time.Now,
+	}
+}
+
+// Reserve 为 source 预占 n 条配额
+func (q *RedisSourceQuota) Reserve(ctx context.Context, source string, n int) (int, error) {
+	dailyLimit, totalLimit := q.daily[source], q.total[source]
+	if n <= 0 || (dailyLimit <= 0 && totalLimit <= 0) {
+		return n, nil
+	}
+
+	dailyKey, totalKey := q.keys(source)
+	granted, err := reserveQuotaScript.Run(ctx, q.client, []string{dailyKey, totalKey},
+		n, dailyLimit, totalLimit, int(dailyQuotaTTL.Seconds())).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve quota for %s: %w", source, err)
+	}
+	return granted, nil
+}
+
+// Release 归还预占后未能保存的配额
+func (q *RedisSourceQuota) Release(ctx context.Context, source string, n int) error {
+	dailyLimit, totalLimit := q.daily[source], q.total[source]
+	if n <= 0 || (dailyLimit <= 0 && totalLimit <= 0) {
+		return nil
+	}
+
+	dailyKey, totalKey := q.keys(source)
+	_, err := releaseQuotaScript.Run(ctx, q.client, []string{dailyKey, totalKey}, n).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to release quota for %s: %w", source, err)
+	}
+	return nil
+}
+
+// Usage 返回所有配置了配额的来源的当前用量，按来源名排序
+func (q *RedisSourceQuota) Usage(ctx context.Context) ([]QuotaUsage, error) {
+	sources := make(map[string]struct{})
+	for source := range q.daily {
+		sources[source] = struct{}{}
+	}
+	for source := range q.total {
+		sources[source] = struct{}{}
+	}
+
+	usages := make([]QuotaUsage, 0, len(sources))
+	for source := range sources {
+		dailyKey, totalKey := q.keys(source)
+		values, err := q.client.MGet(ctx, dailyKey, totalKey).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read quota usage for %s: %w", source, err)
+		}
+		usages = append(usages, QuotaUsage{
+			Source:     source,
+			DailyUsed:  counterValue(values[0]),
+			DailyLimit: q.daily[source],
+			TotalUsed:  counterValue(values[1]),
+			TotalLimit: q.total[source],
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Source < usages[j].Source })
+	return usages, nil
+}
+
+// keys 返回来源当日与累计计数的键
+func (q *RedisSourceQuota) keys(source string) (string, string) {
+	day := q.now().Format("20060102")
+	return fmt.Sprintf("collector:quota:daily:%s:%s", source, day), fmt.Sprintf("collector:quota:total:%s", source)
+}
+
+// counterValue 解析 MGET 返回的计数，键不存在时为 0
+func counterValue(value interface{}) int {
+	s, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func newTestSourceQuota(t *testing.T, daily, total map[string]int) *RedisSourceQuota {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisSourceQuota(client, daily, total)
+}
+
+func TestTaskStopsWhenSourceQuotaReached(t *testing.T) {
+	repo := newMemoryRepository("task-1", "task-2")
+	// sequenceCollector 产出的文本来源为 api
+	c := &sequenceCollector{count: 100}
+	s := newTestCollectorService(repo, c, 2, 10)
+	s.SetSourceQuota(newTestSourceQuota(t, nil, map[string]int{"api": 25}))
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 100})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(25), task.CollectedCount)
+	assert.Equal(t, "quota reached: api", task.ErrorMessage)
+	assert.Len(t, repo.texts, 25)
+
+	// 配额已用尽，后续任务不再写入
+	task = runTestCollection(s, "task-2", &pb.CollectionConfig{MaxCount: 100})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(0), task.CollectedCount)
+	assert.Len(t, repo.texts, 25)
+
+	usages, err := s.QuotaUsage(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []QuotaUsage{{Source: "api", DailyUsed: 25, TotalUsed: 25, TotalLimit: 25}}, usages)
+}
+
+func TestSourceQuotaTakesSmallerOfDailyAndTotal(t *testing.T) {
+	ctx := context.Background()
+	quota := newTestSourceQuota(t, map[string]int{"zhihu:answer": 5}, map[string]int{"zhihu:answer": 8})
+
+	granted, err := quota.Reserve(ctx, "zhihu:answer", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, granted)
+	granted, err = quota.Reserve(ctx, "zhihu:answer", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, granted)
+
+	// 未配置配额的来源不限制
+	granted, err = quota.Reserve(ctx, "zhihu:question", 100)
+	require.NoError(t, err)
+	assert.Equal(t, 100, granted)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// recoverBatchSize 每轮恢复最多认领的任务数
+const recoverBatchSize = 50
+
+// defaultInstanceID 未配置实例ID时使用主机名加随机后缀，避免同一主机上的多个进程冲突
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "data-collector"
+	}
+	return fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+}
+
+// claimTask 在数据库中认领任务，返回 false 表示任务已由其他实例执行
+func (s *CollectorService) claimTask(ctx context.Context, taskID string) (bool, error) {
+	now := time.Now()
+	return s.repo.ClaimCollectionTask(ctx, taskID, s.instanceID, now, now.Add(s.config.Collector.TaskLease))
+}
+
+// keepTaskLease 定期续约直到 ctx 结束；续约失败说明任务已被其他实例接管，调用 onLost 后退出
+func (s *CollectorService) keepTaskLease(ctx context.Context, taskID string, onLost func()) {
+	lease := s.config.Collector.TaskLease
+	if lease <= 0 {
+		return
+	}
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := s.repo.RenewTaskLease(ctx, taskID, s.instanceID, time.Now().Add(lease))
+			if err != nil {
+				// 数据库暂时不可用时继续执行，租约过期前恢复即可
+				logrus.WithError(err).WithField("task_id", taskID).Warn("Failed to renew task lease")
+				continue
+			}
+			if !renewed {
+				logrus.WithFields(logrus.Fields{
+					"task_id":     taskID,
+					"instance_id": s.instanceID,
+				}).Warn("Task lease lost, stopping task")
+				onLost()
+				return
+			}
+		}
+	}
+}
+
+// RecoverTasks 将待执行或租约已过期的任务加入本实例的等待队列，用于实例重启或其他副本宕机后的恢复；
+// 任务在开始执行时才认领，多个实例同时恢复同一任务时只有一个会执行。返回加入队列的任务数
+func (s *CollectorService) RecoverTasks(ctx context.Context) (int, error) {
+	dbTasks, err := s.repo.ListClaimableTasks(ctx, time.Now(), recoverBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list claimable tasks: %w", err)
+	}
+
+	recovered := 0
+	for _, dbTask := range dbTasks {
+		s.tasksMutex.RLock()
+		_, local := s.tasks[dbTask.ID]
+		s.tasksMutex.RUnlock()
+		if local {
+			// 本实例已在排队或执行中的任务
+			continue
+		}
+
+		req, err := collectRequestFromModel(dbTask)
+		if err != nil {
+			logrus.WithError(err).WithField("task_id", dbTask.ID).Error("Failed to restore collection request")
+			continue
+		}
+
+		task := &CollectionTask{
+			ID:         dbTask.ID,
+			SourceType: req.Source.Type,
+			Config:     req.Config,
+			Priority:   int32(dbTask.Priority),
+			Attempts:   int32(dbTask.Attempts),
+			Status:     pb.CollectionStatus_COLLECTION_PENDING,
+		}
+		if dbTask.ResumeOffset > 0 {
+			// 中断的文件采集从断点继续，沿用已保存的计数
+			task.ResumeOffset = dbTask.ResumeOffset
+			task.CollectedCount = int32(dbTask.CollectedCount)
+			task.Progress = int32(dbTask.Progress)
+		}
+		s.tasksMutex.Lock()
+		s.tasks[task.ID] = task
+		s.tasksMutex.Unlock()
+
+		logrus.WithFields(logrus.Fields{
+			"task_id":     task.ID,
+			"instance_id": s.instanceID,
+			"previous":    dbTask.ClaimedBy,
+		}).Info("Recovered collection task")
+		s.enqueueTask(task, req)
+		recovered++
+	}
+	return recovered, nil
+}
+
+// RunTaskRecovery 启动时及之后每隔 interval 恢复一次任务，ctx 结束时退出
+func (s *CollectorService) RunTaskRecovery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RecoverTasks(ctx); err != nil {
+			logrus.WithError(err).Warn("Task recovery failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// secretSourceParameters 含凭据的源参数，不写入任务记录：采集源的连接串与 API 令牌
+var secretSourceParameters = map[string]bool{
+	"dsn":          true,
+	"bearer_token": true,
+}
+
+// persistedSourceParameters 返回写入任务记录的源参数，去掉 secretSourceParameters 中的键。
+// 因此依赖这些参数的任务被其他实例接管时会因缺少凭据而失败，需要重新提交
+func persistedSourceParameters(params map[string]string) map[string]string {
+	persisted := make(map[string]string, len(params))
+	for key, value := range params {
+		if !secretSourceParameters[key] {
+			persisted[key] = value
+		}
+	}
+	return persisted
+}
+
+// collectRequestFromModel 根据数据库中的任务记录还原采集请求
+func collectRequestFromModel(dbTask *model.CollectionTask) (*pb.CollectRequest, error) {
+	sourceType, ok := pb.SourceType_value[dbTask.SourceType]
+	if !ok {
+		return nil, fmt.Errorf("unknown source type: %s", dbTask.SourceType)
+	}
+	req := &pb.CollectRequest{
+		Source: &pb.CollectionSource{
+			Type:     pb.SourceType(sourceType),
+			Url:      dbTask.SourceURL,
+			FilePath: dbTask.SourceFilePath,
+		},
+		Config: &pb.CollectionConfig{},
+	}
+	if dbTask.SourceParameters != "" {
+		if err := json.Unmarshal([]byte(dbTask.SourceParameters), &req.Source.Parameters); err != nil {
+			return nil, fmt.Errorf("invalid source parameters: %w", err)
+		}
+	}
+	if dbTask.Config != "" {
+		if err := json.Unmarshal([]byte(dbTask.Config), req.Config); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// countingCollector 记录 Collect 被调用的次数
+type countingCollector struct {
+	sequenceCollector
+	calls int32
+}
+
+func (c *countingCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	atomic.AddInt32(&c.calls, 1)
+	return c.sequenceCollector.Collect(ctx, source, cfg, textChan)
+}
+
+func TestExecuteCollectionTaskRunsOnceAcrossReplicas(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	c := &countingCollector{sequenceCollector: sequenceCollector{count: 10}}
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"replica-a", "replica-b", "replica-c"} {
+		s := newTestCollectorService(repo, c, 1, 10)
+		s.instanceID = id
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.calls))
+	task, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED.String(), task.Status)
+	assert.Equal(t, 10, task.CollectedCount)
+}
+
+func TestRecoverTasksReclaimsExpiredLease(t *testing.T) {
+	repo := newMemoryRepository("expired", "active")
+	expired := time.Now().Add(-time.Minute)
+	active := time.Now().Add(time.Minute)
+	repo.tasks["expired"].Status = model.TaskStatusRunning
+	repo.tasks["expired"].ClaimedBy = "crashed"
+	repo.tasks["expired"].LeaseExpiresAt = &expired
+	repo.tasks["active"].Status = model.TaskStatusRunning
+	repo.tasks["active"].ClaimedBy = "alive"
+	repo.tasks["active"].LeaseExpiresAt = &active
+	for _, task := range repo.tasks {
+		task.SourceType = pb.SourceType_API.String()
+		task.Config = `{"max_count":5}`
+	}
+
+	s := newTestCollectorService(repo, &sequenceCollector{count: 5}, 1, 10)
+	recovered, err := s.RecoverTasks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+
+	require.Eventually(t, func() bool {
+		task, err := repo.GetCollectionTaskByID(context.Background(), "expired")
+		return err == nil && task.Status == pb.CollectionStatus_COLLECTION_COMPLETED.String()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	task, err := repo.GetCollectionTaskByID(context.Background(), "expired")
+	require.NoError(t, err)
+	assert.Equal(t, "test-instance", task.ClaimedBy)
+	assert.Equal(t, 5, task.CollectedCount)
+
+	task, err = repo.GetCollectionTaskByID(context.Background(), "active")
+	require.NoError(t, err)
+	assert.Equal(t, "alive", task.ClaimedBy)
+	assert.Equal(t, model.TaskStatusRunning, task.Status)
+}
+
+func TestCollectTextDoesNotPersistSourceCredentials(t *testing.T) {
+	repo := newMemoryRepository()
+	s := newTestCollectorService(repo, &sequenceCollector{count: 1}, 1, 10)
+
+	resp, err := s.CollectText(context.Background(), &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API, Parameters: map[string]string{
+			"dsn":          "reader:secret@tcp(db:3306)/app",
+			"bearer_token": "secret",
+			"table":        "comments",
+		}},
+		Config: &pb.CollectionConfig{MaxCount: 1},
+	})
+	require.NoError(t, err)
+
+	task, err := repo.GetCollectionTaskByID(context.Background(), resp.TaskId)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"table":"comments"}`, task.SourceParameters)
+	assert.NotContains(t, task.SourceParameters, "secret")
+
+	require.Eventually(t, func() bool {
+		task, err := repo.GetCollectionTaskByID(context.Background(), resp.TaskId)
+		return err == nil && task.Status == pb.CollectionStatus_COLLECTION_COMPLETED.String()
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// taskEventBuffer 每个订阅者的事件缓冲，消费过慢时丢弃最旧的进度事件
+const taskEventBuffer = 16
+
+// TaskEvent 任务进度事件
+type TaskEvent struct {
+	TaskID         string `json:"task_id"`
+	Status         string `json:"status"`
+	Progress       int32  `json:"progress"`
+	CollectedCount int32  `json:"collected_count"`
+	Message        string `json:"message,omitempty"`
+}
+
+// Terminal 任务是否已结束（完成、失败或取消）
+func (e TaskEvent) Terminal() bool {
+	return isTerminalStatus(e.Status)
+}
+
+func isTerminalStatus(status string) bool {
+	return status == pb.CollectionStatus_COLLECTION_COMPLETED.String() ||
+		status == pb.CollectionStatus_COLLECTION_FAILED.String() ||
+		status == pb.CollectionStatus_COLLECTION_CANCELLED.String()
+}
+
+// taskEventHub 按任务分发进度事件，并记录每个任务的最新事件供新订阅者使用
+type taskEventHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan TaskEvent]struct{}
+	last map[string]TaskEvent
+}
+
+func newTaskEventHub() *taskEventHub {
+	return &taskEventHub{
+		subs: make(map[string]map[chan TaskEvent]struct{}),
+		last: make(map[string]TaskEvent),
+	}
+}
+
+// publish 推送事件；任务结束时关闭该任务的所有订阅
+func (h *taskEventHub) publish(ev TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.last[ev.TaskID] = ev
+	for ch := range h.subs[ev.TaskID] {
+		send(ch, ev)
+		if ev.Terminal() {
+			close(ch)
+		}
+	}
+	if ev.Terminal() {
+		delete(h.subs, ev.TaskID)
+	}
+}
+
+// subscribe 订阅任务事件，首个事件为任务当前状态；ok 为 false 表示该任务没有记录过事件
+func (h *taskEventHub) subscribe(ctx context.Context, taskID string) (<-chan TaskEvent, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last, ok := h.last[taskID]
+	if !ok {
+		return nil, false
+	}
+
+	ch := make(chan TaskEvent, taskEventBuffer)
+	ch <- last
+	if last.Terminal() {
+		close(ch)
+		return ch, true
+	}
+
+	if h.subs[taskID] == nil {
+		h.subs[taskID] = make(map[chan TaskEvent]struct{})
+	}
+	h.subs[taskID][ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(taskID, ch)
+	}()
+	return ch, true
+}
+
+// unsubscribe 取消订阅，任务已结束时订阅早已被移除
+func (h *taskEventHub) unsubscribe(taskID string, ch chan TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[taskID][ch]; !ok {
+		return
+	}
+	delete(h.subs[taskID], ch)
+	if len(h.subs[taskID]) == 0 {
+		delete(h.subs, taskID)
+	}
+	close(ch)
+}
+
+// send 非阻塞发送，缓冲已满时丢弃最旧的事件，保证最新状态总能送达
+func send(ch chan TaskEvent, ev TaskEvent) {
+	select {
+	case ch <- ev:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- ev
+}
+
+// taskEventFrom 根据任务当前状态生成事件
+func taskEventFrom(task *CollectionTask) TaskEvent {
+	task = task.snapshot()
+	return TaskEvent{
+		TaskID:         task.ID,
+		Status:         task.Status.String(),
+		Progress:       task.Progress,
+		CollectedCount: task.CollectedCount,
+		Message:        task.ErrorMessage,
+	}
+}
+
+// SubscribeTask 订阅任务进度事件，ctx 结束时自动取消订阅；
+// 进程内没有该任务的事件时回退到任务状态查询，只返回一次当前状态
+func (s *CollectorService) SubscribeTask(ctx context.Context, taskID string) (<-chan TaskEvent, error) {
+	if events, ok := s.events.subscribe(ctx, taskID); ok {
+		return events, nil
+	}
+
+	resp, err := s.GetCollectionStatus(ctx, &pb.StatusRequest{TaskId: taskID})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan TaskEvent, 1)
+	ch <- TaskEvent{
+		TaskID:   resp.TaskId,
+		Status:   resp.Status.String(),
+		Progress: resp.Progress,
+		Message:  resp.Message,
+	}
+	close(ch)
+	return ch, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func runningEvent(progress int32) TaskEvent {
+	return TaskEvent{TaskID: "task-1", Status: pb.CollectionStatus_COLLECTION_RUNNING.String(), Progress: progress}
+}
+
+func TestTaskEventHubDeliversUntilTerminal(t *testing.T) {
+	hub := newTaskEventHub()
+	hub.publish(runningEvent(0))
+
+	events, ok := hub.subscribe(context.Background(), "task-1")
+	require.True(t, ok)
+
+	hub.publish(runningEvent(50))
+	hub.publish(TaskEvent{TaskID: "task-1", Status: pb.CollectionStatus_COLLECTION_COMPLETED.String(), Progress: 100})
+
+	var got []int32
+	for ev := range events {
+		got = append(got, ev.Progress)
+	}
+	// 首个事件为订阅时的当前状态，任务结束后通道关闭
+	assert.Equal(t, []int32{0, 50, 100}, got)
+
+	// 任务结束后订阅只返回最终状态
+	late, ok := hub.subscribe(context.Background(), "task-1")
+	require.True(t, ok)
+	final := <-late
+	assert.True(t, final.Terminal())
+	_, open := <-late
+	assert.False(t, open)
+}
+
+func TestTaskEventHubUnsubscribesOnCancel(t *testing.T) {
+	hub := newTaskEventHub()
+	hub.publish(runningEvent(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, ok := hub.subscribe(ctx, "task-1")
+	require.True(t, ok)
+	<-events
+
+	cancel()
+	_, open := <-events
+	assert.False(t, open)
+
+	hub.mu.Lock()
+	assert.Empty(t, hub.subs)
+	hub.mu.Unlock()
+
+	// 取消后继续推送不应阻塞或panic
+	hub.publish(runningEvent(10))
+}
+
+func TestTaskEventHubDropsOldestWhenFull(t *testing.T) {
+	hub := newTaskEventHub()
+	hub.publish(runningEvent(0))
+	events, ok := hub.subscribe(context.Background(), "task-1")
+	require.True(t, ok)
+
+	for i := int32(1); i <= taskEventBuffer*2; i++ {
+		hub.publish(runningEvent(i))
+	}
+
+	var last TaskEvent
+	for i := 0; i < taskEventBuffer; i++ {
+		last = <-events
+	}
+	assert.Equal(t, int32(taskEventBuffer*2), last.Progress)
+}
+
+func TestTaskEventHubUnknownTask(t *testing.T) {
+	hub := newTaskEventHub()
+	_, ok := hub.subscribe(context.Background(), "missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"container/heap"
+
+	"github.com/sirupsen/logrus"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// queuedTask 等待执行的任务，seq 为入队序号，相同优先级时先入队的先执行
+type queuedTask struct {
+	task *CollectionTask
+	req  *pb.CollectRequest
+	seq  uint64
+}
+
+// taskQueue 按优先级从高到低排列的任务堆，实现 heap.Interface
+type taskQueue []*queuedTask
+
+func (q taskQueue) Len() int { return len(q) }
+
+func (q taskQueue) Less(i, j int) bool {
+	if q[i].task.Priority != q[j].task.Priority {
+		return q[i].task.Priority > q[j].task.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q taskQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *taskQueue) Push(x interface{}) { *q = append(*q, x.(*queuedTask)) }
+
+func (q *taskQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
+
+// enqueueTask 将任务加入等待队列，有空闲执行槽位时立即开始
+func (s *CollectorService) enqueueTask(task *CollectionTask, req *pb.CollectRequest) {
+	s.queueMutex.Lock()
+	s.queueSeq++
+	heap.Push(&s.pending, &queuedTask{task: task, req: req, seq: s.queueSeq})
+	queued := s.pending.Len()
+	s.queueMutex.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"task_id":  task.ID,
+		"priority": task.Priority,
+		"queued":   queued,
+	}).Info("Collection task queued")
+	s.dispatchTasks()
+}
+
+// dispatchTasks 在执行中的任务数未达上限时按优先级取出任务执行，MaxRunningTasks 不大于 0 时不限制
+func (s *CollectorService) dispatchTasks() {
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+
+	// 服务关闭后排队的任务留在数据库中等待其他实例恢复
+	if s.shuttingDown() {
+		return
+	}
+	limit := s.config.Collector.MaxRunningTasks
+	for s.pending.Len() > 0 && (limit <= 0 || s.running < limit) {
+		item := heap.Pop(&s.pending).(*queuedTask)
+		s.running++
+		go s.runQueuedTask(item)
+	}
+}
+
+// runQueuedTask 执行任务并在结束后释放槽位，唤醒下一个等待的任务
+func (s *CollectorService) runQueuedTask(item *queuedTask) {
+	defer func() {
+		s.queueMutex.Lock()
+		s.running--
+		s.queueMutex.Unlock()
+		s.dispatchTasks()
+	}()
+
+	// 使用服务的根上下文，提交任务的请求结束时任务不受影响，服务关闭时随之取消
+	s.executeCollectionTask(s.ctx, item.task, item.req)
+}
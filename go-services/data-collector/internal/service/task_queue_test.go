@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// gateCollector 记录任务开始的顺序（以 source.Url 标识），release 关闭前阻塞
+type gateCollector struct {
+	release chan struct{}
+
+	mu        sync.Mutex
+	started   []string
+	active    int
+	maxActive int
+}
+
+func (c *gateCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	c.mu.Lock()
+	c.started = append(c.started, source.Url)
+	c.active++
+	if c.active > c.maxActive {
+		c.maxActive = c.active
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-c.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *gateCollector) startedTasks() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.started...)
+}
+
+func enqueueTestTask(s *CollectorService, id string, priority int32) {
+	task := &CollectionTask{ID: id, SourceType: pb.SourceType_API, Priority: priority, Status: pb.CollectionStatus_COLLECTION_PENDING}
+	s.tasksMutex.Lock()
+	s.tasks[id] = task
+	s.tasksMutex.Unlock()
+	s.enqueueTask(task, &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API, Url: id},
+		Config: &pb.CollectionConfig{Priority: priority},
+	})
+}
+
+func TestQueuedTasksStartInPriorityOrder(t *testing.T) {
+	ids := []string{"blocker", "low", "high", "mid", "high-later"}
+	repo := newMemoryRepository(ids...)
+	c := &gateCollector{release: make(chan struct{})}
+	s := newTestCollectorService(repo, c, 1, 10)
+	s.config.Collector.MaxRunningTasks = 1
+
+	// 先占满唯一的执行槽位，之后提交的任务都在队列中等待
+	enqueueTestTask(s, "blocker", 0)
+	require.Eventually(t, func() bool { return len(c.startedTasks()) == 1 }, time.Second, 5*time.Millisecond)
+
+	enqueueTestTask(s, "low", 1)
+	enqueueTestTask(s, "high", 10)
+	enqueueTestTask(s, "mid", 5)
+	enqueueTestTask(s, "high-later", 10)
+	assert.Len(t, c.startedTasks(), 1)
+
+	close(c.release)
+	require.Eventually(t, func() bool { return len(c.startedTasks()) == len(ids) }, 5*time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, []string{"blocker", "high", "high-later", "mid", "low"}, c.startedTasks())
+	c.mu.Lock()
+	assert.Equal(t, 1, c.maxActive)
+	c.mu.Unlock()
+}
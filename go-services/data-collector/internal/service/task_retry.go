@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+const (
+	// defaultRetryBackoff 未配置 retry_backoff 时首次自动重试前的等待秒数
+	defaultRetryBackoff = 5
+	// maxRetryBackoff 自动重试等待时间的上限
+	maxRetryBackoff = 10 * time.Minute
+)
+
+// retryBackoffUnit retry_backoff 的单位，测试中缩短以加快执行
+var retryBackoffUnit = time.Second
+
+// RetryTask 按数据库中保存的采集源与配置重新执行失败的任务。
+// 采集器暂不支持断点续采，重试从头开始，上次已保存的文本保留
+func (s *CollectorService) RetryTask(ctx context.Context, taskID string) (*pb.CollectResponse, error) {
+	dbTask, err := s.repo.GetCollectionTaskByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "task not found: %s", taskID)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if dbTask.Status != model.TaskStatusFailed {
+		return nil, status.Errorf(codes.FailedPrecondition, "task %s is %s, only failed tasks can be retried", taskID, dbTask.Status)
+	}
+
+	req, err := collectRequestFromModel(dbTask)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "task %s cannot be restored: %v", taskID, err)
+	}
+
+	reset, err := s.repo.ResetFailedTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset task: %w", err)
+	}
+	if !reset {
+		// 读取之后被其他请求抢先重试
+		return nil, status.Errorf(codes.FailedPrecondition, "task %s is no longer failed", taskID)
+	}
+
+	task := &CollectionTask{
+		ID:         taskID,
+		SourceType: req.Source.Type,
+		Config:     req.Config,
+		Priority:   int32(dbTask.Priority),
+		Status:     pb.CollectionStatus_COLLECTION_PENDING,
+	}
+	s.tasksMutex.Lock()
+	s.tasks[taskID] = task
+	s.tasksMutex.Unlock()
+	s.events.publish(taskEventFrom(task))
+
+	logrus.WithFields(logrus.Fields{
+		"task_id":        taskID,
+		"source_type":    req.Source.Type,
+		"previous_error": dbTask.ErrorMessage,
+	}).Info("Retrying failed collection task")
+	s.enqueueTask(task, req)
+
+	return &pb.CollectResponse{
+		TaskId:  taskID,
+		Status:  pb.CollectionStatus_COLLECTION_PENDING,
+		Message: "Collection task retried",
+	}, nil
+}
+
+// scheduleRetry 按任务配置的重试策略在等待后重新排队，返回 false 表示不再重试、任务应记为失败。
+// 已达到最大执行次数或错误不可重试时不重试
+func (s *CollectorService) scheduleRetry(ctx context.Context, task *CollectionTask, err error) bool {
+	cfg := task.request.GetConfig()
+	maxAttempts := cfg.GetMaxAttempts()
+	if task.Attempts >= maxAttempts || collector.IsPermanent(err) {
+		return false
+	}
+
+	delay := retryDelay(cfg.GetRetryBackoff(), task.Attempts)
+	retryAt := time.Now().Add(delay)
+	task.mu.Lock()
+	task.Status = pb.CollectionStatus_COLLECTION_PENDING
+	task.RetryAt = &retryAt
+	task.ErrorMessage = fmt.Sprintf("attempt %d/%d failed, retrying in %s: %v", task.Attempts, maxAttempts, delay, err)
+	task.mu.Unlock()
+	s.updateTaskInDB(ctx, task)
+	s.events.publish(taskEventFrom(task))
+
+	logrus.WithFields(logrus.Fields{
+		"task_id":      task.ID,
+		"attempt":      task.Attempts,
+		"max_attempts": maxAttempts,
+		"delay":        delay,
+		"error":        err.Error(),
+	}).Warn("Collection task failed, scheduling retry")
+
+	req := task.request
+	time.AfterFunc(delay, func() { s.enqueueTask(task, req) })
+	return true
+}
+
+// retryDelay 第 attempt 次执行失败后的等待时间，从 backoff 起每次翻倍，不超过 maxRetryBackoff
+func retryDelay(backoff int32, attempt int32) time.Duration {
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	delay := time.Duration(backoff) * retryBackoffUnit
+	for i := int32(1); i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// flakyCollector 前 failures 次调用返回错误，之后正常产出文本
+type flakyCollector struct {
+	sequenceCollector
+	failures int32
+	calls    int32
+}
+
+func (c *flakyCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	if atomic.AddInt32(&c.calls, 1) <= c.failures {
+		return errors.New("connection reset by peer")
+	}
+	return c.sequenceCollector.Collect(ctx, source, cfg, textChan)
+}
+
+func TestRetryTaskRerunsFailedTask(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository("task-1")
+	repo.tasks["task-1"].SourceType = pb.SourceType_API.String()
+	repo.tasks["task-1"].Config = `{"max_count":5}`
+	s := newTestCollectorService(repo, &flakyCollector{sequenceCollector: sequenceCollector{count: 5}, failures: 1}, 1, 10)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 5})
+	require.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+
+	resp, err := s.RetryTask(ctx, "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_PENDING, resp.Status)
+
+	require.Eventually(t, func() bool {
+		dbTask, err := repo.GetCollectionTaskByID(ctx, "task-1")
+		return err == nil && dbTask.Status == pb.CollectionStatus_COLLECTION_COMPLETED.String()
+	}, 5*time.Second, 10*time.Millisecond)
+	dbTask, err := repo.GetCollectionTaskByID(ctx, "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, 5, dbTask.CollectedCount)
+	assert.Empty(t, dbTask.ErrorMessage)
+
+	// 已完成的任务不能重试
+	_, err = s.RetryTask(ctx, "task-1")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestRetryTaskRejectsUnknownAndRunningTasks(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository("running")
+	repo.tasks["running"].Status = model.TaskStatusRunning
+	s := newTestCollectorService(repo, &sequenceCollector{}, 1, 10)
+
+	_, err := s.RetryTask(ctx, "missing")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.RetryTask(ctx, "running")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestAutoRetrySucceedsAfterTransientFailures(t *testing.T) {
+	retryBackoffUnit = time.Millisecond
+	t.Cleanup(func() { retryBackoffUnit = time.Second })
+
+	repo := newMemoryRepository("task-1")
+	c := &flakyCollector{sequenceCollector: sequenceCollector{count: 5}, failures: 2}
+	s := newTestCollectorService(repo, c, 1, 10)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 5, MaxAttempts: 3, RetryBackoff: 10})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_PENDING, task.Status)
+
+	require.Eventually(t, func() bool {
+		dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+		return err == nil && dbTask.Status == pb.CollectionStatus_COLLECTION_COMPLETED.String()
+	}, 5*time.Second, 5*time.Millisecond)
+
+	resp, err := s.GetCollectionStatus(context.Background(), &pb.StatusRequest{TaskId: "task-1"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.Attempts)
+	assert.Empty(t, resp.Message)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&c.calls))
+}
+
+func TestAutoRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	retryBackoffUnit = time.Millisecond
+	t.Cleanup(func() { retryBackoffUnit = time.Second })
+
+	repo := newMemoryRepository("task-1")
+	c := &flakyCollector{failures: 100}
+	s := newTestCollectorService(repo, c, 1, 10)
+
+	runTestCollection(s, "task-1", &pb.CollectionConfig{MaxAttempts: 2, RetryBackoff: 10})
+	require.Eventually(t, func() bool {
+		dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+		return err == nil && dbTask.Status == model.TaskStatusFailed
+	}, 5*time.Second, 5*time.Millisecond)
+
+	dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, dbTask.Attempts)
+	assert.Equal(t, "connection reset by peer", dbTask.ErrorMessage)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&c.calls))
+}
+
+func TestAutoRetrySkipsPermanentErrors(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &sequenceCollector{err: collector.Permanent(errors.New("invalid URL"))}, 1, 10)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxAttempts: 5})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+	assert.Equal(t, int32(1), task.Attempts)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 5*time.Second, retryDelay(0, 1))
+	assert.Equal(t, 2*time.Second, retryDelay(2, 1))
+	assert.Equal(t, 8*time.Second, retryDelay(2, 3))
+	assert.Equal(t, maxRetryBackoff, retryDelay(60, 20))
+}
@@ -0,0 +1,506 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/pii"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/quality"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// textFlushInterval 未攒满一批时的最长等待时间，避免低速采集时文本长时间停留在内存中
+const textFlushInterval = 500 * time.Millisecond
+
+// textWriterPool 从采集通道读取文本，由多个协程批量写入数据库并更新任务进度
+type textWriterPool struct {
+	repo      repository.Repository
+	task      *CollectionTask
+	maxCount  int32
+	batchSize int
+	onSaved   func(TaskEvent)
+	// quota 为空时不限制来源配额；配额用尽时调用 stop 结束采集
+	quota SourceQuota
+	stop  func()
+	// nearDuplicates 为空时不过滤近似重复文本
+	nearDuplicates NearDuplicateDetector
+	// minQuality 保存文本的最低质量分，0 表示不过滤
+	minQuality int
+	// redactPII 入库前遮盖敏感信息
+	redactPII bool
+	// checkpoint 仅文件采集时不为空，随文本保存推进断点偏移
+	checkpoint *fileCheckpoint
+
+	// mu 保护 quotaSource 与 err，并使各写入协程按顺序推送进度；task 的字段由 task.mu 保护
+	mu          sync.Mutex
+	wg          sync.WaitGroup
+	quotaSource string
+	// err 第一次写入失败的错误，失败后调用 stop 结束采集
+	err error
+}
+
+// startTextWriters 启动 writers 个写入协程消费 texts，通道关闭后协程写完剩余文本退出
+func startTextWriters(ctx context.Context, repo repository.Repository, task *CollectionTask, maxCount int32, writers, batchSize int, texts <-chan *pb.RawText, onSaved func(TaskEvent), quota SourceQuota, nearDuplicates NearDuplicateDetector, minQuality int32, redactPII bool, stop func()) *textWriterPool {
+	if writers <= 0 {
+		writers = 1
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	p := &textWriterPool{
+		repo:      repo,
+		task:      task,
+		maxCount:  maxCount,
+		batchSize: batchSize,
+		onSaved:   onSaved,
+		quota:     quota,
+		stop:      stop,
+
+		nearDuplicates: nearDuplicates,
+		minQuality:     int(minQuality),
+		redactPII:      redactPII,
+	}
+	if task.SourceType == pb.SourceType_LOCAL_FILE {
+		p.checkpoint = newFileCheckpoint(task.ResumeOffset)
+		texts = p.checkpoint.track(texts)
+	}
+	p.wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go p.run(ctx, texts)
+	}
+	return p
+}
+
+// Done 返回所有写入协程退出后关闭的通道
+func (p *textWriterPool) Done() <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// Wait 等待所有写入协程退出，返回成功保存的文本数量
+func (p *textWriterPool) Wait() int32 {
+	p.wg.Wait()
+	return p.collected()
+}
+
+func (p *textWriterPool) collected() int32 {
+	return p.task.snapshot().CollectedCount
+}
+
+// Err 返回第一次写入失败的错误，全部写入成功时为 nil
+func (p *textWriterPool) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// QuotaReached 返回配额已用尽的来源，未触发配额时为空
+func (p *textWriterPool) QuotaReached() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.quotaSource
+}
+
+func (p *textWriterPool) run(ctx context.Context, texts <-chan *pb.RawText) {
+	defer p.wg.Done()
+
+	batch := make([]*pb.RawText, 0, p.batchSize)
+	ticker := time.NewTicker(textFlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		p.flush(ctx, batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case text, ok := <-texts:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, text)
+			if len(batch) >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush 在同一事务中写入一批文本、累加任务进度并推进文件断点，保证数据库中的计数、断点与已保存文本一致。
+// 事务提交后才记录指纹；写入失败时归还预占的配额，记录错误并结束采集
+func (p *textWriterPool) flush(ctx context.Context, batch []*pb.RawText) {
+	// 过滤会复用 batch 的底层数组，先取出偏移；被过滤的文本同样视为已处理
+	offsets := textFileOffsets(batch)
+
+	// 入库前清除控制字符，质量评分与去重基于清理后的文本
+	for _, text := range batch {
+		text.Content = collector.SanitizeText(text.Content)
+	}
+
+	// 先过滤再预占配额，低质量与重复文本不占用配额
+	batch = p.dropLowQuality(batch)
+	batch, fingerprints := p.dropNearDuplicates(ctx, batch)
+	batch, reserved := p.applyQuota(ctx, batch)
+	if len(batch) == 0 {
+		p.advanceCheckpoint(ctx, offsets, 0)
+		return
+	}
+
+	dbTexts := make([]*model.RawText, len(batch))
+	for i, text := range batch {
+		if p.redactPII {
+			redactText(text)
+		}
+		dbTexts[i] = toRawTextModel(text)
+	}
+	labeled := labeledTexts(batch)
+
+	var checkpoint int64
+	err := p.repo.WithTransaction(ctx, func(repo repository.Repository) error {
+		if err := repo.SaveRawTexts(ctx, dbTexts); err != nil {
+			return err
+		}
+		for _, text := range labeled {
+			if err := repo.SaveProcessedText(ctx, text); err != nil {
+				return err
+			}
+		}
+		if err := repo.IncrementTaskProgress(ctx, p.task.ID, len(dbTexts), int(p.maxCount)); err != nil {
+			return err
+		}
+		if p.checkpoint == nil {
+			return nil
+		}
+		checkpoint = p.checkpoint.peek(offsets)
+		return repo.UpdateTaskCheckpoint(ctx, p.task.ID, checkpoint)
+	})
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"task_id": p.task.ID,
+			"count":   len(dbTexts),
+		}).Error("Failed to save raw texts")
+		p.releaseQuota(ctx, reserved)
+		p.fail(fmt.Errorf("failed to save %d raw texts: %w", len(dbTexts), err))
+		return
+	}
+	p.advanceCheckpoint(ctx, offsets, checkpoint)
+	p.recordFingerprints(ctx, batch, fingerprints)
+
+	TextsCollected.WithLabelValues(p.task.SourceType.String()).Add(float64(len(dbTexts)))
+
+	p.mu.Lock()
+	p.task.mu.Lock()
+	p.task.CollectedCount += int32(len(dbTexts))
+	if p.maxCount > 0 {
+		p.task.Progress = (p.task.CollectedCount * 100) / p.maxCount
+	}
+	if p.task.StartTime != nil {
+		if elapsed := time.Since(*p.task.StartTime).Seconds(); elapsed > 0 {
+			CollectionRate.WithLabelValues(p.task.ID).Set(float64(p.task.CollectedCount) / elapsed)
+		}
+	}
+	p.task.mu.Unlock()
+	// 持锁推送，保证订阅方看到的进度单调递增
+	if p.onSaved != nil {
+		p.onSaved(taskEventFrom(p.task))
+	}
+	p.mu.Unlock()
+
+	// TODO: 实现消息队列发布功能，repository 接口中暂无 PublishRawText 方法
+}
+
+// fail 记录第一次写入失败的错误并结束采集，由任务按失败处理或重试
+func (p *textWriterPool) fail(err error) {
+	p.mu.Lock()
+	first := p.err == nil
+	if first {
+		p.err = err
+	}
+	p.mu.Unlock()
+	if first && p.stop != nil {
+		p.stop()
+	}
+}
+
+// advanceCheckpoint 将 offsets 标记为已处理。其他协程先前完成的批次可能使断点越过事务中写入的 saved，
+// 此时单独补写一次；补写失败只会让续采多读几行，不影响已保存的数据
+func (p *textWriterPool) advanceCheckpoint(ctx context.Context, offsets []int64, saved int64) {
+	if p.checkpoint == nil || len(offsets) == 0 {
+		return
+	}
+	offset := p.checkpoint.complete(offsets)
+	if offset > saved {
+		if err := p.repo.UpdateTaskCheckpoint(ctx, p.task.ID, offset); err != nil {
+			logrus.WithError(err).WithField("task_id", p.task.ID).Warn("Failed to save file checkpoint")
+		}
+	}
+
+	p.task.mu.Lock()
+	if offset > p.task.ResumeOffset {
+		p.task.ResumeOffset = offset
+	}
+	p.task.mu.Unlock()
+}
+
+// dropLowQuality 丢弃质量分低于 minQuality 的文本
+func (p *textWriterPool) dropLowQuality(batch []*pb.RawText) []*pb.RawText {
+	if p.minQuality <= 0 {
+		return batch
+	}
+
+	kept := batch[:0]
+	for _, text := range batch {
+		if quality.Evaluate(text.Content).Total >= p.minQuality {
+			kept = append(kept, text)
+		}
+	}
+	if dropped := len(batch) - len(kept); dropped > 0 {
+		logrus.WithFields(logrus.Fields{
+			"task_id":     p.task.ID,
+			"dropped":     dropped,
+			"min_quality": p.minQuality,
+		}).Debug("Dropped low-quality texts")
+	}
+	return kept
+}
+
+// dropNearDuplicates 丢弃与近期采集文本及同批文本近似重复的文本，返回保留的文本及其指纹。
+// 指纹存储不可用时保留文本，避免影响正常采集
+func (p *textWriterPool) dropNearDuplicates(ctx context.Context, batch []*pb.RawText) ([]*pb.RawText, map[*pb.RawText]uint64) {
+	if p.nearDuplicates == nil {
+		return batch, nil
+	}
+
+	fingerprints := make(map[*pb.RawText]uint64, len(batch))
+	hashed := make([]*pb.RawText, 0, len(batch))
+	hashes := make([]uint64, 0, len(batch))
+	for _, text := range batch {
+		if hash, ok := simHash(text.Content); ok {
+			fingerprints[text] = hash
+			hashed = append(hashed, text)
+			hashes = append(hashes, hash)
+		}
+	}
+	duplicates, err := p.nearDuplicates.Check(ctx, hashes)
+	if err != nil {
+		logrus.WithError(err).WithField("task_id", p.task.ID).Warn("Near-duplicate check failed, keeping texts")
+		return batch, fingerprints
+	}
+	dropped := make(map[*pb.RawText]bool)
+	for i, duplicate := range duplicates {
+		if duplicate {
+			dropped[hashed[i]] = true
+		}
+	}
+	if len(dropped) == 0 {
+		return batch, fingerprints
+	}
+
+	kept := batch[:0]
+	for _, text := range batch {
+		if !dropped[text] {
+			kept = append(kept, text)
+		}
+	}
+	logrus.WithFields(logrus.Fields{
+		"task_id": p.task.ID,
+		"dropped": len(dropped),
+	}).Debug("Dropped near-duplicate texts")
+	return kept, fingerprints
+}
+
+// recordFingerprints 记录已保存文本的指纹；记录失败只会让之后的近似重复文本不被过滤
+func (p *textWriterPool) recordFingerprints(ctx context.Context, saved []*pb.RawText, fingerprints map[*pb.RawText]uint64) {
+	if p.nearDuplicates == nil || len(fingerprints) == 0 {
+		return
+	}
+	hashes := make([]uint64, 0, len(saved))
+	for _, text := range saved {
+		if hash, ok := fingerprints[text]; ok {
+			hashes = append(hashes, hash)
+		}
+	}
+	if err := p.nearDuplicates.Add(ctx, hashes); err != nil {
+		logrus.WithError(err).WithField("task_id", p.task.ID).Warn("Failed to record near-duplicate fingerprints")
+	}
+}
+
+// applyQuota 按来源预占配额，丢弃超出配额的文本；有来源配额用尽时结束采集。
+// 返回保留的文本与各来源实际预占的条数，写入失败时据此归还。
+// 配额服务不可用时不做限制，避免影响正常采集
+func (p *textWriterPool) applyQuota(ctx context.Context, batch []*pb.RawText) ([]*pb.RawText, map[string]int) {
+	if p.quota == nil {
+		return batch, nil
+	}
+
+	requested := make(map[string]int)
+	for _, text := range batch {
+		requested[text.Source]++
+	}
+	granted := make(map[string]int, len(requested))
+	reserved := make(map[string]int, len(requested))
+	exhausted := ""
+	for source, n := range requested {
+		got, err := p.quota.Reserve(ctx, source, n)
+		if err != nil {
+			logrus.WithError(err).WithField("source", source).Warn("Quota check failed, saving texts without quota")
+			got = n
+		} else {
+			reserved[source] = got
+		}
+		granted[source] = got
+		if got < n {
+			exhausted = source
+		}
+	}
+	if exhausted == "" {
+		return batch, reserved
+	}
+
+	kept := batch[:0]
+	for _, text := range batch {
+		if granted[text.Source] > 0 {
+			granted[text.Source]--
+			kept = append(kept, text)
+		}
+	}
+
+	p.mu.Lock()
+	first := p.quotaSource == ""
+	if first {
+		p.quotaSource = exhausted
+	}
+	p.mu.Unlock()
+	if first {
+		logrus.WithFields(logrus.Fields{
+			"task_id": p.task.ID,
+			"source":  exhausted,
+		}).Info("Source quota reached, stopping task")
+		if p.stop != nil {
+			p.stop()
+		}
+	}
+	return kept, reserved
+}
+
+// releaseQuota 归还写入失败的文本预占的配额
+func (p *textWriterPool) releaseQuota(ctx context.Context, reserved map[string]int) {
+	for source, n := range reserved {
+		if err := p.quota.Release(ctx, source, n); err != nil {
+			logrus.WithError(err).WithField("source", source).Warn("Failed to release quota")
+		}
+	}
+}
+
+// redactText 遮盖文本中的敏感信息，并在 Metadata 的 pii_types 中记录识别到的类型。
+// 原始 HTML 无法可靠脱敏，一并丢弃
+func redactText(text *pb.RawText) {
+	redacted, matches := pii.Redact(text.Content)
+	delete(text.Metadata, "raw_html")
+	delete(text.Metadata, "raw_html_encoding")
+	delete(text.Metadata, "raw_html_truncated")
+	if len(matches) == 0 {
+		return
+	}
+
+	text.Content = redacted
+	types := pii.Types(matches)
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	if text.Metadata == nil {
+		text.Metadata = make(map[string]string)
+	}
+	text.Metadata["pii_types"] = strings.Join(names, ",")
+}
+
+// labeledTexts 为带标注标签的采集结果生成 ProcessedText，使标注数据可直接用于训练
+func labeledTexts(batch []*pb.RawText) []*model.ProcessedText {
+	var processed []*model.ProcessedText
+	for _, text := range batch {
+		raw, ok := text.Metadata[collector.LabelMetadataKey]
+		if !ok {
+			continue
+		}
+		label, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		processed = append(processed, &model.ProcessedText{
+			ID:                 uuid.New().String(),
+			RawTextID:          text.Id,
+			Content:            text.Content,
+			Tokens:             "[]",
+			Features:           "{}",
+			Label:              &label,
+			Source:             text.Source,
+			Timestamp:          text.Timestamp,
+			ProcessingMetadata: `{"label_origin":"collector"}`,
+		})
+	}
+	return processed
+}
+
+// toRawTextModel 将采集结果转换为数据库模型，Metadata 中的常用键写入独立列，其余键序列化为 JSON
+func toRawTextModel(text *pb.RawText) *model.RawText {
+	dbText := &model.RawText{
+		ID:        text.Id,
+		Content:   text.Content,
+		Source:    text.Source,
+		Timestamp: text.Timestamp,
+	}
+	if len(text.Metadata) > 0 {
+		if rest := dbText.PromoteMetadata(text.Metadata); len(rest) > 0 {
+			dbText.Metadata = encodeMetadata(rest)
+		}
+	}
+	return dbText
+}
+
+// metadataBufferPool 复用序列化 Metadata 的缓冲。缓冲只在 encodeMetadata 内使用，
+// 结果经 string 拷贝后归还；*pb.RawText 与 *model.RawText 会交给仓库层保存，不做池化
+var metadataBufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledMetadataSize 超过该容量的 Metadata 缓冲不归还
+const maxPooledMetadataSize = 64 * 1024
+
+// encodeMetadata 将 Metadata 序列化为 JSON 字符串，结果与 json.Marshal 一致
+func encodeMetadata(metadata map[string]string) string {
+	buf := metadataBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		// 含原始 HTML 的大缓冲不归还，避免长期占用内存
+		if buf.Cap() <= maxPooledMetadataSize {
+			metadataBufferPool.Put(buf)
+		}
+	}()
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(metadata); err != nil {
+		return ""
+	}
+	// Encode 会追加换行
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
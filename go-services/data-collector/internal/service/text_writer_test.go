@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+// memoryRepository 只实现采集任务用到的方法，其余方法调用时会 panic；
+// writeDelay 模拟每次写库的耗时，saveErr 不为空时保存文本失败
+type memoryRepository struct {
+	repository.Repository
+	writeDelay time.Duration
+	saveErr    error
+
+	mu        sync.Mutex
+	texts     map[string]*model.RawText
+	processed []*model.ProcessedText
+	tasks     map[string]*model.CollectionTask
+	writes    int
+}
+
+func newMemoryRepository(taskIDs ...string) *memoryRepository {
+	r := &memoryRepository{
+		texts: make(map[string]*model.RawText),
+		tasks: make(map[string]*model.CollectionTask),
+	}
+	for _, id := range taskIDs {
+		r.tasks[id] = &model.CollectionTask{ID: id, Status: model.TaskStatusPending}
+	}
+	return r
+}
+
+func (r *memoryRepository) WithTransaction(ctx context.Context, fn func(repo repository.Repository) error) error {
+	return fn(r)
+}
+
+func (r *memoryRepository) SaveRawTexts(ctx context.Context, texts []*model.RawText) error {
+	time.Sleep(r.writeDelay)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.writes++
+	for _, text := range texts {
+		r.texts[text.ID] = text
+	}
+	return nil
+}
+
+func (r *memoryRepository) SaveProcessedText(ctx context.Context, text *model.ProcessedText) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processed = append(r.processed, text)
+	return nil
+}
+
+func (r *memoryRepository) IncrementTaskProgress(ctx context.Context, taskID string, delta int, maxCount int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task := r.tasks[taskID]
+	task.CollectedCount += delta
+	if maxCount > 0 {
+		task.Progress = task.CollectedCount * 100 / maxCount
+	}
+	return nil
+}
+
+func (r *memoryRepository) UpdateTaskCheckpoint(ctx context.Context, taskID string, offset int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if task := r.tasks[taskID]; task.ResumeOffset < offset {
+		task.ResumeOffset = offset
+	}
+	return nil
+}
+
+func (r *memoryRepository) CreateCollectionTask(ctx context.Context, task *model.CollectionTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *task
+	r.tasks[task.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepository) GetCollectionTaskByID(ctx context.Context, id string) (*model.CollectionTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("task %s: %w", id, gorm.ErrRecordNotFound)
+	}
+	copied := *task
+	return &copied, nil
+}
+
+func (r *memoryRepository) UpdateCollectionTask(ctx context.Context, task *model.CollectionTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *task
+	// 与 MySQLRepository 一致，断点只由 UpdateTaskCheckpoint 更新
+	if existing, ok := r.tasks[task.ID]; ok {
+		copied.ResumeOffset = existing.ResumeOffset
+	}
+	r.tasks[task.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepository) ClaimCollectionTask(ctx context.Context, taskID, instanceID string, now, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[taskID]
+	if !ok || !claimable(task, now) {
+		return false, nil
+	}
+	task.Status = model.TaskStatusRunning
+	task.ClaimedBy = instanceID
+	task.LeaseExpiresAt = &leaseUntil
+	return true, nil
+}
+
+func (r *memoryRepository) RenewTaskLease(ctx context.Context, taskID, instanceID string, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[taskID]
+	if !ok || task.ClaimedBy != instanceID || task.Status != model.TaskStatusRunning {
+		return false, nil
+	}
+	task.LeaseExpiresAt = &leaseUntil
+	return true, nil
+}
+
+func (r *memoryRepository) ListClaimableTasks(ctx context.Context, now time.Time, limit int) ([]*model.CollectionTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tasks []*model.CollectionTask
+	for _, task := range r.tasks {
+		if claimable(task, now) && (task.RetryAt == nil || !task.RetryAt.After(now)) && len(tasks) < limit {
+			copied := *task
+			tasks = append(tasks, &copied)
+		}
+	}
+	return tasks, nil
+}
+
+func (r *memoryRepository) ResetFailedTask(ctx context.Context, taskID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[taskID]
+	if !ok || task.Status != model.TaskStatusFailed {
+		return false, nil
+	}
+	task.Status = model.TaskStatusPending
+	task.CollectedCount, task.Progress, task.ResumeOffset = 0, 0, 0
+	task.ErrorMessage = ""
+	task.StartTime, task.EndTime = nil, nil
+	task.ClaimedBy, task.LeaseExpiresAt = "", nil
+	task.Attempts, task.RetryAt = 0, nil
+	return true, nil
+}
+
+func claimable(task *model.CollectionTask, now time.Time) bool {
+	if task.Status == model.TaskStatusPending {
+		return true
+	}
+	return task.Status == model.TaskStatusRunning && (task.LeaseExpiresAt == nil || task.LeaseExpiresAt.Before(now))
+}
+
+// sequenceCollector 依次产出 count 条文本，err 不为空时在产出后返回该错误
+type sequenceCollector struct {
+	count int
+	err   error
+}
+
+func (c *sequenceCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	for i := 0; i < c.count; i++ {
+		select {
+		case textChan <- &pb.RawText{Id: fmt.Sprintf("text-%d", i), Content: "content", Source: "api"}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return c.err
+}
+
+func newTestCollectorService(repo repository.Repository, c collector.Collector, writers, batchSize int) *CollectorService {
+	cfg := &config.Config{Collector: config.CollectorConfig{
+		TextBufferSize: 100,
+		WriterCount:    writers,
+		WriteBatchSize: batchSize,
+		TaskLease:      time.Minute,
+		InstanceID:     "test-instance",
+	}}
+	s, err := NewCollectorService(cfg, WithRepository(repo), WithCollectors(map[pb.SourceType]collector.Collector{pb.SourceType_API: c}))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func runTestCollection(s *CollectorService, taskID string, cfg *pb.CollectionConfig) *CollectionTask {
+	task := &CollectionTask{ID: taskID, SourceType: pb.SourceType_API, Status: pb.CollectionStatus_COLLECTION_PENDING}
+	req := &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API},
+		Config: cfg,
+	}
+	s.executeCollectionTask(context.Background(), task, req)
+	return task
+}
+
+func TestExecuteCollectionTaskSavesAllTexts(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &sequenceCollector{count: 237}, 4, 50)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 237})
+
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(237), task.CollectedCount)
+	assert.Len(t, repo.texts, 237)
+	// 237 条按每批 50 条写入，至少需要 5 次
+	assert.GreaterOrEqual(t, repo.writes, 5)
+
+	dbTask, err := repo.GetCollectionTaskByID(context.Background(), "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED.String(), dbTask.Status)
+	assert.Equal(t, 237, dbTask.CollectedCount)
+	assert.Equal(t, 100, dbTask.Progress)
+}
+
+// 多个写入协程更新进度时并发查询任务状态，需配合 -race 运行
+func TestCollectionStatusDuringConcurrentWrites(t *testing.T) {
+	repo := newMemoryRepository()
+	s := newTestCollectorService(repo, &sequenceCollector{count: 500}, 4, 5)
+
+	resp, err := s.CollectText(context.Background(), &pb.CollectRequest{
+		Source: &pb.CollectionSource{Type: pb.SourceType_API},
+		Config: &pb.CollectionConfig{MaxCount: 500},
+	})
+	require.NoError(t, err)
+
+	var last int32
+	require.Eventually(t, func() bool {
+		status, err := s.GetCollectionStatus(context.Background(), &pb.StatusRequest{TaskId: resp.TaskId})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, status.Progress, last)
+		last = status.Progress
+		return status.Status == pb.CollectionStatus_COLLECTION_COMPLETED
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, int32(100), last)
+	assert.Len(t, repo.texts, 500)
+}
+
+func TestExecuteCollectionTaskKeepsPartialCountOnError(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &sequenceCollector{count: 30, err: fmt.Errorf("upstream closed")}, 2, 8)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 100})
+
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+	assert.Equal(t, "upstream closed", task.ErrorMessage)
+	// 出错前已采集的文本全部落库，计数与实际保存的数量一致
+	assert.Len(t, repo.texts, 30)
+	assert.Equal(t, int32(30), task.CollectedCount)
+}
+
+func TestExecuteCollectionTaskFailsWhenWriteFails(t *testing.T) {
+	repo := newMemoryRepository("task-1", "task-2")
+	repo.saveErr = fmt.Errorf("database unavailable")
+	c := &textsCollector{contents: []string{originalAnswer, otherAnswer}}
+	s := newTestCollectorService(repo, c, 1, 10)
+	s.SetSourceQuota(newTestSourceQuota(t, nil, map[string]int{"zhihu:answer": 10}))
+	s.SetNearDuplicateDetector(newTestSimHashStore(t, 6, time.Hour))
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_FAILED, task.Status)
+	assert.Contains(t, task.ErrorMessage, "database unavailable")
+	assert.Zero(t, task.CollectedCount)
+
+	// 未保存的文本归还配额，也不记录指纹，重新采集时正常写入
+	usages, err := s.QuotaUsage(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, usages[0].TotalUsed)
+
+	repo.mu.Lock()
+	repo.saveErr = nil
+	repo.mu.Unlock()
+	task = runTestCollection(s, "task-2", &pb.CollectionConfig{MaxCount: 10})
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Equal(t, int32(2), task.CollectedCount)
+	usages, err = s.QuotaUsage(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, usages[0].TotalUsed)
+}
+
+func BenchmarkExecuteCollectionTask(b *testing.B) {
+	cases := []struct {
+		name      string
+		writers   int
+		batchSize int
+	}{
+		{"single_writer_unbatched", 1, 1},
+		{"pooled_batched", 4, 50},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				repo := newMemoryRepository("task-1")
+				repo.writeDelay = 200 * time.Microsecond
+				s := newTestCollectorService(repo, &sequenceCollector{count: 1000}, tc.writers, tc.batchSize)
+				runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 1000})
+			}
+		})
+	}
+}
+
+// fixedTextsCollector 依次产出给定的文本
+type fixedTextsCollector struct {
+	texts []*pb.RawText
+}
+
+func (c *fixedTextsCollector) Collect(ctx context.Context, source *pb.CollectionSource, cfg *pb.CollectionConfig, textChan chan<- *pb.RawText) error {
+	for _, text := range c.texts {
+		select {
+		case textChan <- text:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func TestSavedTextsHaveControlCharactersStripped(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &fixedTextsCollector{texts: []*pb.RawText{
+		{Id: "dirty", Content: "这条\x00评论\x1b夹带\u0085控制字符😀\n第二行", Source: "web"},
+	}}, 1, 10)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10})
+
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	require.Contains(t, repo.texts, "dirty")
+	assert.Equal(t, "这条评论夹带控制字符😀\n第二行", repo.texts["dirty"].Content)
+}
+
+func TestLabeledTextsAreSavedAsProcessedTexts(t *testing.T) {
+	repo := newMemoryRepository("task-1")
+	s := newTestCollectorService(repo, &fixedTextsCollector{texts: []*pb.RawText{
+		{Id: "labeled", Content: "加微信领取优惠券", Source: "csv:labeled.csv", Metadata: map[string]string{collector.LabelMetadataKey: "1"}},
+		{Id: "unlabeled", Content: "这条评论没有标注", Source: "csv:labeled.csv"},
+	}}, 1, 10)
+
+	task := runTestCollection(s, "task-1", &pb.CollectionConfig{MaxCount: 10})
+
+	assert.Equal(t, pb.CollectionStatus_COLLECTION_COMPLETED, task.Status)
+	assert.Len(t, repo.texts, 2)
+	require.Len(t, repo.processed, 1)
+	processed := repo.processed[0]
+	assert.Equal(t, "labeled", processed.RawTextID)
+	assert.Equal(t, "加微信领取优惠券", processed.Content)
+	require.NotNil(t, processed.Label)
+	assert.Equal(t, 1, *processed.Label)
+}
+
+func TestEncodeMetadataMatchesJSONMarshal(t *testing.T) {
+	for _, metadata := range []map[string]string{
+		{"url": "https://example.com/?a=1&b=<2>"},
+		{"author": "张三", "title": "引号\"与\\反斜杠", "empty": ""},
+	} {
+		want, err := json.Marshal(metadata)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), encodeMetadata(metadata))
+	}
+	assert.Empty(t, toRawTextModel(&pb.RawText{Id: "text-1"}).Metadata)
+}
+
+func TestToRawTextModelPromotesMetadata(t *testing.T) {
+	for _, key := range []string{collector.URLMetadataKey, collector.AuthorMetadataKey, collector.PlatformMetadataKey, collector.TypeMetadataKey} {
+		assert.True(t, model.IsPromotedMetadataKey(key), key)
+	}
+
+	metadata := map[string]string{
+		"url":      "https://www.zhihu.com/question/1",
+		"author":   "张三",
+		"platform": "zhihu",
+		"type":     "answer",
+		"title":    "问题标题",
+	}
+	text := toRawTextModel(&pb.RawText{Id: "text-1", Metadata: metadata})
+	assert.Equal(t, "https://www.zhihu.com/question/1", text.URL)
+	assert.Equal(t, "张三", text.Author)
+	assert.Equal(t, "zhihu", text.Platform)
+	assert.Equal(t, "answer", text.Type)
+	assert.JSONEq(t, `{"title":"问题标题"}`, text.Metadata)
+	assert.Len(t, metadata, 5, "collector metadata must not be modified")
+
+	// 超过列宽的取值保留在 JSON 中；只有常用键时不写 JSON
+	longAuthor := strings.Repeat("作", 256)
+	text = toRawTextModel(&pb.RawText{Id: "text-2", Metadata: map[string]string{"author": longAuthor}})
+	assert.Empty(t, text.Author)
+	assert.JSONEq(t, `{"author":"`+longAuthor+`"}`, text.Metadata)
+	assert.Empty(t, toRawTextModel(&pb.RawText{Id: "text-3", Metadata: map[string]string{"platform": "web"}}).Metadata)
+}
+
+func BenchmarkToRawTextModel(b *testing.B) {
+	text := &pb.RawText{
+		Id:      "text-1",
+		Content: "这是一条用于基准测试的评论内容",
+		Source:  "web",
+		Metadata: map[string]string{
+			"url":    "https://example.com/post/1",
+			"author": "张三",
+			"title":  "基准测试",
+		},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		toRawTextModel(text)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -14,13 +16,31 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/collector"
 	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/config"
 	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/handler"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/kafka"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/repository"
+	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/scheduler"
 	"github.com/mj37yhyy/ai-demo/go-services/data-collector/internal/service"
 	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
 )
 
+const (
+	// defaultHTTPAddress 未配置时的HTTP监听地址
+	defaultHTTPAddress = ":8080"
+	// defaultGRPCAddress 未配置时的gRPC监听地址
+	defaultGRPCAddress = ":9090"
+	// healthCheckInterval gRPC 健康状态的检查间隔
+	healthCheckInterval = 10 * time.Second
+)
+
 // Prometheus metrics
 var (
 	requestsTotal = prometheus.NewCounterVec(
@@ -38,20 +58,16 @@ var (
 		},
 		[]string{"method", "endpoint"},
 	)
-	
-	activeCollectionTasks = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "data_collector_active_tasks",
-			Help: "Number of active collection tasks",
-		},
-	)
 )
 
 func init() {
 	// 注册 Prometheus metrics
 	prometheus.MustRegister(requestsTotal)
 	prometheus.MustRegister(requestDuration)
-	prometheus.MustRegister(activeCollectionTasks)
+	prometheus.MustRegister(service.ActiveCollectionTasks)
+	prometheus.MustRegister(service.TextsCollected)
+	prometheus.MustRegister(service.CollectionRate)
+	prometheus.MustRegister(repository.QueryDuration)
 }
 
 func main() {
@@ -68,20 +84,74 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load config: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		logger.Fatalf("Configuration error: %v", err)
+	}
+
+	// 子命令：导出数据集后退出，不启动服务
+	if len(os.Args) > 1 && (os.Args[1] == exportHFCommand || os.Args[1] == exportFeaturesCommand) {
+		if err := runExportCommand(context.Background(), cfg, os.Args[1], os.Args[2:], logger); err != nil {
+			logger.Fatalf("Export failed: %v", err)
+		}
+		return
+	}
 	
 	// 初始化服务
 	collectorService, err := service.NewCollectorService(cfg)
 	if err != nil {
 		logger.Fatalf("Failed to initialize collector service: %v", err)
 	}
+	defer collectorService.Close()
+	
+	// 初始化Redis
+	// Redis不可用时不阻止启动：增量采集、域名限流和配额按各自方式降级，调度器拿不到锁时在下次检查重试
+	redisClient := repository.NewRedisClient(cfg.Redis)
+	if err := repository.PingRedis(redisClient); err != nil {
+		logger.WithError(err).Warn("Redis unavailable at startup, continuing in degraded mode")
+	}
+	defer redisClient.Close()
+	
+	// 增量采集使用Redis记录已采集URL
+	collectorService.SetSeenStore(collector.NewRedisSeenStore(redisClient, cfg.Collector.SeenTTL))
+	// 多个任务、多个实例访问同一域名时共享限速
+	collectorService.SetDomainLimiter(collector.NewRedisDomainLimiter(redisClient, cfg.Collector.RateLimit, cfg.Collector.DomainRateLimits))
+	// 按来源的每日及累计采集配额
+	collectorService.SetSourceQuota(service.NewRedisSourceQuota(redisClient, cfg.Collector.SourceDailyQuotas, cfg.Collector.SourceTotalQuotas))
+	// 近似重复文本过滤，默认关闭
+	if cfg.Collector.NearDuplicateEnabled {
+		collectorService.SetNearDuplicateDetector(service.NewRedisSimHashStore(redisClient, cfg.Collector.NearDuplicateThreshold, cfg.Collector.NearDuplicateWindow))
+	}
+	
+	// 初始化定时采集调度器
+	collectionScheduler := scheduler.NewScheduler(
+		collectorService.GetRepository(),
+		scheduler.NewRedisLocker(redisClient),
+		func(ctx context.Context, req *pb.CollectRequest) error {
+			_, err := collectorService.CollectText(ctx, req)
+			return err
+		},
+	)
 	
 	// 初始化处理器
-	httpHandler := handler.NewHTTPHandler(collectorService)
+	httpHandler, err := handler.NewHTTPHandler(collectorService, collectionScheduler, cfg.HTTP)
+	if err != nil {
+		logger.Fatalf("Failed to initialize HTTP handler: %v", err)
+	}
+	// 就绪检查：数据库之外还需 Kafka 可连接
+	if cfg.Kafka.Enabled {
+		httpHandler.AddReadinessCheck("kafka", kafka.NewBrokerChecker(cfg.Kafka.Brokers))
+	}
 	
 	// 创建上下文用于优雅关闭
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	
+	// 启动定时采集调度器
+	go collectionScheduler.Run(ctx)
+
+	// 接管本实例重启前或其他副本宕机后遗留的任务
+	go collectorService.RunTaskRecovery(ctx, cfg.Collector.TaskLease)
+	
 	// 启动 gRPC 服务器
 	go func() {
 		if err := startGRPCServer(ctx, cfg, collectorService, logger); err != nil {
@@ -105,6 +175,7 @@ func main() {
 	
 	logger.Info("Shutting down data collector service...")
 	cancel()
+	collectorService.Shutdown()
 	
 	// 给服务一些时间来优雅关闭
 	time.Sleep(5 * time.Second)
@@ -112,27 +183,21 @@ func main() {
 }
 
 func startGRPCServer(ctx context.Context, cfg *config.Config, service *service.CollectorService, logger *logrus.Entry) error {
-	// 从配置中解析端口
-	grpcPort := 9090
-	if cfg.GRPC.Address != "" {
-		// 简单解析，假设格式为 ":port"
-		if cfg.GRPC.Address[0] == ':' {
-			fmt.Sscanf(cfg.GRPC.Address, ":%d", &grpcPort)
-		}
+	// 从配置中解析监听地址
+	grpcAddr, err := resolveListenAddress(cfg.GRPC.Address, defaultGRPCAddress)
+	if err != nil {
+		return fmt.Errorf("invalid gRPC address: %w", err)
 	}
 	
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+	lis, err := net.Listen("tcp", grpcAddr)
 	if err != nil {
-		return fmt.Errorf("failed to listen on gRPC port %d: %w", grpcPort, err)
+		return fmt.Errorf("failed to listen on gRPC address %s: %w", grpcAddr, err)
 	}
 	
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(grpcLoggingInterceptor(logger)),
-	)
+	grpcServer, healthServer := newGRPCServer(service, logger)
+	go watchRepositoryHealth(ctx, healthServer, service.GetRepository(), healthCheckInterval, logger)
 	
-	pb.RegisterDataCollectionServiceServer(grpcServer, service)
-	
-	logger.Infof("gRPC server starting on port %d", grpcPort)
+	logger.Infof("gRPC server starting on %s", grpcAddr)
 	
 	go func() {
 		<-ctx.Done()
@@ -143,14 +208,75 @@ func startGRPCServer(ctx context.Context, cfg *config.Config, service *service.C
 	return grpcServer.Serve(lis)
 }
 
-func startHTTPServer(ctx context.Context, cfg *config.Config, handler *handler.HTTPHandler, logger *logrus.Entry) error {
-	// 从配置中解析端口
-	httpPort := 8080
-	if cfg.HTTP.Address != "" {
-		// 简单解析，假设格式为 ":port"
-		if cfg.HTTP.Address[0] == ':' {
-			fmt.Sscanf(cfg.HTTP.Address, ":%d", &httpPort)
+// newGRPCServer 创建 gRPC 服务器并注册采集服务、标准健康检查服务与反射服务；
+// 健康状态初始为 NOT_SERVING，由 watchRepositoryHealth 更新
+func newGRPCServer(service pb.DataCollectionServiceServer, logger *logrus.Entry) (*grpc.Server, *health.Server) {
+	// 恢复拦截器位于日志拦截器内层，panic 转换为错误后仍会被记录
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcLoggingInterceptor(logger),
+			grpcRecoveryInterceptor(logger),
+		),
+		grpc.ChainStreamInterceptor(
+			grpcStreamRecoveryInterceptor(logger),
+		),
+	)
+
+	pb.RegisterDataCollectionServiceServer(grpcServer, service)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus(pb.DataCollectionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	reflection.Register(grpcServer)
+
+	return grpcServer, healthServer
+}
+
+// healthChecker 可被探测健康状态的依赖
+type healthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// watchRepositoryHealth 定期检查存储健康状态并同步到 gRPC 健康检查服务，ctx 结束时置为 NOT_SERVING
+func watchRepositoryHealth(ctx context.Context, healthServer *health.Server, checker healthChecker, interval time.Duration, logger *logrus.Entry) {
+	setStatus := func(status healthpb.HealthCheckResponse_ServingStatus) {
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(pb.DataCollectionService_ServiceDesc.ServiceName, status)
+	}
+
+	check := func() {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		if err := checker.HealthCheck(checkCtx); err != nil {
+			logger.WithError(err).Warn("Repository health check failed")
+			setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+			return
 		}
+		setStatus(healthpb.HealthCheckResponse_SERVING)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	check()
+	for {
+		select {
+		case <-ctx.Done():
+			healthServer.Shutdown()
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+func startHTTPServer(ctx context.Context, cfg *config.Config, handler *handler.HTTPHandler, logger *logrus.Entry) error {
+	// 从配置中解析监听地址
+	httpAddr, err := resolveListenAddress(cfg.HTTP.Address, defaultHTTPAddress)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP address: %w", err)
 	}
 	
 	// 创建 Gin 引擎
@@ -161,14 +287,14 @@ func startHTTPServer(ctx context.Context, cfg *config.Config, handler *handler.H
 	handler.SetupRoutes(router)
 	
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", httpPort),
+		Addr:         httpAddr,
 		Handler:      router,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 	
-	logger.Infof("HTTP server starting on port %d", httpPort)
+	logger.Infof("HTTP server starting on %s", httpAddr)
 	
 	go func() {
 		<-ctx.Done()
@@ -181,6 +307,23 @@ func startHTTPServer(ctx context.Context, cfg *config.Config, handler *handler.H
 	return server.ListenAndServe()
 }
 
+// resolveListenAddress 解析 host:port 形式的监听地址，地址为空时使用默认值
+func resolveListenAddress(address, defaultAddress string) (string, error) {
+	if address == "" {
+		return defaultAddress, nil
+	}
+	
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", fmt.Errorf("invalid port %q in address %q", port, address)
+	}
+	
+	return net.JoinHostPort(host, port), nil
+}
+
 // gRPC 日志拦截器
 func grpcLoggingInterceptor(logger *logrus.Entry) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
@@ -204,4 +347,38 @@ func grpcLoggingInterceptor(logger *logrus.Entry) grpc.UnaryServerInterceptor {
 		
 		return resp, err
 	}
-}
\ No newline at end of file
+}
+
+// grpcRecoveryInterceptor 捕获处理器中的 panic，记录堆栈并返回 codes.Internal，避免进程崩溃
+func grpcRecoveryInterceptor(logger *logrus.Entry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// grpcStreamRecoveryInterceptor 流式 RPC 的 panic 恢复拦截器
+func grpcStreamRecoveryInterceptor(logger *logrus.Entry) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recoverPanic 记录 panic 信息与堆栈，返回对客户端隐藏细节的内部错误
+func recoverPanic(logger *logrus.Entry, method string, recovered interface{}) error {
+	logger.WithFields(logrus.Fields{
+		"method": method,
+		"panic":  fmt.Sprint(recovered),
+		"stack":  string(debug.Stack()),
+	}).Error("gRPC handler panicked")
+	return status.Error(codes.Internal, "internal server error")
+}
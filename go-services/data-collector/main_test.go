@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/mj37yhyy/ai-demo/go-services/data-collector/proto"
+)
+
+func TestResolveListenAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{":9090", ":9090"},
+		{"0.0.0.0:9090", "0.0.0.0:9090"},
+		{"localhost:9090", "localhost:9090"},
+		{"[::1]:9090", "[::1]:9090"},
+		{"", defaultGRPCAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := resolveListenAddress(tt.address, defaultGRPCAddress)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveListenAddressRejectsInvalid(t *testing.T) {
+	for _, address := range []string{"9090", "localhost", "localhost:http"} {
+		_, err := resolveListenAddress(address, defaultGRPCAddress)
+		assert.Error(t, err, address)
+	}
+}
+
+// fakeHealthChecker 健康状态可由测试切换
+type fakeHealthChecker struct {
+	healthy atomic.Bool
+}
+
+func (f *fakeHealthChecker) HealthCheck(ctx context.Context) error {
+	if f.healthy.Load() {
+		return nil
+	}
+	return errors.New("database unavailable")
+}
+
+func TestGRPCHealthService(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	entry := logrus.NewEntry(logger)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer, healthServer := newGRPCServer(&pb.UnimplementedDataCollectionServiceServer{}, entry)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker := &fakeHealthChecker{}
+	go watchRepositoryHealth(ctx, healthServer, checker, 10*time.Millisecond, entry)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	statusOf := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// 存储不可用时不对外提供服务
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(""))
+
+	checker.healthy.Store(true)
+	assert.Eventually(t, func() bool {
+		return statusOf("") == healthpb.HealthCheckResponse_SERVING &&
+			statusOf(pb.DataCollectionService_ServiceDesc.ServiceName) == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	checker.healthy.Store(false)
+	assert.Eventually(t, func() bool {
+		return statusOf("") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGRPCReflectionListsServices(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer, _ := newGRPCServer(&pb.UnimplementedDataCollectionServiceServer{}, logrus.NewEntry(logger))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var names []string
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		names = append(names, svc.Name)
+	}
+	assert.Contains(t, names, pb.DataCollectionService_ServiceDesc.ServiceName)
+	assert.Contains(t, names, healthpb.Health_ServiceDesc.ServiceName)
+}
+
+// panickingCollectionServer CollectText 总是 panic，GetCollectionStatus 正常返回
+type panickingCollectionServer struct {
+	pb.UnimplementedDataCollectionServiceServer
+}
+
+func (panickingCollectionServer) CollectText(ctx context.Context, req *pb.CollectRequest) (*pb.CollectResponse, error) {
+	panic("collector exploded")
+}
+
+func (panickingCollectionServer) GetCollectionStatus(ctx context.Context, req *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return &pb.StatusResponse{TaskId: req.TaskId, Status: pb.CollectionStatus_COLLECTION_RUNNING}, nil
+}
+
+func TestGRPCRecoveryInterceptor(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer, _ := newGRPCServer(panickingCollectionServer{}, logrus.NewEntry(logger))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewDataCollectionServiceClient(conn)
+
+	_, err = client.CollectText(context.Background(), &pb.CollectRequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, err.Error(), "collector exploded")
+
+	// panic 之后服务器仍可继续处理请求
+	resp, err := client.GetCollectionStatus(context.Background(), &pb.StatusRequest{TaskId: "task-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", resp.TaskId)
+}
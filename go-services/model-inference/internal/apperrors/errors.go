@@ -0,0 +1,101 @@
+// Package apperrors 定义服务层使用的错误类别，处理器据此选择 HTTP 状态码
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 错误类别，通过 errors.Is 判断
+var (
+	ErrNotFound       = errors.New("资源不存在")
+	ErrConflict       = errors.New("资源状态冲突")
+	ErrInvalidInput   = errors.New("请求参数无效")
+	ErrModelNotLoaded = errors.New("模型未加载")
+	ErrTooLarge       = errors.New("请求超过限制")
+	ErrUnavailable    = errors.New("服务暂不可用")
+	ErrRateLimited    = errors.New("请求过于频繁")
+)
+
+// Error 带类别的错误，Kind 为上面的错误类别之一，Err 为可选的底层错误，
+// Details 为返回给客户端的结构化补充信息，如字段级校验错误
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+	Details map[string]interface{}
+}
+
+// New 创建指定类别的错误
+func New(kind error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap 为底层错误附加类别与说明
+func Wrap(kind error, err error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// WithDetails 附加返回给客户端的补充信息
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 使 errors.Is 同时匹配错误类别与底层错误
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// HTTPStatus 根据错误类别返回 HTTP 状态码，未分类的错误返回 500
+func HTTPStatus(err error) int {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+	switch appErr.Kind {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict, ErrModelNotLoaded:
+		return http.StatusConflict
+	case ErrInvalidInput:
+		return http.StatusBadRequest
+	case ErrTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrUnavailable:
+		return http.StatusServiceUnavailable
+	case ErrRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Message 返回最外层分类错误的简短说明，未分类错误返回 fallback
+func Message(err error, fallback string) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return fallback
+}
+
+// Details 返回最外层分类错误的补充信息，没有时返回 nil
+func Details(err error) map[string]interface{} {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Details
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", New(ErrNotFound, "模型 %s 不存在", "m"), http.StatusNotFound},
+		{"conflict", New(ErrConflict, "模型 m 已经加载"), http.StatusConflict},
+		{"model not loaded", New(ErrModelNotLoaded, "模型 m 未加载"), http.StatusConflict},
+		{"invalid input", New(ErrInvalidInput, "文本不能为空"), http.StatusBadRequest},
+		{"too large", New(ErrTooLarge, "批量大小超过限制"), http.StatusRequestEntityTooLarge},
+		{"rate limited", New(ErrRateLimited, "模型 m 请求过于频繁"), http.StatusTooManyRequests},
+		{"unavailable", Wrap(ErrUnavailable, errors.New("draining"), "服务正在关闭"), http.StatusServiceUnavailable},
+		{"wrapped by fmt", fmt.Errorf("推理失败: %w", New(ErrModelNotLoaded, "模型 m 未加载")), http.StatusConflict},
+		{"untyped", errors.New("数据库连接失败"), http.StatusInternalServerError},
+		{"nil kind", &Error{Message: "unknown"}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTTPStatus(tt.err))
+		})
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	cause := errors.New("record not found")
+	err := fmt.Errorf("查询失败: %w", Wrap(ErrNotFound, cause, "模型 m 不存在"))
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "查询失败: 模型 m 不存在: record not found", err.Error())
+	assert.Equal(t, "模型 m 不存在", Message(err, "fallback"))
+	assert.Equal(t, "fallback", Message(cause, "fallback"))
+}
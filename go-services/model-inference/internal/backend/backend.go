@@ -0,0 +1,237 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// InferenceBackend 模型推理后端接口，具体实现可以是本地计算、ONNX 会话或远程推理服务
+type InferenceBackend interface {
+	// Embed 批量计算文本向量，返回结果与输入一一对应
+	Embed(ctx context.Context, modelName string, texts []string) ([][]float64, error)
+	// ScoreLabels 计算文本属于各候选标签的独立得分（0-1），用于多标签分类
+	ScoreLabels(ctx context.Context, modelName string, text string, labels []string) (map[string]float64, error)
+	// Logits 计算文本在 classes 个类别上的原始输出（未归一化），下标即类别编号
+	Logits(ctx context.Context, modelName string, text string, classes int) ([]float64, error)
+	// Tokenize 按模型的分词规则切分文本，返回的词元按在文本中的位置排列
+	Tokenize(ctx context.Context, modelName string, text string) ([]Token, error)
+	// ExtractEntities 识别文本中的命名实体，偏移量按字符（rune）计算
+	ExtractEntities(ctx context.Context, modelName string, text string) ([]model.Entity, error)
+	// LoadModel 从 path 加载模型权重，progress 以 0-100 报告加载进度，返回模型常驻内存字节数
+	LoadModel(ctx context.Context, modelName, path string, progress func(percent float64)) (int64, error)
+	// UnloadModel 释放模型占用的资源
+	UnloadModel(modelName string)
+	// PromoteModel 将以 shadowName 加载的权重原子地替换为 modelName 的权重，并释放旧权重
+	PromoteModel(shadowName, modelName string) error
+	// Ping 探测模型在后端是否仍可用，例如外部推理进程或 ONNX 会话是否存活
+	Ping(ctx context.Context, modelName string) error
+}
+
+// DefaultEmbeddingDimension 本地后端的默认向量维度
+const DefaultEmbeddingDimension = 128
+
+// LocalBackend 本地后端：基于字符 n-gram 特征哈希生成确定性的文本向量
+// 不依赖外部模型文件，相同文本始终得到相同向量，字面相近的文本向量也相近
+type LocalBackend struct {
+	dimension int
+
+	mu      sync.Mutex
+	weights map[string][]byte // 已加载的模型权重
+}
+
+// NewLocalBackend 创建本地后端
+func NewLocalBackend(dimension int) *LocalBackend {
+	if dimension <= 0 {
+		dimension = DefaultEmbeddingDimension
+	}
+	return &LocalBackend{dimension: dimension, weights: make(map[string][]byte)}
+}
+
+// loadChunkSize 加载模型文件时每次读取的字节数
+const loadChunkSize = 1 << 20
+
+// LoadModel 分块读取模型文件到内存，按已读取字节数报告进度
+func (b *LocalBackend) LoadModel(ctx context.Context, modelName, path string, progress func(percent float64)) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("打开模型文件失败: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("读取模型文件信息失败: %w", err)
+	}
+
+	total := info.Size()
+	weights := make([]byte, 0, total)
+	chunk := make([]byte, loadChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, fmt.Errorf("加载模型被取消: %w", err)
+		}
+		n, err := file.Read(chunk)
+		weights = append(weights, chunk[:n]...)
+		if total > 0 {
+			progress(float64(len(weights)) * 100 / float64(total))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("读取模型文件失败: %w", err)
+		}
+	}
+	progress(100)
+
+	b.mu.Lock()
+	b.weights[modelName] = weights
+	b.mu.Unlock()
+	return int64(len(weights)), nil
+}
+
+// UnloadModel 释放模型权重
+func (b *LocalBackend) UnloadModel(modelName string) {
+	b.mu.Lock()
+	delete(b.weights, modelName)
+	b.mu.Unlock()
+}
+
+// PromoteModel 用影子权重替换模型权重，替换前后模型始终可用
+func (b *LocalBackend) PromoteModel(shadowName, modelName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	weights, ok := b.weights[shadowName]
+	if !ok {
+		return fmt.Errorf("影子模型 %s 未加载", shadowName)
+	}
+	b.weights[modelName] = weights
+	delete(b.weights, shadowName)
+	return nil
+}
+
+// Ping 检查模型权重是否仍在内存中
+func (b *LocalBackend) Ping(ctx context.Context, modelName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.weights[modelName]; !ok {
+		return fmt.Errorf("模型 %s 的权重未加载", modelName)
+	}
+	return nil
+}
+
+// Embed 批量计算文本向量
+func (b *LocalBackend) Embed(ctx context.Context, modelName string, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("计算向量被取消: %w", err)
+		}
+		embeddings[i] = b.embed(text)
+	}
+	return embeddings, nil
+}
+
+// ScoreLabels 以文本向量与标签名向量的余弦相似度映射到 0-1 作为标签得分
+func (b *LocalBackend) ScoreLabels(ctx context.Context, modelName string, text string, labels []string) (map[string]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("计算标签得分被取消: %w", err)
+	}
+
+	textVector := b.embed(text)
+	scores := make(map[string]float64, len(labels))
+	for _, label := range labels {
+		scores[label] = (CosineSimilarity(textVector, b.embed(label)) + 1) / 2
+	}
+	return scores, nil
+}
+
+// logitScale 本地后端 logits 的缩放系数，余弦相似度范围较窄，放大后 softmax 才有区分度
+const logitScale = 4
+
+// Logits 以文本向量与各类别向量的余弦相似度作为 logits，类别向量由模型名与类别编号生成
+func (b *LocalBackend) Logits(ctx context.Context, modelName string, text string, classes int) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("计算 logits 被取消: %w", err)
+	}
+
+	textVector := b.embed(text)
+	logits := make([]float64, classes)
+	for i := range logits {
+		logits[i] = logitScale * CosineSimilarity(textVector, b.embed(fmt.Sprintf("%s#%d", modelName, i)))
+	}
+	return logits, nil
+}
+
+// embed 将字符 unigram 与 bigram 哈希到固定维度，并按符号位累加
+func (b *LocalBackend) embed(text string) []float64 {
+	vector := make([]float64, b.dimension)
+	runes := []rune(strings.ToLower(strings.TrimSpace(text)))
+
+	add := func(gram string, weight float64) {
+		h := fnv.New64a()
+		h.Write([]byte(gram))
+		sum := h.Sum64()
+		index := int(sum % uint64(b.dimension))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[index] += weight
+	}
+
+	for i, r := range runes {
+		add(string(r), 1)
+		if i+1 < len(runes) {
+			add(string(runes[i:i+2]), 2)
+		}
+	}
+
+	return vector
+}
+
+// Normalize 返回 L2 归一化后的向量，零向量原样返回
+func Normalize(vector []float64) []float64 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+
+	normalized := make([]float64, len(vector))
+	for i, v := range vector {
+		normalized[i] = v / norm
+	}
+	return normalized
+}
+
+// CosineSimilarity 计算两个向量的余弦相似度，维度不一致或存在零向量时返回 0
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,186 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// 本地后端使用的规则与词典，仅覆盖常见中文实体，生产环境应替换为序列标注模型
+var (
+	// commonSurnames 常见单字与复姓
+	commonSurnames = []string{
+		"欧阳", "司马", "诸葛", "上官", "东方", "慕容",
+		"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱",
+		"胡", "郭", "何", "高", "林", "罗", "郑", "梁", "谢", "宋", "唐", "许", "韩", "冯",
+		"邓", "曹", "彭", "曾", "萧", "田", "董", "潘", "袁", "蔡", "蒋", "余", "于", "杜",
+	}
+
+	// personFollowers 人名之后常出现的词，用于确定人名边界
+	personFollowers = []string{
+		"先生", "女士", "教授", "博士", "老师", "表示", "认为", "指出", "昨天", "今天", "明天",
+		"说", "在", "是", "和", "与", "的", "去", "来", "到", "等",
+	}
+
+	// knownLocations 常见地名
+	knownLocations = []string{
+		"中国", "美国", "日本", "英国", "法国", "德国", "韩国", "俄罗斯",
+		"北京", "上海", "天津", "重庆", "广州", "深圳", "杭州", "南京", "武汉", "成都",
+		"西安", "苏州", "长沙", "郑州", "青岛", "厦门", "香港", "澳门", "台湾",
+		"广东", "浙江", "江苏", "四川", "湖北", "湖南", "山东", "河南", "河北", "福建",
+	}
+
+	// locationSuffixes 可附加在地名后的行政区划后缀
+	locationSuffixes = []string{"省", "市", "县", "区"}
+
+	// orgSuffixes 机构名后缀
+	orgSuffixes = []string{"有限公司", "公司", "集团", "大学", "学院", "银行", "医院", "研究院", "研究所", "协会", "委员会"}
+
+	// entityBoundaries 机构名向前扩展时遇到即停止的字
+	entityBoundaries = "在和与及的是了从向对把被说"
+)
+
+const (
+	maxOrgPrefixLen     = 6
+	locationConfidence  = 0.95
+	orgConfidence       = 0.85
+	personConfidence    = 0.8
+	personMaxGivenRunes = 2
+)
+
+// ExtractEntities 基于词典与规则识别人名、地名和机构名，偏移量按字符（rune）计算
+func (b *LocalBackend) ExtractEntities(ctx context.Context, modelName string, text string) ([]model.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("实体识别被取消: %w", err)
+	}
+
+	runes := []rune(text)
+	taken := make([]bool, len(runes))
+	var entities []model.Entity
+
+	add := func(entityType model.EntityType, start, end int, confidence float64) {
+		for i := start; i < end; i++ {
+			if taken[i] {
+				return
+			}
+		}
+		for i := start; i < end; i++ {
+			taken[i] = true
+		}
+		entities = append(entities, model.Entity{
+			Type:       entityType,
+			Text:       string(runes[start:end]),
+			Start:      start,
+			End:        end,
+			Confidence: confidence,
+		})
+	}
+
+	// 机构名优先，避免“北京大学”被拆成地名
+	for i := range runes {
+		for _, suffix := range orgSuffixes {
+			end := i + len([]rune(suffix))
+			if !hasRunesAt(runes, i, suffix) {
+				continue
+			}
+			start := i
+			for start > 0 && i-start < maxOrgPrefixLen && isHan(runes[start-1]) && !strings.ContainsRune(entityBoundaries, runes[start-1]) {
+				start--
+			}
+			if start < i {
+				add(model.EntityTypeOrganization, start, end, orgConfidence)
+			}
+			break
+		}
+	}
+
+	for i := range runes {
+		for _, location := range knownLocations {
+			if !hasRunesAt(runes, i, location) {
+				continue
+			}
+			end := i + len([]rune(location))
+			for _, suffix := range locationSuffixes {
+				if hasRunesAt(runes, end, suffix) {
+					end += len([]rune(suffix))
+					break
+				}
+			}
+			add(model.EntityTypeLocation, i, end, locationConfidence)
+			break
+		}
+	}
+
+	for i := range runes {
+		if taken[i] {
+			continue
+		}
+		for _, surname := range commonSurnames {
+			if !hasRunesAt(runes, i, surname) {
+				continue
+			}
+			nameStart := i + len([]rune(surname))
+			// 优先尝试较长的名字，名字之后须为边界词、标点或文本结尾
+			for given := personMaxGivenRunes; given >= 1; given-- {
+				end := nameStart + given
+				if end > len(runes) || !allHan(runes[nameStart:end]) {
+					continue
+				}
+				if isPersonBoundary(runes, end) {
+					add(model.EntityTypePerson, i, end, personConfidence)
+					break
+				}
+			}
+			break
+		}
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Start < entities[j].Start
+	})
+	return entities, nil
+}
+
+// hasRunesAt 判断 runes 从 pos 开始是否为 word
+func hasRunesAt(runes []rune, pos int, word string) bool {
+	w := []rune(word)
+	if pos < 0 || pos+len(w) > len(runes) {
+		return false
+	}
+	for i, r := range w {
+		if runes[pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// isPersonBoundary 判断 pos 处是否为人名的结束边界
+func isPersonBoundary(runes []rune, pos int) bool {
+	if pos == len(runes) || !isHan(runes[pos]) {
+		return true
+	}
+	for _, follower := range personFollowers {
+		if hasRunesAt(runes, pos, follower) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+func allHan(runes []rune) bool {
+	for _, r := range runes {
+		if !isHan(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestExtractEntitiesChinese(t *testing.T) {
+	text := "据报道，张伟昨天在北京市参加了清华大学的会议。"
+	entities, err := NewLocalBackend(0).ExtractEntities(context.Background(), "ner", text)
+	require.NoError(t, err)
+
+	require.Len(t, entities, 3)
+	assert.Equal(t, model.Entity{Type: model.EntityTypePerson, Text: "张伟", Start: 4, End: 6, Confidence: personConfidence}, entities[0])
+	assert.Equal(t, model.Entity{Type: model.EntityTypeLocation, Text: "北京市", Start: 9, End: 12, Confidence: locationConfidence}, entities[1])
+	assert.Equal(t, model.EntityTypeOrganization, entities[2].Type)
+	assert.Equal(t, "清华大学", entities[2].Text)
+
+	// 偏移量按字符计算，可直接用于截取 rune 切片
+	runes := []rune(text)
+	for _, entity := range entities {
+		assert.Equal(t, entity.Text, string(runes[entity.Start:entity.End]))
+	}
+}
+
+func TestExtractEntitiesNoMatch(t *testing.T) {
+	entities, err := NewLocalBackend(0).ExtractEntities(context.Background(), "ner", "hello world 123")
+	require.NoError(t, err)
+	assert.Empty(t, entities)
+}
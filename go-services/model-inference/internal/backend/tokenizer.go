@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token 分词得到的词元，Start、End 为词元在原文中的字节偏移（左闭右开）
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Tokenize 本地后端的规则分词：中日韩文字每字一个词元，连续的字母与数字合为一个词元，
+// 其余非空白字符各为一个词元，空白不计入词元。生产环境应替换为模型自带的分词器
+func (b *LocalBackend) Tokenize(ctx context.Context, modelName string, text string) ([]Token, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("分词被取消: %w", err)
+	}
+
+	var tokens []Token
+	wordStart := -1
+	flushWord := func(end int) {
+		if wordStart >= 0 {
+			tokens = append(tokens, Token{Text: text[wordStart:end], Start: wordStart, End: end})
+			wordStart = -1
+		}
+	}
+
+	for i, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord(i)
+			end := i + utf8.RuneLen(r)
+			tokens = append(tokens, Token{Text: text[i:end], Start: i, End: end})
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if wordStart < 0 {
+				wordStart = i
+			}
+		case unicode.IsSpace(r):
+			flushWord(i)
+		default:
+			flushWord(i)
+			end := i + utf8.RuneLen(r)
+			tokens = append(tokens, Token{Text: text[i:end], Start: i, End: end})
+		}
+	}
+	flushWord(len(text))
+
+	return tokens, nil
+}
+
+// isCJK 判断字符是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
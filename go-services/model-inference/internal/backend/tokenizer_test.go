@@ -0,0 +1,22 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeMixedText(t *testing.T) {
+	text := "你好 GPT4 模型!"
+	tokens, err := NewLocalBackend(0).Tokenize(context.Background(), "tokenizer", text)
+	require.NoError(t, err)
+
+	var texts []string
+	for _, token := range tokens {
+		texts = append(texts, token.Text)
+		assert.Equal(t, token.Text, text[token.Start:token.End])
+	}
+	assert.Equal(t, []string{"你", "好", "GPT4", "模", "型", "!"}, texts)
+}
@@ -0,0 +1,193 @@
+package batching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDraining 批处理器正在排空或已停止，不再接受新请求
+var ErrDraining = errors.New("批处理器已停止接收新请求")
+
+// BatchFunc 批量执行函数，返回结果需与输入一一对应
+type BatchFunc[Req, Resp any] func(ctx context.Context, key string, reqs []Req) ([]Resp, error)
+
+// Batcher 动态批处理器：同一 key（通常为模型名）的请求在 maxWait 内聚合，
+// 达到 maxSize 或等待超时后合并为一次批量调用
+type Batcher[Req, Resp any] struct {
+	fn      BatchFunc[Req, Resp]
+	maxSize int
+	maxWait time.Duration
+	timeout time.Duration
+
+	mu       sync.Mutex
+	pending  map[string]*batch[Req, Resp]
+	draining bool
+	inflight sync.WaitGroup
+}
+
+type result[Resp any] struct {
+	resp Resp
+	err  error
+}
+
+type call[Req, Resp any] struct {
+	req  Req
+	done chan result[Resp]
+}
+
+type batch[Req, Resp any] struct {
+	calls []*call[Req, Resp]
+	timer *time.Timer
+}
+
+// New 创建动态批处理器，timeout 为单次批量调用的超时时间
+func New[Req, Resp any](fn BatchFunc[Req, Resp], maxSize int, maxWait, timeout time.Duration) *Batcher[Req, Resp] {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+	return &Batcher[Req, Resp]{
+		fn:      fn,
+		maxSize: maxSize,
+		maxWait: maxWait,
+		timeout: timeout,
+		pending: make(map[string]*batch[Req, Resp]),
+	}
+}
+
+// Submit 提交单个请求并等待其所在批次执行完成
+func (b *Batcher[Req, Resp]) Submit(ctx context.Context, key string, req Req) (Resp, error) {
+	resps, err := b.SubmitAll(ctx, key, []Req{req})
+	if err != nil {
+		var zero Resp
+		return zero, err
+	}
+	return resps[0], nil
+}
+
+// SubmitAll 提交一组请求，这些请求可能与其他调用方的请求合并到同一批次
+func (b *Batcher[Req, Resp]) SubmitAll(ctx context.Context, key string, reqs []Req) ([]Resp, error) {
+	calls := make([]*call[Req, Resp], len(reqs))
+	for i, req := range reqs {
+		calls[i] = &call[Req, Resp]{req: req, done: make(chan result[Resp], 1)}
+		if err := b.enqueue(key, calls[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	resps := make([]Resp, len(reqs))
+	for i, c := range calls {
+		select {
+		case r := <-c.done:
+			if r.err != nil {
+				return nil, r.err
+			}
+			resps[i] = r.resp
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return resps, nil
+}
+
+// enqueue 将请求加入 key 对应的待处理批次，批次满时立即派发
+func (b *Batcher[Req, Resp]) enqueue(key string, c *call[Req, Resp]) error {
+	b.mu.Lock()
+	if b.draining {
+		b.mu.Unlock()
+		return ErrDraining
+	}
+	bt := b.pending[key]
+	if bt == nil {
+		bt = &batch[Req, Resp]{}
+		b.pending[key] = bt
+		bt.timer = time.AfterFunc(b.maxWait, func() { b.flush(key, bt) })
+	}
+	bt.calls = append(bt.calls, c)
+
+	if len(bt.calls) < b.maxSize {
+		b.mu.Unlock()
+		return nil
+	}
+	delete(b.pending, key)
+	bt.timer.Stop()
+	b.inflight.Add(1)
+	b.mu.Unlock()
+
+	go b.dispatch(key, bt)
+	return nil
+}
+
+// flush 等待超时后派发未满的批次
+func (b *Batcher[Req, Resp]) flush(key string, bt *batch[Req, Resp]) {
+	b.mu.Lock()
+	if b.pending[key] != bt {
+		// 批次已因达到上限被派发
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, key)
+	b.inflight.Add(1)
+	b.mu.Unlock()
+
+	b.dispatch(key, bt)
+}
+
+// Drain 停止接受新请求，立即派发所有未满的批次，并等待进行中的批量调用完成；
+// ctx 到期时返回 ctx.Err()，尚未完成的调用仍会在结束后把结果交给调用方
+func (b *Batcher[Req, Resp]) Drain(ctx context.Context) error {
+	b.mu.Lock()
+	b.draining = true
+	for key, bt := range b.pending {
+		delete(b.pending, key)
+		bt.timer.Stop()
+		b.inflight.Add(1)
+		go b.dispatch(key, bt)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dispatch 执行批量调用并将结果分发给各调用方
+func (b *Batcher[Req, Resp]) dispatch(key string, bt *batch[Req, Resp]) {
+	defer b.inflight.Done()
+
+	ctx := context.Background()
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	reqs := make([]Req, len(bt.calls))
+	for i, c := range bt.calls {
+		reqs[i] = c.req
+	}
+
+	resps, err := b.fn(ctx, key, reqs)
+	if err == nil && len(resps) != len(reqs) {
+		err = fmt.Errorf("批量结果数量 %d 与请求数量 %d 不一致", len(resps), len(reqs))
+	}
+
+	for i, c := range bt.calls {
+		if err != nil {
+			c.done <- result[Resp]{err: err}
+			continue
+		}
+		c.done <- result[Resp]{resp: resps[i]}
+	}
+}
@@ -0,0 +1,152 @@
+package batching
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatcherMergesConcurrentSubmits(t *testing.T) {
+	var calls int32
+	b := New(func(ctx context.Context, key string, reqs []int) ([]int, error) {
+		atomic.AddInt32(&calls, 1)
+		resps := make([]int, len(reqs))
+		for i, r := range reqs {
+			resps[i] = r * 2
+		}
+		return resps, nil
+	}, 4, time.Second, time.Second)
+
+	var wg sync.WaitGroup
+	results := make([]int, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := b.Submit(context.Background(), "model", i)
+			assert.NoError(t, err)
+			results[i] = resp
+		}(i)
+	}
+	wg.Wait()
+
+	// 达到批次上限后立即派发，无需等待 maxWait
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, []int{0, 2, 4, 6}, results)
+}
+
+func TestBatcherFlushesPartialBatch(t *testing.T) {
+	var sizes []int
+	var mu sync.Mutex
+	b := New(func(ctx context.Context, key string, reqs []string) ([]string, error) {
+		mu.Lock()
+		sizes = append(sizes, len(reqs))
+		mu.Unlock()
+		return reqs, nil
+	}, 10, 5*time.Millisecond, time.Second)
+
+	resps, err := b.SubmitAll(context.Background(), "model", []string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, resps)
+	assert.Equal(t, []int{3}, sizes)
+}
+
+func TestBatcherPropagatesErrors(t *testing.T) {
+	boom := errors.New("boom")
+	b := New(func(ctx context.Context, key string, reqs []int) ([]int, error) {
+		return nil, boom
+	}, 2, time.Millisecond, time.Second)
+
+	_, err := b.Submit(context.Background(), "model", 1)
+	assert.ErrorIs(t, err, boom)
+
+	// 结果数量不一致时返回错误
+	short := New(func(ctx context.Context, key string, reqs []int) ([]int, error) {
+		return reqs[:0], nil
+	}, 2, time.Millisecond, time.Second)
+	_, err = short.Submit(context.Background(), "model", 1)
+	assert.Error(t, err)
+}
+
+func TestBatcherDrainFlushesQueuedRequests(t *testing.T) {
+	var calls int32
+	b := New(func(ctx context.Context, key string, reqs []int) ([]int, error) {
+		atomic.AddInt32(&calls, 1)
+		return reqs, nil
+	}, 100, time.Hour, time.Second)
+
+	// maxWait 足够长，只有 Drain 才会派发这些未满的批次
+	var wg sync.WaitGroup
+	errs := make([]error, 6)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "model-a"
+			if i%2 == 1 {
+				key = "model-b"
+			}
+			_, errs[i] = b.Submit(context.Background(), key, i)
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		n := 0
+		for _, bt := range b.pending {
+			n += len(bt.calls)
+		}
+		return n == 6
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, b.Drain(ctx))
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	_, err := b.Submit(context.Background(), "model-a", 1)
+	assert.ErrorIs(t, err, ErrDraining)
+}
+
+func TestBatcherDrainDeadline(t *testing.T) {
+	release := make(chan struct{})
+	b := New(func(ctx context.Context, key string, reqs []int) ([]int, error) {
+		<-release
+		return reqs, nil
+	}, 100, time.Hour, time.Second)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.Submit(context.Background(), "model", 1)
+		done <- err
+	}()
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.pending) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Drain(ctx), context.DeadlineExceeded)
+
+	// 超时后进行中的调用仍会正常返回结果
+	close(release)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("submit did not return after batch completed")
+	}
+}
@@ -14,6 +14,7 @@ type Config struct {
 	Redis     RedisConfig     `mapstructure:"redis"`
 	Model     ModelConfig     `mapstructure:"model"`
 	Inference InferenceConfig `mapstructure:"inference"`
+	Kafka     KafkaConfig     `mapstructure:"kafka"`
 	Log       LogConfig       `mapstructure:"log"`
 }
 
@@ -24,6 +25,8 @@ type ServerConfig struct {
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
 	IdleTimeout  int    `mapstructure:"idle_timeout"`
+	MaxBodyBytes int64  `mapstructure:"max_body_bytes"`
+	AdminToken   string `mapstructure:"admin_token"`
 }
 
 // DatabaseConfig 数据库配置
@@ -36,6 +39,10 @@ type DatabaseConfig struct {
 	Charset  string `mapstructure:"charset"`
 	ParseTime bool  `mapstructure:"parse_time"`
 	Loc      string `mapstructure:"loc"`
+	// 连接池配置，ConnMaxLifetime 单位为秒
+	MaxOpenConns    int `mapstructure:"max_open_conns"`
+	MaxIdleConns    int `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime int `mapstructure:"conn_max_lifetime"`
 }
 
 // RedisConfig Redis配置
@@ -52,6 +59,14 @@ type ModelConfig struct {
 	CacheTTL        int    `mapstructure:"cache_ttl"`
 	MaxLoadedModels int    `mapstructure:"max_loaded_models"`
 	LoadTimeout     int    `mapstructure:"load_timeout"`
+	// HealthCheckInterval 探测已加载模型推理后端的间隔（秒），随配置热更新生效
+	HealthCheckInterval int `mapstructure:"health_check_interval"`
+	// HealthCheckFailures 连续探测失败达到该次数时将模型标记为 error，随配置热更新生效
+	HealthCheckFailures int `mapstructure:"health_check_failures"`
+	// Preload 启动时预加载的模型，全部结束前就绪检查返回未就绪；只在启动时生效
+	Preload []string `mapstructure:"preload"`
+	// PreloadConcurrency 同时预加载的模型数
+	PreloadConcurrency int `mapstructure:"preload_concurrency"`
 }
 
 // InferenceConfig 推理配置
@@ -61,6 +76,67 @@ type InferenceConfig struct {
 	MaxConcurrency  int `mapstructure:"max_concurrency"`
 	ResultCacheTTL  int `mapstructure:"result_cache_ttl"`
 	HistoryRetention int `mapstructure:"history_retention"`
+	BatchWaitMs      int `mapstructure:"batch_wait_ms"`
+	// VectorRetention 向量库中文本向量的保留天数，超过后由清理任务删除，0 表示不清理
+	VectorRetention int `mapstructure:"vector_retention"`
+	// HistoryCleanupInterval 推理历史与向量库的清理间隔（分钟）
+	HistoryCleanupInterval int `mapstructure:"history_cleanup_interval"`
+	// TrafficSplits 文本分类的 A/B 分流配置，随配置热更新生效
+	TrafficSplits []TrafficSplit `mapstructure:"traffic_splits"`
+	// RateLimits 按模型限流配置，随配置热更新生效
+	RateLimits []ModelRateLimit `mapstructure:"rate_limits"`
+	// SampleLog 推理输入输出抽样日志，随配置热更新生效
+	SampleLog SampleLogConfig `mapstructure:"sample_log"`
+	// CallbackAllowedHosts 允许异步预测回调到内网地址的主机名或 IP；
+	// 其他回调地址解析到回环、私有、链路本地或未指定地址时拒绝
+	CallbackAllowedHosts []string `mapstructure:"callback_allowed_hosts"`
+}
+
+// SampleLogConfig 按 Rate（0-1）的比例抽样记录推理输入与输出，记录前对敏感信息脱敏；Enabled 为 false 时不记录
+type SampleLogConfig struct {
+	Enabled bool    `mapstructure:"enabled"`
+	Rate    float64 `mapstructure:"rate"`
+}
+
+// TrafficSplit 将请求 Model 的文本分类流量按 Percent 百分比分给 Candidate 模型，其余仍由 Model 处理
+type TrafficSplit struct {
+	Model     string  `mapstructure:"model"`
+	Candidate string  `mapstructure:"candidate"`
+	Percent   float64 `mapstructure:"percent"`
+}
+
+// ModelRateLimit 限制 Model 每秒处理的请求数，Burst 为令牌桶容量，未配置时取 RequestsPerSecond 向上取整
+type ModelRateLimit struct {
+	Model             string  `mapstructure:"model"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	Burst             int     `mapstructure:"burst"`
+}
+
+// KafkaConfig Kafka配置，Enabled 为 false 时服务不连接 Kafka，也不校验其余字段
+type KafkaConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Brokers []string `mapstructure:"brokers"`
+	// GroupID 消费预处理文本时使用的消费者组
+	GroupID string            `mapstructure:"group_id"`
+	Topics  KafkaTopicsConfig `mapstructure:"topics"`
+	Auth    KafkaAuthConfig   `mapstructure:"auth"`
+}
+
+// KafkaTopicsConfig Kafka主题配置，默认值与 data-collector 的主题常量一致
+type KafkaTopicsConfig struct {
+	// ProcessedText 消费的预处理文本主题
+	ProcessedText string `mapstructure:"processed_text"`
+	// AuditResult 发布审核结果的主题
+	AuditResult string `mapstructure:"audit_result"`
+}
+
+// KafkaAuthConfig Kafka认证配置，Mechanism 为空时不启用 SASL
+type KafkaAuthConfig struct {
+	// Mechanism SASL 机制：PLAIN、SCRAM-SHA-256 或 SCRAM-SHA-512
+	Mechanism string `mapstructure:"mechanism"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	TLS       bool   `mapstructure:"tls"`
 }
 
 // LogConfig 日志配置
@@ -78,6 +154,18 @@ func Load() (*Config, error) {
 	viper.AddConfigPath("./config")
 	viper.AddConfigPath("/etc/textaudit/")
 
+	return read()
+}
+
+// LoadFile 从指定的配置文件加载配置
+func LoadFile(path string) (*Config, error) {
+	viper.SetConfigFile(path)
+
+	return read()
+}
+
+// read 读取配置文件与环境变量并解析
+func read() (*Config, error) {
 	// 设置默认值
 	setDefaults()
 
@@ -109,6 +197,8 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
 	viper.SetDefault("server.idle_timeout", 60)
+	viper.SetDefault("server.max_body_bytes", 10<<20)
+	viper.SetDefault("server.admin_token", "")
 
 	// 数据库配置
 	viper.SetDefault("database.host", "localhost")
@@ -119,6 +209,9 @@ func setDefaults() {
 	viper.SetDefault("database.charset", "utf8mb4")
 	viper.SetDefault("database.parse_time", true)
 	viper.SetDefault("database.loc", "Local")
+	viper.SetDefault("database.max_open_conns", 100)
+	viper.SetDefault("database.max_idle_conns", 10)
+	viper.SetDefault("database.conn_max_lifetime", 3600)
 
 	// Redis配置
 	viper.SetDefault("redis.host", "localhost")
@@ -131,6 +224,9 @@ func setDefaults() {
 	viper.SetDefault("model.cache_ttl", 3600)
 	viper.SetDefault("model.max_loaded_models", 10)
 	viper.SetDefault("model.load_timeout", 300)
+	viper.SetDefault("model.health_check_interval", 30)
+	viper.SetDefault("model.health_check_failures", 3)
+	viper.SetDefault("model.preload_concurrency", 2)
 
 	// 推理配置
 	viper.SetDefault("inference.max_batch_size", 100)
@@ -138,6 +234,22 @@ func setDefaults() {
 	viper.SetDefault("inference.max_concurrency", 10)
 	viper.SetDefault("inference.result_cache_ttl", 300)
 	viper.SetDefault("inference.history_retention", 7)
+	viper.SetDefault("inference.vector_retention", 30)
+	viper.SetDefault("inference.batch_wait_ms", 5)
+	viper.SetDefault("inference.history_cleanup_interval", 60)
+	viper.SetDefault("inference.sample_log.enabled", false)
+	viper.SetDefault("inference.sample_log.rate", 0.01)
+
+	// Kafka配置，默认关闭
+	viper.SetDefault("kafka.enabled", false)
+	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
+	viper.SetDefault("kafka.group_id", "model-inference-group")
+	viper.SetDefault("kafka.topics.processed_text", "processed-text-topic")
+	viper.SetDefault("kafka.topics.audit_result", "text-audit.audit-result")
+	viper.SetDefault("kafka.auth.mechanism", "")
+	viper.SetDefault("kafka.auth.username", "")
+	viper.SetDefault("kafka.auth.password", "")
+	viper.SetDefault("kafka.auth.tls", false)
 
 	// 日志配置
 	viper.SetDefault("log.level", "info")
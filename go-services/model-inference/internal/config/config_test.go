@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaDisabledByDefault(t *testing.T) {
+	cfg := validConfig(t)
+	assert.False(t, cfg.Kafka.Enabled)
+	assert.Equal(t, []string{"localhost:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, "processed-text-topic", cfg.Kafka.Topics.ProcessedText)
+	assert.Equal(t, "text-audit.audit-result", cfg.Kafka.Topics.AuditResult)
+
+	// 关闭时不校验 Kafka 字段
+	cfg.Kafka.Brokers = nil
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestKafkaConfigFromEnv(t *testing.T) {
+	t.Setenv("TEXTAUDIT_KAFKA_ENABLED", "true")
+	t.Setenv("TEXTAUDIT_KAFKA_BROKERS", "kafka-1:9092,kafka-2:9092")
+	t.Setenv("TEXTAUDIT_KAFKA_GROUP_ID", "inference-test")
+	t.Setenv("TEXTAUDIT_KAFKA_TOPICS_PROCESSED_TEXT", "processed-test")
+	t.Setenv("TEXTAUDIT_KAFKA_TOPICS_AUDIT_RESULT", "audit-result-test")
+	t.Setenv("TEXTAUDIT_KAFKA_AUTH_MECHANISM", "SCRAM-SHA-512")
+	t.Setenv("TEXTAUDIT_KAFKA_AUTH_USERNAME", "inference")
+	t.Setenv("TEXTAUDIT_KAFKA_AUTH_PASSWORD", "secret")
+	t.Setenv("TEXTAUDIT_KAFKA_AUTH_TLS", "true")
+
+	cfg := validConfig(t)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, KafkaConfig{
+		Enabled: true,
+		Brokers: []string{"kafka-1:9092", "kafka-2:9092"},
+		GroupID: "inference-test",
+		Topics:  KafkaTopicsConfig{ProcessedText: "processed-test", AuditResult: "audit-result-test"},
+		Auth:    KafkaAuthConfig{Mechanism: "SCRAM-SHA-512", Username: "inference", Password: "secret", TLS: true},
+	}, cfg.Kafka)
+}
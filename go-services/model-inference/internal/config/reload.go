@@ -0,0 +1,97 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Holder 并发安全的配置持有者，支持配置文件热更新
+type Holder struct {
+	mu        sync.RWMutex
+	config    Config
+	listeners []func(Config)
+}
+
+// NewHolder 创建配置持有者
+func NewHolder(cfg *Config) *Holder {
+	return &Holder{config: *cfg}
+}
+
+// Get 获取当前配置的副本
+func (h *Holder) Get() Config {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.config
+}
+
+// OnChange 注册配置变更回调，回调参数为应用变更后的完整配置
+func (h *Holder) OnChange(fn func(Config)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, fn)
+}
+
+// Watch 监听配置文件变化并热更新可变配置项
+func (h *Holder) Watch() {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		var updated Config
+		if err := viper.Unmarshal(&updated); err != nil {
+			logrus.Errorf("重新解析配置失败: %v", err)
+			return
+		}
+		h.apply(&updated)
+	})
+	viper.WatchConfig()
+}
+
+// apply 应用新配置中的可变项，不可变项（端口、数据库、Redis等）发生变化时仅告警。
+// 新配置校验失败时保留当前配置，不通知监听者
+func (h *Holder) apply(updated *Config) {
+	if err := updated.Validate(); err != nil {
+		logrus.Errorf("新配置校验失败，保留当前配置: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+
+	if updated.Server != h.config.Server {
+		logrus.Warn("服务器配置变更需要重启后生效，已忽略")
+	}
+	if updated.Database != h.config.Database {
+		logrus.Warn("数据库配置变更需要重启后生效，已忽略")
+	}
+	if updated.Redis != h.config.Redis {
+		logrus.Warn("Redis配置变更需要重启后生效，已忽略")
+	}
+	if updated.Model.StoragePath != h.config.Model.StoragePath {
+		logrus.Warn("模型存储路径变更需要重启后生效，已忽略")
+	}
+
+	// 可变配置项
+	h.config.Log.Level = updated.Log.Level
+	h.config.Inference = updated.Inference
+	h.config.Model.CacheTTL = updated.Model.CacheTTL
+	h.config.Model.MaxLoadedModels = updated.Model.MaxLoadedModels
+	h.config.Model.LoadTimeout = updated.Model.LoadTimeout
+	h.config.Model.HealthCheckInterval = updated.Model.HealthCheckInterval
+	h.config.Model.HealthCheckFailures = updated.Model.HealthCheckFailures
+
+	current := h.config
+	listeners := append([]func(Config){}, h.listeners...)
+	h.mu.Unlock()
+
+	if level, err := logrus.ParseLevel(current.Log.Level); err == nil {
+		logrus.SetLevel(level)
+	} else {
+		logrus.Warnf("无效的日志级别 %q: %v", current.Log.Level, err)
+	}
+
+	for _, fn := range listeners {
+		fn(current)
+	}
+
+	logrus.Info("配置已热更新")
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHolderWatchReloadsLogLevel(t *testing.T) {
+	originalLevel := logrus.GetLevel()
+	t.Cleanup(func() { logrus.SetLevel(originalLevel) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8082\nlog:\n  level: info\n"), 0644))
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+	logrus.SetLevel(logrus.InfoLevel)
+
+	holder := NewHolder(cfg)
+	changed := make(chan Config, 1)
+	holder.OnChange(func(c Config) {
+		select {
+		case changed <- c:
+		default:
+		}
+	})
+	holder.Watch()
+
+	// 同时修改日志级别（可变）和端口（不可变）
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9999\nlog:\n  level: debug\ninference:\n  max_concurrency: 42\n"), 0644))
+
+	select {
+	case c := <-changed:
+		assert.Equal(t, "debug", c.Log.Level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not observed")
+	}
+
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	current := holder.Get()
+	assert.Equal(t, 42, current.Inference.MaxConcurrency)
+	assert.Equal(t, 8082, current.Server.Port, "immutable settings must not be reloaded")
+}
+
+func TestHolderRejectsInvalidReload(t *testing.T) {
+	originalLevel := logrus.GetLevel()
+	t.Cleanup(func() { logrus.SetLevel(originalLevel) })
+
+	holder := NewHolder(validConfig(t))
+	before := holder.Get()
+	notified := 0
+	holder.OnChange(func(Config) { notified++ })
+
+	updated := holder.Get()
+	updated.Log.Level = "debug"
+	updated.Inference.MaxConcurrency = 0
+	updated.Inference.SampleLog.Rate = -1
+	holder.apply(&updated)
+
+	assert.Zero(t, notified)
+	current := holder.Get()
+	assert.Equal(t, before.Inference.MaxConcurrency, current.Inference.MaxConcurrency)
+	assert.Equal(t, before.Log.Level, current.Log.Level)
+	assert.Equal(t, originalLevel, logrus.GetLevel())
+
+	// 修正后的配置正常生效
+	updated.Inference.MaxConcurrency = 5
+	updated.Inference.SampleLog.Rate = 0.5
+	holder.apply(&updated)
+	assert.Equal(t, 1, notified)
+	assert.Equal(t, 5, holder.Get().Inference.MaxConcurrency)
+}
+
+func TestHolderReloadsHealthCheckSettings(t *testing.T) {
+	holder := NewHolder(validConfig(t))
+	var observed ModelConfig
+	holder.OnChange(func(c Config) { observed = c.Model })
+
+	updated := holder.Get()
+	updated.Model.HealthCheckInterval = 5
+	updated.Model.HealthCheckFailures = 7
+	updated.Model.PreloadConcurrency = 9
+	holder.apply(&updated)
+
+	assert.Equal(t, 5, observed.HealthCheckInterval)
+	assert.Equal(t, 7, observed.HealthCheckFailures)
+	// 预加载只在启动时生效，不随热更新变化
+	assert.NotEqual(t, 9, holder.Get().Model.PreloadConcurrency)
+}
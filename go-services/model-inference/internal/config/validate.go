@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidationError 汇总配置校验发现的所有问题
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "配置校验失败: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate 校验必填字段与取值范围，返回汇总后的错误
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// 服务器配置
+	if !validPort(c.Server.Port) {
+		addf("server.port %d 超出范围 1-65535", c.Server.Port)
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		addf("server.mode %q 无效，可选值为 debug/release/test", c.Server.Mode)
+	}
+	if c.Server.ReadTimeout <= 0 {
+		addf("server.read_timeout %d 必须为正数", c.Server.ReadTimeout)
+	}
+	if c.Server.WriteTimeout <= 0 {
+		addf("server.write_timeout %d 必须为正数", c.Server.WriteTimeout)
+	}
+	if c.Server.IdleTimeout <= 0 {
+		addf("server.idle_timeout %d 必须为正数", c.Server.IdleTimeout)
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		addf("server.max_body_bytes %d 必须为正数", c.Server.MaxBodyBytes)
+	}
+
+	// 数据库配置
+	if c.Database.Host == "" {
+		addf("database.host 不能为空")
+	}
+	if !validPort(c.Database.Port) {
+		addf("database.port %d 超出范围 1-65535", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		addf("database.user 不能为空")
+	}
+	if c.Database.DBName == "" {
+		addf("database.dbname 不能为空")
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		addf("database.max_open_conns %d 必须为正数", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		addf("database.max_idle_conns %d 必须在 0 到 max_open_conns 之间", c.Database.MaxIdleConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		addf("database.conn_max_lifetime %d 不能为负数", c.Database.ConnMaxLifetime)
+	}
+
+	// Redis配置
+	if c.Redis.Host == "" {
+		addf("redis.host 不能为空")
+	}
+	if !validPort(c.Redis.Port) {
+		addf("redis.port %d 超出范围 1-65535", c.Redis.Port)
+	}
+	if c.Redis.DB < 0 {
+		addf("redis.db %d 不能为负数", c.Redis.DB)
+	}
+
+	// 模型配置
+	if c.Model.StoragePath == "" {
+		addf("model.storage_path 不能为空")
+	}
+	if c.Model.CacheTTL < 0 {
+		addf("model.cache_ttl %d 不能为负数", c.Model.CacheTTL)
+	}
+	if c.Model.MaxLoadedModels <= 0 {
+		addf("model.max_loaded_models %d 必须为正数", c.Model.MaxLoadedModels)
+	}
+	if c.Model.LoadTimeout <= 0 {
+		addf("model.load_timeout %d 必须为正数", c.Model.LoadTimeout)
+	}
+	if c.Model.HealthCheckInterval <= 0 {
+		addf("model.health_check_interval %d 必须为正数", c.Model.HealthCheckInterval)
+	}
+	if c.Model.HealthCheckFailures <= 0 {
+		addf("model.health_check_failures %d 必须为正数", c.Model.HealthCheckFailures)
+	}
+	if c.Model.PreloadConcurrency <= 0 {
+		addf("model.preload_concurrency %d 必须为正数", c.Model.PreloadConcurrency)
+	}
+	if len(c.Model.Preload) > c.Model.MaxLoadedModels {
+		addf("model.preload 包含 %d 个模型，超过 model.max_loaded_models %d", len(c.Model.Preload), c.Model.MaxLoadedModels)
+	}
+	preloaded := make(map[string]bool, len(c.Model.Preload))
+	for i, name := range c.Model.Preload {
+		switch {
+		case name == "":
+			addf("model.preload[%d] 不能为空", i)
+		case preloaded[name]:
+			addf("model.preload[%d] 模型 %s 重复", i, name)
+		}
+		preloaded[name] = true
+	}
+
+	// 推理配置
+	if c.Inference.MaxBatchSize <= 0 {
+		addf("inference.max_batch_size %d 必须为正数", c.Inference.MaxBatchSize)
+	}
+	if c.Inference.TimeoutSeconds <= 0 {
+		addf("inference.timeout_seconds %d 必须为正数", c.Inference.TimeoutSeconds)
+	}
+	if c.Inference.MaxConcurrency <= 0 {
+		addf("inference.max_concurrency %d 必须为正数", c.Inference.MaxConcurrency)
+	}
+	if c.Inference.ResultCacheTTL < 0 {
+		addf("inference.result_cache_ttl %d 不能为负数", c.Inference.ResultCacheTTL)
+	}
+	if c.Inference.HistoryRetention < 0 {
+		addf("inference.history_retention %d 不能为负数", c.Inference.HistoryRetention)
+	}
+	if c.Inference.VectorRetention < 0 {
+		addf("inference.vector_retention %d 不能为负数", c.Inference.VectorRetention)
+	}
+	if c.Inference.HistoryCleanupInterval <= 0 {
+		addf("inference.history_cleanup_interval %d 必须为正数", c.Inference.HistoryCleanupInterval)
+	}
+	if c.Inference.BatchWaitMs < 0 {
+		addf("inference.batch_wait_ms %d 不能为负数", c.Inference.BatchWaitMs)
+	}
+	splitModels := make(map[string]bool, len(c.Inference.TrafficSplits))
+	for i, split := range c.Inference.TrafficSplits {
+		if split.Model == "" || split.Candidate == "" {
+			addf("inference.traffic_splits[%d] 必须同时配置 model 与 candidate", i)
+		} else if split.Model == split.Candidate {
+			addf("inference.traffic_splits[%d] candidate 不能与 model 相同", i)
+		}
+		if split.Percent < 0 || split.Percent > 100 {
+			addf("inference.traffic_splits[%d].percent %g 必须在 0-100 之间", i, split.Percent)
+		}
+		if splitModels[split.Model] {
+			addf("inference.traffic_splits 中模型 %s 重复配置", split.Model)
+		}
+		splitModels[split.Model] = true
+	}
+	limitModels := make(map[string]bool, len(c.Inference.RateLimits))
+	for i, limit := range c.Inference.RateLimits {
+		if limit.Model == "" {
+			addf("inference.rate_limits[%d].model 不能为空", i)
+		}
+		if limit.RequestsPerSecond <= 0 {
+			addf("inference.rate_limits[%d].requests_per_second %g 必须为正数", i, limit.RequestsPerSecond)
+		}
+		if limit.Burst < 0 {
+			addf("inference.rate_limits[%d].burst %d 不能为负数", i, limit.Burst)
+		}
+		if limitModels[limit.Model] {
+			addf("inference.rate_limits 中模型 %s 重复配置", limit.Model)
+		}
+		limitModels[limit.Model] = true
+	}
+	if c.Inference.SampleLog.Enabled && (c.Inference.SampleLog.Rate <= 0 || c.Inference.SampleLog.Rate > 1) {
+		addf("inference.sample_log.rate %g 必须在 (0, 1] 之间", c.Inference.SampleLog.Rate)
+	}
+
+	// Kafka配置
+	if c.Kafka.Enabled {
+		if len(c.Kafka.Brokers) == 0 {
+			addf("kafka.brokers 不能为空")
+		}
+		for i, broker := range c.Kafka.Brokers {
+			if strings.TrimSpace(broker) == "" {
+				addf("kafka.brokers[%d] 不能为空", i)
+			}
+		}
+		if c.Kafka.GroupID == "" {
+			addf("kafka.group_id 不能为空")
+		}
+		if c.Kafka.Topics.ProcessedText == "" {
+			addf("kafka.topics.processed_text 不能为空")
+		}
+		if c.Kafka.Topics.AuditResult == "" {
+			addf("kafka.topics.audit_result 不能为空")
+		}
+		switch c.Kafka.Auth.Mechanism {
+		case "":
+		case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+			if c.Kafka.Auth.Username == "" {
+				addf("kafka.auth.username 不能为空")
+			}
+		default:
+			addf("kafka.auth.mechanism %q 无效，可选值为 PLAIN/SCRAM-SHA-256/SCRAM-SHA-512", c.Kafka.Auth.Mechanism)
+		}
+	}
+
+	// 日志配置
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		addf("log.level %q 无效", c.Log.Level)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig(t *testing.T) *Config {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 8082\n"), 0644))
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	assert.NoError(t, validConfig(t).Validate())
+}
+
+func TestValidateRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   string
+	}{
+		{"port zero", func(c *Config) { c.Server.Port = 0 }, "server.port"},
+		{"port out of range", func(c *Config) { c.Server.Port = 70000 }, "server.port"},
+		{"unknown mode", func(c *Config) { c.Server.Mode = "prod" }, "server.mode"},
+		{"zero read timeout", func(c *Config) { c.Server.ReadTimeout = 0 }, "server.read_timeout"},
+		{"zero write timeout", func(c *Config) { c.Server.WriteTimeout = 0 }, "server.write_timeout"},
+		{"zero idle timeout", func(c *Config) { c.Server.IdleTimeout = 0 }, "server.idle_timeout"},
+		{"zero max body bytes", func(c *Config) { c.Server.MaxBodyBytes = 0 }, "server.max_body_bytes"},
+		{"empty db host", func(c *Config) { c.Database.Host = "" }, "database.host"},
+		{"db port zero", func(c *Config) { c.Database.Port = 0 }, "database.port"},
+		{"empty db user", func(c *Config) { c.Database.User = "" }, "database.user"},
+		{"empty db name", func(c *Config) { c.Database.DBName = "" }, "database.dbname"},
+		{"zero max open conns", func(c *Config) { c.Database.MaxOpenConns = 0 }, "database.max_open_conns"},
+		{"idle conns above open conns", func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, "database.max_idle_conns"},
+		{"negative conn lifetime", func(c *Config) { c.Database.ConnMaxLifetime = -1 }, "database.conn_max_lifetime"},
+		{"empty redis host", func(c *Config) { c.Redis.Host = "" }, "redis.host"},
+		{"redis port zero", func(c *Config) { c.Redis.Port = 0 }, "redis.port"},
+		{"negative redis db", func(c *Config) { c.Redis.DB = -1 }, "redis.db"},
+		{"empty storage path", func(c *Config) { c.Model.StoragePath = "" }, "model.storage_path"},
+		{"negative cache ttl", func(c *Config) { c.Model.CacheTTL = -1 }, "model.cache_ttl"},
+		{"zero max loaded models", func(c *Config) { c.Model.MaxLoadedModels = 0 }, "model.max_loaded_models"},
+		{"zero load timeout", func(c *Config) { c.Model.LoadTimeout = 0 }, "model.load_timeout"},
+		{"zero health check interval", func(c *Config) { c.Model.HealthCheckInterval = 0 }, "model.health_check_interval"},
+		{"zero health check failures", func(c *Config) { c.Model.HealthCheckFailures = 0 }, "model.health_check_failures"},
+		{"zero preload concurrency", func(c *Config) { c.Model.PreloadConcurrency = 0 }, "model.preload_concurrency"},
+		{"empty preload name", func(c *Config) { c.Model.Preload = []string{"spam", ""} }, "model.preload[1]"},
+		{"duplicate preload name", func(c *Config) { c.Model.Preload = []string{"spam", "spam"} }, "model.preload[1]"},
+		{"preload above max loaded models", func(c *Config) { c.Model.MaxLoadedModels = 1; c.Model.Preload = []string{"spam", "ham"} }, "model.preload"},
+		{"negative max batch size", func(c *Config) { c.Inference.MaxBatchSize = -1 }, "inference.max_batch_size"},
+		{"zero inference timeout", func(c *Config) { c.Inference.TimeoutSeconds = 0 }, "inference.timeout_seconds"},
+		{"zero max concurrency", func(c *Config) { c.Inference.MaxConcurrency = 0 }, "inference.max_concurrency"},
+		{"negative result cache ttl", func(c *Config) { c.Inference.ResultCacheTTL = -1 }, "inference.result_cache_ttl"},
+		{"negative history retention", func(c *Config) { c.Inference.HistoryRetention = -1 }, "inference.history_retention"},
+		{"negative vector retention", func(c *Config) { c.Inference.VectorRetention = -1 }, "inference.vector_retention"},
+		{"zero history cleanup interval", func(c *Config) { c.Inference.HistoryCleanupInterval = 0 }, "inference.history_cleanup_interval"},
+		{"traffic split without candidate", func(c *Config) {
+			c.Inference.TrafficSplits = []TrafficSplit{{Model: "classifier", Percent: 10}}
+		}, "inference.traffic_splits[0]"},
+		{"traffic split percent above 100", func(c *Config) {
+			c.Inference.TrafficSplits = []TrafficSplit{{Model: "classifier", Candidate: "classifier-v2", Percent: 120}}
+		}, "inference.traffic_splits[0].percent"},
+		{"rate limit without rate", func(c *Config) {
+			c.Inference.RateLimits = []ModelRateLimit{{Model: "classifier"}}
+		}, "inference.rate_limits[0].requests_per_second"},
+		{"sample log rate above 1", func(c *Config) {
+			c.Inference.SampleLog = SampleLogConfig{Enabled: true, Rate: 1.5}
+		}, "inference.sample_log.rate"},
+		{"kafka without brokers", func(c *Config) { c.Kafka.Enabled = true; c.Kafka.Brokers = nil }, "kafka.brokers"},
+		{"kafka without group", func(c *Config) { c.Kafka.Enabled = true; c.Kafka.GroupID = "" }, "kafka.group_id"},
+		{"kafka unknown sasl mechanism", func(c *Config) { c.Kafka.Enabled = true; c.Kafka.Auth.Mechanism = "GSSAPI" }, "kafka.auth.mechanism"},
+		{"kafka sasl without username", func(c *Config) { c.Kafka.Enabled = true; c.Kafka.Auth.Mechanism = "PLAIN" }, "kafka.auth.username"},
+		{"unknown log level", func(c *Config) { c.Log.Level = "verbose" }, "log.level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestValidateAggregatesProblems(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Server.Port = 0
+	cfg.Database.Host = ""
+	cfg.Inference.MaxBatchSize = -5
+
+	err := cfg.Validate()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 3)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// AdminHandler 管理接口处理器
+type AdminHandler struct {
+	loggers []*logrus.Logger
+}
+
+// NewAdminHandler 创建管理接口处理器，修改日志级别时同时作用于传入的所有 logger
+func NewAdminHandler(loggers ...*logrus.Logger) *AdminHandler {
+	return &AdminHandler{loggers: loggers}
+}
+
+// GetLogLevel 获取当前日志级别
+// @Summary 获取日志级别
+// @Description 获取服务当前的日志级别
+// @Tags 管理接口
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} model.LogLevelResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Router /admin/loglevel [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, model.LogLevelResponse{Level: h.loggers[0].GetLevel().String()})
+}
+
+// SetLogLevel 运行时修改日志级别
+// @Summary 修改日志级别
+// @Description 运行时修改日志级别，无需重启服务
+// @Tags 管理接口
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body model.LogLevelRequest true "日志级别"
+// @Success 200 {object} model.LogLevelResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Router /admin/loglevel [put]
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req model.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的日志级别",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	previous := h.loggers[0].GetLevel()
+	for _, l := range h.loggers {
+		l.SetLevel(level)
+	}
+	h.loggers[0].WithFields(logrus.Fields{
+		"previous": previous.String(),
+		"level":    level.String(),
+	}).Warn("日志级别已修改")
+
+	c.JSON(http.StatusOK, model.LogLevelResponse{Level: level.String()})
+}
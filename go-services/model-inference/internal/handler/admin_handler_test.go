@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/middleware"
+)
+
+func newTestAdminRouter(token string, loggers ...*logrus.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewAdminHandler(loggers...)
+	router := gin.New()
+	admin := router.Group("/admin", middleware.AdminAuth(token))
+	admin.GET("/loglevel", h.GetLogLevel)
+	admin.PUT("/loglevel", h.SetLogLevel)
+	return router
+}
+
+func newQuietLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetLevel(logrus.InfoLevel)
+	return l
+}
+
+func TestSetLogLevel(t *testing.T) {
+	primary, secondary := newQuietLogger(), newQuietLogger()
+	router := newTestAdminRouter("secret", primary, secondary)
+
+	putLevel := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/admin/loglevel", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, putLevel("", `{"level":"debug"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, putLevel("wrong", `{"level":"debug"}`).Code)
+
+	w := putLevel("secret", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, logrus.InfoLevel, primary.GetLevel())
+
+	w = putLevel("secret", `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, primary.IsLevelEnabled(logrus.DebugLevel))
+	assert.True(t, secondary.IsLevelEnabled(logrus.DebugLevel))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, req)
+	assert.JSONEq(t, `{"level":"debug"}`, get.Body.String())
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	router := newTestAdminRouter("", newQuietLogger())
+	req := httptest.NewRequest(http.MethodGet, "/admin/loglevel", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// respondError 根据错误类别选择状态码并返回错误响应，message 为日志内容及未分类错误的说明
+func respondError(c *gin.Context, log *logrus.Entry, err error, message string) {
+	status := apperrors.HTTPStatus(err)
+	if status >= http.StatusInternalServerError && status != http.StatusServiceUnavailable {
+		log.Error(message)
+	} else {
+		log.Warn(message)
+	}
+
+	c.JSON(status, model.ErrorResponse{
+		Error:     apperrors.Message(err, message),
+		Message:   err.Error(),
+		Code:      status,
+		Details:   apperrors.Details(err),
+		Timestamp: time.Now(),
+	})
+}
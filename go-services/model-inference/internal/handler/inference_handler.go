@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -27,13 +30,18 @@ func NewInferenceHandler(inferenceService service.InferenceService, logger *logr
 
 // Predict 单次预测
 // @Summary 单次预测
-// @Description 对单个输入进行预测
+// @Description 对单个输入进行预测。async=true 时立即返回请求ID，推理在后台执行，
+// @Description 结果通过 GET /api/v1/inference/result/{request_id} 轮询，或在完成后回调 callback_url
 // @Tags 推理服务
 // @Accept json
 // @Produce json
+// @Param async query bool false "是否异步执行" default(false)
 // @Param request body model.PredictRequest true "预测请求"
 // @Success 200 {object} model.PredictResponse
+// @Success 202 {object} model.AsyncPredictResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 503 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/inference/predict [post]
 func (h *InferenceHandler) Predict(c *gin.Context) {
@@ -47,17 +55,64 @@ func (h *InferenceHandler) Predict(c *gin.Context) {
 		return
 	}
 
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: "async 必须为布尔值",
+		})
+		return
+	}
+	if async {
+		accepted, err := h.inferenceService.PredictAsync(c.Request.Context(), &req)
+		if err != nil {
+			respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "提交异步预测失败")
+			return
+		}
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
 	// 执行预测
 	response, err := h.inferenceService.Predict(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", req.ModelName).Error("预测失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "预测失败",
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "预测失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Ensemble 集成推理
+// @Summary 集成推理
+// @Description 使用多个分类模型对同一文本打分，按 majority（多数投票）、average（平均置信度）或 weighted（加权平均）聚合。
+// @Description 所有模型必须已加载，否则返回 409 并列出未加载的模型
+// @Tags 推理服务
+// @Accept json
+// @Produce json
+// @Param request body model.EnsembleRequest true "集成推理请求"
+// @Success 200 {object} model.EnsembleResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/inference/ensemble [post]
+func (h *InferenceHandler) Ensemble(c *gin.Context) {
+	var req model.EnsembleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	response, err := h.inferenceService.Ensemble(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_names", req.ModelNames), err, "集成推理失败")
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -70,6 +125,7 @@ func (h *InferenceHandler) Predict(c *gin.Context) {
 // @Param request body model.BatchPredictRequest true "批量预测请求"
 // @Success 200 {object} model.BatchPredictResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/inference/batch-predict [post]
 func (h *InferenceHandler) BatchPredict(c *gin.Context) {
@@ -86,15 +142,77 @@ func (h *InferenceHandler) BatchPredict(c *gin.Context) {
 	// 执行批量预测
 	response, err := h.inferenceService.BatchPredict(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", req.ModelName).Error("批量预测失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "批量预测失败",
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "批量预测失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StreamBatchPredict 流式批量预测
+// @Summary 流式批量预测
+// @Description 以 NDJSON 逐行返回每条输入的预测结果，完成一条写出一条；客户端断开后停止剩余推理。
+// @Description 开始输出前的错误以普通 JSON 错误响应返回，输出过程中的错误作为最后一行 ErrorResponse 返回
+// @Tags 推理服务
+// @Accept json
+// @Produce application/x-ndjson
+// @Param request body model.BatchPredictRequest true "批量预测请求"
+// @Success 200 {object} model.PredictResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/inference/batch-predict/stream [post]
+func (h *InferenceHandler) StreamBatchPredict(c *gin.Context) {
+	var req model.BatchPredictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	ctx := c.Request.Context()
+	encoder := json.NewEncoder(c.Writer)
+	started := false
+	err := h.inferenceService.StreamBatchPredict(ctx, &req, func(prediction *model.PredictResponse) error {
+		if !started {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+			started = true
+		}
+		if err := encoder.Encode(prediction); err != nil {
+			return fmt.Errorf("写入流式响应失败: %w", err)
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		if !started {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		return
+	}
+
+	log := h.logger.WithError(err).WithField("model_name", req.ModelName)
+	if ctx.Err() != nil {
+		log.Warn("客户端断开，流式批量预测已取消")
+		return
+	}
+	if !started {
+		respondError(c, log, err, "流式批量预测失败")
+		return
+	}
+	log.Error("流式批量预测中断")
+	encoder.Encode(model.ErrorResponse{
+		Error:     "流式批量预测中断",
+		Message:   err.Error(),
+		Code:      http.StatusInternalServerError,
+		Timestamp: time.Now(),
+	})
+	c.Writer.Flush()
 }
 
 // TextClassify 文本分类
@@ -122,11 +240,7 @@ func (h *InferenceHandler) TextClassify(c *gin.Context) {
 	// 执行文本分类
 	response, err := h.inferenceService.ClassifyText(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", req.ModelName).Error("文本分类失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "文本分类失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "文本分类失败")
 		return
 	}
 
@@ -158,11 +272,7 @@ func (h *InferenceHandler) SentimentAnalysis(c *gin.Context) {
 	// 执行情感分析
 	response, err := h.inferenceService.AnalyzeSentiment(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", req.ModelName).Error("情感分析失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "情感分析失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "情感分析失败")
 		return
 	}
 
@@ -194,11 +304,7 @@ func (h *InferenceHandler) FeatureExtraction(c *gin.Context) {
 	// 执行特征提取
 	response, err := h.inferenceService.ExtractFeatures(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", req.ModelName).Error("特征提取失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "特征提取失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "特征提取失败")
 		return
 	}
 
@@ -230,14 +336,176 @@ func (h *InferenceHandler) AnomalyDetection(c *gin.Context) {
 	// 执行异常检测
 	response, err := h.inferenceService.DetectAnomaly(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", req.ModelName).Error("异常检测失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "异常检测失败",
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "异常检测失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Embed 文本向量
+// @Summary 文本向量
+// @Description 计算单条或多条文本的稠密向量，可选 L2 归一化
+// @Tags 文本分析
+// @Accept json
+// @Produce json
+// @Param request body model.EmbeddingRequest true "文本向量请求"
+// @Success 200 {object} model.EmbeddingResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/text/embed [post]
+func (h *InferenceHandler) Embed(c *gin.Context) {
+	var req model.EmbeddingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.Text == "" && len(req.Texts) == 0 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: "text 和 texts 不能同时为空",
+		})
+		return
+	}
+
+	// 计算文本向量
+	response, err := h.inferenceService.Embed(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "计算文本向量失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Similar 相似文本检索
+// @Summary 相似文本检索
+// @Description 计算查询文本的向量，返回已处理文本中余弦相似度最高的前 K 条
+// @Tags 文本分析
+// @Accept json
+// @Produce json
+// @Param request body model.SimilarityRequest true "相似文本检索请求"
+// @Success 200 {object} model.SimilarityResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/text/similar [post]
+func (h *InferenceHandler) Similar(c *gin.Context) {
+	var req model.SimilarityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 检索相似文本
+	response, err := h.inferenceService.Similar(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "检索相似文本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RecognizeEntities 命名实体识别
+// @Summary 命名实体识别
+// @Description 识别文本中的人名、地名和机构名，偏移量按字符计算
+// @Tags 文本分析
+// @Accept json
+// @Produce json
+// @Param request body model.NERRequest true "命名实体识别请求"
+// @Success 200 {object} model.TextAnalysisResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/text/ner [post]
+func (h *InferenceHandler) RecognizeEntities(c *gin.Context) {
+	var req model.NERRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 执行实体识别
+	response, err := h.inferenceService.RecognizeEntities(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_name", req.ModelName), err, "实体识别失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ScoreQuality 文本质量评分
+// @Summary 文本质量评分
+// @Description 按长度、语言纯度、标点占比与重复度为文本打出 0-100 的质量分
+// @Tags 文本分析
+// @Accept json
+// @Produce json
+// @Param request body model.TextQualityRequest true "文本质量评分请求"
+// @Success 200 {object} model.TextQualityResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/text/quality [post]
+func (h *InferenceHandler) ScoreQuality(c *gin.Context) {
+	var req model.TextQualityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	response, err := h.inferenceService.ScoreQuality(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, h.logger.WithError(err), err, "质量评分失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Redact 敏感信息脱敏
+// @Summary 敏感信息脱敏
+// @Description 识别手机号、身份证号、邮箱与银行卡号，返回遮盖后的文本及识别到的类型
+// @Tags 文本分析
+// @Accept json
+// @Produce json
+// @Param request body model.RedactRequest true "脱敏请求"
+// @Success 200 {object} model.RedactResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/text/redact [post]
+func (h *InferenceHandler) Redact(c *gin.Context) {
+	var req model.RedactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定请求参数失败")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.inferenceService.Redact(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, h.logger.WithError(err), err, "脱敏失败")
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -272,17 +540,43 @@ func (h *InferenceHandler) GetInferenceHistory(c *gin.Context) {
 	// 获取推理历史
 	history, err := h.inferenceService.GetHistory(c.Request.Context(), limit, offset)
 	if err != nil {
-		h.logger.WithError(err).Error("获取推理历史失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "获取推理历史失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err), err, "获取推理历史失败")
 		return
 	}
 
 	c.JSON(http.StatusOK, history)
 }
 
+// ListPendingReviews 获取待人工复核的分类结果
+// @Summary 获取待复核列表
+// @Description 获取置信度低于模型 review_threshold、状态为 needs_review 的文本分类记录
+// @Tags 推理服务
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(10)
+// @Success 200 {array} model.InferenceRequest
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/inference/reviews [get]
+func (h *InferenceHandler) ListPendingReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	reviews, err := h.inferenceService.ListPendingReviews(c.Request.Context(), limit, (page-1)*limit)
+	if err != nil {
+		respondError(c, h.logger.WithError(err), err, "获取待复核列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
 // GetInferenceResult 获取推理结果
 // @Summary 获取推理结果
 // @Description 根据请求ID获取推理结果
@@ -308,11 +602,7 @@ func (h *InferenceHandler) GetInferenceResult(c *gin.Context) {
 	// 获取推理结果
 	result, err := h.inferenceService.GetInferenceResult(c.Request.Context(), requestID)
 	if err != nil {
-		h.logger.WithError(err).WithField("request_id", requestID).Error("获取推理结果失败")
-		c.JSON(http.StatusNotFound, model.ErrorResponse{
-			Error:   "推理结果不存在",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("request_id", requestID), err, "获取推理结果失败")
 		return
 	}
 
@@ -321,24 +611,48 @@ func (h *InferenceHandler) GetInferenceResult(c *gin.Context) {
 
 // GetInferenceStatistics 获取推理统计信息
 // @Summary 获取推理统计信息
-// @Description 获取推理服务的统计信息
+// @Description 获取推理服务的统计信息，group_by=model 时附带按模型分组的统计
 // @Tags 推理服务
 // @Accept json
 // @Produce json
+// @Param group_by query string false "分组维度，目前仅支持 model"
+// @Param window query string false "延迟分位数统计窗口，如 30m、24h，默认 1h"
 // @Success 200 {object} model.InferenceStatistics
+// @Failure 400 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/inference/statistics [get]
 func (h *InferenceHandler) GetInferenceStatistics(c *gin.Context) {
+	var opts model.StatisticsOptions
+	switch groupBy := c.Query("group_by"); groupBy {
+	case "":
+	case "model":
+		opts.GroupByModel = true
+	default:
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: fmt.Sprintf("不支持的分组维度: %s", groupBy),
+		})
+		return
+	}
+
+	if window := c.Query("window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Error:   "无效的请求参数",
+				Message: fmt.Sprintf("无效的统计窗口: %s", window),
+			})
+			return
+		}
+		opts.Window = d
+	}
+
 	// 获取推理统计信息
-	stats, err := h.inferenceService.GetStatistics(c.Request.Context())
+	stats, err := h.inferenceService.GetStatistics(c.Request.Context(), opts)
 	if err != nil {
-		h.logger.WithError(err).Error("获取推理统计信息失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "获取推理统计信息失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err), err, "获取推理统计信息失败")
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
-}
\ No newline at end of file
+}
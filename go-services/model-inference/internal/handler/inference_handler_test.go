@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/pii"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/service"
+)
+
+func newTestInferenceRouter(maxBatchSize int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	inferenceService := service.NewInferenceService(nil, nil, nil, backend.NewLocalBackend(16), nil, config.InferenceConfig{
+		MaxBatchSize:   maxBatchSize,
+		BatchWaitMs:    1,
+		TimeoutSeconds: 5,
+	})
+	h := NewInferenceHandler(inferenceService, logger)
+
+	router := gin.New()
+	router.POST("/api/v1/inference/batch-predict", h.BatchPredict)
+	router.POST("/api/v1/text/embed", h.Embed)
+	router.POST("/api/v1/text/quality", h.ScoreQuality)
+	router.POST("/api/v1/text/redact", h.Redact)
+	return router
+}
+
+func TestBatchPredictRejectsOversizedBatch(t *testing.T) {
+	router := newTestInferenceRouter(2)
+
+	body := `{"model_name":"m","data":[{"text":"a"},{"text":"b"},{"text":"c"}]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/inference/batch-predict", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp model.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Equal(t, "批量大小超过限制", resp.Error)
+	assert.Contains(t, resp.Message, "共 3 条")
+	assert.Contains(t, resp.Message, "最多 2 条")
+}
+
+func TestEmbedRejectsOversizedBatch(t *testing.T) {
+	router := newTestInferenceRouter(1)
+
+	body := `{"model_name":"m","texts":["a","b"]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/text/embed", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "最多 1 条")
+}
+
+func TestScoreQuality(t *testing.T) {
+	router := newTestInferenceRouter(1)
+
+	w := httptest.NewRecorder()
+	body := `{"text":"这是一个关于人工智能发展的深度讨论，涉及到机器学习、深度学习等多个领域。"}`
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/text/quality", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.TextQualityResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.RequestID)
+	assert.GreaterOrEqual(t, resp.Score, 90)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/text/quality", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRedact(t *testing.T) {
+	router := newTestInferenceRouter(1)
+
+	w := httptest.NewRecorder()
+	body := `{"text":"手机13812345678，邮箱 li4@example.com"}`
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/text/redact", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.RedactResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "手机138****5678，邮箱 l***@example.com", resp.Text)
+	assert.Equal(t, []pii.Type{pii.TypeEmail, pii.TypePhone}, resp.Types)
+	assert.Len(t, resp.Matches, 2)
+	assert.NotContains(t, w.Body.String(), "13812345678")
+}
+
+func TestPredictAsync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewInferenceHandler(&stubInferenceService{}, newQuietHandlerLogger())
+	router := gin.New()
+	router.POST("/api/v1/inference/predict", h.Predict)
+
+	predict := func(query string) *httptest.ResponseRecorder {
+		body := `{"model_name":"m","data":{"text":"x"}}`
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/inference/predict"+query, strings.NewReader(body)))
+		return w
+	}
+
+	w := predict("?async=true")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp model.AsyncPredictResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "async-1", resp.RequestID)
+	assert.Equal(t, model.InferenceStatusPending, resp.Status)
+
+	assert.Equal(t, http.StatusBadRequest, predict("?async=maybe").Code)
+}
+
+func (s *stubInferenceService) StreamBatchPredict(ctx context.Context, req *model.BatchPredictRequest, emit func(*model.PredictResponse) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	for i, data := range req.Data {
+		if err := emit(&model.PredictResponse{RequestID: fmt.Sprintf("batch_%d", i), ModelName: req.ModelName, Prediction: data["text"]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStreamBatchPredict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewInferenceHandler(&stubInferenceService{}, newQuietHandlerLogger())
+	router := gin.New()
+	router.POST("/api/v1/inference/batch-predict/stream", h.StreamBatchPredict)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	body := `{"model_name":"m","data":[{"text":"a"},{"text":"b"},{"text":"c"}]}`
+	resp, err := http.Post(server.URL+"/api/v1/inference/batch-predict/stream", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	var predictions []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var prediction model.PredictResponse
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &prediction))
+		predictions = append(predictions, fmt.Sprintf("%s=%v", prediction.RequestID, prediction.Prediction))
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"batch_0=a", "batch_1=b", "batch_2=c"}, predictions)
+}
+
+func TestStreamBatchPredictErrorBeforeStreaming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewInferenceHandler(&stubInferenceService{err: service.ErrBatchTooLarge}, newQuietHandlerLogger())
+	router := gin.New()
+	router.POST("/api/v1/inference/batch-predict/stream", h.StreamBatchPredict)
+
+	w := httptest.NewRecorder()
+	body := `{"model_name":"m","data":[{"text":"a"}]}`
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/inference/batch-predict/stream", strings.NewReader(body)))
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp model.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "批量大小超过限制", resp.Error)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/middleware"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/service"
 )
@@ -14,17 +15,40 @@ import (
 // ModelHandler 模型处理器
 type ModelHandler struct {
 	modelService service.ModelService
+	auditService service.AuditService
 	logger       *logrus.Logger
 }
 
-// NewModelHandler 创建模型处理器
-func NewModelHandler(modelService service.ModelService, logger *logrus.Logger) *ModelHandler {
+// NewModelHandler 创建模型处理器，加载与卸载操作写入 auditService
+func NewModelHandler(modelService service.ModelService, auditService service.AuditService, logger *logrus.Logger) *ModelHandler {
 	return &ModelHandler{
 		modelService: modelService,
+		auditService: auditService,
 		logger:       logger,
 	}
 }
 
+// recordOperation 写入管理操作审计记录；审计失败只记录日志，不影响操作本身的响应
+func (h *ModelHandler) recordOperation(c *gin.Context, action model.AdminAction, modelName string, opErr error) {
+	operation := &model.AdminOperation{
+		RequestID: c.GetString("request_id"),
+		Actor:     middleware.Actor(c),
+		Action:    action,
+		ModelName: modelName,
+		Result:    model.AdminOperationSucceeded,
+	}
+	if opErr != nil {
+		operation.Result = model.AdminOperationFailed
+		operation.Error = opErr.Error()
+	}
+	if err := h.auditService.Record(c.Request.Context(), operation); err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"action":     action,
+			"model_name": modelName,
+		}).Warn("写入管理操作记录失败")
+	}
+}
+
 // LoadModel 加载模型
 // @Summary 加载模型
 // @Description 加载指定的模型到内存中
@@ -35,6 +59,8 @@ func NewModelHandler(modelService service.ModelService, logger *logrus.Logger) *
 // @Param request body model.ModelLoadRequest true "加载请求"
 // @Success 200 {object} model.ModelStatusResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /models/{name}/load [post]
 func (h *ModelHandler) LoadModel(c *gin.Context) {
@@ -59,23 +85,16 @@ func (h *ModelHandler) LoadModel(c *gin.Context) {
 
 	// 加载模型
 	err := h.modelService.LoadModel(c.Request.Context(), modelName, req.Force)
+	h.recordOperation(c, model.AdminActionLoadModel, modelName, err)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", modelName).Error("加载模型失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "加载模型失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "加载模型失败")
 		return
 	}
 
 	// 获取模型状态
 	status, err := h.modelService.GetModelStatus(c.Request.Context(), modelName)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", modelName).Error("获取模型状态失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "获取模型状态失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "获取模型状态失败")
 		return
 	}
 
@@ -84,13 +103,14 @@ func (h *ModelHandler) LoadModel(c *gin.Context) {
 
 // UnloadModel 卸载模型
 // @Summary 卸载模型
-// @Description 从内存中卸载指定的模型
+// @Description 从内存中卸载指定的模型，等待进行中的推理结束，超时返回 409
 // @Tags 模型管理
 // @Accept json
 // @Produce json
 // @Param name path string true "模型名称"
 // @Success 200 {object} model.ModelStatusResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/models/{name}/unload [post]
 func (h *ModelHandler) UnloadModel(c *gin.Context) {
@@ -105,12 +125,9 @@ func (h *ModelHandler) UnloadModel(c *gin.Context) {
 
 	// 卸载模型
 	err := h.modelService.UnloadModel(c.Request.Context(), modelName)
+	h.recordOperation(c, model.AdminActionUnloadModel, modelName, err)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", modelName).Error("卸载模型失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "卸载模型失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "卸载模型失败")
 		return
 	}
 
@@ -120,6 +137,45 @@ func (h *ModelHandler) UnloadModel(c *gin.Context) {
 	})
 }
 
+// ReloadModel 重新加载模型
+// @Summary 重新加载模型
+// @Description 加载模型文件的新版本并预热后原子替换旧版本，重新加载期间推理不中断
+// @Tags 模型管理
+// @Accept json
+// @Produce json
+// @Param name path string true "模型名称"
+// @Success 200 {object} model.ModelStatusResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/models/{name}/reload [post]
+func (h *ModelHandler) ReloadModel(c *gin.Context) {
+	modelName := c.Param("name")
+	if modelName == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
+			Message: "模型名称不能为空",
+		})
+		return
+	}
+
+	err := h.modelService.ReloadModel(c.Request.Context(), modelName)
+	h.recordOperation(c, model.AdminActionReloadModel, modelName, err)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "重新加载模型失败")
+		return
+	}
+
+	status, err := h.modelService.GetModelStatus(c.Request.Context(), modelName)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "获取模型状态失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
 // GetModel 获取模型信息
 // @Summary 获取模型信息
 // @Description 获取指定模型的详细信息
@@ -145,11 +201,7 @@ func (h *ModelHandler) GetModel(c *gin.Context) {
 	// 获取模型信息
 	modelInfo, err := h.modelService.GetModel(c.Request.Context(), modelName)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", modelName).Error("获取模型信息失败")
-		c.JSON(http.StatusNotFound, model.ErrorResponse{
-			Error:   "模型不存在",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "获取模型信息失败")
 		return
 	}
 
@@ -190,11 +242,7 @@ func (h *ModelHandler) ListModels(c *gin.Context) {
 	// 获取模型列表
 	models, err := h.modelService.ListModels(c.Request.Context(), limit, offset)
 	if err != nil {
-		h.logger.WithError(err).Error("获取模型列表失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "获取模型列表失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err), err, "获取模型列表失败")
 		return
 	}
 
@@ -238,15 +286,41 @@ func (h *ModelHandler) GetModelStatus(c *gin.Context) {
 	// 获取模型状态
 	status, err := h.modelService.GetModelStatus(c.Request.Context(), modelName)
 	if err != nil {
-		h.logger.WithError(err).WithField("model_name", modelName).Error("获取模型状态失败")
-		c.JSON(http.StatusNotFound, model.ErrorResponse{
-			Error:   "模型不存在",
+		respondError(c, h.logger.WithError(err).WithField("model_name", modelName), err, "获取模型状态失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetModelStatuses 批量获取模型状态
+// @Summary 批量获取模型状态
+// @Description 一次获取多个模型的当前状态，不存在的模型以 not_found 状态返回
+// @Tags 模型管理
+// @Accept json
+// @Produce json
+// @Param request body model.BatchModelStatusRequest true "模型名称列表"
+// @Success 200 {object} model.BatchModelStatusResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/models/status [post]
+func (h *ModelHandler) GetModelStatuses(c *gin.Context) {
+	var req model.BatchModelStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "无效的请求参数",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, status)
+	statuses, err := h.modelService.GetModelStatuses(c.Request.Context(), req.Names)
+	if err != nil {
+		respondError(c, h.logger.WithError(err).WithField("model_count", len(req.Names)), err, "批量获取模型状态失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.BatchModelStatusResponse{Models: statuses})
 }
 
 // GetModelStatistics 获取模型统计信息
@@ -262,13 +336,46 @@ func (h *ModelHandler) GetModelStatistics(c *gin.Context) {
 	// 获取模型统计信息
 	stats, err := h.modelService.GetStatistics(c.Request.Context())
 	if err != nil {
-		h.logger.WithError(err).Error("获取模型统计信息失败")
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Error:   "获取模型统计信息失败",
-			Message: err.Error(),
-		})
+		respondError(c, h.logger.WithError(err), err, "获取模型统计信息失败")
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
+}
+
+// ListOperations 获取管理操作审计记录
+// @Summary 获取管理操作记录
+// @Description 按时间倒序分页获取模型加载、卸载等管理操作的审计记录
+// @Tags 管理接口
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Success 200 {object} model.AdminOperationListResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/operations [get]
+func (h *ModelHandler) ListOperations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	operations, total, err := h.auditService.ListOperations(c.Request.Context(), limit, (page-1)*limit)
+	if err != nil {
+		respondError(c, h.logger.WithError(err), err, "获取管理操作记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AdminOperationListResponse{
+		Operations: operations,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+	})
 }
\ No newline at end of file
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/middleware"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/service"
+)
+
+// stubModelService 按测试设定返回错误
+type stubModelService struct {
+	service.ModelService
+	err error
+}
+
+func (s *stubModelService) LoadModel(ctx context.Context, name string, force bool) error {
+	return s.err
+}
+
+func (s *stubModelService) UnloadModel(ctx context.Context, name string) error {
+	return s.err
+}
+
+func (s *stubModelService) ReloadModel(ctx context.Context, name string) error {
+	return s.err
+}
+
+func (s *stubModelService) GetModelStatus(ctx context.Context, name string) (*model.ModelStatusResponse, error) {
+	return &model.ModelStatusResponse{Name: name, Status: model.ModelStatusLoaded}, s.err
+}
+
+func (s *stubModelService) GetModelStatuses(ctx context.Context, names []string) ([]*model.ModelStatusResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	statuses := make([]*model.ModelStatusResponse, len(names))
+	for i, name := range names {
+		statuses[i] = &model.ModelStatusResponse{Name: name, Status: model.ModelStatusLoaded}
+	}
+	return statuses, nil
+}
+
+func (s *stubModelService) GetModel(ctx context.Context, name string) (*model.Model, error) {
+	return nil, s.err
+}
+
+// fakeAuditService 在内存中保存审计记录
+type fakeAuditService struct {
+	operations []*model.AdminOperation
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, operation *model.AdminOperation) error {
+	s.operations = append(s.operations, operation)
+	return nil
+}
+
+func (s *fakeAuditService) ListOperations(ctx context.Context, limit, offset int) ([]*model.AdminOperation, int64, error) {
+	end := offset + limit
+	if end > len(s.operations) {
+		end = len(s.operations)
+	}
+	if offset > end {
+		offset = end
+	}
+	return s.operations[offset:end], int64(len(s.operations)), nil
+}
+
+// stubInferenceService 按测试设定返回错误
+type stubInferenceService struct {
+	service.InferenceService
+	err error
+}
+
+func (s *stubInferenceService) Predict(ctx context.Context, req *model.PredictRequest) (*model.PredictResponse, error) {
+	return nil, s.err
+}
+
+func (s *stubInferenceService) PredictAsync(ctx context.Context, req *model.PredictRequest) (*model.AsyncPredictResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.AsyncPredictResponse{RequestID: "async-1", ModelName: req.ModelName, Status: model.InferenceStatusPending}, nil
+}
+
+func newQuietHandlerLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestHandlersMapErrorTypesToStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", apperrors.New(apperrors.ErrNotFound, "模型 m 不存在"), http.StatusNotFound},
+		{"conflict", fmt.Errorf("%w: m", service.ErrModelLoading), http.StatusConflict},
+		{"model not loaded", fmt.Errorf("推理失败: %w", apperrors.New(apperrors.ErrModelNotLoaded, "模型 m 未加载")), http.StatusConflict},
+		{"invalid input", apperrors.New(apperrors.ErrInvalidInput, "参数错误"), http.StatusBadRequest},
+		{"untyped", errors.New("数据库连接失败"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := newQuietHandlerLogger()
+			modelHandler := NewModelHandler(&stubModelService{err: tt.err}, &fakeAuditService{}, logger)
+			inferenceHandler := NewInferenceHandler(&stubInferenceService{err: tt.err}, logger)
+
+			router := gin.New()
+			router.GET("/models/:name", modelHandler.GetModel)
+			router.POST("/models/:name/load", modelHandler.LoadModel)
+			router.POST("/models/:name/unload", modelHandler.UnloadModel)
+			router.POST("/models/:name/reload", modelHandler.ReloadModel)
+			router.POST("/inference/predict", inferenceHandler.Predict)
+
+			requests := []*http.Request{
+				httptest.NewRequest(http.MethodGet, "/models/m", nil),
+				httptest.NewRequest(http.MethodPost, "/models/m/load", strings.NewReader(`{}`)),
+				httptest.NewRequest(http.MethodPost, "/models/m/unload", nil),
+				httptest.NewRequest(http.MethodPost, "/models/m/reload", nil),
+				httptest.NewRequest(http.MethodPost, "/inference/predict", strings.NewReader(`{"model_name":"m","data":{"text":"x"}}`)),
+			}
+			for _, req := range requests {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				assert.Equal(t, tt.want, w.Code, "%s %s", req.Method, req.URL.Path)
+				assert.Contains(t, w.Body.String(), fmt.Sprintf(`"code":%d`, tt.want))
+			}
+		})
+	}
+}
+
+func TestGetModelStatuses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modelService := &stubModelService{}
+	h := NewModelHandler(modelService, &fakeAuditService{}, newQuietHandlerLogger())
+
+	router := gin.New()
+	router.POST("/models/:name/load", h.LoadModel)
+	router.POST("/models/status", h.GetModelStatuses)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/models/status", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"names":["spam","ham"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.BatchModelStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Models, 2)
+	assert.Equal(t, "spam", resp.Models[0].Name)
+	assert.Equal(t, "ham", resp.Models[1].Name)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"names":[]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"names":[""]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{}`).Code)
+
+	modelService.err = errors.New("数据库连接失败")
+	assert.Equal(t, http.StatusInternalServerError, post(`{"names":["spam"]}`).Code)
+}
+
+func TestModelOperationsAreAudited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	audit := &fakeAuditService{}
+	modelService := &stubModelService{}
+	h := NewModelHandler(modelService, audit, newQuietHandlerLogger())
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.POST("/models/:name/load", middleware.AdminAuth("secret"), h.LoadModel)
+	router.POST("/models/:name/unload", middleware.AdminAuth("secret"), h.UnloadModel)
+	router.GET("/admin/operations", middleware.AdminAuth("secret"), h.ListOperations)
+
+	// 未携带管理令牌的请求被拒绝，不执行也不记录
+	req := httptest.NewRequest(http.MethodPost, "/models/classifier/load", strings.NewReader(`{}`))
+	req.Header.Set("X-Actor", "alice")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, audit.operations)
+
+	// 操作者取自鉴权身份，客户端伪造的 X-Actor 请求头被忽略
+	req = httptest.NewRequest(http.MethodPost, "/models/classifier/load", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Actor", "alice")
+	req.Header.Set("X-Request-ID", "req-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, audit.operations, 1)
+	op := audit.operations[0]
+	assert.Equal(t, "admin", op.Actor)
+	assert.Equal(t, model.AdminActionLoadModel, op.Action)
+	assert.Equal(t, "classifier", op.ModelName)
+	assert.Equal(t, model.AdminOperationSucceeded, op.Result)
+	assert.Equal(t, "req-1", op.RequestID)
+
+	// 失败的操作同样记录
+	modelService.err = errors.New("卸载超时")
+	req = httptest.NewRequest(http.MethodPost, "/models/classifier/unload", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	require.Len(t, audit.operations, 2)
+	op = audit.operations[1]
+	assert.Equal(t, "admin", op.Actor)
+	assert.Equal(t, model.AdminActionUnloadModel, op.Action)
+	assert.Equal(t, model.AdminOperationFailed, op.Result)
+	assert.Equal(t, "卸载超时", op.Error)
+
+	list := func(token, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/operations"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusUnauthorized, list("", "").Code)
+
+	w = list("secret", "?page=2&limit=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.AdminOperationListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(2), resp.Total)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 1, resp.Limit)
+	require.Len(t, resp.Operations, 1)
+	assert.Equal(t, model.AdminActionUnloadModel, resp.Operations[0].Action)
+}
+
+func TestRespondErrorIncludesFieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	err := apperrors.New(apperrors.ErrInvalidInput, "输入数据不符合模型 m 的 input_schema").
+		WithDetails(map[string]interface{}{"errors": []model.FieldError{{Field: "/text", Message: "expected string, but got number"}}})
+	h := NewInferenceHandler(&stubInferenceService{err: err}, newQuietHandlerLogger())
+	router := gin.New()
+	router.POST("/inference/predict", h.Predict)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/inference/predict", strings.NewReader(`{"model_name":"m","data":{"text":1}}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp struct {
+		Details struct {
+			Errors []model.FieldError `json:"errors"`
+		} `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []model.FieldError{{Field: "/text", Message: "expected string, but got number"}}, resp.Details.Errors)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus 指标
+var (
+	// PredictionCacheHits 预测结果缓存命中次数
+	PredictionCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_inference_prediction_cache_hits_total",
+			Help: "Total number of prediction results served from cache",
+		},
+		[]string{"model"},
+	)
+
+	// PredictionCacheMisses 预测结果缓存未命中次数
+	PredictionCacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_inference_prediction_cache_misses_total",
+			Help: "Total number of prediction requests not found in cache",
+		},
+		[]string{"model"},
+	)
+
+	// ModelMemoryBytes 已加载模型的常驻内存字节数
+	ModelMemoryBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_inference_model_memory_bytes",
+			Help: "Resident memory used by each loaded model in bytes",
+		},
+		[]string{"model"},
+	)
+
+	// ModelLoadProgress 模型加载进度（0-100）
+	ModelLoadProgress = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_inference_model_load_progress_percent",
+			Help: "Loading progress of each model in percent",
+		},
+		[]string{"model"},
+	)
+
+	// ModelBackendHealthy 已加载模型推理后端的探测结果，1 为正常，0 为连续探测失败
+	ModelBackendHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_inference_model_backend_healthy",
+			Help: "Whether the inference backend of each loaded model passes health probes",
+		},
+		[]string{"model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(PredictionCacheHits)
+	prometheus.MustRegister(PredictionCacheMisses)
+	prometheus.MustRegister(ModelMemoryBytes)
+	prometheus.MustRegister(ModelLoadProgress)
+	prometheus.MustRegister(ModelBackendHealthy)
+
+	// Go 运行时（协程数、GC、内存）与进程（常驻内存、文件描述符）指标
+	registerOnce(collectors.NewGoCollector())
+	registerOnce(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// registerOnce 注册到默认注册表，已注册过相同指标时忽略
+func registerOnce(c prometheus.Collector) {
+	var already prometheus.AlreadyRegisteredError
+	if err := prometheus.Register(c); err != nil && !errors.As(err, &already) {
+		panic(err)
+	}
+}
+
+// Handler 以 Prometheus 文本格式输出默认注册表中的指标
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
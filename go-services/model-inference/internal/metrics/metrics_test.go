@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerIncludesRuntimeMetrics(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "go_goroutines ")
+	assert.Contains(t, body, "go_memstats_heap_alloc_bytes ")
+	assert.Contains(t, body, "process_resident_memory_bytes ")
+}
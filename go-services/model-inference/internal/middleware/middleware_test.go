@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBodyLimitRouter(global, route int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(global))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/echo", echo)
+	router.POST("/batch", BodyLimit(route), echo)
+	return router
+}
+
+func TestBodyLimit(t *testing.T) {
+	router := newBodyLimitRouter(32, 8)
+
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		unknownSize bool
+		wantStatus  int
+	}{
+		{"within global limit", "/echo", strings.Repeat("a", 32), false, http.StatusOK},
+		{"over global limit", "/echo", strings.Repeat("a", 33), false, http.StatusRequestEntityTooLarge},
+		{"over global limit without content length", "/echo", strings.Repeat("a", 100), true, http.StatusRequestEntityTooLarge},
+		{"within route limit", "/batch", strings.Repeat("a", 8), false, http.StatusOK},
+		{"over route limit", "/batch", strings.Repeat("a", 9), false, http.StatusRequestEntityTooLarge},
+		{"over route limit without content length", "/batch", strings.Repeat("a", 16), true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.unknownSize {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.body, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), "请求体过大")
+			}
+		})
+	}
+}
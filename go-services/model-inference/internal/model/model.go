@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/pii"
 )
 
 // ModelStatus 模型状态枚举
@@ -14,6 +16,8 @@ const (
 	ModelStatusLoading  ModelStatus = "loading"
 	ModelStatusLoaded   ModelStatus = "loaded"
 	ModelStatusError    ModelStatus = "error"
+	// ModelStatusNotFound 模型不存在，只出现在批量状态查询的结果中，不会写入数据库
+	ModelStatusNotFound ModelStatus = "not_found"
 )
 
 // ModelType 模型类型枚举
@@ -30,10 +34,11 @@ const (
 type InferenceStatus string
 
 const (
-	InferenceStatusPending   InferenceStatus = "pending"
-	InferenceStatusRunning   InferenceStatus = "running"
-	InferenceStatusCompleted InferenceStatus = "completed"
-	InferenceStatusFailed    InferenceStatus = "failed"
+	InferenceStatusPending     InferenceStatus = "pending"
+	InferenceStatusRunning     InferenceStatus = "running"
+	InferenceStatusCompleted   InferenceStatus = "completed"
+	InferenceStatusFailed      InferenceStatus = "failed"
+	InferenceStatusNeedsReview InferenceStatus = "needs_review" // 分类置信度低于复核阈值，待人工复核
 )
 
 // Model 模型信息
@@ -62,6 +67,7 @@ type InferenceRequest struct {
 	Status      InferenceStatus `json:"status" gorm:"type:varchar(20);default:pending"`
 	Result      string          `json:"result" gorm:"type:json"`
 	Error       string          `json:"error" gorm:"type:text"`
+	Metadata    string          `json:"metadata,omitempty" gorm:"type:text"` // JSON 格式的附加信息，如 A/B 分流的变体
 	StartTime   time.Time       `json:"start_time"`
 	EndTime     *time.Time      `json:"end_time"`
 	Duration    int64           `json:"duration"` // 毫秒
@@ -70,6 +76,47 @@ type InferenceRequest struct {
 	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
 }
 
+// TextEmbedding 已处理文本的向量记录，同一模型下按文本哈希去重
+type TextEmbedding struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ModelName string    `json:"model_name" gorm:"type:varchar(100);uniqueIndex:idx_embedding_model_hash;not null"`
+	TextHash  string    `json:"text_hash" gorm:"type:varchar(64);uniqueIndex:idx_embedding_model_hash;not null"`
+	Text      string    `json:"text" gorm:"type:text;not null"`
+	Vector    string    `json:"vector" gorm:"type:json;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt 最近一次写入向量库的时间，按此清理过期向量
+	UpdatedAt time.Time `json:"updated_at" gorm:"index"`
+}
+
+// AdminOperation 管理操作审计记录，记录谁在何时对哪个模型执行了什么操作及结果
+type AdminOperation struct {
+	ID        uint                 `json:"id" gorm:"primaryKey"`
+	RequestID string               `json:"request_id" gorm:"type:varchar(100)"`
+	Actor     string               `json:"actor" gorm:"type:varchar(100);index;not null"`
+	Action    AdminAction          `json:"action" gorm:"type:varchar(50);not null"`
+	ModelName string               `json:"model_name" gorm:"type:varchar(100);index;not null"`
+	Result    AdminOperationResult `json:"result" gorm:"type:varchar(20);not null"`
+	Error     string               `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt time.Time            `json:"created_at" gorm:"index"`
+}
+
+// AdminAction 管理操作类型
+type AdminAction string
+
+const (
+	AdminActionLoadModel   AdminAction = "load_model"
+	AdminActionUnloadModel AdminAction = "unload_model"
+	AdminActionReloadModel AdminAction = "reload_model"
+)
+
+// AdminOperationResult 管理操作结果
+type AdminOperationResult string
+
+const (
+	AdminOperationSucceeded AdminOperationResult = "success"
+	AdminOperationFailed    AdminOperationResult = "failure"
+)
+
 // ModelStatistics 模型统计信息
 type ModelStatistics struct {
 	TotalModels   int64 `json:"total_models"`
@@ -85,6 +132,46 @@ type InferenceStatistics struct {
 	FailedRequests    int64   `json:"failed_requests"`
 	AverageLatency    float64 `json:"average_latency"` // 毫秒
 	RequestsPerSecond float64 `json:"requests_per_second"`
+	// 统计窗口内已完成请求的延迟分位数（毫秒）
+	LatencyP50 float64 `json:"latency_p50"`
+	LatencyP95 float64 `json:"latency_p95"`
+	LatencyP99 float64 `json:"latency_p99"`
+	// LatencyWindow 分位数统计窗口，例如 1h0m0s
+	LatencyWindow string `json:"latency_window"`
+	// Models 按模型分组的统计，仅在 group_by=model 时返回
+	Models map[string]*ModelInferenceStatistics `json:"models,omitempty"`
+	// RateLimits 配置了限流的模型的当前请求速率
+	RateLimits map[string]*ModelRateLimitStatistics `json:"rate_limits,omitempty"`
+}
+
+// ModelRateLimitStatistics 单个模型的限流状态
+type ModelRateLimitStatistics struct {
+	Limit       float64 `json:"limit"`        // 每秒允许的请求数
+	Burst       int     `json:"burst"`        // 令牌桶容量
+	CurrentRate float64 `json:"current_rate"` // 最近 10 秒内平均每秒通过的请求数
+	Rejected    int64   `json:"rejected"`     // 被限流拒绝的请求数
+}
+
+// ModelInferenceStatistics 单个模型的推理统计
+type ModelInferenceStatistics struct {
+	TotalRequests     int64   `json:"total_requests"`
+	CompletedRequests int64   `json:"completed_requests"`
+	FailedRequests    int64   `json:"failed_requests"`
+	AverageLatency    float64 `json:"average_latency"` // 毫秒
+}
+
+// StatisticsOptions 推理统计查询选项
+type StatisticsOptions struct {
+	GroupByModel bool
+	// Window 延迟分位数的统计窗口，为 0 时使用默认值
+	Window time.Duration
+}
+
+// LatencyPercentiles 延迟分位数（毫秒）
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
 }
 
 // PredictRequest 预测请求
@@ -92,6 +179,26 @@ type PredictRequest struct {
 	ModelName string                 `json:"model_name" binding:"required"`
 	Data      map[string]interface{} `json:"data" binding:"required"`
 	Options   map[string]interface{} `json:"options,omitempty"`
+	// CallbackURL 异步预测完成后以 POST 回调的地址，仅 async=true 时生效；不能指向本机或内网地址，除非主机在 inference.callback_allowed_hosts 中
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// AsyncPredictResponse 异步预测提交响应
+type AsyncPredictResponse struct {
+	RequestID string          `json:"request_id"`
+	ModelName string          `json:"model_name"`
+	Status    InferenceStatus `json:"status"`
+}
+
+// AsyncPredictCallback 异步预测完成后回调的内容
+type AsyncPredictCallback struct {
+	RequestID  string          `json:"request_id"`
+	ModelName  string          `json:"model_name"`
+	Status     InferenceStatus `json:"status"`
+	Prediction interface{}     `json:"prediction,omitempty"`
+	Confidence float64         `json:"confidence,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Duration   int64           `json:"duration"` // 毫秒
 }
 
 // BatchPredictRequest 批量预测请求
@@ -126,6 +233,40 @@ type TextClassifyRequest struct {
 	Text      string `json:"text" binding:"required"`
 }
 
+// NERRequest 命名实体识别请求
+type NERRequest struct {
+	ModelName string `json:"model_name" binding:"required"`
+	Text      string `json:"text" binding:"required"`
+}
+
+// TextQualityRequest 文本质量评分请求
+type TextQualityRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// TextQualityResponse 文本质量评分，Score 为 0-100 的综合得分，其余为 0-1 的分项得分
+type TextQualityResponse struct {
+	RequestID   string  `json:"request_id"`
+	Score       int     `json:"score"`
+	Length      float64 `json:"length"`
+	Purity      float64 `json:"purity"`
+	Punctuation float64 `json:"punctuation"`
+	Repetition  float64 `json:"repetition"`
+}
+
+// RedactRequest 敏感信息脱敏请求
+type RedactRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// RedactResponse 脱敏结果，Matches 的偏移量按字符计算并对应原文
+type RedactResponse struct {
+	RequestID string      `json:"request_id"`
+	Text      string      `json:"text"`
+	Types     []pii.Type  `json:"types"`
+	Matches   []pii.Match `json:"matches"`
+}
+
 // SentimentAnalysisRequest 情感分析请求
 type SentimentAnalysisRequest struct {
 	ModelName string `json:"model_name" binding:"required"`
@@ -144,15 +285,125 @@ type AnomalyDetectionRequest struct {
 	Data      map[string]interface{} `json:"data" binding:"required"`
 }
 
+// EmbeddingRequest 文本向量请求，Text 与 Texts 至少提供一个
+type EmbeddingRequest struct {
+	ModelName string   `json:"model_name" binding:"required"`
+	Text      string   `json:"text,omitempty"`
+	Texts     []string `json:"texts,omitempty"`
+	Normalize bool     `json:"normalize,omitempty"`
+	// Index 为 true 时将文本及其向量写入向量库，供相似文本检索；其他请求不会写入
+	Index bool `json:"index,omitempty"`
+}
+
+// EmbeddingResponse 文本向量响应，Embeddings 与请求文本顺序一致
+type EmbeddingResponse struct {
+	RequestID  string      `json:"request_id"`
+	ModelName  string      `json:"model_name"`
+	Embeddings [][]float64 `json:"embeddings"`
+	Dimension  int         `json:"dimension"`
+	CacheHits  int         `json:"cache_hits"`
+	Duration   int64       `json:"duration"` // 毫秒
+}
+
+// SimilarityRequest 相似文本检索请求
+type SimilarityRequest struct {
+	ModelName     string  `json:"model_name" binding:"required"`
+	Text          string  `json:"text" binding:"required"`
+	Limit         int     `json:"limit,omitempty"`
+	MinSimilarity float64 `json:"min_similarity,omitempty"`
+}
+
+// SimilarText 相似文本检索结果
+type SimilarText struct {
+	Text       string  `json:"text"`
+	Similarity float64 `json:"similarity"`
+}
+
+// SimilarityResponse 相似文本检索响应，Results 按相似度降序排列
+type SimilarityResponse struct {
+	RequestID string        `json:"request_id"`
+	ModelName string        `json:"model_name"`
+	Results   []SimilarText `json:"results"`
+	Duration  int64         `json:"duration"` // 毫秒
+}
+
 // TextAnalysisResponse 文本分析响应
 type TextAnalysisResponse struct {
-	RequestID  string                 `json:"request_id"`
-	ModelName  string                 `json:"model_name"`
-	Text       string                 `json:"text,omitempty"`
-	Result     interface{}            `json:"result"`
-	Confidence float64                `json:"confidence,omitempty"`
-	Features   map[string]interface{} `json:"features,omitempty"`
-	Duration   int64                  `json:"duration"` // 毫秒
+	RequestID   string                 `json:"request_id"`
+	ModelName   string                 `json:"model_name"`
+	Text        string                 `json:"text,omitempty"`
+	Result      interface{}            `json:"result"`
+	Confidence  float64                `json:"confidence,omitempty"`
+	NeedsReview bool                   `json:"needs_review,omitempty"` // 文本分类置信度低于模型复核阈值，需人工复核
+	Features    map[string]interface{} `json:"features,omitempty"`
+	Labels      []LabelScore           `json:"labels,omitempty"` // 多标签分类时得分超过阈值的标签，按得分降序
+	Entities    []Entity               `json:"entities,omitempty"`
+	Duration    int64                  `json:"duration"` // 毫秒
+}
+
+// EntityType 命名实体类型
+type EntityType string
+
+const (
+	EntityTypePerson       EntityType = "PERSON"
+	EntityTypeLocation     EntityType = "LOCATION"
+	EntityTypeOrganization EntityType = "ORGANIZATION"
+)
+
+// Entity 命名实体，Start/End 为字符（rune）偏移，左闭右开，中文一个汉字计为 1
+type Entity struct {
+	Type       EntityType `json:"type"`
+	Text       string     `json:"text"`
+	Start      int        `json:"start"`
+	End        int        `json:"end"`
+	Confidence float64    `json:"confidence"`
+}
+
+// EnsembleStrategy 集成推理的聚合策略
+type EnsembleStrategy string
+
+const (
+	// EnsembleStrategyMajority 各模型以最高分标签投票，票数最多者胜出
+	EnsembleStrategyMajority EnsembleStrategy = "majority"
+	// EnsembleStrategyAverage 各标签取所有模型得分的平均值
+	EnsembleStrategyAverage EnsembleStrategy = "average"
+	// EnsembleStrategyWeighted 各标签按模型权重加权平均
+	EnsembleStrategyWeighted EnsembleStrategy = "weighted"
+)
+
+// EnsembleRequest 集成推理请求，labels 为空时各模型使用元数据中配置的标签
+type EnsembleRequest struct {
+	ModelNames []string           `json:"model_names" binding:"required,min=1"`
+	Strategy   EnsembleStrategy   `json:"strategy" binding:"required"`
+	Text       string             `json:"text" binding:"required"`
+	Labels     []string           `json:"labels,omitempty"`
+	Weights    map[string]float64 `json:"weights,omitempty"`
+}
+
+// EnsembleModelResult 集成推理中单个模型的结果
+type EnsembleModelResult struct {
+	ModelName  string             `json:"model_name"`
+	Label      string             `json:"label"`
+	Confidence float64            `json:"confidence"`
+	Scores     map[string]float64 `json:"scores"`
+	Weight     float64            `json:"weight,omitempty"`
+}
+
+// EnsembleResponse 集成推理响应，scores 为聚合后的各标签得分（多数投票时为得票比例）
+type EnsembleResponse struct {
+	RequestID  string                `json:"request_id"`
+	Strategy   EnsembleStrategy      `json:"strategy"`
+	Label      string                `json:"label"`
+	Confidence float64               `json:"confidence"`
+	Scores     map[string]float64    `json:"scores"`
+	Models     []EnsembleModelResult `json:"models"`
+	Duration   int64                 `json:"duration"` // 毫秒
+}
+
+// LabelScore 多标签分类的单个标签得分
+type LabelScore struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
 }
 
 // ModelLoadRequest 模型加载请求
@@ -169,6 +420,16 @@ type ModelStatusResponse struct {
 	Metadata  interface{} `json:"metadata,omitempty"`
 }
 
+// BatchModelStatusRequest 批量模型状态查询请求
+type BatchModelStatusRequest struct {
+	Names []string `json:"names" binding:"required,min=1,max=100,dive,required"`
+}
+
+// BatchModelStatusResponse 批量模型状态查询响应，顺序与请求中的名称一致
+type BatchModelStatusResponse struct {
+	Models []*ModelStatusResponse `json:"models"`
+}
+
 // HealthResponse 健康检查响应
 type HealthResponse struct {
 	Status    string                 `json:"status"`
@@ -176,6 +437,30 @@ type HealthResponse struct {
 	Services  map[string]interface{} `json:"services"`
 }
 
+// LogLevelRequest 日志级别修改请求
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevelResponse 日志级别响应
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// AdminOperationListResponse 管理操作审计记录分页响应
+type AdminOperationListResponse struct {
+	Operations []*AdminOperation `json:"operations"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+}
+
+// FieldError 字段级校验错误，Field 为 JSON Pointer 形式的字段位置
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // ErrorResponse 错误响应
 type ErrorResponse struct {
 	Error     string                 `json:"error"`
@@ -193,4 +478,14 @@ func (Model) TableName() string {
 // TableName 指定表名
 func (InferenceRequest) TableName() string {
 	return "inference_requests"
+}
+
+// TableName 指定表名
+func (TextEmbedding) TableName() string {
+	return "text_embeddings"
+}
+
+// TableName 指定表名
+func (AdminOperation) TableName() string {
+	return "admin_operations"
 }
\ No newline at end of file
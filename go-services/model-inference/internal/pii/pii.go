@@ -0,0 +1,223 @@
+// Package pii 识别并遮盖中文文本中的个人敏感信息：手机号、身份证号、邮箱与银行卡号。
+// data-collector 入库前使用相同规则脱敏，修改时需同步，并同步更新两侧的 testdata/cases.json
+package pii
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Type 敏感信息类型
+type Type string
+
+const (
+	TypePhone    Type = "phone"
+	TypeIDCard   Type = "id_card"
+	TypeEmail    Type = "email"
+	TypeBankCard Type = "bank_card"
+)
+
+// Match 一处敏感信息，Start、End 为按字符（rune）计算的偏移量，不包含原文
+type Match struct {
+	Type  Type `json:"type"`
+	Start int  `json:"start"`
+	End   int  `json:"end"`
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	// idCardPattern 18 位身份证号：地区码、出生日期、顺序码与校验位
+	idCardPattern = regexp.MustCompile(`[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]`)
+	// phonePattern 大陆手机号，可带 +86 或 86 前缀，允许 3-4-4 分隔
+	phonePattern = regexp.MustCompile(`(?:\+?86[\s\-]?)?1[3-9]\d(?:[\s\-]?\d{4}){2}`)
+	// bankCardPattern 16-19 位银行卡号，允许每 4 位以空格或连字符分隔
+	bankCardPattern = regexp.MustCompile(`\d{4}(?:[\s\-]?\d{4}){3}(?:[\s\-]?\d{1,3})?`)
+)
+
+// detectors 按优先级排列，先匹配的类型占用的位置不再参与后续匹配，
+// 18 位身份证号可能恰好通过 Luhn 校验，因此先于银行卡识别
+var detectors = []struct {
+	typ     Type
+	pattern *regexp.Regexp
+	valid   func(string) bool
+}{
+	{TypeEmail, emailPattern, nil},
+	{TypeIDCard, idCardPattern, validIDCard},
+	{TypeBankCard, bankCardPattern, validBankCard},
+	{TypePhone, phonePattern, nil},
+}
+
+// Detect 返回文本中的敏感信息，按出现位置排序。
+// 数字类信息要求前后不紧邻数字或字母，避免把更长编号的一部分误判为敏感信息
+func Detect(text string) []Match {
+	type span struct {
+		typ        Type
+		start, end int // 字节偏移
+	}
+	var spans []span
+	overlaps := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && s.start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, d := range detectors {
+		for _, loc := range d.pattern.FindAllStringIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if d.typ != TypeEmail && !isolated(text, start, end) {
+				continue
+			}
+			if d.valid != nil && !d.valid(text[start:end]) {
+				continue
+			}
+			if overlaps(start, end) {
+				continue
+			}
+			spans = append(spans, span{typ: d.typ, start: start, end: end})
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	matches := make([]Match, len(spans))
+	for i, s := range spans {
+		startRunes := utf8.RuneCountInString(text[:s.start])
+		matches[i] = Match{
+			Type:  s.typ,
+			Start: startRunes,
+			End:   startRunes + utf8.RuneCountInString(text[s.start:s.end]),
+		}
+	}
+	return matches
+}
+
+// Redact 遮盖文本中的敏感信息，返回脱敏后的文本与识别到的信息
+func Redact(text string) (string, []Match) {
+	matches := Detect(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	runes := []rune(text)
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(string(runes[last:m.Start]))
+		b.WriteString(mask(m.Type, string(runes[m.Start:m.End])))
+		last = m.End
+	}
+	b.WriteString(string(runes[last:]))
+	return b.String(), matches
+}
+
+// Types 返回 matches 中出现的类型，去重并排序
+func Types(matches []Match) []Type {
+	seen := make(map[Type]bool)
+	var types []Type
+	for _, m := range matches {
+		if !seen[m.Type] {
+			seen[m.Type] = true
+			types = append(types, m.Type)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// mask 邮箱保留用户名首字符与域名，其余类型保留前 3 位与后 4 位数字
+func mask(typ Type, value string) string {
+	if typ == TypeEmail {
+		at := strings.LastIndex(value, "@")
+		first, _ := utf8.DecodeRuneInString(value)
+		return string(first) + "***" + value[at:]
+	}
+
+	digits := []rune(digitsOnly(value))
+	if typ == TypePhone && len(digits) > 11 {
+		// 去掉国家码后按 11 位手机号遮盖
+		digits = digits[len(digits)-11:]
+	}
+	if len(digits) <= 7 {
+		return strings.Repeat("*", len(digits))
+	}
+	return string(digits[:3]) + strings.Repeat("*", len(digits)-7) + string(digits[len(digits)-4:])
+}
+
+// isolated 匹配内容前后不紧邻数字或 ASCII 字母
+func isolated(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isAlnum(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if isAlnum(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// digitsOnly 去掉分隔符，保留数字与身份证校验位 X
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if (r >= '0' && r <= '9') || r == 'X' || r == 'x' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// idCardWeights 身份证前 17 位的加权系数（GB 11643）
+var idCardWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// idCardCheckCodes 加权和模 11 对应的校验位
+const idCardCheckCodes = "10X98765432"
+
+// validIDCard 校验 18 位身份证号的校验位
+func validIDCard(value string) bool {
+	if len(value) != 18 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(value[i]-'0') * idCardWeights[i]
+	}
+	return strings.EqualFold(string(idCardCheckCodes[sum%11]), value[17:])
+}
+
+// validBankCard 去掉分隔符后为 16-19 位且通过 Luhn 校验
+func validBankCard(value string) bool {
+	digits := digitsOnly(value)
+	if len(digits) < 16 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
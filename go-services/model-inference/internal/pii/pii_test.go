@@ -0,0 +1,106 @@
+package pii
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactEachType(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		typ  Type
+	}{
+		{"phone", "有事请打13812345678联系我", "有事请打138****5678联系我", TypePhone},
+		{"phone with country code", "电话：+86 138-1234-5678。", "电话：138****5678。", TypePhone},
+		{"id card", "身份证号11010519491231002X已登记", "身份证号110***********002X已登记", TypeIDCard},
+		{"email", "发到 zhang.san@example.com 就行", "发到 z***@example.com 就行", TypeEmail},
+		{"bank card", "卡号 6222 0212 3456 7890 128 转账", "卡号 622************0128 转账", TypeBankCard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted, matches := Redact(tt.text)
+			assert.Equal(t, tt.want, redacted)
+			require.Len(t, matches, 1)
+			assert.Equal(t, tt.typ, matches[0].Type)
+		})
+	}
+}
+
+func TestDetectGuardsAgainstFalsePositives(t *testing.T) {
+	texts := map[string]string{
+		// 通不过校验位的 18 位数字
+		"bad id checksum": "编号110105194912310021",
+		// 通不过 Luhn 校验的 16 位数字
+		"bad luhn": "订单 6222021234567891",
+		// 更长编号中的 11 位片段
+		"phone inside longer number": "流水号 201381234567890123456",
+		"phone after letters":        "型号AB13812345678",
+		"not a mobile prefix":        "座机 12812345678",
+		"short numbers":              "今年 2024 年，价格 12345 元",
+		"at sign without domain":     "@张三 你好",
+	}
+	for name, text := range texts {
+		t.Run(name, func(t *testing.T) {
+			assert.Empty(t, Detect(text))
+		})
+	}
+}
+
+func TestDetectOffsetsAndTypes(t *testing.T) {
+	text := "联系人：李四，手机13812345678，邮箱 li4@example.com，手机13998765432"
+	matches := Detect(text)
+	require.Len(t, matches, 3)
+	runes := []rune(text)
+	assert.Equal(t, "13812345678", string(runes[matches[0].Start:matches[0].End]))
+	assert.Equal(t, "li4@example.com", string(runes[matches[1].Start:matches[1].End]))
+	assert.Equal(t, []Type{TypeEmail, TypePhone}, Types(matches))
+
+	redacted, _ := Redact("没有敏感信息")
+	assert.Equal(t, "没有敏感信息", redacted)
+}
+
+// sharedCase testdata/cases.json 中的用例，data-collector 与 model-inference 各存一份相同的副本
+type sharedCase struct {
+	Name     string  `json:"name"`
+	Text     string  `json:"text"`
+	Redacted string  `json:"redacted"`
+	Matches  []Match `json:"matches"`
+}
+
+// TestRedactSharedCases 两个服务的 pii 包是同一份规则的副本，用相同的用例表校验，任一侧单独修改规则都会失败
+func TestRedactSharedCases(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cases.json"))
+	require.NoError(t, err)
+	var cases []sharedCase
+	require.NoError(t, json.Unmarshal(data, &cases))
+	require.NotEmpty(t, cases)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			redacted, matches := Redact(tc.Text)
+			assert.Equal(t, tc.Redacted, redacted)
+			if len(tc.Matches) == 0 {
+				assert.Empty(t, matches)
+			} else {
+				assert.Equal(t, tc.Matches, matches)
+			}
+		})
+	}
+
+	// 仓库中另一服务的用例表必须与本服务一致（单独构建某个服务时不存在，跳过）
+	sibling := filepath.Join("..", "..", "..", "data-collector", "internal", "pii", "testdata", "cases.json")
+	other, err := os.ReadFile(sibling)
+	if os.IsNotExist(err) {
+		t.Skip("sibling service not present")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(other), "testdata/cases.json differs from %s", sibling)
+}
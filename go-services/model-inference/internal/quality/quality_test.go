@@ -0,0 +1,80 @@
+package quality
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateSampleTexts(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		minScore int
+		maxScore int
+	}{
+		{"good", "这是一个关于人工智能发展的深度讨论，涉及到机器学习、深度学习等多个领域。", 90, 100},
+		{"mixed english terms", "ChatGPT的出现改变了我们对AI的认知，但我们也需要关注其潜在风险。", 80, 100},
+		{"short", "简短回复", 0, 0},
+		{"repetitive", strings.Repeat("这是一个非常长的文本内容，用于测试长度过滤功能。", 50), 0, 10},
+		{"flooding", "哈哈哈哈哈哈哈哈哈哈哈哈哈哈哈哈哈哈", 0, 10},
+		{"punctuation only", "！！！？？？。。。，，，好的！！！？？？", 0, 40},
+		{"emoji", "😀😀😀👍👍👍太棒了😀😀😀👍👍👍♥♥♥", 0, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Evaluate(tt.text)
+			assert.GreaterOrEqual(t, score.Total, tt.minScore, "%+v", score)
+			assert.LessOrEqual(t, score.Total, tt.maxScore, "%+v", score)
+		})
+	}
+}
+
+func TestEvaluateComponents(t *testing.T) {
+	score := Evaluate(strings.Repeat("这是一个非常长的文本内容，用于测试长度过滤功能。", 50))
+	assert.Zero(t, score.Repetition)
+	assert.Equal(t, 1.0, score.Purity)
+
+	score = Evaluate("简短回复")
+	assert.Zero(t, score.Length)
+	assert.Equal(t, 1.0, score.Repetition)
+
+	assert.Zero(t, Evaluate("").Total)
+}
+
+// sharedCase testdata/cases.json 中的用例，data-collector 与 model-inference 各存一份相同的副本
+type sharedCase struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+	Want Score  `json:"want"`
+}
+
+// TestEvaluateSharedCases 两个服务的 quality 包是同一份规则的副本，用相同的用例表校验，任一侧单独修改规则都会失败
+func TestEvaluateSharedCases(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cases.json"))
+	require.NoError(t, err)
+	var cases []sharedCase
+	require.NoError(t, json.Unmarshal(data, &cases))
+	require.NotEmpty(t, cases)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Want, Evaluate(tc.Text))
+		})
+	}
+
+	// 仓库中另一服务的用例表必须与本服务一致（单独构建某个服务时不存在，跳过）
+	sibling := filepath.Join("..", "..", "..", "data-collector", "internal", "quality", "testdata", "cases.json")
+	other, err := os.ReadFile(sibling)
+	if os.IsNotExist(err) {
+		t.Skip("sibling service not present")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(other), "testdata/cases.json differs from %s", sibling)
+}
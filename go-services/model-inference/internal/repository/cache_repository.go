@@ -3,12 +3,16 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// ErrCacheMiss 缓存键或哈希字段不存在
+var ErrCacheMiss = errors.New("缓存未命中")
+
 // CacheRepository 缓存仓库接口
 type CacheRepository interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
@@ -25,6 +29,13 @@ type CacheRepository interface {
 	HGet(ctx context.Context, key string, field string, dest interface{}) error
 	HGetAll(ctx context.Context, key string) (map[string]string, error)
 	HDel(ctx context.Context, key string, fields ...string) error
+	// MSet 通过一次流水线写入多个键，每个键使用相同的过期时间
+	MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error
+	// MGet 一次读取多个键并反序列化到 dests 对应位置，返回每个键是否命中；
+	// 数据损坏的键按未命中处理，由调用方重新写入
+	MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error)
+	// HMSet 在一次请求中设置同一哈希的多个字段
+	HMSet(ctx context.Context, key string, values map[string]interface{}) error
 }
 
 // cacheRepository 缓存仓库实现
@@ -56,7 +67,7 @@ func (r *cacheRepository) Get(ctx context.Context, key string, dest interface{})
 	data, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil // 缓存不存在
+			return ErrCacheMiss
 		}
 		return fmt.Errorf("获取缓存失败: %w", err)
 	}
@@ -170,7 +181,7 @@ func (r *cacheRepository) HGet(ctx context.Context, key string, field string, de
 	data, err := r.client.HGet(ctx, key, field).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil // 字段不存在
+			return ErrCacheMiss
 		}
 		return fmt.Errorf("获取哈希字段失败: %w", err)
 	}
@@ -197,4 +208,74 @@ func (r *cacheRepository) HDel(ctx context.Context, key string, fields ...string
 		return fmt.Errorf("删除哈希字段失败: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// MSet 批量设置缓存
+func (r *cacheRepository) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+	encoded := make(map[string][]byte, len(values))
+	for key, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("序列化数据失败: %w", err)
+		}
+		encoded[key] = data
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range encoded {
+			pipe.Set(ctx, key, data, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("批量设置缓存失败: %w", err)
+	}
+	return nil
+}
+
+// MGet 批量获取缓存
+func (r *cacheRepository) MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error) {
+	if len(keys) != len(dests) {
+		return nil, fmt.Errorf("批量获取缓存失败: %d 个键对应 %d 个目标", len(keys), len(dests))
+	}
+	hits := make([]bool, len(keys))
+	if len(keys) == 0 {
+		return hits, nil
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("批量获取缓存失败: %w", err)
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		hits[i] = json.Unmarshal([]byte(data), dests[i]) == nil
+	}
+	return hits, nil
+}
+
+// HMSet 批量设置哈希字段
+func (r *cacheRepository) HMSet(ctx context.Context, key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+	pairs := make([]interface{}, 0, len(values)*2)
+	for field, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("序列化数据失败: %w", err)
+		}
+		pairs = append(pairs, field, data)
+	}
+
+	if err := r.client.HSet(ctx, key, pairs...).Err(); err != nil {
+		return fmt.Errorf("批量设置哈希字段失败: %w", err)
+	}
+	return nil
+}
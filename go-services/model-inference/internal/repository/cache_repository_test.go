@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCacheRepository(t *testing.T) (CacheRepository, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewCacheRepository(client), mr
+}
+
+type cachedValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestCacheRepositoryGet(t *testing.T) {
+	ctx := context.Background()
+	repo, mr := newTestCacheRepository(t)
+
+	t.Run("hit", func(t *testing.T) {
+		require.NoError(t, repo.Set(ctx, "hit", cachedValue{Name: "a", Count: 1}, time.Minute))
+
+		var got cachedValue
+		require.NoError(t, repo.Get(ctx, "hit", &got))
+		assert.Equal(t, cachedValue{Name: "a", Count: 1}, got)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		var got cachedValue
+		err := repo.Get(ctx, "missing", &got)
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("deserialize error", func(t *testing.T) {
+		require.NoError(t, mr.Set("corrupt", "{not json"))
+
+		var got cachedValue
+		err := repo.Get(ctx, "corrupt", &got)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCacheMiss)
+	})
+}
+
+func TestCacheRepositoryHGet(t *testing.T) {
+	ctx := context.Background()
+	repo, mr := newTestCacheRepository(t)
+
+	t.Run("hit", func(t *testing.T) {
+		require.NoError(t, repo.HSet(ctx, "hash", "field", cachedValue{Name: "b", Count: 2}))
+
+		var got cachedValue
+		require.NoError(t, repo.HGet(ctx, "hash", "field", &got))
+		assert.Equal(t, cachedValue{Name: "b", Count: 2}, got)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		var got cachedValue
+		assert.ErrorIs(t, repo.HGet(ctx, "hash", "missing", &got), ErrCacheMiss)
+		assert.ErrorIs(t, repo.HGet(ctx, "no-such-hash", "field", &got), ErrCacheMiss)
+	})
+
+	t.Run("deserialize error", func(t *testing.T) {
+		mr.HSet("hash", "corrupt", "{not json")
+
+		var got cachedValue
+		err := repo.HGet(ctx, "hash", "corrupt", &got)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCacheMiss)
+	})
+}
+
+func TestCacheRepositoryMSetMGet(t *testing.T) {
+	ctx := context.Background()
+	repo, mr := newTestCacheRepository(t)
+
+	require.NoError(t, repo.MSet(ctx, map[string]interface{}{
+		"batch:a": cachedValue{Name: "a", Count: 1},
+		"batch:b": cachedValue{Name: "b", Count: 2},
+	}, time.Minute))
+	assert.Equal(t, time.Minute, mr.TTL("batch:a"))
+	require.NoError(t, mr.Set("batch:corrupt", "{not json"))
+
+	got := make([]cachedValue, 4)
+	hits, err := repo.MGet(ctx,
+		[]string{"batch:b", "batch:missing", "batch:a", "batch:corrupt"},
+		[]interface{}{&got[0], &got[1], &got[2], &got[3]})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true, false}, hits)
+	assert.Equal(t, cachedValue{Name: "b", Count: 2}, got[0])
+	assert.Equal(t, cachedValue{Name: "a", Count: 1}, got[2])
+
+	_, err = repo.MGet(ctx, []string{"batch:a"}, nil)
+	assert.Error(t, err)
+	require.NoError(t, repo.MSet(ctx, nil, time.Minute))
+}
+
+func TestCacheRepositoryHMSet(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newTestCacheRepository(t)
+
+	require.NoError(t, repo.HMSet(ctx, "hash", map[string]interface{}{
+		"x": cachedValue{Name: "x", Count: 1},
+		"y": cachedValue{Name: "y", Count: 2},
+	}))
+
+	var got cachedValue
+	require.NoError(t, repo.HGet(ctx, "hash", "y", &got))
+	assert.Equal(t, cachedValue{Name: "y", Count: 2}, got)
+	all, err := repo.HGetAll(ctx, "hash")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+// BenchmarkCacheRepositorySet 对比逐个 Set 与一次流水线 MSet 写入相同数量的键
+func BenchmarkCacheRepositorySet(b *testing.B) {
+	const n = 100
+	ctx := context.Background()
+	mr := miniredis.RunT(b)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	b.Cleanup(func() { client.Close() })
+	repo := NewCacheRepository(client)
+
+	values := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		values[fmt.Sprintf("bench:%d", i)] = cachedValue{Name: "bench", Count: i}
+	}
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for key, value := range values {
+				if err := repo.Set(ctx, key, value, time.Minute); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("pipeline", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := repo.MSet(ctx, values, time.Minute); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
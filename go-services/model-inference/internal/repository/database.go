@@ -30,9 +30,9 @@ func NewDatabase(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	}
 
 	// 设置连接池参数
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
 
 	// 自动迁移数据库表
 	if err := autoMigrate(db); err != nil {
@@ -47,5 +47,7 @@ func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&model.Model{},
 		&model.InferenceRequest{},
+		&model.TextEmbedding{},
+		&model.AdminOperation{},
 	)
 }
\ No newline at end of file
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,8 +24,10 @@ type InferenceRepository interface {
 	UpdateResult(requestID string, result string, endTime time.Time, duration int64) error
 	UpdateError(requestID string, errorMsg string, endTime time.Time, duration int64) error
 	Delete(id uint) error
-	DeleteOldRecords(before time.Time) error
+	DeleteOldRecords(before time.Time, batchSize int) (int64, error)
 	GetStatistics() (*model.InferenceStatistics, error)
+	GetStatisticsByModel() (map[string]*model.ModelInferenceStatistics, error)
+	GetLatencyPercentiles(since time.Time) (*model.LatencyPercentiles, error)
 	Count() (int64, error)
 	CountByStatus(status model.InferenceStatus) (int64, error)
 	CountByModelName(modelName string) (int64, error)
@@ -152,12 +156,33 @@ func (r *inferenceRepository) Delete(id uint) error {
 	return nil
 }
 
-// DeleteOldRecords 删除旧记录
-func (r *inferenceRepository) DeleteOldRecords(before time.Time) error {
-	if err := r.db.Where("created_at < ?", before).Delete(&model.InferenceRequest{}).Error; err != nil {
-		return fmt.Errorf("删除旧记录失败: %w", err)
+// DeleteOldRecords 分批物理删除 before 之前创建的记录，返回删除的行数
+// 每批先按主键取出至多 batchSize 条再删除，避免长时间锁表；多副本并发执行时重复删除无副作用
+func (r *inferenceRepository) DeleteOldRecords(before time.Time, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		if err := r.db.Unscoped().Model(&model.InferenceRequest{}).
+			Where("created_at < ?", before).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return total, fmt.Errorf("查询旧记录失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := r.db.Unscoped().Delete(&model.InferenceRequest{}, ids)
+		if result.Error != nil {
+			return total, fmt.Errorf("删除旧记录失败: %w", result.Error)
+		}
+		total += result.RowsAffected
+
+		if len(ids) < batchSize {
+			return total, nil
+		}
 	}
-	return nil
 }
 
 // GetStatistics 获取推理统计信息
@@ -197,6 +222,67 @@ func (r *inferenceRepository) GetStatistics() (*model.InferenceStatistics, error
 	return &stats, nil
 }
 
+// GetStatisticsByModel 按模型名分组获取推理统计信息
+func (r *inferenceRepository) GetStatisticsByModel() (map[string]*model.ModelInferenceStatistics, error) {
+	var rows []struct {
+		ModelName string
+		model.ModelInferenceStatistics
+	}
+	err := r.db.Model(&model.InferenceRequest{}).
+		Select(`model_name,
+			COUNT(*) AS total_requests,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed_requests,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS failed_requests,
+			COALESCE(AVG(CASE WHEN status = ? AND duration > 0 THEN duration END), 0) AS average_latency`,
+			model.InferenceStatusCompleted, model.InferenceStatusFailed, model.InferenceStatusCompleted).
+		Group("model_name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取模型统计信息失败: %w", err)
+	}
+
+	stats := make(map[string]*model.ModelInferenceStatistics, len(rows))
+	for i := range rows {
+		stats[rows[i].ModelName] = &rows[i].ModelInferenceStatistics
+	}
+	return stats, nil
+}
+
+// latencySampleLimit 计算延迟分位数时最多采样的最近记录数
+const latencySampleLimit = 10000
+
+// GetLatencyPercentiles 根据 since 之后已完成请求的耗时计算 p50/p95/p99，
+// 记录过多时只采样最近的 latencySampleLimit 条
+func (r *inferenceRepository) GetLatencyPercentiles(since time.Time) (*model.LatencyPercentiles, error) {
+	var durations []int64
+	if err := r.db.Model(&model.InferenceRequest{}).
+		Where("status = ? AND created_at > ?", model.InferenceStatusCompleted, since).
+		Order("created_at DESC").
+		Limit(latencySampleLimit).
+		Pluck("duration", &durations).Error; err != nil {
+		return nil, fmt.Errorf("获取请求耗时失败: %w", err)
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return &model.LatencyPercentiles{
+		P50: percentile(durations, 50),
+		P95: percentile(durations, 95),
+		P99: percentile(durations, 99),
+	}, nil
+}
+
+// percentile 按最近秩法计算已排序数据的分位数，数据为空时返回 0
+func percentile(sorted []int64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1])
+}
+
 // Count 获取推理请求总数
 func (r *inferenceRepository) Count() (int64, error) {
 	var count int64
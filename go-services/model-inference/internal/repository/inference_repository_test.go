@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, autoMigrate(db))
+	return db
+}
+
+func TestGetStatisticsByModel(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInferenceRepository(db)
+
+	seq := 0
+	seed := func(modelName string, status model.InferenceStatus, duration int64) {
+		seq++
+		require.NoError(t, repo.Create(&model.InferenceRequest{
+			RequestID: fmt.Sprintf("req-%d", seq),
+			ModelName: modelName,
+			InputData: "{}",
+			Status:    status,
+			Duration:  duration,
+		}))
+	}
+	seed("classifier", model.InferenceStatusCompleted, 10)
+	seed("classifier", model.InferenceStatusCompleted, 30)
+	seed("classifier", model.InferenceStatusFailed, 100)
+	seed("sentiment", model.InferenceStatusPending, 0)
+	seed("sentiment", model.InferenceStatusFailed, 0)
+
+	stats, err := repo.GetStatisticsByModel()
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	// 平均延迟只统计已完成的请求
+	assert.Equal(t, &model.ModelInferenceStatistics{
+		TotalRequests:     3,
+		CompletedRequests: 2,
+		FailedRequests:    1,
+		AverageLatency:    20,
+	}, stats["classifier"])
+	assert.Equal(t, &model.ModelInferenceStatistics{
+		TotalRequests:  2,
+		FailedRequests: 1,
+	}, stats["sentiment"])
+}
+
+func TestGetLatencyPercentiles(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInferenceRepository(db)
+
+	now := time.Now()
+	seed := func(id string, status model.InferenceStatus, duration int64, createdAt time.Time) {
+		require.NoError(t, repo.Create(&model.InferenceRequest{
+			RequestID: id,
+			ModelName: "m",
+			InputData: "{}",
+			Status:    status,
+			Duration:  duration,
+			CreatedAt: createdAt,
+		}))
+	}
+	// 窗口内已完成请求耗时为 1..200 毫秒的乱序排列
+	for i := 1; i <= 200; i++ {
+		seed(fmt.Sprintf("recent-%d", i), model.InferenceStatusCompleted, int64((i*37)%200+1), now.Add(-time.Minute))
+	}
+	// 窗口外或未完成的请求不参与统计
+	seed("old", model.InferenceStatusCompleted, 100000, now.Add(-2*time.Hour))
+	seed("failed", model.InferenceStatusFailed, 100000, now.Add(-time.Minute))
+
+	latency, err := repo.GetLatencyPercentiles(now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, 100, latency.P50, 1)
+	assert.InDelta(t, 190, latency.P95, 1)
+	assert.InDelta(t, 198, latency.P99, 1)
+
+	// 窗口内没有数据时返回 0
+	latency, err = repo.GetLatencyPercentiles(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, &model.LatencyPercentiles{}, latency)
+}
@@ -14,6 +14,7 @@ type ModelRepository interface {
 	Create(model *model.Model) error
 	GetByName(name string) (*model.Model, error)
 	GetByID(id uint) (*model.Model, error)
+	ListByNames(names []string) ([]*model.Model, error)
 	List(limit, offset int) ([]*model.Model, error)
 	ListByType(modelType model.ModelType, limit, offset int) ([]*model.Model, error)
 	Update(model *model.Model) error
@@ -77,6 +78,18 @@ func (r *modelRepository) List(limit, offset int) ([]*model.Model, error) {
 	return models, nil
 }
 
+// ListByNames 批量获取指定名称的模型，不存在的名称不出现在结果中
+func (r *modelRepository) ListByNames(names []string) ([]*model.Model, error) {
+	var models []*model.Model
+	if len(names) == 0 {
+		return models, nil
+	}
+	if err := r.db.Where("name IN ?", names).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("批量获取模型失败: %w", err)
+	}
+	return models, nil
+}
+
 // ListByType 根据类型获取模型列表
 func (r *modelRepository) ListByType(modelType model.ModelType, limit, offset int) ([]*model.Model, error) {
 	var models []*model.Model
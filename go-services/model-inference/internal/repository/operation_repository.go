@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// OperationRepository 管理操作审计仓库接口
+type OperationRepository interface {
+	Create(operation *model.AdminOperation) error
+	List(limit, offset int) ([]*model.AdminOperation, int64, error)
+}
+
+// operationRepository 管理操作审计仓库实现
+type operationRepository struct {
+	db *gorm.DB
+}
+
+// NewOperationRepository 创建管理操作审计仓库
+func NewOperationRepository(db *gorm.DB) OperationRepository {
+	return &operationRepository{db: db}
+}
+
+// Create 写入审计记录
+func (r *operationRepository) Create(operation *model.AdminOperation) error {
+	if err := r.db.Create(operation).Error; err != nil {
+		return fmt.Errorf("写入管理操作记录失败: %w", err)
+	}
+	return nil
+}
+
+// List 按时间倒序分页获取审计记录，同时返回记录总数
+func (r *operationRepository) List(limit, offset int) ([]*model.AdminOperation, int64, error) {
+	var total int64
+	if err := r.db.Model(&model.AdminOperation{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计管理操作记录失败: %w", err)
+	}
+
+	var operations []*model.AdminOperation
+	if err := r.db.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&operations).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取管理操作记录失败: %w", err)
+	}
+	return operations, total, nil
+}
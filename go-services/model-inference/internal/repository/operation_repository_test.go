@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestOperationRepositoryListsNewestFirst(t *testing.T) {
+	repo := NewOperationRepository(newTestDB(t))
+
+	base := time.Now().Add(-time.Hour)
+	for i, name := range []string{"first", "second", "third"} {
+		require.NoError(t, repo.Create(&model.AdminOperation{
+			Actor:     "admin",
+			Action:    model.AdminActionLoadModel,
+			ModelName: name,
+			Result:    model.AdminOperationSucceeded,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	operations, total, err := repo.List(2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, operations, 2)
+	assert.Equal(t, "third", operations[0].ModelName)
+	assert.Equal(t, "second", operations[1].ModelName)
+
+	operations, _, err = repo.List(2, 2)
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+	assert.Equal(t, "first", operations[0].ModelName)
+}
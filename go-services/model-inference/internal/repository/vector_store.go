@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// VectorStore 文本向量存储接口，后续可替换为专用向量数据库实现
+type VectorStore interface {
+	Save(ctx context.Context, modelName, text string, vector []float64) error
+	Search(ctx context.Context, modelName string, query []float64, limit int, minSimilarity float64) ([]model.SimilarText, error)
+	DeleteOlderThan(ctx context.Context, before time.Time, batchSize int) (int64, error)
+}
+
+// gormVectorStore 基于数据库表的向量存储，检索时暴力计算余弦相似度
+type gormVectorStore struct {
+	db *gorm.DB
+}
+
+// NewVectorStore 创建向量存储
+func NewVectorStore(db *gorm.DB) VectorStore {
+	return &gormVectorStore{db: db}
+}
+
+// Save 保存文本向量，同一模型下相同文本只保留最新一份，并刷新写入时间
+func (s *gormVectorStore) Save(ctx context.Context, modelName, text string, vector []float64) error {
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return fmt.Errorf("序列化向量失败: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	embedding := &model.TextEmbedding{
+		ModelName: modelName,
+		TextHash:  hex.EncodeToString(sum[:]),
+		Text:      text,
+		Vector:    string(data),
+	}
+	err = s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "model_name"}, {Name: "text_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vector", "updated_at"}),
+	}).Create(embedding).Error
+	if err != nil {
+		return fmt.Errorf("保存文本向量失败: %w", err)
+	}
+	return nil
+}
+
+// Search 返回与查询向量余弦相似度不低于 minSimilarity 的前 limit 条文本
+func (s *gormVectorStore) Search(ctx context.Context, modelName string, query []float64, limit int, minSimilarity float64) ([]model.SimilarText, error) {
+	var embeddings []*model.TextEmbedding
+	if err := s.db.WithContext(ctx).Where("model_name = ?", modelName).Find(&embeddings).Error; err != nil {
+		return nil, fmt.Errorf("获取文本向量失败: %w", err)
+	}
+
+	results := make([]model.SimilarText, 0, len(embeddings))
+	for _, embedding := range embeddings {
+		var vector []float64
+		if err := json.Unmarshal([]byte(embedding.Vector), &vector); err != nil {
+			return nil, fmt.Errorf("反序列化向量失败: %w", err)
+		}
+
+		similarity := backend.CosineSimilarity(query, vector)
+		if similarity < minSimilarity {
+			continue
+		}
+		results = append(results, model.SimilarText{Text: embedding.Text, Similarity: similarity})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// DeleteOlderThan 分批删除 before 之前写入的向量，返回删除的行数；
+// 新增 updated_at 列之前保存的向量没有写入时间，按创建时间判断
+func (s *gormVectorStore) DeleteOlderThan(ctx context.Context, before time.Time, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		if err := s.db.WithContext(ctx).Model(&model.TextEmbedding{}).
+			Where("updated_at < ? OR (updated_at IS NULL AND created_at < ?)", before, before).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return total, fmt.Errorf("查询过期文本向量失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := s.db.WithContext(ctx).Delete(&model.TextEmbedding{}, ids)
+		if result.Error != nil {
+			return total, fmt.Errorf("删除过期文本向量失败: %w", result.Error)
+		}
+		total += result.RowsAffected
+
+		if len(ids) < batchSize {
+			return total, nil
+		}
+	}
+}
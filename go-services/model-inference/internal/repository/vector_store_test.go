@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestVectorStoreSearch(t *testing.T) {
+	ctx := context.Background()
+	store := NewVectorStore(newTestDB(t))
+	embedder := backend.NewLocalBackend(backend.DefaultEmbeddingDimension)
+
+	corpus := map[string]string{
+		"这家店的外卖配送太慢了":    "delivery",
+		"外卖配送慢，等了一个小时":   "delivery",
+		"配送员态度很好，外卖送得很快": "delivery",
+		"点击链接领取免费优惠券":    "spam",
+		"免费领取优惠券，点击链接":   "spam",
+		"加微信领取免费红包":      "spam",
+	}
+	for text := range corpus {
+		vectors, err := embedder.Embed(ctx, "m", []string{text})
+		require.NoError(t, err)
+		require.NoError(t, store.Save(ctx, "m", text, vectors[0]))
+	}
+	// 重复保存同一文本不会产生重复记录
+	vectors, err := embedder.Embed(ctx, "m", []string{"加微信领取免费红包"})
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, "m", "加微信领取免费红包", vectors[0]))
+	// 其他模型的向量不参与检索
+	require.NoError(t, store.Save(ctx, "other", "点击链接领取免费优惠券", vectors[0]))
+
+	search := func(query string, limit int, minSimilarity float64) []model.SimilarText {
+		vectors, err := embedder.Embed(ctx, "m", []string{query})
+		require.NoError(t, err)
+		results, err := store.Search(ctx, "m", vectors[0], limit, minSimilarity)
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("top-k matches share the query label", func(t *testing.T) {
+		results := search("外卖配送太慢", 3, 0)
+		require.Len(t, results, 3)
+		for i, r := range results {
+			assert.Equal(t, "delivery", corpus[r.Text], r.Text)
+			if i > 0 {
+				assert.GreaterOrEqual(t, results[i-1].Similarity, r.Similarity)
+			}
+		}
+
+		results = search("点击链接免费领取优惠券", 2, 0)
+		require.Len(t, results, 2)
+		for _, r := range results {
+			assert.Equal(t, "spam", corpus[r.Text], r.Text)
+		}
+	})
+
+	t.Run("no limit returns each stored text once", func(t *testing.T) {
+		assert.Len(t, search("外卖", 0, -1), len(corpus))
+	})
+
+	t.Run("min similarity filters weak matches", func(t *testing.T) {
+		results := search("点击链接领取免费优惠券", 10, 0.99)
+		require.Len(t, results, 1)
+		assert.Equal(t, "点击链接领取免费优惠券", results[0].Text)
+		assert.InDelta(t, 1.0, results[0].Similarity, 1e-9)
+	})
+}
+
+func TestVectorStoreDeleteOlderThan(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	store := NewVectorStore(db)
+
+	for _, text := range []string{"旧文本", "再次索引的文本", "新文本"} {
+		require.NoError(t, store.Save(ctx, "m", text, []float64{1, 0}))
+	}
+	old := time.Now().AddDate(0, 0, -10)
+	require.NoError(t, db.Model(&model.TextEmbedding{}).Where("text <> ?", "新文本").
+		Updates(map[string]interface{}{"created_at": old, "updated_at": old}).Error)
+	// 没有写入时间的旧向量按创建时间判断
+	require.NoError(t, db.Model(&model.TextEmbedding{}).Where("text = ?", "旧文本").UpdateColumn("updated_at", nil).Error)
+	// 重新索引刷新写入时间，不会被清理
+	require.NoError(t, store.Save(ctx, "m", "再次索引的文本", []float64{0, 1}))
+
+	purged, err := store.DeleteOlderThan(ctx, time.Now().AddDate(0, 0, -7), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	var texts []string
+	require.NoError(t, db.Model(&model.TextEmbedding{}).Order("text").Pluck("text", &texts).Error)
+	assert.ElementsMatch(t, []string{"再次索引的文本", "新文本"}, texts)
+}
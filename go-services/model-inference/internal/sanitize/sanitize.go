@@ -0,0 +1,48 @@
+// Package sanitize 清除推理输入中的控制字符，避免 NUL 等字符破坏数据库存储与分词。
+// data-collector 入库前使用相同规则（collector.SanitizeText），修改时需同步
+package sanitize
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text 删除除 \t、\n、\r 以外的控制字符（C0、DEL 与 C1），非法 UTF-8 字节替换为 U+FFFD。
+// 中文、emoji 及其组合用的零宽连接符等格式字符原样保留；无需清理时直接返回原字符串
+func Text(text string) string {
+	if isClean(text) {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case isStripped(r):
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isStripped 判断字符是否需要删除
+func isStripped(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
+
+// isClean 判断文本是否为合法 UTF-8 且不含需要删除的控制字符
+func isClean(text string) bool {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if (r == utf8.RuneError && size == 1) || isStripped(r) {
+			return false
+		}
+		i += size
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"clean", "正常的评论 👍", "正常的评论 👍"},
+		{"nul and bell", "加\x00微信\x07领券", "加微信领券"},
+		{"keeps whitespace", "第一行\n第二行\t\r\n", "第一行\n第二行\t\r\n"},
+		{"del and c1", "a\x7fb\u0085c\u009fd", "abcd"},
+		{"escape sequence", "\x1b[31m红色\x1b[0m", "[31m红色[0m"},
+		{"invalid utf8", "坏\xff字节", "坏\ufffd字节"},
+		{"emoji with zwj", "家庭👨\u200d👩\u200d👧\x00", "家庭👨\u200d👩\u200d👧"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Text(tt.text))
+		})
+	}
+}
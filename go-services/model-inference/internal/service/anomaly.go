@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultAnomalyThreshold 模型未配置阈值时判定异常的偏离程度（标准差倍数）
+	defaultAnomalyThreshold = 3.0
+	// anomalyStdFloorRatio 各维标准差的下限为平均标准差的该比例，
+	// 避免参考样本中几乎不变的维度因细微差异得到极大的 z-score
+	anomalyStdFloorRatio = 0.1
+	// minAnomalyReferences 拟合参考分布所需的最少样本数
+	minAnomalyReferences = 2
+)
+
+// anomalyConfig 异常检测配置，来自模型元数据，例如
+// {"anomaly": {"reference_texts": ["正常样本1", "正常样本2"], "threshold": 3}}
+// 参考分布由 reference_texts 的特征向量拟合；也可以直接给出离线统计的 mean 与 std，二者长度须与特征向量一致。
+// threshold 为判定异常的偏离程度，单位为标准差
+type anomalyConfig struct {
+	ReferenceTexts []string  `json:"reference_texts"`
+	Mean           []float64 `json:"mean"`
+	Std            []float64 `json:"std"`
+	Threshold      *float64  `json:"threshold"`
+}
+
+// anomalyBaseline 正常样本特征向量各维的均值与标准差
+type anomalyBaseline struct {
+	mean []float64
+	std  []float64
+}
+
+// loadAnomalyConfig 读取模型元数据中的异常检测配置
+func (s *inferenceService) loadAnomalyConfig(ctx context.Context, modelName string) (anomalyConfig, error) {
+	var metadata struct {
+		Anomaly anomalyConfig `json:"anomaly"`
+	}
+	modelInfo, err := s.modelService.GetModel(ctx, modelName)
+	if err != nil || modelInfo == nil || modelInfo.Metadata == "" {
+		return anomalyConfig{}, fmt.Errorf("模型 %s 未配置异常检测的参考分布", modelName)
+	}
+	if err := json.Unmarshal([]byte(modelInfo.Metadata), &metadata); err != nil {
+		logrus.Warnf("解析模型 %s 异常检测配置失败: %v", modelName, err)
+		return anomalyConfig{}, fmt.Errorf("模型 %s 异常检测配置无效: %w", modelName, err)
+	}
+	cfg := metadata.Anomaly
+	if len(cfg.Mean) == 0 && len(cfg.ReferenceTexts) < minAnomalyReferences {
+		return anomalyConfig{}, fmt.Errorf("模型 %s 未配置异常检测的参考分布，至少需要 %d 条 reference_texts", modelName, minAnomalyReferences)
+	}
+	return cfg, nil
+}
+
+// loadAnomalyBaseline 返回参考分布，未给出 mean、std 时由参考样本拟合；参考样本的向量经 embed 缓存，重复请求不会重新计算
+func (s *inferenceService) loadAnomalyBaseline(ctx context.Context, modelName string, cfg anomalyConfig) (anomalyBaseline, error) {
+	if len(cfg.Mean) > 0 {
+		if len(cfg.Std) != len(cfg.Mean) {
+			return anomalyBaseline{}, fmt.Errorf("异常检测配置的 mean 与 std 长度不一致: %d != %d", len(cfg.Mean), len(cfg.Std))
+		}
+		return newAnomalyBaseline(cfg.Mean, cfg.Std), nil
+	}
+
+	embeddings, _, err := s.embed(ctx, modelName, cfg.ReferenceTexts)
+	if err != nil {
+		return anomalyBaseline{}, err
+	}
+	vectors := make([][]float64, len(cfg.ReferenceTexts))
+	for i, text := range cfg.ReferenceTexts {
+		if vectors[i], err = anomalyFeatureVector(textFeatures(text, embeddings[i])); err != nil {
+			return anomalyBaseline{}, err
+		}
+	}
+	return fitAnomalyBaseline(vectors)
+}
+
+// performAnomalyDetection 提取文本特征，按各维 z-score 衡量与参考分布的偏离程度
+func (s *inferenceService) performAnomalyDetection(ctx context.Context, modelName string, text string) (interface{}, float64, error) {
+	cfg, err := s.loadAnomalyConfig(ctx, modelName)
+	if err != nil {
+		return nil, 0, err
+	}
+	threshold := defaultAnomalyThreshold
+	if cfg.Threshold != nil {
+		threshold = *cfg.Threshold
+	}
+	baseline, err := s.loadAnomalyBaseline(ctx, modelName, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	features, err := s.performFeatureExtraction(ctx, modelName, text)
+	if err != nil {
+		return nil, 0, err
+	}
+	vector, err := anomalyFeatureVector(features)
+	if err != nil {
+		return nil, 0, err
+	}
+	deviation, score, err := baseline.score(vector)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	isAnomaly := deviation > threshold
+	confidence := 1 - score
+	if isAnomaly {
+		confidence = score
+	}
+	result := map[string]interface{}{
+		"is_anomaly":    isAnomaly,
+		"anomaly_score": score,
+		"deviation":     deviation,
+		"threshold":     threshold,
+		"confidence":    confidence,
+	}
+	return result, confidence, nil
+}
+
+// anomalyFeatureVector 由特征提取结果组成特征向量：首维为字符数的对数，其余为文本向量
+func anomalyFeatureVector(features map[string]interface{}) ([]float64, error) {
+	chars, ok := features["char_count"].(int)
+	if !ok {
+		return nil, fmt.Errorf("特征提取结果缺少 char_count")
+	}
+	embedding, ok := features["embeddings"].([]float64)
+	if !ok {
+		return nil, fmt.Errorf("特征提取结果缺少 embeddings")
+	}
+	vector := make([]float64, 0, len(embedding)+1)
+	vector = append(vector, math.Log1p(float64(chars)))
+	return append(vector, embedding...), nil
+}
+
+// fitAnomalyBaseline 计算参考样本特征向量各维的均值与标准差
+func fitAnomalyBaseline(vectors [][]float64) (anomalyBaseline, error) {
+	if len(vectors) < minAnomalyReferences {
+		return anomalyBaseline{}, fmt.Errorf("拟合参考分布至少需要 %d 条样本，实际 %d 条", minAnomalyReferences, len(vectors))
+	}
+	dimension := len(vectors[0])
+	mean := make([]float64, dimension)
+	for _, vector := range vectors {
+		if len(vector) != dimension {
+			return anomalyBaseline{}, fmt.Errorf("参考样本特征维度不一致: %d != %d", len(vector), dimension)
+		}
+		for i, v := range vector {
+			mean[i] += v
+		}
+	}
+	n := float64(len(vectors))
+	for i := range mean {
+		mean[i] /= n
+	}
+
+	std := make([]float64, dimension)
+	for _, vector := range vectors {
+		for i, v := range vector {
+			std[i] += (v - mean[i]) * (v - mean[i])
+		}
+	}
+	for i := range std {
+		std[i] = math.Sqrt(std[i] / (n - 1))
+	}
+	return newAnomalyBaseline(mean, std), nil
+}
+
+// newAnomalyBaseline 按 anomalyStdFloorRatio 为标准差设置下限
+func newAnomalyBaseline(mean, std []float64) anomalyBaseline {
+	var total float64
+	for _, v := range std {
+		total += v
+	}
+	floor := anomalyStdFloorRatio * total / float64(len(std))
+	if floor <= 0 {
+		floor = math.SmallestNonzeroFloat64
+	}
+	floored := make([]float64, len(std))
+	for i, v := range std {
+		floored[i] = math.Max(v, floor)
+	}
+	return anomalyBaseline{mean: mean, std: floored}
+}
+
+// score 计算向量相对参考分布的偏离程度与校准后的异常分数。
+// 正常样本各维 z-score 的平方和近似服从自由度为维数 d 的卡方分布，
+// 经 Wilson-Hilferty 变换转换为标准正态分布下的偏离程度（单位为标准差），
+// 异常分数为其正态分布函数值，即正常样本偏离程度小于该向量的概率
+func (b anomalyBaseline) score(vector []float64) (deviation, score float64, err error) {
+	if len(vector) != len(b.mean) {
+		return 0, 0, fmt.Errorf("特征维度 %d 与参考分布维度 %d 不一致", len(vector), len(b.mean))
+	}
+	var sumSquares float64
+	for i, v := range vector {
+		z := (v - b.mean[i]) / b.std[i]
+		sumSquares += z * z
+	}
+	d := float64(len(vector))
+	variance := 2 / (9 * d)
+	deviation = (math.Cbrt(sumSquares/d) - (1 - variance)) / math.Sqrt(variance)
+	score = 0.5 * (1 + math.Erf(deviation/math.Sqrt2))
+	return deviation, score, nil
+}
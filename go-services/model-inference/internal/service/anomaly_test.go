@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// anomalyReferenceTexts 正常的商品评论，作为异常检测的参考分布
+var anomalyReferenceTexts = []string{
+	"这款耳机音质不错，佩戴也很舒服",
+	"物流很快，包装完好，东西和描述一致",
+	"用了一周了，电池续航比预期的要好",
+	"客服态度很好，耐心解答了我的问题",
+	"性价比挺高的，推荐给身边的朋友了",
+	"颜色和图片一样，尺码也合适",
+	"做工精细，没有异味，孩子很喜欢",
+	"第二次购买了，质量一直很稳定",
+	"屏幕清晰，运行流畅，打游戏不卡",
+	"味道不错，就是分量稍微有点少",
+	"安装简单，说明书写得很清楚",
+	"收到后试了一下，效果比想象的好",
+}
+
+func newTestAnomalyService(t *testing.T) *inferenceService {
+	metadata, err := json.Marshal(map[string]interface{}{
+		"anomaly": map[string]interface{}{"reference_texts": anomalyReferenceTexts, "threshold": 3},
+	})
+	require.NoError(t, err)
+	svc, _ := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"review-anomaly": {Name: "review-anomaly", Metadata: string(metadata)},
+		"unconfigured":   {Name: "unconfigured"},
+	}, backend.NewLocalBackend(16))
+	return svc
+}
+
+func detectAnomaly(t *testing.T, svc *inferenceService, text string) map[string]interface{} {
+	resp, err := svc.DetectAnomaly(context.Background(), &model.AnomalyDetectionRequest{
+		ModelName: "review-anomaly",
+		Data:      map[string]interface{}{"text": text},
+	})
+	require.NoError(t, err)
+	result := resp.Result.(map[string]interface{})
+	assert.Equal(t, resp.Confidence, result["confidence"])
+	return result
+}
+
+func TestDetectAnomalySeparatesNormalAndAnomalousTexts(t *testing.T) {
+	svc := newTestAnomalyService(t)
+
+	normal := []string{
+		"东西收到了，质量很好，下次还会再来",
+		"发货速度快，包装很用心，满意",
+	}
+	anomalous := []string{
+		strings.Repeat("BUY NOW!!! http://cheap-pills.example.com ", 20),
+		strings.Repeat("$$$", 200),
+	}
+
+	// 参考样本与待检测文本都不写入向量库
+	defer func() { assert.Empty(t, svc.vectorStore.(*stubVectorStore).saved) }()
+
+	var normalScores, anomalousScores []float64
+	for _, text := range normal {
+		result := detectAnomaly(t, svc, text)
+		assert.Equal(t, false, result["is_anomaly"], text)
+		normalScores = append(normalScores, result["anomaly_score"].(float64))
+	}
+	for _, text := range anomalous {
+		result := detectAnomaly(t, svc, text)
+		assert.Equal(t, true, result["is_anomaly"], text)
+		assert.Greater(t, result["deviation"].(float64), 3.0)
+		anomalousScores = append(anomalousScores, result["anomaly_score"].(float64))
+	}
+
+	// 异常样本的分数高于所有正常样本
+	for _, a := range anomalousScores {
+		for _, n := range normalScores {
+			assert.Greater(t, a, n)
+		}
+		assert.Greater(t, a, 0.99)
+	}
+}
+
+func TestDetectAnomalyRequiresConfigAndText(t *testing.T) {
+	svc := newTestAnomalyService(t)
+	ctx := context.Background()
+
+	_, err := svc.DetectAnomaly(ctx, &model.AnomalyDetectionRequest{ModelName: "review-anomaly", Data: map[string]interface{}{"value": 1}})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	_, err = svc.DetectAnomaly(ctx, &model.AnomalyDetectionRequest{ModelName: "unconfigured", Data: map[string]interface{}{"text": "你好"}})
+	assert.ErrorContains(t, err, "未配置异常检测的参考分布")
+}
+
+func TestAnomalyBaselineScoreIsCalibrated(t *testing.T) {
+	baseline := newAnomalyBaseline(make([]float64, 50), onesVector(50))
+
+	// 与均值重合时偏离程度远低于正常水平
+	deviation, score, err := baseline.score(make([]float64, 50))
+	require.NoError(t, err)
+	assert.Less(t, deviation, -3.0)
+	assert.Less(t, score, 0.01)
+
+	// 各维恰好偏离一个标准差是正常样本的典型情况，分数约为 0.5
+	deviation, score, err = baseline.score(onesVector(50))
+	require.NoError(t, err)
+	assert.InDelta(t, 0, deviation, 0.3)
+	assert.InDelta(t, 0.5, score, 0.1)
+
+	// 单个维度偏离 10 个标准差
+	far := make([]float64, 50)
+	far[0] = 10
+	deviation, score, err = baseline.score(far)
+	require.NoError(t, err)
+	assert.Greater(t, deviation, 3.0)
+	assert.Greater(t, score, 0.99)
+
+	_, _, err = baseline.score(make([]float64, 3))
+	assert.Error(t, err)
+}
+
+func TestFitAnomalyBaseline(t *testing.T) {
+	baseline, err := fitAnomalyBaseline([][]float64{{1, 5}, {3, 5}})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{2, 5}, baseline.mean)
+	assert.InDelta(t, math.Sqrt2, baseline.std[0], 1e-9)
+	// 不变的维度使用标准差下限
+	assert.InDelta(t, anomalyStdFloorRatio*math.Sqrt2/2, baseline.std[1], 1e-9)
+
+	_, err = fitAnomalyBaseline([][]float64{{1}})
+	assert.Error(t, err)
+	_, err = fitAnomalyBaseline([][]float64{{1}, {1, 2}})
+	assert.Error(t, err)
+}
+
+func onesVector(n int) []float64 {
+	vector := make([]float64, n)
+	for i := range vector {
+		vector[i] = 1
+	}
+	return vector
+}
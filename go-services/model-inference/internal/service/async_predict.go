@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// asyncCallbackTimeout 异步预测结果回调的超时时间
+const asyncCallbackTimeout = 10 * time.Second
+
+// errCallbackAddressBlocked 回调地址指向本机或内网
+var errCallbackAddressBlocked = errors.New("回调地址不能指向本机或内网地址")
+
+// PredictAsync 提交异步预测：模型已加载时创建 pending 状态的推理记录并立即返回请求ID，
+// 推理在后台执行并写入推理记录，可按请求ID轮询结果，携带 callback_url 时完成后回调。
+// 同时执行的异步任务数受 inference.max_concurrency 限制
+func (s *inferenceService) PredictAsync(ctx context.Context, req *model.PredictRequest) (*model.AsyncPredictResponse, error) {
+	if req.CallbackURL != "" {
+		if err := s.validateCallbackURL(ctx, req.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := s.cfg().MaxConcurrency
+	if running := s.asyncRunning.Add(1); limit > 0 && running > int64(limit) {
+		s.asyncRunning.Add(-1)
+		return nil, apperrors.New(apperrors.ErrUnavailable, "异步推理任务数已达上限 %d", limit)
+	}
+
+	// 提交时占用模型，后台推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		s.asyncRunning.Add(-1)
+		return nil, err
+	}
+	if err := s.validateInputs(ctx, req.ModelName, []map[string]interface{}{req.Data}, false); err != nil {
+		release()
+		s.asyncRunning.Add(-1)
+		return nil, err
+	}
+	data, _, err := s.applyTokenLimit(ctx, req.ModelName, sanitizeData(req.Data))
+	if err != nil {
+		release()
+		s.asyncRunning.Add(-1)
+		return nil, err
+	}
+	asyncReq := *req
+	asyncReq.Data = data
+
+	requestID := uuid.New().String()
+	inputData, _ := json.Marshal(data)
+	inferenceReq := &model.InferenceRequest{
+		RequestID: requestID,
+		ModelName: req.ModelName,
+		InputData: string(inputData),
+		Status:    model.InferenceStatusPending,
+		StartTime: time.Now(),
+	}
+	if err := s.inferenceRepo.Create(inferenceReq); err != nil {
+		release()
+		s.asyncRunning.Add(-1)
+		return nil, fmt.Errorf("创建推理请求记录失败: %w", err)
+	}
+
+	s.asyncJobs.Add(1)
+	go func() {
+		defer s.asyncJobs.Done()
+		defer s.asyncRunning.Add(-1)
+		defer release()
+		s.runAsyncPredict(requestID, &asyncReq)
+	}()
+
+	return &model.AsyncPredictResponse{
+		RequestID: requestID,
+		ModelName: req.ModelName,
+		Status:    model.InferenceStatusPending,
+	}, nil
+}
+
+// runAsyncPredict 执行后台推理，依次将推理记录更新为 running 与 completed/failed
+func (s *inferenceService) runAsyncPredict(requestID string, req *model.PredictRequest) {
+	ctx := context.Background()
+	if timeout := s.cfg().TimeoutSeconds; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	if err := s.inferenceRepo.UpdateStatus(requestID, model.InferenceStatusRunning); err != nil {
+		logrus.Warnf("更新推理请求状态失败: %v", err)
+	}
+
+	startTime := time.Now()
+	prediction, confidence, probability, err := s.performInference(ctx, req.ModelName, req.Data)
+	duration := time.Since(startTime).Milliseconds()
+
+	callback := model.AsyncPredictCallback{
+		RequestID: requestID,
+		ModelName: req.ModelName,
+		Duration:  duration,
+	}
+	if err != nil {
+		logrus.Warnf("异步推理 %s 失败: %v", requestID, err)
+		s.inferenceRepo.UpdateError(requestID, err.Error(), time.Now(), duration)
+		callback.Status = model.InferenceStatusFailed
+		callback.Error = err.Error()
+	} else {
+		resultData, _ := json.Marshal(map[string]interface{}{
+			"prediction":  prediction,
+			"confidence":  confidence,
+			"probability": probability,
+		})
+		s.inferenceRepo.UpdateResult(requestID, string(resultData), time.Now(), duration)
+		callback.Status = model.InferenceStatusCompleted
+		callback.Prediction = prediction
+		callback.Confidence = confidence
+	}
+
+	if req.CallbackURL != "" {
+		if err := s.sendCallback(req.CallbackURL, callback); err != nil {
+			logrus.Warnf("异步推理 %s 回调失败: %v", requestID, err)
+		}
+	}
+}
+
+// sendCallback 将异步预测结果 POST 到回调地址，非 2xx 响应（包括重定向）视为失败，不重试
+func (s *inferenceService) sendCallback(callbackURL string, callback model.AsyncPredictCallback) error {
+	body, err := json.Marshal(callback)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), asyncCallbackTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.callbackClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("回调返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// validateCallbackURL 回调地址必须是带主机名的 http/https 地址，且不在允许列表中的主机不能解析到内网地址。
+// 提交时的检查用于尽早返回错误，回调连接时还会再次检查实际连接的 IP，防止 DNS 重绑定
+func (s *inferenceService) validateCallbackURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return apperrors.New(apperrors.ErrInvalidInput, "无效的回调地址 %q", raw)
+	}
+	if s.callbackHostAllowed(u.Hostname()) {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return apperrors.New(apperrors.ErrInvalidInput, "无法解析回调地址 %q: %v", raw, err)
+	}
+	for _, addr := range addrs {
+		if blockedCallbackIP(addr.IP) {
+			return apperrors.New(apperrors.ErrInvalidInput, "%v: %q", errCallbackAddressBlocked, raw)
+		}
+	}
+	return nil
+}
+
+// callbackHostAllowed 主机是否在 inference.callback_allowed_hosts 中，允许的主机不检查解析到的 IP
+func (s *inferenceService) callbackHostAllowed(host string) bool {
+	for _, allowed := range s.cfg().CallbackAllowedHosts {
+		if strings.EqualFold(strings.Trim(allowed, "[]"), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedCallbackIP 回环、私有、链路本地、组播与未指定地址不能作为回调目标
+func blockedCallbackIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// newCallbackClient 创建回调使用的 HTTP 客户端：allowed 之外的主机在建立连接时检查实际连接的 IP，
+// 不使用环境变量中的代理，也不跟随重定向
+func newCallbackClient(allowed func(host string) bool) *http.Client {
+	guarded := &net.Dialer{
+		Timeout: asyncCallbackTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedCallbackIP(ip) {
+				return fmt.Errorf("%w: %s", errCallbackAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+	trusted := &net.Dialer{Timeout: asyncCallbackTimeout}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				if host, _, err := net.SplitHostPort(address); err == nil && allowed(host) {
+					return trusted.DialContext(ctx, network, address)
+				}
+				return guarded.DialContext(ctx, network, address)
+			},
+			TLSHandshakeTimeout: asyncCallbackTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+// newAsyncTestService 使用 sqlite 推理仓库，便于轮询后台写入的推理记录
+func newAsyncTestService(t *testing.T) *inferenceService {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.InferenceRequest{}))
+
+	cacheRepo, _ := newTestCacheRepo(t)
+	svc := NewInferenceService(repository.NewInferenceRepository(db), &stubModelService{models: map[string]*model.Model{
+		"classifier": {Name: "classifier"},
+	}}, cacheRepo, backend.NewLocalBackend(16), &stubVectorStore{saved: map[string][]float64{}}, config.InferenceConfig{
+		MaxBatchSize:   10,
+		BatchWaitMs:    1,
+		TimeoutSeconds: 5,
+		MaxConcurrency: 10,
+	})
+	t.Cleanup(func() { svc.Drain(context.Background()) })
+	return svc.(*inferenceService)
+}
+
+// allowLocalCallbacks 允许回调到 httptest 服务器监听的本机地址
+func allowLocalCallbacks(svc *inferenceService) {
+	cfg := svc.cfg()
+	cfg.CallbackAllowedHosts = []string{"127.0.0.1"}
+	svc.UpdateConfig(cfg)
+}
+
+func TestPredictAsyncPolling(t *testing.T) {
+	ctx := context.Background()
+	svc := newAsyncTestService(t)
+	allowLocalCallbacks(svc)
+
+	callbacks := make(chan model.AsyncPredictCallback, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var callback model.AsyncPredictCallback
+		if err := json.NewDecoder(r.Body).Decode(&callback); err == nil {
+			callbacks <- callback
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	accepted, err := svc.PredictAsync(ctx, &model.PredictRequest{
+		ModelName:   "classifier",
+		Data:        map[string]interface{}{"text": "hello"},
+		CallbackURL: server.URL,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.InferenceStatusPending, accepted.Status)
+	require.NotEmpty(t, accepted.RequestID)
+
+	var record *model.InferenceRequest
+	require.Eventually(t, func() bool {
+		record, err = svc.GetInferenceResult(ctx, accepted.RequestID)
+		require.NoError(t, err)
+		return record.Status == model.InferenceStatusCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var result struct {
+		Prediction map[string]interface{} `json:"prediction"`
+		Confidence float64                `json:"confidence"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(record.Result), &result))
+	assert.Equal(t, "positive", result.Prediction["class"])
+	assert.Equal(t, 0.85, result.Confidence)
+	assert.NotNil(t, record.EndTime)
+
+	select {
+	case callback := <-callbacks:
+		assert.Equal(t, accepted.RequestID, callback.RequestID)
+		assert.Equal(t, model.InferenceStatusCompleted, callback.Status)
+		assert.Equal(t, 0.85, callback.Confidence)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not received")
+	}
+}
+
+func TestPredictAsyncRejectsInvalidRequests(t *testing.T) {
+	ctx := context.Background()
+	svc := newAsyncTestService(t)
+
+	_, err := svc.PredictAsync(ctx, &model.PredictRequest{
+		ModelName:   "classifier",
+		Data:        map[string]interface{}{"text": "hello"},
+		CallbackURL: "ftp://example.com/hook",
+	})
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidInput))
+
+	// 回调地址不能指向本机、内网或云厂商元数据地址
+	for _, callbackURL := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.8/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://0.0.0.0/hook",
+	} {
+		_, err = svc.PredictAsync(ctx, &model.PredictRequest{
+			ModelName:   "classifier",
+			Data:        map[string]interface{}{"text": "hello"},
+			CallbackURL: callbackURL,
+		})
+		assert.True(t, errors.Is(err, apperrors.ErrInvalidInput), callbackURL)
+	}
+
+	// 模型未加载时直接失败，不创建推理记录
+	_, err = svc.PredictAsync(ctx, &model.PredictRequest{ModelName: "missing", Data: map[string]interface{}{"text": "hello"}})
+	require.Error(t, err)
+	history, err := svc.GetHistory(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	assert.Zero(t, svc.asyncRunning.Load())
+}
+
+func TestSendCallbackBlocksInternalAddressesAndRedirects(t *testing.T) {
+	svc := newAsyncTestService(t)
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/hook", http.StatusFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// 跳过提交时的检查，连接时仍会拒绝本机地址
+	err := svc.sendCallback(server.URL+"/hook", model.AsyncPredictCallback{RequestID: "r1"})
+	assert.ErrorIs(t, err, errCallbackAddressBlocked)
+	assert.Zero(t, atomic.LoadInt32(&hits))
+
+	// 允许的主机可以回调，但不跟随重定向
+	allowLocalCallbacks(svc)
+	require.NoError(t, svc.sendCallback(server.URL+"/hook", model.AsyncPredictCallback{RequestID: "r1"}))
+	err = svc.sendCallback(server.URL+"/redirect", model.AsyncPredictCallback{RequestID: "r1"})
+	assert.ErrorContains(t, err, "302")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
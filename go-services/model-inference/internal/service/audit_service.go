@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+// AuditService 管理操作审计服务接口
+type AuditService interface {
+	Record(ctx context.Context, operation *model.AdminOperation) error
+	ListOperations(ctx context.Context, limit, offset int) ([]*model.AdminOperation, int64, error)
+}
+
+// auditService 管理操作审计服务实现
+type auditService struct {
+	operationRepo repository.OperationRepository
+}
+
+// NewAuditService 创建管理操作审计服务
+func NewAuditService(operationRepo repository.OperationRepository) AuditService {
+	return &auditService{operationRepo: operationRepo}
+}
+
+// Record 写入一条审计记录，未设置时间时使用当前时间
+func (s *auditService) Record(ctx context.Context, operation *model.AdminOperation) error {
+	if operation.CreatedAt.IsZero() {
+		operation.CreatedAt = time.Now()
+	}
+	return s.operationRepo.Create(operation)
+}
+
+// ListOperations 分页获取审计记录
+func (s *auditService) ListOperations(ctx context.Context, limit, offset int) ([]*model.AdminOperation, int64, error) {
+	return s.operationRepo.List(limit, offset)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+// ModelBackendHealth 已加载模型推理后端的最近探测结果
+type ModelBackendHealth struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastCheck           time.Time `json:"last_check"`
+}
+
+// BackendWatchdog 定期探测已加载模型的推理后端。连续失败达到 HealthCheckFailures 次时
+// 将模型状态标记为 error 并输出告警日志，探测恢复后改回 loaded
+type BackendWatchdog struct {
+	modelService ModelService
+	modelRepo    repository.ModelRepository
+	backend      backend.InferenceBackend
+	config       config.ModelConfig
+	configMu     sync.RWMutex
+
+	mu     sync.Mutex
+	states map[string]*ModelBackendHealth
+}
+
+// NewBackendWatchdog 创建模型后端探测任务
+func NewBackendWatchdog(modelService ModelService, modelRepo repository.ModelRepository, inferenceBackend backend.InferenceBackend, cfg config.ModelConfig) *BackendWatchdog {
+	return &BackendWatchdog{
+		modelService: modelService,
+		modelRepo:    modelRepo,
+		backend:      inferenceBackend,
+		config:       cfg,
+		states:       make(map[string]*ModelBackendHealth),
+	}
+}
+
+// UpdateConfig 热更新探测配置，下一轮探测生效
+func (w *BackendWatchdog) UpdateConfig(cfg config.ModelConfig) {
+	w.configMu.Lock()
+	w.config = cfg
+	w.configMu.Unlock()
+}
+
+func (w *BackendWatchdog) cfg() config.ModelConfig {
+	w.configMu.RLock()
+	defer w.configMu.RUnlock()
+	return w.config
+}
+
+// Run 每隔 HealthCheckInterval 秒探测一次，直到 ctx 取消
+func (w *BackendWatchdog) Run(ctx context.Context) {
+	for {
+		interval := time.Duration(w.cfg().HealthCheckInterval) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		w.Probe(ctx)
+	}
+}
+
+// Probe 探测所有已加载的模型，单个模型的探测超时为一个探测间隔
+func (w *BackendWatchdog) Probe(ctx context.Context) {
+	cfg := w.cfg()
+	loaded := w.modelService.GetLoadedModels()
+	results := make(map[string]error, len(loaded))
+	for _, name := range loaded {
+		probeCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.HealthCheckInterval)*time.Second)
+		results[name] = w.backend.Ping(probeCtx, name)
+		cancel()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 已卸载的模型不再跟踪
+	for name := range w.states {
+		if _, ok := results[name]; !ok {
+			delete(w.states, name)
+			metrics.ModelBackendHealthy.DeleteLabelValues(name)
+		}
+	}
+
+	now := time.Now()
+	for name, err := range results {
+		state, ok := w.states[name]
+		if !ok {
+			state = &ModelBackendHealth{Name: name, Healthy: true}
+			w.states[name] = state
+		}
+		state.LastCheck = now
+
+		if err == nil {
+			if !state.Healthy {
+				logrus.Infof("模型 %s 的推理后端已恢复", name)
+				if err := w.modelRepo.UpdateStatus(name, model.ModelStatusLoaded); err != nil {
+					logrus.Errorf("更新模型 %s 状态失败: %v", name, err)
+				}
+			}
+			state.Healthy = true
+			state.ConsecutiveFailures = 0
+			state.LastError = ""
+			metrics.ModelBackendHealthy.WithLabelValues(name).Set(1)
+			continue
+		}
+
+		state.ConsecutiveFailures++
+		state.LastError = err.Error()
+		if state.Healthy && state.ConsecutiveFailures >= cfg.HealthCheckFailures {
+			state.Healthy = false
+			logrus.WithFields(logrus.Fields{
+				"model_name": name,
+				"failures":   state.ConsecutiveFailures,
+				"alert":      "model_backend_unhealthy",
+			}).Errorf("模型 %s 的推理后端连续 %d 次探测失败: %v", name, state.ConsecutiveFailures, err)
+			if err := w.modelRepo.UpdateStatus(name, model.ModelStatusError); err != nil {
+				logrus.Errorf("更新模型 %s 状态失败: %v", name, err)
+			}
+			metrics.ModelBackendHealthy.WithLabelValues(name).Set(0)
+		}
+	}
+}
+
+// Snapshot 返回各已加载模型的最近探测结果，按模型名排序
+func (w *BackendWatchdog) Snapshot() []ModelBackendHealth {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	snapshot := make([]ModelBackendHealth, 0, len(w.states))
+	for _, state := range w.states {
+		snapshot = append(snapshot, *state)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Name < snapshot[j].Name })
+	return snapshot
+}
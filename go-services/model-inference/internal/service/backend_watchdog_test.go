@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// pingBackend Ping 返回测试设定的错误
+type pingBackend struct {
+	*backend.LocalBackend
+	mu  sync.Mutex
+	err error
+}
+
+func (b *pingBackend) Ping(ctx context.Context, modelName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+func (b *pingBackend) setErr(err error) {
+	b.mu.Lock()
+	b.err = err
+	b.mu.Unlock()
+}
+
+func TestBackendWatchdogFlagsModelAfterRepeatedFailures(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestLoadedModelService(t, "flaky", "steady")
+	repo := &stubModelRepository{}
+	flaky := &pingBackend{LocalBackend: backend.NewLocalBackend(16)}
+	watchdog := NewBackendWatchdog(svc, repo, flaky, config.ModelConfig{HealthCheckInterval: 1, HealthCheckFailures: 3})
+
+	watchdog.Probe(ctx)
+	for _, health := range watchdog.Snapshot() {
+		assert.True(t, health.Healthy, health.Name)
+	}
+
+	// 连续失败未达到阈值时不标记
+	flaky.setErr(errors.New("会话已失效"))
+	watchdog.Probe(ctx)
+	watchdog.Probe(ctx)
+	assert.Empty(t, repo.statuses)
+	assert.True(t, watchdog.Snapshot()[0].Healthy)
+
+	watchdog.Probe(ctx)
+	snapshot := watchdog.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, "flaky", snapshot[0].Name)
+	assert.False(t, snapshot[0].Healthy)
+	assert.Equal(t, 3, snapshot[0].ConsecutiveFailures)
+	assert.Equal(t, "会话已失效", snapshot[0].LastError)
+	assert.Equal(t, model.ModelStatusError, repo.statuses["flaky"])
+	assert.Zero(t, testutil.ToFloat64(metrics.ModelBackendHealthy.WithLabelValues("flaky")))
+
+	// 探测恢复后改回 loaded
+	flaky.setErr(nil)
+	watchdog.Probe(ctx)
+	assert.True(t, watchdog.Snapshot()[0].Healthy)
+	assert.Equal(t, model.ModelStatusLoaded, repo.statuses["flaky"])
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.ModelBackendHealthy.WithLabelValues("flaky")))
+
+	// 卸载的模型不再出现在探测结果中
+	svc.loadedModels.Delete("flaky")
+	watchdog.Probe(ctx)
+	snapshot = watchdog.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, "steady", snapshot[0].Name)
+}
+
+func TestHealthReportsModelBackends(t *testing.T) {
+	svc := newTestLoadedModelService(t, "broken")
+	broken := &pingBackend{LocalBackend: backend.NewLocalBackend(16), err: errors.New("进程已退出")}
+	watchdog := NewBackendWatchdog(svc, &stubModelRepository{}, broken, config.ModelConfig{HealthCheckInterval: 1, HealthCheckFailures: 1})
+	watchdog.Probe(context.Background())
+
+	health := &healthService{watchdog: watchdog}
+	status := health.checkModels()
+	assert.Equal(t, false, status["healthy"])
+	assert.Contains(t, status["message"], "1 个模型")
+	models := status["models"].([]ModelBackendHealth)
+	require.Len(t, models, 1)
+	assert.Equal(t, "broken", models[0].Name)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// concurrencyLimiter 限制同时执行的同步推理数，槽位占满时按到达顺序等待；
+// 上限可热更新，不大于 0 时不限制
+type concurrencyLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	waiters  []chan struct{}
+}
+
+// newConcurrencyLimiter 创建并发限制器
+func newConcurrencyLimiter(limit int) *concurrencyLimiter {
+	return &concurrencyLimiter{limit: limit}
+}
+
+// acquire 占用一个槽位，返回释放函数；ctx 结束前未等到槽位时返回 ctx 的错误
+func (l *concurrencyLimiter) acquire(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	if l.limit <= 0 || l.inFlight < l.limit {
+		l.inFlight++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return l.release, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, waiter := range l.waiters {
+			if waiter == ready {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return nil, ctx.Err()
+			}
+		}
+		// 取消的同时已分到槽位，交给下一个等待者
+		l.inFlight--
+		l.grant()
+		return nil, ctx.Err()
+	}
+}
+
+// release 归还槽位并唤醒等待者
+func (l *concurrencyLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.grant()
+}
+
+// resize 修改并发上限：调大时立即唤醒等待者，调小时已在执行的请求不受影响，新请求等待其完成
+func (l *concurrencyLimiter) resize(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.grant()
+}
+
+// grant 在上限内按顺序把槽位分给等待者，调用方需持有 mu
+func (l *concurrencyLimiter) grant() {
+	for len(l.waiters) > 0 && (l.limit <= 0 || l.inFlight < l.limit) {
+		l.inFlight++
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+	}
+}
+
+// acquireSlot 占用一个同步推理槽位，同时执行的同步推理数受 inference.max_concurrency 限制
+func (s *inferenceService) acquireSlot(ctx context.Context) (func(), error) {
+	return s.concurrency.acquire(ctx)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// gatedNERBackend 实体识别在 gate 关闭前阻塞，active 为正在执行的请求数
+type gatedNERBackend struct {
+	*backend.LocalBackend
+	gate   chan struct{}
+	active atomic.Int32
+}
+
+func (b *gatedNERBackend) ExtractEntities(ctx context.Context, modelName string, text string) ([]model.Entity, error) {
+	b.active.Add(1)
+	defer b.active.Add(-1)
+	<-b.gate
+	return nil, nil
+}
+
+func TestMaxConcurrencyReloadChangesInFlightLimit(t *testing.T) {
+	ctx := context.Background()
+	b := &gatedNERBackend{LocalBackend: backend.NewLocalBackend(16), gate: make(chan struct{})}
+	svc, _ := newTestInferenceServiceWithBackend(t, map[string]*model.Model{"m": {Name: "m"}}, b)
+	cfg := svc.cfg()
+	cfg.MaxConcurrency = 2
+	svc.UpdateConfig(cfg)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.RecognizeEntities(ctx, &model.NERRequest{ModelName: "m", Text: "张伟在北京"})
+			assert.NoError(t, err)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return b.active.Load() == 2 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 2, b.active.Load())
+
+	// 热更新调大上限后，等待中的请求立即开始执行
+	cfg.MaxConcurrency = 4
+	svc.UpdateConfig(cfg)
+	require.Eventually(t, func() bool { return b.active.Load() == 4 }, time.Second, 5*time.Millisecond)
+
+	close(b.gate)
+	wg.Wait()
+}
+
+func TestConcurrencyLimiterWaitFollowsContext(t *testing.T) {
+	l := newConcurrencyLimiter(1)
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 超时的等待者不占用槽位，释放后下一个请求可立即获得
+	release()
+	release, err = l.acquire(context.Background())
+	require.NoError(t, err)
+
+	// 上限不大于 0 时不限制
+	l.resize(0)
+	other, err := l.acquire(context.Background())
+	require.NoError(t, err)
+	other()
+	release()
+}
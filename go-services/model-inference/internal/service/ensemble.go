@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/sanitize"
+)
+
+// Ensemble 集成推理：所有模型均已加载时并发计算各模型的标签得分，再按策略聚合为最终标签
+func (s *inferenceService) Ensemble(ctx context.Context, req *model.EnsembleRequest) (*model.EnsembleResponse, error) {
+	startTime := time.Now()
+	req.Text = sanitize.Text(req.Text)
+
+	if err := validateEnsembleRequest(req); err != nil {
+		return nil, err
+	}
+
+	// 同步推理受 inference.max_concurrency 限制，一次集成推理占用一个槽位
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 占用全部模型，任一模型未加载时整体失败，并列出所有未加载的模型
+	var missing []string
+	releases := make([]func(), 0, len(req.ModelNames))
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	for _, name := range req.ModelNames {
+		release, err := s.acquireModel(name)
+		if err != nil {
+			if !errors.Is(err, apperrors.ErrModelNotLoaded) {
+				return nil, err
+			}
+			missing = append(missing, name)
+			continue
+		}
+		releases = append(releases, release)
+	}
+	if len(missing) > 0 {
+		return nil, apperrors.New(apperrors.ErrModelNotLoaded, "集成推理的模型未加载: %s", strings.Join(missing, ", "))
+	}
+
+	results, err := s.scoreEnsembleModels(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var label string
+	var confidence float64
+	var scores map[string]float64
+	switch req.Strategy {
+	case model.EnsembleStrategyMajority:
+		label, confidence, scores = majorityVote(results)
+	default:
+		label, confidence, scores = weightedAverage(results)
+	}
+
+	return &model.EnsembleResponse{
+		RequestID:  uuid.New().String(),
+		Strategy:   req.Strategy,
+		Label:      label,
+		Confidence: confidence,
+		Scores:     scores,
+		Models:     results,
+		Duration:   time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// validateEnsembleRequest 校验模型列表、策略与权重
+func validateEnsembleRequest(req *model.EnsembleRequest) error {
+	seen := make(map[string]bool, len(req.ModelNames))
+	for _, name := range req.ModelNames {
+		if name == "" {
+			return apperrors.New(apperrors.ErrInvalidInput, "模型名称不能为空")
+		}
+		if seen[name] {
+			return apperrors.New(apperrors.ErrInvalidInput, "模型 %s 重复", name)
+		}
+		seen[name] = true
+	}
+
+	switch req.Strategy {
+	case model.EnsembleStrategyMajority, model.EnsembleStrategyAverage:
+	case model.EnsembleStrategyWeighted:
+		for _, name := range req.ModelNames {
+			if req.Weights[name] <= 0 {
+				return apperrors.New(apperrors.ErrInvalidInput, "weighted 策略需为模型 %s 指定正数权重", name)
+			}
+		}
+	default:
+		return apperrors.New(apperrors.ErrInvalidInput, "不支持的聚合策略 %q，可选 majority、average、weighted", req.Strategy)
+	}
+	return nil
+}
+
+// scoreEnsembleModels 并发计算各模型的标签得分，结果顺序与请求中的模型顺序一致
+func (s *inferenceService) scoreEnsembleModels(ctx context.Context, req *model.EnsembleRequest) ([]model.EnsembleModelResult, error) {
+	if timeout := s.cfg().TimeoutSeconds; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	results := make([]model.EnsembleModelResult, len(req.ModelNames))
+	errs := make([]error, len(req.ModelNames))
+	var wg sync.WaitGroup
+	for i, name := range req.ModelNames {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			labels := req.Labels
+			if len(labels) == 0 {
+				labels = s.loadClassificationConfig(ctx, name).Labels
+			}
+			if len(labels) == 0 {
+				errs[i] = apperrors.New(apperrors.ErrInvalidInput, "请求未指定 labels，且模型 %s 未配置分类标签", name)
+				return
+			}
+
+			scores, err := s.backend.ScoreLabels(ctx, name, req.Text, labels)
+			if err != nil {
+				errs[i] = fmt.Errorf("模型 %s 计算标签得分失败: %w", name, err)
+				return
+			}
+			label, confidence := topLabel(scores)
+			results[i] = model.EnsembleModelResult{
+				ModelName:  name,
+				Label:      label,
+				Confidence: confidence,
+				Scores:     scores,
+			}
+			if req.Strategy == model.EnsembleStrategyWeighted {
+				results[i].Weight = req.Weights[name]
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// topLabel 返回得分最高的标签，得分相同时取标签名较小者，保证结果确定
+func topLabel(scores map[string]float64) (string, float64) {
+	var best string
+	bestScore := -1.0
+	for label, score := range scores {
+		if score > bestScore || (score == bestScore && label < best) {
+			best, bestScore = label, score
+		}
+	}
+	if bestScore < 0 {
+		return "", 0
+	}
+	return best, bestScore
+}
+
+// majorityVote 各模型投票给自己的最高分标签，得票最多者胜出；
+// 票数相同时比较投票模型的置信度之和。置信度为胜出标签的得票比例
+func majorityVote(results []model.EnsembleModelResult) (string, float64, map[string]float64) {
+	votes := make(map[string]int)
+	confidenceSum := make(map[string]float64)
+	for _, r := range results {
+		votes[r.Label]++
+		confidenceSum[r.Label] += r.Confidence
+	}
+
+	labels := make([]string, 0, len(votes))
+	for label := range votes {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if votes[a] != votes[b] {
+			return votes[a] > votes[b]
+		}
+		if confidenceSum[a] != confidenceSum[b] {
+			return confidenceSum[a] > confidenceSum[b]
+		}
+		return a < b
+	})
+
+	shares := make(map[string]float64, len(votes))
+	for label, n := range votes {
+		shares[label] = float64(n) / float64(len(results))
+	}
+	return labels[0], shares[labels[0]], shares
+}
+
+// weightedAverage 按模型权重对各标签得分加权平均，未设置权重（average 策略）时等权。
+// 某模型未给出的标签按 0 分计
+func weightedAverage(results []model.EnsembleModelResult) (string, float64, map[string]float64) {
+	var totalWeight float64
+	sums := make(map[string]float64)
+	for _, r := range results {
+		weight := r.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		totalWeight += weight
+		for label, score := range r.Scores {
+			sums[label] += weight * score
+		}
+	}
+
+	averages := make(map[string]float64, len(sums))
+	for label, sum := range sums {
+		averages[label] = sum / totalWeight
+	}
+	label, confidence := topLabel(averages)
+	return label, confidence, averages
+}
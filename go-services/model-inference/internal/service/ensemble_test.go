@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// ensembleBackend 按模型返回固定的标签得分
+type ensembleBackend struct {
+	*backend.LocalBackend
+	scores map[string]map[string]float64
+}
+
+func (b *ensembleBackend) ScoreLabels(ctx context.Context, modelName string, text string, labels []string) (map[string]float64, error) {
+	return b.scores[modelName], nil
+}
+
+func newEnsembleTestService(t *testing.T) *inferenceService {
+	svc, _ := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"m1": {Name: "m1"},
+		"m2": {Name: "m2"},
+		"m3": {Name: "m3"},
+	}, &ensembleBackend{LocalBackend: backend.NewLocalBackend(16), scores: map[string]map[string]float64{
+		"m1": {"spam": 0.9, "ham": 0.1},
+		"m2": {"spam": 0.4, "ham": 0.6},
+		"m3": {"spam": 0.45, "ham": 0.55},
+	}})
+	return svc
+}
+
+func TestEnsembleStrategies(t *testing.T) {
+	svc := newEnsembleTestService(t)
+
+	tests := []struct {
+		name       string
+		strategy   model.EnsembleStrategy
+		weights    map[string]float64
+		label      string
+		confidence float64
+	}{
+		// m2、m3 投给 ham
+		{"majority", model.EnsembleStrategyMajority, nil, "ham", 2.0 / 3},
+		// spam 平均 (0.9+0.4+0.45)/3，高置信度的 m1 拉高了 spam
+		{"average", model.EnsembleStrategyAverage, map[string]float64{"m1": 5}, "spam", 1.75 / 3},
+		// ham 加权 (0.1+0.6*3+0.55*3)/7
+		{"weighted", model.EnsembleStrategyWeighted, map[string]float64{"m1": 1, "m2": 3, "m3": 3}, "ham", 3.55 / 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.Ensemble(context.Background(), &model.EnsembleRequest{
+				ModelNames: []string{"m1", "m2", "m3"},
+				Strategy:   tt.strategy,
+				Text:       "buy now",
+				Labels:     []string{"spam", "ham"},
+				Weights:    tt.weights,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.label, resp.Label)
+			assert.InDelta(t, tt.confidence, resp.Confidence, 1e-9)
+
+			require.Len(t, resp.Models, 3)
+			assert.Equal(t, "m1", resp.Models[0].ModelName)
+			assert.Equal(t, "spam", resp.Models[0].Label)
+			assert.Equal(t, 0.9, resp.Models[0].Confidence)
+			assert.Equal(t, "ham", resp.Models[1].Label)
+		})
+	}
+}
+
+func TestEnsembleRequiresLoadedModels(t *testing.T) {
+	svc := newEnsembleTestService(t)
+
+	_, err := svc.Ensemble(context.Background(), &model.EnsembleRequest{
+		ModelNames: []string{"m1", "missing-a", "missing-b"},
+		Strategy:   model.EnsembleStrategyMajority,
+		Text:       "buy now",
+		Labels:     []string{"spam", "ham"},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperrors.ErrModelNotLoaded))
+	assert.Contains(t, err.Error(), "missing-a, missing-b")
+}
+
+func TestEnsembleValidatesRequest(t *testing.T) {
+	svc := newEnsembleTestService(t)
+
+	tests := map[string]*model.EnsembleRequest{
+		"unknown strategy":    {ModelNames: []string{"m1"}, Strategy: "median", Labels: []string{"spam"}},
+		"duplicate model":     {ModelNames: []string{"m1", "m1"}, Strategy: model.EnsembleStrategyAverage, Labels: []string{"spam"}},
+		"missing weight":      {ModelNames: []string{"m1", "m2"}, Strategy: model.EnsembleStrategyWeighted, Labels: []string{"spam"}, Weights: map[string]float64{"m1": 1}},
+		"no configured label": {ModelNames: []string{"m1"}, Strategy: model.EnsembleStrategyAverage},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			req.Text = "buy now"
+			_, err := svc.Ensemble(context.Background(), req)
+			assert.True(t, errors.Is(err, apperrors.ErrInvalidInput), "%v", err)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -20,13 +21,18 @@ type HealthService interface {
 type healthService struct {
 	db          *gorm.DB
 	redisClient *redis.Client
+	watchdog    *BackendWatchdog
+	preloader   *ModelPreloader
 }
 
-// NewHealthService 创建健康检查服务
-func NewHealthService(db *gorm.DB, redisClient *redis.Client) HealthService {
+// NewHealthService 创建健康检查服务，watchdog 提供已加载模型推理后端的探测结果，
+// preloader 预加载结束前就绪检查返回未就绪
+func NewHealthService(db *gorm.DB, redisClient *redis.Client, watchdog *BackendWatchdog, preloader *ModelPreloader) HealthService {
 	return &healthService{
 		db:          db,
 		redisClient: redisClient,
+		watchdog:    watchdog,
+		preloader:   preloader,
 	}
 }
 
@@ -46,6 +52,9 @@ func (s *healthService) Health(ctx context.Context) *model.HealthResponse {
 	redisStatus := s.checkRedis(ctx)
 	response.Services["redis"] = redisStatus
 
+	// 模型推理后端只报告不影响整体状态，避免单个模型故障导致实例被重启
+	response.Services["models"] = s.checkModels()
+
 	// 如果任何服务不健康，整体状态为不健康
 	if !dbStatus["healthy"].(bool) || !redisStatus["healthy"].(bool) {
 		response.Status = "unhealthy"
@@ -54,10 +63,39 @@ func (s *healthService) Health(ctx context.Context) *model.HealthResponse {
 	return response
 }
 
-// Ready 就绪检查
+// Ready 就绪检查，在健康检查的基础上要求模型预加载已结束；预加载失败的模型只报告不影响就绪
 func (s *healthService) Ready(ctx context.Context) *model.HealthResponse {
-	// 就绪检查与健康检查相同
-	return s.Health(ctx)
+	response := s.Health(ctx)
+	preload := s.checkPreload()
+	response.Services["preload"] = preload
+	if response.Status == "healthy" && !preload["done"].(bool) {
+		response.Status = "not_ready"
+	}
+	return response
+}
+
+// checkPreload 汇总启动时模型预加载的进度与结果
+func (s *healthService) checkPreload() map[string]interface{} {
+	done, results := s.preloader.Status()
+	failed := 0
+	for _, result := range results {
+		if done && !result.Loaded {
+			failed++
+		}
+	}
+
+	status := map[string]interface{}{
+		"done":    done,
+		"message": "模型预加载完成",
+		"models":  results,
+	}
+	switch {
+	case !done:
+		status["message"] = "模型预加载中"
+	case failed > 0:
+		status["message"] = fmt.Sprintf("%d 个模型预加载失败", failed)
+	}
+	return status
 }
 
 // checkDatabase 检查数据库连接
@@ -88,6 +126,9 @@ func (s *healthService) checkDatabase(ctx context.Context) map[string]interface{
 		"open_connections": stats.OpenConnections,
 		"in_use":          stats.InUse,
 		"idle":            stats.Idle,
+		"max_open":        stats.MaxOpenConnections,
+		"wait_count":      stats.WaitCount,
+		"wait_duration_ms": stats.WaitDuration.Milliseconds(),
 	}
 
 	return status
@@ -118,4 +159,25 @@ func (s *healthService) checkRedis(ctx context.Context) map[string]interface{} {
 	status["info"] = info
 
 	return status
-}
\ No newline at end of file
+}
+
+// checkModels 汇总已加载模型推理后端的最近探测结果
+func (s *healthService) checkModels() map[string]interface{} {
+	models := s.watchdog.Snapshot()
+	unhealthy := 0
+	for _, m := range models {
+		if !m.Healthy {
+			unhealthy++
+		}
+	}
+
+	status := map[string]interface{}{
+		"healthy": unhealthy == 0,
+		"message": "模型推理后端正常",
+		"models":  models,
+	}
+	if unhealthy > 0 {
+		status["message"] = fmt.Sprintf("%d 个模型的推理后端探测失败", unhealthy)
+	}
+	return status
+}
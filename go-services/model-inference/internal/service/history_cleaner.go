@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+// historyCleanupBatchSize 每批删除的推理记录或文本向量数
+const historyCleanupBatchSize = 1000
+
+// HistoryCleaner 按保留天数定期清理推理历史与向量库
+type HistoryCleaner struct {
+	inferenceRepo repository.InferenceRepository
+	vectorStore   repository.VectorStore
+	config        config.InferenceConfig
+	configMu      sync.RWMutex
+	now           func() time.Time
+}
+
+// NewHistoryCleaner 创建推理历史清理任务，vectorStore 为 nil 时不清理向量库
+func NewHistoryCleaner(inferenceRepo repository.InferenceRepository, vectorStore repository.VectorStore, cfg config.InferenceConfig) *HistoryCleaner {
+	return &HistoryCleaner{
+		inferenceRepo: inferenceRepo,
+		vectorStore:   vectorStore,
+		config:        cfg,
+		now:           time.Now,
+	}
+}
+
+// UpdateConfig 热更新清理配置，下一轮清理生效
+func (c *HistoryCleaner) UpdateConfig(cfg config.InferenceConfig) {
+	c.configMu.Lock()
+	c.config = cfg
+	c.configMu.Unlock()
+}
+
+func (c *HistoryCleaner) cfg() config.InferenceConfig {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	return c.config
+}
+
+// Run 每隔 HistoryCleanupInterval 分钟执行一次清理，直到 ctx 取消
+func (c *HistoryCleaner) Run(ctx context.Context) {
+	for {
+		c.Cleanup()
+		c.CleanupVectors(ctx)
+
+		interval := time.Duration(c.cfg().HistoryCleanupInterval) * time.Minute
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Cleanup 删除超过保留天数的推理记录，HistoryRetention 为 0 时不清理
+func (c *HistoryCleaner) Cleanup() int64 {
+	retentionDays := c.cfg().HistoryRetention
+	if retentionDays <= 0 {
+		return 0
+	}
+
+	before := c.now().AddDate(0, 0, -retentionDays)
+	purged, err := c.inferenceRepo.DeleteOldRecords(before, historyCleanupBatchSize)
+	if err != nil {
+		logrus.Errorf("清理推理历史失败（已删除 %d 条）: %v", purged, err)
+		return purged
+	}
+	if purged > 0 {
+		logrus.Infof("已清理 %s 之前的推理历史 %d 条", before.Format(time.RFC3339), purged)
+	}
+	return purged
+}
+
+// CleanupVectors 删除超过保留天数未再写入的文本向量，VectorRetention 为 0 时不清理
+func (c *HistoryCleaner) CleanupVectors(ctx context.Context) int64 {
+	retentionDays := c.cfg().VectorRetention
+	if c.vectorStore == nil || retentionDays <= 0 {
+		return 0
+	}
+
+	before := c.now().AddDate(0, 0, -retentionDays)
+	purged, err := c.vectorStore.DeleteOlderThan(ctx, before, historyCleanupBatchSize)
+	if err != nil {
+		logrus.Errorf("清理文本向量失败（已删除 %d 条）: %v", purged, err)
+		return purged
+	}
+	if purged > 0 {
+		logrus.Infof("已清理 %s 之前的文本向量 %d 条", before.Format(time.RFC3339), purged)
+	}
+	return purged
+}
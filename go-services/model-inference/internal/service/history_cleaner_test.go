@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+func TestHistoryCleanerRemovesOnlyOldRecords(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.InferenceRequest{}))
+
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	seed := func(prefix string, count int, createdAt time.Time) {
+		for i := 0; i < count; i++ {
+			require.NoError(t, db.Create(&model.InferenceRequest{
+				RequestID: fmt.Sprintf("%s-%d", prefix, i),
+				ModelName: "m",
+				InputData: "{}",
+				CreatedAt: createdAt,
+			}).Error)
+		}
+	}
+	// 旧记录数超过单批上限，验证分批删除
+	seed("old", historyCleanupBatchSize+5, now.AddDate(0, 0, -8))
+	seed("new", 3, now.AddDate(0, 0, -6))
+
+	cleaner := NewHistoryCleaner(repository.NewInferenceRepository(db), nil, config.InferenceConfig{HistoryRetention: 7})
+	cleaner.now = func() time.Time { return now }
+
+	assert.Equal(t, int64(historyCleanupBatchSize+5), cleaner.Cleanup())
+
+	// 物理删除，软删除记录也不会保留
+	var remaining []model.InferenceRequest
+	require.NoError(t, db.Unscoped().Order("request_id").Find(&remaining).Error)
+	require.Len(t, remaining, 3)
+	for _, r := range remaining {
+		assert.Contains(t, r.RequestID, "new-")
+	}
+
+	// 再次执行无记录可删
+	assert.Zero(t, cleaner.Cleanup())
+
+	// 保留天数为 0 时不清理
+	cleaner.UpdateConfig(config.InferenceConfig{HistoryRetention: 0})
+	cleaner.now = func() time.Time { return now.AddDate(1, 0, 0) }
+	assert.Zero(t, cleaner.Cleanup())
+}
+
+func TestHistoryCleanerRemovesOldVectors(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.TextEmbedding{}))
+
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < historyCleanupBatchSize+2; i++ {
+		require.NoError(t, db.Create(&model.TextEmbedding{
+			ModelName: "m",
+			TextHash:  fmt.Sprintf("old-%d", i),
+			Vector:    "[1]",
+			UpdatedAt: now.AddDate(0, 0, -31),
+		}).Error)
+	}
+	require.NoError(t, db.Create(&model.TextEmbedding{ModelName: "m", TextHash: "new", Vector: "[1]", UpdatedAt: now.AddDate(0, 0, -29)}).Error)
+
+	cleaner := NewHistoryCleaner(repository.NewInferenceRepository(db), repository.NewVectorStore(db), config.InferenceConfig{VectorRetention: 30})
+	cleaner.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	assert.Equal(t, int64(historyCleanupBatchSize+2), cleaner.CleanupVectors(ctx))
+	var remaining int64
+	require.NoError(t, db.Model(&model.TextEmbedding{}).Count(&remaining).Error)
+	assert.Equal(t, int64(1), remaining)
+
+	// 保留天数为 0 时不清理
+	cleaner.UpdateConfig(config.InferenceConfig{VectorRetention: 0})
+	cleaner.now = func() time.Time { return now.AddDate(1, 0, 0) }
+	assert.Zero(t, cleaner.CleanupVectors(ctx))
+}
@@ -2,30 +2,59 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/batching"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/pii"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/quality"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/sanitize"
 )
 
+// ErrBatchTooLarge 单次请求的条数超过 inference.max_batch_size
+var ErrBatchTooLarge = apperrors.New(apperrors.ErrTooLarge, "批量大小超过限制")
+
 // InferenceService 推理服务接口
 type InferenceService interface {
 	Predict(ctx context.Context, req *model.PredictRequest) (*model.PredictResponse, error)
+	PredictAsync(ctx context.Context, req *model.PredictRequest) (*model.AsyncPredictResponse, error)
+	Ensemble(ctx context.Context, req *model.EnsembleRequest) (*model.EnsembleResponse, error)
 	BatchPredict(ctx context.Context, req *model.BatchPredictRequest) (*model.BatchPredictResponse, error)
+	StreamBatchPredict(ctx context.Context, req *model.BatchPredictRequest, emit func(*model.PredictResponse) error) error
 	ClassifyText(ctx context.Context, req *model.TextClassifyRequest) (*model.TextAnalysisResponse, error)
 	AnalyzeSentiment(ctx context.Context, req *model.SentimentAnalysisRequest) (*model.TextAnalysisResponse, error)
 	ExtractFeatures(ctx context.Context, req *model.FeatureExtractionRequest) (*model.TextAnalysisResponse, error)
 	DetectAnomaly(ctx context.Context, req *model.AnomalyDetectionRequest) (*model.TextAnalysisResponse, error)
+	Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error)
+	Similar(ctx context.Context, req *model.SimilarityRequest) (*model.SimilarityResponse, error)
+	RecognizeEntities(ctx context.Context, req *model.NERRequest) (*model.TextAnalysisResponse, error)
+	ScoreQuality(ctx context.Context, req *model.TextQualityRequest) (*model.TextQualityResponse, error)
+	Redact(ctx context.Context, req *model.RedactRequest) (*model.RedactResponse, error)
 	GetHistory(ctx context.Context, limit, offset int) ([]*model.InferenceRequest, error)
+	ListPendingReviews(ctx context.Context, limit, offset int) ([]*model.InferenceRequest, error)
 	GetInferenceResult(ctx context.Context, requestID string) (*model.InferenceRequest, error)
-	GetStatistics(ctx context.Context) (*model.InferenceStatistics, error)
+	GetStatistics(ctx context.Context, opts model.StatisticsOptions) (*model.InferenceStatistics, error)
+	UpdateConfig(cfg config.InferenceConfig)
+	Drain(ctx context.Context) error
 }
 
 // inferenceService 推理服务实现
@@ -33,22 +62,93 @@ type inferenceService struct {
 	inferenceRepo repository.InferenceRepository
 	modelService  ModelService
 	cacheRepo     repository.CacheRepository
+	backend       backend.InferenceBackend
+	vectorStore   repository.VectorStore
+	embedBatcher  *batching.Batcher[string, []float64]
+	rateLimiter   *modelRateLimiter
+	concurrency   *concurrencyLimiter
+	sampleLogger  logrus.FieldLogger
 	config        config.InferenceConfig
+	configMu      sync.RWMutex
+	// asyncJobs 进行中的异步预测，asyncRunning 为其数量
+	asyncJobs    sync.WaitGroup
+	asyncRunning atomic.Int64
+	// callbackClient 发送异步预测回调，拒绝连接内网地址且不跟随重定向
+	callbackClient *http.Client
 }
 
+// defaultStatisticsWindow 延迟分位数的默认统计窗口
+const defaultStatisticsWindow = time.Hour
+
+// 相似文本检索的默认与最大返回数量
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 100
+)
+
 // NewInferenceService 创建推理服务
 func NewInferenceService(
 	inferenceRepo repository.InferenceRepository,
 	modelService ModelService,
 	cacheRepo repository.CacheRepository,
+	inferenceBackend backend.InferenceBackend,
+	vectorStore repository.VectorStore,
 	cfg config.InferenceConfig,
 ) InferenceService {
-	return &inferenceService{
+	s := &inferenceService{
 		inferenceRepo: inferenceRepo,
 		modelService:  modelService,
 		cacheRepo:     cacheRepo,
+		backend:       inferenceBackend,
+		vectorStore:   vectorStore,
+		rateLimiter:   newModelRateLimiter(cfg.RateLimits),
+		concurrency:   newConcurrencyLimiter(cfg.MaxConcurrency),
+		sampleLogger:  logrus.StandardLogger(),
 		config:        cfg,
 	}
+	s.callbackClient = newCallbackClient(s.callbackHostAllowed)
+	s.embedBatcher = batching.New(
+		inferenceBackend.Embed,
+		cfg.MaxBatchSize,
+		time.Duration(cfg.BatchWaitMs)*time.Millisecond,
+		time.Duration(cfg.TimeoutSeconds)*time.Second,
+	)
+	return s
+}
+
+// Drain 停止接收新的批处理请求，派发队列中剩余的批次并等待其完成，随后等待进行中的异步预测结束
+func (s *inferenceService) Drain(ctx context.Context) error {
+	if err := s.embedBatcher.Drain(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.asyncJobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UpdateConfig 热更新推理配置
+func (s *inferenceService) UpdateConfig(cfg config.InferenceConfig) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config = cfg
+	s.rateLimiter.update(cfg.RateLimits)
+	s.concurrency.resize(cfg.MaxConcurrency)
+}
+
+// cfg 获取当前推理配置
+func (s *inferenceService) cfg() config.InferenceConfig {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
 }
 
 // Predict 单次预测
@@ -56,13 +156,56 @@ func (s *inferenceService) Predict(ctx context.Context, req *model.PredictReques
 	startTime := time.Now()
 	requestID := uuid.New().String()
 
-	// 检查模型是否已加载
-	if !s.modelService.IsModelLoaded(req.ModelName) {
-		return nil, fmt.Errorf("模型 %s 未加载", req.ModelName)
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// 按模型的输入 schema 校验数据，避免格式错误的输入进入后端
+	if err := s.validateInputs(ctx, req.ModelName, []map[string]interface{}{req.Data}, false); err != nil {
+		return nil, err
+	}
+
+	// 按模型的 max_tokens 拒绝或截断过长的文本
+	data, tokenUsage, err := s.applyTokenLimit(ctx, req.ModelName, sanitizeData(req.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	// 查询预测结果缓存
+	resultCacheKey := ""
+	if s.resultCacheEnabled(ctx, req) {
+		resultCacheKey = predictionCacheKey(req.ModelName, data)
+
+		var cached model.PredictResponse
+		err := s.cacheRepo.Get(ctx, resultCacheKey, &cached)
+		if err == nil {
+			metrics.PredictionCacheHits.WithLabelValues(req.ModelName).Inc()
+			cached.RequestID = requestID
+			cached.Duration = time.Since(startTime).Milliseconds()
+			if cached.Metadata == nil {
+				cached.Metadata = make(map[string]interface{})
+			}
+			cached.Metadata["cache_hit"] = true
+			return &cached, nil
+		}
+		if !errors.Is(err, repository.ErrCacheMiss) {
+			logrus.Warnf("读取预测结果缓存失败: %v", err)
+		}
+		metrics.PredictionCacheMisses.WithLabelValues(req.ModelName).Inc()
 	}
 
 	// 创建推理请求记录
-	inputData, _ := json.Marshal(req.Data)
+	inputData, _ := json.Marshal(data)
 	inferenceReq := &model.InferenceRequest{
 		RequestID: requestID,
 		ModelName: req.ModelName,
@@ -76,7 +219,7 @@ func (s *inferenceService) Predict(ctx context.Context, req *model.PredictReques
 	}
 
 	// 执行推理
-	prediction, confidence, err := s.performInference(ctx, req.ModelName, req.Data)
+	prediction, confidence, probability, err := s.performInference(ctx, req.ModelName, data)
 	duration := time.Since(startTime).Milliseconds()
 
 	if err != nil {
@@ -87,84 +230,231 @@ func (s *inferenceService) Predict(ctx context.Context, req *model.PredictReques
 
 	// 更新成功结果
 	resultData, _ := json.Marshal(map[string]interface{}{
-		"prediction": prediction,
-		"confidence": confidence,
+		"prediction":  prediction,
+		"confidence":  confidence,
+		"probability": probability,
 	})
 	s.inferenceRepo.UpdateResult(requestID, string(resultData), time.Now(), duration)
+	s.sampleLog(requestID, req.ModelName, data, json.RawMessage(resultData))
 
 	// 构建响应
 	response := &model.PredictResponse{
-		RequestID:  requestID,
-		ModelName:  req.ModelName,
-		Prediction: prediction,
-		Confidence: confidence,
-		Duration:   duration,
+		RequestID:   requestID,
+		ModelName:   req.ModelName,
+		Prediction:  prediction,
+		Confidence:  confidence,
+		Probability: probability,
+		Metadata:    tokenUsage,
+		Duration:    duration,
 	}
 
 	// 缓存结果
+	cacheTTL := time.Duration(s.cfg().ResultCacheTTL) * time.Second
 	cacheKey := fmt.Sprintf("inference_result:%s", requestID)
-	s.cacheRepo.Set(ctx, cacheKey, response, time.Duration(s.config.ResultCacheTTL)*time.Second)
+	s.cacheRepo.Set(ctx, cacheKey, response, cacheTTL)
+	if resultCacheKey != "" {
+		if err := s.cacheRepo.Set(ctx, resultCacheKey, response, cacheTTL); err != nil {
+			logrus.Warnf("写入预测结果缓存失败: %v", err)
+		}
+	}
 
 	return response, nil
 }
 
+// resultCacheEnabled 判断本次预测是否使用结果缓存
+// 请求 options.no_cache=true 可跳过缓存；模型元数据 result_cache=false 表示模型输出不确定，不缓存
+func (s *inferenceService) resultCacheEnabled(ctx context.Context, req *model.PredictRequest) bool {
+	if s.cfg().ResultCacheTTL <= 0 {
+		return false
+	}
+	if noCache, ok := req.Options["no_cache"].(bool); ok && noCache {
+		return false
+	}
+
+	modelInfo, err := s.modelService.GetModel(ctx, req.ModelName)
+	if err != nil || modelInfo == nil || modelInfo.Metadata == "" {
+		return true
+	}
+	var metadata struct {
+		ResultCache *bool `json:"result_cache"`
+	}
+	if err := json.Unmarshal([]byte(modelInfo.Metadata), &metadata); err != nil {
+		return true
+	}
+	return metadata.ResultCache == nil || *metadata.ResultCache
+}
+
+// predictionCacheKey 根据模型名和输入数据生成缓存键，json 序列化 map 时键有序，保证相同输入得到相同哈希
+func predictionCacheKey(modelName string, data map[string]interface{}) string {
+	input, _ := json.Marshal(data)
+	sum := sha256.Sum256(append([]byte(modelName+"\x00"), input...))
+	return fmt.Sprintf("prediction:%s:%s", modelName, hex.EncodeToString(sum[:]))
+}
+
 // BatchPredict 批量预测
 func (s *inferenceService) BatchPredict(ctx context.Context, req *model.BatchPredictRequest) (*model.BatchPredictResponse, error) {
 	startTime := time.Now()
 	requestID := uuid.New().String()
 
+	var predictions []model.PredictResponse
+	err := s.batchPredict(ctx, requestID, req, func(prediction *model.PredictResponse) error {
+		predictions = append(predictions, *prediction)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Since(startTime).Milliseconds()
+
+	response := &model.BatchPredictResponse{
+		RequestID:   requestID,
+		ModelName:   req.ModelName,
+		Predictions: predictions,
+		Duration:    duration,
+	}
+
+	return response, nil
+}
+
+// StreamBatchPredict 流式批量预测：每完成一项即调用 emit，顺序与输入一致。
+// 校验失败时在首次 emit 之前返回错误；ctx 取消或 emit 返回错误时停止剩余条目的推理
+func (s *inferenceService) StreamBatchPredict(ctx context.Context, req *model.BatchPredictRequest, emit func(*model.PredictResponse) error) error {
+	return s.batchPredict(ctx, uuid.New().String(), req, emit)
+}
+
+// batchPredict 校验批量请求后逐项推理，推理失败的条目记录日志后跳过
+func (s *inferenceService) batchPredict(ctx context.Context, requestID string, req *model.BatchPredictRequest, emit func(*model.PredictResponse) error) error {
 	// 检查批量大小限制
-	if len(req.Data) > s.config.MaxBatchSize {
-		return nil, fmt.Errorf("批量大小超过限制 %d", s.config.MaxBatchSize)
+	if maxBatchSize := s.cfg().MaxBatchSize; len(req.Data) > maxBatchSize {
+		return fmt.Errorf("%w: 共 %d 条，最多 %d 条，请拆分后重试", ErrBatchTooLarge, len(req.Data), maxBatchSize)
 	}
 
-	// 检查模型是否已加载
-	if !s.modelService.IsModelLoaded(req.ModelName) {
-		return nil, fmt.Errorf("模型 %s 未加载", req.ModelName)
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return err
 	}
+	defer done()
 
-	var predictions []model.PredictResponse
+	// 占用已加载的模型，推理结束前模型不会被卸载；每条输入各计一次限流
+	release, err := s.acquireModelN(req.ModelName, len(req.Data))
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	// 批量处理
+	if err := s.validateInputs(ctx, req.ModelName, req.Data, true); err != nil {
+		return err
+	}
+
+	// 推理前统一检查词元数，任一条目被拒绝时整批失败
+	inputs := make([]map[string]interface{}, len(req.Data))
+	tokenUsages := make([]map[string]interface{}, len(req.Data))
 	for i, data := range req.Data {
-		prediction, confidence, err := s.performInference(ctx, req.ModelName, data)
+		if inputs[i], tokenUsages[i], err = s.applyTokenLimit(ctx, req.ModelName, sanitizeData(data)); err != nil {
+			return fmt.Errorf("批量推理第 %d 项: %w", i, err)
+		}
+	}
+
+	// 批量处理
+	for i, data := range inputs {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("批量推理在第 %d 项被取消: %w", i, err)
+		}
+
+		itemStart := time.Now()
+		prediction, confidence, probability, err := s.performInference(ctx, req.ModelName, data)
 		if err != nil {
 			logrus.Errorf("批量推理第 %d 项失败: %v", i, err)
 			continue
 		}
 
-		predictions = append(predictions, model.PredictResponse{
-			RequestID:  fmt.Sprintf("%s_%d", requestID, i),
-			ModelName:  req.ModelName,
-			Prediction: prediction,
-			Confidence: confidence,
-		})
-	}
-
-	duration := time.Since(startTime).Milliseconds()
-
-	response := &model.BatchPredictResponse{
-		RequestID:   requestID,
-		ModelName:   req.ModelName,
-		Predictions: predictions,
-		Duration:    duration,
+		if err := emit(&model.PredictResponse{
+			RequestID:   fmt.Sprintf("%s_%d", requestID, i),
+			ModelName:   req.ModelName,
+			Prediction:  prediction,
+			Confidence:  confidence,
+			Probability: probability,
+			Metadata:    tokenUsages[i],
+			Duration:    time.Since(itemStart).Milliseconds(),
+		}); err != nil {
+			return err
+		}
 	}
 
-	return response, nil
+	return nil
 }
 
 // ClassifyText 文本分类
 func (s *inferenceService) ClassifyText(ctx context.Context, req *model.TextClassifyRequest) (*model.TextAnalysisResponse, error) {
 	startTime := time.Now()
 	requestID := uuid.New().String()
+	req.Text = sanitize.Text(req.Text)
+
+	// 配置了 A/B 分流时按比例选择实际处理请求的模型
+	modelName, variant := s.routeClassification(req.ModelName)
+
+	response, err := s.classifyText(ctx, requestID, modelName, req.Text, startTime)
+	if err == nil {
+		s.sampleLog(requestID, modelName, map[string]interface{}{"text": req.Text}, map[string]interface{}{
+			"result":     response.Result,
+			"confidence": response.Confidence,
+			"labels":     response.Labels,
+		})
+	}
 
-	// 检查模型是否已加载
-	if !s.modelService.IsModelLoaded(req.ModelName) {
-		return nil, fmt.Errorf("模型 %s 未加载", req.ModelName)
+	// 分流请求与需人工复核的结果写入推理记录，便于对比变体与复核
+	if variant != nil || (response != nil && response.NeedsReview) {
+		s.recordClassification(requestID, modelName, req.Text, variant, response, err, startTime)
+	}
+	return response, err
+}
+
+// classifyText 使用指定模型执行文本分类，置信度低于模型 review_threshold 时标记为需人工复核
+func (s *inferenceService) classifyText(ctx context.Context, requestID, modelName, text string, startTime time.Time) (*model.TextAnalysisResponse, error) {
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(modelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	classification := s.loadClassificationConfig(ctx, modelName)
+
+	// 模型配置为多标签时返回所有超过阈值的标签
+	if classification.MultiLabel {
+		labels, err := s.performMultiLabelClassification(ctx, modelName, text, classification)
+		if err != nil {
+			return nil, fmt.Errorf("文本分类失败: %w", err)
+		}
+
+		response := &model.TextAnalysisResponse{
+			RequestID: requestID,
+			ModelName: modelName,
+			Text:      text,
+			Result:    map[string]interface{}{"class": "", "confidence": 0.0},
+			Labels:    labels,
+		}
+		// 没有标签超过阈值时视为未命中任何标签，不需要复核
+		if len(labels) > 0 {
+			response.Result = map[string]interface{}{"class": labels[0].Label, "confidence": labels[0].Score}
+			response.Confidence = labels[0].Score
+			response.NeedsReview = classification.needsReview(response.Confidence)
+		}
+		response.Duration = time.Since(startTime).Milliseconds()
+		return response, nil
 	}
 
 	// 执行文本分类
-	result, confidence, err := s.performTextClassification(ctx, req.ModelName, req.Text)
+	result, confidence, err := s.performTextClassification(ctx, modelName, text)
 	if err != nil {
 		return nil, fmt.Errorf("文本分类失败: %w", err)
 	}
@@ -172,26 +462,107 @@ func (s *inferenceService) ClassifyText(ctx context.Context, req *model.TextClas
 	duration := time.Since(startTime).Milliseconds()
 
 	response := &model.TextAnalysisResponse{
-		RequestID:  requestID,
-		ModelName:  req.ModelName,
-		Text:       req.Text,
-		Result:     result,
-		Confidence: confidence,
-		Duration:   duration,
+		RequestID:   requestID,
+		ModelName:   modelName,
+		Text:        text,
+		Result:      result,
+		Confidence:  confidence,
+		NeedsReview: classification.needsReview(confidence),
+		Duration:    duration,
 	}
 
 	return response, nil
 }
 
+// recordClassification 写入文本分类的推理记录：元数据 ab_test 字段标明处理请求的分流变体，
+// 需人工复核的结果状态记为 needs_review
+func (s *inferenceService) recordClassification(requestID, modelName, text string, variant *trafficVariant, response *model.TextAnalysisResponse, classifyErr error, startTime time.Time) {
+	inputData, _ := json.Marshal(map[string]interface{}{"text": text})
+	endTime := time.Now()
+
+	inferenceReq := &model.InferenceRequest{
+		RequestID: requestID,
+		ModelName: modelName,
+		InputData: string(inputData),
+		Status:    model.InferenceStatusCompleted,
+		StartTime: startTime,
+		EndTime:   &endTime,
+		Duration:  endTime.Sub(startTime).Milliseconds(),
+	}
+	if variant != nil {
+		metadata, _ := json.Marshal(map[string]interface{}{"ab_test": variant})
+		inferenceReq.Metadata = string(metadata)
+	}
+	if classifyErr != nil {
+		inferenceReq.Status = model.InferenceStatusFailed
+		inferenceReq.Error = classifyErr.Error()
+	} else {
+		result, _ := json.Marshal(map[string]interface{}{
+			"result":     response.Result,
+			"confidence": response.Confidence,
+			"labels":     response.Labels,
+		})
+		inferenceReq.Result = string(result)
+		if response.NeedsReview {
+			inferenceReq.Status = model.InferenceStatusNeedsReview
+		}
+	}
+
+	if err := s.inferenceRepo.Create(inferenceReq); err != nil {
+		logrus.Errorf("创建推理请求记录失败: %v", err)
+	}
+}
+
+// classificationConfig 分类模型配置，来自模型元数据，例如
+// {"multi_label": true, "labels": ["垃圾信息", "广告"], "label_threshold": 0.6, "review_threshold": 0.8}
+type classificationConfig struct {
+	MultiLabel      bool     `json:"multi_label"`
+	Labels          []string `json:"labels"`
+	LabelThreshold  *float64 `json:"label_threshold"`
+	ReviewThreshold *float64 `json:"review_threshold"` // 置信度低于该值的结果需人工复核，未配置时不复核
+}
+
+// needsReview 判断置信度是否低于复核阈值
+func (c classificationConfig) needsReview(confidence float64) bool {
+	return c.ReviewThreshold != nil && confidence < *c.ReviewThreshold
+}
+
+// defaultLabelThreshold 模型未配置阈值时的多标签得分阈值
+const defaultLabelThreshold = 0.5
+
+// loadClassificationConfig 读取模型元数据中的分类配置，读取失败时按单标签处理
+func (s *inferenceService) loadClassificationConfig(ctx context.Context, modelName string) classificationConfig {
+	var cfg classificationConfig
+	modelInfo, err := s.modelService.GetModel(ctx, modelName)
+	if err != nil || modelInfo == nil || modelInfo.Metadata == "" {
+		return cfg
+	}
+	if err := json.Unmarshal([]byte(modelInfo.Metadata), &cfg); err != nil {
+		logrus.Warnf("解析模型 %s 分类配置失败: %v", modelName, err)
+		return classificationConfig{}
+	}
+	return cfg
+}
+
 // AnalyzeSentiment 情感分析
 func (s *inferenceService) AnalyzeSentiment(ctx context.Context, req *model.SentimentAnalysisRequest) (*model.TextAnalysisResponse, error) {
 	startTime := time.Now()
 	requestID := uuid.New().String()
+	req.Text = sanitize.Text(req.Text)
 
-	// 检查模型是否已加载
-	if !s.modelService.IsModelLoaded(req.ModelName) {
-		return nil, fmt.Errorf("模型 %s 未加载", req.ModelName)
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	// 执行情感分析
 	result, confidence, err := s.performSentimentAnalysis(ctx, req.ModelName, req.Text)
@@ -217,11 +588,21 @@ func (s *inferenceService) AnalyzeSentiment(ctx context.Context, req *model.Sent
 func (s *inferenceService) ExtractFeatures(ctx context.Context, req *model.FeatureExtractionRequest) (*model.TextAnalysisResponse, error) {
 	startTime := time.Now()
 	requestID := uuid.New().String()
+	req.Text = sanitize.Text(req.Text)
 
-	// 检查模型是否已加载
-	if !s.modelService.IsModelLoaded(req.ModelName) {
-		return nil, fmt.Errorf("模型 %s 未加载", req.ModelName)
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	// 执行特征提取
 	features, err := s.performFeatureExtraction(ctx, req.ModelName, req.Text)
@@ -247,14 +628,28 @@ func (s *inferenceService) ExtractFeatures(ctx context.Context, req *model.Featu
 func (s *inferenceService) DetectAnomaly(ctx context.Context, req *model.AnomalyDetectionRequest) (*model.TextAnalysisResponse, error) {
 	startTime := time.Now()
 	requestID := uuid.New().String()
+	text, _ := req.Data["text"].(string)
+	text = sanitize.Text(text)
+	if strings.TrimSpace(text) == "" {
+		return nil, apperrors.New(apperrors.ErrInvalidInput, "异常检测的文本 data.text 不能为空")
+	}
 
-	// 检查模型是否已加载
-	if !s.modelService.IsModelLoaded(req.ModelName) {
-		return nil, fmt.Errorf("模型 %s 未加载", req.ModelName)
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	// 执行异常检测
-	result, confidence, err := s.performAnomalyDetection(ctx, req.ModelName, req.Data)
+	result, confidence, err := s.performAnomalyDetection(ctx, req.ModelName, text)
 	if err != nil {
 		return nil, fmt.Errorf("异常检测失败: %w", err)
 	}
@@ -264,6 +659,7 @@ func (s *inferenceService) DetectAnomaly(ctx context.Context, req *model.Anomaly
 	response := &model.TextAnalysisResponse{
 		RequestID:  requestID,
 		ModelName:  req.ModelName,
+		Text:       text,
 		Result:     result,
 		Confidence: confidence,
 		Duration:   duration,
@@ -272,23 +668,352 @@ func (s *inferenceService) DetectAnomaly(ctx context.Context, req *model.Anomaly
 	return response, nil
 }
 
+// Embed 计算文本向量，未缓存的文本经动态批处理合并后交由后端计算
+func (s *inferenceService) Embed(ctx context.Context, req *model.EmbeddingRequest) (*model.EmbeddingResponse, error) {
+	startTime := time.Now()
+	requestID := uuid.New().String()
+
+	texts := req.Texts
+	if req.Text != "" {
+		texts = append([]string{req.Text}, texts...)
+	}
+	texts = sanitizeTexts(texts)
+	if len(texts) == 0 {
+		return nil, apperrors.New(apperrors.ErrInvalidInput, "待计算向量的文本不能为空")
+	}
+	if maxBatchSize := s.cfg().MaxBatchSize; len(texts) > maxBatchSize {
+		return nil, fmt.Errorf("%w: 共 %d 条，最多 %d 条，请拆分后重试", ErrBatchTooLarge, len(texts), maxBatchSize)
+	}
+
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载；每条文本各计一次限流
+	release, err := s.acquireModelN(req.ModelName, len(texts))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	embeddings, cacheHits, err := s.embed(ctx, req.ModelName, texts)
+	if err != nil {
+		return nil, fmt.Errorf("计算向量失败: %w", err)
+	}
+
+	// 只有显式要求索引的文本写入向量库，临时查询的文本不会出现在相似检索结果中
+	if req.Index {
+		for i, text := range texts {
+			if err := s.vectorStore.Save(ctx, req.ModelName, text, embeddings[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.Normalize {
+		for i := range embeddings {
+			embeddings[i] = backend.Normalize(embeddings[i])
+		}
+	}
+
+	dimension := 0
+	if len(embeddings) > 0 {
+		dimension = len(embeddings[0])
+	}
+
+	return &model.EmbeddingResponse{
+		RequestID:  requestID,
+		ModelName:  req.ModelName,
+		Embeddings: embeddings,
+		Dimension:  dimension,
+		CacheHits:  cacheHits,
+		Duration:   time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// Similar 计算查询文本的向量，并检索已索引文本（向量请求 index 为 true）中余弦相似度最高的前 K 条
+func (s *inferenceService) Similar(ctx context.Context, req *model.SimilarityRequest) (*model.SimilarityResponse, error) {
+	startTime := time.Now()
+	requestID := uuid.New().String()
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSimilarLimit
+	}
+	if limit > maxSimilarLimit {
+		return nil, apperrors.New(apperrors.ErrInvalidInput, "返回数量超过限制 %d", maxSimilarLimit)
+	}
+
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query, err := s.embedBatcher.Submit(ctx, req.ModelName, sanitize.Text(req.Text))
+	if err != nil {
+		return nil, fmt.Errorf("计算向量失败: %w", classifyBatchError(err))
+	}
+
+	results, err := s.vectorStore.Search(ctx, req.ModelName, query, limit, req.MinSimilarity)
+	if err != nil {
+		return nil, fmt.Errorf("检索相似文本失败: %w", err)
+	}
+
+	return &model.SimilarityResponse{
+		RequestID: requestID,
+		ModelName: req.ModelName,
+		Results:   results,
+		Duration:  time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// ScoreQuality 按长度、语言纯度、标点占比与重复度为文本打分，基于规则计算，不占用模型
+func (s *inferenceService) ScoreQuality(ctx context.Context, req *model.TextQualityRequest) (*model.TextQualityResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("质量评分被取消: %w", err)
+	}
+
+	score := quality.Evaluate(req.Text)
+	return &model.TextQualityResponse{
+		RequestID:   uuid.New().String(),
+		Score:       score.Total,
+		Length:      score.Length,
+		Purity:      score.Purity,
+		Punctuation: score.Punctuation,
+		Repetition:  score.Repetition,
+	}, nil
+}
+
+// Redact 识别并遮盖手机号、身份证号、邮箱与银行卡号，基于规则计算，不占用模型
+func (s *inferenceService) Redact(ctx context.Context, req *model.RedactRequest) (*model.RedactResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("脱敏被取消: %w", err)
+	}
+
+	redacted, matches := pii.Redact(req.Text)
+	if matches == nil {
+		matches = []pii.Match{}
+	}
+	types := pii.Types(matches)
+	if types == nil {
+		types = []pii.Type{}
+	}
+	return &model.RedactResponse{
+		RequestID: uuid.New().String(),
+		Text:      redacted,
+		Types:     types,
+		Matches:   matches,
+	}, nil
+}
+
+// RecognizeEntities 命名实体识别
+func (s *inferenceService) RecognizeEntities(ctx context.Context, req *model.NERRequest) (*model.TextAnalysisResponse, error) {
+	startTime := time.Now()
+	requestID := uuid.New().String()
+	req.Text = sanitize.Text(req.Text)
+
+	// 同步推理受 inference.max_concurrency 限制，槽位占满时等待
+	done, err := s.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// 占用已加载的模型，推理结束前模型不会被卸载
+	release, err := s.acquireModel(req.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	entities, err := s.backend.ExtractEntities(ctx, req.ModelName, req.Text)
+	if err != nil {
+		return nil, fmt.Errorf("实体识别失败: %w", err)
+	}
+
+	// 结果按实体类型汇总数量
+	counts := make(map[model.EntityType]int)
+	for _, entity := range entities {
+		counts[entity.Type]++
+	}
+
+	return &model.TextAnalysisResponse{
+		RequestID: requestID,
+		ModelName: req.ModelName,
+		Text:      req.Text,
+		Result:    counts,
+		Entities:  entities,
+		Duration:  time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// embed 按文本哈希查询向量缓存，仅对未命中的文本调用后端，返回向量与缓存命中数
+func (s *inferenceService) embed(ctx context.Context, modelName string, texts []string) ([][]float64, int, error) {
+	embeddings := make([][]float64, len(texts))
+	var missTexts []string
+	var missIndexes []int
+
+	// 一次请求读取全部文本的向量缓存
+	keys := make([]string, len(texts))
+	dests := make([]interface{}, len(texts))
+	for i, text := range texts {
+		keys[i] = embeddingCacheKey(modelName, text)
+		dests[i] = &embeddings[i]
+	}
+	hits, err := s.cacheRepo.MGet(ctx, keys, dests)
+	if err != nil {
+		logrus.Warnf("读取向量缓存失败: %v", err)
+		hits = make([]bool, len(texts))
+	}
+	for i, text := range texts {
+		if hits[i] {
+			continue
+		}
+		embeddings[i] = nil
+		missTexts = append(missTexts, text)
+		missIndexes = append(missIndexes, i)
+	}
+
+	if len(missTexts) > 0 {
+		computed, err := s.embedBatcher.SubmitAll(ctx, modelName, missTexts)
+		if err != nil {
+			return nil, 0, classifyBatchError(err)
+		}
+
+		cacheTTL := time.Duration(s.cfg().ResultCacheTTL) * time.Second
+		entries := make(map[string]interface{}, len(missIndexes))
+		for j, index := range missIndexes {
+			embeddings[index] = computed[j]
+			entries[keys[index]] = computed[j]
+		}
+		if cacheTTL > 0 {
+			if err := s.cacheRepo.MSet(ctx, entries, cacheTTL); err != nil {
+				logrus.Warnf("写入向量缓存失败: %v", err)
+			}
+		}
+	}
+
+	return embeddings, len(texts) - len(missTexts), nil
+}
+
+// sanitizeData 清除输入数据中 text 字段的控制字符，需要清理时返回副本，不修改原数据
+func sanitizeData(data map[string]interface{}) map[string]interface{} {
+	text, ok := data["text"].(string)
+	if !ok {
+		return data
+	}
+	cleaned := sanitize.Text(text)
+	if cleaned == text {
+		return data
+	}
+	copied := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+	copied["text"] = cleaned
+	return copied
+}
+
+// sanitizeTexts 清除每条文本中的控制字符，返回新的切片
+func sanitizeTexts(texts []string) []string {
+	cleaned := make([]string, len(texts))
+	for i, text := range texts {
+		cleaned[i] = sanitize.Text(text)
+	}
+	return cleaned
+}
+
+// embeddingCacheKey 根据模型名和文本哈希生成向量缓存键
+func embeddingCacheKey(modelName, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("embedding:%s:%s", modelName, hex.EncodeToString(sum[:]))
+}
+
 // GetHistory 获取推理历史
 func (s *inferenceService) GetHistory(ctx context.Context, limit, offset int) ([]*model.InferenceRequest, error) {
 	return s.inferenceRepo.List(limit, offset)
 }
 
+// ListPendingReviews 获取待人工复核的文本分类记录
+func (s *inferenceService) ListPendingReviews(ctx context.Context, limit, offset int) ([]*model.InferenceRequest, error) {
+	return s.inferenceRepo.ListByStatus(model.InferenceStatusNeedsReview, limit, offset)
+}
+
 // GetInferenceResult 获取推理结果
 func (s *inferenceService) GetInferenceResult(ctx context.Context, requestID string) (*model.InferenceRequest, error) {
-	return s.inferenceRepo.GetByRequestID(requestID)
+	result, err := s.inferenceRepo.GetByRequestID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, apperrors.New(apperrors.ErrNotFound, "推理请求 %s 不存在", requestID)
+	}
+	return result, nil
+}
+
+// classifyBatchError 批处理器排空时返回服务不可用，便于客户端重试其他实例
+func classifyBatchError(err error) error {
+	if errors.Is(err, batching.ErrDraining) {
+		return apperrors.Wrap(apperrors.ErrUnavailable, err, "服务正在关闭")
+	}
+	return err
 }
 
 // GetStatistics 获取推理统计信息
-func (s *inferenceService) GetStatistics(ctx context.Context) (*model.InferenceStatistics, error) {
-	return s.inferenceRepo.GetStatistics()
+func (s *inferenceService) GetStatistics(ctx context.Context, opts model.StatisticsOptions) (*model.InferenceStatistics, error) {
+	stats, err := s.inferenceRepo.GetStatistics()
+	if err != nil {
+		return nil, err
+	}
+
+	window := opts.Window
+	if window <= 0 {
+		window = defaultStatisticsWindow
+	}
+	latency, err := s.inferenceRepo.GetLatencyPercentiles(time.Now().Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	stats.LatencyP50 = latency.P50
+	stats.LatencyP95 = latency.P95
+	stats.LatencyP99 = latency.P99
+	stats.LatencyWindow = window.String()
+	stats.RateLimits = s.rateLimiter.statistics()
+
+	if opts.GroupByModel {
+		stats.Models, err = s.inferenceRepo.GetStatisticsByModel()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
 }
 
-// performInference 执行推理（模拟实现）
-func (s *inferenceService) performInference(ctx context.Context, modelName string, data map[string]interface{}) (interface{}, float64, error) {
+// performInference 执行推理，返回预测结果、置信度与各标签概率。
+// 模型配置了输出标签且输入包含 text 时经后端 logits 与后处理得到标签，否则为模拟实现
+func (s *inferenceService) performInference(ctx context.Context, modelName string, data map[string]interface{}) (interface{}, float64, map[string]float64, error) {
+	if cfg, ok := s.loadOutputConfig(ctx, modelName); ok {
+		if text, isText := data["text"].(string); isText {
+			label, confidence, probability, err := s.classifyWithOutput(ctx, modelName, text, cfg)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			return label, confidence, probability, nil
+		}
+	}
+
 	// 模拟推理延迟
 	time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
 
@@ -304,26 +1029,57 @@ func (s *inferenceService) performInference(ctx context.Context, modelName strin
 
 	confidence := 0.85
 
-	return prediction, confidence, nil
+	return prediction, confidence, nil, nil
 }
 
-// performTextClassification 执行文本分类（模拟实现）
+// performTextClassification 执行单标签文本分类，类别取自模型的输出配置，未配置时使用默认类别
 func (s *inferenceService) performTextClassification(ctx context.Context, modelName string, text string) (interface{}, float64, error) {
-	// 模拟文本分类
-	time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
+	cfg, ok := s.loadOutputConfig(ctx, modelName)
+	if !ok {
+		cfg = outputConfig{Labels: defaultTextClasses}
+	}
 
-	classes := []string{"正常", "违规", "疑似违规"}
-	selectedClass := classes[rand.Intn(len(classes))]
-	confidence := 0.7 + rand.Float64()*0.3
+	class, confidence, probability, err := s.classifyWithOutput(ctx, modelName, text, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	result := map[string]interface{}{
-		"class":      selectedClass,
-		"confidence": confidence,
+		"class":         class,
+		"confidence":    confidence,
+		"probabilities": probability,
 	}
 
 	return result, confidence, nil
 }
 
+// performMultiLabelClassification 计算各标签得分，返回超过阈值的标签（按得分降序）
+func (s *inferenceService) performMultiLabelClassification(ctx context.Context, modelName string, text string, cfg classificationConfig) ([]model.LabelScore, error) {
+	if len(cfg.Labels) == 0 {
+		return nil, fmt.Errorf("模型 %s 未配置多标签分类的标签列表", modelName)
+	}
+	threshold := defaultLabelThreshold
+	if cfg.LabelThreshold != nil {
+		threshold = *cfg.LabelThreshold
+	}
+
+	scores, err := s.backend.ScoreLabels(ctx, modelName, text, cfg.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]model.LabelScore, 0, len(cfg.Labels))
+	for _, label := range cfg.Labels {
+		if score, ok := scores[label]; ok && score >= threshold {
+			labels = append(labels, model.LabelScore{Label: label, Score: score})
+		}
+	}
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].Score > labels[j].Score
+	})
+	return labels, nil
+}
+
 // performSentimentAnalysis 执行情感分析（模拟实现）
 func (s *inferenceService) performSentimentAnalysis(ctx context.Context, modelName string, text string) (interface{}, float64, error) {
 	// 模拟情感分析
@@ -346,40 +1102,22 @@ func (s *inferenceService) performSentimentAnalysis(ctx context.Context, modelNa
 	return result, confidence, nil
 }
 
-// performFeatureExtraction 执行特征提取（模拟实现）
+// performFeatureExtraction 执行特征提取，文本向量由推理后端计算
 func (s *inferenceService) performFeatureExtraction(ctx context.Context, modelName string, text string) (map[string]interface{}, error) {
-	// 模拟特征提取
-	time.Sleep(time.Duration(rand.Intn(30)) * time.Millisecond)
+	embeddings, _, err := s.embed(ctx, modelName, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return textFeatures(text, embeddings[0]), nil
+}
 
-	features := map[string]interface{}{
+// textFeatures 由文本及其向量组成特征提取的结果
+func textFeatures(text string, embedding []float64) map[string]interface{} {
+	return map[string]interface{}{
 		"word_count":     len(text),
 		"char_count":     len([]rune(text)),
 		"sentence_count": 1,
-		"embeddings":     make([]float64, 128), // 模拟词向量
+		"embeddings":     embedding,
 		"keywords":       []string{"关键词1", "关键词2"},
 	}
-
-	// 填充模拟词向量
-	for i := range features["embeddings"].([]float64) {
-		features["embeddings"].([]float64)[i] = rand.Float64()
-	}
-
-	return features, nil
-}
-
-// performAnomalyDetection 执行异常检测（模拟实现）
-func (s *inferenceService) performAnomalyDetection(ctx context.Context, modelName string, data map[string]interface{}) (interface{}, float64, error) {
-	// 模拟异常检测
-	time.Sleep(time.Duration(rand.Intn(80)) * time.Millisecond)
-
-	isAnomaly := rand.Float64() > 0.8
-	confidence := 0.5 + rand.Float64()*0.5
-
-	result := map[string]interface{}{
-		"is_anomaly":    isAnomaly,
-		"anomaly_score": rand.Float64(),
-		"confidence":    confidence,
-	}
-
-	return result, confidence, nil
 }
\ No newline at end of file
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+// stubInferenceRepository 记录推理请求写入次数
+type stubInferenceRepository struct {
+	repository.InferenceRepository
+	created  int
+	requests []*model.InferenceRequest
+}
+
+func (r *stubInferenceRepository) Create(request *model.InferenceRequest) error {
+	r.created++
+	r.requests = append(r.requests, request)
+	return nil
+}
+
+func (r *stubInferenceRepository) ListByStatus(status model.InferenceStatus, limit, offset int) ([]*model.InferenceRequest, error) {
+	var matched []*model.InferenceRequest
+	for _, request := range r.requests {
+		if request.Status == status {
+			matched = append(matched, request)
+		}
+	}
+	return matched, nil
+}
+
+func (r *stubInferenceRepository) UpdateResult(requestID string, result string, endTime time.Time, duration int64) error {
+	return nil
+}
+
+func (r *stubInferenceRepository) UpdateError(requestID string, errorMsg string, endTime time.Time, duration int64) error {
+	return nil
+}
+
+// stubModelService 所有模型均视为已加载
+type stubModelService struct {
+	ModelService
+	models map[string]*model.Model
+}
+
+func (s *stubModelService) IsModelLoaded(name string) bool {
+	_, ok := s.models[name]
+	return ok
+}
+
+func (s *stubModelService) AcquireModel(name string) (func(), error) {
+	if !s.IsModelLoaded(name) {
+		return nil, apperrors.New(apperrors.ErrModelNotLoaded, "模型 %s 未加载", name)
+	}
+	return func() {}, nil
+}
+
+func (s *stubModelService) GetModel(ctx context.Context, name string) (*model.Model, error) {
+	return s.models[name], nil
+}
+
+// stubVectorStore 记录保存的向量，检索时返回空结果
+type stubVectorStore struct {
+	saved map[string][]float64
+}
+
+func (s *stubVectorStore) Save(ctx context.Context, modelName, text string, vector []float64) error {
+	s.saved[text] = vector
+	return nil
+}
+
+func (s *stubVectorStore) Search(ctx context.Context, modelName string, query []float64, limit int, minSimilarity float64) ([]model.SimilarText, error) {
+	return nil, nil
+}
+
+func (s *stubVectorStore) DeleteOlderThan(ctx context.Context, before time.Time, batchSize int) (int64, error) {
+	return 0, nil
+}
+
+// stubBackend 返回固定的标签得分，其余方法使用本地后端
+type stubBackend struct {
+	*backend.LocalBackend
+	scores map[string]float64
+}
+
+func (b *stubBackend) ScoreLabels(ctx context.Context, modelName string, text string, labels []string) (map[string]float64, error) {
+	return b.scores, nil
+}
+
+func newTestInferenceService(t *testing.T, models map[string]*model.Model) (*inferenceService, *stubInferenceRepository) {
+	return newTestInferenceServiceWithBackend(t, models, backend.NewLocalBackend(16))
+}
+
+func newTestInferenceServiceWithBackend(t *testing.T, models map[string]*model.Model, inferenceBackend backend.InferenceBackend) (*inferenceService, *stubInferenceRepository) {
+	cacheRepo, _ := newTestCacheRepo(t)
+	inferenceRepo := &stubInferenceRepository{}
+	svc := NewInferenceService(inferenceRepo, &stubModelService{models: models}, cacheRepo, inferenceBackend, &stubVectorStore{saved: map[string][]float64{}}, config.InferenceConfig{
+		MaxBatchSize:   10,
+		BatchWaitMs:    1,
+		TimeoutSeconds: 5,
+		ResultCacheTTL: 60,
+	})
+	return svc.(*inferenceService), inferenceRepo
+}
+
+func TestPredictResultCache(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestInferenceService(t, map[string]*model.Model{
+		"cached-model": {Name: "cached-model"},
+	})
+	hitsBefore := testutil.ToFloat64(metrics.PredictionCacheHits.WithLabelValues("cached-model"))
+
+	req := &model.PredictRequest{ModelName: "cached-model", Data: map[string]interface{}{"text": "hello", "lang": "en"}}
+	first, err := svc.Predict(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.created)
+
+	// 相同输入（键顺序不同）命中缓存，不再执行推理
+	second, err := svc.Predict(ctx, &model.PredictRequest{ModelName: "cached-model", Data: map[string]interface{}{"lang": "en", "text": "hello"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.created)
+	assert.Equal(t, first.Confidence, second.Confidence)
+	assert.NotEqual(t, first.RequestID, second.RequestID)
+	assert.Equal(t, true, second.Metadata["cache_hit"])
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(metrics.PredictionCacheHits.WithLabelValues("cached-model")))
+
+	// 不同输入不命中
+	_, err = svc.Predict(ctx, &model.PredictRequest{ModelName: "cached-model", Data: map[string]interface{}{"text": "other"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.created)
+
+	// no_cache 选项跳过缓存
+	_, err = svc.Predict(ctx, &model.PredictRequest{ModelName: "cached-model", Data: req.Data, Options: map[string]interface{}{"no_cache": true}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, repo.created)
+}
+
+func TestPredictResultCacheModelOptOut(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestInferenceService(t, map[string]*model.Model{
+		"sampling-model": {Name: "sampling-model", Metadata: `{"result_cache": false}`},
+	})
+
+	req := &model.PredictRequest{ModelName: "sampling-model", Data: map[string]interface{}{"text": "hello"}}
+	for i := 0; i < 2; i++ {
+		resp, err := svc.Predict(ctx, req)
+		require.NoError(t, err)
+		assert.Nil(t, resp.Metadata["cache_hit"])
+	}
+	assert.Equal(t, 2, repo.created)
+}
+
+func TestEmbed(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"embed-model": {Name: "embed-model"},
+	})
+
+	first, err := svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "embed-model", Texts: []string{"你好世界", "hello"}})
+	require.NoError(t, err)
+	require.Len(t, first.Embeddings, 2)
+	assert.Equal(t, 16, first.Dimension)
+	assert.Equal(t, 0, first.CacheHits)
+	// 未要求索引的文本不写入向量库
+	assert.Empty(t, svc.vectorStore.(*stubVectorStore).saved)
+
+	// 已计算过的文本命中缓存，且结果保持一致
+	second, err := svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "embed-model", Text: "hello", Texts: []string{"new text"}, Normalize: true})
+	require.NoError(t, err)
+	require.Len(t, second.Embeddings, 2)
+	assert.Equal(t, 1, second.CacheHits)
+	assert.InDeltaSlice(t, backend.Normalize(first.Embeddings[1]), second.Embeddings[0], 1e-9)
+
+	var norm float64
+	for _, v := range second.Embeddings[1] {
+		norm += v * v
+	}
+	assert.InDelta(t, 1.0, math.Sqrt(norm), 1e-9)
+
+	_, err = svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "embed-model"})
+	assert.Error(t, err)
+	_, err = svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "missing", Text: "hello"})
+	assert.Error(t, err)
+}
+
+func TestEmbedIndexesOnlyOnRequest(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"embed-model": {Name: "embed-model"},
+	})
+	saved := svc.vectorStore.(*stubVectorStore).saved
+
+	// 特征提取与预测都会计算向量，但不写入向量库
+	_, err := svc.ExtractFeatures(ctx, &model.FeatureExtractionRequest{ModelName: "embed-model", Text: "临时查询"})
+	require.NoError(t, err)
+	_, err = svc.Predict(ctx, &model.PredictRequest{ModelName: "embed-model", Data: map[string]interface{}{"text": "预测文本"}})
+	require.NoError(t, err)
+	assert.Empty(t, saved)
+
+	// 显式索引时保存未归一化的向量，缓存命中的文本同样写入
+	resp, err := svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "embed-model", Texts: []string{"临时查询", "新文本"}, Normalize: true, Index: true})
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	assert.InDeltaSlice(t, backend.Normalize(saved["临时查询"]), resp.Embeddings[0], 1e-9)
+	assert.Contains(t, saved, "新文本")
+}
+
+func TestInferenceStripsControlCharacters(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"embed-model": {Name: "embed-model"},
+	})
+
+	_, err := svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "embed-model", Text: "你好👋"})
+	require.NoError(t, err)
+	// 夹带控制字符的文本清理后与原文相同，命中同一条向量缓存
+	embedded, err := svc.Embed(ctx, &model.EmbeddingRequest{ModelName: "embed-model", Text: "你\x00好\x1b👋"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, embedded.CacheHits)
+
+	analyzed, err := svc.AnalyzeSentiment(ctx, &model.SentimentAnalysisRequest{ModelName: "embed-model", Text: "很\x00好\u0085用\n😀"})
+	require.NoError(t, err)
+	assert.Equal(t, "很好用\n😀", analyzed.Text)
+}
+
+func TestClassifyTextNeedsReview(t *testing.T) {
+	ctx := context.Background()
+	scores := &stubBackend{LocalBackend: backend.NewLocalBackend(16), scores: map[string]float64{
+		"垃圾信息": 0.7,
+	}}
+	svc, repo := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"lenient": {Name: "lenient", Metadata: `{"multi_label": true, "labels": ["垃圾信息"], "review_threshold": 0.6}`},
+		"strict":  {Name: "strict", Metadata: `{"multi_label": true, "labels": ["垃圾信息"], "review_threshold": 0.8}`},
+		"plain":   {Name: "plain", Metadata: `{"multi_label": true, "labels": ["垃圾信息"]}`},
+		// 单标签分类的置信度为 softmax 概率，总小于 1
+		"single": {Name: "single", Metadata: `{"review_threshold": 1.01}`},
+	}, scores)
+
+	resp, err := svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "lenient", Text: "加微信领优惠"})
+	require.NoError(t, err)
+	assert.False(t, resp.NeedsReview)
+
+	resp, err = svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "plain", Text: "加微信领优惠"})
+	require.NoError(t, err)
+	assert.False(t, resp.NeedsReview)
+	// 无需复核且未分流的请求不写推理记录
+	assert.Zero(t, repo.created)
+
+	resp, err = svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "strict", Text: "加微信领优惠"})
+	require.NoError(t, err)
+	assert.True(t, resp.NeedsReview)
+
+	resp, err = svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "single", Text: "hello"})
+	require.NoError(t, err)
+	assert.True(t, resp.NeedsReview)
+
+	reviews, err := svc.ListPendingReviews(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, "strict", reviews[0].ModelName)
+	assert.Equal(t, model.InferenceStatusNeedsReview, reviews[0].Status)
+	assert.Contains(t, reviews[0].InputData, "加微信领优惠")
+	assert.Equal(t, "single", reviews[1].ModelName)
+}
+
+func TestClassifyTextMultiLabel(t *testing.T) {
+	ctx := context.Background()
+	scores := &stubBackend{LocalBackend: backend.NewLocalBackend(16), scores: map[string]float64{
+		"垃圾信息": 0.9,
+		"广告":   0.75,
+		"色情":   0.2,
+	}}
+	svc, _ := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"multi":  {Name: "multi", Metadata: `{"multi_label": true, "labels": ["色情", "广告", "垃圾信息"]}`},
+		"strict": {Name: "strict", Metadata: `{"multi_label": true, "labels": ["色情", "广告", "垃圾信息"], "label_threshold": 0.95}`},
+		"single": {Name: "single"},
+	}, scores)
+
+	t.Run("labels above default threshold sorted by score", func(t *testing.T) {
+		resp, err := svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "multi", Text: "加微信领优惠"})
+		require.NoError(t, err)
+		assert.Equal(t, []model.LabelScore{{Label: "垃圾信息", Score: 0.9}, {Label: "广告", Score: 0.75}}, resp.Labels)
+		assert.Equal(t, "垃圾信息", resp.Result.(map[string]interface{})["class"])
+		assert.Equal(t, 0.9, resp.Confidence)
+	})
+
+	t.Run("no label above threshold", func(t *testing.T) {
+		resp, err := svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "strict", Text: "今天天气不错"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Labels)
+		assert.Equal(t, "", resp.Result.(map[string]interface{})["class"])
+		assert.Zero(t, resp.Confidence)
+	})
+
+	t.Run("single label models keep the class result", func(t *testing.T) {
+		resp, err := svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "single", Text: "hello"})
+		require.NoError(t, err)
+		assert.Nil(t, resp.Labels)
+		assert.Contains(t, resp.Result.(map[string]interface{}), "class")
+	})
+}
+
+func TestStreamBatchPredictStopsWhenCancelled(t *testing.T) {
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{"m": {Name: "m"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := make([]map[string]interface{}, 5)
+	for i := range data {
+		data[i] = map[string]interface{}{"value": i}
+	}
+
+	var requestIDs []string
+	err := svc.StreamBatchPredict(ctx, &model.BatchPredictRequest{ModelName: "m", Data: data}, func(prediction *model.PredictResponse) error {
+		requestIDs = append(requestIDs, prediction.RequestID)
+		// 模拟客户端在收到两条结果后断开
+		if len(requestIDs) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, requestIDs, 2)
+	assert.True(t, strings.HasSuffix(requestIDs[0], "_0"))
+	assert.True(t, strings.HasSuffix(requestIDs[1], "_1"))
+}
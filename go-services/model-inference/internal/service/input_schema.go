@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// inputSchemaCache 按 schema 原文缓存编译结果，模型元数据更新后 schema 原文变化即重新编译
+var inputSchemaCache sync.Map // map[string]*jsonschema.Schema
+
+// loadInputSchema 读取模型元数据中的 input_schema（JSON Schema），未配置时返回 nil
+func (s *inferenceService) loadInputSchema(ctx context.Context, modelName string) (*jsonschema.Schema, error) {
+	modelInfo, err := s.modelService.GetModel(ctx, modelName)
+	if err != nil || modelInfo == nil || modelInfo.Metadata == "" {
+		return nil, nil
+	}
+	var metadata struct {
+		InputSchema json.RawMessage `json:"input_schema"`
+	}
+	if err := json.Unmarshal([]byte(modelInfo.Metadata), &metadata); err != nil || len(metadata.InputSchema) == 0 || string(metadata.InputSchema) == "null" {
+		return nil, nil
+	}
+
+	raw := string(metadata.InputSchema)
+	if cached, ok := inputSchemaCache.Load(raw); ok {
+		return cached.(*jsonschema.Schema), nil
+	}
+	schema, err := jsonschema.CompileString(modelName+".input_schema.json", raw)
+	if err != nil {
+		return nil, fmt.Errorf("模型 %s 的 input_schema 无效: %w", modelName, err)
+	}
+	inputSchemaCache.Store(raw, schema)
+	return schema, nil
+}
+
+// validateInputs 按模型的输入 schema 校验预测数据，未配置 schema 时不校验。
+// 校验失败返回 ErrInvalidInput，Details 中的 errors 为字段级错误；indexed 为 true（批量请求）时字段位置以条目下标开头
+func (s *inferenceService) validateInputs(ctx context.Context, modelName string, inputs []map[string]interface{}, indexed bool) error {
+	schema, err := s.loadInputSchema(ctx, modelName)
+	if err != nil {
+		logrus.Warnf("%v，跳过输入校验", err)
+		return nil
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var fieldErrors []model.FieldError
+	for i, input := range inputs {
+		prefix := ""
+		if indexed {
+			prefix = fmt.Sprintf("/%d", i)
+		}
+		fieldErrors = append(fieldErrors, schemaFieldErrors(schema, input, prefix)...)
+	}
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return apperrors.New(apperrors.ErrInvalidInput, "输入数据不符合模型 %s 的 input_schema", modelName).
+		WithDetails(map[string]interface{}{"errors": fieldErrors})
+}
+
+// schemaFieldErrors 校验单条输入并展开为字段级错误，只保留最内层的具体原因
+func schemaFieldErrors(schema *jsonschema.Schema, input map[string]interface{}, prefix string) []model.FieldError {
+	// 统一为 json.Unmarshal 得到的类型，schema 校验不识别 int 等 Go 原生数值类型
+	raw, _ := json.Marshal(input)
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return []model.FieldError{{Field: prefix, Message: err.Error()}}
+	}
+
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []model.FieldError{{Field: prefix, Message: err.Error()}}
+	}
+
+	var fieldErrors []model.FieldError
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			fieldErrors = append(fieldErrors, model.FieldError{Field: prefix + e.InstanceLocation, Message: e.Message})
+			return
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Field < fieldErrors[j].Field
+	})
+	return fieldErrors
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+const schemaMetadata = `{"input_schema": {
+	"type": "object",
+	"required": ["text"],
+	"properties": {
+		"text": {"type": "string", "minLength": 1},
+		"lang": {"enum": ["zh", "en"]}
+	}
+}}`
+
+func TestPredictValidatesInputSchema(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestInferenceService(t, map[string]*model.Model{
+		"schema-model": {Name: "schema-model", Metadata: schemaMetadata},
+		"free-model":   {Name: "free-model"},
+	})
+
+	_, err := svc.Predict(ctx, &model.PredictRequest{ModelName: "schema-model", Data: map[string]interface{}{"text": "hello", "lang": "en"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.created)
+
+	_, err = svc.Predict(ctx, &model.PredictRequest{ModelName: "schema-model", Data: map[string]interface{}{"text": 42, "lang": "fr"}})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidInput))
+	fieldErrors := apperrors.Details(err)["errors"].([]model.FieldError)
+	require.Len(t, fieldErrors, 2)
+	assert.Equal(t, "/lang", fieldErrors[0].Field)
+	assert.Equal(t, "/text", fieldErrors[1].Field)
+	// 校验失败的请求不进入推理
+	assert.Equal(t, 1, repo.created)
+
+	_, err = svc.Predict(ctx, &model.PredictRequest{ModelName: "schema-model", Data: map[string]interface{}{"lang": "zh"}})
+	require.Error(t, err)
+	assert.Equal(t, []model.FieldError{{Field: "", Message: "missing properties: 'text'"}}, apperrors.Details(err)["errors"])
+
+	// 未定义 schema 的模型保持原有行为
+	_, err = svc.Predict(ctx, &model.PredictRequest{ModelName: "free-model", Data: map[string]interface{}{"anything": 1}})
+	require.NoError(t, err)
+}
+
+func TestBatchPredictValidatesInputSchema(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"schema-model": {Name: "schema-model", Metadata: schemaMetadata},
+	})
+
+	_, err := svc.BatchPredict(ctx, &model.BatchPredictRequest{ModelName: "schema-model", Data: []map[string]interface{}{
+		{"text": "ok"},
+		{"text": ""},
+	}})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidInput))
+	fieldErrors := apperrors.Details(err)["errors"].([]model.FieldError)
+	require.Len(t, fieldErrors, 1)
+	assert.Equal(t, "/1/text", fieldErrors[0].Field)
+
+	resp, err := svc.BatchPredict(ctx, &model.BatchPredictRequest{ModelName: "schema-model", Data: []map[string]interface{}{
+		{"text": "a"},
+		{"text": "b", "lang": "zh"},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, resp.Predictions, 2)
+}
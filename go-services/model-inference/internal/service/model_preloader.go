@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+)
+
+// ModelPreloadResult 单个模型的预加载结果
+type ModelPreloadResult struct {
+	Name       string `json:"name"`
+	Loaded     bool   `json:"loaded"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ModelPreloader 启动时按 PreloadConcurrency 并发加载 Preload 中的模型，避免部署后首批请求遇到冷启动。
+// 单个模型加载失败只记录结果，不影响其余模型；全部结束前 Done 返回 false
+type ModelPreloader struct {
+	modelService ModelService
+	names        []string
+	concurrency  int
+
+	mu      sync.Mutex
+	done    bool
+	results []ModelPreloadResult
+}
+
+// NewModelPreloader 创建模型预加载任务
+func NewModelPreloader(modelService ModelService, cfg config.ModelConfig) *ModelPreloader {
+	concurrency := cfg.PreloadConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results := make([]ModelPreloadResult, len(cfg.Preload))
+	for i, name := range cfg.Preload {
+		results[i].Name = name
+	}
+	return &ModelPreloader{
+		modelService: modelService,
+		names:        cfg.Preload,
+		concurrency:  concurrency,
+		results:      results,
+	}
+}
+
+// Run 预加载所有配置的模型，全部结束后返回按配置顺序排列的结果
+func (p *ModelPreloader) Run(ctx context.Context) []ModelPreloadResult {
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i, name := range p.names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				p.record(i, ModelPreloadResult{Name: name, Error: ctx.Err().Error()})
+				return
+			}
+
+			start := time.Now()
+			err := p.modelService.LoadModelAndWait(ctx, name)
+			result := ModelPreloadResult{Name: name, Loaded: err == nil, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Error = err.Error()
+				logrus.WithError(err).WithField("model_name", name).Error("预加载模型失败")
+			} else {
+				logrus.WithFields(logrus.Fields{"model_name": name, "duration_ms": result.DurationMs}).Info("预加载模型完成")
+			}
+			p.record(i, result)
+		}(i, name)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	p.done = true
+	results := append([]ModelPreloadResult(nil), p.results...)
+	p.mu.Unlock()
+
+	failed := 0
+	for _, result := range results {
+		if !result.Loaded {
+			failed++
+		}
+	}
+	logrus.WithFields(logrus.Fields{"total": len(results), "failed": failed}).Info("模型预加载结束")
+	return results
+}
+
+func (p *ModelPreloader) record(i int, result ModelPreloadResult) {
+	p.mu.Lock()
+	p.results[i] = result
+	p.mu.Unlock()
+}
+
+// Status 返回预加载是否结束及各模型目前的结果，尚未加载完成的模型 Loaded 为 false 且没有 Error
+func (p *ModelPreloader) Status() (bool, []ModelPreloadResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, append([]ModelPreloadResult(nil), p.results...)
+}
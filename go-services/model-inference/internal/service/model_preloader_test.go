@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// concurrencyBackend 记录同时进行中的最大加载数，加载等待 gate 放行
+type concurrencyBackend struct {
+	*backend.LocalBackend
+	gate         chan struct{}
+	active, peak int32
+}
+
+func (b *concurrencyBackend) LoadModel(ctx context.Context, modelName, path string, progress func(percent float64)) (int64, error) {
+	active := atomic.AddInt32(&b.active, 1)
+	defer atomic.AddInt32(&b.active, -1)
+	for {
+		peak := atomic.LoadInt32(&b.peak)
+		if active <= peak || atomic.CompareAndSwapInt32(&b.peak, peak, active) {
+			break
+		}
+	}
+	<-b.gate
+	return b.LocalBackend.LoadModel(ctx, modelName, path, progress)
+}
+
+func TestModelPreloaderReadyAfterConfiguredModelsLoad(t *testing.T) {
+	ctx := context.Background()
+	storage := t.TempDir()
+	for _, name := range []string{"spam", "ham", "toxic"} {
+		require.NoError(t, os.WriteFile(filepath.Join(storage, name+".bin"), []byte(name), 0644))
+	}
+
+	cacheRepo, mr := newTestCacheRepo(t)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"spam":   {Name: "spam", FilePath: "spam.bin"},
+		"ham":    {Name: "ham", FilePath: "ham.bin"},
+		"toxic":  {Name: "toxic", FilePath: "toxic.bin"},
+		"broken": {Name: "broken", FilePath: "missing.bin"},
+	}}
+	gated := &concurrencyBackend{LocalBackend: backend.NewLocalBackend(16), gate: make(chan struct{})}
+	cfg := config.ModelConfig{
+		StoragePath:        storage,
+		CacheTTL:           60,
+		MaxLoadedModels:    5,
+		LoadTimeout:        5,
+		Preload:            []string{"spam", "broken", "ham", "unknown", "toxic"},
+		PreloadConcurrency: 2,
+	}
+	svc := NewModelService(repo, cacheRepo, gated, cfg)
+	preloader := NewModelPreloader(svc, cfg)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	health := NewHealthService(db, redisClient, NewBackendWatchdog(svc, repo, gated, cfg), preloader)
+
+	results := make(chan []ModelPreloadResult, 1)
+	go func() { results <- preloader.Run(ctx) }()
+
+	// 预加载结束前就绪检查报告未完成。miniredis 不支持 INFO server，整体状态始终为 unhealthy，这里只检查预加载部分
+	ready := health.Ready(ctx)
+	assert.NotEqual(t, "healthy", ready.Status)
+	preload := ready.Services["preload"].(map[string]interface{})
+	assert.Equal(t, false, preload["done"])
+	assert.Equal(t, "模型预加载中", preload["message"])
+	assert.NotContains(t, health.Health(ctx).Services, "preload")
+
+	close(gated.gate)
+	var got []ModelPreloadResult
+	select {
+	case got = <-results:
+	case <-time.After(5 * time.Second):
+		t.Fatal("preload did not finish")
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&gated.peak), int32(2))
+
+	preload = health.Ready(ctx).Services["preload"].(map[string]interface{})
+	require.Equal(t, true, preload["done"])
+	for _, name := range []string{"spam", "ham", "toxic"} {
+		assert.True(t, svc.IsModelLoaded(name), name)
+	}
+
+	// 结果按配置顺序排列，失败的模型不影响其余模型
+	require.Len(t, got, 5)
+	for i, name := range cfg.Preload {
+		assert.Equal(t, name, got[i].Name)
+	}
+	assert.True(t, got[0].Loaded)
+	assert.False(t, got[1].Loaded)
+	assert.Contains(t, got[1].Error, "模型文件不存在")
+	assert.True(t, got[2].Loaded)
+	assert.False(t, got[3].Loaded)
+	assert.Contains(t, got[3].Error, "不存在")
+	assert.True(t, got[4].Loaded)
+
+	assert.Equal(t, "2 个模型预加载失败", preload["message"])
+	assert.Equal(t, got, preload["models"])
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,118 +11,232 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
 )
 
+// ErrModelInUse 模型仍有进行中的推理请求，无法卸载
+var ErrModelInUse = apperrors.New(apperrors.ErrConflict, "模型正在使用中")
+
+// ErrModelLoading 模型正在加载，重复的加载请求被拒绝
+var ErrModelLoading = apperrors.New(apperrors.ErrConflict, "模型正在加载")
+
+// ErrModelUnloading 模型正在卸载，同时到达的加载与重新加载请求被拒绝
+var ErrModelUnloading = apperrors.New(apperrors.ErrConflict, "模型正在卸载")
+
+// defaultUnloadTimeout 卸载模型时等待进行中推理结束的最长时间
+const defaultUnloadTimeout = 10 * time.Second
+
 // ModelService 模型服务接口
 type ModelService interface {
 	LoadModel(ctx context.Context, name string, force bool) error
+	LoadModelAndWait(ctx context.Context, name string) error
 	UnloadModel(ctx context.Context, name string) error
+	ReloadModel(ctx context.Context, name string) error
 	GetModel(ctx context.Context, name string) (*model.Model, error)
 	ListModels(ctx context.Context, limit, offset int) ([]*model.Model, error)
 	ListModelsByType(ctx context.Context, modelType model.ModelType, limit, offset int) ([]*model.Model, error)
 	GetModelStatus(ctx context.Context, name string) (*model.ModelStatusResponse, error)
+	GetModelStatuses(ctx context.Context, names []string) ([]*model.ModelStatusResponse, error)
 	GetStatistics(ctx context.Context) (*model.ModelStatistics, error)
 	IsModelLoaded(name string) bool
+	AcquireModel(name string) (release func(), err error)
 	GetLoadedModels() []string
+	UpdateConfig(cfg config.ModelConfig)
 }
 
 // modelService 模型服务实现
 type modelService struct {
 	modelRepo   repository.ModelRepository
 	cacheRepo   repository.CacheRepository
+	backend     backend.InferenceBackend
 	config      config.ModelConfig
 	loadedModels sync.Map // 存储已加载的模型
 	mu          sync.RWMutex
+	unloadTimeout time.Duration
+
+	loadMu  sync.Mutex
+	loading map[string]float64 // 正在加载的模型及其加载进度，与已加载模型一起计入数量上限
+	// unloading 正在卸载的模型，与 loading 互斥：加载或重新加载期间不能卸载，反之亦然
+	unloading map[string]struct{}
 }
 
 // NewModelService 创建模型服务
-func NewModelService(modelRepo repository.ModelRepository, cacheRepo repository.CacheRepository, cfg config.ModelConfig) ModelService {
+func NewModelService(modelRepo repository.ModelRepository, cacheRepo repository.CacheRepository, inferenceBackend backend.InferenceBackend, cfg config.ModelConfig) ModelService {
 	return &modelService{
 		modelRepo: modelRepo,
 		cacheRepo: cacheRepo,
+		backend:   inferenceBackend,
 		config:    cfg,
+		unloadTimeout: defaultUnloadTimeout,
+		loading:       make(map[string]float64),
+		unloading:     make(map[string]struct{}),
 	}
 }
 
-// LoadModel 加载模型
+// UpdateConfig 热更新模型配置
+func (s *modelService) UpdateConfig(cfg config.ModelConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = cfg
+}
+
+// cfg 获取当前模型配置
+func (s *modelService) cfg() config.ModelConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// LoadModel 加载模型，校验通过后由推理后端异步加载
 func (s *modelService) LoadModel(ctx context.Context, name string, force bool) error {
-	// 检查模型是否已加载
-	if !force && s.IsModelLoaded(name) {
-		return fmt.Errorf("模型 %s 已经加载", name)
+	modelInfo, modelPath, err := s.prepareLoad(name, force)
+	if err != nil {
+		return err
 	}
 
+	go func() {
+		defer s.finishLoad(name)
+		if err := s.runLoad(name, modelInfo, modelPath); err != nil {
+			logrus.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+// LoadModelAndWait 同步加载模型，加载完成或失败后返回
+func (s *modelService) LoadModelAndWait(ctx context.Context, name string) error {
+	modelInfo, modelPath, err := s.prepareLoad(name, false)
+	if err != nil {
+		return err
+	}
+	defer s.finishLoad(name)
+	return s.runLoad(name, modelInfo, modelPath)
+}
+
+// prepareLoad 登记加载中的模型，校验模型信息与文件并将状态置为加载中。
+// 成功时由调用方在加载结束后调用 finishLoad，失败时已撤销登记
+func (s *modelService) prepareLoad(name string, force bool) (*model.Model, string, error) {
+	// 原子地检查并登记加载中的模型，同一模型同时只允许一个加载
+	if err := s.reserveLoad(name, force); err != nil {
+		return nil, "", err
+	}
+	prepared := false
+	defer func() {
+		if !prepared {
+			s.finishLoad(name)
+		}
+	}()
+
 	// 获取模型信息
 	modelInfo, err := s.modelRepo.GetByName(name)
 	if err != nil {
-		return fmt.Errorf("获取模型信息失败: %w", err)
+		return nil, "", fmt.Errorf("获取模型信息失败: %w", err)
 	}
 	if modelInfo == nil {
-		return fmt.Errorf("模型 %s 不存在", name)
+		return nil, "", apperrors.New(apperrors.ErrNotFound, "模型 %s 不存在", name)
 	}
 
 	// 检查模型文件是否存在
-	modelPath := filepath.Join(s.config.StoragePath, modelInfo.FilePath)
+	modelPath := filepath.Join(s.cfg().StoragePath, modelInfo.FilePath)
 	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
-		return fmt.Errorf("模型文件不存在: %s", modelPath)
-	}
-
-	// 检查已加载模型数量限制
-	if err := s.checkLoadedModelsLimit(); err != nil {
-		return err
+		return nil, "", apperrors.New(apperrors.ErrNotFound, "模型文件不存在: %s", modelPath)
 	}
 
 	// 更新模型状态为加载中
 	if err := s.modelRepo.UpdateStatus(name, model.ModelStatusLoading); err != nil {
-		return fmt.Errorf("更新模型状态失败: %w", err)
+		return nil, "", fmt.Errorf("更新模型状态失败: %w", err)
 	}
 
-	// 模拟模型加载过程（实际项目中这里会加载真实的模型）
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logrus.Errorf("加载模型 %s 时发生panic: %v", name, r)
-				s.modelRepo.UpdateStatus(name, model.ModelStatusError)
-			}
-		}()
+	prepared = true
+	return modelInfo, modelPath, nil
+}
 
-		// 模拟加载时间
-		time.Sleep(2 * time.Second)
+// runLoad 由推理后端加载模型文件并标记为已加载，失败时将状态置为 error
+func (s *modelService) runLoad(name string, modelInfo *model.Model, modelPath string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.modelRepo.UpdateStatus(name, model.ModelStatusError)
+			err = fmt.Errorf("加载模型 %s 时发生panic: %v", name, r)
+		}
+	}()
 
-		// 将模型标记为已加载
-		now := time.Now()
-		s.loadedModels.Store(name, &LoadedModel{
-			Name:     name,
-			Type:     modelInfo.Type,
-			LoadedAt: now,
-			FilePath: modelPath,
-		})
+	loadCtx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg().LoadTimeout)*time.Second)
+	defer cancel()
+	memoryBytes, err := s.backend.LoadModel(loadCtx, name, modelPath, func(percent float64) {
+		s.setLoadProgress(name, percent)
+	})
+	if err != nil {
+		metrics.ModelLoadProgress.DeleteLabelValues(name)
+		s.modelRepo.UpdateStatus(name, model.ModelStatusError)
+		return fmt.Errorf("加载模型 %s 失败: %w", name, err)
+	}
 
-		// 更新数据库状态
-		s.modelRepo.UpdateStatus(name, model.ModelStatusLoaded)
-		s.modelRepo.UpdateLoadedAt(name, &now)
+	// 将模型标记为已加载
+	now := time.Now()
+	s.loadedModels.Store(name, &LoadedModel{
+		Name:        name,
+		Type:        modelInfo.Type,
+		LoadedAt:    now,
+		FilePath:    modelPath,
+		MemoryBytes: memoryBytes,
+	})
+	metrics.ModelMemoryBytes.WithLabelValues(name).Set(float64(memoryBytes))
 
-		// 缓存模型信息
-		cacheKey := fmt.Sprintf("model:%s", name)
-		s.cacheRepo.Set(context.Background(), cacheKey, modelInfo, time.Duration(s.config.CacheTTL)*time.Second)
+	// 更新数据库状态
+	s.modelRepo.UpdateStatus(name, model.ModelStatusLoaded)
+	s.modelRepo.UpdateLoadedAt(name, &now)
 
-		logrus.Infof("模型 %s 加载成功", name)
-	}()
+	// 缓存模型信息
+	cacheKey := fmt.Sprintf("model:%s", name)
+	s.cacheRepo.Set(context.Background(), cacheKey, modelInfo, time.Duration(s.cfg().CacheTTL)*time.Second)
 
+	logrus.Infof("模型 %s 加载成功", name)
 	return nil
 }
 
-// UnloadModel 卸载模型
+// UnloadModel 卸载模型，等待进行中的推理结束，超时返回 ErrModelInUse；
+// 模型正在加载或重新加载时返回 ErrModelLoading，已在卸载时返回 ErrModelUnloading
 func (s *modelService) UnloadModel(ctx context.Context, name string) error {
+	if err := s.reserveUnload(name); err != nil {
+		return err
+	}
+	defer s.finishUnload(name)
+
 	// 检查模型是否已加载
-	if !s.IsModelLoaded(name) {
-		return fmt.Errorf("模型 %s 未加载", name)
+	value, ok := s.loadedModels.Load(name)
+	if !ok {
+		return apperrors.New(apperrors.ErrModelNotLoaded, "模型 %s 未加载", name)
+	}
+	lm := value.(*LoadedModel)
+
+	// 拒绝新的推理请求，并等待已有请求释放模型
+	drained, err := lm.beginUnload()
+	if err != nil {
+		return err
+	}
+	timer := time.NewTimer(s.unloadTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+		lm.cancelUnload()
+		return fmt.Errorf("%w: 模型 %s 仍有 %d 个推理请求", ErrModelInUse, name, lm.refCount())
+	case <-ctx.Done():
+		lm.cancelUnload()
+		return ctx.Err()
 	}
 
 	// 从内存中移除模型
 	s.loadedModels.Delete(name)
+	s.backend.UnloadModel(name)
+	metrics.ModelMemoryBytes.DeleteLabelValues(name)
+	metrics.ModelLoadProgress.DeleteLabelValues(name)
 
 	// 更新模型状态
 	if err := s.modelRepo.UpdateStatus(name, model.ModelStatusUnloaded); err != nil {
@@ -141,13 +256,114 @@ func (s *modelService) UnloadModel(ctx context.Context, name string) error {
 	return nil
 }
 
+// reloadShadowSuffix 重新加载时新版本权重在推理后端中的临时名称后缀
+const reloadShadowSuffix = "@reload"
+
+// reloadWarmupText 新版本替换前用于预热的样本文本
+const reloadWarmupText = "模型预热"
+
+// ReloadModel 不停机地重新加载已加载的模型：新版本先加载到影子位置并预热，
+// 成功后原子地替换旧版本，整个过程中推理请求始终可用；失败时旧版本继续服务。
+// 加载期间内存中同时存在新旧两份权重
+func (s *modelService) ReloadModel(ctx context.Context, name string) error {
+	value, ok := s.loadedModels.Load(name)
+	if !ok {
+		return apperrors.New(apperrors.ErrModelNotLoaded, "模型 %s 未加载", name)
+	}
+	current := value.(*LoadedModel)
+
+	if err := s.reserveLoad(name, true); err != nil {
+		return err
+	}
+	defer s.finishLoad(name)
+
+	modelInfo, err := s.modelRepo.GetByName(name)
+	if err != nil {
+		return fmt.Errorf("获取模型信息失败: %w", err)
+	}
+	if modelInfo == nil {
+		return apperrors.New(apperrors.ErrNotFound, "模型 %s 不存在", name)
+	}
+	modelPath := filepath.Join(s.cfg().StoragePath, modelInfo.FilePath)
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		return apperrors.New(apperrors.ErrNotFound, "模型文件不存在: %s", modelPath)
+	}
+
+	// 加载新版本到影子位置
+	shadow := name + reloadShadowSuffix
+	loadCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg().LoadTimeout)*time.Second)
+	defer cancel()
+	memoryBytes, err := s.backend.LoadModel(loadCtx, shadow, modelPath, func(percent float64) {
+		s.setLoadProgress(name, percent)
+	})
+	if err != nil {
+		s.backend.UnloadModel(shadow)
+		return fmt.Errorf("加载模型新版本失败: %w", err)
+	}
+
+	// 预热新版本，失败时丢弃新版本
+	if err := s.warmup(loadCtx, shadow, current.Type); err != nil {
+		s.backend.UnloadModel(shadow)
+		return fmt.Errorf("预热模型新版本失败: %w", err)
+	}
+
+	// 加载期间卸载被拒绝，这里再确认替换的仍是开始时的版本
+	if value, ok := s.loadedModels.Load(name); !ok || value.(*LoadedModel) != current {
+		s.backend.UnloadModel(shadow)
+		return apperrors.New(apperrors.ErrConflict, "模型 %s 在重新加载期间已被卸载或替换", name)
+	}
+
+	// 原子替换：后端切换权重后再替换 LoadedModel，进行中的请求在旧记录上正常释放
+	if err := s.backend.PromoteModel(shadow, name); err != nil {
+		s.backend.UnloadModel(shadow)
+		return fmt.Errorf("替换模型版本失败: %w", err)
+	}
+	now := time.Now()
+	s.loadedModels.Store(name, &LoadedModel{
+		Name:        name,
+		Type:        current.Type,
+		LoadedAt:    now,
+		FilePath:    modelPath,
+		MemoryBytes: memoryBytes,
+	})
+	metrics.ModelMemoryBytes.WithLabelValues(name).Set(float64(memoryBytes))
+
+	s.modelRepo.UpdateLoadedAt(name, &now)
+	cacheKey := fmt.Sprintf("model:%s", name)
+	s.cacheRepo.Set(context.Background(), cacheKey, modelInfo, time.Duration(s.cfg().CacheTTL)*time.Second)
+
+	logrus.Infof("模型 %s 重新加载成功", name)
+	return nil
+}
+
+// warmup 按模型类型调用对应的推理接口预热模型：分类模型计算 logits，文本分析模型识别实体，其余计算向量
+func (s *modelService) warmup(ctx context.Context, name string, modelType model.ModelType) error {
+	var err error
+	switch modelType {
+	case model.ModelTypeClassification:
+		_, err = s.backend.Logits(ctx, name, reloadWarmupText, 2)
+	case model.ModelTypeTextAnalysis:
+		_, err = s.backend.ExtractEntities(ctx, name, reloadWarmupText)
+	default:
+		_, err = s.backend.Embed(ctx, name, []string{reloadWarmupText})
+	}
+	return err
+}
+
 // GetModel 获取模型信息
 func (s *modelService) GetModel(ctx context.Context, name string) (*model.Model, error) {
 	// 先从缓存获取
 	cacheKey := fmt.Sprintf("model:%s", name)
 	var cachedModel model.Model
-	if err := s.cacheRepo.Get(ctx, cacheKey, &cachedModel); err == nil {
+	err := s.cacheRepo.Get(ctx, cacheKey, &cachedModel)
+	switch {
+	case err == nil:
 		return &cachedModel, nil
+	case errors.Is(err, repository.ErrCacheMiss):
+		// 未缓存，回源数据库
+	default:
+		// 缓存不可用或数据损坏时仍回源数据库，并重新写入缓存
+		logrus.Warnf("读取模型 %s 缓存失败: %v", name, err)
 	}
 
 	// 从数据库获取
@@ -156,11 +372,13 @@ func (s *modelService) GetModel(ctx context.Context, name string) (*model.Model,
 		return nil, fmt.Errorf("获取模型信息失败: %w", err)
 	}
 
-	if modelInfo != nil {
-		// 缓存模型信息
-		s.cacheRepo.Set(ctx, cacheKey, modelInfo, time.Duration(s.config.CacheTTL)*time.Second)
+	if modelInfo == nil {
+		return nil, apperrors.New(apperrors.ErrNotFound, "模型 %s 不存在", name)
 	}
 
+	// 缓存模型信息
+	s.cacheRepo.Set(ctx, cacheKey, modelInfo, time.Duration(s.cfg().CacheTTL)*time.Second)
+
 	return modelInfo, nil
 }
 
@@ -181,9 +399,42 @@ func (s *modelService) GetModelStatus(ctx context.Context, name string) (*model.
 		return nil, err
 	}
 	if modelInfo == nil {
-		return nil, fmt.Errorf("模型 %s 不存在", name)
+		return nil, apperrors.New(apperrors.ErrNotFound, "模型 %s 不存在", name)
 	}
+	return s.modelStatus(modelInfo), nil
+}
 
+// GetModelStatuses 批量获取模型状态，数据库中的模型信息一次查询取回，结果顺序与 names 一致。
+// 不存在的模型以 not_found 状态返回，不影响其余模型
+func (s *modelService) GetModelStatuses(ctx context.Context, names []string) ([]*model.ModelStatusResponse, error) {
+	models, err := s.modelRepo.ListByNames(names)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取模型信息失败: %w", err)
+	}
+	byName := make(map[string]*model.Model, len(models))
+	for _, m := range models {
+		byName[m.Name] = m
+	}
+
+	statuses := make([]*model.ModelStatusResponse, len(names))
+	for i, name := range names {
+		modelInfo, ok := byName[name]
+		if !ok {
+			statuses[i] = &model.ModelStatusResponse{
+				Name:   name,
+				Status: model.ModelStatusNotFound,
+				Error:  fmt.Sprintf("模型 %s 不存在", name),
+			}
+			continue
+		}
+		statuses[i] = s.modelStatus(modelInfo)
+	}
+	return statuses, nil
+}
+
+// modelStatus 以数据库中的模型信息为基础，补充已加载模型的加载信息与加载中模型的进度
+func (s *modelService) modelStatus(modelInfo *model.Model) *model.ModelStatusResponse {
+	name := modelInfo.Name
 	response := &model.ModelStatusResponse{
 		Name:     modelInfo.Name,
 		Status:   modelInfo.Status,
@@ -195,13 +446,26 @@ func (s *modelService) GetModelStatus(ctx context.Context, name string) (*model.
 		if lm, ok := loadedModel.(*LoadedModel); ok {
 			response.LoadedAt = &lm.LoadedAt
 			response.Metadata = map[string]interface{}{
-				"file_path": lm.FilePath,
-				"type":      lm.Type,
+				"file_path":     lm.FilePath,
+				"type":          lm.Type,
+				"memory_bytes":  lm.MemoryBytes,
+				"load_progress": 100.0,
 			}
 		}
 	}
 
-	return response, nil
+	// 加载中的模型返回当前进度
+	s.loadMu.Lock()
+	progress, loading := s.loading[name]
+	s.loadMu.Unlock()
+	if loading {
+		response.Status = model.ModelStatusLoading
+		response.Metadata = map[string]interface{}{
+			"load_progress": progress,
+		}
+	}
+
+	return response
 }
 
 // GetStatistics 获取模型统计信息
@@ -215,6 +479,18 @@ func (s *modelService) IsModelLoaded(name string) bool {
 	return loaded
 }
 
+// AcquireModel 占用已加载的模型，调用方在推理结束后必须调用 release
+func (s *modelService) AcquireModel(name string) (func(), error) {
+	value, ok := s.loadedModels.Load(name)
+	if !ok || !value.(*LoadedModel).acquire() {
+		return nil, apperrors.New(apperrors.ErrModelNotLoaded, "模型 %s 未加载", name)
+	}
+	lm := value.(*LoadedModel)
+
+	var once sync.Once
+	return func() { once.Do(lm.release) }, nil
+}
+
 // GetLoadedModels 获取已加载的模型列表
 func (s *modelService) GetLoadedModels() []string {
 	var models []string
@@ -227,16 +503,85 @@ func (s *modelService) GetLoadedModels() []string {
 	return models
 }
 
-// checkLoadedModelsLimit 检查已加载模型数量限制
+// reserveLoad 检查是否可以加载并登记为加载中，调用方完成后须调用 finishLoad
+func (s *modelService) reserveLoad(name string, force bool) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	if _, loading := s.loading[name]; loading {
+		return fmt.Errorf("%w: %s", ErrModelLoading, name)
+	}
+	if _, unloading := s.unloading[name]; unloading {
+		return fmt.Errorf("%w: %s", ErrModelUnloading, name)
+	}
+	loaded := s.IsModelLoaded(name)
+	if loaded && !force {
+		return apperrors.New(apperrors.ErrConflict, "模型 %s 已经加载", name)
+	}
+
+	// 强制重新加载已加载的模型不增加占用数量
+	if !loaded {
+		if err := s.checkLoadedModelsLimit(); err != nil {
+			return err
+		}
+	}
+
+	s.loading[name] = 0
+	return nil
+}
+
+// setLoadProgress 更新加载中模型的进度
+func (s *modelService) setLoadProgress(name string, percent float64) {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	if _, loading := s.loading[name]; loading {
+		s.loading[name] = percent
+	}
+	metrics.ModelLoadProgress.WithLabelValues(name).Set(percent)
+}
+
+// finishLoad 取消模型的加载中登记
+func (s *modelService) finishLoad(name string) {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	delete(s.loading, name)
+}
+
+// reserveUnload 登记卸载中的模型，模型正在加载或已在卸载时拒绝
+func (s *modelService) reserveUnload(name string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	if _, loading := s.loading[name]; loading {
+		return fmt.Errorf("%w: %s", ErrModelLoading, name)
+	}
+	if _, unloading := s.unloading[name]; unloading {
+		return fmt.Errorf("%w: %s", ErrModelUnloading, name)
+	}
+	s.unloading[name] = struct{}{}
+	return nil
+}
+
+// finishUnload 撤销卸载中模型的登记
+func (s *modelService) finishUnload(name string) {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	delete(s.unloading, name)
+}
+
+// checkLoadedModelsLimit 检查已加载及加载中的模型数量限制，调用方须持有 loadMu
 func (s *modelService) checkLoadedModelsLimit() error {
-	loadedCount := 0
+	loadedCount := len(s.loading)
 	s.loadedModels.Range(func(key, value interface{}) bool {
-		loadedCount++
+		if _, loading := s.loading[key.(string)]; !loading {
+			loadedCount++
+		}
 		return true
 	})
 
-	if loadedCount >= s.config.MaxLoadedModels {
-		return fmt.Errorf("已加载模型数量达到上限 %d", s.config.MaxLoadedModels)
+	maxLoadedModels := s.cfg().MaxLoadedModels
+	if loadedCount >= maxLoadedModels {
+		return apperrors.New(apperrors.ErrConflict, "已加载模型数量达到上限 %d", maxLoadedModels)
 	}
 
 	return nil
@@ -248,4 +593,65 @@ type LoadedModel struct {
 	Type     model.ModelType
 	LoadedAt time.Time
 	FilePath string
+	// MemoryBytes 模型常驻内存字节数，由推理后端在加载时报告
+	MemoryBytes int64
+
+	mu        sync.Mutex
+	refs      int           // 进行中的推理请求数
+	unloading bool          // 卸载中时不再接受新的推理请求
+	drained   chan struct{} // 卸载中且引用数归零时关闭
+}
+
+// acquire 增加引用计数，模型卸载中时返回 false
+func (m *LoadedModel) acquire() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unloading {
+		return false
+	}
+	m.refs++
+	return true
+}
+
+// release 减少引用计数
+func (m *LoadedModel) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refs--
+	if m.refs == 0 && m.drained != nil {
+		close(m.drained)
+		m.drained = nil
+	}
+}
+
+// beginUnload 标记为卸载中，返回引用数归零时关闭的通道
+func (m *LoadedModel) beginUnload() (<-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unloading {
+		return nil, apperrors.New(apperrors.ErrConflict, "模型 %s 正在卸载", m.Name)
+	}
+	m.unloading = true
+
+	drained := make(chan struct{})
+	if m.refs == 0 {
+		close(drained)
+	} else {
+		m.drained = drained
+	}
+	return drained, nil
+}
+
+// cancelUnload 等待超时后恢复接受推理请求
+func (m *LoadedModel) cancelUnload() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unloading = false
+	m.drained = nil
+}
+
+func (m *LoadedModel) refCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.refs
 }
\ No newline at end of file
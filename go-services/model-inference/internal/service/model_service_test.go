@@ -0,0 +1,504 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
+)
+
+// stubModelRepository 只实现测试用到的方法，其余方法调用时会 panic
+type stubModelRepository struct {
+	repository.ModelRepository
+	models  map[string]*model.Model
+	mu      sync.Mutex
+	lookups int
+	// batchLookups ListByNames 的调用次数
+	batchLookups int
+	loads        int // 状态被置为加载中的次数
+	// statuses 各模型最近一次更新的状态
+	statuses map[string]model.ModelStatus
+}
+
+func (r *stubModelRepository) GetByName(name string) (*model.Model, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	return r.models[name], nil
+}
+
+func (r *stubModelRepository) ListByNames(names []string) ([]*model.Model, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batchLookups++
+	var models []*model.Model
+	for _, name := range names {
+		if m, ok := r.models[name]; ok {
+			models = append(models, m)
+		}
+	}
+	return models, nil
+}
+
+func (r *stubModelRepository) UpdateStatus(name string, status model.ModelStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if status == model.ModelStatusLoading {
+		r.loads++
+	}
+	if r.statuses == nil {
+		r.statuses = make(map[string]model.ModelStatus)
+	}
+	r.statuses[name] = status
+	return nil
+}
+
+func (r *stubModelRepository) UpdateLoadedAt(name string, loadedAt *time.Time) error {
+	return nil
+}
+
+// gatedBackend 在加载模型前报告一半进度，并等待 delay 或 gate 放行
+type gatedBackend struct {
+	*backend.LocalBackend
+	delay time.Duration
+	gate  chan struct{}
+}
+
+func (b *gatedBackend) LoadModel(ctx context.Context, modelName, path string, progress func(percent float64)) (int64, error) {
+	progress(50)
+	if b.gate != nil {
+		<-b.gate
+	}
+	time.Sleep(b.delay)
+	return b.LocalBackend.LoadModel(ctx, modelName, path, progress)
+}
+
+func newTestCacheRepo(t *testing.T) (repository.CacheRepository, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return repository.NewCacheRepository(client), mr
+}
+
+func TestGetModelCachePaths(t *testing.T) {
+	ctx := context.Background()
+	cacheRepo, mr := newTestCacheRepo(t)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"spam": {Name: "spam", Type: model.ModelTypeClassification, Version: "1.0"},
+	}}
+	svc := NewModelService(repo, cacheRepo, backend.NewLocalBackend(16), config.ModelConfig{CacheTTL: 60})
+
+	// 未缓存时回源数据库并写入缓存
+	got, err := svc.GetModel(ctx, "spam")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "1.0", got.Version)
+	assert.Equal(t, 1, repo.lookups)
+
+	// 命中缓存时不再访问数据库
+	got, err = svc.GetModel(ctx, "spam")
+	require.NoError(t, err)
+	assert.Equal(t, "spam", got.Name)
+	assert.Equal(t, 1, repo.lookups)
+
+	// 缓存数据损坏时回源数据库
+	require.NoError(t, mr.Set("model:spam", "{corrupt"))
+	got, err = svc.GetModel(ctx, "spam")
+	require.NoError(t, err)
+	assert.Equal(t, "spam", got.Name)
+	assert.Equal(t, 2, repo.lookups)
+
+	// 不存在的模型不会返回空的缓存对象
+	mr.FastForward(2 * time.Minute)
+	got, err = svc.GetModel(ctx, "unknown")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	assert.Nil(t, got)
+}
+
+// newTestLoadedModelService 返回已直接加载指定模型的服务，跳过异步加载流程
+func newTestLoadedModelService(t *testing.T, names ...string) *modelService {
+	cacheRepo, _ := newTestCacheRepo(t)
+	svc := NewModelService(&stubModelRepository{}, cacheRepo, backend.NewLocalBackend(16), config.ModelConfig{CacheTTL: 60, MaxLoadedModels: 10}).(*modelService)
+	for _, name := range names {
+		svc.loadedModels.Store(name, &LoadedModel{Name: name, LoadedAt: time.Now()})
+	}
+	return svc
+}
+
+func TestUnloadModelWaitsForInFlightRequests(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestLoadedModelService(t, "busy")
+
+	var wg sync.WaitGroup
+	var served int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := svc.AcquireModel("busy")
+			if err != nil {
+				// 卸载开始后新请求被拒绝
+				return
+			}
+			defer release()
+			atomic.AddInt32(&served, 1)
+			time.Sleep(20 * time.Millisecond)
+			// 持有引用期间模型不会被移除
+			assert.True(t, svc.IsModelLoaded("busy"))
+		}()
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, svc.UnloadModel(ctx, "busy"))
+	wg.Wait()
+
+	assert.Positive(t, atomic.LoadInt32(&served))
+	assert.False(t, svc.IsModelLoaded("busy"))
+	_, err := svc.AcquireModel("busy")
+	assert.Error(t, err)
+}
+
+func TestUnloadModelTimesOutWhenInUse(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestLoadedModelService(t, "stuck")
+	svc.unloadTimeout = 10 * time.Millisecond
+
+	release, err := svc.AcquireModel("stuck")
+	require.NoError(t, err)
+
+	err = svc.UnloadModel(ctx, "stuck")
+	assert.ErrorIs(t, err, ErrModelInUse)
+	assert.True(t, svc.IsModelLoaded("stuck"))
+
+	// 超时后恢复接受请求，释放后可正常卸载
+	again, err := svc.AcquireModel("stuck")
+	require.NoError(t, err)
+	again()
+	release()
+	release() // 重复释放无副作用
+	require.NoError(t, svc.UnloadModel(ctx, "stuck"))
+	assert.False(t, svc.IsModelLoaded("stuck"))
+}
+
+func TestLoadModelConcurrentCallsLoadOnce(t *testing.T) {
+	ctx := context.Background()
+	storage := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "spam.bin"), []byte("weights"), 0644))
+
+	cacheRepo, _ := newTestCacheRepo(t)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"spam": {Name: "spam", FilePath: "spam.bin"},
+	}}
+	slow := &gatedBackend{LocalBackend: backend.NewLocalBackend(16), delay: 20 * time.Millisecond}
+	svc := NewModelService(repo, cacheRepo, slow, config.ModelConfig{StoragePath: storage, CacheTTL: 60, MaxLoadedModels: 1, LoadTimeout: 5}).(*modelService)
+
+	var wg sync.WaitGroup
+	var started, rejected int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := svc.LoadModel(ctx, "spam", false)
+			switch {
+			case err == nil:
+				atomic.AddInt32(&started, 1)
+			case errors.Is(err, ErrModelLoading):
+				atomic.AddInt32(&rejected, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	waitLoaded := func() {
+		require.Eventually(t, func() bool {
+			svc.loadMu.Lock()
+			defer svc.loadMu.Unlock()
+			return len(svc.loading) == 0 && svc.IsModelLoaded("spam")
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&started))
+	assert.Positive(t, atomic.LoadInt32(&rejected))
+	waitLoaded()
+	assert.Equal(t, 1, repo.loads)
+
+	// 加载完成后普通加载被拒绝，强制重新加载不受数量上限限制
+	assert.Error(t, svc.LoadModel(ctx, "spam", false))
+	require.NoError(t, svc.LoadModel(ctx, "spam", true))
+	assert.ErrorIs(t, svc.LoadModel(ctx, "spam", true), ErrModelLoading)
+	waitLoaded()
+	assert.Equal(t, 2, repo.loads)
+}
+
+func TestModelStatusReportsMemoryAndProgress(t *testing.T) {
+	ctx := context.Background()
+	storage := t.TempDir()
+	weights := make([]byte, 3<<20)
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "big.bin"), weights, 0644))
+
+	cacheRepo, _ := newTestCacheRepo(t)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"big": {Name: "big", FilePath: "big.bin", Status: model.ModelStatusUnloaded},
+	}}
+	gated := &gatedBackend{LocalBackend: backend.NewLocalBackend(16), gate: make(chan struct{})}
+	svc := NewModelService(repo, cacheRepo, gated, config.ModelConfig{StoragePath: storage, CacheTTL: 60, MaxLoadedModels: 2, LoadTimeout: 5})
+
+	require.NoError(t, svc.LoadModel(ctx, "big", false))
+
+	// 加载过程中返回当前进度
+	require.Eventually(t, func() bool {
+		status, err := svc.GetModelStatus(ctx, "big")
+		require.NoError(t, err)
+		metadata, _ := status.Metadata.(map[string]interface{})
+		return status.Status == model.ModelStatusLoading && metadata["load_progress"] == 50.0
+	}, time.Second, 5*time.Millisecond)
+	close(gated.gate)
+
+	require.Eventually(t, func() bool { return svc.IsModelLoaded("big") }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		status, err := svc.GetModelStatus(ctx, "big")
+		require.NoError(t, err)
+		return status.Status != model.ModelStatusLoading
+	}, time.Second, 5*time.Millisecond)
+
+	status, err := svc.GetModelStatus(ctx, "big")
+	require.NoError(t, err)
+	metadata := status.Metadata.(map[string]interface{})
+	assert.Equal(t, int64(len(weights)), metadata["memory_bytes"])
+	assert.Equal(t, 100.0, metadata["load_progress"])
+	assert.Equal(t, float64(len(weights)), testutil.ToFloat64(metrics.ModelMemoryBytes.WithLabelValues("big")))
+
+	require.NoError(t, svc.UnloadModel(ctx, "big"))
+	assert.Zero(t, testutil.ToFloat64(metrics.ModelMemoryBytes.WithLabelValues("big")))
+}
+
+func TestGetModelStatusesInOneLookup(t *testing.T) {
+	ctx := context.Background()
+	cacheRepo, _ := newTestCacheRepo(t)
+	loadedAt := time.Now().Add(-time.Minute)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"spam":    {Name: "spam", Status: model.ModelStatusLoaded, LoadedAt: &loadedAt},
+		"ham":     {Name: "ham", Status: model.ModelStatusUnloaded},
+		"loading": {Name: "loading", Status: model.ModelStatusLoading},
+	}}
+	svc := NewModelService(repo, cacheRepo, backend.NewLocalBackend(16), config.ModelConfig{CacheTTL: 60, MaxLoadedModels: 10}).(*modelService)
+	svc.loadedModels.Store("spam", &LoadedModel{Name: "spam", FilePath: "spam.bin", MemoryBytes: 42, LoadedAt: loadedAt})
+	svc.loading["loading"] = 30
+
+	statuses, err := svc.GetModelStatuses(ctx, []string{"ham", "unknown", "spam", "loading"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.batchLookups)
+	assert.Zero(t, repo.lookups)
+
+	require.Len(t, statuses, 4)
+	assert.Equal(t, "ham", statuses[0].Name)
+	assert.Equal(t, model.ModelStatusUnloaded, statuses[0].Status)
+	assert.Nil(t, statuses[0].Metadata)
+
+	// 不存在的模型单独报告，不影响其余结果
+	assert.Equal(t, "unknown", statuses[1].Name)
+	assert.Equal(t, model.ModelStatusNotFound, statuses[1].Status)
+	assert.Contains(t, statuses[1].Error, "不存在")
+
+	assert.Equal(t, model.ModelStatusLoaded, statuses[2].Status)
+	metadata := statuses[2].Metadata.(map[string]interface{})
+	assert.Equal(t, int64(42), metadata["memory_bytes"])
+	assert.Equal(t, 100.0, metadata["load_progress"])
+
+	assert.Equal(t, model.ModelStatusLoading, statuses[3].Status)
+	assert.Equal(t, 30.0, statuses[3].Metadata.(map[string]interface{})["load_progress"])
+
+	// 单个查询与批量查询的结果一致
+	single, err := svc.GetModelStatus(ctx, "spam")
+	require.NoError(t, err)
+	assert.Equal(t, statuses[2], single)
+}
+
+func TestReloadModelKeepsServingDuringSwap(t *testing.T) {
+	ctx := context.Background()
+	storage := t.TempDir()
+	path := filepath.Join(storage, "spam.bin")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0644))
+
+	cacheRepo, _ := newTestCacheRepo(t)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"spam": {Name: "spam", FilePath: "spam.bin"},
+	}}
+	gated := &gatedBackend{LocalBackend: backend.NewLocalBackend(16), gate: make(chan struct{})}
+	svc := NewModelService(repo, cacheRepo, gated, config.ModelConfig{StoragePath: storage, CacheTTL: 60, MaxLoadedModels: 1, LoadTimeout: 5}).(*modelService)
+
+	// 未加载的模型不能重新加载
+	assert.ErrorIs(t, svc.ReloadModel(ctx, "spam"), apperrors.ErrModelNotLoaded)
+
+	close(gated.gate)
+	require.NoError(t, svc.LoadModel(ctx, "spam", false))
+	require.Eventually(t, func() bool { return svc.IsModelLoaded("spam") }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		svc.loadMu.Lock()
+		defer svc.loadMu.Unlock()
+		return len(svc.loading) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	// 重新加载期间持续推理，任何请求都不应失败
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var served, failed int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				release, err := svc.AcquireModel("spam")
+				if err != nil {
+					atomic.AddInt32(&failed, 1)
+					continue
+				}
+				if _, err := svc.backend.Embed(ctx, "spam", []string{"text"}); err != nil {
+					atomic.AddInt32(&failed, 1)
+				}
+				release()
+				atomic.AddInt32(&served, 1)
+			}
+		}()
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("weights-v2"), 0644))
+	gated.gate = make(chan struct{})
+	reloaded := make(chan error, 1)
+	go func() { reloaded <- svc.ReloadModel(ctx, "spam") }()
+
+	// 新版本加载中，旧版本继续服务，并发的加载请求被拒绝
+	require.Eventually(t, func() bool {
+		svc.loadMu.Lock()
+		defer svc.loadMu.Unlock()
+		return svc.loading["spam"] == 50.0
+	}, time.Second, 5*time.Millisecond)
+	assert.ErrorIs(t, svc.LoadModel(ctx, "spam", true), ErrModelLoading)
+	// 重新加载期间卸载被拒绝，否则替换后的版本会在数据库标记为未加载时回到内存
+	assert.ErrorIs(t, svc.UnloadModel(ctx, "spam"), ErrModelLoading)
+	before := atomic.LoadInt32(&served)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&served) > before }, time.Second, time.Millisecond)
+	close(gated.gate)
+	require.NoError(t, <-reloaded)
+
+	close(stop)
+	wg.Wait()
+	assert.Zero(t, atomic.LoadInt32(&failed))
+
+	value, ok := svc.loadedModels.Load("spam")
+	require.True(t, ok)
+	assert.Equal(t, int64(len("weights-v2")), value.(*LoadedModel).MemoryBytes)
+	assert.Equal(t, float64(len("weights-v2")), testutil.ToFloat64(metrics.ModelMemoryBytes.WithLabelValues("spam")))
+	assert.Error(t, gated.PromoteModel("spam"+reloadShadowSuffix, "spam"), "影子权重替换后应被释放")
+	// 重新加载不改变数据库中的已加载状态
+	assert.Equal(t, 1, repo.loads)
+
+	// 替换后的模型可以正常卸载
+	require.NoError(t, svc.UnloadModel(ctx, "spam"))
+}
+
+func TestReloadModelRejectedWhileUnloading(t *testing.T) {
+	ctx := context.Background()
+	storage := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "spam.bin"), []byte("v1"), 0644))
+
+	cacheRepo, _ := newTestCacheRepo(t)
+	repo := &stubModelRepository{models: map[string]*model.Model{
+		"spam": {Name: "spam", FilePath: "spam.bin"},
+	}}
+	svc := NewModelService(repo, cacheRepo, backend.NewLocalBackend(16), config.ModelConfig{StoragePath: storage, CacheTTL: 60, MaxLoadedModels: 1, LoadTimeout: 5}).(*modelService)
+	require.NoError(t, svc.LoadModelAndWait(ctx, "spam"))
+
+	// 进行中的推理使卸载停在等待阶段
+	release, err := svc.AcquireModel("spam")
+	require.NoError(t, err)
+	unloaded := make(chan error, 1)
+	go func() { unloaded <- svc.UnloadModel(ctx, "spam") }()
+	require.Eventually(t, func() bool {
+		svc.loadMu.Lock()
+		defer svc.loadMu.Unlock()
+		_, unloading := svc.unloading["spam"]
+		return unloading
+	}, time.Second, 5*time.Millisecond)
+
+	assert.ErrorIs(t, svc.ReloadModel(ctx, "spam"), ErrModelUnloading)
+	assert.ErrorIs(t, svc.UnloadModel(ctx, "spam"), ErrModelUnloading)
+
+	release()
+	require.NoError(t, <-unloaded)
+	assert.False(t, svc.IsModelLoaded("spam"))
+	assert.Equal(t, model.ModelStatusUnloaded, repo.statuses["spam"])
+}
+
+// warmupRecordingBackend 记录重新加载预热时调用的推理接口
+type warmupRecordingBackend struct {
+	*backend.LocalBackend
+	mu    sync.Mutex
+	calls []string
+}
+
+func (b *warmupRecordingBackend) record(call string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, call)
+}
+
+func (b *warmupRecordingBackend) Embed(ctx context.Context, modelName string, texts []string) ([][]float64, error) {
+	b.record("embed")
+	return b.LocalBackend.Embed(ctx, modelName, texts)
+}
+
+func (b *warmupRecordingBackend) Logits(ctx context.Context, modelName string, text string, classes int) ([]float64, error) {
+	b.record("logits")
+	return b.LocalBackend.Logits(ctx, modelName, text, classes)
+}
+
+func (b *warmupRecordingBackend) ExtractEntities(ctx context.Context, modelName string, text string) ([]model.Entity, error) {
+	b.record("entities")
+	return b.LocalBackend.ExtractEntities(ctx, modelName, text)
+}
+
+func TestReloadModelWarmupDependsOnModelType(t *testing.T) {
+	ctx := context.Background()
+	storage := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "m.bin"), []byte("v1"), 0644))
+
+	for modelType, call := range map[model.ModelType]string{
+		model.ModelTypeClassification: "logits",
+		model.ModelTypeTextAnalysis:   "entities",
+		model.ModelTypeClustering:     "embed",
+	} {
+		cacheRepo, _ := newTestCacheRepo(t)
+		repo := &stubModelRepository{models: map[string]*model.Model{
+			"m": {Name: "m", Type: modelType, FilePath: "m.bin"},
+		}}
+		recording := &warmupRecordingBackend{LocalBackend: backend.NewLocalBackend(16)}
+		svc := NewModelService(repo, cacheRepo, recording, config.ModelConfig{StoragePath: storage, CacheTTL: 60, MaxLoadedModels: 1, LoadTimeout: 5}).(*modelService)
+		require.NoError(t, svc.LoadModelAndWait(ctx, "m"))
+
+		require.NoError(t, svc.ReloadModel(ctx, "m"), modelType)
+		assert.Equal(t, []string{call}, recording.calls, modelType)
+	}
+}
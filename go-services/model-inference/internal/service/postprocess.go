@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultTextClasses 未配置 output.labels 的模型进行文本分类时使用的类别
+var defaultTextClasses = []string{"正常", "违规", "疑似违规"}
+
+// outputConfig 模型输出后处理配置，来自模型元数据，例如
+// {"output": {"softmax": true, "labels": ["正常", "违规", "疑似违规"]}}
+// labels 按类别编号排列；softmax 默认开启，关闭表示后端输出已经是概率
+type outputConfig struct {
+	Softmax *bool    `json:"softmax"`
+	Labels  []string `json:"labels"`
+}
+
+// loadOutputConfig 读取模型元数据中的输出后处理配置，未配置 labels 时返回 false
+func (s *inferenceService) loadOutputConfig(ctx context.Context, modelName string) (outputConfig, bool) {
+	var metadata struct {
+		Output outputConfig `json:"output"`
+	}
+	modelInfo, err := s.modelService.GetModel(ctx, modelName)
+	if err != nil || modelInfo == nil || modelInfo.Metadata == "" {
+		return outputConfig{}, false
+	}
+	if err := json.Unmarshal([]byte(modelInfo.Metadata), &metadata); err != nil {
+		logrus.Warnf("解析模型 %s 输出配置失败: %v", modelName, err)
+		return outputConfig{}, false
+	}
+	return metadata.Output, len(metadata.Output.Labels) > 0
+}
+
+// softmax 将 logits 归一化为概率，先减去最大值避免指数溢出
+func softmax(logits []float64) []float64 {
+	if len(logits) == 0 {
+		return nil
+	}
+	max := logits[0]
+	for _, v := range logits[1:] {
+		if v > max {
+			max = v
+		}
+	}
+
+	probabilities := make([]float64, len(logits))
+	var sum float64
+	for i, v := range logits {
+		probabilities[i] = math.Exp(v - max)
+		sum += probabilities[i]
+	}
+	for i := range probabilities {
+		probabilities[i] /= sum
+	}
+	return probabilities
+}
+
+// postProcess 对后端输出做 softmax 并把类别编号映射为标签，返回概率最高的标签及各标签概率
+func (c outputConfig) postProcess(outputs []float64) (string, float64, map[string]float64, error) {
+	if len(outputs) != len(c.Labels) {
+		return "", 0, nil, fmt.Errorf("模型输出 %d 个类别，与配置的 %d 个标签不一致", len(outputs), len(c.Labels))
+	}
+	if c.Softmax == nil || *c.Softmax {
+		outputs = softmax(outputs)
+	}
+
+	best := 0
+	probability := make(map[string]float64, len(outputs))
+	for i, p := range outputs {
+		probability[c.Labels[i]] = p
+		if p > outputs[best] {
+			best = i
+		}
+	}
+	return c.Labels[best], outputs[best], probability, nil
+}
+
+// classifyWithOutput 计算文本的 logits 并按输出配置后处理
+func (s *inferenceService) classifyWithOutput(ctx context.Context, modelName, text string, cfg outputConfig) (string, float64, map[string]float64, error) {
+	logits, err := s.backend.Logits(ctx, modelName, text, len(cfg.Labels))
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return cfg.postProcess(logits)
+}
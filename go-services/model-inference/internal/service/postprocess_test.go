@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// logitsBackend 返回固定的 logits，其余方法使用本地后端
+type logitsBackend struct {
+	*backend.LocalBackend
+	logits []float64
+}
+
+func (b *logitsBackend) Logits(ctx context.Context, modelName string, text string, classes int) ([]float64, error) {
+	return b.logits, nil
+}
+
+func TestSoftmax(t *testing.T) {
+	probabilities := softmax([]float64{2, 1, 0.1})
+	require.Len(t, probabilities, 3)
+
+	var sum float64
+	for _, p := range probabilities {
+		sum += p
+	}
+	assert.InDelta(t, 1, sum, 1e-9)
+	assert.InDelta(t, 0.659, probabilities[0], 1e-3)
+	assert.InDelta(t, 0.242, probabilities[1], 1e-3)
+	assert.InDelta(t, 0.099, probabilities[2], 1e-3)
+
+	// 大数值 logits 不溢出
+	probabilities = softmax([]float64{1000, 1000})
+	assert.InDelta(t, 0.5, probabilities[0], 1e-9)
+	assert.InDelta(t, 0.5, probabilities[1], 1e-9)
+}
+
+func TestOutputConfigPostProcess(t *testing.T) {
+	cfg := outputConfig{Labels: []string{"正常", "违规", "疑似违规"}}
+
+	label, confidence, probability, err := cfg.postProcess([]float64{0.1, 3, 1})
+	require.NoError(t, err)
+	assert.Equal(t, "违规", label)
+	assert.Equal(t, probability["违规"], confidence)
+	assert.Greater(t, probability["疑似违规"], probability["正常"])
+	assert.InDelta(t, 1, probability["正常"]+probability["违规"]+probability["疑似违规"], 1e-9)
+
+	// 关闭 softmax 时直接使用后端输出的概率
+	disabled := false
+	cfg.Softmax = &disabled
+	label, confidence, probability, err = cfg.postProcess([]float64{0.2, 0.3, 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, "疑似违规", label)
+	assert.Equal(t, 0.5, confidence)
+	assert.Equal(t, 0.2, probability["正常"])
+
+	_, _, _, err = cfg.postProcess([]float64{0.5, 0.5})
+	assert.Error(t, err)
+}
+
+func TestPredictAppliesOutputPostProcessing(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"moderation": {Name: "moderation", Metadata: `{"output": {"labels": ["正常", "违规"]}}`},
+	}, &logitsBackend{LocalBackend: backend.NewLocalBackend(16), logits: []float64{-1, 1}})
+
+	resp, err := svc.Predict(ctx, &model.PredictRequest{
+		ModelName: "moderation",
+		Data:      map[string]interface{}{"text": "加微信领优惠"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "违规", resp.Prediction)
+	assert.InDelta(t, 0.881, resp.Confidence, 1e-3)
+	require.Len(t, resp.Probability, 2)
+	assert.InDelta(t, 1, resp.Probability["正常"]+resp.Probability["违规"], 1e-9)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+// rateWindowSeconds 统计当前请求速率的窗口长度（秒）
+const rateWindowSeconds = 10
+
+// modelRateLimiter 按模型名维护令牌桶，未配置限流的模型不受限制
+type modelRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*modelLimiter
+	now      func() time.Time
+}
+
+// modelLimiter 单个模型的令牌桶与最近的通过计数
+type modelLimiter struct {
+	limit    config.ModelRateLimit
+	bucket   *rate.Limiter
+	rejected int64
+	// 按秒分桶的通过条目数，seconds[i] 为 counts[i] 对应的 Unix 秒
+	counts  [rateWindowSeconds]int64
+	seconds [rateWindowSeconds]int64
+}
+
+// newModelRateLimiter 按配置创建限流器
+func newModelRateLimiter(limits []config.ModelRateLimit) *modelRateLimiter {
+	l := &modelRateLimiter{limiters: make(map[string]*modelLimiter), now: time.Now}
+	l.update(limits)
+	return l
+}
+
+// update 热更新限流配置，参数未变的模型保留原令牌桶与计数
+func (l *modelRateLimiter) update(limits []config.ModelRateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiters := make(map[string]*modelLimiter, len(limits))
+	for _, limit := range limits {
+		if limit.Burst <= 0 {
+			limit.Burst = int(math.Ceil(limit.RequestsPerSecond))
+		}
+		if existing, ok := l.limiters[limit.Model]; ok && existing.limit == limit {
+			limiters[limit.Model] = existing
+			continue
+		}
+		limiters[limit.Model] = &modelLimiter{
+			limit:  limit,
+			bucket: rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.Burst),
+		}
+	}
+	l.limiters = limiters
+}
+
+// allow 从模型的令牌桶取一个令牌，令牌不足时返回 ErrRateLimited
+func (l *modelRateLimiter) allow(modelName string) error {
+	return l.allowN(modelName, 1)
+}
+
+// allowN 按条目数从模型的令牌桶取 n 个令牌，批量请求每条计一次；
+// n 超过突发上限时令牌桶永远无法满足，返回 ErrTooLarge 提示拆分请求
+func (l *modelRateLimiter) allowN(modelName string, n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[modelName]
+	if !ok {
+		return nil
+	}
+	if n > limiter.limit.Burst {
+		limiter.rejected++
+		return apperrors.New(apperrors.ErrTooLarge, "批量 %d 条超过模型 %s 的限流突发上限 %d 条，请拆分后重试", n, modelName, limiter.limit.Burst)
+	}
+	now := l.now()
+	if !limiter.bucket.AllowN(now, n) {
+		limiter.rejected++
+		return apperrors.New(apperrors.ErrRateLimited, "模型 %s 请求过于频繁，限制为每秒 %g 个", modelName, limiter.limit.RequestsPerSecond)
+	}
+
+	sec := now.Unix()
+	i := sec % rateWindowSeconds
+	if limiter.seconds[i] != sec {
+		limiter.seconds[i] = sec
+		limiter.counts[i] = 0
+	}
+	limiter.counts[i] += int64(n)
+	return nil
+}
+
+// statistics 返回各模型的限流状态，未配置限流时返回 nil
+func (l *modelRateLimiter) statistics() map[string]*model.ModelRateLimitStatistics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.limiters) == 0 {
+		return nil
+	}
+	now := l.now().Unix()
+	stats := make(map[string]*model.ModelRateLimitStatistics, len(l.limiters))
+	for name, limiter := range l.limiters {
+		var passed int64
+		for i, sec := range limiter.seconds {
+			if now-sec < rateWindowSeconds {
+				passed += limiter.counts[i]
+			}
+		}
+		stats[name] = &model.ModelRateLimitStatistics{
+			Limit:       limiter.limit.RequestsPerSecond,
+			Burst:       limiter.limit.Burst,
+			CurrentRate: float64(passed) / rateWindowSeconds,
+			Rejected:    limiter.rejected,
+		}
+	}
+	return stats
+}
+
+// acquireModel 占用已加载的模型并按模型限流，被限流时立即释放模型
+func (s *inferenceService) acquireModel(modelName string) (func(), error) {
+	return s.acquireModelN(modelName, 1)
+}
+
+// acquireModelN 与 acquireModel 相同，按 n 条输入计入限流
+func (s *inferenceService) acquireModelN(modelName string, n int) (func(), error) {
+	release, err := s.modelService.AcquireModel(modelName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rateLimiter.allowN(modelName, n); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestRateLimitIsolatesModels(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"hot":  {Name: "hot"},
+		"cold": {Name: "cold"},
+	})
+	cfg := svc.cfg()
+	cfg.RateLimits = []config.ModelRateLimit{
+		{Model: "hot", RequestsPerSecond: 5},
+		{Model: "cold", RequestsPerSecond: 5},
+	}
+	svc.UpdateConfig(cfg)
+	// 固定时钟，令牌桶在测试期间不补充令牌
+	now := time.Now()
+	svc.rateLimiter.now = func() time.Time { return now }
+
+	var mu sync.Mutex
+	var passed, limited int
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.RecognizeEntities(ctx, &model.NERRequest{ModelName: "hot", Text: "张伟在北京"})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				passed++
+			} else {
+				assert.ErrorIs(t, err, apperrors.ErrRateLimited)
+				limited++
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 5, passed)
+	assert.Equal(t, 45, limited)
+
+	// 其他模型的令牌桶不受影响
+	for i := 0; i < 5; i++ {
+		_, err := svc.RecognizeEntities(ctx, &model.NERRequest{ModelName: "cold", Text: "张伟在北京"})
+		require.NoError(t, err)
+	}
+
+	stats := svc.rateLimiter.statistics()
+	require.Contains(t, stats, "hot")
+	assert.Equal(t, 5.0, stats["hot"].Limit)
+	assert.Equal(t, 5, stats["hot"].Burst)
+	assert.Equal(t, 0.5, stats["hot"].CurrentRate)
+	assert.EqualValues(t, 45, stats["hot"].Rejected)
+	assert.EqualValues(t, 0, stats["cold"].Rejected)
+}
+
+func TestRateLimitUpdateKeepsUnchangedBuckets(t *testing.T) {
+	limiter := newModelRateLimiter([]config.ModelRateLimit{{Model: "m", RequestsPerSecond: 1}})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	require.NoError(t, limiter.allow("m"))
+	assert.ErrorIs(t, limiter.allow("m"), apperrors.ErrRateLimited)
+
+	// 配置未变时令牌桶保留，仍处于耗尽状态
+	limiter.update([]config.ModelRateLimit{{Model: "m", RequestsPerSecond: 1}})
+	assert.ErrorIs(t, limiter.allow("m"), apperrors.ErrRateLimited)
+
+	// 移除限流后不再受限
+	limiter.update(nil)
+	assert.NoError(t, limiter.allow("m"))
+	assert.Nil(t, limiter.statistics())
+}
+
+func TestRateLimitChargesEachBatchItem(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"hot":  {Name: "hot"},
+		"cold": {Name: "cold"},
+	})
+	cfg := svc.cfg()
+	cfg.RateLimits = []config.ModelRateLimit{
+		{Model: "hot", RequestsPerSecond: 10},
+		{Model: "cold", RequestsPerSecond: 10, Burst: 5},
+	}
+	svc.UpdateConfig(cfg)
+	now := time.Now()
+	svc.rateLimiter.now = func() time.Time { return now }
+
+	batch := func(modelName string, n int) *model.BatchPredictRequest {
+		data := make([]map[string]interface{}, n)
+		for i := range data {
+			data[i] = map[string]interface{}{"value": i}
+		}
+		return &model.BatchPredictRequest{ModelName: modelName, Data: data}
+	}
+
+	// 每条输入消耗一个令牌，两批 4 条后只剩 2 个令牌
+	for i := 0; i < 2; i++ {
+		_, err := svc.BatchPredict(ctx, batch("hot", 4))
+		require.NoError(t, err)
+	}
+	_, err := svc.BatchPredict(ctx, batch("hot", 4))
+	assert.ErrorIs(t, err, apperrors.ErrRateLimited)
+	err = svc.StreamBatchPredict(ctx, batch("hot", 3), func(*model.PredictResponse) error { return nil })
+	assert.ErrorIs(t, err, apperrors.ErrRateLimited)
+
+	// 超过突发上限的批量永远无法通过，提示拆分而不是重试
+	_, err = svc.BatchPredict(ctx, batch("cold", 6))
+	assert.ErrorIs(t, err, apperrors.ErrTooLarge)
+	assert.Contains(t, err.Error(), "突发上限")
+
+	// 其他模型的令牌桶不受影响
+	_, err = svc.BatchPredict(ctx, batch("cold", 5))
+	require.NoError(t, err)
+
+	stats := svc.rateLimiter.statistics()
+	assert.Equal(t, 0.8, stats["hot"].CurrentRate)
+	assert.EqualValues(t, 2, stats["hot"].Rejected)
+}
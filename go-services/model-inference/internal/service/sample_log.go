@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/json"
+	"math/rand"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/pii"
+)
+
+// sampleLog 按 inference.sample_log 抽样记录推理的输入与输出，用于排查错误预测。
+// 所有字符串经 pii.Redact 脱敏后写入日志；未开启时直接返回，不做任何序列化
+func (s *inferenceService) sampleLog(requestID, modelName string, input, output interface{}) {
+	cfg := s.cfg().SampleLog
+	if !cfg.Enabled || rand.Float64() >= cfg.Rate {
+		return
+	}
+
+	inputData, _ := json.Marshal(redactValue(input))
+	outputData, _ := json.Marshal(redactValue(output))
+	s.sampleLogger.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"model_name": modelName,
+		"input":      string(inputData),
+		"output":     string(outputData),
+	}).Info("推理抽样记录")
+}
+
+// redactValue 将值转为 JSON 通用结构后逐个字符串脱敏，无法序列化时返回 nil
+func redactValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	return redactGeneric(generic)
+}
+
+// redactGeneric 递归脱敏 JSON 通用结构中的字符串，对象的键不脱敏
+func redactGeneric(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		redacted, _ := pii.Redact(v)
+		return redacted
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = redactGeneric(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactGeneric(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestSampleLogRecordsConfiguredFraction(t *testing.T) {
+	svc, _ := newTestInferenceService(t, nil)
+	logger, hook := logtest.NewNullLogger()
+	svc.sampleLogger = logger
+
+	const requests = 20000
+	input := map[string]interface{}{"text": "hello"}
+
+	// 未开启时即使比例为 1 也不记录
+	svc.UpdateConfig(config.InferenceConfig{SampleLog: config.SampleLogConfig{Rate: 1}})
+	for i := 0; i < requests; i++ {
+		svc.sampleLog("req", "m", input, "ok")
+	}
+	assert.Empty(t, hook.AllEntries())
+
+	svc.UpdateConfig(config.InferenceConfig{SampleLog: config.SampleLogConfig{Enabled: true, Rate: 0.1}})
+	for i := 0; i < requests; i++ {
+		svc.sampleLog("req", "m", input, "ok")
+	}
+	fraction := float64(len(hook.AllEntries())) / requests
+	assert.InDelta(t, 0.1, fraction, 0.02)
+}
+
+func TestSampleLogRedactsPII(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{"spam": {Name: "spam"}})
+	logger, hook := logtest.NewNullLogger()
+	svc.sampleLogger = logger
+	svc.UpdateConfig(config.InferenceConfig{MaxBatchSize: 10, TimeoutSeconds: 5, SampleLog: config.SampleLogConfig{Enabled: true, Rate: 1}})
+
+	_, err := svc.Predict(ctx, &model.PredictRequest{ModelName: "spam", Data: map[string]interface{}{"text": "联系 13812345678 或 a@example.com"}})
+	require.NoError(t, err)
+
+	require.Len(t, hook.AllEntries(), 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, "spam", entry.Data["model_name"])
+	assert.NotEmpty(t, entry.Data["request_id"])
+	assert.NotContains(t, entry.Data["input"], "13812345678")
+	assert.NotContains(t, entry.Data["input"], "a@example.com")
+	assert.Contains(t, entry.Data["input"], "138****5678")
+	assert.Contains(t, entry.Data["output"], "prediction")
+}
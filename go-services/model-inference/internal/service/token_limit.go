@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+)
+
+// 超出 max_tokens 时的处理方式
+const (
+	tokenOverflowTruncate = "truncate"
+	tokenOverflowReject   = "reject"
+)
+
+// tokenLimitConfig 模型输入长度限制，来自模型元数据，例如
+// {"max_tokens": 512, "token_overflow": "truncate"}
+// token_overflow 默认为 truncate，max_tokens 未配置或不大于 0 时不限制
+type tokenLimitConfig struct {
+	MaxTokens int    `json:"max_tokens"`
+	Overflow  string `json:"token_overflow"`
+}
+
+// loadTokenLimitConfig 读取模型元数据中的输入长度限制
+func (s *inferenceService) loadTokenLimitConfig(ctx context.Context, modelName string) tokenLimitConfig {
+	var cfg tokenLimitConfig
+	modelInfo, err := s.modelService.GetModel(ctx, modelName)
+	if err != nil || modelInfo == nil || modelInfo.Metadata == "" {
+		return cfg
+	}
+	if err := json.Unmarshal([]byte(modelInfo.Metadata), &cfg); err != nil {
+		logrus.Warnf("解析模型 %s 输入长度限制失败: %v", modelName, err)
+	}
+	if cfg.Overflow != "" && cfg.Overflow != tokenOverflowTruncate && cfg.Overflow != tokenOverflowReject {
+		logrus.Warnf("模型 %s 的 token_overflow %q 无效，按 truncate 处理", modelName, cfg.Overflow)
+	}
+	return cfg
+}
+
+// applyTokenLimit 统计输入 text 的词元数，超出模型 max_tokens 时按配置拒绝或在词元边界截断。
+// 返回实际用于推理的数据（截断时为副本，不修改原数据）与写入响应 metadata 的词元统计；
+// 模型未配置限制或输入不含文本时原样返回
+func (s *inferenceService) applyTokenLimit(ctx context.Context, modelName string, data map[string]interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	text, ok := data["text"].(string)
+	if !ok {
+		return data, nil, nil
+	}
+	cfg := s.loadTokenLimitConfig(ctx, modelName)
+	if cfg.MaxTokens <= 0 {
+		return data, nil, nil
+	}
+
+	tokens, err := s.backend.Tokenize(ctx, modelName, text)
+	if err != nil {
+		return nil, nil, fmt.Errorf("统计输入词元数失败: %w", err)
+	}
+	usage := map[string]interface{}{
+		"original_tokens": len(tokens),
+		"tokens":          len(tokens),
+		"truncated":       false,
+	}
+	if len(tokens) <= cfg.MaxTokens {
+		return data, usage, nil
+	}
+
+	if cfg.Overflow == tokenOverflowReject {
+		return nil, nil, apperrors.New(apperrors.ErrTooLarge, "输入共 %d 个词元，超过模型 %s 的上限 %d", len(tokens), modelName, cfg.MaxTokens)
+	}
+
+	truncated := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		truncated[k] = v
+	}
+	truncated["text"] = text[:tokens[cfg.MaxTokens-1].End]
+	usage["tokens"] = cfg.MaxTokens
+	usage["truncated"] = true
+	return truncated, usage, nil
+}
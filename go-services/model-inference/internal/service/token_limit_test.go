@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/apperrors"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestPredictTruncatesOverLengthChineseInput(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestInferenceService(t, map[string]*model.Model{
+		"short": {Name: "short", Metadata: `{"max_tokens": 8}`},
+	})
+
+	// 每个汉字与标点各占一个词元，"AI" 合为一个词元，共 16 个
+	text := "人工智能AI正在改变世界，未来可期"
+	resp, err := svc.Predict(ctx, &model.PredictRequest{
+		ModelName: "short",
+		Data:      map[string]interface{}{"text": text, "lang": "zh"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 16, resp.Metadata["original_tokens"])
+	assert.Equal(t, 8, resp.Metadata["tokens"])
+	assert.Equal(t, true, resp.Metadata["truncated"])
+
+	// 截断落在第 8 个词元之后，不会切开多字节字符
+	require.Len(t, repo.requests, 1)
+	assert.Contains(t, repo.requests[0].InputData, `"text":"人工智能AI正在改"`)
+	assert.Contains(t, repo.requests[0].InputData, `"lang":"zh"`)
+}
+
+func TestPredictWithinTokenLimit(t *testing.T) {
+	svc, _ := newTestInferenceService(t, map[string]*model.Model{
+		"short": {Name: "short", Metadata: `{"max_tokens": 8}`},
+	})
+
+	resp, err := svc.Predict(context.Background(), &model.PredictRequest{
+		ModelName: "short",
+		Data:      map[string]interface{}{"text": "你好世界"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.Metadata["original_tokens"])
+	assert.Equal(t, false, resp.Metadata["truncated"])
+}
+
+func TestPredictRejectsOverLengthInput(t *testing.T) {
+	svc, repo := newTestInferenceService(t, map[string]*model.Model{
+		"strict": {Name: "strict", Metadata: `{"max_tokens": 8, "token_overflow": "reject"}`},
+	})
+
+	_, err := svc.Predict(context.Background(), &model.PredictRequest{
+		ModelName: "strict",
+		Data:      map[string]interface{}{"text": strings.Repeat("长", 9)},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrTooLarge)
+	assert.Zero(t, repo.created)
+
+	_, err = svc.BatchPredict(context.Background(), &model.BatchPredictRequest{
+		ModelName: "strict",
+		Data: []map[string]interface{}{
+			{"text": "短文本"},
+			{"text": strings.Repeat("长", 9)},
+		},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrTooLarge)
+}
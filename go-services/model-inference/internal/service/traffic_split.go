@@ -0,0 +1,39 @@
+package service
+
+import "math/rand"
+
+// 分流变体名称
+const (
+	variantControl   = "control"
+	variantCandidate = "candidate"
+)
+
+// trafficVariant 一次文本分类请求的分流结果，记录到推理请求的元数据中
+type trafficVariant struct {
+	RequestedModel string  `json:"requested_model"`
+	ServedModel    string  `json:"served_model"`
+	Variant        string  `json:"variant"`
+	Percent        float64 `json:"percent"`
+}
+
+// routeClassification 按 inference.traffic_splits 选择处理文本分类的模型。
+// 未配置分流时返回请求的模型与 nil；候选模型未加载时回退到请求的模型
+func (s *inferenceService) routeClassification(modelName string) (string, *trafficVariant) {
+	for _, split := range s.cfg().TrafficSplits {
+		if split.Model != modelName {
+			continue
+		}
+		variant := &trafficVariant{
+			RequestedModel: modelName,
+			ServedModel:    modelName,
+			Variant:        variantControl,
+			Percent:        split.Percent,
+		}
+		if rand.Float64()*100 < split.Percent && s.modelService.IsModelLoaded(split.Candidate) {
+			variant.ServedModel = split.Candidate
+			variant.Variant = variantCandidate
+		}
+		return variant.ServedModel, variant
+	}
+	return modelName, nil
+}
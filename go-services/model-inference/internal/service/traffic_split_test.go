@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/model"
+)
+
+func TestClassifyTextTrafficSplit(t *testing.T) {
+	ctx := context.Background()
+	// 多标签模型走后端打分，不经过模拟分类的随机延迟
+	metadata := `{"multi_label": true, "labels": ["spam"], "label_threshold": 0}`
+	svc, repo := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"classifier":    {Name: "classifier", Metadata: metadata},
+		"classifier-v2": {Name: "classifier-v2", Metadata: metadata},
+	}, &stubBackend{LocalBackend: backend.NewLocalBackend(16), scores: map[string]float64{"spam": 0.7}})
+
+	// 运行时通过配置热更新开启分流
+	cfg := svc.cfg()
+	cfg.TrafficSplits = []config.TrafficSplit{{Model: "classifier", Candidate: "classifier-v2", Percent: 30}}
+	svc.UpdateConfig(cfg)
+
+	const total = 2000
+	served := map[string]int{}
+	for i := 0; i < total; i++ {
+		resp, err := svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "classifier", Text: "buy now"})
+		require.NoError(t, err)
+		served[resp.ModelName]++
+	}
+	assert.InDelta(t, 0.3, float64(served["classifier-v2"])/total, 0.05)
+	assert.Equal(t, total, served["classifier"]+served["classifier-v2"])
+
+	// 每个请求都记录了处理它的变体
+	require.Len(t, repo.requests, total)
+	variants := map[string]int{}
+	for _, req := range repo.requests {
+		var metadata struct {
+			ABTest trafficVariant `json:"ab_test"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(req.Metadata), &metadata))
+		assert.Equal(t, "classifier", metadata.ABTest.RequestedModel)
+		assert.Equal(t, req.ModelName, metadata.ABTest.ServedModel)
+		assert.Equal(t, model.InferenceStatusCompleted, req.Status)
+		variants[metadata.ABTest.Variant]++
+	}
+	assert.Equal(t, served["classifier-v2"], variants[variantCandidate])
+	assert.Equal(t, served["classifier"], variants[variantControl])
+
+	// 关闭分流后不再记录
+	cfg.TrafficSplits = nil
+	svc.UpdateConfig(cfg)
+	resp, err := svc.ClassifyText(ctx, &model.TextClassifyRequest{ModelName: "classifier", Text: "buy now"})
+	require.NoError(t, err)
+	assert.Equal(t, "classifier", resp.ModelName)
+	assert.Len(t, repo.requests, total)
+}
+
+func TestClassifyTextTrafficSplitFallsBackWhenCandidateNotLoaded(t *testing.T) {
+	svc, _ := newTestInferenceServiceWithBackend(t, map[string]*model.Model{
+		"classifier": {Name: "classifier", Metadata: `{"multi_label": true, "labels": ["spam"]}`},
+	}, &stubBackend{LocalBackend: backend.NewLocalBackend(16), scores: map[string]float64{"spam": 0.7}})
+	cfg := svc.cfg()
+	cfg.TrafficSplits = []config.TrafficSplit{{Model: "classifier", Candidate: "classifier-v2", Percent: 100}}
+	svc.UpdateConfig(cfg)
+
+	resp, err := svc.ClassifyText(context.Background(), &model.TextClassifyRequest{ModelName: "classifier", Text: "buy now"})
+	require.NoError(t, err)
+	assert.Equal(t, "classifier", resp.ModelName)
+}
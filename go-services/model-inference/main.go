@@ -14,8 +14,10 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/backend"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/config"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/handler"
+	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/metrics"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/middleware"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/repository"
 	"github.com/mj37yhyy/ai-demo/go-services/model-inference/internal/service"
@@ -50,6 +52,9 @@ func main() {
 	if err != nil {
 		logrus.Fatalf("加载配置失败: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		logrus.Fatalf("%v", err)
+	}
 
 	// 设置日志级别
 	if level, err := logrus.ParseLevel(cfg.Log.Level); err == nil {
@@ -74,20 +79,49 @@ func main() {
 	modelRepo := repository.NewModelRepository(db)
 	inferenceRepo := repository.NewInferenceRepository(db)
 	cacheRepo := repository.NewCacheRepository(redisClient)
+	operationRepo := repository.NewOperationRepository(db)
 
 	// 初始化服务层
-	modelService := service.NewModelService(modelRepo, cacheRepo, cfg.Model)
-	inferenceService := service.NewInferenceService(inferenceRepo, modelService, cacheRepo, cfg.Inference)
-	healthService := service.NewHealthService(db, redisClient)
+	inferenceBackend := backend.NewLocalBackend(backend.DefaultEmbeddingDimension)
+	modelService := service.NewModelService(modelRepo, cacheRepo, inferenceBackend, cfg.Model)
+	vectorStore := repository.NewVectorStore(db)
+	inferenceService := service.NewInferenceService(inferenceRepo, modelService, cacheRepo, inferenceBackend, vectorStore, cfg.Inference)
+	backendWatchdog := service.NewBackendWatchdog(modelService, modelRepo, inferenceBackend, cfg.Model)
+	modelPreloader := service.NewModelPreloader(modelService, cfg.Model)
+	healthService := service.NewHealthService(db, redisClient, backendWatchdog, modelPreloader)
+	historyCleaner := service.NewHistoryCleaner(inferenceRepo, vectorStore, cfg.Inference)
+	auditService := service.NewAuditService(operationRepo)
 
 	// 初始化日志
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
 
+	// 配置热更新
+	configHolder := config.NewHolder(cfg)
+	configHolder.OnChange(func(c config.Config) {
+		if level, err := logrus.ParseLevel(c.Log.Level); err == nil {
+			logger.SetLevel(level)
+		}
+		inferenceService.UpdateConfig(c.Inference)
+		historyCleaner.UpdateConfig(c.Inference)
+		modelService.UpdateConfig(c.Model)
+		backendWatchdog.UpdateConfig(c.Model)
+	})
+	configHolder.Watch()
+
+	// 定期清理推理历史
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go historyCleaner.Run(cleanupCtx)
+	go backendWatchdog.Run(cleanupCtx)
+	// 预加载常用模型，结束前 /ready 返回 503
+	go modelPreloader.Run(cleanupCtx)
+
 	// 初始化处理器
-	modelHandler := handler.NewModelHandler(modelService, logger)
+	modelHandler := handler.NewModelHandler(modelService, auditService, logger)
 	inferenceHandler := handler.NewInferenceHandler(inferenceService, logger)
 	healthHandler := handler.NewHealthHandler(healthService, logger)
+	adminHandler := handler.NewAdminHandler(logrus.StandardLogger(), logger)
 
 	// 设置Gin模式
 	if cfg.Server.Mode == "release" {
@@ -102,6 +136,7 @@ func main() {
 	router.Use(middleware.Recovery(logger))
 	router.Use(middleware.CORS())
 	router.Use(middleware.RequestID())
+	router.Use(middleware.BodyLimit(cfg.Server.MaxBodyBytes))
 
 	// 健康检查
 	router.GET("/health", healthHandler.Health)
@@ -115,9 +150,12 @@ func main() {
 		{
 			models.GET("", modelHandler.ListModels)
 			models.GET("/:name", modelHandler.GetModel)
-			models.POST("/:name/load", modelHandler.LoadModel)
-			models.POST("/:name/unload", modelHandler.UnloadModel)
+			// 加载、卸载与重新加载需管理令牌，审计记录的操作者取自鉴权身份
+			models.POST("/:name/load", middleware.AdminAuth(cfg.Server.AdminToken), modelHandler.LoadModel)
+			models.POST("/:name/unload", middleware.AdminAuth(cfg.Server.AdminToken), modelHandler.UnloadModel)
+			models.POST("/:name/reload", middleware.AdminAuth(cfg.Server.AdminToken), modelHandler.ReloadModel)
 			models.GET("/:name/status", modelHandler.GetModelStatus)
+			models.POST("/status", modelHandler.GetModelStatuses)
 			models.GET("/statistics", modelHandler.GetModelStatistics)
 		}
 
@@ -125,10 +163,14 @@ func main() {
 		inference := v1.Group("/inference")
 		{
 			inference.POST("/predict", inferenceHandler.Predict)
-			inference.POST("/batch-predict", inferenceHandler.BatchPredict)
+			inference.POST("/ensemble", inferenceHandler.Ensemble)
+			inference.POST("/batch-predict", middleware.BodyLimit(batchBodyLimit(cfg)), inferenceHandler.BatchPredict)
+			inference.POST("/batch-predict/stream", middleware.BodyLimit(batchBodyLimit(cfg)), inferenceHandler.StreamBatchPredict)
 			inference.GET("/history", inferenceHandler.GetInferenceHistory)
-			inference.GET("/history/:id", inferenceHandler.GetInferenceResult)
+			inference.GET("/history/:request_id", inferenceHandler.GetInferenceResult)
+			inference.GET("/result/:request_id", inferenceHandler.GetInferenceResult)
 			inference.GET("/statistics", inferenceHandler.GetInferenceStatistics)
+			inference.GET("/reviews", inferenceHandler.ListPendingReviews)
 		}
 
 		// 文本分析
@@ -139,6 +181,29 @@ func main() {
 			textAnalysis.POST("/extract-features", inferenceHandler.FeatureExtraction)
 			textAnalysis.POST("/detect-anomaly", inferenceHandler.AnomalyDetection)
 		}
+
+		// 文本向量
+		text := v1.Group("/text")
+		{
+			text.POST("/embed", inferenceHandler.Embed)
+			text.POST("/similar", inferenceHandler.Similar)
+			text.POST("/ner", inferenceHandler.RecognizeEntities)
+			text.POST("/quality", inferenceHandler.ScoreQuality)
+			text.POST("/redact", inferenceHandler.Redact)
+		}
+
+		// 管理操作审计
+		v1Admin := v1.Group("/admin", middleware.AdminAuth(cfg.Server.AdminToken))
+		{
+			v1Admin.GET("/operations", modelHandler.ListOperations)
+		}
+	}
+
+	// 管理接口
+	admin := router.Group("/admin", middleware.AdminAuth(cfg.Server.AdminToken))
+	{
+		admin.GET("/loglevel", adminHandler.GetLogLevel)
+		admin.PUT("/loglevel", adminHandler.SetLogLevel)
 	}
 
 	// Swagger文档
@@ -146,8 +211,8 @@ func main() {
 		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	// 指标端点 - 暂时注释掉，因为PrometheusHandler未实现
-	// router.GET("/metrics", middleware.PrometheusHandler())
+	// 指标端点
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// 创建HTTP服务器
 	server := &http.Server{
@@ -172,11 +237,17 @@ func main() {
 	<-quit
 
 	logrus.Info("正在关闭服务器...")
+	stopCleanup()
 
 	// 优雅关闭
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// 先派发批处理队列中剩余的请求，避免等待中的客户端挂起
+	if err := inferenceService.Drain(ctx); err != nil {
+		logrus.Errorf("批处理队列排空超时: %v", err)
+	}
+
 	if err := server.Shutdown(ctx); err != nil {
 		logrus.Errorf("服务器关闭失败: %v", err)
 	}
@@ -190,4 +261,15 @@ func main() {
 	redisClient.Close()
 
 	logrus.Info("服务器已关闭")
-}
\ No newline at end of file
+}
+// batchItemMaxBytes 批量预测中单条文本预估的最大字节数
+const batchItemMaxBytes = 64 << 10
+
+// batchBodyLimit 根据最大批量大小收紧批量预测接口的请求体限制
+func batchBodyLimit(cfg *config.Config) int64 {
+	limit := int64(cfg.Inference.MaxBatchSize) * batchItemMaxBytes
+	if limit <= 0 || limit > cfg.Server.MaxBodyBytes {
+		return cfg.Server.MaxBodyBytes
+	}
+	return limit
+}
@@ -479,7 +479,15 @@ run_go_test_suite() {
     echo -e "${YELLOW}运行Go测试套件...${NC}"
     
     cd test
-    if go run ProductionInferenceTest.go > "$LOG_DIR/go_test_suite.log" 2>&1; then
+    if go run . \
+        -base-url "http://localhost:$SERVICE_PORT" \
+        -data-size "$TEST_DATA_SIZE" \
+        -users "$CONCURRENT_USERS" \
+        -duration "$TEST_DURATION" \
+        -batch-size "$BATCH_SIZE" \
+        -max-memory-mb "$MAX_MEMORY_MB" \
+        -max-cpu "$MAX_CPU_PERCENT" \
+        -report-dir "$TEST_DIR" > "$LOG_DIR/go_test_suite.log" 2>&1; then
         echo -e "${GREEN}✓ Go测试套件执行完成${NC}"
     else
         echo -e "${RED}✗ Go测试套件执行失败${NC}"
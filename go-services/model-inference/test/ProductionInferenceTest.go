@@ -1,15 +1,20 @@
-package test
+// 生产级推理测试套件，可通过命令行参数或 LOADTEST_ 前缀的环境变量指定目标环境与压测参数，
+// 例如: go run ./test -base-url http://staging:8080 -users 50 -tests single,concurrent
+package main
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -22,9 +27,15 @@ type TestConfig struct {
 	TestDataSize      int
 	ConcurrentUsers   int
 	TestDuration      time.Duration
+	LongRunDuration   time.Duration
+	RequestTimeout    time.Duration
 	BatchSize         int
 	ModelNames        []string
 	PerformanceTarget PerformanceTarget
+	// Tests 只运行指定的测试，为空时运行全部
+	Tests       []string
+	Environment string
+	ReportDir   string
 }
 
 // 性能目标
@@ -123,34 +134,59 @@ func NewProductionInferenceTestSuite(config TestConfig) *ProductionInferenceTest
 	return &ProductionInferenceTestSuite{
 		config: config,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: config.RequestTimeout,
 		},
 		results: make([]TestResult, 0),
 		logger:  logger,
 	}
 }
 
+// testCase 测试项，key 为 -tests 参数中使用的名称
+type testCase struct {
+	key  string
+	name string
+	fn   func() TestResult
+}
+
+func (suite *ProductionInferenceTestSuite) testCases() []testCase {
+	return []testCase{
+		{"model_loading", "模型加载测试", suite.TestModelLoading},
+		{"single", "单次推理测试", suite.TestSingleInference},
+		{"batch", "批量推理测试", suite.TestBatchInference},
+		{"classify", "文本分类测试", suite.TestTextClassification},
+		{"sentiment", "情感分析测试", suite.TestSentimentAnalysis},
+		{"concurrent", "并发推理测试", suite.TestConcurrentInference},
+		{"stress", "性能压力测试", suite.TestPerformanceStress},
+		{"errors", "错误处理测试", suite.TestErrorHandling},
+		{"memory", "内存泄漏测试", suite.TestMemoryLeak},
+		{"long_running", "长时间运行测试", suite.TestLongRunning},
+	}
+}
+
+// selectedTests 返回配置中选择的测试，未选择时返回全部
+func (suite *ProductionInferenceTestSuite) selectedTests() []testCase {
+	cases := suite.testCases()
+	if len(suite.config.Tests) == 0 {
+		return cases
+	}
+	selected := make(map[string]bool, len(suite.config.Tests))
+	for _, key := range suite.config.Tests {
+		selected[key] = true
+	}
+	var tests []testCase
+	for _, c := range cases {
+		if selected[c.key] {
+			tests = append(tests, c)
+		}
+	}
+	return tests
+}
+
 // 运行所有测试
 func (suite *ProductionInferenceTestSuite) RunAllTests() error {
 	suite.logger.Info("开始运行生产级推理测试套件")
-	
-	tests := []struct {
-		name string
-		fn   func() TestResult
-	}{
-		{"模型加载测试", suite.TestModelLoading},
-		{"单次推理测试", suite.TestSingleInference},
-		{"批量推理测试", suite.TestBatchInference},
-		{"文本分类测试", suite.TestTextClassification},
-		{"情感分析测试", suite.TestSentimentAnalysis},
-		{"并发推理测试", suite.TestConcurrentInference},
-		{"性能压力测试", suite.TestPerformanceStress},
-		{"错误处理测试", suite.TestErrorHandling},
-		{"内存泄漏测试", suite.TestMemoryLeak},
-		{"长时间运行测试", suite.TestLongRunning},
-	}
-
-	for _, test := range tests {
+
+	for _, test := range suite.selectedTests() {
 		suite.logger.Infof("运行测试: %s", test.name)
 		result := test.fn()
 		suite.addResult(result)
@@ -766,7 +802,7 @@ func (suite *ProductionInferenceTestSuite) TestLongRunning() TestResult {
 		Errors:   make([]string, 0),
 	}
 
-	duration := 5 * time.Minute // 5分钟长时间测试
+	duration := suite.config.LongRunDuration
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
 
@@ -1041,10 +1077,22 @@ func (suite *ProductionInferenceTestSuite) addResult(result TestResult) {
 	suite.results = append(suite.results, result)
 }
 
+// passed 是否所有已运行的测试均通过
+func (suite *ProductionInferenceTestSuite) passed() bool {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	for _, result := range suite.results {
+		if result.Status != "PASSED" {
+			return false
+		}
+	}
+	return true
+}
+
 func (suite *ProductionInferenceTestSuite) generateReport() error {
 	report := TestReport{
 		Timestamp:   time.Now(),
-		Environment: "production",
+		Environment: suite.config.Environment,
 		Version:     "1.0.0",
 		TestResults: suite.results,
 	}
@@ -1074,9 +1122,12 @@ func (suite *ProductionInferenceTestSuite) generateReport() error {
 	}
 
 	// 保存报告
-	filename := fmt.Sprintf("inference_test_report_%s.json", 
-		time.Now().Format("20060102_150405"))
+	filename := filepath.Join(suite.config.ReportDir, fmt.Sprintf("inference_test_report_%s.json",
+		time.Now().Format("20060102_150405")))
 	
+	if err := os.MkdirAll(suite.config.ReportDir, 0755); err != nil {
+		return fmt.Errorf("创建报告目录失败: %w", err)
+	}
 	if err := os.WriteFile(filename, jsonData, 0644); err != nil {
 		return fmt.Errorf("保存报告失败: %w", err)
 	}
@@ -1095,20 +1146,12 @@ func (suite *ProductionInferenceTestSuite) generateReport() error {
 // 主函数
 func main() {
 	// 测试配置
-	config := TestConfig{
-		BaseURL:         "http://localhost:8080",
-		TestDataSize:    100,
-		ConcurrentUsers: 10,
-		TestDuration:    2 * time.Minute,
-		BatchSize:       10,
-		ModelNames:      []string{"text_classifier", "sentiment_analyzer", "feature_extractor"},
-		PerformanceTarget: PerformanceTarget{
-			MaxLatency:     2 * time.Second,
-			MinThroughput:  10.0,
-			MaxErrorRate:   0.05,
-			MaxMemoryUsage: 500,
-			MaxCPUUsage:    80.0,
-		},
+	config, err := parseTestConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("解析测试配置失败: %v", err)
 	}
 
 	// 创建测试套件
@@ -1118,4 +1161,9 @@ func main() {
 	if err := suite.RunAllTests(); err != nil {
 		log.Fatalf("测试运行失败: %v", err)
 	}
+
+	// 有测试未通过时以非零状态退出，便于在 CI 中判定结果
+	if !suite.passed() {
+		os.Exit(1)
+	}
 }
\ No newline at end of file
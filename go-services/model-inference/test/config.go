@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// envPrefix 参数对应的环境变量前缀，如 -base-url 对应 LOADTEST_BASE_URL；命令行参数优先于环境变量
+const envPrefix = "LOADTEST_"
+
+// defaultTestConfig 未指定参数时使用的默认配置
+func defaultTestConfig() TestConfig {
+	return TestConfig{
+		BaseURL:         "http://localhost:8080",
+		TestDataSize:    100,
+		ConcurrentUsers: 10,
+		TestDuration:    2 * time.Minute,
+		LongRunDuration: 5 * time.Minute,
+		RequestTimeout:  30 * time.Second,
+		BatchSize:       10,
+		ModelNames:      []string{"text_classifier", "sentiment_analyzer", "feature_extractor"},
+		PerformanceTarget: PerformanceTarget{
+			MaxLatency:     2 * time.Second,
+			MinThroughput:  10.0,
+			MaxErrorRate:   0.05,
+			MaxMemoryUsage: 500,
+			MaxCPUUsage:    80.0,
+		},
+		Environment: "production",
+		ReportDir:   ".",
+	}
+}
+
+// parseTestConfig 解析命令行参数，未在命令行指定的参数读取对应的环境变量，两者都未设置时使用默认值
+func parseTestConfig(args []string, getenv func(string) string) (TestConfig, error) {
+	cfg := defaultTestConfig()
+	models := strings.Join(cfg.ModelNames, ",")
+	var tests string
+
+	flags := flag.NewFlagSet("inference-loadtest", flag.ContinueOnError)
+	flags.StringVar(&cfg.BaseURL, "base-url", cfg.BaseURL, "推理服务地址")
+	flags.StringVar(&models, "models", models, "参与测试的模型，逗号分隔")
+	flags.IntVar(&cfg.TestDataSize, "data-size", cfg.TestDataSize, "单次与批量推理测试的请求条数")
+	flags.IntVar(&cfg.ConcurrentUsers, "users", cfg.ConcurrentUsers, "并发用户数")
+	flags.DurationVar(&cfg.TestDuration, "duration", cfg.TestDuration, "压力测试持续时间")
+	flags.DurationVar(&cfg.LongRunDuration, "long-run-duration", cfg.LongRunDuration, "长时间运行测试持续时间")
+	flags.DurationVar(&cfg.RequestTimeout, "request-timeout", cfg.RequestTimeout, "单个请求超时时间")
+	flags.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "批量推理每批条数")
+	flags.DurationVar(&cfg.PerformanceTarget.MaxLatency, "max-latency", cfg.PerformanceTarget.MaxLatency, "最大延迟目标")
+	flags.Float64Var(&cfg.PerformanceTarget.MinThroughput, "min-throughput", cfg.PerformanceTarget.MinThroughput, "最小吞吐量目标（请求/秒）")
+	flags.Float64Var(&cfg.PerformanceTarget.MaxErrorRate, "max-error-rate", cfg.PerformanceTarget.MaxErrorRate, "最大错误率目标（0-1）")
+	flags.Int64Var(&cfg.PerformanceTarget.MaxMemoryUsage, "max-memory-mb", cfg.PerformanceTarget.MaxMemoryUsage, "最大内存占用目标（MB）")
+	flags.Float64Var(&cfg.PerformanceTarget.MaxCPUUsage, "max-cpu", cfg.PerformanceTarget.MaxCPUUsage, "最大 CPU 占用目标（%）")
+	flags.StringVar(&tests, "tests", "", "只运行指定的测试，逗号分隔，可选: "+strings.Join(testKeys(), ","))
+	flags.StringVar(&cfg.Environment, "env", cfg.Environment, "写入报告的环境名称")
+	flags.StringVar(&cfg.ReportDir, "report-dir", cfg.ReportDir, "测试报告输出目录")
+
+	var envErr error
+	flags.VisitAll(func(f *flag.Flag) {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if value := getenv(name); value != "" && envErr == nil {
+			if err := f.Value.Set(value); err != nil {
+				envErr = fmt.Errorf("环境变量 %s 无效: %w", name, err)
+			}
+		}
+	})
+	if envErr != nil {
+		return cfg, envErr
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	cfg.ModelNames = splitList(models)
+	cfg.Tests = splitList(tests)
+	return cfg, validateTestConfig(cfg)
+}
+
+// validateTestConfig 检查配置是否可用于运行测试
+func validateTestConfig(cfg TestConfig) error {
+	switch {
+	case cfg.BaseURL == "":
+		return fmt.Errorf("base-url 不能为空")
+	case len(cfg.ModelNames) == 0:
+		return fmt.Errorf("models 不能为空")
+	case cfg.TestDataSize <= 0 || cfg.ConcurrentUsers <= 0 || cfg.BatchSize <= 0:
+		return fmt.Errorf("data-size、users 与 batch-size 必须大于 0")
+	case cfg.TestDuration <= 0 || cfg.LongRunDuration <= 0 || cfg.RequestTimeout <= 0:
+		return fmt.Errorf("duration、long-run-duration 与 request-timeout 必须大于 0")
+	}
+	known := make(map[string]bool)
+	for _, key := range testKeys() {
+		known[key] = true
+	}
+	for _, key := range cfg.Tests {
+		if !known[key] {
+			return fmt.Errorf("未知的测试 %q，可选: %s", key, strings.Join(testKeys(), ","))
+		}
+	}
+	return nil
+}
+
+// testKeys 返回可通过 -tests 选择的测试名称
+func testKeys() []string {
+	cases := (&ProductionInferenceTestSuite{}).testCases()
+	keys := make([]string, len(cases))
+	for i, c := range cases {
+		keys[i] = c.key
+	}
+	return keys
+}
+
+// splitList 按逗号拆分并去除空白项
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envMap(values map[string]string) func(string) string {
+	return func(name string) string { return values[name] }
+}
+
+func TestParseTestConfigDefaults(t *testing.T) {
+	cfg, err := parseTestConfig(nil, envMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, defaultTestConfig(), cfg)
+}
+
+func TestParseTestConfigFlagsOverrideEnv(t *testing.T) {
+	cfg, err := parseTestConfig(
+		[]string{"-users", "50", "-tests", "single, concurrent"},
+		envMap(map[string]string{
+			"LOADTEST_BASE_URL":       "http://staging:8080",
+			"LOADTEST_USERS":          "20",
+			"LOADTEST_MODELS":         "a,b",
+			"LOADTEST_DURATION":       "30s",
+			"LOADTEST_MAX_ERROR_RATE": "0.01",
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://staging:8080", cfg.BaseURL)
+	assert.Equal(t, 50, cfg.ConcurrentUsers)
+	assert.Equal(t, []string{"a", "b"}, cfg.ModelNames)
+	assert.Equal(t, 30*time.Second, cfg.TestDuration)
+	assert.Equal(t, 0.01, cfg.PerformanceTarget.MaxErrorRate)
+	assert.Equal(t, []string{"single", "concurrent"}, cfg.Tests)
+
+	suite := NewProductionInferenceTestSuite(cfg)
+	var names []string
+	for _, c := range suite.selectedTests() {
+		names = append(names, c.name)
+	}
+	assert.Equal(t, []string{"单次推理测试", "并发推理测试"}, names)
+}
+
+func TestParseTestConfigRejectsInvalidValues(t *testing.T) {
+	_, err := parseTestConfig([]string{"-tests", "unknown"}, envMap(nil))
+	assert.ErrorContains(t, err, "unknown")
+
+	_, err = parseTestConfig(nil, envMap(map[string]string{"LOADTEST_USERS": "many"}))
+	assert.ErrorContains(t, err, "LOADTEST_USERS")
+
+	_, err = parseTestConfig([]string{"-batch-size", "0"}, envMap(nil))
+	assert.Error(t, err)
+}
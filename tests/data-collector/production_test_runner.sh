@@ -220,16 +220,16 @@ run_performance_tests() {
     # 创建性能测试请求
     local test_payload='{
         "source": {
-            "type": "web",
-            "url": "https://www.zhihu.com/topic/19551137/hot"
+            "type": "WEB_CRAWLER",
+            "url": "https://www.zhihu.com/topic/19551137/hot",
+            "parameters": {
+                "selectors": ".CommentContent"
+            }
         },
         "config": {
-            "max_texts": 50,
-            "timeout": 60,
-            "concurrent": 3,
-            "selectors": {
-                "comment": ".CommentContent"
-            }
+            "max_count": 50,
+            "concurrent_limit": 3,
+            "rate_limit": 5
         }
     }'
     